@@ -7,6 +7,7 @@
 package addrmgr
 
 import (
+	"bytes"
 	"container/list"
 	crand "crypto/rand" // for seeding
 	"encoding/base32"
@@ -26,6 +27,7 @@ import (
 
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcd/wire"
+	"golang.org/x/crypto/sha3"
 )
 
 // AddrManager provides a concurrency safe address manager for caching potential
@@ -57,6 +59,12 @@ type serializedKnownAddress struct {
 	LastAttempt int64
 	LastSuccess int64
 	// no refcount or tried, that is available from context.
+
+	// The networks of the addresses are only stored for the networks
+	// which can't be told apart by the address strings.  They were added
+	// in version 2.
+	Network    wire.NetAddressType `json:",omitempty"`
+	SrcNetwork wire.NetAddressType `json:",omitempty"`
 }
 
 type serializedAddrManager struct {
@@ -156,9 +164,25 @@ const (
 	getAddrPercent = 23
 
 	// serialisationVersion is the current version of the on-disk format.
-	serialisationVersion = 1
+	// Version 2 added the networks of the addresses, so version 1 files
+	// are still read and migrated on the next write.
+	serialisationVersion = 2
+
+	// torV3Version is the version byte of Tor v3 onion addresses.
+	torV3Version = 0x03
+
+	// torV3HostLen is the length of the host of a Tor v3 onion address,
+	// which is 56 char base32 + ".onion".
+	torV3HostLen = 62
+
+	// i2pHostLen is the length of the host of an I2P address, which is 52
+	// char unpadded base32 + ".b32.i2p".
+	i2pHostLen = 60
 )
 
+// i2pEncoding is the base32 encoding used by I2P addresses.
+var i2pEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 // updateAddress is a helper function to either update an address already known
 // to the address manager, or to add the address if not already known.
 func (a *AddrManager) updateAddress(netAddr, srcAddr *wire.NetAddress) {
@@ -377,6 +401,8 @@ func (a *AddrManager) savePeers() {
 		ska.Addr = k
 		ska.TimeStamp = v.na.Timestamp.Unix()
 		ska.Src = NetAddressKey(v.srcAddr)
+		ska.Network = serializedNetwork(v.na)
+		ska.SrcNetwork = serializedNetwork(v.srcAddr)
 		ska.Attempts = v.attempts
 		ska.LastAttempt = v.lastattempt.Unix()
 		ska.LastSuccess = v.lastsuccess.Unix()
@@ -456,7 +482,9 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 		return fmt.Errorf("error reading %s: %v", filePath, err)
 	}
 
-	if sam.Version != serialisationVersion {
+	// Version 1 files only differ by the lack of the networks of the
+	// addresses, which are all IPv4, IPv6 or Tor v2 addresses.
+	if sam.Version < 1 || sam.Version > serialisationVersion {
 		return fmt.Errorf("unknown version %v in serialized "+
 			"addrmanager", sam.Version)
 	}
@@ -464,12 +492,12 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 
 	for _, v := range sam.Addresses {
 		ka := new(KnownAddress)
-		ka.na, err = a.DeserializeNetAddress(v.Addr)
+		ka.na, err = a.deserializeNetAddress(v.Addr, v.Network)
 		if err != nil {
 			return fmt.Errorf("failed to deserialize netaddress "+
 				"%s: %v", v.Addr, err)
 		}
-		ka.srcAddr, err = a.DeserializeNetAddress(v.Src)
+		ka.srcAddr, err = a.deserializeNetAddress(v.Src, v.SrcNetwork)
 		if err != nil {
 			return fmt.Errorf("failed to deserialize netaddress "+
 				"%s: %v", v.Src, err)
@@ -527,6 +555,13 @@ func (a *AddrManager) deserializePeers(filePath string) error {
 
 // DeserializeNetAddress converts a given address string to a *wire.NetAddress
 func (a *AddrManager) DeserializeNetAddress(addr string) (*wire.NetAddress, error) {
+	return a.deserializeNetAddress(addr, 0)
+}
+
+// deserializeNetAddress converts a given address string of the given network
+// to a *wire.NetAddress.  The network is only needed for CJDNS addresses which
+// look like IPv6 addresses and is ignored for all other networks.
+func (a *AddrManager) deserializeNetAddress(addr string, network wire.NetAddressType) (*wire.NetAddress, error) {
 	host, portStr, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
@@ -536,9 +571,27 @@ func (a *AddrManager) DeserializeNetAddress(addr string) (*wire.NetAddress, erro
 		return nil, err
 	}
 
+	if network == wire.CJDNSAddress {
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid cjdns address %s", host)
+		}
+		return wire.NewNetAddressType(wire.CJDNSAddress, ip.To16(),
+			uint16(port), wire.SFNodeNetwork)
+	}
 	return a.HostToNetAddress(host, uint16(port), wire.SFNodeNetwork)
 }
 
+// serializedNetwork returns the network of the passed address to store in the
+// peers file, which is only non-zero for networks which can't be told apart
+// by the address strings.
+func serializedNetwork(na *wire.NetAddress) wire.NetAddressType {
+	if IsCJDNS(na) {
+		return wire.CJDNSAddress
+	}
+	return 0
+}
+
 // Start begins the core address handler which manages a pool of known
 // addresses, timeouts, and interval based writes.
 func (a *AddrManager) Start() {
@@ -707,10 +760,51 @@ func (a *AddrManager) reset() {
 	}
 }
 
+// torV3Checksum returns the checksum of a Tor v3 onion address for the passed
+// ed25519 public key.
+func torV3Checksum(pubKey []byte) []byte {
+	h := sha3.New256()
+	h.Write([]byte(".onion checksum"))
+	h.Write(pubKey)
+	h.Write([]byte{torV3Version})
+	return h.Sum(nil)[:2]
+}
+
 // HostToNetAddress returns a netaddress given a host address. If the address is
-// a tor .onion address this will be taken care of. else if the host is not an
-// IP address it will be resolved (via tor if required).
+// a tor .onion or an I2P .b32.i2p address this will be taken care of. else if
+// the host is not an IP address it will be resolved (via tor if required).
 func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.ServiceFlag) (*wire.NetAddress, error) {
+	// tor v3 address is 56 char base32 + ".onion" which decodes to the
+	// public key, the checksum and the version.
+	if len(host) == torV3HostLen && strings.HasSuffix(host, ".onion") {
+		data, err := base32.StdEncoding.DecodeString(
+			strings.ToUpper(host[:torV3HostLen-6]))
+		if err != nil {
+			return nil, err
+		}
+		pubKey, checksum := data[:32], data[32:34]
+		if data[34] != torV3Version {
+			return nil, fmt.Errorf("unsupported onion address "+
+				"version %d", data[34])
+		}
+		if !bytes.Equal(checksum, torV3Checksum(pubKey)) {
+			return nil, fmt.Errorf("invalid onion address checksum")
+		}
+		return wire.NewNetAddressType(wire.TorV3Address, pubKey, port,
+			services)
+	}
+
+	// i2p address is 52 char unpadded base32 + ".b32.i2p".
+	if len(host) == i2pHostLen && strings.HasSuffix(host, ".b32.i2p") {
+		data, err := i2pEncoding.DecodeString(
+			strings.ToUpper(host[:i2pHostLen-8]))
+		if err != nil {
+			return nil, err
+		}
+		return wire.NewNetAddressType(wire.I2PAddress, data, port,
+			services)
+	}
+
 	// tor address is 16 char base32 + ".onion"
 	var ip net.IP
 	if len(host) == 22 && host[16:] == ".onion" {
@@ -740,7 +834,9 @@ func (a *AddrManager) HostToNetAddress(host string, port uint16, services wire.S
 
 // ipString returns a string for the ip from the provided NetAddress. If the
 // ip is in the range used for tor addresses then it will be transformed into
-// the relevant .onion address.
+// the relevant .onion address.  Tor v3 and I2P addresses are transformed into
+// their .onion and .b32.i2p addresses and CJDNS addresses into their IPv6
+// address.
 func ipString(na *wire.NetAddress) string {
 	if IsOnionCatTor(na) {
 		// We know now that na.IP is long enogh.
@@ -748,6 +844,23 @@ func ipString(na *wire.NetAddress) string {
 		return strings.ToLower(base32) + ".onion"
 	}
 
+	switch {
+	case IsTorV3(na) && IsValid(na):
+		data := make([]byte, 0, 35)
+		data = append(data, na.Addr...)
+		data = append(data, torV3Checksum(na.Addr)...)
+		data = append(data, torV3Version)
+		base32 := base32.StdEncoding.EncodeToString(data)
+		return strings.ToLower(base32) + ".onion"
+
+	case IsI2P(na) && IsValid(na):
+		base32 := i2pEncoding.EncodeToString(na.Addr)
+		return strings.ToLower(base32) + ".b32.i2p"
+
+	case IsCJDNS(na) && IsValid(na):
+		return net.IP(na.Addr).String()
+	}
+
 	return na.IP.String()
 }
 
@@ -971,7 +1084,7 @@ func (a *AddrManager) Good(addr *wire.NetAddress) {
 // with the given priority.
 func (a *AddrManager) AddLocalAddress(na *wire.NetAddress, priority AddressPriority) error {
 	if !IsRoutable(na) {
-		return fmt.Errorf("address %s is not routable", na.IP)
+		return fmt.Errorf("address %s is not routable", ipString(na))
 	}
 
 	a.lamtx.Lock()
@@ -1009,8 +1122,8 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Unreachable
 	}
 
-	if IsOnionCatTor(remoteAddr) {
-		if IsOnionCatTor(localAddr) {
+	if IsOnionCatTor(remoteAddr) || IsTorV3(remoteAddr) {
+		if IsOnionCatTor(localAddr) || IsTorV3(localAddr) {
 			return Private
 		}
 
@@ -1021,6 +1134,14 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		return Default
 	}
 
+	if IsI2P(remoteAddr) || IsCJDNS(remoteAddr) {
+		if localAddr.Type == remoteAddr.Type {
+			return Private
+		}
+
+		return Default
+	}
+
 	if IsRFC4380(remoteAddr) {
 		if !IsRoutable(localAddr) {
 			return Default
@@ -1051,7 +1172,7 @@ func getReachabilityFrom(localAddr, remoteAddr *wire.NetAddress) int {
 		tunnelled = true
 	}
 
-	if !IsRoutable(localAddr) {
+	if !IsRoutable(localAddr) || localAddr.RequiresAddrV2() {
 		return Default
 	}
 
@@ -1090,11 +1211,10 @@ func (a *AddrManager) GetBestLocalAddress(remoteAddr *wire.NetAddress) *wire.Net
 		}
 	}
 	if bestAddress != nil {
-		log.Debugf("Suggesting address %s:%d for %s:%d", bestAddress.IP,
-			bestAddress.Port, remoteAddr.IP, remoteAddr.Port)
+		log.Debugf("Suggesting address %s for %s",
+			NetAddressKey(bestAddress), NetAddressKey(remoteAddr))
 	} else {
-		log.Debugf("No worthy address for %s:%d", remoteAddr.IP,
-			remoteAddr.Port)
+		log.Debugf("No worthy address for %s", NetAddressKey(remoteAddr))
 
 		// Send something unroutable if nothing suitable.
 		var ip net.IP
@@ -1124,6 +1244,19 @@ func New(dataDir string, lookupFunc func(string) ([]net.IP, error)) *AddrManager
 }
 
 
+// KnownServices returns the services the passed address is known to support
+// along with whether the address is known to the address manager at all.
+func (a *AddrManager) KnownServices(addr *wire.NetAddress) (wire.ServiceFlag, bool) {
+	a.mtx.Lock()
+	defer a.mtx.Unlock()
+
+	ka := a.find(addr)
+	if ka == nil {
+		return 0, false
+	}
+	return ka.NetAddress().Services, true
+}
+
 // SetServices sets the services for the giiven address to the provided value.
 func (a *AddrManager) SetServices(addr *wire.NetAddress, services wire.ServiceFlag) {
 	a.mtx.Lock()
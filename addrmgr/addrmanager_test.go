@@ -9,7 +9,10 @@ package addrmgr_test
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"net"
+	"os"
+	"path/filepath"
 	"reflect"
 	"testing"
 	"time"
@@ -256,6 +259,35 @@ func TestConnected(t *testing.T) {
 	}
 }
 
+// TestKnownServices ensures the services of known addresses are returned along
+// with their updates and unknown addresses are reported as such.
+func TestKnownServices(t *testing.T) {
+	n := addrmgr.New("testknownservices", lookupFunc)
+
+	na, err := n.DeserializeNetAddress(someIP + ":8333")
+	if err != nil {
+		t.Fatalf("DeserializeNetAddress failed: %v", err)
+	}
+	if _, ok := n.KnownServices(na); ok {
+		t.Fatalf("KnownServices reported an unknown address as known")
+	}
+
+	srcAddr := wire.NewNetAddressIPPort(net.IPv4(173, 144, 173, 111), 8333, 0)
+	n.AddAddress(na, srcAddr)
+	services, ok := n.KnownServices(na)
+	if !ok || services != wire.SFNodeNetwork {
+		t.Fatalf("KnownServices: got %v, %v, want %v, true", services,
+			ok, wire.SFNodeNetwork)
+	}
+
+	n.SetServices(na, wire.SFNodeNetwork|wire.SFNodeP2PV2)
+	services, ok = n.KnownServices(na)
+	if !ok || services != wire.SFNodeNetwork|wire.SFNodeP2PV2 {
+		t.Fatalf("KnownServices: got %v, %v, want %v, true", services,
+			ok, wire.SFNodeNetwork|wire.SFNodeP2PV2)
+	}
+}
+
 func TestNeedMoreAddresses(t *testing.T) {
 	n := addrmgr.New("testneedmoreaddresses", lookupFunc)
 	addrsToAdd := 1500
@@ -482,7 +514,7 @@ func TestCorruptPeersFile(t *testing.T) {
 		t.Fatal(err)
 	}
 	defer os.RemoveAll(dir)
-	peersFile := filepath.Join(dir, PeersFilename)
+	peersFile := filepath.Join(dir, "peers.json")
 	// create corrupt (empty) peers file
 	fp, err := os.Create(peersFile)
 	if err != nil {
@@ -491,7 +523,7 @@ func TestCorruptPeersFile(t *testing.T) {
 	if err := fp.Close(); err != nil {
 		t.Fatalf("Could not write empty peers file: %s", peersFile)
 	}
-	amgr := New(dir, nil)
+	amgr := addrmgr.New(dir, nil)
 	amgr.Start()
 	amgr.Stop()
 	if _, err := os.Stat(peersFile); err != nil {
@@ -0,0 +1,164 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package addrmgr_test
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/HcashOrg/hcd/addrmgr"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestAddrV2Networks ensures Tor v3, I2P and CJDNS addresses are parsed,
+// keyed and grouped as intended.
+func TestAddrV2Networks(t *testing.T) {
+	n := addrmgr.New("testaddrv2networks", lookupFunc)
+
+	tests := []struct {
+		name     string
+		host     string
+		network  wire.NetAddressType
+		key      string
+		group    string
+		routable bool
+	}{
+		{
+			name:     "tor v3",
+			host:     "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion",
+			network:  wire.TorV3Address,
+			key:      "duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion:8333",
+			group:    "tor:13",
+			routable: true,
+		},
+		{
+			name:     "i2p",
+			host:     "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p",
+			network:  wire.I2PAddress,
+			key:      "ukeu3k5oycgaauneqgtnvselmt4yemvoilkln7jpvamvfx7dnkdq.b32.i2p:8333",
+			group:    "i2p:2",
+			routable: true,
+		},
+	}
+
+	for _, test := range tests {
+		na, err := n.HostToNetAddress(test.host, 8333, wire.SFNodeNetwork)
+		if err != nil {
+			t.Errorf("%s: HostToNetAddress: unexpected error %v",
+				test.name, err)
+			continue
+		}
+		if na.NetworkType() != test.network {
+			t.Errorf("%s: unexpected network - got %v, want %v",
+				test.name, na.NetworkType(), test.network)
+		}
+		if key := addrmgr.NetAddressKey(na); key != test.key {
+			t.Errorf("%s: unexpected key - got %s, want %s",
+				test.name, key, test.key)
+		}
+		if group := addrmgr.GroupKey(na); group != test.group {
+			t.Errorf("%s: unexpected group key - got %s, want %s",
+				test.name, group, test.group)
+		}
+		if addrmgr.IsRoutable(na) != test.routable {
+			t.Errorf("%s: unexpected routable - got %v, want %v",
+				test.name, addrmgr.IsRoutable(na), test.routable)
+		}
+	}
+
+	// Ensure onion addresses with an invalid checksum are rejected.
+	_, err := n.HostToNetAddress("duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczaa.onion",
+		8333, wire.SFNodeNetwork)
+	if err == nil {
+		t.Error("HostToNetAddress: did not reject invalid onion checksum")
+	}
+
+	// Ensure CJDNS addresses are keyed by their IPv6 address and only
+	// addresses in fc00::/8 are valid.
+	cjdns, err := wire.NewNetAddressType(wire.CJDNSAddress,
+		net.ParseIP("fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa"), 8333,
+		wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddressType: unexpected error %v", err)
+	}
+	if key := addrmgr.NetAddressKey(cjdns); key != "[fc32:17ea:e415:c3bf:9808:149d:b5a2:c9aa]:8333" {
+		t.Errorf("cjdns: unexpected key %s", key)
+	}
+	if group := addrmgr.GroupKey(cjdns); group != "cjdns:2" {
+		t.Errorf("cjdns: unexpected group key %s", group)
+	}
+	cjdns.Addr = net.ParseIP("fd00::1")
+	if addrmgr.IsValid(cjdns) {
+		t.Error("cjdns: address outside fc00::/8 is valid")
+	}
+}
+
+// TestPeersFileMigration ensures version 1 peers files are still loaded and
+// addresses of all networks survive a round trip through the peers file.
+func TestPeersFileMigration(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testpeersfilemigration")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	v1File := []byte(`{"Version":1,"Addresses":[{"Addr":"173.194.115.66:8333",` +
+		`"Src":"173.194.115.66:8333","Attempts":0,"TimeStamp":0,` +
+		`"LastAttempt":0,"LastSuccess":0}],` +
+		`"NewBuckets":[["173.194.115.66:8333"]],"TriedBuckets":[]}`)
+	peersFile := filepath.Join(dir, "peers.json")
+	if err := ioutil.WriteFile(peersFile, v1File, 0644); err != nil {
+		t.Fatalf("unable to write peers file: %v", err)
+	}
+
+	amgr := addrmgr.New(dir, lookupFunc)
+	amgr.Start()
+	if amgr.NumAddresses() != 1 {
+		t.Fatalf("version 1 peers file: got %d addresses, want 1",
+			amgr.NumAddresses())
+	}
+
+	// Add addresses of the new networks and write them to the peers file.
+	src := wire.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 8333,
+		wire.SFNodeNetwork)
+	torV3, err := wire.NewNetAddressType(wire.TorV3Address,
+		bytes.Repeat([]byte{0x01}, 32), 8333, wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddressType: unexpected error %v", err)
+	}
+	cjdns, err := wire.NewNetAddressType(wire.CJDNSAddress,
+		net.ParseIP("fc00::1"), 8333, wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddressType: unexpected error %v", err)
+	}
+	amgr.AddAddresses([]*wire.NetAddress{torV3, cjdns}, src)
+	if err := amgr.Stop(); err != nil {
+		t.Fatalf("Stop: unexpected error %v", err)
+	}
+
+	amgr = addrmgr.New(dir, lookupFunc)
+	amgr.Start()
+	defer amgr.Stop()
+	if amgr.NumAddresses() != 3 {
+		t.Fatalf("version 2 peers file: got %d addresses, want 3",
+			amgr.NumAddresses())
+	}
+	networks := make(map[wire.NetAddressType]bool)
+	for _, na := range addrmgr.TstAddresses(amgr) {
+		networks[na.NetworkType()] = true
+	}
+	for _, network := range []wire.NetAddressType{wire.IPv4Address,
+		wire.TorV3Address, wire.CJDNSAddress} {
+
+		if !networks[network] {
+			t.Errorf("no %v address after reloading peers file",
+				network)
+		}
+	}
+}
@@ -25,3 +25,7 @@ func TstNewKnownAddress(na *wire.NetAddress, attempts int,
 	return &KnownAddress{na: na, attempts: attempts, lastattempt: lastattempt,
 		lastsuccess: lastsuccess, tried: tried, refs: refs}
 }
+
+func TstAddresses(a *AddrManager) []*wire.NetAddress {
+	return a.getAddresses()
+}
@@ -119,6 +119,21 @@ func IsOnionCatTor(na *wire.NetAddress) bool {
 	return onionCatNet.Contains(na.IP)
 }
 
+// IsTorV3 returns whether or not the passed address is a Tor v3 onion address.
+func IsTorV3(na *wire.NetAddress) bool {
+	return na.Type == wire.TorV3Address
+}
+
+// IsI2P returns whether or not the passed address is an I2P address.
+func IsI2P(na *wire.NetAddress) bool {
+	return na.Type == wire.I2PAddress
+}
+
+// IsCJDNS returns whether or not the passed address is a CJDNS address.
+func IsCJDNS(na *wire.NetAddress) bool {
+	return na.Type == wire.CJDNSAddress
+}
+
 // IsRFC1918 returns whether or not the passed address is part of the IPv4
 // private network address space as defined by RFC1918 (10.0.0.0/8,
 // 172.16.0.0/12, or 192.168.0.0/16).
@@ -214,7 +229,20 @@ func IsRFC6598(na *wire.NetAddress) bool {
 // considered invalid under the following circumstances:
 // IPv4: It is either a zero or all bits set address.
 // IPv6: It is either a zero or RFC3849 documentation address.
+// Tor v3, I2P and CJDNS: The raw address has the wrong length, or for CJDNS,
+// is not in fc00::/8.
+// Addresses of all other networks are invalid.
 func IsValid(na *wire.NetAddress) bool {
+	switch na.Type {
+	case 0:
+	case wire.TorV3Address, wire.I2PAddress:
+		return len(na.Addr) == 32
+	case wire.CJDNSAddress:
+		return len(na.Addr) == net.IPv6len && na.Addr[0] == 0xfc
+	default:
+		return false
+	}
+
 	// IsUnspecified returns if address is 0, so only all bits set, and
 	// RFC3849 need to be explicitly checked.
 	return na.IP != nil && !(na.IP.IsUnspecified() ||
@@ -223,8 +251,12 @@ func IsValid(na *wire.NetAddress) bool {
 
 // IsRoutable returns whether or not the passed address is routable over
 // the public internet.  This is true as long as the address is valid and is not
-// in any reserved ranges.
+// in any reserved ranges.  Valid Tor v3, I2P and CJDNS addresses are always
+// routable over their respective networks.
 func IsRoutable(na *wire.NetAddress) bool {
+	if na.Type != 0 {
+		return IsValid(na)
+	}
 	return IsValid(na) && !(IsRFC1918(na) || IsRFC2544(na) ||
 		IsRFC3927(na) || IsRFC4862(na) || IsRFC3849(na) ||
 		IsRFC4843(na) || IsRFC5737(na) || IsRFC6598(na) ||
@@ -234,8 +266,9 @@ func IsRoutable(na *wire.NetAddress) bool {
 // GroupKey returns a string representing the network group an address is part
 // of.  This is the /16 for IPv4, the /32 (/36 for he.net) for IPv6, the string
 // "local" for a local address, the string "tor:key" where key is the /4 of the
-// onion address for tor address, and the string "unroutable" for an unroutable
-// address.
+// onion address for tor address, the strings "i2p:key" and "cjdns:key" where
+// key is the /4 of the raw address for I2P and CJDNS addresses, and the string
+// "unroutable" for an unroutable address.
 func GroupKey(na *wire.NetAddress) string {
 	if IsLocal(na) {
 		return "local"
@@ -243,6 +276,17 @@ func GroupKey(na *wire.NetAddress) string {
 	if !IsRoutable(na) {
 		return "unroutable"
 	}
+	switch na.Type {
+	case wire.TorV3Address:
+		// group is keyed off the first 4 bits of the onion key just
+		// like tor v2 addresses.
+		return fmt.Sprintf("tor:%d", na.Addr[0]&((1<<4)-1))
+	case wire.I2PAddress:
+		return fmt.Sprintf("i2p:%d", na.Addr[0]&((1<<4)-1))
+	case wire.CJDNSAddress:
+		// all cjdns addresses share the first byte.
+		return fmt.Sprintf("cjdns:%d", na.Addr[1]&((1<<4)-1))
+	}
 	if IsIPv4(na) {
 		return na.IP.Mask(net.CIDRMask(16, 32)).String()
 	}
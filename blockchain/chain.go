@@ -212,6 +212,10 @@ type BlockChain struct {
 	// values.
 	subsidyCache *SubsidyCache
 
+	// utxoCache is the cache of the utxo set which serves all of the utxo
+	// lookups and batches the writes of the utxo set to the database.
+	utxoCache *utxoCache
+
 	// chainLock protects concurrent access to the vast majority of the
 	// fields in this struct below this point.
 	chainLock sync.RWMutex
@@ -276,6 +280,16 @@ type BlockChain struct {
 	// it is unlikely to be referenced in the future.
 	pruner *chainPruner
 
+	// pruneTarget is the target size in bytes for the stored block data
+	// when block data pruning is enabled.  It is zero when pruning is
+	// disabled.
+	//
+	// pruneHeight is the height of the most recent main chain block whose
+	// data has been pruned, or -1 when no block data has been pruned.  It
+	// is protected by the chain lock.
+	pruneTarget uint64
+	pruneHeight int64
+
 	// The following maps are various caches for the stake version/voting
 	// system.  The goal of these is to reduce disk access to load blocks
 	// from disk.  Measurements indicate that it is slightly more expensive
@@ -656,16 +670,27 @@ func (b *BlockChain) GetGeneration(hash chainhash.Hash) ([]chainhash.Hash, error
 // This function MUST be called with the chain state lock held (for writes).
 // The database transaction may be read-only.
 func (b *BlockChain) loadBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*blockNode, error) {
+	var node *blockNode
 	block, err := dbFetchBlockByHash(dbTx, hash)
-	if err != nil {
+	switch {
+	case isDbBlockPrunedErr(err):
+		// The block data has been pruned, so create the node from the
+		// header and the votes retained when the block was pruned.
+		node, err = dbFetchPrunedBlockNode(dbTx, hash)
+		if err != nil {
+			return nil, err
+		}
+
+	case err != nil:
 		return nil, err
-	}
 
-	blockHeader := block.MsgBlock().Header
-	node := newBlockNode(&blockHeader, ticketsSpentInBlock(block),
-		ticketsRevokedInBlock(block), voteBitsInBlock(block))
+	default:
+		blockHeader := block.MsgBlock().Header
+		node = newBlockNode(&blockHeader, ticketsSpentInBlock(block),
+			ticketsRevokedInBlock(block), voteBitsInBlock(block))
+	}
 	node.inMainChain = true
-	prevHash := &blockHeader.PrevBlock
+	prevHash := &node.header.PrevBlock
 
 	// Add the node to the chain.
 	// There are a few possibilities here:
@@ -1249,7 +1274,11 @@ func (b *BlockChain) connectBlock(node *blockNode, block *hcutil.Block, view *Ut
 		return err
 	}
 
-	// Atomically insert info into the database.
+	// Atomically insert info into the database.  The changes to the utxo
+	// set are only written along with the block when the utxo cache is
+	// flushed.  Otherwise, they are applied to the cache below.
+	flushUtxos := b.utxoCache.needsFlush()
+	pruneHeight := b.pruneHeight
 	err = b.db.Update(func(dbTx database.Tx) error {
 		// Update best block state.
 		err := dbPutBestState(dbTx, state, node.workSum)
@@ -1264,12 +1293,15 @@ func (b *BlockChain) connectBlock(node *blockNode, block *hcutil.Block, view *Ut
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
-		// entails removing all of the utxos spent and adding the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
-		if err != nil {
-			return err
+		// Update the utxo set using the state of the utxo view when the
+		// cache is flushed.  This entails removing all of the utxos
+		// spent and adding the new ones created by the block.
+		if flushUtxos {
+			err = b.utxoCache.dbFlush(dbTx, view, &node.hash,
+				node.height)
+			if err != nil {
+				return err
+			}
 		}
 
 		// Update the transaction spend journal by adding a record for
@@ -1301,14 +1333,26 @@ func (b *BlockChain) connectBlock(node *blockNode, block *hcutil.Block, view *Ut
 			}
 		}
 
-		return nil
+		// Remove the data for the oldest blocks when pruning is enabled
+		// and the stored block data exceeds the target.
+		pruneHeight, err = b.maybePruneBlocks(dbTx, node)
+		return err
 	})
 	if err != nil {
 		return err
 	}
+	b.pruneHeight = pruneHeight
+
+	// Apply the modifications to the utxo cache unless they were already
+	// written to the database.
+	if flushUtxos {
+		b.utxoCache.flushed(view, &node.hash, node.height)
+	} else {
+		b.utxoCache.commit(view)
+	}
 
 	// Prune fully spent entries and mark all entries in the view unmodified
-	// now that the modifications have been committed to the database.
+	// now that the modifications have been committed.
 	view.commit()
 
 	// Add the new node to the memory main chain indices for faster
@@ -1468,10 +1512,14 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *hcutil.Block, view
 			return err
 		}
 
-		// Update the utxo set using the state of the utxo view.  This
+		// Update the utxo set using the state of the utxo view along
+		// with all of the modified entries of the utxo cache.  This
 		// entails restoring all of the utxos spent and removing the new
-		// ones created by the block.
-		err = dbPutUtxoView(dbTx, view)
+		// ones created by the block.  The cache is always flushed here
+		// since the spend journal entry needed to bring the utxo set up
+		// to date on start up is removed.
+		err = b.utxoCache.dbFlush(dbTx, view, &prevNode.hash,
+			prevNode.height)
 		if err != nil {
 			return err
 		}
@@ -1504,6 +1552,7 @@ func (b *BlockChain) disconnectBlock(node *blockNode, block *hcutil.Block, view
 	if err != nil {
 		return err
 	}
+	b.utxoCache.flushed(view, &prevNode.hash, prevNode.height)
 
 	// Prune fully spent entries and mark all entries in the view unmodified
 	// now that the modifications have been committed to the database.
@@ -1697,7 +1746,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 		// thus will not be generated.  This is done because the state
 		// is not being immediately written to the database, so it is
 		// not needed.
-		err := b.checkConnectBlock(n, block, view, nil)
+		err := b.checkConnectBlock(n, block, view, nil, BFNone)
 		if err != nil {
 			return err
 		}
@@ -1745,7 +1794,7 @@ func (b *BlockChain) reorganizeChain(detachNodes, attachNodes *list.List,
 
 		// Load all of the utxos referenced by the block that aren't
 		// already in the view.
-		err = view.fetchInputUtxos(b.db, block, parent)
+		err = view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -1893,7 +1942,8 @@ func (b *BlockChain) forceHeadReorganization(formerBest chainhash.Hash, newBest
 		return err
 	}
 
-	err = b.checkConnectBlock(newBestNode, newBestBlock, view, nil)
+	err = b.checkConnectBlock(newBestNode, newBestBlock, view, nil,
+		BFNone)
 	if err != nil {
 		return err
 	}
@@ -1933,6 +1983,8 @@ func (b *BlockChain) ForceHeadReorganization(formerBest chainhash.Hash, newBest
 //  - BFDryRun: Prevents the block from being connected and avoids modifying the
 //    state of the memory chain index.  Also, any log messages related to
 //    modifying the state are avoided.
+//  - BFAssumeValid: Skips the script validation when the block extends the
+//    main chain.
 //
 // This function MUST be called with the chain state lock held (for writes).
 func (b *BlockChain) connectBestChain(node *blockNode, block *hcutil.Block, flags BehaviorFlags) (bool, error) {
@@ -1957,7 +2009,8 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *hcutil.Block, flag
 		view.SetStakeViewpoint(ViewpointPrevValidInitial)
 		var stxos []spentTxOut
 		if !fastAdd {
-			err := b.checkConnectBlock(node, block, view, &stxos)
+			err := b.checkConnectBlock(node, block, view, &stxos,
+				flags)
 			if err != nil {
 				return false, err
 			}
@@ -1973,7 +2026,7 @@ func (b *BlockChain) connectBestChain(node *blockNode, block *hcutil.Block, flag
 		// utxos, spend them, and add the new utxos being created by
 		// this block.
 		if fastAdd {
-			err := view.fetchInputUtxos(b.db, block, parent)
+			err := view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return false, err
 			}
@@ -2275,6 +2328,23 @@ type Config struct {
 	// This field can be nil if the caller does not wish to make use of an
 	// index manager.
 	IndexManager IndexManager
+
+	// PruneTarget is the target size in bytes for the block data stored in
+	// the database.  When it is non-zero, the data for the oldest blocks is
+	// removed as needed to keep the stored block data at or below the
+	// target, while the data for recent blocks is always retained in order
+	// to support reorganizations.
+	//
+	// This field can be zero to keep the data for all blocks.
+	PruneTarget uint64
+
+	// UtxoCacheMaxSize is the approximate maximum number of bytes of memory
+	// used by the cache of the utxo set.  The modified entries of the cache
+	// are written to the database in a batch once it is exceeded.
+	//
+	// This field can be zero to write the changes to the utxo set to the
+	// database along with every block.
+	UtxoCacheMaxSize uint64
 }
 
 // New returns a BlockChain instance using the provided configuration details.
@@ -2315,6 +2385,9 @@ func New(config *Config) (*BlockChain, error) {
 		mainchainBlockCache:           make(map[chainhash.Hash]*hcutil.Block),
 		mainchainBlockCacheSize:       mainchainBlockCacheSize,
 		deploymentCaches:              newThresholdCaches(params),
+		pruneTarget:                   config.PruneTarget,
+		pruneHeight:                   -1,
+		utxoCache:                     newUtxoCache(config.DB, config.UtxoCacheMaxSize),
 		isVoterMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		isStakeMajorityVersionCache:   make(map[[stakeMajorityCacheKeySize]byte]bool),
 		calcPriorStakeVersionCache:    make(map[[chainhash.HashSize]byte]uint32),
@@ -2329,6 +2402,12 @@ func New(config *Config) (*BlockChain, error) {
 		return nil, err
 	}
 
+	// Bring the utxo set up to date with the chain state in case it was
+	// not flushed before the last shutdown.
+	if err := b.initUtxoCache(); err != nil {
+		return nil, err
+	}
+
 	// Initialize and catch up all of the currently active optional indexes
 	// as needed.
 	if config.IndexManager != nil {
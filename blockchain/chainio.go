@@ -96,6 +96,13 @@ func isDbBucketNotFoundErr(err error) bool {
 	return ok && dbErr.ErrorCode == database.ErrBucketNotFound
 }
 
+// isDbBlockPrunedErr returns whether or not the passed error is a
+// database.Error with an error code of database.ErrBlockPruned.
+func isDbBlockPrunedErr(err error) bool {
+	dbErr, ok := err.(database.Error)
+	return ok && dbErr.ErrorCode == database.ErrBlockPruned
+}
+
 // -----------------------------------------------------------------------------
 // The staking system requires some extra information to be stored for tickets
 // to maintain consensus rules. The full set of minimal outputs are thus required
@@ -991,6 +998,82 @@ func dbPutUtxoView(dbTx database.Tx, view *UtxoViewpoint) error {
 	return nil
 }
 
+// -----------------------------------------------------------------------------
+// The utxo set state identifies the block up to which the utxo set stored in
+// the database is consistent.  Since the utxo cache only writes its changes to
+// the database in batches, the utxo set may lag behind the best chain state
+// and is brought up to date on start up by connecting the transactions of the
+// main chain blocks after it.
+//
+// The serialized format is:
+//
+//   <block hash><block height>
+//
+//   Field             Type             Size
+//   block hash        chainhash.Hash   chainhash.HashSize
+//   block height      uint32           4 bytes
+// -----------------------------------------------------------------------------
+
+// utxoSetState represents the data stored in the database for the utxo set
+// state.
+type utxoSetState struct {
+	hash   chainhash.Hash
+	height uint32
+}
+
+// serializeUtxoSetState returns the serialization of the passed utxo set
+// state.
+func serializeUtxoSetState(state utxoSetState) []byte {
+	serialized := make([]byte, chainhash.HashSize+4)
+	copy(serialized[0:chainhash.HashSize], state.hash[:])
+	dbnamespace.ByteOrder.PutUint32(serialized[chainhash.HashSize:],
+		state.height)
+	return serialized
+}
+
+// deserializeUtxoSetState deserializes the passed serialized utxo set state.
+func deserializeUtxoSetState(serialized []byte) (utxoSetState, error) {
+	if len(serialized) != chainhash.HashSize+4 {
+		return utxoSetState{}, database.Error{
+			ErrorCode: database.ErrCorruption,
+			Description: fmt.Sprintf("corrupt utxo set state size; "+
+				"want %v got %v", chainhash.HashSize+4,
+				len(serialized)),
+		}
+	}
+
+	var state utxoSetState
+	copy(state.hash[:], serialized[0:chainhash.HashSize])
+	state.height = dbnamespace.ByteOrder.Uint32(
+		serialized[chainhash.HashSize:])
+	return state, nil
+}
+
+// dbPutUtxoSetState uses an existing database transaction to store the block up
+// to which the utxo set in the database is consistent.
+func dbPutUtxoSetState(dbTx database.Tx, hash *chainhash.Hash, height int64) error {
+	serialized := serializeUtxoSetState(utxoSetState{
+		hash:   *hash,
+		height: uint32(height),
+	})
+	return dbTx.Metadata().Put(dbnamespace.UtxoSetStateKeyName, serialized)
+}
+
+// dbFetchUtxoSetState uses an existing database transaction to retrieve the
+// block up to which the utxo set in the database is consistent.  Nil is
+// returned for the state when it has not been stored yet.
+func dbFetchUtxoSetState(dbTx database.Tx) (*utxoSetState, error) {
+	serialized := dbTx.Metadata().Get(dbnamespace.UtxoSetStateKeyName)
+	if serialized == nil {
+		return nil, nil
+	}
+	state, err := deserializeUtxoSetState(serialized)
+	if err != nil {
+		return nil, err
+	}
+	return &state, nil
+}
+
 // -----------------------------------------------------------------------------
 // The block index consists of two buckets with an entry for every block in the
 // main chain.  One bucket is for the hash to height mapping and the other is
@@ -1080,6 +1163,125 @@ func dbFetchHashByHeight(dbTx database.Tx, height int64) (*chainhash.Hash, error
 	return &hash, nil
 }
 
+// -----------------------------------------------------------------------------
+// When the data for a block is pruned, the votes it contains are retained in
+// the pruned votes bucket so the block node can still be reconstructed from
+// the header for the purposes of the stake version and rule change vote
+// tallies.  The height of the most recent pruned block is also stored.
+//
+// The serialized format for keys and values in the pruned votes bucket is:
+//   <hash> = <num votes><votes>
+//
+//   Field      Type             Size
+//   hash       chainhash.Hash   chainhash.HashSize
+//   num votes  uint16           2 bytes
+//   votes      []vote           6 bytes * num votes
+//
+// Each vote is serialized as:
+//   <version><bits>
+//
+//   Field      Type     Size
+//   version    uint32   4 bytes
+//   bits       uint16   2 bytes
+//
+// The serialized format for the prune height value is:
+//   <height>
+//
+//   Field      Type     Size
+//   height     uint32   4 bytes
+// -----------------------------------------------------------------------------
+
+// serializePrunedVotes returns the serialization of the passed votes.
+func serializePrunedVotes(votes []VoteVersionTuple) []byte {
+	serialized := make([]byte, 2+len(votes)*6)
+	dbnamespace.ByteOrder.PutUint16(serialized[0:2], uint16(len(votes)))
+	offset := 2
+	for _, vote := range votes {
+		dbnamespace.ByteOrder.PutUint32(serialized[offset:], vote.Version)
+		dbnamespace.ByteOrder.PutUint16(serialized[offset+4:], vote.Bits)
+		offset += 6
+	}
+	return serialized
+}
+
+// deserializePrunedVotes deserializes the passed serialized votes.
+func deserializePrunedVotes(serialized []byte) ([]VoteVersionTuple, error) {
+	if len(serialized) < 2 {
+		return nil, errDeserialize("unexpected end of data while " +
+			"reading number of pruned votes")
+	}
+	numVotes := int(dbnamespace.ByteOrder.Uint16(serialized[0:2]))
+	if len(serialized) != 2+numVotes*6 {
+		return nil, errDeserialize(fmt.Sprintf("unexpected length for "+
+			"%d pruned votes: %d", numVotes, len(serialized)))
+	}
+
+	votes := make([]VoteVersionTuple, 0, numVotes)
+	for offset := 2; offset < len(serialized); offset += 6 {
+		votes = append(votes, VoteVersionTuple{
+			Version: dbnamespace.ByteOrder.Uint32(serialized[offset:]),
+			Bits:    dbnamespace.ByteOrder.Uint16(serialized[offset+4:]),
+		})
+	}
+	return votes, nil
+}
+
+// dbPutPrunedVotes uses an existing database transaction to store the votes for
+// the block with the provided hash in the pruned votes bucket.
+func dbPutPrunedVotes(dbTx database.Tx, hash *chainhash.Hash, votes []VoteVersionTuple) error {
+	bucket, err := dbTx.Metadata().CreateBucketIfNotExists(
+		dbnamespace.PrunedVotesBucketName)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(hash[:], serializePrunedVotes(votes))
+}
+
+// dbFetchPrunedBlockNode uses an existing database transaction to create a
+// block node for the pruned block with the provided hash from its header and
+// the votes that were retained when its data was pruned.
+func dbFetchPrunedBlockNode(dbTx database.Tx, hash *chainhash.Hash) (*blockNode, error) {
+	header, err := dbFetchHeaderByHash(dbTx, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var serialized []byte
+	bucket := dbTx.Metadata().Bucket(dbnamespace.PrunedVotesBucketName)
+	if bucket != nil {
+		serialized = bucket.Get(hash[:])
+	}
+	if serialized == nil {
+		return nil, AssertError(fmt.Sprintf("missing votes for pruned "+
+			"block %v", hash))
+	}
+	votes, err := deserializePrunedVotes(serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	return newBlockNode(header, nil, nil, votes), nil
+}
+
+// dbPutPruneHeight uses an existing database transaction to store the height
+// of the most recent block whose data has been pruned.
+func dbPutPruneHeight(dbTx database.Tx, height int64) error {
+	var serialized [4]byte
+	dbnamespace.ByteOrder.PutUint32(serialized[:], uint32(height))
+	return dbTx.Metadata().Put(dbnamespace.PruneHeightKeyName, serialized[:])
+}
+
+// dbFetchPruneHeight uses an existing database transaction to retrieve the
+// height of the most recent block whose data has been pruned.  A height of -1
+// is returned when no block data has been pruned.
+func dbFetchPruneHeight(dbTx database.Tx) int64 {
+	serialized := dbTx.Metadata().Get(dbnamespace.PruneHeightKeyName)
+	if len(serialized) != 4 {
+		return -1
+	}
+	return int64(dbnamespace.ByteOrder.Uint32(serialized))
+}
+
 // -----------------------------------------------------------------------------
 // The database information contains information about the version and date
 // of the blockchain database.
@@ -1380,6 +1582,13 @@ func (b *BlockChain) createChainState() error {
 			return err
 		}
 
+		// The utxo set is empty and thus consistent with the genesis
+		// block.
+		err = dbPutUtxoSetState(dbTx, &b.bestNode.hash, b.bestNode.height)
+		if err != nil {
+			return err
+		}
+
 		// Initialize the stake buckets in the database, along with
 		// the best state for the stake database.
 		b.bestNode.stakeNode, err = stake.InitDatabaseState(dbTx, b.chainParams)
@@ -1436,6 +1645,7 @@ func (b *BlockChain) initChainState() error {
 		}
 
 		b.dbInfo = dbInfo
+		b.pruneHeight = dbFetchPruneHeight(dbTx)
 
 		// Fetch the stored chain state from the database metadata.
 		// When it doesn't exist, it means the database hasn't been
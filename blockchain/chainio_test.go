@@ -1223,3 +1223,67 @@ func TestBestChainStateDeserializeErrors(t *testing.T) {
 		}
 	}
 }
+
+// TestUtxoSetStateSerialization ensures serializing and deserializing the
+// utxo set state works as expected.
+func TestUtxoSetStateSerialization(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		state      utxoSetState
+		serialized []byte
+	}{
+		{
+			name: "genesis",
+			state: utxoSetState{
+				hash:   *newHashFromStr("000000000019d6689c085ae165831e934ff763ae46a2a6c172b3f1b60a8ce26f"),
+				height: 0,
+			},
+			serialized: hexToBytes("6fe28c0ab6f1b372c1a6a246ae63f74f931e8365e15a089c68d619000000000000000000"),
+		},
+		{
+			name: "block 1",
+			state: utxoSetState{
+				hash:   *newHashFromStr("00000000839a8e6886ab5951d76f411475428afc90947ee320161bbf18eb6048"),
+				height: 1,
+			},
+			serialized: hexToBytes("4860eb18bf1b1620e37e9490fc8a427514416fd75159ab86688e9a830000000001000000"),
+		},
+	}
+
+	for i, test := range tests {
+		// Ensure the state serializes to the expected value.
+		gotBytes := serializeUtxoSetState(test.state)
+		if !bytes.Equal(gotBytes, test.serialized) {
+			t.Errorf("serializeUtxoSetState #%d (%s): mismatched "+
+				"bytes - got %x, want %x", i, test.name,
+				gotBytes, test.serialized)
+			continue
+		}
+
+		// Ensure the serialized bytes are decoded back to the expected
+		// state.
+		state, err := deserializeUtxoSetState(test.serialized)
+		if err != nil {
+			t.Errorf("deserializeUtxoSetState #%d (%s) "+
+				"unexpected error: %v", i, test.name, err)
+			continue
+		}
+		if !reflect.DeepEqual(state, test.state) {
+			t.Errorf("deserializeUtxoSetState #%d (%s) "+
+				"mismatched state - got %v, want %v", i,
+				test.name, state, test.state)
+			continue
+		}
+	}
+
+	// Ensure truncated data is detected as corruption.
+	_, err := deserializeUtxoSetState(tests[1].serialized[:35])
+	if derr, ok := err.(database.Error); !ok ||
+		derr.ErrorCode != database.ErrCorruption {
+
+		t.Errorf("deserializeUtxoSetState: unexpected error for "+
+			"truncated data: %v", err)
+	}
+}
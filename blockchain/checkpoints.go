@@ -14,6 +14,7 @@ import (
 	"github.com/HcashOrg/hcd/database"
 	"github.com/HcashOrg/hcd/hcutil"
 	"github.com/HcashOrg/hcd/txscript"
+	"github.com/HcashOrg/hcd/wire"
 )
 
 // CheckpointConfirmations is the number of blocks before the end of the current
@@ -98,6 +99,23 @@ func (b *BlockChain) verifyCheckpoint(height int64, hash *chainhash.Hash) bool {
 	return true
 }
 
+// dbFetchCheckpointBlock uses an existing database transaction to retrieve the
+// main chain checkpoint block for the provided hash.  Only the header of a
+// checkpoint block is used, so a block with just the header is returned when
+// the block data has been pruned.
+func dbFetchCheckpointBlock(dbTx database.Tx, hash *chainhash.Hash) (*hcutil.Block, error) {
+	block, err := dbFetchBlockByHash(dbTx, hash)
+	if !isDbBlockPrunedErr(err) {
+		return block, err
+	}
+
+	header, err := dbFetchHeaderByHash(dbTx, hash)
+	if err != nil {
+		return nil, err
+	}
+	return hcutil.NewBlock(&wire.MsgBlock{Header: *header}), nil
+}
+
 // findPreviousCheckpoint finds the most recent checkpoint that is already
 // available in the downloaded portion of the block chain and returns the
 // associated block.  It returns nil if a checkpoint can't be found (this should
@@ -148,7 +166,7 @@ func (b *BlockChain) findPreviousCheckpoint() (*hcutil.Block, error) {
 		// Cache the latest known checkpoint block for future lookups.
 		checkpoint := checkpoints[checkpointIndex]
 		err = b.db.View(func(dbTx database.Tx) error {
-			block, err := dbFetchBlockByHash(dbTx, checkpoint.Hash)
+			block, err := dbFetchCheckpointBlock(dbTx, checkpoint.Hash)
 			if err != nil {
 				return err
 			}
@@ -193,7 +211,7 @@ func (b *BlockChain) findPreviousCheckpoint() (*hcutil.Block, error) {
 	// has already passed the checkpoint which was verified as accurate
 	// before inserting it.
 	err := b.db.View(func(tx database.Tx) error {
-		block, err := dbFetchBlockByHash(tx, b.nextCheckpoint.Hash)
+		block, err := dbFetchCheckpointBlock(tx, b.nextCheckpoint.Hash)
 		if err != nil {
 			return err
 		}
@@ -916,3 +916,178 @@ func (b *BlockChain) EstimateNextStakeDifficulty(newTickets int64, useMaxTickets
 	b.chainLock.Unlock()
 	return estimate, err
 }
+
+// StakeDiffProjection describes the projected stake difficulty of a future
+// stake difficulty retarget interval.
+type StakeDiffProjection struct {
+	// Height is the height of the first block of the interval.
+	Height int64
+
+	// StakeDiff is the projected stake difficulty of the interval.
+	StakeDiff int64
+
+	// PoolSize is the projected number of live tickets committed to by the
+	// first block of the interval.
+	PoolSize int64
+
+	// ImmatureTickets is the projected number of immature tickets as of the
+	// block before the interval.
+	ImmatureTickets int64
+}
+
+// stakeDiffSimBlock houses the header fields of an existing or hypothetical
+// block which are used to calculate stake difficulties.
+type stakeDiffSimBlock struct {
+	poolSize   int64
+	freshStake int64
+	sbits      int64
+}
+
+// projectStakeDifficulty projects the stake difficulties of the retarget
+// intervals after the passed block node by simulating the blocks that follow
+// it, where each entry of the passed purchases is the number of tickets
+// purchased in the respective block.  Every interval which starts at or before
+// the block after the last simulated block is projected.
+//
+// The simulated pool sizes assume every block after stake validation height
+// removes the full number of tickets selected to vote from the pool and that
+// no tickets expire.  The stake difficulties are then calculated with the same
+// algorithm defined in DCP0001 which is used for the actual blocks.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) projectStakeDifficulty(curNode *blockNode, purchases []int64) ([]StakeDiffProjection, error) {
+	// Ensure the specified numbers of tickets can actually be purchased.
+	curHeight := curNode.height
+	maxTicketsPerBlock := int64(b.chainParams.MaxFreshStakePerBlock)
+	for i, numTickets := range purchases {
+		if numTickets < 0 || numTickets > maxTicketsPerBlock {
+			return nil, fmt.Errorf("unable to project the stake "+
+				"difficulty with %d tickets purchased in block %d "+
+				"since it is not between 0 and the maximum of %d",
+				numTickets, curHeight+int64(i)+1, maxTicketsPerBlock)
+		}
+	}
+
+	// Load the existing blocks the first projected interval depends on,
+	// which at most reach back one interval plus the ticket maturity.
+	intervalSize := b.chainParams.StakeDiffWindowSize
+	ticketMaturity := int64(b.chainParams.TicketMaturity)
+	firstHeight := curHeight - intervalSize - ticketMaturity
+	if firstHeight < 0 {
+		firstHeight = 0
+	}
+	numBlocks := curHeight - firstHeight + 1
+	blocks := make([]stakeDiffSimBlock, numBlocks,
+		numBlocks+int64(len(purchases))+1)
+	for node := curNode; node != nil && node.height >= firstHeight; {
+		blocks[node.height-firstHeight] = stakeDiffSimBlock{
+			poolSize:   int64(node.header.PoolSize),
+			freshStake: int64(node.header.FreshStake),
+			sbits:      node.header.SBits,
+		}
+
+		var err error
+		node, err = b.getPrevNodeFromNode(node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// sumPurchased returns the number of tickets purchased in the given
+	// number of blocks ending at the passed height.
+	sumPurchased := func(height, numToSum int64) int64 {
+		var numPurchased int64
+		for h := height; h > height-numToSum && h >= firstHeight; h-- {
+			numPurchased += blocks[h-firstHeight].freshStake
+		}
+		return numPurchased
+	}
+
+	// nextStakeDiff mirrors calcNextRequiredStakeDifficultyV2 for the
+	// block at the passed height using the existing and simulated blocks.
+	stakeDiffStartHeight := int64(b.chainParams.CoinbaseMaturity) + 1
+	nextStakeDiff := func(nextHeight int64) int64 {
+		if nextHeight < stakeDiffStartHeight {
+			return b.chainParams.MinimumStakeDiff
+		}
+		prev := &blocks[nextHeight-1-firstHeight]
+		curDiff := prev.sbits
+		if nextHeight%intervalSize != 0 {
+			return curDiff
+		}
+
+		var prevPoolSize int64
+		prevRetargetHeight := nextHeight - intervalSize - 1
+		if prevRetargetHeight >= firstHeight {
+			prevPoolSize = blocks[prevRetargetHeight-firstHeight].poolSize
+		}
+		prevImmatureTickets := sumPurchased(prevRetargetHeight,
+			ticketMaturity)
+		prevPoolSizeAll := prevPoolSize + prevImmatureTickets
+		if prevPoolSizeAll == 0 {
+			return curDiff
+		}
+
+		immatureTickets := sumPurchased(nextHeight-1, ticketMaturity)
+		curPoolSizeAll := prev.poolSize + immatureTickets
+		return calcNextStakeDiffV2(b.chainParams, nextHeight, curDiff,
+			prevPoolSizeAll, curPoolSizeAll)
+	}
+
+	// Simulate the blocks after the current one.  The pool size committed
+	// to by a block is the pool size of its parent adjusted by the tickets
+	// which matured and were selected to vote in the parent.
+	stakeValidationHeight := int64(b.chainParams.StakeValidationHeight)
+	votesPerBlock := int64(b.chainParams.TicketsPerBlock)
+	var projections []StakeDiffProjection
+	for i := 0; i <= len(purchases); i++ {
+		height := curHeight + int64(i) + 1
+		prev := &blocks[height-1-firstHeight]
+		poolSize := prev.poolSize
+		if maturedHeight := height - 1 - ticketMaturity; maturedHeight >= firstHeight {
+			poolSize += blocks[maturedHeight-firstHeight].freshStake
+		}
+		if height-1 >= stakeValidationHeight {
+			poolSize -= votesPerBlock
+		}
+		if poolSize < 0 {
+			poolSize = 0
+		}
+
+		var freshStake int64
+		if i < len(purchases) {
+			freshStake = purchases[i]
+		}
+		sbits := nextStakeDiff(height)
+		blocks = append(blocks, stakeDiffSimBlock{
+			poolSize:   poolSize,
+			freshStake: freshStake,
+			sbits:      sbits,
+		})
+
+		if height%intervalSize == 0 {
+			projections = append(projections, StakeDiffProjection{
+				Height:          height,
+				StakeDiff:       sbits,
+				PoolSize:        poolSize,
+				ImmatureTickets: sumPurchased(height-1, ticketMaturity),
+			})
+		}
+	}
+
+	return projections, nil
+}
+
+// ProjectStakeDifficulty projects the stake difficulties of the retarget
+// intervals after the end of the current best chain by simulating the blocks
+// that follow it, where each entry of the passed purchases is the number of
+// tickets purchased in the respective block.  Every interval which starts at
+// or before the block after the last simulated block is projected.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ProjectStakeDifficulty(purchases []int64) ([]StakeDiffProjection, error) {
+	b.chainLock.Lock()
+	projections, err := b.projectStakeDifficulty(b.bestNode, purchases)
+	b.chainLock.Unlock()
+	return projections, err
+}
@@ -747,3 +747,228 @@ nextTest:
 		}
 	}
 }
+
+// TestProjectStakeDiff ensures the stake difficulties projected for the
+// retarget intervals following a chain match the stake difficulties which are
+// required once the chain is actually extended with the same ticket purchases
+// as well as the estimated stake difficulty of the next retarget interval.
+func TestProjectStakeDiff(t *testing.T) {
+	t.Parallel()
+
+	// ticketInfo is used to control the tests by specifying the details
+	// about how many fake blocks to create with the specified number of
+	// tickets.  The stake difficulty of each block is the required one.
+	type ticketInfo struct {
+		numNodes   uint32
+		newTickets uint8
+	}
+
+	params := &chaincfg.MainNetParams
+	ticketMaturity := uint32(params.TicketMaturity)
+	stakeValidationHeight := params.StakeValidationHeight
+	intervalSize := params.StakeDiffWindowSize
+	maxFreshStake := params.MaxFreshStakePerBlock
+
+	tests := []struct {
+		name       string
+		ticketInfo []ticketInfo // Blocks which exist in the chain.
+		purchases  []ticketInfo // Blocks which are projected.
+	}{
+		{
+			name:       "before stake diff start height",
+			ticketInfo: []ticketInfo{{100, 0}},
+			purchases:  []ticketInfo{{187, 0}},
+		},
+		{
+			name: "first purchases, 100% demand",
+			ticketInfo: []ticketInfo{
+				{513, 0},
+				{40, maxFreshStake},
+			},
+			purchases: []ticketInfo{{22 + 288, maxFreshStake}},
+		},
+		{
+			name: "into stake validation height, 100% demand",
+			ticketInfo: []ticketInfo{
+				{513, 0},
+				{3500, maxFreshStake},
+			},
+			purchases: []ticketInfo{{18 + 288, maxFreshStake}},
+		},
+		{
+			name: "after stake validation height, waning demand",
+			ticketInfo: []ticketInfo{
+				{513, 0},
+				{3700, maxFreshStake},
+				{200, 5},
+			},
+			purchases: []ticketInfo{
+				{10, 10},
+				{288, 2},
+				{288, 0},
+			},
+		},
+		{
+			name: "after stake validation height, varying demand",
+			ticketInfo: []ticketInfo{
+				{513, 0},
+				{3700, maxFreshStake},
+			},
+			purchases: []ticketInfo{
+				{100, 0},
+				{100, maxFreshStake},
+				{100, 7},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		bc := newFakeChain(params)
+
+		// immatureTickets tracks which height the purchased tickets
+		// will mature and thus be eligible for admission to the live
+		// ticket pool.
+		immatureTickets := make(map[uint32]uint8)
+		var poolSize uint32
+
+		// retargets tracks the stake difficulty and pool size of the
+		// retarget interval blocks created by addBlocks.
+		type retarget struct {
+			stakeDiff int64
+			poolSize  int64
+		}
+		retargets := make(map[int64]retarget)
+
+		// addBlocks extends the fake chain with the specified number of
+		// blocks which purchase the passed number of tickets at the
+		// required stake difficulty.
+		addBlocks := func(info ticketInfo) error {
+			for i := uint32(0); i < info.numNodes; i++ {
+				stakeDiff, err := bc.calcNextRequiredStakeDifficultyV2(bc.bestNode)
+				if err != nil {
+					return err
+				}
+
+				// Make up a header.
+				nextHeight := bc.bestNode.header.Height + 1
+				header := &wire.BlockHeader{
+					Version:    4,
+					SBits:      stakeDiff,
+					Height:     nextHeight,
+					FreshStake: info.newTickets,
+					PoolSize:   poolSize,
+				}
+				node := newBlockNode(header, nil, nil, nil)
+				node.parent = bc.bestNode
+				if int64(nextHeight)%intervalSize == 0 {
+					retargets[int64(nextHeight)] = retarget{
+						stakeDiff: stakeDiff,
+						poolSize:  int64(poolSize),
+					}
+				}
+
+				// Update the pool size for the next header.
+				poolSize += uint32(immatureTickets[nextHeight])
+				delete(immatureTickets, nextHeight)
+				if int64(nextHeight) >= stakeValidationHeight {
+					poolSize -= uint32(params.TicketsPerBlock)
+				}
+
+				// Track maturity height for new ticket
+				// purchases.
+				maturityHeight := nextHeight + ticketMaturity
+				immatureTickets[maturityHeight] = info.newTickets
+
+				// Update the chain to use the new fake node as
+				// the new best node.
+				bc.bestNode = node
+			}
+			return nil
+		}
+
+		for _, info := range test.ticketInfo {
+			if err := addBlocks(info); err != nil {
+				t.Fatalf("%s: unexpected error creating fake chain: %v",
+					test.name, err)
+			}
+		}
+
+		// Project the stake difficulties for the purchases and estimate
+		// the stake difficulty of the next interval using the tickets
+		// which are purchased before it.
+		var purchases []int64
+		for _, info := range test.purchases {
+			for i := uint32(0); i < info.numNodes; i++ {
+				purchases = append(purchases, int64(info.newTickets))
+			}
+		}
+		curNode := bc.bestNode
+		projections, err := bc.projectStakeDifficulty(curNode, purchases)
+		if err != nil {
+			t.Errorf("projectStakeDifficulty (%s): unexpected error: %v",
+				test.name, err)
+			continue
+		}
+		blocksUntilRetarget := intervalSize - curNode.height%intervalSize
+		var newTickets int64
+		for _, numTickets := range purchases[:blocksUntilRetarget-1] {
+			newTickets += numTickets
+		}
+		estimate, err := bc.estimateNextStakeDifficultyV2(curNode,
+			newTickets, false)
+		if err != nil {
+			t.Errorf("estimateNextStakeDifficultyV2 (%s): unexpected "+
+				"error: %v", test.name, err)
+			continue
+		}
+
+		// Extend the chain with the same purchases and ensure every
+		// projected interval matches the one which is actually required.
+		for _, info := range test.purchases {
+			if err := addBlocks(info); err != nil {
+				t.Fatalf("%s: unexpected error extending fake chain: "+
+					"%v", test.name, err)
+			}
+		}
+		if err := addBlocks(ticketInfo{1, 0}); err != nil {
+			t.Fatalf("%s: unexpected error extending fake chain: %v",
+				test.name, err)
+		}
+		wantNumProjections := (curNode.height+int64(len(purchases))+1)/
+			intervalSize - curNode.height/intervalSize
+		if int64(len(projections)) != wantNumProjections {
+			t.Errorf("projectStakeDifficulty (%s): unexpected number "+
+				"of projections -- got %d, want %d", test.name,
+				len(projections), wantNumProjections)
+			continue
+		}
+		for _, projection := range projections {
+			want, ok := retargets[projection.Height]
+			if !ok {
+				t.Errorf("projectStakeDifficulty (%s): unexpected "+
+					"projection height %d", test.name,
+					projection.Height)
+				continue
+			}
+			if projection.StakeDiff != want.stakeDiff {
+				t.Errorf("projectStakeDifficulty (%s): mismatched "+
+					"stake difficulty at height %d -- got %d, "+
+					"want %d", test.name, projection.Height,
+					projection.StakeDiff, want.stakeDiff)
+			}
+			if projection.PoolSize != want.poolSize {
+				t.Errorf("projectStakeDifficulty (%s): mismatched "+
+					"pool size at height %d -- got %d, want %d",
+					test.name, projection.Height,
+					projection.PoolSize, want.poolSize)
+			}
+		}
+
+		// Ensure the first projected interval matches the estimate.
+		if projections[0].StakeDiff != estimate {
+			t.Errorf("projectStakeDifficulty (%s): first projected "+
+				"stake difficulty %d does not match the estimate %d",
+				test.name, projections[0].StakeDiff, estimate)
+		}
+	}
+}
@@ -0,0 +1,796 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"encoding/binary"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/txscript"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+const (
+	// addrUtxoIndexName is the human-readable name for the index.
+	addrUtxoIndexName = "address utxo index"
+
+	// The following prefixes identify the type of each record stored in
+	// the address utxo index bucket.
+	addrUtxoPrefixUtxo    = 'u'
+	addrUtxoPrefixBalance = 'b'
+	addrUtxoPrefixDelta   = 'd'
+	addrUtxoPrefixUndo    = 'r'
+
+	// addrUtxoKeySize is the size of the key of an unspent output entry.
+	// It consists of the prefix + address key + tx hash + output index.
+	addrUtxoKeySize = 1 + addrKeySize + chainhash.HashSize + 4
+
+	// addrUtxoValueMinSize is the size of the value of an unspent output
+	// entry excluding the public key script.  It consists of the amount +
+	// block height + tree + script version.
+	addrUtxoValueMinSize = 8 + 4 + 1 + 2
+
+	// addrBalanceValueSize is the size of the value of a balance entry.  It
+	// consists of the balance + total received.
+	addrBalanceValueSize = 8 + 8
+
+	// addrDeltaKeySize is the size of the key of a delta entry.  It
+	// consists of the prefix + address key + block height + tree + tx
+	// index + input flag + input or output index.
+	addrDeltaKeySize = 1 + addrKeySize + 4 + 1 + 4 + 1 + 4
+
+	// addrDeltaValueSize is the size of the value of a delta entry.  It
+	// consists of the tx hash + amount.
+	addrDeltaValueSize = chainhash.HashSize + 8
+
+	// addrUndoEntrySize is the size of each spent output recorded in the
+	// undo data of a block.  It consists of the address key + tx hash +
+	// output index + block height.
+	addrUndoEntrySize = addrKeySize + chainhash.HashSize + 4 + 4
+)
+
+var (
+	// addrUtxoIndexKey is the key of the address utxo index and the db
+	// bucket used to house it.
+	addrUtxoIndexKey = []byte("utxobyaddridx")
+)
+
+// -----------------------------------------------------------------------------
+// The address utxo index maintains the unspent outputs, the balance, and the
+// list of credits and debits (deltas) of every address that is paid by an
+// output with a single standard address in either transaction tree.  Outputs
+// that pay to multiple addresses, such as bare multisig, are not indexed since
+// they can't be attributed to a single balance.
+//
+// Since the regular transaction tree of a block is only applied once the next
+// block approves it, the regular transactions of a block are indexed when its
+// child is connected with the vote bits approving it, in the same way as the
+// address index.  Regular trees which are disapproved are never indexed.
+//
+// All records are stored in a single bucket and distinguished by a one byte
+// prefix so the entire index can be dropped like any other index.
+//
+// The serialized format for unspent output entries is:
+//
+//   'u'<addr key><tx hash><output index> = <amount><height><tree><script version><pk script>
+//
+//   Field           Type              Size
+//   addr key        [21]byte          21 bytes
+//   tx hash         chainhash.Hash    32 bytes
+//   output index    uint32 (BE)       4 bytes
+//   amount          int64             8 bytes
+//   height          uint32            4 bytes
+//   tree            int8              1 byte
+//   script version  uint16            2 bytes
+//   pk script       []byte            variable
+//
+// The serialized format for balance entries is:
+//
+//   'b'<addr key> = <balance><received>
+//
+//   Field           Type              Size
+//   addr key        [21]byte          21 bytes
+//   balance         int64             8 bytes
+//   received        int64             8 bytes
+//
+// The serialized format for delta entries is:
+//
+//   'd'<addr key><height><tree><tx index><is input><index> = <tx hash><amount>
+//
+//   Field           Type              Size
+//   addr key        [21]byte          21 bytes
+//   height          uint32 (BE)       4 bytes
+//   tree            int8              1 byte
+//   tx index        uint32 (BE)       4 bytes
+//   is input        bool              1 byte
+//   index           uint32 (BE)       4 bytes
+//   tx hash         chainhash.Hash    32 bytes
+//   amount          int64             8 bytes
+//
+// The big endian fields in the delta keys cause the deltas of each address to
+// be ordered by their position in the chain.
+//
+// Finally, the heights of the outputs spent by each block are recorded so the
+// spent outputs can be restored when the block is disconnected:
+//
+//   'r'<block hash> = [<addr key><tx hash><output index><height>,...]
+//
+//   Field           Type              Size
+//   block hash      chainhash.Hash    32 bytes
+//   addr key        [21]byte          21 bytes
+//   tx hash         chainhash.Hash    32 bytes
+//   output index    uint32            4 bytes
+//   height          uint32            4 bytes
+// -----------------------------------------------------------------------------
+
+// AddrUtxo describes an unspent output paying to an address as stored in the
+// address utxo index.
+type AddrUtxo struct {
+	Hash          chainhash.Hash
+	Index         uint32
+	Tree          int8
+	Amount        int64
+	Height        int64
+	ScriptVersion uint16
+	PkScript      []byte
+}
+
+// AddrDelta describes a change to the balance of an address caused by either
+// an input spending from the address or an output paying to it.
+type AddrDelta struct {
+	Hash    chainhash.Hash
+	Index   uint32
+	IsInput bool
+	Tree    int8
+	Amount  int64
+	Height  int64
+}
+
+// addrUtxoKey returns the key of the unspent output entry for the passed
+// address key and outpoint.
+func addrUtxoKey(addrKey [addrKeySize]byte, hash *chainhash.Hash, index uint32) []byte {
+	key := make([]byte, addrUtxoKeySize)
+	key[0] = addrUtxoPrefixUtxo
+	copy(key[1:], addrKey[:])
+	copy(key[1+addrKeySize:], hash[:])
+	binary.BigEndian.PutUint32(key[1+addrKeySize+chainhash.HashSize:], index)
+	return key
+}
+
+// serializeAddrUtxo returns the value of the unspent output entry for the
+// passed utxo.
+func serializeAddrUtxo(utxo *AddrUtxo) []byte {
+	serialized := make([]byte, addrUtxoValueMinSize+len(utxo.PkScript))
+	byteOrder.PutUint64(serialized[0:8], uint64(utxo.Amount))
+	byteOrder.PutUint32(serialized[8:12], uint32(utxo.Height))
+	serialized[12] = byte(utxo.Tree)
+	byteOrder.PutUint16(serialized[13:15], utxo.ScriptVersion)
+	copy(serialized[addrUtxoValueMinSize:], utxo.PkScript)
+	return serialized
+}
+
+// deserializeAddrUtxo decodes the passed unspent output entry key and value.
+func deserializeAddrUtxo(key, serialized []byte) (*AddrUtxo, error) {
+	if len(key) != addrUtxoKeySize {
+		return nil, errDeserialize("unexpected address utxo key length")
+	}
+	if len(serialized) < addrUtxoValueMinSize {
+		return nil, errDeserialize("unexpected end of data")
+	}
+
+	var utxo AddrUtxo
+	copy(utxo.Hash[:], key[1+addrKeySize:])
+	utxo.Index = binary.BigEndian.Uint32(key[1+addrKeySize+chainhash.HashSize:])
+	utxo.Amount = int64(byteOrder.Uint64(serialized[0:8]))
+	utxo.Height = int64(byteOrder.Uint32(serialized[8:12]))
+	utxo.Tree = int8(serialized[12])
+	utxo.ScriptVersion = byteOrder.Uint16(serialized[13:15])
+	utxo.PkScript = make([]byte, len(serialized)-addrUtxoValueMinSize)
+	copy(utxo.PkScript, serialized[addrUtxoValueMinSize:])
+	return &utxo, nil
+}
+
+// addrBalanceKey returns the key of the balance entry for the passed address
+// key.
+func addrBalanceKey(addrKey [addrKeySize]byte) []byte {
+	key := make([]byte, 1+addrKeySize)
+	key[0] = addrUtxoPrefixBalance
+	copy(key[1:], addrKey[:])
+	return key
+}
+
+// dbFetchAddrBalance returns the balance and total received by the passed
+// address key from the index.
+func dbFetchAddrBalance(bucket internalBucket, addrKey [addrKeySize]byte) (int64, int64, error) {
+	serialized := bucket.Get(addrBalanceKey(addrKey))
+	if serialized == nil {
+		return 0, 0, nil
+	}
+	if len(serialized) != addrBalanceValueSize {
+		return 0, 0, errDeserialize("unexpected address balance length")
+	}
+
+	balance := int64(byteOrder.Uint64(serialized[0:8]))
+	received := int64(byteOrder.Uint64(serialized[8:16]))
+	return balance, received, nil
+}
+
+// dbUpdateAddrBalance adds the passed amounts to the balance and total
+// received by the passed address key.  The entry is removed once both reach
+// zero which happens when all blocks involving the address are disconnected.
+func dbUpdateAddrBalance(bucket internalBucket, addrKey [addrKeySize]byte, balanceDelta, receivedDelta int64) error {
+	balance, received, err := dbFetchAddrBalance(bucket, addrKey)
+	if err != nil {
+		return err
+	}
+	balance += balanceDelta
+	received += receivedDelta
+
+	key := addrBalanceKey(addrKey)
+	if balance == 0 && received == 0 {
+		return bucket.Delete(key)
+	}
+	serialized := make([]byte, addrBalanceValueSize)
+	byteOrder.PutUint64(serialized[0:8], uint64(balance))
+	byteOrder.PutUint64(serialized[8:16], uint64(received))
+	return bucket.Put(key, serialized)
+}
+
+// addrDeltaKey returns the key of the delta entry for the passed address key
+// and position of the input or output in the chain.
+func addrDeltaKey(addrKey [addrKeySize]byte, height int64, tree int8, txIdx int, isInput bool, index uint32) []byte {
+	key := make([]byte, addrDeltaKeySize)
+	key[0] = addrUtxoPrefixDelta
+	offset := 1
+	copy(key[offset:], addrKey[:])
+	offset += addrKeySize
+	binary.BigEndian.PutUint32(key[offset:], uint32(height))
+	offset += 4
+	key[offset] = byte(tree)
+	offset++
+	binary.BigEndian.PutUint32(key[offset:], uint32(txIdx))
+	offset += 4
+	if isInput {
+		key[offset] = 1
+	}
+	offset++
+	binary.BigEndian.PutUint32(key[offset:], index)
+	return key
+}
+
+// deserializeAddrDelta decodes the passed delta entry key and value.
+func deserializeAddrDelta(key, serialized []byte) (*AddrDelta, error) {
+	if len(key) != addrDeltaKeySize {
+		return nil, errDeserialize("unexpected address delta key length")
+	}
+	if len(serialized) != addrDeltaValueSize {
+		return nil, errDeserialize("unexpected address delta length")
+	}
+
+	var delta AddrDelta
+	offset := 1 + addrKeySize
+	delta.Height = int64(binary.BigEndian.Uint32(key[offset:]))
+	offset += 4
+	delta.Tree = int8(key[offset])
+	offset += 1 + 4
+	delta.IsInput = key[offset] != 0
+	offset++
+	delta.Index = binary.BigEndian.Uint32(key[offset:])
+	copy(delta.Hash[:], serialized[0:chainhash.HashSize])
+	delta.Amount = int64(byteOrder.Uint64(serialized[chainhash.HashSize:]))
+	return &delta, nil
+}
+
+// addrUndoKey returns the key of the undo data for the block with the passed
+// hash.
+func addrUndoKey(blockHash *chainhash.Hash) []byte {
+	key := make([]byte, 1+chainhash.HashSize)
+	key[0] = addrUtxoPrefixUndo
+	copy(key[1:], blockHash[:])
+	return key
+}
+
+// AddrUtxoIndex implements an unspent output and balance by address index.
+// It supports querying the balance, the unspent outputs, and the history of
+// balance changes of an address without having to replay all of the
+// transactions involving it.
+type AddrUtxoIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the AddrUtxoIndex type implements the Indexer interface.
+var _ Indexer = (*AddrUtxoIndex)(nil)
+
+// Ensure the AddrUtxoIndex type implements the NeedsInputser interface.
+var _ NeedsInputser = (*AddrUtxoIndex)(nil)
+
+// NeedsInputs signals that the index requires the referenced inputs in order
+// to properly create the index.
+//
+// This implements the NeedsInputser interface.
+func (idx *AddrUtxoIndex) NeedsInputs() bool {
+	return true
+}
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Key() []byte {
+	return addrUtxoIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Name() string {
+	return addrUtxoIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the address
+// utxo index.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(addrUtxoIndexKey)
+	return err
+}
+
+// addrKeyForPkScript returns the address key of the single standard address
+// the passed public key script pays to.  False is returned when the script
+// does not pay to exactly one supported address.
+func (idx *AddrUtxoIndex) addrKeyForPkScript(scriptVersion uint16, pkScript []byte) ([addrKeySize]byte, bool) {
+	_, addrs, _, err := txscript.ExtractPkScriptAddrs(scriptVersion,
+		pkScript, idx.chainParams)
+	if err != nil || len(addrs) != 1 {
+		return [addrKeySize]byte{}, false
+	}
+	addrKey, err := addrToKey(addrs[0], idx.chainParams)
+	if err != nil {
+		return [addrKeySize]byte{}, false
+	}
+	return addrKey, true
+}
+
+// indexedTx describes a transaction along with its position in the chain as
+// it is applied to the index.
+type indexedTx struct {
+	tx     *hcutil.Tx
+	height int64
+	tree   int8
+	txIdx  int
+}
+
+// indexedTxns returns the transactions which are applied when the passed block
+// is connected in the order they are applied.  That is the regular
+// transactions of the parent when the block approves them followed by the
+// stake transactions of the block.
+func indexedTxns(block, parent *hcutil.Block) []indexedTx {
+	var txns []indexedTx
+	if approvesParent(block) && block.Height() > 1 {
+		for txIdx, tx := range parent.Transactions() {
+			txns = append(txns, indexedTx{tx, parent.Height(),
+				wire.TxTreeRegular, txIdx})
+		}
+	}
+	for txIdx, tx := range block.STransactions() {
+		txns = append(txns, indexedTx{tx, block.Height(),
+			wire.TxTreeStake, txIdx})
+	}
+	return txns
+}
+
+// firstSpentInput returns the index of the first input of the passed
+// transaction which spends a previous output.  That excludes the input of
+// coinbases, for which the number of inputs is returned, and the stakebase
+// input of votes.
+func firstSpentInput(itx *indexedTx) int {
+	msgTx := itx.tx.MsgTx()
+	if itx.tree == wire.TxTreeRegular && itx.txIdx == 0 {
+		return len(msgTx.TxIn)
+	}
+	if isSSGen, _ := stake.IsSSGen(msgTx); isSSGen {
+		return 1
+	}
+	return 0
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the outputs paying to
+// addresses, removes the outputs spent, and updates the balances and deltas
+// of all involved addresses.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) ConnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+	var undo []byte
+	for _, itx := range indexedTxns(block, parent) {
+		txHash := itx.tx.Hash()
+		msgTx := itx.tx.MsgTx()
+		for i := firstSpentInput(&itx); i < len(msgTx.TxIn); i++ {
+			// The view should always have the input since the
+			// index contract requires it, however, be safe and
+			// simply ignore any missing entries.
+			origin := &msgTx.TxIn[i].PreviousOutPoint
+			entry := view.LookupEntry(&origin.Hash)
+			if entry == nil {
+				log.Warnf("Missing input %v for tx %v while "+
+					"indexing block %v (height %v)", origin.Hash,
+					txHash, block.Hash(), block.Height())
+				continue
+			}
+			addrKey, ok := idx.addrKeyForPkScript(
+				entry.ScriptVersionByIndex(origin.Index),
+				entry.PkScriptByIndex(origin.Index))
+			if !ok {
+				continue
+			}
+
+			// Remove the spent output while recording its height
+			// so it can be restored if the block is disconnected.
+			utxoKey := addrUtxoKey(addrKey, &origin.Hash, origin.Index)
+			serialized := bucket.Get(utxoKey)
+			if serialized == nil {
+				log.Warnf("Missing address utxo %v spent by tx %v "+
+					"while indexing block %v (height %v)", origin,
+					txHash, block.Hash(), block.Height())
+				continue
+			}
+			utxo, err := deserializeAddrUtxo(utxoKey, serialized)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Delete(utxoKey); err != nil {
+				return err
+			}
+			var undoEntry [addrUndoEntrySize]byte
+			copy(undoEntry[:], utxoKey[1:])
+			byteOrder.PutUint32(undoEntry[addrUndoEntrySize-4:],
+				uint32(utxo.Height))
+			undo = append(undo, undoEntry[:]...)
+
+			err = dbUpdateAddrBalance(bucket, addrKey, -utxo.Amount, 0)
+			if err != nil {
+				return err
+			}
+			value := make([]byte, addrDeltaValueSize)
+			copy(value, txHash[:])
+			byteOrder.PutUint64(value[chainhash.HashSize:],
+				uint64(-utxo.Amount))
+			err = bucket.Put(addrDeltaKey(addrKey, itx.height, itx.tree,
+				itx.txIdx, true, uint32(i)), value)
+			if err != nil {
+				return err
+			}
+		}
+
+		for i, txOut := range msgTx.TxOut {
+			addrKey, ok := idx.addrKeyForPkScript(txOut.Version,
+				txOut.PkScript)
+			if !ok {
+				continue
+			}
+
+			utxo := AddrUtxo{
+				Hash:          *txHash,
+				Index:         uint32(i),
+				Tree:          itx.tree,
+				Amount:        txOut.Value,
+				Height:        itx.height,
+				ScriptVersion: txOut.Version,
+				PkScript:      txOut.PkScript,
+			}
+			err := bucket.Put(addrUtxoKey(addrKey, txHash, uint32(i)),
+				serializeAddrUtxo(&utxo))
+			if err != nil {
+				return err
+			}
+
+			err = dbUpdateAddrBalance(bucket, addrKey, txOut.Value,
+				txOut.Value)
+			if err != nil {
+				return err
+			}
+			value := make([]byte, addrDeltaValueSize)
+			copy(value, txHash[:])
+			byteOrder.PutUint64(value[chainhash.HashSize:],
+				uint64(txOut.Value))
+			err = bucket.Put(addrDeltaKey(addrKey, itx.height, itx.tree,
+				itx.txIdx, false, uint32(i)), value)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(undo) == 0 {
+		return nil
+	}
+	return bucket.Put(addrUndoKey(block.Hash()), undo)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer reverses all of the changes
+// made when the block was connected, restoring the outputs it spent.
+//
+// This is part of the Indexer interface.
+func (idx *AddrUtxoIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+
+	// Load the heights of the outputs spent by the block.
+	undoKey := addrUndoKey(block.Hash())
+	serializedUndo := bucket.Get(undoKey)
+	if len(serializedUndo)%addrUndoEntrySize != 0 {
+		return errDeserialize("unexpected address utxo undo length")
+	}
+	spentHeights := make(map[string]int64,
+		len(serializedUndo)/addrUndoEntrySize)
+	for offset := 0; offset < len(serializedUndo); offset += addrUndoEntrySize {
+		entry := serializedUndo[offset : offset+addrUndoEntrySize]
+		height := byteOrder.Uint32(entry[addrUndoEntrySize-4:])
+		spentHeights[string(entry[:addrUndoEntrySize-4])] = int64(height)
+	}
+
+	// Undo the transactions in the reverse order they were applied.
+	txns := indexedTxns(block, parent)
+	for txnIdx := len(txns) - 1; txnIdx >= 0; txnIdx-- {
+		itx := &txns[txnIdx]
+		txHash := itx.tx.Hash()
+		msgTx := itx.tx.MsgTx()
+		for i, txOut := range msgTx.TxOut {
+			addrKey, ok := idx.addrKeyForPkScript(txOut.Version,
+				txOut.PkScript)
+			if !ok {
+				continue
+			}
+
+			err := bucket.Delete(addrUtxoKey(addrKey, txHash, uint32(i)))
+			if err != nil {
+				return err
+			}
+			err = dbUpdateAddrBalance(bucket, addrKey, -txOut.Value,
+				-txOut.Value)
+			if err != nil {
+				return err
+			}
+			err = bucket.Delete(addrDeltaKey(addrKey, itx.height,
+				itx.tree, itx.txIdx, false, uint32(i)))
+			if err != nil {
+				return err
+			}
+		}
+
+		for i := firstSpentInput(itx); i < len(msgTx.TxIn); i++ {
+			origin := &msgTx.TxIn[i].PreviousOutPoint
+			entry := view.LookupEntry(&origin.Hash)
+			if entry == nil {
+				log.Warnf("Missing input %v for tx %v while "+
+					"unindexing block %v (height %v)", origin.Hash,
+					txHash, block.Hash(), block.Height())
+				continue
+			}
+			scriptVersion := entry.ScriptVersionByIndex(origin.Index)
+			pkScript := entry.PkScriptByIndex(origin.Index)
+			addrKey, ok := idx.addrKeyForPkScript(scriptVersion, pkScript)
+			if !ok {
+				continue
+			}
+
+			utxoKey := addrUtxoKey(addrKey, &origin.Hash, origin.Index)
+			// Outputs without undo data were not removed when the
+			// block was connected, so there is nothing to restore.
+			height, ok := spentHeights[string(utxoKey[1:])]
+			if !ok {
+				continue
+			}
+			utxo := AddrUtxo{
+				Hash:          origin.Hash,
+				Index:         origin.Index,
+				Tree:          origin.Tree,
+				Amount:        entry.AmountByIndex(origin.Index),
+				Height:        height,
+				ScriptVersion: scriptVersion,
+				PkScript:      pkScript,
+			}
+			err := bucket.Put(utxoKey, serializeAddrUtxo(&utxo))
+			if err != nil {
+				return err
+			}
+			err = dbUpdateAddrBalance(bucket, addrKey, utxo.Amount, 0)
+			if err != nil {
+				return err
+			}
+			err = bucket.Delete(addrDeltaKey(addrKey, itx.height,
+				itx.tree, itx.txIdx, true, uint32(i)))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	if serializedUndo == nil {
+		return nil
+	}
+	return bucket.Delete(undoKey)
+}
+
+// prefixSuccessor returns the smallest key which is greater than all of the
+// keys starting with the passed prefix.  The prefix must not consist entirely
+// of 0xff bytes which is never the case for the prefixes used by this index.
+func prefixSuccessor(prefix []byte) []byte {
+	successor := make([]byte, len(prefix))
+	copy(successor, prefix)
+	for i := len(successor) - 1; i >= 0; i-- {
+		successor[i]++
+		if successor[i] != 0 {
+			return successor[:i+1]
+		}
+	}
+	return successor
+}
+
+// forEachWithPrefix invokes the passed function with the key and value of
+// every entry in the bucket that starts with the passed prefix, in reverse
+// order when requested, until the function returns false or an error.
+func forEachWithPrefix(bucket database.Bucket, prefix []byte, reverse bool, fn func(k, v []byte) (bool, error)) error {
+	cursor := bucket.Cursor()
+	var ok bool
+	if reverse {
+		if cursor.Seek(prefixSuccessor(prefix)) {
+			ok = cursor.Prev()
+		} else {
+			ok = cursor.Last()
+		}
+	} else {
+		ok = cursor.Seek(prefix)
+	}
+
+	for ; ok; ok = advanceCursor(cursor, reverse) {
+		key := cursor.Key()
+		if !bytes.HasPrefix(key, prefix) {
+			return nil
+		}
+		cont, err := fn(key, cursor.Value())
+		if err != nil || !cont {
+			return err
+		}
+	}
+	return nil
+}
+
+// advanceCursor moves the passed cursor to the next entry in the requested
+// direction.
+func advanceCursor(cursor database.Cursor, reverse bool) bool {
+	if reverse {
+		return cursor.Prev()
+	}
+	return cursor.Next()
+}
+
+// Balance returns the balance and the total amount ever received by the passed
+// address in atoms.
+//
+// This function is safe for concurrent access.
+func (idx *AddrUtxoIndex) Balance(addr hcutil.Address) (int64, int64, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var balance, received int64
+	err = idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+		var err error
+		balance, received, err = dbFetchAddrBalance(bucket, addrKey)
+		return err
+	})
+	return balance, received, err
+}
+
+// UtxosForAddress returns the unspent outputs paying to the passed address
+// according to the specified number to skip and number requested.  The
+// outputs are ordered by transaction hash and output index.
+//
+// This function is safe for concurrent access.
+func (idx *AddrUtxoIndex) UtxosForAddress(addr hcutil.Address, numToSkip, numRequested uint32) ([]*AddrUtxo, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+	prefix := make([]byte, 1+addrKeySize)
+	prefix[0] = addrUtxoPrefixUtxo
+	copy(prefix[1:], addrKey[:])
+
+	var utxos []*AddrUtxo
+	err = idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+		return forEachWithPrefix(bucket, prefix, false, func(k, v []byte) (bool, error) {
+			if numToSkip > 0 {
+				numToSkip--
+				return true, nil
+			}
+			utxo, err := deserializeAddrUtxo(k, v)
+			if err != nil {
+				return false, err
+			}
+			utxos = append(utxos, utxo)
+			return uint32(len(utxos)) < numRequested, nil
+		})
+	})
+	return utxos, err
+}
+
+// DeltasForAddress returns the changes to the balance of the passed address
+// according to the specified number to skip, number requested, and whether or
+// not the results should be reversed.  The deltas are ordered according to
+// their position in the blockchain, oldest first unless reversed.
+//
+// This function is safe for concurrent access.
+func (idx *AddrUtxoIndex) DeltasForAddress(addr hcutil.Address, numToSkip, numRequested uint32, reverse bool) ([]*AddrDelta, error) {
+	addrKey, err := addrToKey(addr, idx.chainParams)
+	if err != nil {
+		return nil, err
+	}
+	if numRequested == 0 {
+		return nil, nil
+	}
+	prefix := make([]byte, 1+addrKeySize)
+	prefix[0] = addrUtxoPrefixDelta
+	copy(prefix[1:], addrKey[:])
+
+	var deltas []*AddrDelta
+	err = idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(addrUtxoIndexKey)
+		return forEachWithPrefix(bucket, prefix, reverse, func(k, v []byte) (bool, error) {
+			if numToSkip > 0 {
+				numToSkip--
+				return true, nil
+			}
+			delta, err := deserializeAddrDelta(k, v)
+			if err != nil {
+				return false, err
+			}
+			deltas = append(deltas, delta)
+			return uint32(len(deltas)) < numRequested, nil
+		})
+	})
+	return deltas, err
+}
+
+// NewAddrUtxoIndex returns a new instance of an indexer that is used to create
+// a mapping of addresses to their unspent outputs, balances, and balance
+// changes.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewAddrUtxoIndex(db database.DB, chainParams *chaincfg.Params) *AddrUtxoIndex {
+	return &AddrUtxoIndex{db: db, chainParams: chainParams}
+}
+
+// DropAddrUtxoIndex drops the address utxo index from the provided database if
+// it exists.
+func DropAddrUtxoIndex(db database.DB) error {
+	return dropIndex(db, addrUtxoIndexKey, addrUtxoIndexName)
+}
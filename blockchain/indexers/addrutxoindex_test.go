@@ -0,0 +1,479 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/chaincfg/chainec"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/txscript"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// mapBucket provides a mock database bucket backed by a map by implementing
+// the internalBucket interface.
+type mapBucket map[string][]byte
+
+// Get returns the value associated with the key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b mapBucket) Get(key []byte) []byte {
+	return b[string(key)]
+}
+
+// Put stores the provided key/value pair to the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b mapBucket) Put(key []byte, value []byte) error {
+	b[string(key)] = value
+	return nil
+}
+
+// Delete removes the provided key from the mock bucket.
+//
+// This is part of the internalBucket interface.
+func (b mapBucket) Delete(key []byte) error {
+	delete(b, string(key))
+	return nil
+}
+
+// TestAddrUtxoSerialization ensures the unspent output and delta entries of the
+// address utxo index round trip through their serialized forms and that delta
+// keys are ordered by their position in the chain.
+func TestAddrUtxoSerialization(t *testing.T) {
+	t.Parallel()
+
+	addrKey := [addrKeySize]byte{0x00, 0x01, 0x02, 0x03}
+	hash := chainhash.Hash{0xaa, 0xbb, 0xcc}
+
+	utxo := &AddrUtxo{
+		Hash:          hash,
+		Index:         2,
+		Tree:          1,
+		Amount:        384000000,
+		Height:        123456,
+		ScriptVersion: 0,
+		PkScript:      []byte{0x76, 0xa9, 0x14, 0x88, 0xac},
+	}
+	key := addrUtxoKey(addrKey, &utxo.Hash, utxo.Index)
+	gotUtxo, err := deserializeAddrUtxo(key, serializeAddrUtxo(utxo))
+	if err != nil {
+		t.Fatalf("deserializeAddrUtxo: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotUtxo, utxo) {
+		t.Fatalf("deserializeAddrUtxo: mismatched utxo - got %+v, "+
+			"want %+v", gotUtxo, utxo)
+	}
+	if _, err := deserializeAddrUtxo(key, []byte{0x00}); err == nil {
+		t.Fatal("deserializeAddrUtxo: did not reject short value")
+	}
+
+	delta := &AddrDelta{
+		Hash:    hash,
+		Index:   1,
+		IsInput: true,
+		Tree:    0,
+		Amount:  -5000,
+		Height:  77,
+	}
+	key = addrDeltaKey(addrKey, delta.Height, delta.Tree, 3, delta.IsInput,
+		delta.Index)
+	value := make([]byte, addrDeltaValueSize)
+	copy(value, delta.Hash[:])
+	byteOrder.PutUint64(value[chainhash.HashSize:], uint64(delta.Amount))
+	gotDelta, err := deserializeAddrDelta(key, value)
+	if err != nil {
+		t.Fatalf("deserializeAddrDelta: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotDelta, delta) {
+		t.Fatalf("deserializeAddrDelta: mismatched delta - got %+v, "+
+			"want %+v", gotDelta, delta)
+	}
+
+	// Ensure deltas sort by height, then tree, then transaction index.
+	ordered := [][]byte{
+		addrDeltaKey(addrKey, 77, 0, 3, true, 1),
+		addrDeltaKey(addrKey, 77, 0, 4, false, 0),
+		addrDeltaKey(addrKey, 77, 1, 0, false, 0),
+		addrDeltaKey(addrKey, 256, 0, 0, false, 0),
+	}
+	for i := 1; i < len(ordered); i++ {
+		if bytes.Compare(ordered[i-1], ordered[i]) >= 0 {
+			t.Fatalf("delta key #%d does not sort before key #%d",
+				i-1, i)
+		}
+	}
+}
+
+// TestAddrBalanceUpdate ensures balance entries are accumulated and removed
+// once they are fully reversed.
+func TestAddrBalanceUpdate(t *testing.T) {
+	t.Parallel()
+
+	bucket := make(mapBucket)
+	addrKey := [addrKeySize]byte{0x00, 0x05}
+
+	updates := []struct {
+		balanceDelta  int64
+		receivedDelta int64
+		wantBalance   int64
+		wantReceived  int64
+	}{
+		{1000, 1000, 1000, 1000},
+		{500, 500, 1500, 1500},
+		{-1200, 0, 300, 1500},
+		{1200, 0, 1500, 1500},
+		{-1500, -1500, 0, 0},
+	}
+	for i, update := range updates {
+		err := dbUpdateAddrBalance(bucket, addrKey, update.balanceDelta,
+			update.receivedDelta)
+		if err != nil {
+			t.Fatalf("dbUpdateAddrBalance #%d: unexpected error: %v",
+				i, err)
+		}
+		balance, received, err := dbFetchAddrBalance(bucket, addrKey)
+		if err != nil {
+			t.Fatalf("dbFetchAddrBalance #%d: unexpected error: %v",
+				i, err)
+		}
+		if balance != update.wantBalance || received != update.wantReceived {
+			t.Fatalf("#%d: got balance %d, received %d - want "+
+				"balance %d, received %d", i, balance, received,
+				update.wantBalance, update.wantReceived)
+		}
+	}
+	if len(bucket) != 0 {
+		t.Fatalf("balance entry was not removed - %d entries remain",
+			len(bucket))
+	}
+}
+
+// indexTestChain houses a small chain of blocks which is used to exercise
+// connecting and disconnecting blocks through the indexes.
+//
+// Block a pays addrA and addrC in its coinbase.  Block b approves a and spends
+// the output to addrA in its regular tree and the output to addrC in a ticket.
+// Blocks approveC and disapproveC are alternative children of b which
+// respectively approve and disapprove it and both contain a vote spending the
+// ticket.
+type indexTestChain struct {
+	addrA, addrB, addrC                 hcutil.Address
+	coinbase, spend, ticket, vote       *hcutil.Tx
+	parent, a, b, approveC, disapproveC *hcutil.Block
+	view                                *blockchain.UtxoViewpoint
+}
+
+// newIndexTestChain returns a new instance of the test chain along with a view
+// which contains all of the outputs spent by its blocks.
+func newIndexTestChain(t *testing.T) *indexTestChain {
+	t.Helper()
+
+	params := &chaincfg.SimNetParams
+	newAddr := func(b byte) hcutil.Address {
+		addr, err := hcutil.NewAddressPubKeyHash(bytes.Repeat([]byte{b},
+			20), params, chainec.ECTypeSecp256k1)
+		if err != nil {
+			t.Fatalf("NewAddressPubKeyHash: unexpected error: %v", err)
+		}
+		return addr
+	}
+	mustScript := func(script []byte, err error) []byte {
+		if err != nil {
+			t.Fatalf("unable to create script: %v", err)
+		}
+		return script
+	}
+	newBlock := func(height uint32, voteBits uint16, parent *hcutil.Block, txns, stxns []*hcutil.Tx) *hcutil.Block {
+		var msgBlock wire.MsgBlock
+		msgBlock.Header.Height = height
+		msgBlock.Header.VoteBits = voteBits
+		if parent != nil {
+			msgBlock.Header.PrevBlock = *parent.Hash()
+		}
+		for _, tx := range txns {
+			msgBlock.AddTransaction(tx.MsgTx())
+		}
+		for _, tx := range stxns {
+			msgBlock.AddSTransaction(tx.MsgTx())
+		}
+		return hcutil.NewBlock(&msgBlock)
+	}
+
+	c := &indexTestChain{
+		addrA: newAddr(0x0a),
+		addrB: newAddr(0x0b),
+		addrC: newAddr(0x0c),
+	}
+
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), nil))
+	coinbase.AddTxOut(wire.NewTxOut(100,
+		mustScript(txscript.PayToAddrScript(c.addrA))))
+	coinbase.AddTxOut(wire.NewTxOut(50,
+		mustScript(txscript.PayToAddrScript(c.addrC))))
+	c.coinbase = hcutil.NewTx(coinbase)
+
+	spend := wire.NewMsgTx()
+	spend.AddTxIn(wire.NewTxIn(wire.NewOutPoint(c.coinbase.Hash(), 0,
+		wire.TxTreeRegular), nil))
+	spend.AddTxOut(wire.NewTxOut(70,
+		mustScript(txscript.PayToAddrScript(c.addrB))))
+	spend.AddTxOut(wire.NewTxOut(30,
+		mustScript(txscript.PayToAddrScript(c.addrA))))
+	c.spend = hcutil.NewTx(spend)
+
+	ticket := wire.NewMsgTx()
+	ticket.AddTxIn(wire.NewTxIn(wire.NewOutPoint(c.coinbase.Hash(), 1,
+		wire.TxTreeRegular), nil))
+	ticket.AddTxOut(wire.NewTxOut(50,
+		mustScript(txscript.PayToSStx(c.addrC))))
+	c.ticket = hcutil.NewTx(ticket)
+
+	// The coinbase of b doesn't pay any addresses.
+	coinbaseB := wire.NewMsgTx()
+	coinbaseB.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), nil))
+
+	c.parent = newBlock(9, hcutil.BlockValid, nil, nil, nil)
+	c.a = newBlock(10, hcutil.BlockValid, c.parent,
+		[]*hcutil.Tx{c.coinbase}, nil)
+	c.b = newBlock(11, hcutil.BlockValid, c.a,
+		[]*hcutil.Tx{hcutil.NewTx(coinbaseB), c.spend},
+		[]*hcutil.Tx{c.ticket})
+
+	vote := wire.NewMsgTx()
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), nil))
+	vote.AddTxIn(wire.NewTxIn(wire.NewOutPoint(c.ticket.Hash(), 0,
+		wire.TxTreeStake), nil))
+	vote.AddTxOut(wire.NewTxOut(0,
+		mustScript(txscript.GenerateSSGenBlockRef(*c.b.Hash(), 11))))
+	vote.AddTxOut(wire.NewTxOut(0,
+		mustScript(txscript.GenerateSSGenVotes(hcutil.BlockValid))))
+	vote.AddTxOut(wire.NewTxOut(55,
+		mustScript(txscript.PayToSSGen(c.addrC))))
+	c.vote = hcutil.NewTx(vote)
+	if isSSGen, err := stake.IsSSGen(vote); !isSSGen {
+		t.Fatalf("test vote is not a vote: %v", err)
+	}
+
+	c.approveC = newBlock(12, hcutil.BlockValid, c.b, nil,
+		[]*hcutil.Tx{c.vote})
+	c.disapproveC = newBlock(12, 0, c.b, nil, []*hcutil.Tx{c.vote})
+
+	c.view = blockchain.NewUtxoViewpoint()
+	c.view.AddTxOuts(c.coinbase, 10, 0)
+	c.view.AddTxOuts(c.ticket, 11, 0)
+	return c
+}
+
+// createTestIndex creates the passed index in the passed database.
+func createTestIndex(t *testing.T, db database.DB, idx Indexer) {
+	t.Helper()
+	err := db.Update(func(dbTx database.Tx) error {
+		return idx.Create(dbTx)
+	})
+	if err != nil {
+		t.Fatalf("unable to create %s: %v", idx.Name(), err)
+	}
+}
+
+// connectTestBlock connects the passed block to the passed index through the
+// database.
+func connectTestBlock(t *testing.T, db database.DB, idx Indexer, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) {
+	t.Helper()
+	err := db.Update(func(dbTx database.Tx) error {
+		return idx.ConnectBlock(dbTx, block, parent, view)
+	})
+	if err != nil {
+		t.Fatalf("ConnectBlock(%d): unexpected error: %v",
+			block.Height(), err)
+	}
+}
+
+// disconnectTestBlock disconnects the passed block from the passed index
+// through the database.
+func disconnectTestBlock(t *testing.T, db database.DB, idx Indexer, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) {
+	t.Helper()
+	err := db.Update(func(dbTx database.Tx) error {
+		return idx.DisconnectBlock(dbTx, block, parent, view)
+	})
+	if err != nil {
+		t.Fatalf("DisconnectBlock(%d): unexpected error: %v",
+			block.Height(), err)
+	}
+}
+
+// indexSnapshot returns all of the entries of the passed index.
+func indexSnapshot(t *testing.T, db database.DB, idx Indexer) map[string]string {
+	t.Helper()
+	snapshot := make(map[string]string)
+	err := db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(idx.Key())
+		return bucket.ForEach(func(k, v []byte) error {
+			snapshot[string(k)] = string(v)
+			return nil
+		})
+	})
+	if err != nil {
+		t.Fatalf("unable to load %s entries: %v", idx.Name(), err)
+	}
+	return snapshot
+}
+
+// TestAddrUtxoIndexConnectDisconnect ensures the unspent outputs, balances, and
+// deltas of the address utxo index are updated as blocks are connected through
+// the database, that disapproved regular trees are not indexed, and that
+// disconnecting blocks restores the previous state.
+func TestAddrUtxoIndexConnectDisconnect(t *testing.T) {
+	db, teardown := newTestDB(t)
+	defer teardown()
+
+	c := newIndexTestChain(t)
+	idx := NewAddrUtxoIndex(db, &chaincfg.SimNetParams)
+	createTestIndex(t, db, idx)
+
+	type balance struct {
+		addr              hcutil.Address
+		balance, received int64
+	}
+	checkBalances := func(desc string, want []balance) {
+		t.Helper()
+		for _, w := range want {
+			balance, received, err := idx.Balance(w.addr)
+			if err != nil {
+				t.Fatalf("%s: Balance(%v): unexpected error: %v",
+					desc, w.addr, err)
+			}
+			if balance != w.balance || received != w.received {
+				t.Fatalf("%s: Balance(%v): got balance %d, "+
+					"received %d - want balance %d, "+
+					"received %d", desc, w.addr, balance,
+					received, w.balance, w.received)
+			}
+		}
+	}
+	checkUtxos := func(desc string, addr hcutil.Address, want []*AddrUtxo) {
+		t.Helper()
+		utxos, err := idx.UtxosForAddress(addr, 0, 10)
+		if err != nil {
+			t.Fatalf("%s: UtxosForAddress(%v): unexpected error: %v",
+				desc, addr, err)
+		}
+		if !reflect.DeepEqual(utxos, want) {
+			t.Fatalf("%s: UtxosForAddress(%v): got %+v, want %+v",
+				desc, addr, utxos, want)
+		}
+	}
+	newUtxo := func(tx *hcutil.Tx, index uint32, tree int8, height int64) *AddrUtxo {
+		txOut := tx.MsgTx().TxOut[index]
+		return &AddrUtxo{
+			Hash:          *tx.Hash(),
+			Index:         index,
+			Tree:          tree,
+			Amount:        txOut.Value,
+			Height:        height,
+			ScriptVersion: txOut.Version,
+			PkScript:      txOut.PkScript,
+		}
+	}
+
+	connectTestBlock(t, db, idx, c.a, c.parent, c.view)
+	connectTestBlock(t, db, idx, c.b, c.a, c.view)
+	checkBalances("after b", []balance{
+		{c.addrA, 100, 100},
+		{c.addrB, 0, 0},
+		{c.addrC, 50, 100},
+	})
+	checkUtxos("after b", c.addrA, []*AddrUtxo{
+		newUtxo(c.coinbase, 0, wire.TxTreeRegular, 10),
+	})
+	checkUtxos("after b", c.addrC, []*AddrUtxo{
+		newUtxo(c.ticket, 0, wire.TxTreeStake, 11),
+	})
+	snapshotB := indexSnapshot(t, db, idx)
+
+	// Connecting the block approving b applies its regular tree.
+	connectTestBlock(t, db, idx, c.approveC, c.b, c.view)
+	checkBalances("after approving c", []balance{
+		{c.addrA, 30, 130},
+		{c.addrB, 70, 70},
+		{c.addrC, 55, 155},
+	})
+	checkUtxos("after approving c", c.addrA, []*AddrUtxo{
+		newUtxo(c.spend, 1, wire.TxTreeRegular, 11),
+	})
+	checkUtxos("after approving c", c.addrB, []*AddrUtxo{
+		newUtxo(c.spend, 0, wire.TxTreeRegular, 11),
+	})
+	checkUtxos("after approving c", c.addrC, []*AddrUtxo{
+		newUtxo(c.vote, 2, wire.TxTreeStake, 12),
+	})
+
+	// Ensure the vote input is recorded with its actual input index which
+	// follows the stakebase.
+	deltas, err := idx.DeltasForAddress(c.addrC, 0, 10, false)
+	if err != nil {
+		t.Fatalf("DeltasForAddress: unexpected error: %v", err)
+	}
+	wantDeltas := []*AddrDelta{
+		{*c.coinbase.Hash(), 1, false, wire.TxTreeRegular, 50, 10},
+		{*c.ticket.Hash(), 0, false, wire.TxTreeStake, 50, 11},
+		{*c.ticket.Hash(), 0, true, wire.TxTreeStake, -50, 11},
+		{*c.vote.Hash(), 2, false, wire.TxTreeStake, 55, 12},
+		{*c.vote.Hash(), 1, true, wire.TxTreeStake, -50, 12},
+	}
+	if len(deltas) != len(wantDeltas) {
+		t.Fatalf("DeltasForAddress: got %d deltas, want %d",
+			len(deltas), len(wantDeltas))
+	}
+	for i, delta := range deltas {
+		if !reflect.DeepEqual(delta, wantDeltas[i]) {
+			t.Fatalf("DeltasForAddress #%d: got %+v, want %+v", i,
+				*delta, *wantDeltas[i])
+		}
+	}
+
+	disconnectTestBlock(t, db, idx, c.approveC, c.b, c.view)
+	if snapshot := indexSnapshot(t, db, idx); !reflect.DeepEqual(snapshot, snapshotB) {
+		t.Fatal("disconnecting the block approving b did not restore " +
+			"the previous state")
+	}
+
+	// Connecting the block disapproving b only applies its own stake tree.
+	connectTestBlock(t, db, idx, c.disapproveC, c.b, c.view)
+	checkBalances("after disapproving c", []balance{
+		{c.addrA, 100, 100},
+		{c.addrB, 0, 0},
+		{c.addrC, 55, 155},
+	})
+	checkUtxos("after disapproving c", c.addrA, []*AddrUtxo{
+		newUtxo(c.coinbase, 0, wire.TxTreeRegular, 10),
+	})
+	checkUtxos("after disapproving c", c.addrB, nil)
+
+	disconnectTestBlock(t, db, idx, c.disapproveC, c.b, c.view)
+	if snapshot := indexSnapshot(t, db, idx); !reflect.DeepEqual(snapshot, snapshotB) {
+		t.Fatal("disconnecting the block disapproving b did not " +
+			"restore the previous state")
+	}
+
+	disconnectTestBlock(t, db, idx, c.b, c.a, c.view)
+	disconnectTestBlock(t, db, idx, c.a, c.parent, c.view)
+	if snapshot := indexSnapshot(t, db, idx); len(snapshot) != 0 {
+		t.Fatalf("index is not empty after disconnecting all blocks: "+
+			"%d entries", len(snapshot))
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/hcutil/gcs"
+	"github.com/HcashOrg/hcd/hcutil/gcs/blockcf"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+const (
+	// cfIndexName is the human-readable name for the index.
+	cfIndexName = "committed filter index"
+)
+
+var (
+	// cfIndexKey is the key of the committed filter index and the db
+	// bucket used to house the regular filters keyed by block hash.
+	cfIndexKey = []byte("cf0byhashidx")
+
+	// cfHeaderIndexBucketName is the name of the db bucket used to house
+	// the regular filter headers keyed by block hash.
+	cfHeaderIndexBucketName = []byte("cf0headerbyhashidx")
+)
+
+// -----------------------------------------------------------------------------
+// The committed filter index consists of a Golomb-coded set filter for every
+// block in the main chain along with a filter header which commits to the
+// filter and the filter header of the previous block.  The filter headers form
+// a chain which allows light clients to verify the filters served by multiple
+// peers agree without downloading all of them.
+//
+// There are two buckets used in total.  The first bucket maps the hash of each
+// block to its serialized filter and the second maps the hash of each block to
+// its filter header.
+//
+// The serialized format for keys and values in the filter bucket is:
+//   <block hash> = <N><filter data>
+//
+//   Field           Type              Size
+//   block hash      chainhash.Hash    32 bytes
+//   N               uint32            4 bytes
+//   filter data     []byte            variable
+//
+// The serialized format for keys and values in the filter header bucket is:
+//   <block hash> = <filter header>
+//
+//   Field           Type              Size
+//   block hash      chainhash.Hash    32 bytes
+//   filter header   chainhash.Hash    32 bytes
+//   -----
+//   Total: 64 bytes
+// -----------------------------------------------------------------------------
+
+// dbFetchFilterHeader retrieves the filter header for the block with the
+// passed hash from the index.  nil is returned when there is no entry.
+func dbFetchFilterHeader(dbTx database.Tx, blockHash *chainhash.Hash) []byte {
+	bucket := dbTx.Metadata().Bucket(cfHeaderIndexBucketName)
+	return bucket.Get(blockHash[:])
+}
+
+// dbStoreFilter stores the passed filter and its header for the block with the
+// passed hash, chaining the header onto the passed previous filter header.
+func dbStoreFilter(dbTx database.Tx, blockHash *chainhash.Hash, filter *gcs.Filter, prevHeader *chainhash.Hash) error {
+	meta := dbTx.Metadata()
+	err := meta.Bucket(cfIndexKey).Put(blockHash[:], filter.NBytes())
+	if err != nil {
+		return err
+	}
+
+	header := gcs.MakeHeaderForFilter(filter, prevHeader)
+	return meta.Bucket(cfHeaderIndexBucketName).Put(blockHash[:], header[:])
+}
+
+// dbRemoveFilter removes the filter and filter header for the block with the
+// passed hash from the index.
+func dbRemoveFilter(dbTx database.Tx, blockHash *chainhash.Hash) error {
+	meta := dbTx.Metadata()
+	if err := meta.Bucket(cfIndexKey).Delete(blockHash[:]); err != nil {
+		return err
+	}
+
+	return meta.Bucket(cfHeaderIndexBucketName).Delete(blockHash[:])
+}
+
+// CfIndex implements a committed filter (cf) by hash index.
+type CfIndex struct {
+	db          database.DB
+	chainParams *chaincfg.Params
+}
+
+// Ensure the CfIndex type implements the Indexer interface.
+var _ Indexer = (*CfIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) Key() []byte {
+	return cfIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) Name() string {
+	return cfIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs to be
+// created for the first time.  It creates the buckets for the filters and
+// filter headers and stores the filter for the genesis block since it is never
+// connected.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) Create(dbTx database.Tx) error {
+	meta := dbTx.Metadata()
+	if _, err := meta.CreateBucket(cfIndexKey); err != nil {
+		return err
+	}
+	if _, err := meta.CreateBucket(cfHeaderIndexBucketName); err != nil {
+		return err
+	}
+
+	genesis := idx.chainParams.GenesisBlock
+	filter, err := blockcf.Regular(genesis)
+	if err != nil {
+		return err
+	}
+	genesisHash := genesis.BlockHash()
+	return dbStoreFilter(dbTx, &genesisHash, filter, &chainhash.Hash{})
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds the filter for the block
+// along with its filter header.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) ConnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	msgBlock := block.MsgBlock()
+	filter, err := blockcf.Regular(msgBlock)
+	if err != nil {
+		return err
+	}
+
+	prevHash := &msgBlock.Header.PrevBlock
+	serializedPrevHeader := dbFetchFilterHeader(dbTx, prevHash)
+	if serializedPrevHeader == nil {
+		return AssertError(fmt.Sprintf("missing filter header for "+
+			"block %v", prevHash))
+	}
+	var prevHeader chainhash.Hash
+	copy(prevHeader[:], serializedPrevHeader)
+
+	return dbStoreFilter(dbTx, block.Hash(), filter, &prevHeader)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the filter and
+// filter header for the block.
+//
+// This is part of the Indexer interface.
+func (idx *CfIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	return dbRemoveFilter(dbTx, block.Hash())
+}
+
+// checkFilterType returns an error when the passed filter type is not
+// maintained by the index.
+func checkFilterType(filterType wire.FilterType) error {
+	if filterType != wire.GCSFilterRegular {
+		return fmt.Errorf("unsupported filter type %v", filterType)
+	}
+	return nil
+}
+
+// FilterByBlockHash returns the serialized contents of a block's committed
+// filter of the given type.  The returned filter is nil when there is no entry
+// for the provided hash.
+//
+// This function is safe for concurrent access.
+func (idx *CfIndex) FilterByBlockHash(hash *chainhash.Hash, filterType wire.FilterType) ([]byte, error) {
+	if err := checkFilterType(filterType); err != nil {
+		return nil, err
+	}
+
+	var filter []byte
+	err := idx.db.View(func(dbTx database.Tx) error {
+		serialized := dbTx.Metadata().Bucket(cfIndexKey).Get(hash[:])
+		if serialized != nil {
+			filter = make([]byte, len(serialized))
+			copy(filter, serialized)
+		}
+		return nil
+	})
+	return filter, err
+}
+
+// FilterHeaderByBlockHash returns the serialized contents of a block's
+// committed filter header of the given type.  The returned header is nil when
+// there is no entry for the provided hash.
+//
+// This function is safe for concurrent access.
+func (idx *CfIndex) FilterHeaderByBlockHash(hash *chainhash.Hash, filterType wire.FilterType) ([]byte, error) {
+	if err := checkFilterType(filterType); err != nil {
+		return nil, err
+	}
+
+	var header []byte
+	err := idx.db.View(func(dbTx database.Tx) error {
+		serialized := dbFetchFilterHeader(dbTx, hash)
+		if serialized != nil {
+			header = make([]byte, len(serialized))
+			copy(header, serialized)
+		}
+		return nil
+	})
+	return header, err
+}
+
+// NewCfIndex returns a new instance of an indexer that is used to create a
+// mapping of the hashes of all blocks in the blockchain to their respective
+// committed filters and filter headers.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewCfIndex(db database.DB, chainParams *chaincfg.Params) *CfIndex {
+	return &CfIndex{db: db, chainParams: chainParams}
+}
+
+// dropCfHeaderIndex drops the internal filter header index.
+func dropCfHeaderIndex(db database.DB) error {
+	return db.Update(func(dbTx database.Tx) error {
+		return dbTx.Metadata().DeleteBucket(cfHeaderIndexBucketName)
+	})
+}
+
+// DropCfIndex drops the committed filter index from the provided database if
+// it exists.
+func DropCfIndex(db database.DB) error {
+	return dropIndex(db, cfIndexKey, cfIndexName)
+}
@@ -224,6 +224,35 @@ func (m *Manager) maybeCreateIndexes(dbTx database.Tx) error {
 	return nil
 }
 
+// IndexesBehind returns the enabled indexes whose tip is at or below the passed
+// height.  Indexes that do not exist yet are treated as being at the genesis
+// block since that is where they start when they are created.
+//
+// This is used to detect indexes which would need the data of pruned blocks to
+// catch up before calling Init.
+func (m *Manager) IndexesBehind(height int64) ([]Indexer, error) {
+	var behind []Indexer
+	err := m.db.View(func(dbTx database.Tx) error {
+		indexesBucket := dbTx.Metadata().Bucket(indexTipsBucketName)
+		for _, indexer := range m.enabledIndexes {
+			var tipHeight int32
+			idxKey := indexer.Key()
+			if indexesBucket != nil && indexesBucket.Get(idxKey) != nil {
+				var err error
+				_, tipHeight, err = dbFetchIndexerTip(dbTx, idxKey)
+				if err != nil {
+					return err
+				}
+			}
+			if int64(tipHeight) <= height {
+				behind = append(behind, indexer)
+			}
+		}
+		return nil
+	})
+	return behind, err
+}
+
 // Init initializes the enabled indexes.  This is called during chain
 // initialization and primarily consists of catching up all indexes to the
 // current best chain tip.  This is necessary since each index can be disabled
@@ -688,11 +717,18 @@ func dropIndex(db database.DB, idxKey []byte, idxName string) error {
 		}
 	}
 
-	// Call extra index specific deinitialization for the transaction index.
-	if idxName == txIndexName {
+	// Call extra index specific deinitialization for the transaction index
+	// and the committed filter index.
+	switch idxName {
+	case txIndexName:
 		if err := dropBlockIDIndex(db); err != nil {
 			return err
 		}
+
+	case cfIndexName:
+		if err := dropCfHeaderIndex(db); err != nil {
+			return err
+		}
 	}
 
 	// Remove the index tip, index bucket, and in-progress drop flag now
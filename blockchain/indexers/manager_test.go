@@ -0,0 +1,84 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	_ "github.com/HcashOrg/hcd/database/ffldb"
+)
+
+// newTestDB returns a new database in a temporary directory along with a
+// teardown function which closes and removes it.
+func newTestDB(t *testing.T) (database.DB, func()) {
+	dbPath, err := ioutil.TempDir("", "indexers")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := database.Create("ffldb", dbPath, chaincfg.SimNetParams.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("unable to create db: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+	return db, teardown
+}
+
+// TestIndexesBehind ensures the indexes which don't exist yet or whose tip is
+// at or below the passed height are reported as behind.
+func TestIndexesBehind(t *testing.T) {
+	db, teardown := newTestDB(t)
+	defer teardown()
+
+	params := &chaincfg.SimNetParams
+	existsAddrIndex := NewExistsAddrIndex(db, params)
+	cfIndex := NewCfIndex(db, params)
+	m := NewManager(db, []Indexer{existsAddrIndex, cfIndex}, params)
+
+	checkBehind := func(height int64, want []Indexer) {
+		t.Helper()
+		behind, err := m.IndexesBehind(height)
+		if err != nil {
+			t.Fatalf("IndexesBehind(%d): %v", height, err)
+		}
+		if !reflect.DeepEqual(behind, want) {
+			t.Fatalf("IndexesBehind(%d): got %d indexes, want %d",
+				height, len(behind), len(want))
+		}
+	}
+
+	// Indexes that don't exist yet start at the genesis block.
+	checkBehind(-1, nil)
+	checkBehind(0, []Indexer{existsAddrIndex, cfIndex})
+
+	err := db.Update(func(dbTx database.Tx) error {
+		_, err := dbTx.Metadata().CreateBucket(indexTipsBucketName)
+		if err != nil {
+			return err
+		}
+		var hash chainhash.Hash
+		err = dbPutIndexerTip(dbTx, existsAddrIndex.Key(), &hash, 100)
+		if err != nil {
+			return err
+		}
+		return dbPutIndexerTip(dbTx, cfIndex.Key(), &hash, 50)
+	})
+	if err != nil {
+		t.Fatalf("unable to store index tips: %v", err)
+	}
+
+	checkBehind(49, nil)
+	checkBehind(50, []Indexer{cfIndex})
+	checkBehind(100, []Indexer{existsAddrIndex, cfIndex})
+}
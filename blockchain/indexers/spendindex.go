@@ -0,0 +1,214 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+const (
+	// spendIndexName is the human-readable name for the index.
+	spendIndexName = "spend index"
+
+	// spendKeySize is the size of the key of a spend entry.  It consists
+	// of the tx hash + output index of the spent outpoint.
+	spendKeySize = chainhash.HashSize + 4
+
+	// spendValueSize is the size of the value of a spend entry.  It
+	// consists of the spending tx hash + input index + block height.
+	spendValueSize = chainhash.HashSize + 4 + 4
+)
+
+var (
+	// spendIndexKey is the key of the spend index and the db bucket used
+	// to house it.
+	spendIndexKey = []byte("spendidx")
+)
+
+// -----------------------------------------------------------------------------
+// The spend index maps every outpoint spent in the main chain to the
+// transaction input which spends it.  Like the other indexes, the regular
+// transactions of a block are indexed once the next block approves them and
+// disapproved regular trees are never indexed.
+//
+// The serialized format for spend entries is:
+//
+//   <tx hash><output index> = <spending tx hash><input index><block height>
+//
+//   Field              Type              Size
+//   tx hash            chainhash.Hash    32 bytes
+//   output index       uint32            4 bytes
+//   spending tx hash   chainhash.Hash    32 bytes
+//   input index        uint32            4 bytes
+//   block height       uint32            4 bytes
+// -----------------------------------------------------------------------------
+
+// SpendInfo describes the transaction input which spends an outpoint along with
+// the height of the block which contains it.
+type SpendInfo struct {
+	Hash   chainhash.Hash
+	Index  uint32
+	Height int64
+}
+
+// spendKey returns the key of the spend entry for the passed outpoint.
+func spendKey(outpoint *wire.OutPoint) []byte {
+	key := make([]byte, spendKeySize)
+	copy(key, outpoint.Hash[:])
+	byteOrder.PutUint32(key[chainhash.HashSize:], outpoint.Index)
+	return key
+}
+
+// serializeSpendInfo returns the value of the spend entry for the passed spend
+// information.
+func serializeSpendInfo(info *SpendInfo) []byte {
+	serialized := make([]byte, spendValueSize)
+	copy(serialized, info.Hash[:])
+	byteOrder.PutUint32(serialized[chainhash.HashSize:], info.Index)
+	byteOrder.PutUint32(serialized[chainhash.HashSize+4:],
+		uint32(info.Height))
+	return serialized
+}
+
+// deserializeSpendInfo decodes the passed spend entry value.
+func deserializeSpendInfo(serialized []byte) (*SpendInfo, error) {
+	if len(serialized) != spendValueSize {
+		return nil, errDeserialize("unexpected spend entry length")
+	}
+
+	var info SpendInfo
+	copy(info.Hash[:], serialized[:chainhash.HashSize])
+	info.Index = byteOrder.Uint32(serialized[chainhash.HashSize:])
+	info.Height = int64(byteOrder.Uint32(serialized[chainhash.HashSize+4:]))
+	return &info, nil
+}
+
+// SpendIndex implements a spent outpoint to spending transaction index.
+type SpendIndex struct {
+	db database.DB
+}
+
+// Ensure the SpendIndex type implements the Indexer interface.
+var _ Indexer = (*SpendIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Key() []byte {
+	return spendIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Name() string {
+	return spendIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the spend
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(spendIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer adds a mapping for every outpoint
+// spent by the transactions applied by the block.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) ConnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	for _, itx := range indexedTxns(block, parent) {
+		msgTx := itx.tx.MsgTx()
+		for i := firstSpentInput(&itx); i < len(msgTx.TxIn); i++ {
+			info := SpendInfo{
+				Hash:   *itx.tx.Hash(),
+				Index:  uint32(i),
+				Height: itx.height,
+			}
+			err := bucket.Put(spendKey(&msgTx.TxIn[i].PreviousOutPoint),
+				serializeSpendInfo(&info))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the mappings for the
+// outpoints spent by the transactions applied by the block.
+//
+// This is part of the Indexer interface.
+func (idx *SpendIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(spendIndexKey)
+	for _, itx := range indexedTxns(block, parent) {
+		msgTx := itx.tx.MsgTx()
+		for i := firstSpentInput(&itx); i < len(msgTx.TxIn); i++ {
+			err := bucket.Delete(spendKey(&msgTx.TxIn[i].PreviousOutPoint))
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// SpendingTx returns the transaction input which spends the passed outpoint in
+// the main chain.  Nil is returned when the outpoint has not been spent or does
+// not exist.
+//
+// This function is safe for concurrent access.
+func (idx *SpendIndex) SpendingTx(outpoint *wire.OutPoint) (*SpendInfo, error) {
+	var info *SpendInfo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(spendIndexKey)
+		serialized := bucket.Get(spendKey(outpoint))
+		if serialized == nil {
+			return nil
+		}
+		var err error
+		info, err = deserializeSpendInfo(serialized)
+		return err
+	})
+	return info, err
+}
+
+// NewSpendIndex returns a new instance of an indexer that is used to create a
+// mapping of the outpoints spent in the main chain to the transactions which
+// spend them.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewSpendIndex(db database.DB) *SpendIndex {
+	return &SpendIndex{db: db}
+}
+
+// DropSpendIndex drops the spend index from the provided database if it
+// exists.
+func DropSpendIndex(db database.DB) error {
+	return dropIndex(db, spendIndexKey, spendIndexName)
+}
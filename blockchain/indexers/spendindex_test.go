@@ -0,0 +1,126 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestSpendInfoSerialization ensures spend entries round trip through their
+// serialized form and malformed entries are rejected.
+func TestSpendInfoSerialization(t *testing.T) {
+	t.Parallel()
+
+	info := &SpendInfo{
+		Hash:   chainhash.Hash{0x01, 0x02, 0x03},
+		Index:  7,
+		Height: 500000,
+	}
+	serialized := serializeSpendInfo(info)
+	if len(serialized) != spendValueSize {
+		t.Fatalf("serializeSpendInfo: unexpected length - got %d, "+
+			"want %d", len(serialized), spendValueSize)
+	}
+	got, err := deserializeSpendInfo(serialized)
+	if err != nil {
+		t.Fatalf("deserializeSpendInfo: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(got, info) {
+		t.Fatalf("deserializeSpendInfo: mismatched info - got %+v, "+
+			"want %+v", got, info)
+	}
+
+	_, err = deserializeSpendInfo(serialized[:spendValueSize-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("deserializeSpendInfo: did not reject truncated "+
+			"entry - got %v", err)
+	}
+}
+
+// TestSpendIndexConnectDisconnect ensures the spend index records the inputs
+// spending outpoints, including the ticket input of votes, as blocks are
+// connected through the database, that disapproved regular trees are not
+// indexed, and that disconnecting blocks removes the records.
+func TestSpendIndexConnectDisconnect(t *testing.T) {
+	db, teardown := newTestDB(t)
+	defer teardown()
+
+	c := newIndexTestChain(t)
+	idx := NewSpendIndex(db)
+	createTestIndex(t, db, idx)
+
+	type spend struct {
+		outpoint *wire.OutPoint
+		want     *SpendInfo
+	}
+	checkSpends := func(desc string, spends []spend) {
+		t.Helper()
+		for _, s := range spends {
+			info, err := idx.SpendingTx(s.outpoint)
+			if err != nil {
+				t.Fatalf("%s: SpendingTx(%v): unexpected error: %v",
+					desc, s.outpoint, err)
+			}
+			if !reflect.DeepEqual(info, s.want) {
+				t.Fatalf("%s: SpendingTx(%v): got %+v, want %+v",
+					desc, s.outpoint, info, s.want)
+			}
+		}
+	}
+	coinbaseOut0 := wire.NewOutPoint(c.coinbase.Hash(), 0, wire.TxTreeRegular)
+	coinbaseOut1 := wire.NewOutPoint(c.coinbase.Hash(), 1, wire.TxTreeRegular)
+	ticketOut := wire.NewOutPoint(c.ticket.Hash(), 0, wire.TxTreeStake)
+	nullOut := wire.NewOutPoint(&chainhash.Hash{}, wire.MaxPrevOutIndex,
+		wire.TxTreeRegular)
+	spendInfo := &SpendInfo{Hash: *c.spend.Hash(), Index: 0, Height: 11}
+	ticketInfo := &SpendInfo{Hash: *c.ticket.Hash(), Index: 0, Height: 11}
+	voteInfo := &SpendInfo{Hash: *c.vote.Hash(), Index: 1, Height: 12}
+
+	connectTestBlock(t, db, idx, c.a, c.parent, c.view)
+	connectTestBlock(t, db, idx, c.b, c.a, c.view)
+	checkSpends("after b", []spend{
+		{coinbaseOut0, nil},
+		{coinbaseOut1, ticketInfo},
+		{ticketOut, nil},
+	})
+
+	// Connecting the block approving b applies its regular tree.  The null
+	// inputs of coinbases and stakebases must never be recorded.
+	connectTestBlock(t, db, idx, c.approveC, c.b, c.view)
+	checkSpends("after approving c", []spend{
+		{coinbaseOut0, spendInfo},
+		{coinbaseOut1, ticketInfo},
+		{ticketOut, voteInfo},
+		{nullOut, nil},
+	})
+
+	disconnectTestBlock(t, db, idx, c.approveC, c.b, c.view)
+	checkSpends("after disconnecting approving c", []spend{
+		{coinbaseOut0, nil},
+		{coinbaseOut1, ticketInfo},
+		{ticketOut, nil},
+	})
+
+	// Connecting the block disapproving b only applies its own stake tree.
+	connectTestBlock(t, db, idx, c.disapproveC, c.b, c.view)
+	checkSpends("after disapproving c", []spend{
+		{coinbaseOut0, nil},
+		{coinbaseOut1, ticketInfo},
+		{ticketOut, voteInfo},
+		{nullOut, nil},
+	})
+
+	disconnectTestBlock(t, db, idx, c.disapproveC, c.b, c.view)
+	disconnectTestBlock(t, db, idx, c.b, c.a, c.view)
+	disconnectTestBlock(t, db, idx, c.a, c.parent, c.view)
+	if snapshot := indexSnapshot(t, db, idx); len(snapshot) != 0 {
+		t.Fatalf("index is not empty after disconnecting all blocks: "+
+			"%d entries", len(snapshot))
+	}
+}
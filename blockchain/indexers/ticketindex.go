@@ -0,0 +1,597 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+)
+
+const (
+	// ticketIndexName is the human-readable name for the index.
+	ticketIndexName = "ticket history index"
+
+	// The following prefixes identify the type of each record stored in
+	// the ticket index bucket.
+	ticketPrefixHistory = 't'
+	ticketPrefixPool    = 'p'
+
+	// ticketHistoryKeySize is the size of the key of a ticket history
+	// entry.  It consists of the prefix + ticket hash.
+	ticketHistoryKeySize = 1 + chainhash.HashSize
+
+	// ticketChangeSize is the size of each status change recorded in a
+	// ticket history entry.  It consists of the status + block height.
+	ticketChangeSize = 1 + 4
+
+	// ticketPoolKeySize is the size of the key of a ticket pool entry.  It
+	// consists of the prefix + block height.
+	ticketPoolKeySize = 1 + 4
+
+	// ticketPoolValueMinSize is the size of the value of a ticket pool
+	// entry excluding the changes made by the block.  It consists of the
+	// live tickets + live value + immature tickets + immature value + voted
+	// + missed + expired + revoked tickets.
+	ticketPoolValueMinSize = 4 + 8 + 4 + 8 + 4 + 4 + 4 + 4
+
+	// ticketPoolChangeSize is the size of each change recorded in a ticket
+	// pool entry.  It consists of the ticket hash + status.
+	ticketPoolChangeSize = chainhash.HashSize + 1
+)
+
+var (
+	// ticketIndexKey is the key of the ticket index and the db bucket used
+	// to house it.
+	ticketIndexKey = []byte("tickethistidx")
+)
+
+// -----------------------------------------------------------------------------
+// The ticket history index records every change of the status of every ticket
+// in the main chain along with the height of the block which made it, and the
+// state of the ticket pool after every block.  Tickets are immature once they
+// are purchased and become live when they mature.  Live tickets are then voted,
+// missed when they are selected but don't vote or expire, and missed and
+// expired tickets are eventually revoked.
+//
+// All changes except the purchases are taken from the undo data the stake
+// database keeps for every block.  Since the stake transactions of a block are
+// always applied, unlike the regular transactions, the changes are indexed
+// with the block that makes them.
+//
+// All records are stored in a single bucket and distinguished by a one byte
+// prefix so the entire index can be dropped like any other index.
+//
+// The serialized format for ticket history entries is:
+//
+//   't'<ticket hash> = <price>[<status><block height>,...]
+//
+//   Field           Type              Size
+//   ticket hash     chainhash.Hash    32 bytes
+//   price           int64             8 bytes
+//   status          TicketStatus      1 byte
+//   block height    uint32            4 bytes
+//
+// The serialized format for ticket pool entries is:
+//
+//   'p'<block height> = <live><live value><immature><immature value><voted>
+//                       <missed><expired><revoked>[<ticket hash><status>,...]
+//
+//   Field           Type              Size
+//   block height    uint32 (BE)       4 bytes
+//   live            uint32            4 bytes
+//   live value      int64             8 bytes
+//   immature        uint32            4 bytes
+//   immature value  int64             8 bytes
+//   voted           uint32            4 bytes
+//   missed          uint32            4 bytes
+//   expired         uint32            4 bytes
+//   revoked         uint32            4 bytes
+//   ticket hash     chainhash.Hash    32 bytes
+//   status          TicketStatus      1 byte
+//
+// The voted, missed, expired and revoked fields count all tickets which have
+// had the status up to the block.  The changes made by the block are recorded
+// in the order they were applied so they can be undone when the block is
+// disconnected.
+// -----------------------------------------------------------------------------
+
+// TicketStatus describes the status of a ticket.
+type TicketStatus byte
+
+// These constants define the statuses of tickets.
+const (
+	// TicketImmature is the status of tickets which have been purchased
+	// but have not matured yet.
+	TicketImmature TicketStatus = iota
+
+	// TicketLive is the status of tickets which may be selected to vote.
+	TicketLive
+
+	// TicketVoted is the status of tickets which have voted.
+	TicketVoted
+
+	// TicketMissed is the status of tickets which were selected to vote
+	// but did not vote.
+	TicketMissed
+
+	// TicketExpired is the status of tickets which were never selected to
+	// vote before they expired.
+	TicketExpired
+
+	// TicketRevoked is the status of missed or expired tickets which have
+	// been revoked.
+	TicketRevoked
+)
+
+// ticketStatusStrings is a map of ticket statuses back to their constant names
+// for pretty printing.
+var ticketStatusStrings = map[TicketStatus]string{
+	TicketImmature: "immature",
+	TicketLive:     "live",
+	TicketVoted:    "voted",
+	TicketMissed:   "missed",
+	TicketExpired:  "expired",
+	TicketRevoked:  "revoked",
+}
+
+// String returns the TicketStatus in human-readable form.
+func (s TicketStatus) String() string {
+	if str, ok := ticketStatusStrings[s]; ok {
+		return str
+	}
+	return fmt.Sprintf("unknown ticket status (%d)", byte(s))
+}
+
+// TicketStatusChange describes a change of the status of a ticket along with
+// the height of the block which made it.
+type TicketStatusChange struct {
+	Status TicketStatus
+	Height int64
+}
+
+// TicketHistory describes the price of a ticket along with all changes of its
+// status in the main chain in the order they were made.
+type TicketHistory struct {
+	Price   int64
+	Changes []TicketStatusChange
+}
+
+// TicketChange describes a ticket along with its new status.
+type TicketChange struct {
+	Ticket chainhash.Hash
+	Status TicketStatus
+}
+
+// TicketPoolInfo describes the state of the ticket pool after a block along
+// with the changes of the statuses of tickets made by the block.
+type TicketPoolInfo struct {
+	Live          uint32
+	LiveValue     int64
+	Immature      uint32
+	ImmatureValue int64
+	Voted         uint32
+	Missed        uint32
+	Expired       uint32
+	Revoked       uint32
+	Changes       []TicketChange
+}
+
+// ticketHistoryKey returns the key of the ticket history entry for the passed
+// ticket.
+func ticketHistoryKey(ticket *chainhash.Hash) []byte {
+	key := make([]byte, ticketHistoryKeySize)
+	key[0] = ticketPrefixHistory
+	copy(key[1:], ticket[:])
+	return key
+}
+
+// serializeTicketHistory returns the value of the ticket history entry for the
+// passed history.
+func serializeTicketHistory(history *TicketHistory) []byte {
+	serialized := make([]byte, 8+len(history.Changes)*ticketChangeSize)
+	byteOrder.PutUint64(serialized, uint64(history.Price))
+	offset := 8
+	for _, change := range history.Changes {
+		serialized[offset] = byte(change.Status)
+		byteOrder.PutUint32(serialized[offset+1:], uint32(change.Height))
+		offset += ticketChangeSize
+	}
+	return serialized
+}
+
+// deserializeTicketHistory decodes the passed ticket history entry value.
+func deserializeTicketHistory(serialized []byte) (*TicketHistory, error) {
+	if len(serialized) < 8 ||
+		(len(serialized)-8)%ticketChangeSize != 0 {
+
+		return nil, errDeserialize("unexpected ticket history entry " +
+			"length")
+	}
+
+	numChanges := (len(serialized) - 8) / ticketChangeSize
+	history := TicketHistory{
+		Price:   int64(byteOrder.Uint64(serialized)),
+		Changes: make([]TicketStatusChange, 0, numChanges),
+	}
+	for offset := 8; offset < len(serialized); offset += ticketChangeSize {
+		status := TicketStatus(serialized[offset])
+		if status > TicketRevoked {
+			return nil, errDeserialize(fmt.Sprintf("unknown ticket "+
+				"status %d", status))
+		}
+		history.Changes = append(history.Changes, TicketStatusChange{
+			Status: status,
+			Height: int64(byteOrder.Uint32(serialized[offset+1:])),
+		})
+	}
+	return &history, nil
+}
+
+// ticketPoolKey returns the key of the ticket pool entry for the passed block
+// height.
+func ticketPoolKey(height int64) []byte {
+	key := make([]byte, ticketPoolKeySize)
+	key[0] = ticketPrefixPool
+	binary.BigEndian.PutUint32(key[1:], uint32(height))
+	return key
+}
+
+// serializeTicketPoolInfo returns the value of the ticket pool entry for the
+// passed ticket pool information.
+func serializeTicketPoolInfo(info *TicketPoolInfo) []byte {
+	serialized := make([]byte, ticketPoolValueMinSize+
+		len(info.Changes)*ticketPoolChangeSize)
+	byteOrder.PutUint32(serialized[0:], info.Live)
+	byteOrder.PutUint64(serialized[4:], uint64(info.LiveValue))
+	byteOrder.PutUint32(serialized[12:], info.Immature)
+	byteOrder.PutUint64(serialized[16:], uint64(info.ImmatureValue))
+	byteOrder.PutUint32(serialized[24:], info.Voted)
+	byteOrder.PutUint32(serialized[28:], info.Missed)
+	byteOrder.PutUint32(serialized[32:], info.Expired)
+	byteOrder.PutUint32(serialized[36:], info.Revoked)
+	offset := ticketPoolValueMinSize
+	for _, change := range info.Changes {
+		copy(serialized[offset:], change.Ticket[:])
+		serialized[offset+chainhash.HashSize] = byte(change.Status)
+		offset += ticketPoolChangeSize
+	}
+	return serialized
+}
+
+// deserializeTicketPoolInfo decodes the passed ticket pool entry value.
+func deserializeTicketPoolInfo(serialized []byte) (*TicketPoolInfo, error) {
+	if len(serialized) < ticketPoolValueMinSize ||
+		(len(serialized)-ticketPoolValueMinSize)%ticketPoolChangeSize != 0 {
+
+		return nil, errDeserialize("unexpected ticket pool entry length")
+	}
+
+	numChanges := (len(serialized) - ticketPoolValueMinSize) /
+		ticketPoolChangeSize
+	info := TicketPoolInfo{
+		Live:          byteOrder.Uint32(serialized[0:]),
+		LiveValue:     int64(byteOrder.Uint64(serialized[4:])),
+		Immature:      byteOrder.Uint32(serialized[12:]),
+		ImmatureValue: int64(byteOrder.Uint64(serialized[16:])),
+		Voted:         byteOrder.Uint32(serialized[24:]),
+		Missed:        byteOrder.Uint32(serialized[28:]),
+		Expired:       byteOrder.Uint32(serialized[32:]),
+		Revoked:       byteOrder.Uint32(serialized[36:]),
+		Changes:       make([]TicketChange, numChanges),
+	}
+	offset := ticketPoolValueMinSize
+	for i := range info.Changes {
+		change := &info.Changes[i]
+		copy(change.Ticket[:], serialized[offset:])
+		change.Status = TicketStatus(serialized[offset+chainhash.HashSize])
+		if change.Status > TicketRevoked {
+			return nil, errDeserialize(fmt.Sprintf("unknown ticket "+
+				"status %d", change.Status))
+		}
+		offset += ticketPoolChangeSize
+	}
+	return &info, nil
+}
+
+// undoTicketStatus returns the status a ticket changed to according to the
+// passed flags of its stake undo data.
+func undoTicketStatus(missed, revoked, spent, expired bool) TicketStatus {
+	switch {
+	case spent:
+		return TicketVoted
+	case revoked:
+		return TicketRevoked
+	case missed && expired:
+		return TicketExpired
+	case missed:
+		return TicketMissed
+	}
+	return TicketLive
+}
+
+// applyTicketPoolChange updates the passed ticket pool information with the
+// passed new status of a ticket with the passed price.
+func applyTicketPoolChange(info *TicketPoolInfo, status TicketStatus, price int64) {
+	switch status {
+	case TicketImmature:
+		info.Immature++
+		info.ImmatureValue += price
+	case TicketLive:
+		info.Immature--
+		info.ImmatureValue -= price
+		info.Live++
+		info.LiveValue += price
+	case TicketVoted, TicketMissed, TicketExpired:
+		info.Live--
+		info.LiveValue -= price
+		switch status {
+		case TicketVoted:
+			info.Voted++
+		case TicketMissed:
+			info.Missed++
+		case TicketExpired:
+			info.Expired++
+		}
+	case TicketRevoked:
+		info.Revoked++
+	}
+}
+
+// dbConnectTicketChanges records the passed changes of the statuses of tickets
+// made by the block at the passed height along with the resulting state of the
+// ticket pool.  The prices of purchased tickets are taken from the passed map.
+func dbConnectTicketChanges(bucket internalBucket, height int64, changes []TicketChange, prices map[chainhash.Hash]int64) error {
+	// The state of the ticket pool is based on the state after the previous
+	// block, which is empty for the genesis block.
+	var info TicketPoolInfo
+	if serialized := bucket.Get(ticketPoolKey(height - 1)); serialized != nil {
+		prev, err := deserializeTicketPoolInfo(serialized)
+		if err != nil {
+			return err
+		}
+		info = *prev
+	}
+	info.Changes = changes
+
+	for _, change := range changes {
+		key := ticketHistoryKey(&change.Ticket)
+		history := &TicketHistory{Price: prices[change.Ticket]}
+		if change.Status != TicketImmature {
+			serialized := bucket.Get(key)
+			if serialized == nil {
+				log.Warnf("Missing history of ticket %v while "+
+					"indexing block at height %d", change.Ticket,
+					height)
+			} else {
+				var err error
+				history, err = deserializeTicketHistory(serialized)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		history.Changes = append(history.Changes, TicketStatusChange{
+			Status: change.Status,
+			Height: height,
+		})
+		applyTicketPoolChange(&info, change.Status, history.Price)
+
+		err := bucket.Put(key, serializeTicketHistory(history))
+		if err != nil {
+			return err
+		}
+	}
+
+	return bucket.Put(ticketPoolKey(height), serializeTicketPoolInfo(&info))
+}
+
+// dbDisconnectTicketChanges removes the changes of the statuses of tickets made
+// by the block at the passed height along with the state of the ticket pool
+// after it.
+func dbDisconnectTicketChanges(bucket internalBucket, height int64) error {
+	poolKey := ticketPoolKey(height)
+	serialized := bucket.Get(poolKey)
+	if serialized == nil {
+		return AssertError(fmt.Sprintf("missing ticket pool entry for "+
+			"height %d", height))
+	}
+	info, err := deserializeTicketPoolInfo(serialized)
+	if err != nil {
+		return err
+	}
+
+	// Remove the changes in reverse order so each of them is the last
+	// change of its ticket.
+	for i := len(info.Changes) - 1; i >= 0; i-- {
+		change := &info.Changes[i]
+		key := ticketHistoryKey(&change.Ticket)
+		serialized := bucket.Get(key)
+		if serialized == nil {
+			log.Warnf("Missing history of ticket %v while removing "+
+				"block at height %d", change.Ticket, height)
+			continue
+		}
+		history, err := deserializeTicketHistory(serialized)
+		if err != nil {
+			return err
+		}
+		last := len(history.Changes) - 1
+		if last < 0 || history.Changes[last].Status != change.Status ||
+			history.Changes[last].Height != height {
+
+			return AssertError(fmt.Sprintf("last change of ticket "+
+				"%v is not its change to %v at height %d",
+				change.Ticket, change.Status, height))
+		}
+
+		history.Changes = history.Changes[:last]
+		if len(history.Changes) == 0 {
+			err = bucket.Delete(key)
+		} else {
+			err = bucket.Put(key, serializeTicketHistory(history))
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return bucket.Delete(poolKey)
+}
+
+// TicketIndex implements a ticket history and ticket pool state index.
+type TicketIndex struct {
+	db database.DB
+}
+
+// Ensure the TicketIndex type implements the Indexer interface.
+var _ Indexer = (*TicketIndex)(nil)
+
+// Init is only provided to satisfy the Indexer interface as there is nothing to
+// initialize for this index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Init() error {
+	// Nothing to do.
+	return nil
+}
+
+// Key returns the database key to use for the index as a byte slice.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Key() []byte {
+	return ticketIndexKey
+}
+
+// Name returns the human-readable name of the index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Name() string {
+	return ticketIndexName
+}
+
+// Create is invoked when the indexer manager determines the index needs
+// to be created for the first time.  It creates the bucket for the ticket
+// index.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) Create(dbTx database.Tx) error {
+	_, err := dbTx.Metadata().CreateBucket(ticketIndexKey)
+	return err
+}
+
+// ConnectBlock is invoked by the index manager when a new block has been
+// connected to the main chain.  This indexer records the changes of the
+// statuses of tickets made by the block and the resulting state of the ticket
+// pool.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) ConnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	// The stake database has already been updated with the block, so its
+	// undo data describes the changes made by the block.
+	height := block.Height()
+	undo, err := stake.FetchBlockUndoData(dbTx, uint32(height))
+	if err != nil {
+		return err
+	}
+
+	changes := make([]TicketChange, 0, len(undo)+
+		int(block.MsgBlock().Header.FreshStake))
+	for _, utd := range undo {
+		changes = append(changes, TicketChange{
+			Ticket: utd.TicketHash,
+			Status: undoTicketStatus(utd.Missed, utd.Revoked,
+				utd.Spent, utd.Expired),
+		})
+	}
+	prices := make(map[chainhash.Hash]int64)
+	for _, stx := range block.STransactions() {
+		msgTx := stx.MsgTx()
+		if isSStx, _ := stake.IsSStx(msgTx); !isSStx {
+			continue
+		}
+		changes = append(changes, TicketChange{
+			Ticket: *stx.Hash(),
+			Status: TicketImmature,
+		})
+		prices[*stx.Hash()] = msgTx.TxOut[0].Value
+	}
+
+	bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+	return dbConnectTicketChanges(bucket, height, changes, prices)
+}
+
+// DisconnectBlock is invoked by the index manager when a block has been
+// disconnected from the main chain.  This indexer removes the changes of the
+// statuses of tickets made by the block and the state of the ticket pool after
+// it.
+//
+// This is part of the Indexer interface.
+func (idx *TicketIndex) DisconnectBlock(dbTx database.Tx, block, parent *hcutil.Block, view *blockchain.UtxoViewpoint) error {
+	bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+	return dbDisconnectTicketChanges(bucket, block.Height())
+}
+
+// TicketHistory returns the price of the passed ticket along with the changes
+// of its status in the main chain.  Nil is returned when the ticket has never
+// been purchased in the main chain.
+//
+// This function is safe for concurrent access.
+func (idx *TicketIndex) TicketHistory(ticket *chainhash.Hash) (*TicketHistory, error) {
+	var history *TicketHistory
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+		serialized := bucket.Get(ticketHistoryKey(ticket))
+		if serialized == nil {
+			return nil
+		}
+		var err error
+		history, err = deserializeTicketHistory(serialized)
+		return err
+	})
+	return history, err
+}
+
+// TicketPoolInfo returns the state of the ticket pool after the main chain
+// block at the passed height along with the changes of the statuses of tickets
+// made by the block.  Nil is returned when the block has not been indexed.
+//
+// This function is safe for concurrent access.
+func (idx *TicketIndex) TicketPoolInfo(height int64) (*TicketPoolInfo, error) {
+	var info *TicketPoolInfo
+	err := idx.db.View(func(dbTx database.Tx) error {
+		bucket := dbTx.Metadata().Bucket(ticketIndexKey)
+		serialized := bucket.Get(ticketPoolKey(height))
+		if serialized == nil {
+			return nil
+		}
+		var err error
+		info, err = deserializeTicketPoolInfo(serialized)
+		return err
+	})
+	return info, err
+}
+
+// NewTicketIndex returns a new instance of an indexer that is used to create a
+// history of the statuses of all tickets and the state of the ticket pool after
+// every block in the main chain.
+//
+// It implements the Indexer interface which plugs into the IndexManager that in
+// turn is used by the blockchain package.  This allows the index to be
+// seamlessly maintained along with the chain.
+func NewTicketIndex(db database.DB) *TicketIndex {
+	return &TicketIndex{db: db}
+}
+
+// DropTicketIndex drops the ticket history index from the provided database if
+// it exists.
+func DropTicketIndex(db database.DB) error {
+	return dropIndex(db, ticketIndexKey, ticketIndexName)
+}
@@ -0,0 +1,200 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package indexers
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+// TestTicketIndexSerialization ensures the ticket history and ticket pool
+// entries round trip through their serialized forms and malformed entries are
+// rejected.
+func TestTicketIndexSerialization(t *testing.T) {
+	t.Parallel()
+
+	history := &TicketHistory{
+		Price: 2e8,
+		Changes: []TicketStatusChange{
+			{Status: TicketImmature, Height: 100},
+			{Status: TicketLive, Height: 356},
+			{Status: TicketMissed, Height: 400},
+			{Status: TicketRevoked, Height: 402},
+		},
+	}
+	serialized := serializeTicketHistory(history)
+	gotHistory, err := deserializeTicketHistory(serialized)
+	if err != nil {
+		t.Fatalf("deserializeTicketHistory: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotHistory, history) {
+		t.Fatalf("deserializeTicketHistory: mismatched history - got "+
+			"%+v, want %+v", gotHistory, history)
+	}
+	_, err = deserializeTicketHistory(serialized[:len(serialized)-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("deserializeTicketHistory: did not reject truncated "+
+			"entry - got %v", err)
+	}
+
+	info := &TicketPoolInfo{
+		Live:          40960,
+		LiveValue:     8e12,
+		Immature:      1280,
+		ImmatureValue: 3e11,
+		Voted:         100000,
+		Missed:        500,
+		Expired:       20,
+		Revoked:       510,
+		Changes: []TicketChange{
+			{Ticket: chainhash.Hash{0x01}, Status: TicketVoted},
+			{Ticket: chainhash.Hash{0x02}, Status: TicketImmature},
+		},
+	}
+	serialized = serializeTicketPoolInfo(info)
+	gotInfo, err := deserializeTicketPoolInfo(serialized)
+	if err != nil {
+		t.Fatalf("deserializeTicketPoolInfo: unexpected error: %v", err)
+	}
+	if !reflect.DeepEqual(gotInfo, info) {
+		t.Fatalf("deserializeTicketPoolInfo: mismatched info - got "+
+			"%+v, want %+v", gotInfo, info)
+	}
+	_, err = deserializeTicketPoolInfo(serialized[:len(serialized)-1])
+	if !isDeserializeErr(err) {
+		t.Fatalf("deserializeTicketPoolInfo: did not reject truncated "+
+			"entry - got %v", err)
+	}
+}
+
+// TestTicketIndexChanges ensures the ticket index tracks the statuses of
+// tickets and the state of the ticket pool as blocks are connected and that
+// disconnecting all blocks removes every entry.
+func TestTicketIndexChanges(t *testing.T) {
+	t.Parallel()
+
+	bucket := make(mapBucket)
+	voted := chainhash.Hash{0x01}
+	missed := chainhash.Hash{0x02}
+	expired := chainhash.Hash{0x03}
+	prices := map[chainhash.Hash]int64{voted: 1e8, missed: 2e8, expired: 4e8}
+
+	blocks := []struct {
+		height  int64
+		changes []TicketChange
+		want    TicketPoolInfo
+	}{{
+		height: 1,
+		changes: []TicketChange{
+			{Ticket: voted, Status: TicketImmature},
+			{Ticket: missed, Status: TicketImmature},
+		},
+		want: TicketPoolInfo{Immature: 2, ImmatureValue: 3e8},
+	}, {
+		height:  2,
+		changes: []TicketChange{{Ticket: expired, Status: TicketImmature}},
+		want:    TicketPoolInfo{Immature: 3, ImmatureValue: 7e8},
+	}, {
+		height: 3,
+		changes: []TicketChange{
+			{Ticket: voted, Status: TicketLive},
+			{Ticket: missed, Status: TicketLive},
+		},
+		want: TicketPoolInfo{Live: 2, LiveValue: 3e8, Immature: 1,
+			ImmatureValue: 4e8},
+	}, {
+		height:  4,
+		changes: []TicketChange{{Ticket: expired, Status: TicketLive}},
+		want:    TicketPoolInfo{Live: 3, LiveValue: 7e8},
+	}, {
+		height: 5,
+		changes: []TicketChange{
+			{Ticket: voted, Status: TicketVoted},
+			{Ticket: missed, Status: TicketMissed},
+		},
+		want: TicketPoolInfo{Live: 1, LiveValue: 4e8, Voted: 1,
+			Missed: 1},
+	}, {
+		height: 6,
+		changes: []TicketChange{
+			{Ticket: missed, Status: TicketRevoked},
+			{Ticket: expired, Status: TicketExpired},
+		},
+		want: TicketPoolInfo{Voted: 1, Missed: 1, Expired: 1,
+			Revoked: 1},
+	}, {
+		height:  7,
+		changes: []TicketChange{{Ticket: expired, Status: TicketRevoked}},
+		want: TicketPoolInfo{Voted: 1, Missed: 1, Expired: 1,
+			Revoked: 2},
+	}}
+
+	for _, block := range blocks {
+		err := dbConnectTicketChanges(bucket, block.height,
+			block.changes, prices)
+		if err != nil {
+			t.Fatalf("dbConnectTicketChanges(%d): unexpected error: "+
+				"%v", block.height, err)
+		}
+		info, err := deserializeTicketPoolInfo(bucket.Get(
+			ticketPoolKey(block.height)))
+		if err != nil {
+			t.Fatalf("deserializeTicketPoolInfo(%d): unexpected "+
+				"error: %v", block.height, err)
+		}
+		block.want.Changes = block.changes
+		if !reflect.DeepEqual(*info, block.want) {
+			t.Fatalf("height %d: mismatched pool info - got %+v, "+
+				"want %+v", block.height, *info, block.want)
+		}
+	}
+
+	wantHistories := map[chainhash.Hash]TicketHistory{
+		voted: {Price: 1e8, Changes: []TicketStatusChange{
+			{TicketImmature, 1}, {TicketLive, 3}, {TicketVoted, 5},
+		}},
+		missed: {Price: 2e8, Changes: []TicketStatusChange{
+			{TicketImmature, 1}, {TicketLive, 3}, {TicketMissed, 5},
+			{TicketRevoked, 6},
+		}},
+		expired: {Price: 4e8, Changes: []TicketStatusChange{
+			{TicketImmature, 2}, {TicketLive, 4}, {TicketExpired, 6},
+			{TicketRevoked, 7},
+		}},
+	}
+	for ticket, want := range wantHistories {
+		history, err := deserializeTicketHistory(bucket.Get(
+			ticketHistoryKey(&ticket)))
+		if err != nil {
+			t.Fatalf("deserializeTicketHistory(%v): unexpected "+
+				"error: %v", ticket, err)
+		}
+		if !reflect.DeepEqual(*history, want) {
+			t.Fatalf("ticket %v: mismatched history - got %+v, "+
+				"want %+v", ticket, *history, want)
+		}
+	}
+
+	// Ensure disconnecting a block which is not the last one fails since
+	// its changes are no longer the last changes of their tickets.
+	if err := dbDisconnectTicketChanges(bucket, 6); err == nil {
+		t.Fatal("dbDisconnectTicketChanges: did not fail to " +
+			"disconnect block before the tip")
+	}
+
+	for i := len(blocks) - 1; i >= 0; i-- {
+		err := dbDisconnectTicketChanges(bucket, blocks[i].height)
+		if err != nil {
+			t.Fatalf("dbDisconnectTicketChanges(%d): unexpected "+
+				"error: %v", blocks[i].height, err)
+		}
+	}
+	if len(bucket) != 0 {
+		t.Fatalf("bucket is not empty after disconnecting all blocks: "+
+			"%d entries", len(bucket))
+	}
+}
@@ -36,4 +36,16 @@ var (
 	// UtxoSetBucketName is the name of the db bucket used to house the
 	// unspent transaction output set.
 	UtxoSetBucketName = []byte("utxoset")
+
+	// UtxoSetStateKeyName is the name of the db key used to store the block
+	// up to which the utxo set in the database is consistent.
+	UtxoSetStateKeyName = []byte("utxosetstate")
+
+	// PrunedVotesBucketName is the name of the db bucket used to house the
+	// votes of blocks whose data has been pruned.
+	PrunedVotesBucketName = []byte("prunedvotes")
+
+	// PruneHeightKeyName is the name of the db key used to store the height
+	// of the most recent block whose data has been pruned.
+	PruneHeightKeyName = []byte("pruneheight")
 )
@@ -37,6 +37,12 @@ const (
 	// without modifying the current state.
 	BFDryRun
 
+	// BFAssumeValid may be set to indicate the block is an ancestor of the
+	// assumed valid block on the best known header chain, so the expensive
+	// script validation is skipped while all of the other checks are still
+	// performed.
+	BFAssumeValid
+
 	// BFNone is a convenience value to specifically indicate no flags.
 	BFNone BehaviorFlags = 0
 )
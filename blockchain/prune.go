@@ -2,6 +2,10 @@ package blockchain
 
 import (
 	"time"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
 )
 
 // pruningIntervalInMinutes is the interval in which to prune the blockchain's
@@ -37,3 +41,85 @@ func (c *chainPruner) pruneChainIfNeeded() {
 	c.lastNodeInsertTime = now
 	c.chain.pruneStakeNodes()
 }
+
+// minPruneRetainBlocks is the number of the most recent main chain blocks whose
+// data is always retained when block data pruning is enabled.  It matches the
+// maximum reorganization depth so the blocks needed to disconnect from the main
+// chain are always available.
+const minPruneRetainBlocks = maxSearchDepth
+
+// maybePruneBlocks removes the data for the oldest blocks from the database
+// when block data pruning is enabled and the stored block data exceeds the
+// target size.  The data for the most recent minPruneRetainBlocks main chain
+// blocks is always retained.  The votes of each pruned block are stored so its
+// block node can still be reconstructed from the header.
+//
+// The height of the most recent pruned main chain block after pruning is
+// returned.
+//
+// This function MUST be called with the chain state lock held (for writes).
+func (b *BlockChain) maybePruneBlocks(dbTx database.Tx, node *blockNode) (int64, error) {
+	pruneHeight := b.pruneHeight
+	if b.pruneTarget == 0 || node.height <= minPruneRetainBlocks {
+		return pruneHeight, nil
+	}
+
+	// The data for the blocks after the block up to which the utxo set in
+	// the database is consistent is also retained since it is needed to
+	// bring the utxo set up to date after an unclean shutdown.
+	keepHeight := node.height - minPruneRetainBlocks
+	if flushedHeight := b.utxoCache.flushedHeight(); flushedHeight < keepHeight {
+		keepHeight = flushedHeight
+	}
+	keepHash, err := dbFetchHashByHeight(dbTx, keepHeight)
+	if err != nil {
+		return pruneHeight, err
+	}
+
+	newPruneHeight := pruneHeight
+	err = dbTx.PruneBlocks(b.pruneTarget, keepHash, func(hash *chainhash.Hash, blockBytes []byte) error {
+		block, err := hcutil.NewBlockFromBytes(blockBytes)
+		if err != nil {
+			return err
+		}
+		err = dbPutPrunedVotes(dbTx, hash, voteBitsInBlock(block))
+		if err != nil {
+			return err
+		}
+
+		height := block.Height()
+		if height > newPruneHeight && dbMainChainHasBlock(dbTx, hash) {
+			newPruneHeight = height
+		}
+		return nil
+	})
+	if err != nil {
+		return pruneHeight, err
+	}
+	if newPruneHeight == pruneHeight {
+		return pruneHeight, nil
+	}
+
+	log.Infof("Pruned block data up to height %d", newPruneHeight)
+	if err := dbPutPruneHeight(dbTx, newPruneHeight); err != nil {
+		return pruneHeight, err
+	}
+	return newPruneHeight, nil
+}
+
+// PruneHeight returns the height of the most recent main chain block whose data
+// has been pruned.  A height of -1 is returned when no block data has been
+// pruned.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) PruneHeight() int64 {
+	b.chainLock.RLock()
+	pruneHeight := b.pruneHeight
+	b.chainLock.RUnlock()
+	return pruneHeight
+}
+
+// DBFetchPruneHeight is the exported version of dbFetchPruneHeight.
+func DBFetchPruneHeight(dbTx database.Tx) int64 {
+	return dbFetchPruneHeight(dbTx)
+}
@@ -162,6 +162,34 @@ func FindTicketIdxs(size int, n uint16, prng *Hash256PRNG) ([]int, error) {
 	return findTicketIdxs(size, n, prng)
 }
 
+// ReplayLottery reproduces the selection of the tickets which may vote on the
+// children of a block, which is otherwise only performed when its stake node is
+// connected.  The PRNG is seeded with the passed serialized header of the block
+// and selects n tickets from the passed live tickets after the block, which
+// must be sorted by their hashes like the keys of the live ticket treap.
+//
+// The indexes of the winning tickets in the passed slice are returned in the
+// order they were selected along with the final state of the lottery, which the
+// headers of the children commit to.
+func ReplayLottery(header []byte, liveTickets []chainhash.Hash, n uint16) ([]int, [6]byte, error) {
+	var finalState [6]byte
+	prng := NewHash256PRNG(header)
+	idxs, err := findTicketIdxs(len(liveTickets), n, prng)
+	if err != nil {
+		return nil, finalState, err
+	}
+
+	stateBuffer := make([]byte, 0, (int(n)+1)*chainhash.HashSize)
+	for _, idx := range idxs {
+		stateBuffer = append(stateBuffer, liveTickets[idx][:]...)
+	}
+	lastHash := prng.StateHash()
+	stateBuffer = append(stateBuffer, lastHash[:]...)
+	copy(finalState[:], chainhash.HashB(stateBuffer)[0:6])
+
+	return idxs, finalState, nil
+}
+
 // fetchWinners is a ticket database specific function which iterates over the
 // entire treap and finds winners at selected indexes.  These are returned
 // as a slice of pointers to keys, which can be recast as []*chainhash.Hash.
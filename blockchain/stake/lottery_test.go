@@ -14,7 +14,9 @@ import (
 	"testing"
 
 	"github.com/HcashOrg/hcd/blockchain/stake/internal/tickettreap"
+	"github.com/HcashOrg/hcd/chaincfg"
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/wire"
 )
 
 func TestBasicPRNG(t *testing.T) {
@@ -173,6 +175,71 @@ func TestFetchWinnersErrors(t *testing.T) {
 	}
 }
 
+// TestReplayLottery ensures replaying the lottery of a block with the live
+// tickets after it reproduces the winners and final state of its stake node.
+func TestReplayLottery(t *testing.T) {
+	params := &chaincfg.SimNetParams
+	node := genesisNode(params)
+	node.height = uint32(params.StakeValidationHeight - 2)
+
+	// Connect blocks which purchase new tickets and spend all of the
+	// winners selected by their parent, starting with the block before
+	// stake validation height where the first winners are selected.
+	for i := 0; i < 5; i++ {
+		newTickets := make([]chainhash.Hash, 0, 200)
+		for j := 0; j < cap(newTickets); j++ {
+			h := chainhash.HashH([]byte{byte(i), byte(j)})
+			newTickets = append(newTickets, h)
+		}
+		header := wire.BlockHeader{
+			Height: node.height + 1,
+			Nonce:  uint32(i),
+		}
+		var err error
+		node, err = connectNode(node, header, node.Winners(), nil,
+			newTickets)
+		if err != nil {
+			t.Fatalf("connectNode: unexpected error: %v", err)
+		}
+
+		hB, err := header.Bytes()
+		if err != nil {
+			t.Fatalf("header.Bytes: unexpected error: %v", err)
+		}
+		liveTickets := node.LiveTickets()
+		idxs, finalState, err := ReplayLottery(hB, liveTickets,
+			params.TicketsPerBlock)
+		if err != nil {
+			t.Fatalf("ReplayLottery: unexpected error: %v", err)
+		}
+		winners := node.Winners()
+		if len(idxs) != len(winners) {
+			t.Fatalf("ReplayLottery: height %d: got %d winners, want "+
+				"%d", node.Height(), len(idxs), len(winners))
+		}
+		for j, idx := range idxs {
+			if liveTickets[idx] != winners[j] {
+				t.Fatalf("ReplayLottery: height %d: winner %d is "+
+					"%v, want %v", node.Height(), j,
+					liveTickets[idx], winners[j])
+			}
+		}
+		if finalState != node.FinalState() {
+			t.Fatalf("ReplayLottery: height %d: mismatched final "+
+				"state - got %x, want %x", node.Height(),
+				finalState, node.FinalState())
+		}
+	}
+
+	// Not enough live tickets.
+	hB := chainhash.HashB([]byte{0x01, 0x02})
+	_, _, err := ReplayLottery(hB, node.LiveTickets()[:4],
+		params.TicketsPerBlock)
+	if err == nil {
+		t.Errorf("Expected list size too small error")
+	}
+}
+
 func TestTicketSorting(t *testing.T) {
 	ticketsPerBlock := 5
 	ticketPoolSize := uint16(8192)
@@ -1051,3 +1051,11 @@ func WriteDisconnectedBestNode(dbTx database.Tx, node *Node, hash chainhash.Hash
 		NextWinners: nextWinners,
 	})
 }
+
+// FetchBlockUndoData returns the undo data of the main chain block at the passed
+// height from the database.  The undo data describes all changes the block made
+// to the state of the tickets, so it may be used to track the state of tickets
+// over time.
+func FetchBlockUndoData(dbTx database.Tx, height uint32) (UndoTicketDataSlice, error) {
+	return ticketdb.DbFetchBlockUndoData(dbTx, height)
+}
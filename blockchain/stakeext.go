@@ -7,8 +7,10 @@
 package blockchain
 
 import (
+	"fmt"
+
+	"github.com/HcashOrg/hcd/blockchain/stake"
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
-	"github.com/HcashOrg/hcd/database"
 	"github.com/HcashOrg/hcd/hcutil"
 	"github.com/HcashOrg/hcd/txscript"
 )
@@ -68,6 +70,124 @@ func (b *BlockChain) LotteryDataForBlock(hash *chainhash.Hash) ([]chainhash.Hash
 	return b.lotteryDataForBlock(hash)
 }
 
+// LotteryWinner describes a ticket which was selected to vote on a block.
+type LotteryWinner struct {
+	// Ticket is the hash of the ticket.
+	Ticket chainhash.Hash
+
+	// Index is the index of the ticket in the live tickets sorted by their
+	// hashes.
+	Index int
+
+	// Voted is whether the block contains a vote of the ticket.
+	Voted bool
+}
+
+// LotteryReplay describes the reproduced ticket lottery which selected the
+// tickets that may vote on a block.
+type LotteryReplay struct {
+	// PoolSize is the number of live tickets the winners were selected
+	// from.
+	PoolSize int
+
+	// FinalState is the final state of the lottery.
+	FinalState [6]byte
+
+	// Winners are the selected tickets in the order they were selected.
+	Winners []LotteryWinner
+
+	// UnselectedVotes are the tickets voted by the block which were not
+	// selected.
+	UnselectedVotes []chainhash.Hash
+}
+
+// ReplayLottery reproduces the ticket lottery which selected the tickets that
+// may vote on the block with the passed hash, including side chain blocks.  The
+// lottery is performed independently of the winners cached by the stake node
+// of the parent by seeding the PRNG with the serialized header of the parent
+// and selecting the winners from its live tickets.  The pool size and final
+// state of the replay are expected to match the header of the block and the
+// winners are compared against the votes in the block.
+//
+// The stake node of the parent is regenerated when it is not cached, which is
+// expensive for blocks far away from the best block.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) ReplayLottery(hash *chainhash.Hash) (*LotteryReplay, error) {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	node, exists := b.index[*hash]
+	if !exists {
+		var err error
+		node, err = b.findNode(hash, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if node.height < b.chainParams.StakeValidationHeight {
+		return nil, fmt.Errorf("block %v at height %d precedes the "+
+			"stake validation height %d and has no winning tickets",
+			hash, node.height, b.chainParams.StakeValidationHeight)
+	}
+	parent, err := b.getPrevNodeFromNode(node)
+	if err != nil {
+		return nil, err
+	}
+	parentStakeNode, err := b.fetchStakeNode(parent)
+	if err != nil {
+		return nil, err
+	}
+	block, err := b.fetchBlockByHash(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	// The live tickets of a stake node are sorted by their hashes.
+	liveTickets := parentStakeNode.LiveTickets()
+	header, err := parent.header.Bytes()
+	if err != nil {
+		return nil, err
+	}
+	idxs, finalState, err := stake.ReplayLottery(header, liveTickets,
+		b.chainParams.TicketsPerBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	var votes []chainhash.Hash
+	for _, stx := range block.MsgBlock().STransactions {
+		if isSSGen, _ := stake.IsSSGen(stx); isSSGen {
+			votes = append(votes, stx.TxIn[1].PreviousOutPoint.Hash)
+		}
+	}
+
+	replay := &LotteryReplay{
+		PoolSize:   len(liveTickets),
+		FinalState: finalState,
+		Winners:    make([]LotteryWinner, 0, len(idxs)),
+	}
+	selected := make(map[chainhash.Hash]int, len(idxs))
+	for i, idx := range idxs {
+		selected[liveTickets[idx]] = i
+		replay.Winners = append(replay.Winners, LotteryWinner{
+			Ticket: liveTickets[idx],
+			Index:  idx,
+		})
+	}
+	for _, ticket := range votes {
+		i, ok := selected[ticket]
+		if !ok {
+			replay.UnselectedVotes = append(replay.UnselectedVotes,
+				ticket)
+			continue
+		}
+		replay.Winners[i].Voted = true
+	}
+
+	return replay, nil
+}
+
 // LiveTickets returns all currently live tickets from the stake database.
 //
 // This function is NOT safe for concurrent access.
@@ -102,27 +222,21 @@ func (b *BlockChain) TicketsWithAddress(address hcutil.Address) ([]chainhash.Has
 	tickets := sn.LiveTickets()
 
 	var ticketsWithAddr []chainhash.Hash
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range tickets {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			_, addrs, _, err :=
-				txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion,
-					utxo.PkScriptByIndex(0), b.chainParams)
-			if err != nil {
-				return err
-			}
-			if addrs[0].EncodeAddress() == address.EncodeAddress() {
-				ticketsWithAddr = append(ticketsWithAddr, hash)
-			}
+	for _, hash := range tickets {
+		utxo, err := b.utxoCache.fetchEntry(&hash)
+		if err != nil {
+			return nil, err
+		}
+
+		_, addrs, _, err :=
+			txscript.ExtractPkScriptAddrs(txscript.DefaultScriptVersion,
+				utxo.PkScriptByIndex(0), b.chainParams)
+		if err != nil {
+			return nil, err
+		}
+		if addrs[0].EncodeAddress() == address.EncodeAddress() {
+			ticketsWithAddr = append(ticketsWithAddr, hash)
 		}
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
 	return ticketsWithAddr, nil
@@ -232,19 +346,13 @@ func (b *BlockChain) TicketPoolValue() (hcutil.Amount, error) {
 	b.chainLock.RUnlock()
 
 	var amt int64
-	err := b.db.View(func(dbTx database.Tx) error {
-		for _, hash := range sn.LiveTickets() {
-			utxo, err := dbFetchUtxoEntry(dbTx, &hash)
-			if err != nil {
-				return err
-			}
-
-			amt += utxo.sparseOutputs[0].amount
+	for _, hash := range sn.LiveTickets() {
+		utxo, err := b.utxoCache.fetchEntry(&hash)
+		if err != nil {
+			return 0, err
 		}
-		return nil
-	})
-	if err != nil {
-		return 0, err
+
+		amt += utxo.sparseOutputs[0].amount
 	}
 	return hcutil.Amount(amt), nil
 }
@@ -0,0 +1,417 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/hcutil"
+)
+
+const (
+	// utxoCacheFlushInterval is the maximum amount of time the modified
+	// entries of the utxo cache are kept in memory before they are written
+	// to the database.
+	utxoCacheFlushInterval = 2 * time.Minute
+
+	// utxoEntryOverhead is the approximate number of bytes of memory used
+	// by a utxo entry in the cache excluding its outputs and stake extra
+	// data.  It accounts for the map entry in the cache, the entry itself
+	// and its map of outputs.
+	utxoEntryOverhead = 200
+
+	// utxoOutputOverhead is the approximate number of bytes of memory used
+	// by an output of a utxo entry excluding its public key script.
+	utxoOutputOverhead = 72
+)
+
+// memSize returns the approximate number of bytes of memory used by the entry
+// when it is stored in the utxo cache.
+func (entry *UtxoEntry) memSize() uint64 {
+	size := uint64(utxoEntryOverhead + len(entry.stakeExtra))
+	for _, output := range entry.sparseOutputs {
+		size += uint64(utxoOutputOverhead + len(output.pkScript))
+	}
+	return size
+}
+
+// utxoCache is a memory-bounded cache of the utxo set that sits between the
+// utxo viewpoints and the database.  The entries of the connected blocks are
+// only written to the database in batches, so the utxo set in the database is
+// generally behind the best chain state.  The block up to which the utxo set in
+// the database is consistent is stored along with every batch so it can be
+// brought up to date on start up after an unclean shutdown.
+//
+// The cache houses copies of the entries.  Modified entries are marked as such
+// and are written to the database by the next flush.  Entries which are marked
+// fresh are not stored in the database, so they are simply removed from the
+// cache once they are fully spent.  Fully spent entries which are not fresh are
+// kept until the next flush so they are removed from the database.
+type utxoCache struct {
+	db      database.DB
+	maxSize uint64
+
+	// The following fields are protected by the mutex since the cache is
+	// also populated by lookups which only hold the chain lock for reads.
+	mtx             sync.Mutex
+	entries         map[chainhash.Hash]*UtxoEntry
+	totalSize       uint64
+	lastFlushHash   chainhash.Hash
+	lastFlushHeight int64
+	lastFlushTime   time.Time
+}
+
+// newUtxoCache returns a new empty utxo cache backed by the passed database
+// which holds approximately up to the passed number of bytes of entries.
+func newUtxoCache(db database.DB, maxSize uint64) *utxoCache {
+	return &utxoCache{
+		db:            db,
+		maxSize:       maxSize,
+		entries:       make(map[chainhash.Hash]*UtxoEntry),
+		lastFlushTime: time.Now(),
+	}
+}
+
+// setEntry adds or replaces the cached entry for the passed hash.  A nil entry
+// removes it.
+//
+// This function MUST be called with the cache mutex held.
+func (c *utxoCache) setEntry(hash *chainhash.Hash, entry *UtxoEntry) {
+	if cached, ok := c.entries[*hash]; ok {
+		c.totalSize -= cached.memSize()
+		delete(c.entries, *hash)
+	}
+	if entry != nil {
+		c.entries[*hash] = entry
+		c.totalSize += entry.memSize()
+	}
+}
+
+// fetchEntries adds copies of the entries for the passed set of transaction
+// hashes which are not already in the passed map of entries to it.  Entries
+// which are not in the cache are loaded from the database and added to the
+// cache.  Fully spent transactions, or those which otherwise don't exist,
+// result in a nil entry.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntries(txSet map[chainhash.Hash]struct{}, entries map[chainhash.Hash]*UtxoEntry) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	var missing []chainhash.Hash
+	for hash := range txSet {
+		if _, ok := entries[hash]; ok {
+			continue
+		}
+		if cached, ok := c.entries[hash]; ok {
+			if cached.IsFullySpent() {
+				entries[hash] = nil
+				continue
+			}
+			entries[hash] = cached.Clone()
+			continue
+		}
+		missing = append(missing, hash)
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	return c.db.View(func(dbTx database.Tx) error {
+		for i := range missing {
+			hash := &missing[i]
+			entry, err := dbFetchUtxoEntry(dbTx, hash)
+			if err != nil {
+				return err
+			}
+			if entry != nil {
+				c.setEntry(hash, entry)
+				entry = entry.Clone()
+			}
+			entries[*hash] = entry
+		}
+		return nil
+	})
+}
+
+// fetchEntry returns a copy of the entry for the passed transaction hash or nil
+// when the transaction is fully spent or otherwise doesn't exist.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) fetchEntry(hash *chainhash.Hash) (*UtxoEntry, error) {
+	entries := make(map[chainhash.Hash]*UtxoEntry, 1)
+	err := c.fetchEntries(map[chainhash.Hash]struct{}{*hash: {}}, entries)
+	if err != nil {
+		return nil, err
+	}
+	return entries[*hash], nil
+}
+
+// commit applies the modified entries of the passed view to the cache.  The
+// changes are written to the database by a later flush.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) commit(view *UtxoViewpoint) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	for hashIter, entry := range view.entries {
+		if entry == nil || !entry.modified {
+			continue
+		}
+		hash := hashIter
+
+		// The entry is fresh when it was created by the view and is not
+		// known to the cache or when the cached entry is fresh.
+		cached, inCache := c.entries[hash]
+		fresh := entry.fresh && !inCache
+		if inCache {
+			fresh = cached.fresh
+		}
+
+		// Fully spent fresh entries are not stored in the database, so
+		// there is nothing left to do for them.
+		if entry.IsFullySpent() && fresh {
+			c.setEntry(&hash, nil)
+			continue
+		}
+
+		newEntry := entry.Clone()
+		newEntry.modified = true
+		newEntry.fresh = fresh
+		c.setEntry(&hash, newEntry)
+	}
+}
+
+// needsFlush returns whether the cache should be flushed when the next block is
+// connected either because it exceeds its maximum size or because its modified
+// entries were kept in memory long enough.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) needsFlush() bool {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.totalSize >= c.maxSize ||
+		time.Since(c.lastFlushTime) >= utxoCacheFlushInterval
+}
+
+// dbFlush uses an existing database transaction to write the modified entries
+// of the cache along with the modified entries of the passed view, which take
+// precedence, to the database and to mark the utxo set as consistent with the
+// passed block.  The view may be nil.  The cache itself is not changed until
+// flushed is called once the database transaction has been committed.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) dbFlush(dbTx database.Tx, view *UtxoViewpoint, hash *chainhash.Hash, height int64) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	pending := NewUtxoViewpoint()
+	for txHash, entry := range c.entries {
+		if !entry.modified {
+			continue
+		}
+		if view != nil {
+			if viewEntry, ok := view.entries[txHash]; ok &&
+				viewEntry != nil && viewEntry.modified {
+
+				continue
+			}
+		}
+		pending.entries[txHash] = entry
+	}
+	if err := dbPutUtxoView(dbTx, pending); err != nil {
+		return err
+	}
+	if view != nil {
+		if err := dbPutUtxoView(dbTx, view); err != nil {
+			return err
+		}
+	}
+	return dbPutUtxoSetState(dbTx, hash, height)
+}
+
+// flushed updates the cache after the database transaction of a successful
+// dbFlush with the same view and block has been committed.  All of the entries
+// are evicted when the cache exceeds its maximum size.  Otherwise, they are
+// kept and marked unmodified.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flushed(view *UtxoViewpoint, hash *chainhash.Hash, height int64) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	numFlushed := 0
+	for txHash, entry := range c.entries {
+		if !entry.modified {
+			continue
+		}
+		numFlushed++
+		if entry.IsFullySpent() {
+			hash := txHash
+			c.setEntry(&hash, nil)
+			continue
+		}
+		entry.modified = false
+		entry.fresh = false
+	}
+	if view != nil {
+		for txHashIter, entry := range view.entries {
+			if entry == nil || !entry.modified {
+				continue
+			}
+			numFlushed++
+			txHash := txHashIter
+			if entry.IsFullySpent() {
+				c.setEntry(&txHash, nil)
+				continue
+			}
+			newEntry := entry.Clone()
+			c.setEntry(&txHash, newEntry)
+		}
+	}
+	if c.totalSize > c.maxSize {
+		c.entries = make(map[chainhash.Hash]*UtxoEntry)
+		c.totalSize = 0
+	}
+
+	c.lastFlushHash = *hash
+	c.lastFlushHeight = height
+	c.lastFlushTime = time.Now()
+	log.Debugf("Flushed %d utxo entries at height %d (%d cached entries "+
+		"using %d bytes)", numFlushed, height, len(c.entries),
+		c.totalSize)
+}
+
+// flush writes the modified entries of the cache to the database and marks the
+// utxo set as consistent with the passed block.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flush(hash *chainhash.Hash, height int64) error {
+	err := c.db.Update(func(dbTx database.Tx) error {
+		return c.dbFlush(dbTx, nil, hash, height)
+	})
+	if err != nil {
+		return err
+	}
+	c.flushed(nil, hash, height)
+	return nil
+}
+
+// flushedHeight returns the height of the block up to which the utxo set in the
+// database is consistent.
+//
+// This function is safe for concurrent access.
+func (c *utxoCache) flushedHeight() int64 {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+
+	return c.lastFlushHeight
+}
+
+// initUtxoCache loads the block up to which the utxo set in the database is
+// consistent and brings the utxo set up to date with the best chain state by
+// connecting the transactions of the main chain blocks after it, which is
+// necessary after an unclean shutdown.
+//
+// This function MUST only be called while the chain is being initialized.
+func (b *BlockChain) initUtxoCache() error {
+	var state *utxoSetState
+	err := b.db.View(func(dbTx database.Tx) error {
+		var err error
+		state, err = dbFetchUtxoSetState(dbTx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	// Databases created before the utxo cache was introduced updated the
+	// utxo set along with every block, so it is consistent with the best
+	// chain state.
+	best := b.bestNode
+	if state == nil {
+		return b.utxoCache.flush(&best.hash, best.height)
+	}
+
+	c := b.utxoCache
+	c.lastFlushHash = state.hash
+	c.lastFlushHeight = int64(state.height)
+	if state.hash == best.hash {
+		return nil
+	}
+
+	// The utxo set must be consistent with a block of the main chain since
+	// the cache is always flushed before a block is disconnected.
+	var inMainChain bool
+	err = b.db.View(func(dbTx database.Tx) error {
+		inMainChain = dbMainChainHasBlock(dbTx, &state.hash)
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if !inMainChain || int64(state.height) > best.height {
+		return AssertError(fmt.Sprintf("the utxo set is consistent with "+
+			"block %v (height %d) which is not part of the main "+
+			"chain", state.hash, state.height))
+	}
+
+	log.Infof("Recovering the utxo set from height %d to %d",
+		state.height, best.height)
+	var parent *hcutil.Block
+	for height := int64(state.height) + 1; height <= best.height; height++ {
+		var block *hcutil.Block
+		err := b.db.View(func(dbTx database.Tx) error {
+			var err error
+			if parent == nil {
+				parent, err = dbFetchBlockByHeight(dbTx, height-1)
+				if err != nil {
+					return err
+				}
+			}
+			block, err = dbFetchBlockByHeight(dbTx, height)
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		view := NewUtxoViewpoint()
+		view.SetBestHash(parent.Hash())
+		err = b.connectTransactions(view, block, parent, nil)
+		if err != nil {
+			return err
+		}
+		c.commit(view)
+
+		if c.needsFlush() {
+			err := c.flush(block.Hash(), height)
+			if err != nil {
+				return err
+			}
+		}
+		parent = block
+	}
+
+	return c.flush(&best.hash, best.height)
+}
+
+// FlushUtxoCache writes the modified entries of the utxo cache to the database
+// so the utxo set does not need to be brought up to date on the next start.
+// It is typically called on shutdown.
+//
+// This function is safe for concurrent access.
+func (b *BlockChain) FlushUtxoCache() error {
+	b.chainLock.Lock()
+	defer b.chainLock.Unlock()
+
+	return b.utxoCache.flush(&b.bestNode.hash, b.bestNode.height)
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockchain
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestUtxoCacheCommit ensures committing views to the utxo cache tracks fresh
+// and modified entries as expected.
+func TestUtxoCacheCommit(t *testing.T) {
+	t.Parallel()
+
+	newTx := func(lockTime uint32) *hcutil.Tx {
+		msgTx := wire.NewMsgTx()
+		msgTx.LockTime = lockTime
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil))
+		msgTx.AddTxOut(wire.NewTxOut(1000, []byte{0x51}))
+		msgTx.AddTxOut(wire.NewTxOut(2000, []byte{0x51}))
+		return hcutil.NewTx(msgTx)
+	}
+
+	cache := newUtxoCache(nil, 1<<20)
+
+	// Add the outputs of two transactions.  The entries are created by the
+	// view, so they are fresh.
+	tx1, tx2 := newTx(1), newTx(2)
+	view := NewUtxoViewpoint()
+	view.AddTxOuts(tx1, 1, 0)
+	view.AddTxOuts(tx2, 1, 1)
+	cache.commit(view)
+	view.commit()
+	for _, tx := range []*hcutil.Tx{tx1, tx2} {
+		entry := cache.entries[*tx.Hash()]
+		if entry == nil || !entry.modified || !entry.fresh {
+			t.Fatalf("entry for %v is not fresh and modified: %+v",
+				tx.Hash(), entry)
+		}
+	}
+
+	// Ensure the cache hands out copies of its entries.
+	entry, err := cache.fetchEntry(tx1.Hash())
+	if err != nil {
+		t.Fatalf("fetchEntry: unexpected error: %v", err)
+	}
+	entry.SpendOutput(0)
+	if cache.entries[*tx1.Hash()].IsOutputSpent(0) {
+		t.Fatal("spending an output of a fetched entry modified the cache")
+	}
+
+	// Partially spending a fresh entry keeps it fresh while fully spending
+	// it removes it from the cache since it is not in the database.
+	view.LookupEntry(tx1.Hash()).SpendOutput(0)
+	view.LookupEntry(tx2.Hash()).SpendOutput(0)
+	view.LookupEntry(tx2.Hash()).SpendOutput(1)
+	cache.commit(view)
+	view.commit()
+	if entry := cache.entries[*tx1.Hash()]; entry == nil || !entry.fresh ||
+		!entry.IsOutputSpent(0) || entry.IsOutputSpent(1) {
+
+		t.Fatalf("unexpected entry for partially spent tx: %+v", entry)
+	}
+	if _, ok := cache.entries[*tx2.Hash()]; ok {
+		t.Fatal("fully spent fresh entry is still cached")
+	}
+
+	// Entries which are not fresh are kept once they are fully spent so they
+	// are removed from the database by the next flush.
+	cache.entries[*tx1.Hash()].fresh = false
+	view.LookupEntry(tx1.Hash()).SpendOutput(1)
+	cache.commit(view)
+	if entry := cache.entries[*tx1.Hash()]; entry == nil ||
+		!entry.IsFullySpent() || !entry.modified {
+
+		t.Fatalf("unexpected entry for fully spent tx: %+v", entry)
+	}
+	entry, err = cache.fetchEntry(tx1.Hash())
+	if err != nil || entry != nil {
+		t.Fatalf("fetchEntry: unexpected entry for fully spent tx: "+
+			"%+v (err %v)", entry, err)
+	}
+
+	// Ensure the size accounting matches the cached entries.
+	var wantSize uint64
+	for _, entry := range cache.entries {
+		wantSize += entry.memSize()
+	}
+	if cache.totalSize != wantSize {
+		t.Fatalf("mismatched cache size - got %d, want %d",
+			cache.totalSize, wantSize)
+	}
+}
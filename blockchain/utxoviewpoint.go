@@ -11,7 +11,6 @@ import (
 
 	"github.com/HcashOrg/hcd/blockchain/stake"
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
-	"github.com/HcashOrg/hcd/database"
 	"github.com/HcashOrg/hcd/txscript"
 	"github.com/HcashOrg/hcd/hcutil"
 )
@@ -101,6 +100,7 @@ type UtxoEntry struct {
 	isCoinBase bool // Whether entry is a coinbase tx.
 	hasExpiry  bool // Whether entry has an expiry.
 	modified   bool // Entry changed since load.
+	fresh      bool // Entry was created and is not in the database.
 }
 
 // TxVersion returns the transaction version of the transaction the
@@ -342,6 +342,7 @@ func (view *UtxoViewpoint) AddTxOuts(tx *hcutil.Tx, blockHeight int64,
 			putTxToMinimalOutputs(stakeExtra, tx)
 			entry.stakeExtra = stakeExtra
 		}
+		entry.fresh = true
 		view.entries[*tx.Hash()] = entry
 	} else {
 		entry.height = uint32(blockHeight)
@@ -473,7 +474,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *hcutil.Bloc
 
 	if parent != nil && block.Height() != 0 {
 		view.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -493,7 +494,7 @@ func (b *BlockChain) connectTransactions(view *UtxoViewpoint, block *hcutil.Bloc
 
 	for i, stx := range block.STransactions() {
 		view.SetStakeViewpoint(thisNodeStakeViewpoint)
-		err := view.fetchInputUtxos(b.db, block, parent)
+		err := view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return err
 		}
@@ -536,7 +537,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 		thisNodeStakeViewpoint = ViewpointPrevValidStake
 	}
 	view.SetStakeViewpoint(thisNodeStakeViewpoint)
-	err := view.fetchInputUtxos(b.db, block, parent)
+	err := view.fetchInputUtxos(b.utxoCache, block, parent)
 	if err != nil {
 		return err
 	}
@@ -636,7 +637,7 @@ func (b *BlockChain) disconnectTransactions(view *UtxoViewpoint,
 		// history in the first place.
 		if regularTxTreeValid {
 			view.SetStakeViewpoint(ViewpointPrevValidInitial)
-			err = view.fetchInputUtxos(b.db, block, parent)
+			err = view.fetchInputUtxos(b.utxoCache, block, parent)
 			if err != nil {
 				return err
 			}
@@ -848,17 +849,19 @@ func (view *UtxoViewpoint) commit() {
 		}
 
 		entry.modified = false
+		entry.fresh = false
 	}
 }
 
 // fetchUtxosMain fetches unspent transaction output data about the provided
 // set of transactions from the point of view of the end of the main chain at
-// the time of the call.
+// the time of the call.  The data is served by the utxo cache which loads it
+// from the database as needed.
 //
 // Upon completion of this function, the view will contain an entry for each
 // requested transaction.  Fully spent transactions, or those which otherwise
 // don't exist, will result in a nil entry in the view.
-func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+func (view *UtxoViewpoint) fetchUtxosMain(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -871,30 +874,15 @@ func (view *UtxoViewpoint) fetchUtxosMain(db database.DB, txSet map[chainhash.Ha
 	// will result in nil entries in the view.  This is intentionally done
 	// since other code uses the presence of an entry in the store as a way
 	// to optimize spend and unspend updates to apply only to the specific
-	// utxos that the caller needs access to.
-	return db.View(func(dbTx database.Tx) error {
-		for hash := range txSet {
-			hashCopy := hash
-			// If the UTX already exists in the view, skip adding it.
-			if _, ok := view.entries[hashCopy]; ok {
-				continue
-			}
-			entry, err := dbFetchUtxoEntry(dbTx, &hashCopy)
-			if err != nil {
-				return err
-			}
-
-			view.entries[hash] = entry
-		}
-
-		return nil
-	})
+	// utxos that the caller needs access to.  Entries which already exist in
+	// the view are not modified.
+	return cache.fetchEntries(txSet, view.entries)
 }
 
 // fetchUtxos loads utxo details about provided set of transaction hashes into
-// the view from the database as needed unless they already exist in the view in
-// which case they are ignored.
-func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]struct{}) error {
+// the view from the utxo cache as needed unless they already exist in the view
+// in which case they are ignored.
+func (view *UtxoViewpoint) fetchUtxos(cache *utxoCache, txSet map[chainhash.Hash]struct{}) error {
 	// Nothing to do if there are no requested hashes.
 	if len(txSet) == 0 {
 		return nil
@@ -911,15 +899,15 @@ func (view *UtxoViewpoint) fetchUtxos(db database.DB, txSet map[chainhash.Hash]s
 		txNeededSet[hash] = struct{}{}
 	}
 
-	// Request the input utxos from the database.
-	return view.fetchUtxosMain(db, txNeededSet)
+	// Request the input utxos from the utxo cache.
+	return view.fetchUtxosMain(cache, txNeededSet)
 }
 
 // fetchInputUtxos loads utxo details about the input transactions referenced
-// by the transactions in the given block into the view from the database as
+// by the transactions in the given block into the view from the utxo cache as
 // needed.  In particular, referenced entries that are earlier in the block are
 // added to the view and entries that are already in the view are not modified.
-func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
+func (view *UtxoViewpoint) fetchInputUtxos(cache *utxoCache,
 	block, parent *hcutil.Block) error {
 	viewpoint := view.StakeViewpoint()
 
@@ -973,8 +961,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 2+3: ViewpointPrevValidStake and ViewpointPrevValidStake.
@@ -1013,8 +1001,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// Case 4+5: ViewpointPrevValidRegular and
@@ -1070,8 +1058,8 @@ func (view *UtxoViewpoint) fetchInputUtxos(db database.DB,
 			}
 		}
 
-		// Request the input utxos from the database.
-		return view.fetchUtxosMain(db, txNeededSet)
+		// Request the input utxos from the utxo cache.
+		return view.fetchUtxosMain(cache, txNeededSet)
 	}
 
 	// TODO actual blockchain error
@@ -1109,7 +1097,7 @@ func (b *BlockChain) FetchUtxoView(tx *hcutil.Tx, treeValid bool) (*UtxoViewpoin
 		if err != nil {
 			return nil, err
 		}
-		err = view.fetchInputUtxos(b.db, block, parent)
+		err = view.fetchInputUtxos(b.utxoCache, block, parent)
 		if err != nil {
 			return nil, err
 		}
@@ -1140,7 +1128,7 @@ func (b *BlockChain) FetchUtxoView(tx *hcutil.Tx, treeValid bool) (*UtxoViewpoin
 		}
 	}
 
-	err := view.fetchUtxosMain(b.db, txNeededSet)
+	err := view.fetchUtxosMain(b.utxoCache, txNeededSet)
 
 	return view, err
 }
@@ -1159,15 +1147,5 @@ func (b *BlockChain) FetchUtxoEntry(txHash *chainhash.Hash) (*UtxoEntry, error)
 	b.chainLock.RLock()
 	defer b.chainLock.RUnlock()
 
-	var entry *UtxoEntry
-	err := b.db.View(func(dbTx database.Tx) error {
-		var err error
-		entry, err = dbFetchUtxoEntry(dbTx, txHash)
-		return err
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return entry, nil
+	return b.utxoCache.fetchEntry(txHash)
 }
@@ -890,7 +890,7 @@ func (b *BlockChain) checkDupTxs(txSet []*hcutil.Tx, view *UtxoViewpoint) error
 	for _, tx := range txSet {
 		fetchSet[*tx.Hash()] = struct{}{}
 	}
-	err := view.fetchUtxos(b.db, fetchSet)
+	err := view.fetchUtxos(b.utxoCache, fetchSet)
 	if err != nil {
 		return err
 	}
@@ -2413,8 +2413,11 @@ func (b *BlockChain) consensusScriptVerifyFlags(node *blockNode) (txscript.Scrip
 // See the comments for CheckConnectBlock for some examples of the type of
 // checks performed by this function.
 //
+// The flags modify the behavior of this function as follows:
+//  - BFAssumeValid: The scripts of the transactions are not run.
+//
 // This function MUST be called with the chain state lock held (for writes).
-func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utxoView *UtxoViewpoint, stxos *[]spentTxOut) error {
+func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utxoView *UtxoViewpoint, stxos *[]spentTxOut, flags BehaviorFlags) error {
 	// If the side chain blocks end up in the database, a call to
 	// CheckBlockSanity should be done here in case a previous version
 	// allowed a block that is no longer valid.  However, since the
@@ -2466,6 +2469,12 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utx
 	if checkpoint != nil && node.height <= checkpoint.Height {
 		runScripts = false
 	}
+
+	// Likewise, don't run scripts for the ancestors of the assumed valid
+	// block.
+	if flags&BFAssumeValid == BFAssumeValid {
+		runScripts = false
+	}
 	var scriptFlags txscript.ScriptFlags
 	if runScripts {
 		var err error
@@ -2491,7 +2500,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utx
 		thisNodeRegularViewpoint = ViewpointPrevValidRegular
 
 		utxoView.SetStakeViewpoint(ViewpointPrevValidInitial)
-		err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+		err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 		if err != nil {
 			return err
 		}
@@ -2513,7 +2522,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utx
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 	if err != nil {
 		return err
 	}
@@ -2582,7 +2591,7 @@ func (b *BlockChain) checkConnectBlock(node *blockNode, block *hcutil.Block, utx
 		return err
 	}
 
-	err = utxoView.fetchInputUtxos(b.db, block, parentBlock)
+	err = utxoView.fetchInputUtxos(b.utxoCache, block, parentBlock)
 	if err != nil {
 		return err
 	}
@@ -2687,7 +2696,7 @@ func (b *BlockChain) CheckConnectBlock(block *hcutil.Block) error {
 		prevNode.hash == b.bestNode.hash) {
 		view := NewUtxoViewpoint()
 		view.SetBestHash(&prevNode.hash)
-		return b.checkConnectBlock(newNode, block, view, nil)
+		return b.checkConnectBlock(newNode, block, view, nil, BFNone)
 	}
 
 	// The requested node is either on a side chain or is a node on the
@@ -2741,7 +2750,7 @@ func (b *BlockChain) CheckConnectBlock(block *hcutil.Block) error {
 	// if there are no nodes to attach, we're done.
 	if attachNodes.Len() == 0 {
 		view.SetBestHash(&parentHash)
-		return b.checkConnectBlock(newNode, block, view, nil)
+		return b.checkConnectBlock(newNode, block, view, nil, BFNone)
 	}
 
 	// The requested node is on a side chain, so we need to apply the
@@ -2767,5 +2776,5 @@ func (b *BlockChain) CheckConnectBlock(block *hcutil.Block) error {
 	}
 
 	view.SetBestHash(&parentHash)
-	return b.checkConnectBlock(newNode, block, view, &stxos)
+	return b.checkConnectBlock(newNode, block, view, &stxos, BFNone)
 }
@@ -13,6 +13,7 @@ import (
 	"math/rand"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -28,10 +29,44 @@ import (
 )
 
 const (
-	// minInFlightBlocks is the minimum number of blocks that should be
-	// in the request queue for headers-first mode before requesting
-	// more.
-	minInFlightBlocks = 10
+	// maxBlocksInFlightPerPeer is the maximum number of blocks of the
+	// header list which may be requested from a single peer at once in
+	// headers-first mode.
+	maxBlocksInFlightPerPeer = 32
+
+	// blockDownloadWindow is the number of blocks of the header list,
+	// starting with the next block to be processed, which may be requested
+	// in headers-first mode.  It bounds the number of blocks which are
+	// downloaded out of order and held until the blocks before them are
+	// processed.
+	blockDownloadWindow = 1024
+
+	// blockStallTimeout is the duration after which a peer which has not
+	// delivered any of the blocks requested from it in headers-first mode
+	// is considered to be stalling the download.  Its requests are then
+	// reassigned to other peers.
+	blockStallTimeout = 15 * time.Second
+
+	// blockStallCheckInterval is the interval at which peers are checked
+	// for stalling the block download in headers-first mode and for not
+	// delivering the missing transactions of compact blocks.
+	blockStallCheckInterval = 5 * time.Second
+
+	// blockTxnTimeout is the duration after which a peer which has not
+	// delivered the missing transactions of a compact block is considered
+	// to be stalling it.  The full block is then requested instead.
+	blockTxnTimeout = 10 * time.Second
+
+	// maxHeadersPerRound is the maximum number of headers which are
+	// downloaded after the final checkpoint before the blocks they
+	// describe are fetched.  It limits the size of the header list.
+	maxHeadersPerRound = 20 * wire.MaxBlockHeadersPerMsg
+
+	// assumeValidAnchorInterval is the interval of the heights at which the
+	// hashes of the ancestors of the assumed valid block are recorded while
+	// searching for it.  The scripts of the blocks of a round of headers
+	// after the last recorded ancestor it contains are validated.
+	assumeValidAnchorInterval = 100
 
 	// blockDbNamePrefix is the prefix for the block database name.  The
 	// database type is appended to this value to form the full block
@@ -79,6 +114,20 @@ type blockMsg struct {
 	peer  *serverPeer
 }
 
+// cmpctBlockMsg packages a hcd cmpctblock message and the peer it came from
+// together so the block handler has access to that information.
+type cmpctBlockMsg struct {
+	cmpctBlock *wire.MsgCmpctBlock
+	peer       *serverPeer
+}
+
+// blockTxnMsg packages a hcd blocktxn message and the peer it came from
+// together so the block handler has access to that information.
+type blockTxnMsg struct {
+	blockTxn *wire.MsgBlockTxn
+	peer     *serverPeer
+}
+
 // invMsg packages a hcd inv message and the peer it came from together
 // so the block handler has access to that information.
 type invMsg struct {
@@ -328,6 +377,13 @@ type headerNode struct {
 	hash   *chainhash.Hash
 }
 
+// blockRequest describes an outstanding request for a block of the header list
+// in headers-first mode.
+type blockRequest struct {
+	peer      *serverPeer
+	requested time.Time
+}
+
 // chainState tracks the state of the best chain as blocks are inserted.  This
 // is done because blockchain is currently not safe for concurrent access and the
 // block manager is typically quite busy processing block and inventory.
@@ -427,12 +483,47 @@ type blockManager struct {
 	wg                  sync.WaitGroup
 	quit                chan struct{}
 
-	// The following fields are used for headers-first mode.
+	// The following fields are used for headers-first mode.  After the
+	// final checkpoint, headersFirstMode is still used to download the
+	// headers up to the tip of the sync peer while nextCheckpoint is nil.
 	headersFirstMode bool
+	headersSynced    bool
 	headerList       *list.List
-	startHeader      *list.Element
 	nextCheckpoint   *chaincfg.Checkpoint
 
+	// The following fields are used to skip the script validation of the
+	// ancestors of the assumed valid block.  The headers after the final
+	// checkpoint are searched for it once, recording the hashes of its
+	// ancestors every assumeValidAnchorInterval blocks along with its own
+	// in assumeValidAnchors, so the headers of every round can be matched
+	// against its chain.  The headers beyond the first round are only
+	// linked to assumeValidSearchTip during the search.  The scripts of the
+	// blocks of the header list up to assumeValidListHeight, the greatest
+	// height of the list which matched one of the recorded hashes, are not
+	// validated.
+	assumeValid           *chainhash.Hash
+	assumeValidResolved   bool
+	assumeValidHeight     int64
+	assumeValidAnchors    map[int64]chainhash.Hash
+	assumeValidSearchTip  *headerNode
+	assumeValidListHeight int64
+
+	// The following fields are used to download the blocks of the header
+	// list from multiple peers in parallel.  blockRequests houses the
+	// outstanding requests, blockBuffer the blocks which were received
+	// before the blocks preceding them, blockProgress the last time each
+	// peer delivered a requested block and blockStalls the last time each
+	// peer was found to be stalling.
+	blockRequests map[chainhash.Hash]*blockRequest
+	blockBuffer   map[chainhash.Hash]*blockMsg
+	blockProgress map[*serverPeer]time.Time
+	blockStalls   map[*serverPeer]time.Time
+
+	// partialBlocks houses the blocks which are being reconstructed from a
+	// compact block sent by each peer while the missing transactions are
+	// requested from it.
+	partialBlocks map[*serverPeer]*pendingCmpctBlock
+
 	// lotteryDataBroadcastMutex is a mutex protecting the map
 	// that checks if block lottery data has been broadcasted
 	// yet for any given block, so notifications are never
@@ -440,25 +531,41 @@ type blockManager struct {
 	lotteryDataBroadcast      map[chainhash.Hash]struct{}
 	lotteryDataBroadcastMutex sync.Mutex
 
+	// blockArrivals houses the height of the recently accepted blocks
+	// along with the time they were accepted, so the votes on the tip
+	// candidates can be reported along with how long they are pending.
+	blockArrivals      map[chainhash.Hash]blockArrival
+	blockArrivalsMutex sync.Mutex
+
 	cachedCurrentTemplate *BlockTemplate
 	cachedParentTemplate  *BlockTemplate
 	AggressiveMining      bool
 }
 
 // resetHeaderState sets the headers-first mode state to values appropriate for
-// syncing from a new peer.
+// syncing from a new peer.  Any outstanding block requests of the header list
+// are abandoned.
 func (b *blockManager) resetHeaderState(newestHash *chainhash.Hash, newestHeight int64) {
 	b.headersFirstMode = false
+	b.headersSynced = false
 	b.headerList.Init()
-	b.startHeader = nil
-
-	// When there is a next checkpoint, add an entry for the latest known
-	// block into the header pool.  This allows the next downloaded header
-	// to prove it links to the chain properly.
-	if b.nextCheckpoint != nil {
-		node := headerNode{height: newestHeight, hash: newestHash}
-		b.headerList.PushBack(&node)
+	b.assumeValidSearchTip = nil
+	b.assumeValidListHeight = 0
+	if !b.assumeValidResolved {
+		b.assumeValidAnchors = make(map[int64]chainhash.Hash)
 	}
+	for hash, req := range b.blockRequests {
+		delete(req.peer.requestedBlocks, hash)
+		delete(b.requestedBlocks, hash)
+	}
+	b.blockRequests = make(map[chainhash.Hash]*blockRequest)
+	b.blockBuffer = make(map[chainhash.Hash]*blockMsg)
+
+	// Add an entry for the latest known block into the header pool.  This
+	// allows the next downloaded header to prove it links to the chain
+	// properly.
+	node := headerNode{height: newestHeight, hash: newestHash}
+	b.headerList.PushBack(&node)
 }
 
 // updateChainState updates the chain state associated with the block manager.
@@ -583,10 +690,11 @@ func (b *blockManager) startSync(peers *list.List) {
 		// full block hasn't been tampered with.
 		//
 		// Once we have passed the final checkpoint, or checkpoints are
-		// disabled, use standard inv messages learn about the blocks
-		// and fully validate them.  Finally, regression test mode does
-		// not support the headers-first approach so do normal block
-		// downloads when in regression test mode.
+		// disabled, the headers up to the tip of the peer are still
+		// downloaded first when it has more blocks, so the blocks can
+		// be fetched from multiple peers in parallel, but the blocks
+		// are fully validated.  Otherwise, use standard inv messages to
+		// learn about the blocks.
 		if b.nextCheckpoint != nil &&
 			best.Height < b.nextCheckpoint.Height &&
 			!cfg.DisableCheckpoints {
@@ -601,6 +709,17 @@ func (b *blockManager) startSync(peers *list.List) {
 			bmgrLog.Infof("Downloading headers for blocks %d to "+
 				"%d from peer %s", best.Height+1,
 				b.nextCheckpoint.Height, bestPeer.Addr())
+		} else if bestPeer.LastBlock() > best.Height {
+			b.resetHeaderState(best.Hash, best.Height)
+			err := bestPeer.PushGetHeadersMsg(locator, &zeroHash)
+			if err != nil {
+				bmgrLog.Errorf("Failed to push getheadermsg for the "+
+					"latest blocks: %v", err)
+				return
+			}
+			b.headersFirstMode = true
+			bmgrLog.Infof("Downloading headers for blocks after %d "+
+				"from peer %s", best.Height, bestPeer.Addr())
 		} else {
 			err := bestPeer.PushGetBlocksMsg(locator, &zeroHash)
 			if err != nil {
@@ -696,7 +815,11 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 	// and request them now to speed things up a little.
 	for k := range sp.requestedBlocks {
 		delete(b.requestedBlocks, k)
+		delete(b.blockRequests, k)
 	}
+	delete(b.blockProgress, sp)
+	delete(b.blockStalls, sp)
+	delete(b.partialBlocks, sp)
 
 	// Attempt to find a new peer to sync from if the quitting peer is the
 	// sync peer.  Also, reset the headers-first state if in headers-first
@@ -708,6 +831,13 @@ func (b *blockManager) handleDonePeerMsg(peers *list.List, sp *serverPeer) {
 			b.resetHeaderState(best.Hash, best.Height)
 		}
 		b.startSync(peers)
+		return
+	}
+
+	// Reassign the blocks of the header list which were requested from the
+	// peer to the remaining peers.
+	if b.headersFirstMode {
+		b.fetchHeaderBlocks(peers)
 	}
 }
 
@@ -771,6 +901,12 @@ func (b *blockManager) handleTxMsg(tmsg *txMsg) {
 		return
 	}
 
+	// Keep track of peers relaying new transactions to protect them from
+	// eviction.
+	if len(acceptedTxs) > 0 {
+		atomic.StoreInt64(&tmsg.peer.lastTxRelay, time.Now().Unix())
+	}
+
 	b.server.AnnounceNewTransactions(acceptedTxs)
 }
 
@@ -976,7 +1112,7 @@ func (b *blockManager) checkBlockForHiddenVotes(block *hcutil.Block) {
 }
 
 // handleBlockMsg handles block messages from all peers.
-func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
+func (b *blockManager) handleBlockMsg(peers *list.List, bmsg *blockMsg) {
 	// If we didn't ask for this block then the peer is misbehaving.
 	blockHash := bmsg.block.Hash()
 	if _, exists := bmsg.peer.requestedBlocks[*blockHash]; !exists {
@@ -1002,13 +1138,251 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		}
 	}
 
+	// Drop the partial block of the peer when the full block was received
+	// instead.
+	if pending, exists := b.partialBlocks[bmsg.peer]; exists &&
+		pending.pb.BlockHash() == *blockHash {
+
+		delete(b.partialBlocks, bmsg.peer)
+	}
+
+	// Record the download progress of the peer when the block was requested
+	// from it as part of the header list.  The request is removed even when
+	// the block was reassigned to another peer in the mean time since it is
+	// no longer needed.
+	if req, exists := b.blockRequests[*blockHash]; exists {
+		if req.peer == bmsg.peer {
+			b.blockProgress[bmsg.peer] = time.Now()
+		}
+		delete(req.peer.requestedBlocks, *blockHash)
+		delete(b.blockRequests, *blockHash)
+	}
+
+	// Hold the blocks of the header list which are received before the
+	// blocks preceding them.
+	if b.headersFirstMode && b.bufferHeaderBlock(peers, bmsg) {
+		return
+	}
+
+	b.processBlockMsg(peers, bmsg)
+
+	// Process the held blocks which are next in line now.
+	for b.headersFirstMode {
+		firstNodeEl := b.headerList.Front()
+		if firstNodeEl == nil {
+			break
+		}
+		firstNode := firstNodeEl.Value.(*headerNode)
+		next, exists := b.blockBuffer[*firstNode.hash]
+		if !exists {
+			break
+		}
+		delete(b.blockBuffer, *firstNode.hash)
+		b.processBlockMsg(peers, next)
+	}
+}
+
+// pendingCmpctBlock houses a block which is being reconstructed from a compact
+// block along with the time its missing transactions were requested.
+type pendingCmpctBlock struct {
+	pb        *mempool.PartialBlock
+	height    int64
+	requested time.Time
+}
+
+// handleCmpctBlockMsg handles cmpctblock messages from all peers.  The block is
+// reconstructed from the transactions of the memory pool and processed like a
+// block message when it is complete.  Otherwise, the missing transactions are
+// requested from the peer.  The full block is requested when the compact block
+// can't be reconstructed.
+func (b *blockManager) handleCmpctBlockMsg(peers *list.List, cmsg *cmpctBlockMsg) {
+	sp := cmsg.peer
+	blockHash := cmsg.cmpctBlock.Header.BlockHash()
+
+	// Compact blocks are only requested when the chain is current, so
+	// ignore the ones which were not requested.
+	if _, exists := sp.requestedBlocks[blockHash]; !exists {
+		bmgrLog.Debugf("Ignoring unrequested compact block %v from %s",
+			blockHash, sp)
+		return
+	}
+
+	pb, err := b.server.txMemPool.NewPartialBlock(cmsg.cmpctBlock)
+	if err != nil {
+		bmgrLog.Debugf("Unable to reconstruct compact block %v from "+
+			"%s: %v -- requesting the full block", blockHash, sp, err)
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+	if !pb.IsComplete() {
+		indexes, stakeIndexes := pb.MissingIndexes()
+		bmgrLog.Debugf("Requesting %d missing transactions of compact "+
+			"block %v from %s", len(indexes)+len(stakeIndexes),
+			blockHash, sp)
+		b.partialBlocks[sp] = &pendingCmpctBlock{
+			pb:        pb,
+			height:    int64(cmsg.cmpctBlock.Header.Height),
+			requested: time.Now(),
+		}
+		sp.QueueMessage(wire.NewMsgGetBlockTxn(&blockHash, indexes,
+			stakeIndexes), nil)
+		return
+	}
+
+	b.handlePartialBlock(peers, sp, pb)
+}
+
+// handleBlockTxnMsg handles blocktxn messages from all peers.  The
+// transactions complete the partial block which was requested from the peer.
+func (b *blockManager) handleBlockTxnMsg(peers *list.List, bmsg *blockTxnMsg) {
+	sp := bmsg.peer
+	blockHash := bmsg.blockTxn.BlockHash
+	pending, exists := b.partialBlocks[sp]
+	if !exists || pending.pb.BlockHash() != blockHash {
+		bmgrLog.Debugf("Ignoring unrequested transactions of block %v "+
+			"from %s", blockHash, sp)
+		return
+	}
+	delete(b.partialBlocks, sp)
+	pb := pending.pb
+
+	err := pb.FillMissing(bmsg.blockTxn.Transactions,
+		bmsg.blockTxn.STransactions)
+	if err != nil {
+		bmgrLog.Debugf("Unable to complete compact block %v from %s: "+
+			"%v -- requesting the full block", blockHash, sp, err)
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+
+	b.handlePartialBlock(peers, sp, pb)
+}
+
+// handlePartialBlock processes the complete block of the passed partial block
+// like a block message from the peer.  The full block is requested when the
+// reconstructed transactions do not match the header.
+func (b *blockManager) handlePartialBlock(peers *list.List, sp *serverPeer, pb *mempool.PartialBlock) {
+	block, err := pb.Block()
+	if err != nil {
+		blockHash := pb.BlockHash()
+		bmgrLog.Debugf("Unable to reconstruct compact block %v from "+
+			"%s: %v -- requesting the full block", blockHash, sp, err)
+		b.requestFullBlock(sp, &blockHash)
+		return
+	}
+
+	b.handleBlockMsg(peers, &blockMsg{block: block, peer: sp})
+}
+
+// handleBlockTxnTimeouts drops the partial blocks whose missing transactions
+// were not delivered within blockTxnTimeout.  Their full blocks are requested
+// from another peer which has them instead, or from the same peer when there is
+// no such peer.
+func (b *blockManager) handleBlockTxnTimeouts(peers *list.List) {
+	now := time.Now()
+	for sp, pending := range b.partialBlocks {
+		if now.Sub(pending.requested) < blockTxnTimeout {
+			continue
+		}
+		delete(b.partialBlocks, sp)
+		blockHash := pending.pb.BlockHash()
+		delete(sp.requestedBlocks, blockHash)
+		delete(b.requestedBlocks, blockHash)
+
+		// Nothing more to do when the block was received in the mean
+		// time.
+		haveBlock, err := b.chain.HaveBlock(&blockHash)
+		if err != nil || haveBlock {
+			continue
+		}
+
+		peer := sp
+		for e := peers.Front(); e != nil; e = e.Next() {
+			candidate := e.Value.(*serverPeer)
+			if candidate != sp && candidate.Connected() &&
+				candidate.LastBlock() >= pending.height {
+
+				peer = candidate
+				break
+			}
+		}
+		bmgrLog.Debugf("Peer %s did not send the missing transactions "+
+			"of compact block %v within %v -- requesting the full "+
+			"block from %s", sp, blockHash, blockTxnTimeout, peer)
+		peer.requestedBlocks[blockHash] = struct{}{}
+		b.requestedBlocks[blockHash] = struct{}{}
+		b.requestFullBlock(peer, &blockHash)
+	}
+}
+
+// requestFullBlock requests the full block with the passed hash from the peer
+// after the block could not be reconstructed from a compact block.  The block
+// remains requested from the peer.
+func (b *blockManager) requestFullBlock(sp *serverPeer, hash *chainhash.Hash) {
+	gdmsg := wire.NewMsgGetData()
+	gdmsg.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, hash))
+	sp.QueueMessage(gdmsg, nil)
+}
+
+// bufferHeaderBlock holds a block of the header list which was received in
+// headers-first mode before the blocks preceding it were processed until it is
+// next in line.  Blocks which were already received from another peer are
+// dropped.  It returns whether the block was consumed and must not be processed
+// now.
+func (b *blockManager) bufferHeaderBlock(peers *list.List, bmsg *blockMsg) bool {
+	blockHash := bmsg.block.Hash()
+	if _, exists := b.blockBuffer[*blockHash]; exists {
+		delete(bmsg.peer.requestedBlocks, *blockHash)
+		return true
+	}
+
+	i := 0
+	for e := b.headerList.Front(); e != nil && i < blockDownloadWindow; e = e.Next() {
+		node := e.Value.(*headerNode)
+		if !node.hash.IsEqual(blockHash) {
+			i++
+			continue
+		}
+
+		// The block is processed right away when it is next in line.
+		if i == 0 {
+			return false
+		}
+
+		delete(bmsg.peer.requestedBlocks, *blockHash)
+		delete(b.requestedBlocks, *blockHash)
+		b.blockBuffer[*blockHash] = bmsg
+		b.fetchHeaderBlocks(peers)
+		return true
+	}
+
+	// Drop blocks which were already processed.  This happens when a block
+	// which was reassigned from a stalling peer is delivered by both peers.
+	haveBlock, err := b.chain.HaveBlock(blockHash)
+	if err == nil && haveBlock {
+		bmgrLog.Debugf("Ignoring already received block %v from %s",
+			blockHash, bmsg.peer)
+		delete(bmsg.peer.requestedBlocks, *blockHash)
+		return true
+	}
+	return false
+}
+
+// processBlockMsg processes a block received from a peer which is either not
+// part of the header list or next in line in headers-first mode.
+func (b *blockManager) processBlockMsg(peers *list.List, bmsg *blockMsg) {
+	blockHash := bmsg.block.Hash()
+
 	// When in headers-first mode, if the block matches the hash of the
 	// first header in the list of headers that are being fetched, it's
 	// eligible for less validation since the headers have already been
 	// verified to link together and are valid up to the next checkpoint.
 	// Also, remove the list entry for all blocks except the checkpoint
 	// since it is needed to verify the next round of headers links
-	// properly.
+	// properly.  The blocks after the final checkpoint are fully
+	// validated except for the scripts of the ancestors of the assumed
+	// valid block.
+	isHeaderBlock := false
 	isCheckpointBlock := false
 	behaviorFlags := blockchain.BFNone
 	if b.headersFirstMode {
@@ -1016,10 +1390,17 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		if firstNodeEl != nil {
 			firstNode := firstNodeEl.Value.(*headerNode)
 			if blockHash.IsEqual(firstNode.hash) {
-				behaviorFlags |= blockchain.BFFastAdd
-				if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
+				isHeaderBlock = true
+				if b.nextCheckpoint == nil {
+					if firstNode.height <= b.assumeValidListHeight {
+						behaviorFlags |= blockchain.BFAssumeValid
+					}
+					b.headerList.Remove(firstNodeEl)
+				} else if firstNode.hash.IsEqual(b.nextCheckpoint.Hash) {
+					behaviorFlags |= blockchain.BFFastAdd
 					isCheckpointBlock = true
 				} else {
+					behaviorFlags |= blockchain.BFFastAdd
 					b.headerList.Remove(firstNodeEl)
 				}
 			}
@@ -1058,9 +1439,23 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 		code, reason := mempool.ErrToRejectErr(err)
 		bmsg.peer.PushRejectMsg(wire.CmdBlock, code, reason,
 			blockHash, false)
+
+		// The remaining blocks of the header list can't be connected
+		// either, so fall back to normal mode.
+		if isHeaderBlock {
+			bmgrLog.Infof("Failed to process block %v of the header "+
+				"list -- switching to normal mode", blockHash)
+			b.exitHeadersFirstMode()
+		}
 		return
 	}
 
+	// Keep track of peers relaying new blocks to protect them from
+	// eviction.
+	if !isOrphan {
+		atomic.StoreInt64(&bmsg.peer.lastBlockRelay, time.Now().Unix())
+	}
+
 	// Meta-data about the new block this peer is reporting. We use this
 	// below to update this peer's lastest block height and the heights of
 	// other peers based on their last announced block hash. This allows us
@@ -1237,72 +1632,174 @@ func (b *blockManager) handleBlockMsg(bmsg *blockMsg) {
 	}
 
 	// This is headers-first mode, so if the block is not a checkpoint
-	// request more blocks using the header list when the request queue is
-	// getting short.
+	// request more blocks using the header list.  Once all of the blocks
+	// of the headers after the final checkpoint are processed, continue
+	// with the next round of headers.
 	if !isCheckpointBlock {
-		if b.startHeader != nil &&
-			len(bmsg.peer.requestedBlocks) < minInFlightBlocks {
-			b.fetchHeaderBlocks()
+		if b.headerList.Len() == 0 {
+			b.finishHeadersRound()
+			return
 		}
+		b.fetchHeaderBlocks(peers)
 		return
 	}
 
-	// This is headers-first mode and the block is a checkpoint.  When
-	// there is a next checkpoint, get the next round of headers by asking
-	// for headers starting from the block after this one up to the next
-	// checkpoint.
+	// This is headers-first mode and the block is a checkpoint.  Get the
+	// next round of headers by asking the sync peer for headers starting
+	// from the block after this one up to the next checkpoint or, when
+	// there are no more checkpoints, up to the end of its chain (zero
+	// hash).
 	prevHeight := b.nextCheckpoint.Height
 	prevHash := b.nextCheckpoint.Hash
 	b.nextCheckpoint = b.findNextHeaderCheckpoint(prevHeight)
+	b.headersSynced = false
+	stopHash := &zeroHash
+	if b.nextCheckpoint != nil {
+		stopHash = b.nextCheckpoint.Hash
+	}
+	locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
+	err = b.syncPeer.PushGetHeadersMsg(locator, stopHash)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to "+
+			"peer %s: %v", b.syncPeer.Addr(), err)
+		return
+	}
 	if b.nextCheckpoint != nil {
-		locator := blockchain.BlockLocator([]*chainhash.Hash{prevHash})
-		err := bmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
-		if err != nil {
-			bmgrLog.Warnf("Failed to send getheaders message to "+
-				"peer %s: %v", bmsg.peer.Addr(), err)
-			return
-		}
 		bmgrLog.Infof("Downloading headers for blocks %d to %d from "+
 			"peer %s", prevHeight+1, b.nextCheckpoint.Height,
 			b.syncPeer.Addr())
 		return
 	}
+	bmgrLog.Infof("Reached the final checkpoint -- downloading headers for "+
+		"blocks after %d from peer %s", prevHeight, b.syncPeer.Addr())
+}
 
-	// This is headers-first mode, the block is a checkpoint, and there are
-	// no more checkpoints, so switch to normal mode by requesting blocks
-	// from the block after this one up to the end of the chain (zero hash).
-	b.headersFirstMode = false
-	b.headerList.Init()
-	bmgrLog.Infof("Reached the final checkpoint -- switching to normal mode")
-	locator := blockchain.BlockLocator([]*chainhash.Hash{blockHash})
-	err = bmsg.peer.PushGetBlocksMsg(locator, &zeroHash)
+// finishHeadersRound is invoked once all of the blocks of the headers
+// downloaded after the final checkpoint were processed.  It starts another
+// round of headers when the sync peer is known to have more blocks and
+// switches to normal mode otherwise.
+func (b *blockManager) finishHeadersRound() {
+	best := b.chain.BestSnapshot()
+	if b.syncPeer.LastBlock() <= best.Height {
+		bmgrLog.Infof("Processed the blocks of all downloaded headers " +
+			"-- switching to normal mode")
+		b.exitHeadersFirstMode()
+		return
+	}
+
+	b.resetHeaderState(best.Hash, best.Height)
+	locator, err := b.chain.LatestBlockLocator()
 	if err != nil {
-		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
-			bmsg.peer.Addr(), err)
+		bmgrLog.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
+		return
+	}
+	err = b.syncPeer.PushGetHeadersMsg(locator, &zeroHash)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getheaders message to peer %s: %v",
+			b.syncPeer.Addr(), err)
+		return
+	}
+	b.headersFirstMode = true
+	bmgrLog.Infof("Downloading headers for blocks after %d from peer %s",
+		best.Height, b.syncPeer.Addr())
+}
+
+// exitHeadersFirstMode switches from headers-first mode to normal mode by
+// requesting the blocks after the best block up to the end of the chain of the
+// sync peer (zero hash).
+func (b *blockManager) exitHeadersFirstMode() {
+	best := b.chain.BestSnapshot()
+	b.resetHeaderState(best.Hash, best.Height)
+	locator, err := b.chain.LatestBlockLocator()
+	if err != nil {
+		bmgrLog.Errorf("Failed to get block locator for the latest "+
+			"block: %v", err)
 		return
 	}
+	err = b.syncPeer.PushGetBlocksMsg(locator, &zeroHash)
+	if err != nil {
+		bmgrLog.Warnf("Failed to send getblocks message to peer %s: %v",
+			b.syncPeer.Addr(), err)
+	}
+}
+
+// blockDownloadPeers returns the peers the blocks of the header list may be
+// requested from.  These are the connected outbound sync candidates along with
+// the sync peer.  Peers which were recently found to be stalling are excluded
+// unless there are no others.
+func (b *blockManager) blockDownloadPeers(peers *list.List) []*serverPeer {
+	now := time.Now()
+	var downloadPeers, stalledPeers []*serverPeer
+	for e := peers.Front(); e != nil; e = e.Next() {
+		sp := e.Value.(*serverPeer)
+		if !sp.Connected() || (sp.Inbound() && sp != b.syncPeer) {
+			continue
+		}
+		stalled, exists := b.blockStalls[sp]
+		if exists && now.Sub(stalled) < blockStallTimeout {
+			stalledPeers = append(stalledPeers, sp)
+			continue
+		}
+		downloadPeers = append(downloadPeers, sp)
+	}
+	if len(downloadPeers) == 0 {
+		return stalledPeers
+	}
+	return downloadPeers
 }
 
-// fetchHeaderBlocks creates and sends a request to the syncPeer for the next
-// list of blocks to be downloaded based on the current list of headers.
-func (b *blockManager) fetchHeaderBlocks() {
-	// Nothing to do if there is no start header.
-	if b.startHeader == nil {
-		bmgrLog.Warnf("fetchHeaderBlocks called with no start header")
+// canRequestHeaderBlock returns whether the block at the passed height may be
+// requested from the passed peer.  The peer must not already have the maximum
+// number of blocks in flight and must be known to have the block, which the
+// sync peer is always assumed to have.
+func (b *blockManager) canRequestHeaderBlock(sp *serverPeer, height int64) bool {
+	if len(sp.requestedBlocks) >= maxBlocksInFlightPerPeer {
+		return false
+	}
+	return sp == b.syncPeer || sp.LastBlock() >= height
+}
+
+// fetchHeaderBlocks requests the blocks of the header list within the download
+// window which are neither requested nor held yet.  Consecutive blocks are
+// requested as ranges from the same peer, starting with the peers with the
+// fewest blocks in flight, so the download is spread over all of the download
+// peers.
+func (b *blockManager) fetchHeaderBlocks(peers *list.List) {
+	// Nothing to do until all of the headers of the current round have
+	// been received.
+	if !b.headersSynced {
 		return
 	}
+	downloadPeers := b.blockDownloadPeers(peers)
+	if len(downloadPeers) == 0 {
+		return
+	}
+	sort.Slice(downloadPeers, func(i, j int) bool {
+		return len(downloadPeers[i].requestedBlocks) <
+			len(downloadPeers[j].requestedBlocks)
+	})
 
-	// Build up a getdata request for the list of blocks the headers
-	// describe.  The size hint will be limited to wire.MaxInvPerMsg by
-	// the function, so no need to double check it here.
-	gdmsg := wire.NewMsgGetDataSizeHint(uint(b.headerList.Len()))
-	numRequested := 0
-	for e := b.startHeader; e != nil; e = e.Next() {
+	// Build up a getdata request for each peer.  The number of blocks per
+	// peer is limited to maxBlocksInFlightPerPeer, so there is no need to
+	// check against wire.MaxInvPerMsg here.
+	gdmsgs := make(map[*serverPeer]*wire.MsgGetData)
+	now := time.Now()
+	peerIdx := 0
+	i := 0
+	for e := b.headerList.Front(); e != nil && i < blockDownloadWindow; e = e.Next() {
+		i++
 		node, ok := e.Value.(*headerNode)
 		if !ok {
 			bmgrLog.Warn("Header list node type is not a headerNode")
 			continue
 		}
+		if _, exists := b.blockRequests[*node.hash]; exists {
+			continue
+		}
+		if _, exists := b.blockBuffer[*node.hash]; exists {
+			continue
+		}
 
 		iv := wire.NewInvVect(wire.InvTypeBlock, node.hash)
 		haveInv, err := b.haveInventory(iv)
@@ -1312,29 +1809,115 @@ func (b *blockManager) fetchHeaderBlocks() {
 				"fetch: %v", err)
 			continue
 		}
-		if !haveInv {
-			b.requestedBlocks[*node.hash] = struct{}{}
-			b.requestedEverBlocks[*node.hash] = 0
-			b.syncPeer.requestedBlocks[*node.hash] = struct{}{}
-			err = gdmsg.AddInvVect(iv)
-			if err != nil {
-				bmgrLog.Warnf("Failed to add invvect while fetching "+
-					"block headers: %v", err)
-			}
-			numRequested++
+		if haveInv {
+			continue
 		}
-		b.startHeader = e.Next()
-		if numRequested >= wire.MaxInvPerMsg {
+
+		// Move on to the next peer once the current one can't take
+		// the block.
+		for peerIdx < len(downloadPeers) &&
+			!b.canRequestHeaderBlock(downloadPeers[peerIdx], node.height) {
+
+			peerIdx++
+		}
+		if peerIdx == len(downloadPeers) {
 			break
 		}
+		sp := downloadPeers[peerIdx]
+
+		b.requestedBlocks[*node.hash] = struct{}{}
+		b.requestedEverBlocks[*node.hash] = 0
+		sp.requestedBlocks[*node.hash] = struct{}{}
+		b.blockRequests[*node.hash] = &blockRequest{peer: sp, requested: now}
+		gdmsg, exists := gdmsgs[sp]
+		if !exists {
+			gdmsg = wire.NewMsgGetDataSizeHint(maxBlocksInFlightPerPeer)
+			gdmsgs[sp] = gdmsg
+		}
+		err = gdmsg.AddInvVect(iv)
+		if err != nil {
+			bmgrLog.Warnf("Failed to add invvect while fetching "+
+				"block headers: %v", err)
+		}
 	}
-	if len(gdmsg.InvList) > 0 {
-		b.syncPeer.QueueMessage(gdmsg, nil)
+	for sp, gdmsg := range gdmsgs {
+		sp.QueueMessage(gdmsg, nil)
 	}
 }
 
+// handleBlockStalls reassigns the blocks of the header list requested from
+// peers which have not delivered any of them within blockStallTimeout to other
+// peers.  Stalling peers other than the sync peer are disconnected when there
+// are other peers to download the blocks from.
+func (b *blockManager) handleBlockStalls(peers *list.List) {
+	if !b.headersFirstMode || len(b.blockRequests) == 0 {
+		return
+	}
+
+	now := time.Now()
+	stalled := make(map[*serverPeer]struct{})
+	for _, req := range b.blockRequests {
+		lastProgress := req.requested
+		if progress := b.blockProgress[req.peer]; progress.After(lastProgress) {
+			lastProgress = progress
+		}
+		if now.Sub(lastProgress) > blockStallTimeout {
+			stalled[req.peer] = struct{}{}
+		}
+	}
+	if len(stalled) == 0 {
+		return
+	}
+
+	haveOtherPeers := false
+	for _, sp := range b.blockDownloadPeers(peers) {
+		if _, exists := stalled[sp]; !exists {
+			haveOtherPeers = true
+			break
+		}
+	}
+	for sp := range stalled {
+		numReassigned := 0
+		for hash, req := range b.blockRequests {
+			if req.peer != sp {
+				continue
+			}
+			delete(sp.requestedBlocks, hash)
+			delete(b.requestedBlocks, hash)
+			delete(b.blockRequests, hash)
+			numReassigned++
+		}
+		b.blockStalls[sp] = now
+
+		if sp != b.syncPeer && haveOtherPeers {
+			bmgrLog.Infof("Peer %s stalled the block download -- "+
+				"reassigning %d blocks and disconnecting", sp,
+				numReassigned)
+			sp.Disconnect()
+			continue
+		}
+		bmgrLog.Infof("Peer %s stalled the block download -- "+
+			"reassigning %d blocks", sp, numReassigned)
+	}
+	b.fetchHeaderBlocks(peers)
+}
+
+// startBlockDownload switches from downloading headers to fetching the blocks
+// they describe once all of the headers of the current round were received.
+func (b *blockManager) startBlockDownload(peers *list.List) {
+	// Since the first entry of the list is always the final block that is
+	// already in the database and is only used to ensure the next header
+	// links properly, it must be removed before fetching the blocks.
+	b.headerList.Remove(b.headerList.Front())
+	b.headersSynced = true
+	bmgrLog.Infof("Received %v block headers: Fetching blocks",
+		b.headerList.Len())
+	b.progressLogger.SetLastLogTime(time.Now())
+	b.fetchHeaderBlocks(peers)
+}
+
 // handleHeadersMsg handles headers messages from all peers.
-func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
+func (b *blockManager) handleHeadersMsg(peers *list.List, hmsg *headersMsg) {
 	// The remote peer is misbehaving if we didn't request headers.
 	msg := hmsg.headers
 	numHeaders := len(msg.Headers)
@@ -1345,14 +1928,30 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		return
 	}
 
-	// Nothing to do for an empty headers message.
+	// Nothing to do for an empty headers message unless the headers after
+	// the final checkpoint are being downloaded.  In that case the sync
+	// peer has no more headers, so either fetch the blocks of the headers
+	// received so far or switch to normal mode when there are none.
 	if numHeaders == 0 {
+		if b.nextCheckpoint == nil && !b.headersSynced {
+			if b.searchingAssumeValid() {
+				b.finishAssumeValidSearch(hmsg.peer)
+			}
+			if b.headerList.Len() > 1 {
+				b.startBlockDownload(peers)
+				return
+			}
+			b.exitHeadersFirstMode()
+		}
 		return
 	}
 
 	// Process all of the received headers ensuring each one connects to the
-	// previous and that checkpoints match.
+	// previous and that checkpoints match.  While searching for the assumed
+	// valid block, the headers beyond the first round are not added to the
+	// list.
 	receivedCheckpoint := false
+	searching := b.searchingAssumeValid()
 	var finalHash *chainhash.Hash
 	for _, blockHeader := range msg.Headers {
 		blockHash := blockHeader.BlockHash()
@@ -1368,15 +1967,30 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		}
 
 		// Ensure the header properly connects to the previous one and
-		// add it to the list of headers.
+		// add it to the list of headers.  The headers after the final
+		// checkpoint do not connect to the best block when it is not
+		// part of the chain of the sync peer, so fall back to normal
+		// mode in that case to let it find the fork point.
 		node := headerNode{hash: &blockHash}
 		prevNode := prevNodeEl.Value.(*headerNode)
+		if b.assumeValidSearchTip != nil {
+			prevNode = b.assumeValidSearchTip
+		}
+		inList := true
 		if prevNode.hash.IsEqual(&blockHeader.PrevBlock) {
 			node.height = prevNode.height + 1
-			e := b.headerList.PushBack(&node)
-			if b.startHeader == nil {
-				b.startHeader = e
+			if searching && b.headerList.Len() > maxHeadersPerRound {
+				b.assumeValidSearchTip = &node
+				inList = false
+			} else {
+				b.headerList.PushBack(&node)
 			}
+		} else if b.nextCheckpoint == nil && b.headerList.Len() == 1 {
+			bmgrLog.Infof("Headers from peer %s do not extend the "+
+				"best chain -- switching to normal mode",
+				hmsg.peer.Addr())
+			b.exitHeadersFirstMode()
+			return
 		} else {
 			bmgrLog.Warnf("Received block header that does not "+
 				"properly connect to the chain from peer %s "+
@@ -1385,8 +1999,29 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 			return
 		}
 
+		// Record the ancestors of the assumed valid block until it is
+		// found and note up to which height the header list follows its
+		// chain.
+		if searching && b.assumeValidHeight == 0 &&
+			(node.height%assumeValidAnchorInterval == 0 ||
+				node.hash.IsEqual(b.assumeValid)) {
+
+			b.assumeValidAnchors[node.height] = *node.hash
+			if node.hash.IsEqual(b.assumeValid) {
+				b.assumeValidHeight = node.height
+			}
+		}
+		if inList {
+			anchor, exists := b.assumeValidAnchors[node.height]
+			if exists && anchor == *node.hash {
+				b.assumeValidListHeight = node.height
+			}
+		}
+
 		// Verify the header at the next checkpoint height matches.
-		if node.height == b.nextCheckpoint.Height {
+		if b.nextCheckpoint != nil &&
+			node.height == b.nextCheckpoint.Height {
+
 			if node.hash.IsEqual(b.nextCheckpoint.Hash) {
 				receivedCheckpoint = true
 				bmgrLog.Infof("Verified downloaded block "+
@@ -1406,26 +2041,38 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 		}
 	}
 
+	// The search for the assumed valid block is over once it is found or
+	// the sync peer has no more headers.
+	if searching && (b.assumeValidHeight != 0 ||
+		numHeaders < wire.MaxBlockHeadersPerMsg) {
+
+		b.finishAssumeValidSearch(hmsg.peer)
+	}
+
 	// When this header is a checkpoint, switch to fetching the blocks for
-	// all of the headers since the last checkpoint.
-	if receivedCheckpoint {
-		// Since the first entry of the list is always the final block
-		// that is already in the database and is only used to ensure
-		// the next header links properly, it must be removed before
-		// fetching the blocks.
-		b.headerList.Remove(b.headerList.Front())
-		bmgrLog.Infof("Received %v block headers: Fetching blocks",
-			b.headerList.Len())
-		b.progressLogger.SetLastLogTime(time.Now())
-		b.fetchHeaderBlocks()
+	// all of the headers since the last checkpoint.  The same applies
+	// after the final checkpoint once the sync peer has no more headers,
+	// which is signalled by a partial headers message, or the maximum
+	// number of headers per round were received and the assumed valid
+	// block is not being searched for.
+	if receivedCheckpoint || (b.nextCheckpoint == nil &&
+		!b.searchingAssumeValid() &&
+		(numHeaders < wire.MaxBlockHeadersPerMsg ||
+			b.headerList.Len() > maxHeadersPerRound)) {
+
+		b.startBlockDownload(peers)
 		return
 	}
 
 	// This header is not a checkpoint, so request the next batch of
 	// headers starting from the latest known header and ending with the
-	// next checkpoint.
+	// next checkpoint or the end of the chain of the sync peer.
+	stopHash := &zeroHash
+	if b.nextCheckpoint != nil {
+		stopHash = b.nextCheckpoint.Hash
+	}
 	locator := blockchain.BlockLocator([]*chainhash.Hash{finalHash})
-	err := hmsg.peer.PushGetHeadersMsg(locator, b.nextCheckpoint.Hash)
+	err := hmsg.peer.PushGetHeadersMsg(locator, stopHash)
 	if err != nil {
 		bmgrLog.Warnf("Failed to send getheaders message to "+
 			"peer %s: %v", hmsg.peer.Addr(), err)
@@ -1433,6 +2080,32 @@ func (b *blockManager) handleHeadersMsg(hmsg *headersMsg) {
 	}
 }
 
+// searchingAssumeValid returns whether the headers after the final checkpoint
+// which are received in headers-first mode are searched for the assumed valid
+// block.
+func (b *blockManager) searchingAssumeValid() bool {
+	return b.assumeValid != nil && !b.assumeValidResolved &&
+		b.nextCheckpoint == nil
+}
+
+// finishAssumeValidSearch ends the search for the assumed valid block in the
+// headers of the passed sync peer.  The recorded ancestors are dropped when the
+// block was not found since they are not known to be part of its chain.  The
+// search is not repeated in either case.
+func (b *blockManager) finishAssumeValidSearch(sp *serverPeer) {
+	b.assumeValidResolved = true
+	b.assumeValidSearchTip = nil
+	if b.assumeValidHeight == 0 {
+		b.assumeValidAnchors = make(map[int64]chainhash.Hash)
+		b.assumeValidListHeight = 0
+		bmgrLog.Infof("Assumed valid block %v is not part of the chain "+
+			"of peer %s -- validating all scripts", b.assumeValid, sp)
+		return
+	}
+	bmgrLog.Infof("Assuming valid scripts for the blocks up to height "+
+		"%d/hash %s", b.assumeValidHeight, b.assumeValid)
+}
+
 // haveInventory returns whether or not the inventory represented by the passed
 // inventory vector is known.  This includes checking all of the various places
 // inventory can be when it is in different states such as blocks that are part
@@ -1594,6 +2267,12 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 		}
 	}
 
+	// Request new blocks as compact blocks when the chain is current and the
+	// peer supports them since the transactions are most likely in the
+	// memory pool already.
+	requestCmpct := b.current() &&
+		imsg.peer.CmpctBlockVersion() == wire.CmpctBlockVersion
+
 	// Request as much as possible at once.  Anything that won't fit into
 	// the request will be requested on the next inv message.
 	numRequested := 0
@@ -1613,6 +2292,10 @@ func (b *blockManager) handleInvMsg(imsg *invMsg) {
 				b.requestedEverBlocks[iv.Hash] = 0
 				b.limitMap(b.requestedBlocks, maxRequestedBlocks)
 				imsg.peer.requestedBlocks[iv.Hash] = struct{}{}
+				if requestCmpct {
+					iv = wire.NewInvVect(wire.InvTypeCmpctBlock,
+						&iv.Hash)
+				}
 				gdmsg.AddInvVect(iv)
 				numRequested++
 			}
@@ -1666,6 +2349,8 @@ func (b *blockManager) limitMap(m map[chainhash.Hash]struct{}, limit int) {
 // the fetching should proceed.
 func (b *blockManager) blockHandler() {
 	candidatePeers := list.New()
+	stallTicker := time.NewTicker(blockStallCheckInterval)
+	defer stallTicker.Stop()
 out:
 	for {
 		select {
@@ -1679,14 +2364,22 @@ out:
 				msg.peer.txProcessed <- struct{}{}
 
 			case *blockMsg:
-				b.handleBlockMsg(msg)
+				b.handleBlockMsg(candidatePeers, msg)
+				msg.peer.blockProcessed <- struct{}{}
+
+			case *cmpctBlockMsg:
+				b.handleCmpctBlockMsg(candidatePeers, msg)
+				msg.peer.blockProcessed <- struct{}{}
+
+			case *blockTxnMsg:
+				b.handleBlockTxnMsg(candidatePeers, msg)
 				msg.peer.blockProcessed <- struct{}{}
 
 			case *invMsg:
 				b.handleInvMsg(msg)
 
 			case *headersMsg:
-				b.handleHeadersMsg(msg)
+				b.handleHeadersMsg(candidatePeers, msg)
 
 			case *donePeerMsg:
 				b.handleDonePeerMsg(candidatePeers, msg.peer)
@@ -1967,11 +2660,21 @@ out:
 					"handler: %T", msg)
 			}
 
+		case <-stallTicker.C:
+			b.handleBlockStalls(candidatePeers)
+			b.handleBlockTxnTimeouts(candidatePeers)
+
 		case <-b.quit:
 			break out
 		}
 	}
 
+	// Write the changes to the utxo set which are still cached to the
+	// database so they do not need to be recovered on the next start.
+	if err := b.chain.FlushUtxoCache(); err != nil {
+		bmgrLog.Errorf("Unable to flush the utxo cache: %v", err)
+	}
+
 	b.wg.Done()
 	bmgrLog.Trace("Block handler done")
 }
@@ -1998,6 +2701,7 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		}
 		block := band.Block
 		r := b.server.rpcServer
+		b.recordBlockArrival(block)
 
 		// Determine the winning tickets for this block if it hasn't
 		// already been sent out.  Skip notifications if we're not
@@ -2063,6 +2767,11 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 		block := blockSlice[0]
 		parentBlock := blockSlice[1]
 
+		// Record the confirmation of the transactions tracked by the
+		// fee estimator.  This must happen before the transactions are
+		// removed from the memory pool below.
+		b.server.feeEstimator.ProcessBlock(block)
+
 		// Check and see if the regular tx tree of the previous block was
 		// invalid or not. If it wasn't, then we need to restore all the tx
 		// from this block into the mempool. They may end up being spent in
@@ -2130,6 +2839,11 @@ func (b *blockManager) handleNotifyMsg(notification *blockchain.Notification) {
 			r.ntfnMgr.NotifyBlockConnected(block)
 		}
 
+		// Hand out new work to Stratum clients building on the block.
+		if s := b.server.stratumServer; s != nil {
+			s.NotifyBlockConnected()
+		}
+
 	// Stake tickets are spent or missed from the most recently connected block.
 	case blockchain.NTSpentAndMissedTickets:
 		tnd, ok := notification.Data.(*blockchain.TicketNotificationsData)
@@ -2265,6 +2979,30 @@ func (b *blockManager) QueueBlock(block *hcutil.Block, sp *serverPeer) {
 	b.msgChan <- &blockMsg{block: block, peer: sp}
 }
 
+// QueueCmpctBlock adds the passed cmpctblock message and peer to the block
+// handling queue.
+func (b *blockManager) QueueCmpctBlock(cmpctBlock *wire.MsgCmpctBlock, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &cmpctBlockMsg{cmpctBlock: cmpctBlock, peer: sp}
+}
+
+// QueueBlockTxn adds the passed blocktxn message and peer to the block handling
+// queue.
+func (b *blockManager) QueueBlockTxn(blockTxn *wire.MsgBlockTxn, sp *serverPeer) {
+	// Don't accept more blocks if we're shutting down.
+	if atomic.LoadInt32(&b.shutdown) != 0 {
+		sp.blockProcessed <- struct{}{}
+		return
+	}
+
+	b.msgChan <- &blockTxnMsg{blockTxn: blockTxn, peer: sp}
+}
+
 // QueueInv adds the passed inv message and peer to the block handling queue.
 func (b *blockManager) QueueInv(inv *wire.MsgInv, sp *serverPeer) {
 	// No channel handling here because peers do not need to block on inv
@@ -2497,6 +3235,53 @@ func (b *blockManager) TipGeneration() ([]chainhash.Hash, error) {
 	return response.hashes, response.err
 }
 
+// blockArrival describes when a block was accepted into the block chain.
+type blockArrival struct {
+	height   int64
+	received time.Time
+}
+
+// recordBlockArrival records the passed block was accepted into the block
+// chain now unless its arrival is already known.  The arrivals of blocks which
+// are too old for their lottery data to be calculated are forgotten.  Nothing
+// is recorded unless the block manager believes it is synced, since blocks
+// processed while catching up did not arrive when they were announced.
+//
+// This is UNSAFE for concurrent access. It must be called from the block
+// handler goroutine.
+func (b *blockManager) recordBlockArrival(block *hcutil.Block) {
+	if !b.current() {
+		return
+	}
+
+	height := block.Height()
+	b.blockArrivalsMutex.Lock()
+	if _, ok := b.blockArrivals[*block.Hash()]; !ok {
+		b.blockArrivals[*block.Hash()] = blockArrival{
+			height:   height,
+			received: time.Now(),
+		}
+	}
+	for hash, arrival := range b.blockArrivals {
+		if arrival.height <= height-maxLotteryDataBlockDelta {
+			delete(b.blockArrivals, hash)
+		}
+	}
+	b.blockArrivalsMutex.Unlock()
+}
+
+// BlockArrival returns the time the block with the passed hash was accepted
+// into the block chain and whether it is known.  Only the arrivals of recent
+// blocks accepted while the block manager believed it was synced are known.
+//
+// This function is safe for concurrent access.
+func (b *blockManager) BlockArrival(hash *chainhash.Hash) (time.Time, bool) {
+	b.blockArrivalsMutex.Lock()
+	arrival, ok := b.blockArrivals[*hash]
+	b.blockArrivalsMutex.Unlock()
+	return arrival.received, ok
+}
+
 // GetTopBlockFromChain obtains the current top block from HEAD of the blockchain.
 // Returns a pointer to the cached copy of the block in memory.
 func (b *blockManager) GetTopBlockFromChain() (*hcutil.Block, error) {
@@ -2597,6 +3382,13 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 		progressLogger:      newBlockProgressLogger("Processed", bmgrLog),
 		msgChan:             make(chan interface{}, cfg.MaxPeers*3),
 		headerList:          list.New(),
+		blockRequests:       make(map[chainhash.Hash]*blockRequest),
+		blockBuffer:         make(map[chainhash.Hash]*blockMsg),
+		blockProgress:       make(map[*serverPeer]time.Time),
+		blockStalls:         make(map[*serverPeer]time.Time),
+		partialBlocks:       make(map[*serverPeer]*pendingCmpctBlock),
+		assumeValid:         cfg.assumeValid,
+		assumeValidAnchors:  make(map[int64]chainhash.Hash),
 		AggressiveMining:    !cfg.NonAggressive,
 		quit:                make(chan struct{}),
 	}
@@ -2604,17 +3396,29 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 	// Create a new block chain instance with the appropriate configuration.
 	var err error
 	bm.chain, err = blockchain.New(&blockchain.Config{
-		DB:            s.db,
-		ChainParams:   s.chainParams,
-		TimeSource:    s.timeSource,
-		Notifications: bm.handleNotifyMsg,
-		SigCache:      s.sigCache,
-		IndexManager:  indexManager,
+		DB:               s.db,
+		ChainParams:      s.chainParams,
+		TimeSource:       s.timeSource,
+		Notifications:    bm.handleNotifyMsg,
+		SigCache:         s.sigCache,
+		IndexManager:     indexManager,
+		PruneTarget:      cfg.Prune * 1024 * 1024,
+		UtxoCacheMaxSize: uint64(cfg.UtxoCacheMaxSize) * 1024 * 1024,
 	})
 	if err != nil {
 		return nil, err
 	}
 	best := bm.chain.BestSnapshot()
+
+	// There is no need to search for the assumed valid block when it is
+	// already part of the main chain.
+	if bm.assumeValid != nil {
+		height, err := bm.chain.BlockHeightByHash(bm.assumeValid)
+		if err == nil {
+			bm.assumeValidResolved = true
+			bm.assumeValidHeight = height
+		}
+	}
 	bm.chain.DisableCheckpoints(cfg.DisableCheckpoints)
 	if !cfg.DisableCheckpoints {
 		// Initialize the next checkpoint based on the current height.
@@ -2664,6 +3468,7 @@ func newBlockManager(s *server, indexManager blockchain.IndexManager) (*blockMan
 		missedTickets,
 		curPrevHash)
 	bm.lotteryDataBroadcast = make(map[chainhash.Hash]struct{})
+	bm.blockArrivals = make(map[chainhash.Hash]blockArrival)
 
 	return &bm, nil
 }
@@ -0,0 +1,627 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"container/list"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/chaingen"
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+	_ "github.com/HcashOrg/hcd/database/ffldb"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/peer"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// newTestBlockManager returns a block manager for the simulation test network
+// which is backed by a chain only containing the genesis block and is not
+// started.  The returned teardown function must be invoked when done testing.
+func newTestBlockManager(t *testing.T) (*blockManager, func()) {
+	t.Helper()
+
+	setLogLevels("off")
+	params := &chaincfg.SimNetParams
+	dbPath, err := ioutil.TempDir("", "blockmanagertest")
+	if err != nil {
+		t.Fatalf("unable to create temp dir: %v", err)
+	}
+	db, err := database.Create("ffldb", filepath.Join(dbPath, "db"),
+		params.Net)
+	if err != nil {
+		os.RemoveAll(dbPath)
+		t.Fatalf("unable to create db: %v", err)
+	}
+	teardown := func() {
+		db.Close()
+		os.RemoveAll(dbPath)
+	}
+
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: params,
+		TimeSource:  blockchain.NewMedianTime(),
+	})
+	if err != nil {
+		teardown()
+		t.Fatalf("unable to create chain: %v", err)
+	}
+
+	bm := &blockManager{
+		server:               &server{chainParams: params},
+		chain:                chain,
+		rejectedTxns:         make(map[chainhash.Hash]struct{}),
+		requestedTxns:        make(map[chainhash.Hash]struct{}),
+		requestedEverTxns:    make(map[chainhash.Hash]uint8),
+		requestedBlocks:      make(map[chainhash.Hash]struct{}),
+		requestedEverBlocks:  make(map[chainhash.Hash]uint8),
+		progressLogger:       newBlockProgressLogger("Processed", bmgrLog),
+		headerList:           list.New(),
+		blockRequests:        make(map[chainhash.Hash]*blockRequest),
+		blockBuffer:          make(map[chainhash.Hash]*blockMsg),
+		blockProgress:        make(map[*serverPeer]time.Time),
+		blockStalls:          make(map[*serverPeer]time.Time),
+		partialBlocks:        make(map[*serverPeer]*pendingCmpctBlock),
+		lotteryDataBroadcast: make(map[chainhash.Hash]struct{}),
+		blockArrivals:        make(map[chainhash.Hash]blockArrival),
+		quit:                 make(chan struct{}),
+	}
+	return bm, teardown
+}
+
+// newTestServerPeer returns a connected outbound server peer which is known to
+// have the blocks up to the passed height.  Everything the peer sends is
+// discarded.  The returned teardown function must be invoked when done testing.
+func newTestServerPeer(t *testing.T, lastBlock int64) (*serverPeer, func()) {
+	t.Helper()
+
+	p, err := peer.NewOutboundPeer(&peer.Config{
+		ChainParams: &chaincfg.SimNetParams,
+	}, "127.0.0.1:18555")
+	if err != nil {
+		t.Fatalf("unable to create peer: %v", err)
+	}
+	conn, remote := net.Pipe()
+	go io.Copy(ioutil.Discard, remote)
+	p.AssociateConnection(conn)
+	p.UpdateLastBlockHeight(lastBlock)
+
+	sp := newServerPeer(nil, false)
+	sp.Peer = p
+	return sp, func() {
+		p.Disconnect()
+		remote.Close()
+	}
+}
+
+// startTestHeadersRound puts the block manager in headers-first mode after
+// the final checkpoint with all of the passed headers received from the sync
+// peer, so the blocks they describe are ready to be fetched.  Any outstanding
+// block requests of a previous round are abandoned.
+func startTestHeadersRound(bm *blockManager, syncPeer *serverPeer, nodes []*headerNode) {
+	best := bm.chain.BestSnapshot()
+	bm.resetHeaderState(best.Hash, best.Height)
+	bm.syncPeer = syncPeer
+	bm.headersFirstMode = true
+	for _, node := range nodes {
+		bm.headerList.PushBack(node)
+	}
+	bm.headerList.Remove(bm.headerList.Front())
+	bm.headersSynced = true
+}
+
+// fakeHeaderNodes returns header nodes with hashes which are unknown to the
+// chain for the passed range of heights.
+func fakeHeaderNodes(startHeight, endHeight int64) []*headerNode {
+	var nodes []*headerNode
+	for height := startHeight; height <= endHeight; height++ {
+		var hash chainhash.Hash
+		copy(hash[:], fmt.Sprintf("fakeheader%d", height))
+		nodes = append(nodes, &headerNode{hash: &hash, height: height})
+	}
+	return nodes
+}
+
+// testBlockMsg returns a block message for the passed block as delivered by
+// the passed peer.
+func testBlockMsg(block *wire.MsgBlock, sp *serverPeer) *blockMsg {
+	return &blockMsg{block: hcutil.NewBlock(block), peer: sp}
+}
+
+// testHeaderChain returns the passed number of headers which extend the block
+// with the passed hash and height.  Chains which extend the same block are
+// distinguished by the nonce.  The headers are not valid, but link together.
+func testHeaderChain(prevHash chainhash.Hash, prevHeight int64, count int, nonce uint32) []*wire.BlockHeader {
+	headers := make([]*wire.BlockHeader, 0, count)
+	for i := 0; i < count; i++ {
+		header := &wire.BlockHeader{
+			PrevBlock: prevHash,
+			Height:    uint32(prevHeight) + uint32(i) + 1,
+			Nonce:     nonce,
+		}
+		headers = append(headers, header)
+		prevHash = header.BlockHash()
+	}
+	return headers
+}
+
+// sendTestHeaders delivers the passed headers from the passed peer to the block
+// manager in messages of the maximum size.
+func sendTestHeaders(bm *blockManager, peers *list.List, sp *serverPeer, headers []*wire.BlockHeader) {
+	for len(headers) > 0 {
+		msg := wire.NewMsgHeaders()
+		for len(headers) > 0 && len(msg.Headers) < wire.MaxBlockHeadersPerMsg {
+			msg.AddBlockHeader(headers[0])
+			headers = headers[1:]
+		}
+		bm.handleHeadersMsg(peers, &headersMsg{headers: msg, peer: sp})
+	}
+}
+
+// startTestHeadersDownload puts the block manager in headers-first mode after
+// the final checkpoint with the headers after the passed block being requested
+// from the sync peer.
+func startTestHeadersDownload(bm *blockManager, syncPeer *serverPeer, hash *chainhash.Hash, height int64) {
+	bm.resetHeaderState(hash, height)
+	bm.syncPeer = syncPeer
+	bm.headersFirstMode = true
+}
+
+// TestFetchHeaderBlocksWindow ensures the blocks of the header list are only
+// requested within the download window, spread over the download peers without
+// exceeding the blocks in flight per peer, and that the window advances as the
+// blocks next in line are processed.
+func TestFetchHeaderBlocksWindow(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	// Generate the first block of the header list so it can be processed
+	// and describe the remaining blocks by headers unknown to the chain.
+	g, err := chaingen.MakeGenerator(&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("unable to create generator: %v", err)
+	}
+	g.CreatePremineBlock("bp", 0)
+	lastHeight := int64(blockDownloadWindow + 10)
+	firstHash := g.Tip().BlockHash()
+	nodes := []*headerNode{{hash: &firstHash, height: 1}}
+	nodes = append(nodes, fakeHeaderNodes(2, lastHeight)...)
+
+	// Create enough peers for the download window to be filled.
+	peers := list.New()
+	numPeers := blockDownloadWindow/maxBlocksInFlightPerPeer + 1
+	for i := 0; i < numPeers; i++ {
+		sp, teardownPeer := newTestServerPeer(t, lastHeight)
+		defer teardownPeer()
+		peers.PushBack(sp)
+	}
+	startTestHeadersRound(bm, peers.Front().Value.(*serverPeer), nodes)
+
+	// Ensure exactly the blocks within the window are requested.
+	bm.fetchHeaderBlocks(peers)
+	if len(bm.blockRequests) != blockDownloadWindow {
+		t.Fatalf("unexpected number of block requests -- got %d, want %d",
+			len(bm.blockRequests), blockDownloadWindow)
+	}
+	for i, node := range nodes {
+		_, requested := bm.blockRequests[*node.hash]
+		if requested != (i < blockDownloadWindow) {
+			t.Fatalf("unexpected request state for header %d -- "+
+				"got %v, want %v", i, requested,
+				i < blockDownloadWindow)
+		}
+		if _, exists := bm.requestedBlocks[*node.hash]; exists != requested {
+			t.Fatalf("requested blocks state for header %d does "+
+				"not match block requests", i)
+		}
+	}
+	for e := peers.Front(); e != nil; e = e.Next() {
+		sp := e.Value.(*serverPeer)
+		if len(sp.requestedBlocks) > maxBlocksInFlightPerPeer {
+			t.Fatalf("peer %s has %d blocks in flight", sp,
+				len(sp.requestedBlocks))
+		}
+		for hash := range sp.requestedBlocks {
+			if req := bm.blockRequests[hash]; req == nil || req.peer != sp {
+				t.Fatalf("block %v of peer %s is not assigned "+
+					"to it", hash, sp)
+			}
+		}
+	}
+
+	// Ensure a subsequent fetch does not request any blocks again.
+	bm.fetchHeaderBlocks(peers)
+	if len(bm.blockRequests) != blockDownloadWindow {
+		t.Fatalf("unexpected number of block requests after refetch "+
+			"-- got %d, want %d", len(bm.blockRequests),
+			blockDownloadWindow)
+	}
+
+	// Deliver the first block and ensure it is processed and the window
+	// advances by exactly one block.
+	firstReq := bm.blockRequests[*nodes[0].hash]
+	bm.handleBlockMsg(peers, testBlockMsg(g.Tip(), firstReq.peer))
+	if best := bm.chain.BestSnapshot(); best.Height != 1 {
+		t.Fatalf("first block was not processed -- best height %d",
+			best.Height)
+	}
+	if bm.headerList.Len() != len(nodes)-1 {
+		t.Fatalf("unexpected header list length -- got %d, want %d",
+			bm.headerList.Len(), len(nodes)-1)
+	}
+	if _, exists := bm.blockRequests[*nodes[0].hash]; exists {
+		t.Fatal("request for processed block was not removed")
+	}
+	if _, exists := bm.blockProgress[firstReq.peer]; !exists {
+		t.Fatal("progress of the delivering peer was not recorded")
+	}
+	if _, exists := bm.blockRequests[*nodes[blockDownloadWindow].hash]; !exists {
+		t.Fatal("block after the advanced window start was not requested")
+	}
+	next := nodes[blockDownloadWindow+1]
+	if _, exists := bm.blockRequests[*next.hash]; exists {
+		t.Fatal("block beyond the advanced window was requested")
+	}
+}
+
+// TestHeaderBlockStallReassignment ensures the blocks of the header list which
+// were requested from peers which disconnect or stall are reassigned to the
+// remaining peers and that stalling peers are only disconnected when they are
+// not the sync peer.
+func TestHeaderBlockStallReassignment(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	nodes := fakeHeaderNodes(1, 20)
+	syncPeer, teardownSyncPeer := newTestServerPeer(t, 20)
+	defer teardownSyncPeer()
+
+	// assertAssigned ensures all of the blocks of the header list are
+	// requested from the passed peer.
+	assertAssigned := func(sp *serverPeer) {
+		t.Helper()
+		if len(bm.blockRequests) != len(nodes) {
+			t.Fatalf("unexpected number of block requests -- got "+
+				"%d, want %d", len(bm.blockRequests), len(nodes))
+		}
+		if len(sp.requestedBlocks) != len(nodes) {
+			t.Fatalf("unexpected number of blocks in flight for "+
+				"peer %s -- got %d, want %d", sp,
+				len(sp.requestedBlocks), len(nodes))
+		}
+		for _, node := range nodes {
+			if req := bm.blockRequests[*node.hash]; req.peer != sp {
+				t.Fatalf("block %d is not assigned to peer %s",
+					node.height, sp)
+			}
+		}
+	}
+
+	// Request all of the blocks from a peer other than the sync peer and
+	// ensure they are reassigned to the sync peer once it disconnects.
+	donePeer, teardownDonePeer := newTestServerPeer(t, 20)
+	defer teardownDonePeer()
+	peers := list.New()
+	peers.PushBack(donePeer)
+	startTestHeadersRound(bm, syncPeer, nodes)
+	bm.fetchHeaderBlocks(peers)
+	assertAssigned(donePeer)
+	peers.PushBack(syncPeer)
+	donePeer.Disconnect()
+	bm.handleDonePeerMsg(peers, donePeer)
+	assertAssigned(syncPeer)
+	if peers.Len() != 1 {
+		t.Fatal("disconnected peer was not removed from the peers")
+	}
+
+	// Request all of the blocks from another peer and ensure they are
+	// reassigned to the sync peer once the peer stalls and that the
+	// stalling peer is disconnected.
+	stallPeer, teardownStallPeer := newTestServerPeer(t, 20)
+	defer teardownStallPeer()
+	peers.Init()
+	peers.PushBack(stallPeer)
+	startTestHeadersRound(bm, syncPeer, nodes)
+	bm.fetchHeaderBlocks(peers)
+	assertAssigned(stallPeer)
+	peers.PushBack(syncPeer)
+
+	// Nothing is reassigned before the stall timeout.
+	bm.handleBlockStalls(peers)
+	assertAssigned(stallPeer)
+
+	stalledTime := time.Now().Add(-2 * blockStallTimeout)
+	for _, req := range bm.blockRequests {
+		req.requested = stalledTime
+	}
+	bm.handleBlockStalls(peers)
+	assertAssigned(syncPeer)
+	if stallPeer.Connected() {
+		t.Fatal("stalling peer was not disconnected")
+	}
+	if _, exists := bm.blockStalls[stallPeer]; !exists {
+		t.Fatal("stall of the stalling peer was not recorded")
+	}
+
+	// Ensure the blocks are requested from the sync peer again when it
+	// stalls and there are no other peers, without disconnecting it.
+	peers.Init()
+	peers.PushBack(syncPeer)
+	for _, req := range bm.blockRequests {
+		req.requested = stalledTime
+	}
+	bm.handleBlockStalls(peers)
+	assertAssigned(syncPeer)
+	for _, req := range bm.blockRequests {
+		if !req.requested.After(stalledTime) {
+			t.Fatal("stalled block request was not renewed")
+		}
+	}
+	if !syncPeer.Connected() {
+		t.Fatal("stalling sync peer was disconnected")
+	}
+	if _, exists := bm.blockStalls[syncPeer]; !exists {
+		t.Fatal("stall of the sync peer was not recorded")
+	}
+}
+
+// TestHeaderBlockBuffering ensures the blocks of the header list which are
+// received before the blocks preceding them are held until they are next in
+// line, are then processed in order, and that the block manager switches to
+// normal mode once all of the blocks of the header list are processed.
+func TestHeaderBlockBuffering(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	g, err := chaingen.MakeGenerator(&chaincfg.SimNetParams)
+	if err != nil {
+		t.Fatalf("unable to create generator: %v", err)
+	}
+	g.CreatePremineBlock("bp", 0)
+	blocks := []*wire.MsgBlock{g.Tip()}
+	for i := 0; i < 3; i++ {
+		g.NextBlock(fmt.Sprintf("b%d", i), nil, nil)
+		blocks = append(blocks, g.Tip())
+	}
+	var nodes []*headerNode
+	for _, block := range blocks {
+		hash := block.BlockHash()
+		nodes = append(nodes, &headerNode{
+			hash:   &hash,
+			height: int64(block.Header.Height),
+		})
+	}
+
+	syncPeer, teardownSyncPeer := newTestServerPeer(t, int64(len(blocks)))
+	defer teardownSyncPeer()
+	otherPeer, teardownOtherPeer := newTestServerPeer(t, int64(len(blocks)))
+	defer teardownOtherPeer()
+	peers := list.New()
+	peers.PushBack(syncPeer)
+	startTestHeadersRound(bm, syncPeer, nodes)
+	bm.fetchHeaderBlocks(peers)
+	if len(syncPeer.requestedBlocks) != len(blocks) {
+		t.Fatalf("unexpected number of blocks in flight -- got %d, "+
+			"want %d", len(syncPeer.requestedBlocks), len(blocks))
+	}
+
+	// assertBestHeight ensures the best block of the chain has the passed
+	// height.
+	assertBestHeight := func(height int64) {
+		t.Helper()
+		if best := bm.chain.BestSnapshot(); best.Height != height {
+			t.Fatalf("unexpected best height -- got %d, want %d",
+				best.Height, height)
+		}
+	}
+
+	// Deliver the third and second blocks before the first one and ensure
+	// they are held without being processed.
+	bm.handleBlockMsg(peers, testBlockMsg(blocks[2], syncPeer))
+	bm.handleBlockMsg(peers, testBlockMsg(blocks[1], syncPeer))
+	assertBestHeight(0)
+	if len(bm.blockBuffer) != 2 {
+		t.Fatalf("unexpected number of held blocks -- got %d, want 2",
+			len(bm.blockBuffer))
+	}
+	for _, block := range blocks[1:3] {
+		hash := block.BlockHash()
+		if _, exists := bm.blockBuffer[hash]; !exists {
+			t.Fatalf("block %v was not held", hash)
+		}
+		if _, exists := syncPeer.requestedBlocks[hash]; exists {
+			t.Fatalf("held block %v is still in flight", hash)
+		}
+	}
+
+	// Ensure a held block which is delivered again by another peer is
+	// dropped.
+	dupHash := blocks[2].BlockHash()
+	otherPeer.requestedBlocks[dupHash] = struct{}{}
+	bm.handleBlockMsg(peers, testBlockMsg(blocks[2], otherPeer))
+	if len(bm.blockBuffer) != 2 || bm.blockBuffer[dupHash].peer != syncPeer {
+		t.Fatal("duplicate of held block replaced the held block")
+	}
+	if _, exists := otherPeer.requestedBlocks[dupHash]; exists {
+		t.Fatal("duplicate of held block is still in flight")
+	}
+
+	// Deliver the first block and ensure the held blocks are flushed in
+	// order.
+	bm.handleBlockMsg(peers, testBlockMsg(blocks[0], syncPeer))
+	assertBestHeight(3)
+	if len(bm.blockBuffer) != 0 {
+		t.Fatalf("held blocks were not flushed -- %d remaining",
+			len(bm.blockBuffer))
+	}
+	if bm.headerList.Len() != 1 {
+		t.Fatalf("unexpected header list length -- got %d, want 1",
+			bm.headerList.Len())
+	}
+	if !bm.headersFirstMode {
+		t.Fatal("left headers-first mode before processing all blocks")
+	}
+
+	// Deliver the final block and ensure the block manager switches to
+	// normal mode since the sync peer has no further blocks.
+	bm.handleBlockMsg(peers, testBlockMsg(blocks[3], syncPeer))
+	assertBestHeight(4)
+	if bm.headersFirstMode {
+		t.Fatal("did not leave headers-first mode after processing " +
+			"all blocks")
+	}
+	if len(bm.blockRequests) != 0 || len(syncPeer.requestedBlocks) != 0 {
+		t.Fatal("block requests remain after leaving headers-first mode")
+	}
+}
+
+// TestAssumeValidSearch ensures the assumed valid block is searched for in the
+// headers after the final checkpoint once, beyond the first round of headers,
+// and that the scripts of the blocks of every round of headers are only skipped
+// up to the last recorded ancestor of the assumed valid block the round contains.
+func TestAssumeValidSearch(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	syncPeer, teardownSyncPeer := newTestServerPeer(t, 0)
+	defer teardownSyncPeer()
+	peers := list.New()
+	peers.PushBack(syncPeer)
+
+	// Create a chain of headers which extends beyond the first round with
+	// the assumed valid block after the first round.
+	genesisHash := *chaincfg.SimNetParams.GenesisHash
+	headers := testHeaderChain(genesisHash, 0, maxHeadersPerRound+5000, 0)
+	avHeight := int64(maxHeadersPerRound + 4000)
+	avHash := headers[avHeight-1].BlockHash()
+	bm.assumeValid = &avHash
+
+	// assertListHeight ensures the header list is known to follow the chain
+	// of the assumed valid block up to the passed height.
+	assertListHeight := func(height int64) {
+		t.Helper()
+		if bm.assumeValidListHeight != height {
+			t.Fatalf("unexpected assumed valid height of the header "+
+				"list -- got %d, want %d", bm.assumeValidListHeight,
+				height)
+		}
+	}
+
+	// Ensure the search continues beyond the first round of headers while
+	// only the headers of the first round are kept and that the blocks of
+	// the first round are fetched once it is found.
+	startTestHeadersDownload(bm, syncPeer, &genesisHash, 0)
+	sendTestHeaders(bm, peers, syncPeer, headers[:avHeight])
+	if !bm.assumeValidResolved {
+		t.Fatal("assumed valid block was not resolved")
+	}
+	if bm.assumeValidHeight != avHeight {
+		t.Fatalf("unexpected assumed valid height -- got %d, want %d",
+			bm.assumeValidHeight, avHeight)
+	}
+	if bm.assumeValidSearchTip != nil {
+		t.Fatal("search tip was not cleared")
+	}
+	wantAnchors := int(avHeight / assumeValidAnchorInterval)
+	if len(bm.assumeValidAnchors) != wantAnchors {
+		t.Fatalf("unexpected number of recorded ancestors -- got %d, "+
+			"want %d", len(bm.assumeValidAnchors), wantAnchors)
+	}
+	if !bm.headersSynced {
+		t.Fatal("blocks of the first round are not being fetched")
+	}
+	if bm.headerList.Len() != maxHeadersPerRound {
+		t.Fatalf("unexpected header list length -- got %d, want %d",
+			bm.headerList.Len(), maxHeadersPerRound)
+	}
+	assertListHeight(maxHeadersPerRound)
+
+	// Ensure the ancestors are kept for the next round and that its blocks
+	// are assumed valid up to the assumed valid block.
+	roundHash := headers[maxHeadersPerRound-1].BlockHash()
+	startTestHeadersDownload(bm, syncPeer, &roundHash, maxHeadersPerRound)
+	assertListHeight(0)
+	sendTestHeaders(bm, peers, syncPeer, headers[maxHeadersPerRound:])
+	if !bm.headersSynced {
+		t.Fatal("blocks of the second round are not being fetched")
+	}
+	if len(bm.assumeValidAnchors) != wantAnchors {
+		t.Fatalf("recorded ancestors changed -- got %d, want %d",
+			len(bm.assumeValidAnchors), wantAnchors)
+	}
+	assertListHeight(avHeight)
+
+	// Ensure the blocks of a round of headers on another chain are not
+	// assumed valid.
+	forkHeaders := testHeaderChain(roundHash, maxHeadersPerRound, 1000, 1)
+	startTestHeadersDownload(bm, syncPeer, &roundHash, maxHeadersPerRound)
+	sendTestHeaders(bm, peers, syncPeer, forkHeaders)
+	if !bm.headersSynced {
+		t.Fatal("blocks of the fork are not being fetched")
+	}
+	assertListHeight(0)
+}
+
+// TestAssumeValidSearchNotFound ensures the ancestors recorded while searching
+// for the assumed valid block are dropped when the sync peer is lost during the
+// search or does not have the block and that the search is not repeated in the
+// latter case.
+func TestAssumeValidSearchNotFound(t *testing.T) {
+	bm, teardown := newTestBlockManager(t)
+	defer teardown()
+
+	syncPeer, teardownSyncPeer := newTestServerPeer(t, 0)
+	defer teardownSyncPeer()
+	peers := list.New()
+	peers.PushBack(syncPeer)
+
+	genesisHash := *chaincfg.SimNetParams.GenesisHash
+	headers := testHeaderChain(genesisHash, 0, 3000, 0)
+	avHash := testHeaderChain(genesisHash, 0, 1, 1)[0].BlockHash()
+	bm.assumeValid = &avHash
+
+	// Ensure the recorded ancestors are dropped when the search is aborted.
+	startTestHeadersDownload(bm, syncPeer, &genesisHash, 0)
+	sendTestHeaders(bm, peers, syncPeer, headers[:wire.MaxBlockHeadersPerMsg])
+	if len(bm.assumeValidAnchors) == 0 {
+		t.Fatal("no ancestors were recorded during the search")
+	}
+	startTestHeadersDownload(bm, syncPeer, &genesisHash, 0)
+	if len(bm.assumeValidAnchors) != 0 || bm.assumeValidResolved {
+		t.Fatal("aborted search was not reset")
+	}
+
+	// Ensure the search ends without any ancestors when the sync peer does
+	// not have the block.
+	sendTestHeaders(bm, peers, syncPeer, headers)
+	if !bm.assumeValidResolved {
+		t.Fatal("search did not end at the end of the headers")
+	}
+	if bm.assumeValidHeight != 0 || len(bm.assumeValidAnchors) != 0 ||
+		bm.assumeValidListHeight != 0 {
+
+		t.Fatal("ancestors of the missing block were kept")
+	}
+	if !bm.headersSynced || bm.headerList.Len() != len(headers) {
+		t.Fatal("blocks of the headers are not being fetched")
+	}
+
+	// Ensure the search is not repeated.
+	startTestHeadersDownload(bm, syncPeer, &genesisHash, 0)
+	sendTestHeaders(bm, peers, syncPeer, headers[:wire.MaxBlockHeadersPerMsg])
+	if len(bm.assumeValidAnchors) != 0 {
+		t.Fatal("ancestors were recorded after the search ended")
+	}
+}
@@ -311,6 +311,12 @@ type Params struct {
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints []Checkpoint
 
+	// AssumeValid is the hash of a block which is assumed to have valid
+	// scripts along with all of its ancestors.  The scripts of those blocks
+	// are not validated during the initial sync when the block is part of
+	// the best known header chain.  It may be nil to validate all scripts.
+	AssumeValid *chainhash.Hash
+
 	// These fields are related to voting on consensus rule changes as
 	// defined by BIP0009.
 	//
@@ -493,6 +499,10 @@ var MainNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
+	// No block is assumed to have valid scripts by default, so all scripts
+	// are validated unless a block is specified with --assumevalid.
+	AssumeValid: nil,
+
 	// The miner confirmation window is defined as:
 	//   target proof of work timespan / target proof of work spacing
 	RuleChangeActivationQuorum:     4032, // 10 % of RuleChangeActivationInterval * TicketsPerBlock
@@ -604,6 +614,10 @@ var TestNet2Params = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: []Checkpoint{},
 
+	// No block is assumed to have valid scripts by default, so all scripts
+	// are validated unless a block is specified with --assumevalid.
+	AssumeValid: nil,
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
@@ -746,6 +760,10 @@ var SimNetParams = Params{
 	// Checkpoints ordered from oldest to newest.
 	Checkpoints: nil,
 
+	// There is no block which is assumed to have valid scripts since every
+	// simulation network is private.
+	AssumeValid: nil,
+
 	// Consensus rule change deployments.
 	//
 	// The miner confirmation window is defined as:
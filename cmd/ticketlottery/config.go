@@ -0,0 +1,135 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/database"
+	_ "github.com/HcashOrg/hcd/database/ffldb"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+	flags "github.com/jessevdk/go-flags"
+)
+
+const (
+	defaultNumBlocks = 1
+	defaultDbType    = "ffldb"
+)
+
+var (
+	hcdHomeDir      = hcutil.AppDataDir("hcd", false)
+	defaultDataDir  = filepath.Join(hcdHomeDir, "data")
+	knownDbTypes    = database.SupportedDrivers()
+	activeNetParams = &chaincfg.MainNetParams
+)
+
+// config defines the configuration options for ticketlottery.
+//
+// See loadConfig for details on the configuration load process.
+type config struct {
+	DataDir   string `short:"b" long:"datadir" description:"Location of the hcd data directory"`
+	DbType    string `long:"dbtype" description:"Database backend to use for the Block Chain"`
+	TestNet   bool   `long:"testnet" description:"Use the test network"`
+	SimNet    bool   `long:"simnet" description:"Use the simulation test network"`
+	Block     string `long:"block" description:"Hash of the last block to verify (default: the best block)"`
+	NumBlocks int64  `short:"n" long:"numblocks" description:"Number of blocks to verify walking backwards from the last block"`
+	Verbose   bool   `short:"v" long:"verbose" description:"Display the winning tickets of every block instead of only the ones of blocks which fail verification"`
+}
+
+// validDbType returns whether or not dbType is a supported database type.
+func validDbType(dbType string) bool {
+	for _, knownType := range knownDbTypes {
+		if dbType == knownType {
+			return true
+		}
+	}
+
+	return false
+}
+
+// netName returns the name used when referring to a hcd network.  At the
+// time of writing, hcd currently places blocks for testnet version 2 in the
+// data and log directory "testnet2", which does not match the Name field of the
+// chaincfg parameters.  This function can be used to override this directory name
+// as "testnet2" when the passed active network matches wire.TestNet2.
+func netName(chainParams *chaincfg.Params) string {
+	switch chainParams.Net {
+	case wire.TestNet2:
+		return "testnet2"
+	default:
+		return chainParams.Name
+	}
+}
+
+// loadConfig initializes and parses the config using command line options.
+func loadConfig() (*config, []string, error) {
+	// Default config.
+	cfg := config{
+		DataDir:   defaultDataDir,
+		DbType:    defaultDbType,
+		NumBlocks: defaultNumBlocks,
+	}
+
+	// Parse command line options.
+	parser := flags.NewParser(&cfg, flags.Default)
+	remainingArgs, err := parser.Parse()
+	if err != nil {
+		if e, ok := err.(*flags.Error); !ok || e.Type != flags.ErrHelp {
+			parser.WriteHelp(os.Stderr)
+		}
+		return nil, nil, err
+	}
+
+	// Multiple networks can't be selected simultaneously.
+	funcName := "loadConfig"
+	numNets := 0
+	// Count number of network flags passed; assign active network params
+	// while we're at it
+	if cfg.TestNet {
+		numNets++
+		activeNetParams = &chaincfg.TestNet2Params
+	}
+	if cfg.SimNet {
+		numNets++
+		activeNetParams = &chaincfg.SimNetParams
+	}
+	if numNets > 1 {
+		str := "%s: the testnet and simnet params can't be used " +
+			"together -- choose one of the two"
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Validate database type.
+	if !validDbType(cfg.DbType) {
+		str := "%s: the specified database type [%v] is invalid -- " +
+			"supported types %v"
+		err := fmt.Errorf(str, funcName, cfg.DbType, knownDbTypes)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	// Append the network type to the data directory so it is "namespaced"
+	// per network.
+	cfg.DataDir = filepath.Join(cfg.DataDir, netName(activeNetParams))
+
+	// Validate the number of blocks.
+	if cfg.NumBlocks < 1 {
+		str := "%s: the number of blocks must be positive -- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.NumBlocks)
+		fmt.Fprintln(os.Stderr, err)
+		parser.WriteHelp(os.Stderr)
+		return nil, nil, err
+	}
+
+	return &cfg, remainingArgs, nil
+}
@@ -0,0 +1,154 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/database"
+)
+
+const blockDbNamePrefix = "blocks"
+
+var (
+	cfg *config
+)
+
+// loadBlockDB opens the block database and returns a handle to it.
+func loadBlockDB() (database.DB, error) {
+	// The database name is based on the database type.
+	dbName := blockDbNamePrefix + "_" + cfg.DbType
+	dbPath := filepath.Join(cfg.DataDir, dbName)
+	fmt.Printf("Loading block database from '%s'\n", dbPath)
+	db, err := database.Open(cfg.DbType, dbPath, activeNetParams.Net)
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// verifyBlock reproduces the ticket lottery which selected the tickets that may
+// vote on the passed block and compares the result against its header and
+// votes.  It returns whether the block passed verification along with the hash
+// of its parent.
+func verifyBlock(chain *blockchain.BlockChain, hash *chainhash.Hash) (bool, *chainhash.Hash, error) {
+	block, err := chain.BlockByHash(hash)
+	if err != nil {
+		return false, nil, err
+	}
+	header := &block.MsgBlock().Header
+	replay, err := chain.ReplayLottery(hash)
+	if err != nil {
+		return false, nil, err
+	}
+
+	poolSizeOK := replay.PoolSize == int(header.PoolSize)
+	finalStateOK := replay.FinalState == header.FinalState
+	ok := poolSizeOK && finalStateOK && len(replay.UnselectedVotes) == 0
+	status := "OK"
+	if !ok {
+		status = "FAILED"
+	}
+	fmt.Printf("Block %v (height %d): %s\n", hash, header.Height, status)
+	if !ok || cfg.Verbose {
+		fmt.Printf("  Pool size: %d (header %d)\n", replay.PoolSize,
+			header.PoolSize)
+		fmt.Printf("  Final state: %x (header %x)\n", replay.FinalState,
+			header.FinalState)
+		for i, winner := range replay.Winners {
+			voted := "missed"
+			if winner.Voted {
+				voted = "voted"
+			}
+			fmt.Printf("  Winner %d: %v (index %d) %s\n", i,
+				winner.Ticket, winner.Index, voted)
+		}
+		for _, ticket := range replay.UnselectedVotes {
+			fmt.Printf("  Unselected vote: %v\n", ticket)
+		}
+	}
+
+	return ok, &header.PrevBlock, nil
+}
+
+func main() {
+	// Load configuration and parse command line.
+	tcfg, _, err := loadConfig()
+	if err != nil {
+		return
+	}
+	cfg = tcfg
+
+	// Load the block database.
+	db, err := loadBlockDB()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to load database:", err)
+		return
+	}
+	defer db.Close()
+
+	// Setup chain.  Ignore notifications since they aren't needed for this
+	// util.
+	chain, err := blockchain.New(&blockchain.Config{
+		DB:          db,
+		ChainParams: activeNetParams,
+	})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "failed to initialize chain: %v\n", err)
+		return
+	}
+
+	// Get the latest block hash and height from the database and report
+	// status.
+	best := chain.BestSnapshot()
+	fmt.Printf("Block database loaded with block height %d\n", best.Height)
+
+	hash := best.Hash
+	if cfg.Block != "" {
+		hash, err = chainhash.NewHashFromStr(cfg.Block)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "invalid block hash: %v\n", err)
+			return
+		}
+	}
+
+	// Verify the blocks walking backwards until the requested number of
+	// blocks is verified or the first block with votes is reached.
+	var numVerified, numFailed int64
+	svh := activeNetParams.StakeValidationHeight
+	for numVerified < cfg.NumBlocks {
+		height, err := chain.BlockHeightByHash(hash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to find block %v in the "+
+				"main chain: %v\n", hash, err)
+			return
+		}
+		if height < svh {
+			break
+		}
+
+		ok, prevHash, err := verifyBlock(chain, hash)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "failed to verify block %v: %v\n",
+				hash, err)
+			return
+		}
+		if !ok {
+			numFailed++
+		}
+		numVerified++
+		hash = prevHash
+	}
+
+	fmt.Printf("Verified %d blocks, %d failed\n", numVerified, numFailed)
+	if numFailed != 0 {
+		db.Close()
+		os.Exit(1)
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcd/connmgr"
 	"github.com/HcashOrg/hcd/database"
 	_ "github.com/HcashOrg/hcd/database/ffldb"
@@ -56,9 +57,19 @@ const (
 	defaultAllowOldVotes         = false
 	defaultMaxOrphanTransactions = 1000
 	defaultMaxOrphanTxSize       = 5000
+	defaultMaxMempoolMiB         = 300
+	defaultLimitAncestorCount    = 25
+	defaultLimitAncestorSize     = 101
+	defaultLimitDescendantCount  = 25
+	defaultLimitDescendantSize   = 101
+	defaultStratumPort           = "3333"
+	defaultStratumDifficulty     = 1.0
+	defaultMaxStratumClients     = 50
 	defaultSigCacheMaxSize       = 100000
+	defaultUtxoCacheMaxSize      = 150
 	defaultTxIndex               = false
 	defaultNoExistsAddrIndex     = false
+	pruneMinSizeMiB              = 1536
 )
 
 var (
@@ -102,6 +113,7 @@ type config struct {
 	BanDuration          time.Duration `long:"banduration" description:"How long to ban misbehaving peers.  Valid time units are {s, m, h}.  Minimum 1 second"`
 	BanThreshold         uint32        `long:"banthreshold" description:"Maximum allowed ban score before disconnecting and banning misbehaving peers."`
 	Whitelists           []string      `long:"whitelist" description:"Add an IP network or IP that will not be banned. (eg. 192.168.1.0/24 or ::1)"`
+	V2Transport          bool          `long:"v2transport" description:"Encrypt connections with peers which support the v2 transport"`
 	RPCUser              string        `short:"u" long:"rpcuser" description:"Username for RPC connections"`
 	RPCPass              string        `short:"P" long:"rpcpass" default-mask:"-" description:"Password for RPC connections"`
 	RPCLimitUser         string        `long:"rpclimituser" description:"Username for limited RPC connections"`
@@ -124,9 +136,12 @@ type config struct {
 	OnionProxyPass       string        `long:"onionpass" default-mask:"-" description:"Password for onion proxy server"`
 	NoOnion              bool          `long:"noonion" description:"Disable connecting to tor hidden services"`
 	TorIsolation         bool          `long:"torisolation" description:"Enable Tor stream isolation by randomizing user credentials for each connection."`
+	I2PProxy             string        `long:"i2pproxy" description:"Connect to I2P destinations via SOCKS5 proxy (eg. 127.0.0.1:4447)"`
+	CJDNSReachable       bool          `long:"cjdnsreachable" description:"Connect to CJDNS addresses, which requires the host to be part of the CJDNS network"`
 	TestNet              bool          `long:"testnet" description:"Use the test network"`
 	SimNet               bool          `long:"simnet" description:"Use the simulation test network"`
 	DisableCheckpoints   bool          `long:"nocheckpoints" description:"Disable built-in checkpoints.  Don't do this unless you know what you're doing."`
+	AssumeValid          string        `long:"assumevalid" description:"Hash of a block whose ancestors are assumed to have valid scripts during the initial sync when it is part of the best header chain (default: none, all scripts are validated)"`
 	DbType               string        `long:"dbtype" description:"Database backend to use for the Block Chain"`
 	Profile              string        `long:"profile" description:"Enable HTTP profiling on given [addr:]port -- NOTE port must be between 1024 and 65536"`
 	CPUProfile           string        `long:"cpuprofile" description:"Write CPU profile to the specified file"`
@@ -139,34 +154,58 @@ type config struct {
 	FreeTxRelayLimit     float64       `long:"limitfreerelay" description:"Limit relay of transactions with no transaction fee to the given amount in thousands of bytes per minute"`
 	NoRelayPriority      bool          `long:"norelaypriority" description:"Do not require free or low-fee transactions to have high priority for relaying"`
 	MaxOrphanTxs         int           `long:"maxorphantx" description:"Max number of orphan transactions to keep in memory"`
+	MaxMempool           int64         `long:"maxmempool" description:"Max size in MiB of the transaction memory pool -- The transactions with the lowest fee rate are evicted when it is exceeded (0 to disable)"`
+	NoPersistMempool     bool          `long:"nopersistmempool" description:"Do not save the transaction memory pool on shutdown and reload it on startup"`
+	LimitAncestorCount   int           `long:"limitancestorcount" description:"Max number of unconfirmed ancestors in the memory pool a transaction may have, including itself (0 to disable)"`
+	LimitAncestorSize    int64         `long:"limitancestorsize" description:"Max total size in kB of a transaction and its unconfirmed ancestors in the memory pool (0 to disable)"`
+	LimitDescendantCount int           `long:"limitdescendantcount" description:"Max number of descendants in the memory pool any ancestor of a transaction may have, including itself (0 to disable)"`
+	LimitDescendantSize  int64         `long:"limitdescendantsize" description:"Max total size in kB of any ancestor of a transaction and its descendants in the memory pool (0 to disable)"`
 	Generate             bool          `long:"generate" description:"Generate (mine) coins using the CPU"`
 	MiningAddrs          []string      `long:"miningaddr" description:"Add the specified payment address to the list of addresses to use for generated blocks -- At least one address is required if the generate option is set"`
+	Stratum              bool          `long:"stratum" description:"Enable the built-in Stratum mining server -- At least one mining address is required"`
+	StratumListeners     []string      `long:"stratumlisten" description:"Add an interface/port to listen for Stratum miner connections (default port: 3333)"`
+	StratumDifficulty    float64       `long:"stratumdiff" description:"Initial share difficulty assigned to Stratum miner connections"`
+	StratumMaxClients    int           `long:"stratummaxclients" description:"Max number of Stratum miner connections"`
+	StratumPass          string        `long:"stratumpass" default-mask:"-" description:"Password Stratum miners must authorize with -- NOTE: This is required if the Stratum server is bound to non localhost addresses"`
 	BlockMinSize         uint32        `long:"blockminsize" description:"Mininum block size in bytes to be used when creating a block"`
 	BlockMaxSize         uint32        `long:"blockmaxsize" description:"Maximum block size in bytes to be used when creating a block"`
 	BlockPrioritySize    uint32        `long:"blockprioritysize" description:"Size in bytes for high-priority/low-fee transactions when creating a block"`
 	GetWorkKeys          []string      `long:"getworkkey" description:"DEPRECATED -- Use the --miningaddr option instead"`
 	NoPeerBloomFilters   bool          `long:"nopeerbloomfilters" description:"Disable bloom filtering support"`
+	NoCFilters           bool          `long:"nocfilters" description:"Disable committed filtering (CF) support"`
 	SigCacheMaxSize      uint          `long:"sigcachemaxsize" description:"The maximum number of entries in the signature verification cache"`
+	UtxoCacheMaxSize     uint          `long:"utxocachemaxsize" description:"The maximum size in MiB of the UTXO cache (0 = write the UTXO set changes of every block to the database)"`
 	NonAggressive        bool          `long:"nonaggressive" description:"Disable mining off of the parent block of the blockchain if there aren't enough voters"`
 	NoMiningStateSync    bool          `long:"nominingstatesync" description:"Disable synchronizing the mining state with other nodes"`
 	AllowOldVotes        bool          `long:"allowoldvotes" description:"Enable the addition of very old votes to the mempool"`
 	BlocksOnly           bool          `long:"blocksonly" description:"Do not accept transactions from remote peers."`
 	RelayNonStd          bool          `long:"relaynonstd" description:"Relay non-standard transactions regardless of the default settings for the active network."`
 	RejectNonStd         bool          `long:"rejectnonstd" description:"Reject non-standard transactions regardless of the default settings for the active network."`
+	RejectReplacement    bool          `long:"rejectreplacement" description:"Reject transactions that attempt to replace existing transactions within the mempool through the replace-by-fee policy."`
 	TxIndex              bool          `long:"txindex" description:"Maintain a full hash-based transaction index which makes all transactions available via the getrawtransaction RPC"`
 	DropTxIndex          bool          `long:"droptxindex" description:"Deletes the hash-based transaction index from the database on start up and then exits."`
 	AddrIndex            bool          `long:"addrindex" description:"Maintain a full address-based transaction index which makes the searchrawtransactions RPC available"`
 	DropAddrIndex        bool          `long:"dropaddrindex" description:"Deletes the address-based transaction index from the database on start up and then exits."`
+	AddrUtxoIndex        bool          `long:"addrutxoindex" description:"Maintain an address-based unspent output and balance index which makes the getaddressbalance, getaddressutxos, and getaddressdeltas RPCs available"`
+	DropAddrUtxoIndex    bool          `long:"dropaddrutxoindex" description:"Deletes the address-based unspent output and balance index from the database on start up and then exits."`
+	SpendIndex           bool          `long:"spendindex" description:"Maintain an index of the transactions which spend each output which makes the getspendinginfo RPC available"`
+	DropSpendIndex       bool          `long:"dropspendindex" description:"Deletes the spend index from the database on start up and then exits."`
+	TicketIndex          bool          `long:"ticketindex" description:"Maintain an index of the status changes of all tickets and the state of the ticket pool after every block which makes the getticketinfo and getticketpoolinfo RPCs available"`
+	DropTicketIndex      bool          `long:"dropticketindex" description:"Deletes the ticket history index from the database on start up and then exits."`
 	NoExistsAddrIndex    bool          `long:"noexistsaddrindex" description:"Disable the exists address index, which tracks whether or not an address has even been used."`
 	DropExistsAddrIndex  bool          `long:"dropexistsaddrindex" description:"Deletes the exists address index from the database on start up and then exits."`
+	DropCFIndex          bool          `long:"dropcfindex" description:"Deletes the index used for committed filtering (CF) support from the database on start up and then exits."`
+	Prune                uint64        `long:"prune" description:"Delete the data of old blocks to keep the total size of the stored blocks below the specified target in MiB (0 = disabled, minimum 1536).  Incompatible with --txindex, --addrindex, --addrutxoindex, --spendindex, and --ticketindex"`
 	PipeRx               uint          `long:"piperx" description:"File descriptor of read end pipe to enable parent -> child process communication"`
 	PipeTx               uint          `long:"pipetx" description:"File descriptor of write end pipe to enable parent <- child process communication"`
 	LifetimeEvents       bool          `long:"lifetimeevents" description:"Send lifetime notifications over the TX pipe"`
 	onionlookup          func(string) ([]net.IP, error)
 	lookup               func(string) ([]net.IP, error)
 	oniondial            func(string, string) (net.Conn, error)
+	i2pdial              func(string, string) (net.Conn, error)
 	dial                 func(string, string) (net.Conn, error)
 	miningAddrs          []hcutil.Address
+	assumeValid          *chainhash.Hash
 	minRelayTxFee        hcutil.Amount
 	whitelists           []*net.IPNet
 }
@@ -440,7 +479,15 @@ func loadConfig() (*config, []string, error) {
 		BlockMaxSize:         defaultBlockMaxSize,
 		BlockPrioritySize:    mempool.DefaultBlockPrioritySize,
 		MaxOrphanTxs:         defaultMaxOrphanTransactions,
+		MaxMempool:           defaultMaxMempoolMiB,
+		LimitAncestorCount:   defaultLimitAncestorCount,
+		LimitAncestorSize:    defaultLimitAncestorSize,
+		LimitDescendantCount: defaultLimitDescendantCount,
+		LimitDescendantSize:  defaultLimitDescendantSize,
+		StratumDifficulty:    defaultStratumDifficulty,
+		StratumMaxClients:    defaultMaxStratumClients,
 		SigCacheMaxSize:      defaultSigCacheMaxSize,
+		UtxoCacheMaxSize:     defaultUtxoCacheMaxSize,
 		Generate:             defaultGenerate,
 		NoMiningStateSync:    defaultNoMiningStateSync,
 		TxIndex:              defaultTxIndex,
@@ -856,6 +903,37 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Limit the max mempool size to a sane value.
+	if cfg.MaxMempool < 0 {
+		str := "%s: the maxmempool option may not be less than 0 " +
+			"-- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.MaxMempool)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the mempool chain limits are sane.
+	chainLimits := []struct {
+		name  string
+		value int64
+	}{
+		{"limitancestorcount", int64(cfg.LimitAncestorCount)},
+		{"limitancestorsize", cfg.LimitAncestorSize},
+		{"limitdescendantcount", int64(cfg.LimitDescendantCount)},
+		{"limitdescendantsize", cfg.LimitDescendantSize},
+	}
+	for _, limit := range chainLimits {
+		if limit.value < 0 {
+			str := "%s: the %s option may not be less than 0 " +
+				"-- parsed [%d]"
+			err := fmt.Errorf(str, funcName, limit.name, limit.value)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+	}
+
 	// Limit the block priority and minimum block sizes to max block size.
 	cfg.BlockPrioritySize = minUint32(cfg.BlockPrioritySize, cfg.BlockMaxSize)
 	cfg.BlockMinSize = minUint32(cfg.BlockMinSize, cfg.BlockMaxSize)
@@ -892,6 +970,47 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// --addrutxoindex and --dropaddrutxoindex do not mix.
+	if cfg.AddrUtxoIndex && cfg.DropAddrUtxoIndex {
+		err := fmt.Errorf("%s: the --addrutxoindex and "+
+			"--dropaddrutxoindex options may not be activated at "+
+			"the same time", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --spendindex and --dropspendindex do not mix.
+	if cfg.SpendIndex && cfg.DropSpendIndex {
+		err := fmt.Errorf("%s: the --spendindex and --dropspendindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --ticketindex and --dropticketindex do not mix.
+	if cfg.TicketIndex && cfg.DropTicketIndex {
+		err := fmt.Errorf("%s: the --ticketindex and --dropticketindex "+
+			"options may not be activated at the same time",
+			funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --addrutxoindex and --droptxindex do not mix.
+	if cfg.AddrUtxoIndex && cfg.DropTxIndex {
+		err := fmt.Errorf("%s: the --addrutxoindex and --droptxindex "+
+			"options may not be activated at the same time "+
+			"because the address utxo index relies on the "+
+			"transaction index", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
 	// !--noexistsaddrindex and --dropexistsaddrindex do not mix.
 	if !cfg.NoExistsAddrIndex && cfg.DropExistsAddrIndex {
 		err := fmt.Errorf("dropexistsaddrindex cannot be activated when " +
@@ -901,6 +1020,59 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// !--nocfilters and --dropcfindex do not mix.
+	if !cfg.NoCFilters && cfg.DropCFIndex {
+		err := fmt.Errorf("dropcfindex cannot be activated when " +
+			"committed filtering is on (try setting --nocfilters)")
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Ensure the prune target is large enough to always retain the blocks
+	// that are needed to handle reorganizations.
+	if cfg.Prune != 0 && cfg.Prune < pruneMinSizeMiB {
+		str := "%s: the --prune option must be at least %d MiB -- " +
+			"parsed [%d]"
+		err := fmt.Errorf(str, funcName, pruneMinSizeMiB, cfg.Prune)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// --prune and --txindex, --addrindex, --addrutxoindex, --spendindex, or
+	// --ticketindex do not mix.
+	if cfg.Prune != 0 && (cfg.TxIndex || cfg.AddrIndex ||
+		cfg.AddrUtxoIndex || cfg.SpendIndex || cfg.TicketIndex) {
+		err := fmt.Errorf("%s: the --prune option may not be "+
+			"activated at the same time as the --txindex, "+
+			"--addrindex, --addrutxoindex, --spendindex, or "+
+			"--ticketindex options because they require the data "+
+			"for all blocks", funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Use the assumed valid block of the network, if any, unless it is
+	// overridden.  A value of 0 disables it.
+	cfg.assumeValid = activeNetParams.AssumeValid
+	switch cfg.AssumeValid {
+	case "":
+	case "0":
+		cfg.assumeValid = nil
+	default:
+		hash, err := chainhash.NewHashFromStr(cfg.AssumeValid)
+		if err != nil {
+			str := "%s: the assumevalid value of '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.AssumeValid, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+		cfg.assumeValid = hash
+	}
+
 	// Check getwork keys are valid and saved parsed versions.
 	cfg.miningAddrs = make([]hcutil.Address, 0, len(cfg.GetWorkKeys)+
 		len(cfg.MiningAddrs))
@@ -954,6 +1126,50 @@ func loadConfig() (*config, []string, error) {
 		return nil, nil, err
 	}
 
+	// Ensure there is at least one mining address when the Stratum server
+	// is enabled since there is otherwise nothing to pay found blocks to.
+	if cfg.Stratum && len(cfg.miningAddrs) == 0 {
+		str := "%s: the stratum flag is set, but there are no mining " +
+			"addresses specified "
+		err := fmt.Errorf(str, funcName)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The initial Stratum share difficulty must be positive.
+	if cfg.StratumDifficulty <= 0 {
+		str := "%s: the stratumdiff option must be greater than 0 " +
+			"-- parsed [%v]"
+		err := fmt.Errorf(str, funcName, cfg.StratumDifficulty)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// The Stratum server must allow at least one connection.
+	if cfg.StratumMaxClients <= 0 {
+		str := "%s: the stratummaxclients option must be greater " +
+			"than 0 -- parsed [%d]"
+		err := fmt.Errorf(str, funcName, cfg.StratumMaxClients)
+		fmt.Fprintln(os.Stderr, err)
+		fmt.Fprintln(os.Stderr, usageMessage)
+		return nil, nil, err
+	}
+
+	// Default Stratum to listen on localhost only.
+	if cfg.Stratum && len(cfg.StratumListeners) == 0 {
+		addrs, err := net.LookupHost("localhost")
+		if err != nil {
+			return nil, nil, err
+		}
+		cfg.StratumListeners = make([]string, 0, len(addrs))
+		for _, addr := range addrs {
+			addr = net.JoinHostPort(addr, defaultStratumPort)
+			cfg.StratumListeners = append(cfg.StratumListeners, addr)
+		}
+	}
+
 	// Add default port to all listener addresses if needed and remove
 	// duplicate addresses.
 	cfg.Listeners = normalizeAddresses(cfg.Listeners,
@@ -964,6 +1180,11 @@ func loadConfig() (*config, []string, error) {
 	cfg.RPCListeners = normalizeAddresses(cfg.RPCListeners,
 		activeNetParams.rpcPort)
 
+	// Add default port to all Stratum listener addresses if needed and
+	// remove duplicate addresses.
+	cfg.StratumListeners = normalizeAddresses(cfg.StratumListeners,
+		defaultStratumPort)
+
 	// Only allow TLS to be disabled if the RPC is bound to localhost
 	// addresses.
 	if !cfg.DisableRPC && cfg.DisableTLS {
@@ -994,6 +1215,36 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Only allow Stratum miners to authorize without a password if the
+	// Stratum server is bound to localhost addresses.
+	if cfg.Stratum && cfg.StratumPass == "" {
+		allowedNoPassListeners := map[string]struct{}{
+			"localhost": {},
+			"127.0.0.1": {},
+			"::1":       {},
+		}
+		for _, addr := range cfg.StratumListeners {
+			host, _, err := net.SplitHostPort(addr)
+			if err != nil {
+				str := "%s: Stratum listen interface '%s' is " +
+					"invalid: %v"
+				err := fmt.Errorf(str, funcName, addr, err)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+			if _, ok := allowedNoPassListeners[host]; !ok {
+				str := "%s: the --stratumpass option must be " +
+					"specified when binding Stratum to non " +
+					"localhost addresses: %s"
+				err := fmt.Errorf(str, funcName, addr)
+				fmt.Fprintln(os.Stderr, err)
+				fmt.Fprintln(os.Stderr, usageMessage)
+				return nil, nil, err
+			}
+		}
+	}
+
 	// Add default port to all added peer addresses if needed and remove
 	// duplicate addresses.
 	cfg.AddPeers = normalizeAddresses(cfg.AddPeers,
@@ -1102,6 +1353,26 @@ func loadConfig() (*config, []string, error) {
 		}
 	}
 
+	// Setup the I2P dial function which uses the I2P proxy.  There is no
+	// lookup function since I2P destinations can't be resolved to IP
+	// addresses.
+	cfg.i2pdial = func(a, b string) (net.Conn, error) {
+		return nil, errors.New("no i2p proxy has been specified")
+	}
+	if cfg.I2PProxy != "" {
+		_, _, err := net.SplitHostPort(cfg.I2PProxy)
+		if err != nil {
+			str := "%s: I2P proxy address '%s' is invalid: %v"
+			err := fmt.Errorf(str, funcName, cfg.I2PProxy, err)
+			fmt.Fprintln(os.Stderr, err)
+			fmt.Fprintln(os.Stderr, usageMessage)
+			return nil, nil, err
+		}
+
+		proxy := &socks.Proxy{Addr: cfg.I2PProxy}
+		cfg.i2pdial = proxy.Dial
+	}
+
 	// Warn if old testnet directory is present.
 	for _, oldDir := range oldTestNets {
 		if fileExists(oldDir) {
@@ -1125,14 +1396,29 @@ func loadConfig() (*config, []string, error) {
 // dial function depending on the address and configuration options.  For
 // example, .onion addresses will be dialed using the onion specific proxy if
 // one was specified, but will otherwise use the normal dial function (which
-// could itself use a proxy or not).
+// could itself use a proxy or not).  Likewise, .i2p addresses are dialed using
+// the I2P proxy and CJDNS addresses are dialed directly when CJDNS is reachable.
 func hcdDial(addr net.Addr) (net.Conn, error) {
 	if strings.Contains(addr.String(), ".onion:") {
 		return cfg.oniondial(addr.Network(), addr.String())
 	}
+	if strings.Contains(addr.String(), ".i2p:") {
+		return cfg.i2pdial(addr.Network(), addr.String())
+	}
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok && cfg.CJDNSReachable &&
+		isCJDNSAddr(tcpAddr.IP) {
+
+		return net.Dial(addr.Network(), addr.String())
+	}
 	return cfg.dial(addr.Network(), addr.String())
 }
 
+// isCJDNSAddr returns whether the passed IP address is in the fc00::/8 range
+// used by CJDNS.
+func isCJDNSAddr(ip net.IP) bool {
+	return len(ip) == net.IPv6len && ip.To4() == nil && ip[0] == 0xfc
+}
+
 // hcdLookup returns the correct DNS lookup function to use depending on the
 // passed host and configuration options.  For example, .onion addresses will be
 // resolved using the onion specific proxy if one was specified, but will
@@ -0,0 +1,474 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// banListVersion is the current version of the on-disk format of the
+	// ban list.
+	banListVersion = 1
+
+	// autoBanIPv6PrefixLen is the prefix length of the subnets automatic
+	// bans of IPv6 addresses apply to.  A single host is typically assigned
+	// an entire /64, so banning only the address would be trivial to evade.
+	autoBanIPv6PrefixLen = 64
+
+	// maxAutoBans is the maximum number of automatic bans.  The ones which
+	// expire first are removed to make room for new ones.
+	maxAutoBans = 5000
+
+	// banListSaveDelay is how long writing the ban list to its file is
+	// delayed after an automatic ban so bans in quick succession only
+	// result in a single write.
+	banListSaveDelay = 10 * time.Second
+)
+
+// BanEntry describes a banned subnet.
+type BanEntry struct {
+	// Subnet is the banned subnet.  Banned IP addresses are represented by
+	// a subnet which only contains the address.
+	Subnet *net.IPNet
+
+	// Created is the time the ban was created.
+	Created time.Time
+
+	// Expires is the time the ban expires.
+	Expires time.Time
+
+	// Reason describes why the subnet was banned.
+	Reason string
+
+	// Automatic is whether the ban was created by BanAddress rather than
+	// requested for the subnet.
+	Automatic bool
+}
+
+// serializedBanEntry is the on-disk format of a ban entry.
+type serializedBanEntry struct {
+	Subnet    string
+	Created   int64
+	Expires   int64
+	Reason    string
+	Automatic bool `json:",omitempty"`
+}
+
+// banPrefix is the prefix length of a banned subnet along with the number of
+// bits of its addresses.
+type banPrefix struct {
+	ones, bits int
+}
+
+// serializedBanList is the on-disk format of the ban list.
+type serializedBanList struct {
+	Version int
+	Entries []*serializedBanEntry
+}
+
+// BanList houses the banned subnets along with the time their bans expire and
+// the reasons they were banned.  The bans are written to a file whenever they
+// change so they survive restarts, although writing automatic bans is delayed
+// until Flush is called or banListSaveDelay has passed.
+//
+// It is safe for concurrent access.
+type BanList struct {
+	mtx       sync.Mutex
+	filePath  string
+	entries   map[string]*BanEntry // keyed by subnet
+	prefixes  map[banPrefix]int    // number of entries per prefix
+	numAuto   int
+	saveTimer *time.Timer
+}
+
+// ParseSubnet parses the passed IP address or subnet in CIDR notation.  IP
+// addresses are returned as a subnet which only contains the address.
+func ParseSubnet(s string) (*net.IPNet, error) {
+	if strings.Contains(s, "/") {
+		_, subnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return nil, err
+		}
+		return subnet, nil
+	}
+
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid IP address or subnet %q", s)
+	}
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+	}
+	return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+}
+
+// addrIP returns the IP address of the passed address or nil if its host is
+// not an IP address.
+func addrIP(addr net.Addr) net.IP {
+	if tcpAddr, ok := addr.(*net.TCPAddr); ok {
+		return tcpAddr.IP
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return nil
+	}
+	return net.ParseIP(host)
+}
+
+// NewBanList returns a ban list which is written to the passed file.  The bans
+// which were previously written to the file are loaded, except for the ones
+// which have expired in the meantime.  An empty file path disables writing the
+// ban list.
+func NewBanList(filePath string) (*BanList, error) {
+	b := &BanList{
+		filePath: filePath,
+		entries:  make(map[string]*BanEntry),
+		prefixes: make(map[banPrefix]int),
+	}
+	if filePath == "" {
+		return b, nil
+	}
+
+	serialized, err := ioutil.ReadFile(filePath)
+	if os.IsNotExist(err) {
+		return b, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sbl serializedBanList
+	if err := json.Unmarshal(serialized, &sbl); err != nil {
+		return nil, fmt.Errorf("unable to decode ban list %s: %v",
+			filePath, err)
+	}
+	if sbl.Version != banListVersion {
+		return nil, fmt.Errorf("unknown version %d of ban list %s",
+			sbl.Version, filePath)
+	}
+
+	now := time.Now()
+	for _, sbe := range sbl.Entries {
+		subnet, err := ParseSubnet(sbe.Subnet)
+		if err != nil {
+			return nil, fmt.Errorf("invalid ban of ban list %s: %v",
+				filePath, err)
+		}
+		entry := &BanEntry{
+			Subnet:    subnet,
+			Created:   time.Unix(sbe.Created, 0),
+			Expires:   time.Unix(sbe.Expires, 0),
+			Reason:    sbe.Reason,
+			Automatic: sbe.Automatic,
+		}
+		if !now.Before(entry.Expires) {
+			continue
+		}
+		b.add(entry)
+	}
+	b.enforceAutoBanLimit()
+	return b, nil
+}
+
+// add adds the passed ban, replacing an existing ban of the same subnet.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) add(entry *BanEntry) {
+	key := entry.Subnet.String()
+	b.remove(key)
+	b.entries[key] = entry
+	ones, bits := entry.Subnet.Mask.Size()
+	b.prefixes[banPrefix{ones, bits}]++
+	if entry.Automatic {
+		b.numAuto++
+	}
+}
+
+// remove removes the ban of the subnet with the passed key if there is one.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) remove(key string) {
+	entry, ok := b.entries[key]
+	if !ok {
+		return
+	}
+	delete(b.entries, key)
+	ones, bits := entry.Subnet.Mask.Size()
+	prefix := banPrefix{ones, bits}
+	b.prefixes[prefix]--
+	if b.prefixes[prefix] == 0 {
+		delete(b.prefixes, prefix)
+	}
+	if entry.Automatic {
+		b.numAuto--
+	}
+}
+
+// pruneExpired removes the bans which have expired.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) pruneExpired() {
+	now := time.Now()
+	for key, entry := range b.entries {
+		if !now.Before(entry.Expires) {
+			log.Infof("Ban of %s has expired", key)
+			b.remove(key)
+		}
+	}
+}
+
+// enforceAutoBanLimit removes the automatic bans which expire first until
+// there are no more than the maximum allowed.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) enforceAutoBanLimit() {
+	if b.numAuto <= maxAutoBans {
+		return
+	}
+
+	autoBans := make([]*BanEntry, 0, b.numAuto)
+	for _, entry := range b.entries {
+		if entry.Automatic {
+			autoBans = append(autoBans, entry)
+		}
+	}
+	sort.Slice(autoBans, func(i, j int) bool {
+		return autoBans[i].Expires.Before(autoBans[j].Expires)
+	})
+	for _, entry := range autoBans[:len(autoBans)-maxAutoBans] {
+		b.remove(entry.Subnet.String())
+	}
+}
+
+// scheduleSave arranges for the ban list to be written to its file after
+// banListSaveDelay unless a write is already pending.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) scheduleSave() {
+	if b.filePath == "" || b.saveTimer != nil {
+		return
+	}
+	b.saveTimer = time.AfterFunc(banListSaveDelay, func() {
+		b.mtx.Lock()
+		defer b.mtx.Unlock()
+
+		b.saveTimer = nil
+		if err := b.save(); err != nil {
+			log.Errorf("Unable to save ban list: %v", err)
+		}
+	})
+}
+
+// save writes the ban list to its file.  Any pending delayed write is
+// canceled since it is no longer needed.
+//
+// This function MUST be called with the ban list lock held.
+func (b *BanList) save() error {
+	if b.saveTimer != nil {
+		b.saveTimer.Stop()
+		b.saveTimer = nil
+	}
+	if b.filePath == "" {
+		return nil
+	}
+
+	sbl := serializedBanList{
+		Version: banListVersion,
+		Entries: make([]*serializedBanEntry, 0, len(b.entries)),
+	}
+	for key, entry := range b.entries {
+		sbl.Entries = append(sbl.Entries, &serializedBanEntry{
+			Subnet:    key,
+			Created:   entry.Created.Unix(),
+			Expires:   entry.Expires.Unix(),
+			Reason:    entry.Reason,
+			Automatic: entry.Automatic,
+		})
+	}
+	serialized, err := json.Marshal(&sbl)
+	if err != nil {
+		return err
+	}
+
+	// Write to a temporary file first so an interrupted write does not
+	// corrupt the existing ban list.
+	tmpPath := b.filePath + ".tmp"
+	if err := ioutil.WriteFile(tmpPath, serialized, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, b.filePath)
+}
+
+// Ban bans the passed subnet until the passed expiry time for the passed
+// reason.  An existing ban of the subnet is replaced.
+func (b *BanList) Ban(subnet *net.IPNet, expires time.Time, reason string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.add(&BanEntry{
+		Subnet:  subnet,
+		Created: time.Now(),
+		Expires: expires,
+		Reason:  reason,
+	})
+	b.pruneExpired()
+	return b.save()
+}
+
+// AutoBanSubnet returns the subnet an automatic ban of the passed IP address
+// applies to, which is the address itself for IPv4 addresses and the /64 it is
+// part of for IPv6 addresses.
+func AutoBanSubnet(ip net.IP) *net.IPNet {
+	if ip4 := ip.To4(); ip4 != nil {
+		return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}
+	}
+	mask := net.CIDRMask(autoBanIPv6PrefixLen, 128)
+	return &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+}
+
+// BanAddress automatically bans the subnet returned by AutoBanSubnet for the
+// passed IP address until the passed expiry time for the passed reason and
+// returns the banned subnet.  An existing ban of the subnet is only extended.
+// When there are too many automatic bans, the ones which expire first are
+// removed.
+//
+// Unlike Ban, the ban list is not written to its file right away so frequent
+// automatic bans don't result in a write each.
+func (b *BanList) BanAddress(ip net.IP, expires time.Time, reason string) *net.IPNet {
+	subnet := AutoBanSubnet(ip)
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	key := subnet.String()
+	if entry, ok := b.entries[key]; ok && !expires.After(entry.Expires) {
+		return subnet
+	}
+	b.add(&BanEntry{
+		Subnet:    subnet,
+		Created:   time.Now(),
+		Expires:   expires,
+		Reason:    reason,
+		Automatic: true,
+	})
+	b.enforceAutoBanLimit()
+	b.scheduleSave()
+	return subnet
+}
+
+// Flush writes any bans whose write was delayed to the ban list file.
+func (b *BanList) Flush() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	if b.saveTimer == nil {
+		return nil
+	}
+	return b.save()
+}
+
+// Unban removes the ban of the passed subnet.  It returns whether the subnet
+// was banned.
+func (b *BanList) Unban(subnet *net.IPNet) (bool, error) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	key := subnet.String()
+	if _, ok := b.entries[key]; !ok {
+		return false, nil
+	}
+	b.remove(key)
+	return true, b.save()
+}
+
+// Clear removes all bans.
+func (b *BanList) Clear() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.entries = make(map[string]*BanEntry)
+	b.prefixes = make(map[banPrefix]int)
+	b.numAuto = 0
+	return b.save()
+}
+
+// IsBannedSubnet returns whether the passed subnet itself is banned.
+func (b *BanList) IsBannedSubnet(subnet *net.IPNet) bool {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	entry, ok := b.entries[subnet.String()]
+	return ok && time.Now().Before(entry.Expires)
+}
+
+// Lookup returns the ban of the passed IP address or nil when it is not
+// banned.  When the address is part of multiple banned subnets, the ban which
+// expires last is returned.
+//
+// Rather than checking every ban, the address is masked with each distinct
+// prefix length of the banned subnets and the result is looked up directly.
+func (b *BanList) Lookup(ip net.IP) *BanEntry {
+	bits := 8 * net.IPv6len
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+		bits = 8 * net.IPv4len
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	now := time.Now()
+	var banned *BanEntry
+	for prefix := range b.prefixes {
+		if prefix.bits != bits {
+			continue
+		}
+		mask := net.CIDRMask(prefix.ones, prefix.bits)
+		subnet := net.IPNet{IP: ip.Mask(mask), Mask: mask}
+		entry, ok := b.entries[subnet.String()]
+		if !ok || !now.Before(entry.Expires) {
+			continue
+		}
+		if banned == nil || entry.Expires.After(banned.Expires) {
+			banned = entry
+		}
+	}
+	if banned == nil {
+		return nil
+	}
+	entry := *banned
+	return &entry
+}
+
+// IsBanned returns whether the host of the passed address is a banned IP
+// address.  Addresses with hosts which are not IP addresses are never banned.
+func (b *BanList) IsBanned(addr net.Addr) bool {
+	ip := addrIP(addr)
+	return ip != nil && b.Lookup(ip) != nil
+}
+
+// Entries returns all bans which have not expired ordered by their subnets.
+func (b *BanList) Entries() []BanEntry {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.pruneExpired()
+	entries := make([]BanEntry, 0, len(b.entries))
+	for _, entry := range b.entries {
+		entries = append(entries, *entry)
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		return entries[i].Subnet.String() < entries[j].Subnet.String()
+	})
+	return entries
+}
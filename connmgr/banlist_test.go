@@ -0,0 +1,355 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package connmgr
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// TestParseSubnet ensures IP addresses and subnets are parsed as intended.
+func TestParseSubnet(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"10.0.0.1", "10.0.0.1/32"},
+		{"10.0.0.1/24", "10.0.0.0/24"},
+		{"::ffff:10.0.0.1", "10.0.0.1/32"},
+		{"2001:db8::1", "2001:db8::1/128"},
+		{"2001:db8::/32", "2001:db8::/32"},
+		{"", ""},
+		{"10.0.0.256", ""},
+		{"10.0.0.1/33", ""},
+		{"example.com", ""},
+	}
+
+	for _, test := range tests {
+		subnet, err := ParseSubnet(test.in)
+		if test.want == "" {
+			if err == nil {
+				t.Errorf("ParseSubnet(%q): did not fail", test.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("ParseSubnet(%q): unexpected error %v", test.in, err)
+			continue
+		}
+		if subnet.String() != test.want {
+			t.Errorf("ParseSubnet(%q): got %s, want %s", test.in, subnet,
+				test.want)
+		}
+	}
+}
+
+// TestBanList ensures bans match the addresses of their subnets until they
+// expire and survive a round trip through the ban list file.
+func TestBanList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testbanlist")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	banFile := filepath.Join(dir, "banlist.json")
+
+	b, err := NewBanList(banFile)
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+
+	mustParse := func(s string) *net.IPNet {
+		subnet, err := ParseSubnet(s)
+		if err != nil {
+			t.Fatalf("ParseSubnet(%q): unexpected error %v", s, err)
+		}
+		return subnet
+	}
+	now := time.Now()
+	bans := []struct {
+		subnet  string
+		expires time.Time
+		reason  string
+	}{
+		{"10.0.0.0/24", now.Add(time.Hour), "manually added"},
+		{"10.0.0.1", now.Add(2 * time.Hour), "ban score 101: getdata"},
+		{"2001:db8::/32", now.Add(time.Hour), "manually added"},
+		{"192.168.0.1", now.Add(-time.Second), "expired"},
+	}
+	for _, ban := range bans {
+		err := b.Ban(mustParse(ban.subnet), ban.expires, ban.reason)
+		if err != nil {
+			t.Fatalf("Ban(%s): unexpected error %v", ban.subnet, err)
+		}
+	}
+
+	lookups := []struct {
+		addr   string
+		reason string
+	}{
+		{"10.0.0.1:8333", "ban score 101: getdata"},
+		{"10.0.0.2:8333", "manually added"},
+		{"[2001:db8::5]:8333", "manually added"},
+		{"10.0.1.1:8333", ""},
+		{"192.168.0.1:8333", ""},
+		{"duckduckgogg42xjoc72x3sjasowoarfbgcmvfimaftt6twagswzczad.onion:8333", ""},
+	}
+	check := func(b *BanList, state string) {
+		for _, lookup := range lookups {
+			addr := &mockAddr{"tcp", lookup.addr}
+			banned := b.IsBanned(addr)
+			if banned != (lookup.reason != "") {
+				t.Errorf("%s: IsBanned(%s): got %v, want %v", state,
+					lookup.addr, banned, !banned)
+				continue
+			}
+			if !banned {
+				continue
+			}
+			host, _, _ := net.SplitHostPort(lookup.addr)
+			entry := b.Lookup(net.ParseIP(host))
+			if entry.Reason != lookup.reason {
+				t.Errorf("%s: Lookup(%s): got reason %q, want %q",
+					state, host, entry.Reason, lookup.reason)
+			}
+		}
+		if entries := b.Entries(); len(entries) != 3 {
+			t.Errorf("%s: got %d bans, want 3", state, len(entries))
+		}
+	}
+	check(b, "before reload")
+
+	// Ensure the bans survive reloading the ban list and the expired ban
+	// is not loaded.
+	b, err = NewBanList(banFile)
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+	check(b, "after reload")
+
+	if !b.IsBannedSubnet(mustParse("10.0.0.0/24")) {
+		t.Error("IsBannedSubnet: 10.0.0.0/24 is not banned")
+	}
+	if b.IsBannedSubnet(mustParse("10.0.0.2")) {
+		t.Error("IsBannedSubnet: 10.0.0.2 is banned")
+	}
+	if ok, err := b.Unban(mustParse("10.0.0.0/24")); !ok || err != nil {
+		t.Errorf("Unban: got %v, %v, want true, nil", ok, err)
+	}
+	if ok, err := b.Unban(mustParse("10.0.0.0/24")); ok || err != nil {
+		t.Errorf("Unban: got %v, %v, want false, nil", ok, err)
+	}
+	if b.IsBanned(&mockAddr{"tcp", "10.0.0.2:8333"}) {
+		t.Error("IsBanned: 10.0.0.2 is banned after unban")
+	}
+	if !b.IsBanned(&mockAddr{"tcp", "10.0.0.1:8333"}) {
+		t.Error("IsBanned: 10.0.0.1 is not banned after unban of " +
+			"10.0.0.0/24")
+	}
+
+	if err := b.Clear(); err != nil {
+		t.Fatalf("Clear: unexpected error %v", err)
+	}
+	b, err = NewBanList(banFile)
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+	if entries := b.Entries(); len(entries) != 0 {
+		t.Errorf("got %d bans after clear, want 0", len(entries))
+	}
+}
+
+// TestBanAddress ensures automatic bans apply to the intended subnets, are
+// limited in number, and are written to the ban list file once flushed.
+func TestBanAddress(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testbanaddress")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	banFile := filepath.Join(dir, "banlist.json")
+
+	b, err := NewBanList(banFile)
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+
+	now := time.Now()
+	bans := []struct {
+		ip   string
+		want string
+	}{
+		{"10.0.0.1", "10.0.0.1/32"},
+		{"::ffff:10.0.0.2", "10.0.0.2/32"},
+		{"2001:db8:1:2:3:4:5:6", "2001:db8:1:2::/64"},
+	}
+	for _, ban := range bans {
+		subnet := b.BanAddress(net.ParseIP(ban.ip), now.Add(time.Hour),
+			"ban score 101")
+		if subnet.String() != ban.want {
+			t.Errorf("BanAddress(%s): got %s, want %s", ban.ip, subnet,
+				ban.want)
+		}
+	}
+
+	lookups := []struct {
+		ip     string
+		banned bool
+	}{
+		{"10.0.0.1", true},
+		{"10.0.0.2", true},
+		{"10.0.0.3", false},
+		{"2001:db8:1:2:ffff::1", true},
+		{"2001:db8:1:3::1", false},
+	}
+	for _, lookup := range lookups {
+		entry := b.Lookup(net.ParseIP(lookup.ip))
+		if (entry != nil) != lookup.banned {
+			t.Errorf("Lookup(%s): got banned %v, want %v", lookup.ip,
+				entry != nil, lookup.banned)
+			continue
+		}
+		if entry != nil && !entry.Automatic {
+			t.Errorf("Lookup(%s): ban is not automatic", lookup.ip)
+		}
+	}
+
+	// Ensure an automatic ban does not shorten an existing ban.
+	b.BanAddress(net.ParseIP("10.0.0.1"), now.Add(time.Minute), "shorter")
+	if entry := b.Lookup(net.ParseIP("10.0.0.1")); entry.Reason != "ban score 101" {
+		t.Errorf("BanAddress: existing ban replaced by %q", entry.Reason)
+	}
+
+	// Ensure the automatic bans are not written until they are flushed and
+	// survive reloading the ban list afterwards.
+	if _, err := os.Stat(banFile); !os.IsNotExist(err) {
+		t.Errorf("ban list written before flush: %v", err)
+	}
+	if err := b.Flush(); err != nil {
+		t.Fatalf("Flush: unexpected error %v", err)
+	}
+	b, err = NewBanList(banFile)
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+	entries := b.Entries()
+	if len(entries) != len(bans) {
+		t.Fatalf("got %d bans after reload, want %d", len(entries),
+			len(bans))
+	}
+	for _, entry := range entries {
+		if !entry.Automatic {
+			t.Errorf("ban of %s is not automatic after reload",
+				entry.Subnet)
+		}
+	}
+
+	// Ensure the automatic bans which expire first are removed once there
+	// are too many while manual bans are kept.
+	b, err = NewBanList("")
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+	manual, err := ParseSubnet("192.168.0.1")
+	if err != nil {
+		t.Fatalf("ParseSubnet: unexpected error %v", err)
+	}
+	if err := b.Ban(manual, now.Add(time.Minute), "manually added"); err != nil {
+		t.Fatalf("Ban: unexpected error %v", err)
+	}
+	for i := 0; i < maxAutoBans+10; i++ {
+		ip := net.IPv4(10, 1, byte(i>>8), byte(i))
+		expires := now.Add(time.Hour + time.Duration(i)*time.Second)
+		b.BanAddress(ip, expires, "ban score 101")
+	}
+	if entries := b.Entries(); len(entries) != maxAutoBans+1 {
+		t.Errorf("got %d bans, want %d", len(entries), maxAutoBans+1)
+	}
+	if b.Lookup(net.IPv4(10, 1, 0, 9)) != nil {
+		t.Error("Lookup: earliest expiring automatic ban was kept")
+	}
+	if b.Lookup(net.IPv4(10, 1, 0, 10)) == nil {
+		t.Error("Lookup: automatic ban was removed")
+	}
+	if b.Lookup(net.ParseIP("192.168.0.1")) == nil {
+		t.Error("Lookup: manual ban was removed")
+	}
+}
+
+// TestBanListConnections ensures the connection manager neither dials banned
+// addresses nor accepts connections from them.
+func TestBanListConnections(t *testing.T) {
+	b, err := NewBanList("")
+	if err != nil {
+		t.Fatalf("NewBanList: unexpected error %v", err)
+	}
+	subnet, err := ParseSubnet("127.0.0.2")
+	if err != nil {
+		t.Fatalf("ParseSubnet: unexpected error %v", err)
+	}
+	if err := b.Ban(subnet, time.Now().Add(time.Hour), "test"); err != nil {
+		t.Fatalf("Ban: unexpected error %v", err)
+	}
+
+	dialed := make(chan net.Addr, 2)
+	accepted := make(chan net.Conn)
+	listener := newMockListener("127.0.0.1:8333")
+	cmgr, err := New(&Config{
+		Listeners: []net.Listener{listener},
+		OnAccept: func(conn net.Conn) {
+			accepted <- conn
+		},
+		Dial: func(addr net.Addr) (net.Conn, error) {
+			dialed <- addr
+			return mockDialer(addr)
+		},
+		BanList: b,
+	})
+	if err != nil {
+		t.Fatalf("New error: %v", err)
+	}
+	cmgr.Start()
+
+	cmgr.Connect(&ConnReq{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.2"), Port: 18555},
+	})
+	cmgr.Connect(&ConnReq{
+		Addr: &net.TCPAddr{IP: net.ParseIP("127.0.0.3"), Port: 18555},
+	})
+	select {
+	case addr := <-dialed:
+		if addr.String() != "127.0.0.3:18555" {
+			t.Errorf("dialed banned address %v", addr)
+		}
+	case <-time.After(time.Millisecond * 50):
+		t.Fatal("timeout waiting for dial")
+	}
+	select {
+	case addr := <-dialed:
+		t.Errorf("dialed unexpected address %v", addr)
+	case <-time.After(time.Millisecond * 10):
+	}
+
+	go func() {
+		listener.Connect("127.0.0.2", 10000)
+		listener.Connect("127.0.0.3", 10001)
+	}()
+	select {
+	case conn := <-accepted:
+		if conn.RemoteAddr().String() != "127.0.0.3:10001" {
+			t.Errorf("accepted connection from banned address %v",
+				conn.RemoteAddr())
+		}
+	case <-time.After(time.Millisecond * 50):
+		t.Fatal("timeout waiting for accepted connection")
+	}
+
+	cmgr.Stop()
+	cmgr.Wait()
+}
@@ -143,6 +143,12 @@ type Config struct {
 
 	// Dial connects to the address on the named network. It cannot be nil.
 	Dial func(net.Addr) (net.Conn, error)
+
+	// BanList houses the banned subnets.  Inbound connections from banned
+	// addresses are closed right after they are accepted and outbound
+	// connections to banned addresses are never dialed.  It may be nil if
+	// the caller does not wish to ban addresses.
+	BanList *BanList
 }
 
 // handleConnected is used to queue a successful connection.
@@ -308,6 +314,11 @@ func (cm *ConnManager) Connect(c *ConnReq) {
 	if atomic.LoadUint64(&c.id) == 0 {
 		atomic.StoreUint64(&c.id, atomic.AddUint64(&cm.connReqCount, 1))
 	}
+	if cm.cfg.BanList != nil && cm.cfg.BanList.IsBanned(c.Addr) {
+		cm.requests <- handleFailed{c, fmt.Errorf("address %v is banned",
+			c.Addr)}
+		return
+	}
 	log.Debugf("Attempting to connect to %v", c)
 	conn, err := cm.cfg.Dial(c.Addr)
 	if err != nil {
@@ -349,6 +360,12 @@ func (cm *ConnManager) listenHandler(listener net.Listener) {
 			}
 			continue
 		}
+		if cm.cfg.BanList != nil && cm.cfg.BanList.IsBanned(conn.RemoteAddr()) {
+			log.Debugf("Rejecting connection from banned address %s",
+				conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
 		go cm.cfg.OnAccept(conn)
 	}
 
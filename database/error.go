@@ -118,6 +118,11 @@ const (
 	// ErrBlockNotFound instead.
 	ErrBlockRegionInvalid
 
+	// ErrBlockPruned indicates the header for the block with the provided
+	// hash is still available, but the block data itself has been removed
+	// from the database by pruning.
+	ErrBlockPruned
+
 	// ***********************************
 	// Support for driver-specific errors.
 	// ***********************************
@@ -154,6 +159,7 @@ var errorCodeStrings = map[ErrorCode]string{
 	ErrBlockNotFound:      "ErrBlockNotFound",
 	ErrBlockExists:        "ErrBlockExists",
 	ErrBlockRegionInvalid: "ErrBlockRegionInvalid",
+	ErrBlockPruned:        "ErrBlockPruned",
 	ErrDriverSpecific:     "ErrDriverSpecific",
 }
 
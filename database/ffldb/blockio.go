@@ -173,6 +173,11 @@ type blockStore struct {
 	// new blocks are written to.
 	writeCursor *writeCursor
 
+	// firstFileNum is the number of the oldest block file that has not
+	// been pruned.  It is only modified when committing a write
+	// transaction, so it is protected by the database write lock.
+	firstFileNum uint32
+
 	// These functions are set to openFile, openWriteFile, and deleteFile by
 	// default, but are exposed here to allow the whitebox tests to replace
 	// them when working with mock files.
@@ -188,6 +193,17 @@ type blockLocation struct {
 	blockLen     uint32
 }
 
+// prunedBlockLoc is the block location stored in the block index for blocks
+// whose data has been pruned.  No stored block can have a zero length, so it is
+// not possible for it to be confused with an actual location.
+var prunedBlockLoc = blockLocation{}
+
+// isPruned returns whether or not the block location refers to a block whose
+// data has been pruned.
+func (loc blockLocation) isPruned() bool {
+	return loc.blockLen == 0
+}
+
 // deserializeBlockLoc deserializes the passed serialized block location
 // information.  This is data stored into the block index metadata for each
 // block.  The serialized data passed to this function MUST be at least
@@ -312,6 +328,30 @@ func (s *blockStore) deleteFile(fileNum uint32) error {
 	return nil
 }
 
+// removeFile closes the block file for the passed flat file number if it is
+// currently open and then removes it from disk.  It must not be called for the
+// current write file.
+func (s *blockStore) removeFile(fileNum uint32) error {
+	s.obfMutex.Lock()
+	if blockFile, ok := s.openBlockFiles[fileNum]; ok {
+		s.lruMutex.Lock()
+		s.openBlocksLRU.Remove(s.fileNumToLRUElem[fileNum])
+		delete(s.fileNumToLRUElem, fileNum)
+		s.lruMutex.Unlock()
+
+		// Close the file under the write lock for the file in case any
+		// readers are currently reading from it so it's not closed out
+		// from under them.
+		blockFile.Lock()
+		_ = blockFile.file.Close()
+		blockFile.Unlock()
+		delete(s.openBlockFiles, fileNum)
+	}
+	s.obfMutex.Unlock()
+
+	return s.deleteFileFunc(fileNum)
+}
+
 // blockFile attempts to return an existing file handle for the passed flat file
 // number if it is already open as well as marking it as most recently used.  It
 // will also open the file when it's not already open subject to the rules
@@ -719,14 +759,34 @@ func (s *blockStore) handleRollback(oldBlockFileNum, oldBlockOffset uint32) {
 }
 
 // scanBlockFiles searches the database directory for all flat block files to
-// find the end of the most recent file.  This position is considered the
-// current write cursor which is also stored in the metadata.  Thus, it is used
-// to detect unexpected shutdowns in the middle of writes so the block files
-// can be reconciled.
-func scanBlockFiles(dbPath string) (int, uint32) {
+// find the oldest file and the end of the most recent file.  The latter
+// position is considered the current write cursor which is also stored in the
+// metadata.  Thus, it is used to detect unexpected shutdowns in the middle of
+// writes so the block files can be reconciled.
+//
+// The oldest file is not necessarily the first one since the files before it
+// might have been pruned.
+func scanBlockFiles(dbPath string) (int, int, uint32) {
+	firstFile := -1
+	matches, _ := filepath.Glob(filepath.Join(dbPath, "*.fdb"))
+	for _, match := range matches {
+		var fileNum int
+		_, err := fmt.Sscanf(filepath.Base(match), blockFilenameTemplate,
+			&fileNum)
+		if err != nil {
+			continue
+		}
+		if firstFile == -1 || fileNum < firstFile {
+			firstFile = fileNum
+		}
+	}
+	if firstFile == -1 {
+		firstFile = 0
+	}
+
 	lastFile := -1
 	fileLen := uint32(0)
-	for i := 0; ; i++ {
+	for i := firstFile; ; i++ {
 		filePath := blockFilePath(dbPath, uint32(i))
 		st, err := os.Stat(filePath)
 		if err != nil {
@@ -737,9 +797,9 @@ func scanBlockFiles(dbPath string) (int, uint32) {
 		fileLen = uint32(st.Size())
 	}
 
-	log.Tracef("Scan found latest block file #%d with length %d", lastFile,
-		fileLen)
-	return lastFile, fileLen
+	log.Tracef("Scan found oldest block file #%d and latest block file #%d "+
+		"with length %d", firstFile, lastFile, fileLen)
+	return firstFile, lastFile, fileLen
 }
 
 // newBlockStore returns a new block store with the current block file number
@@ -748,8 +808,9 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 	// Look for the end of the latest block to file to determine what the
 	// write cursor position is from the viewpoing of the block files on
 	// disk.
-	fileNum, fileOff := scanBlockFiles(basePath)
+	firstFileNum, fileNum, fileOff := scanBlockFiles(basePath)
 	if fileNum == -1 {
+		firstFileNum = 0
 		fileNum = 0
 		fileOff = 0
 	}
@@ -761,6 +822,7 @@ func newBlockStore(basePath string, network wire.CurrencyNet) *blockStore {
 		openBlockFiles:   make(map[uint32]*lockableFile),
 		openBlocksLRU:    list.New(),
 		fileNumToLRUElem: make(map[uint32]*list.Element),
+		firstFileNum:     uint32(firstFileNum),
 
 		writeCursor: &writeCursor{
 			curFile:    &lockableFile{},
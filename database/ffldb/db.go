@@ -968,6 +968,9 @@ type transaction struct {
 	pendingBlocks    map[chainhash.Hash]int
 	pendingBlockData []pendingBlock
 
+	// Block files that need to be removed on commit due to pruning.
+	pendingPruneFiles []uint32
+
 	// Keys that need to be stored or deleted on commit.
 	pendingKeys   *treap.Mutable
 	pendingRemove *treap.Mutable
@@ -1240,6 +1243,23 @@ func (tx *transaction) fetchBlockRow(hash *chainhash.Hash) ([]byte, error) {
 	return blockRow, nil
 }
 
+// fetchBlockLoc fetches the location of the block data for the provided hash
+// from the block index.  It will return ErrBlockNotFound if there is no entry
+// and ErrBlockPruned if the block data has been pruned.
+func (tx *transaction) fetchBlockLoc(hash *chainhash.Hash) (blockLocation, error) {
+	blockRow, err := tx.fetchBlockRow(hash)
+	if err != nil {
+		return blockLocation{}, err
+	}
+	location := deserializeBlockLoc(blockRow)
+	if location.isPruned() {
+		str := fmt.Sprintf("block %s data has been pruned", hash)
+		return blockLocation{}, makeDbErr(database.ErrBlockPruned, str, nil)
+	}
+
+	return location, nil
+}
+
 // FetchBlockHeader returns the raw serialized bytes for the block header
 // identified by the given hash.  The raw bytes are in the format returned by
 // Serialize on a wire.BlockHeader.
@@ -1339,6 +1359,7 @@ func (tx *transaction) FetchBlockHeaders(hashes []chainhash.Hash) ([][]byte, err
 //
 // Returns the following errors as required by the interface contract:
 //   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrBlockPruned if the requested block data has been pruned
 //   - ErrTxClosed if the transaction has already been closed
 //   - ErrCorruption if the database has somehow become corrupted
 //
@@ -1364,11 +1385,10 @@ func (tx *transaction) FetchBlock(hash *chainhash.Hash) ([]byte, error) {
 	}
 
 	// Lookup the location of the block in the files from the block index.
-	blockRow, err := tx.fetchBlockRow(hash)
+	location, err := tx.fetchBlockLoc(hash)
 	if err != nil {
 		return nil, err
 	}
-	location := deserializeBlockLoc(blockRow)
 
 	// Read the block from the appropriate location.  The function also
 	// performs a checksum over the data to detect data corruption.
@@ -1386,6 +1406,7 @@ func (tx *transaction) FetchBlock(hash *chainhash.Hash) ([]byte, error) {
 //
 // Returns the following errors as required by the interface contract:
 //   - ErrBlockNotFound if any of the requested block hashed do not exist
+//   - ErrBlockPruned if any of the requested block data has been pruned
 //   - ErrTxClosed if the transaction has already been closed
 //   - ErrCorruption if the database has somehow become corrupted
 //
@@ -1462,6 +1483,7 @@ func (tx *transaction) fetchPendingRegion(region *database.BlockRegion) ([]byte,
 //
 // Returns the following errors as required by the interface contract:
 //   - ErrBlockNotFound if the requested block hash does not exist
+//   - ErrBlockPruned if the requested block data has been pruned
 //   - ErrBlockRegionInvalid if the region exceeds the bounds of the associated
 //     block
 //   - ErrTxClosed if the transaction has already been closed
@@ -1495,11 +1517,10 @@ func (tx *transaction) FetchBlockRegion(region *database.BlockRegion) ([]byte, e
 	}
 
 	// Lookup the location of the block in the files from the block index.
-	blockRow, err := tx.fetchBlockRow(region.Hash)
+	location, err := tx.fetchBlockLoc(region.Hash)
 	if err != nil {
 		return nil, err
 	}
-	location := deserializeBlockLoc(blockRow)
 
 	// Ensure the region is within the bounds of the block.
 	endOffset := region.Offset + region.Len
@@ -1535,6 +1556,7 @@ func (tx *transaction) FetchBlockRegion(region *database.BlockRegion) ([]byte, e
 //
 // Returns the following errors as required by the interface contract:
 //   - ErrBlockNotFound if any of the request block hashes do not exist
+//   - ErrBlockPruned if any of the requested block data has been pruned
 //   - ErrBlockRegionInvalid if one or more region exceed the bounds of the
 //     associated block
 //   - ErrTxClosed if the transaction has already been closed
@@ -1593,11 +1615,10 @@ func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]by
 
 		// Lookup the location of the block in the files from the block
 		// index.
-		blockRow, err := tx.fetchBlockRow(region.Hash)
+		location, err := tx.fetchBlockLoc(region.Hash)
 		if err != nil {
 			return nil, err
 		}
-		location := deserializeBlockLoc(blockRow)
 
 		// Ensure the region is within the bounds of the block.
 		endOffset := region.Offset + region.Len
@@ -1629,6 +1650,138 @@ func (tx *transaction) FetchBlockRegions(regions []database.BlockRegion) ([][]by
 	return blockRegions, nil
 }
 
+// PruneBlocks removes the data for the oldest blocks in the database by removing
+// whole flat block files, oldest first, until the total size of the block files
+// is at or below the provided target size in bytes.  The current write file and
+// any files that contain the block identified by keepHash or any block stored
+// after it are never removed.
+//
+// The block index entries for pruned blocks are retained with their location
+// cleared so their headers remain available.  The files themselves are not
+// removed until the transaction is committed.
+//
+// Returns the following errors as required by the interface contract:
+//   - ErrBlockNotFound if the block identified by keepHash does not exist
+//   - ErrTxNotWritable if attempted against a read-only transaction
+//   - ErrTxClosed if the transaction has already been closed
+//
+// In addition, returns ErrDriverSpecific if any failures occur when reading the
+// block files.
+//
+// This function is part of the database.Tx interface implementation.
+func (tx *transaction) PruneBlocks(targetSize uint64, keepHash *chainhash.Hash, fn func(hash *chainhash.Hash, block []byte) error) error {
+	// Ensure transaction state is valid.
+	if err := tx.checkClosed(); err != nil {
+		return err
+	}
+
+	// Ensure the transaction is writable.
+	if !tx.writable {
+		str := "prune blocks requires a writable database transaction"
+		return makeDbErr(database.ErrTxNotWritable, str, nil)
+	}
+
+	store := tx.db.store
+	wc := store.writeCursor
+	wc.RLock()
+	lastFileNum := wc.curFileNum
+	lastOffset := wc.curOffset
+	wc.RUnlock()
+
+	// Determine the file that houses the block to keep.  Every block that
+	// is already stored was written before a block that is pending to be
+	// written on commit, so only the current write file needs to be kept in
+	// that case.
+	keepFileNum := lastFileNum
+	if _, exists := tx.pendingBlocks[*keepHash]; !exists {
+		location, err := tx.fetchBlockLoc(keepHash)
+		if err != nil {
+			return err
+		}
+		keepFileNum = location.blockFileNum
+	}
+
+	// Account for any files that are already pending removal due to a
+	// previous prune in this transaction.
+	firstFileNum := store.firstFileNum
+	if n := len(tx.pendingPruneFiles); n > 0 {
+		firstFileNum = tx.pendingPruneFiles[n-1] + 1
+	}
+
+	// Determine which files to remove.  The total size is approximated by
+	// treating every file other than the current write file as full.
+	maxFileSize := uint64(store.maxBlockFileSize)
+	totalSize := uint64(lastFileNum-firstFileNum)*maxFileSize +
+		uint64(lastOffset)
+	pruneToFileNum := firstFileNum
+	for pruneToFileNum < keepFileNum && pruneToFileNum < lastFileNum &&
+		totalSize > targetSize {
+
+		pruneToFileNum++
+		totalSize -= maxFileSize
+	}
+	if pruneToFileNum == firstFileNum {
+		return nil
+	}
+
+	// Find all of the blocks that are stored in the files to remove and
+	// sort them by their location so they are processed in the order they
+	// were stored.
+	var hashes []chainhash.Hash
+	var fetchList []bulkFetchData
+	cursor := tx.blockIdxBucket.Cursor()
+	for ok := cursor.First(); ok; ok = cursor.Next() {
+		location := deserializeBlockLoc(cursor.Value())
+		if location.isPruned() || location.blockFileNum < firstFileNum ||
+			location.blockFileNum >= pruneToFileNum {
+
+			continue
+		}
+
+		var hash chainhash.Hash
+		copy(hash[:], cursor.Key())
+		fetchList = append(fetchList, bulkFetchData{&location,
+			len(hashes)})
+		hashes = append(hashes, hash)
+	}
+	sort.Sort(bulkFetchDataSorter(fetchList))
+
+	log.Debugf("Pruning %d blocks from block files %d through %d",
+		len(hashes), firstFileNum, pruneToFileNum-1)
+
+	// Provide each block to the caller and replace its location in the
+	// block index with one that marks the data as pruned.
+	for i := range fetchList {
+		hash := &hashes[fetchList[i].replyIndex]
+		if fn != nil {
+			blockBytes, err := store.readBlock(hash,
+				*fetchList[i].blockLocation)
+			if err != nil {
+				return err
+			}
+			if err := fn(hash, blockBytes); err != nil {
+				return err
+			}
+		}
+
+		blockRow, err := tx.fetchBlockRow(hash)
+		if err != nil {
+			return err
+		}
+		blockHdr := blockRow[blockHdrOffset : blockHdrOffset+blockHdrSize]
+		prunedRow := serializeBlockRow(prunedBlockLoc, blockHdr)
+		if err := tx.blockIdxBucket.Put(hash[:], prunedRow); err != nil {
+			return err
+		}
+	}
+
+	for fileNum := firstFileNum; fileNum < pruneToFileNum; fileNum++ {
+		tx.pendingPruneFiles = append(tx.pendingPruneFiles, fileNum)
+	}
+
+	return nil
+}
+
 // close marks the transaction closed then releases any pending data, the
 // underlying snapshot, the transaction read lock, and the write lock when the
 // transaction is writable.
@@ -1638,6 +1791,7 @@ func (tx *transaction) close() {
 	// Clear pending blocks that would have been written on commit.
 	tx.pendingBlocks = nil
 	tx.pendingBlockData = nil
+	tx.pendingPruneFiles = nil
 
 	// Clear pending keys that would have been written or deleted on commit.
 	tx.pendingKeys = nil
@@ -1726,7 +1880,26 @@ func (tx *transaction) writePendingAndCommit() error {
 
 	// Atomically update the database cache.  The cache automatically
 	// handles flushing to the underlying persistent storage database.
-	return tx.db.cache.commitTx(tx)
+	if err := tx.db.cache.commitTx(tx); err != nil {
+		return err
+	}
+
+	// Remove any pruned block files now that the block index no longer
+	// references them.  The cache is always flushed when there are files to
+	// prune, so the updated block index has already been persisted.  Any
+	// failures are only logged since there is no longer any data that
+	// refers to the files and they will be removed by the next prune.
+	store := tx.db.store
+	for _, fileNum := range tx.pendingPruneFiles {
+		log.Debugf("Removing pruned block file %d", fileNum)
+		if err := store.removeFile(fileNum); err != nil {
+			log.Warnf("Failed to remove pruned block file %d: %v",
+				fileNum, err)
+		}
+		store.firstFileNum = fileNum + 1
+	}
+
+	return nil
 }
 
 // Commit commits all changes that have been made to the root metadata bucket
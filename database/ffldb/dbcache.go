@@ -520,6 +520,12 @@ func (c *dbCache) flush() error {
 //
 // This function MUST be called with the database write lock held.
 func (c *dbCache) needsFlush(tx *transaction) bool {
+	// A flush is always needed when block files are being pruned so the
+	// updated block index is persisted before the files are removed.
+	if len(tx.pendingPruneFiles) > 0 {
+		return true
+	}
+
 	// A flush is needed when more time has elapsed than the configured
 	// flush interval.
 	if time.Since(c.lastFlush) > c.flushInterval {
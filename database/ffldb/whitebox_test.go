@@ -22,6 +22,7 @@ import (
 
 	"github.com/btcsuite/goleveldb/leveldb"
 	ldberrors "github.com/btcsuite/goleveldb/leveldb/errors"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcd/database"
 	"github.com/HcashOrg/hcd/wire"
 	"github.com/HcashOrg/hcd/hcutil"
@@ -682,3 +683,191 @@ func TestFailureScenarios(t *testing.T) {
 	// Test various corruption scenarios.
 	testCorruption(tc)
 }
+
+// TestPruneBlocks ensures pruning removes the oldest block files while
+// retaining the headers of the pruned blocks and the data for all blocks that
+// are required to be kept.
+func TestPruneBlocks(t *testing.T) {
+	// Create a new database to run tests against.
+	dbPath := filepath.Join(os.TempDir(), "ffldb-pruneblocks")
+	_ = os.RemoveAll(dbPath)
+	idb, err := database.Create(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to create test database (%s) %v", dbType, err)
+		return
+	}
+	defer os.RemoveAll(dbPath)
+	defer func() {
+		idb.Close()
+	}()
+
+	// Change the maximum file size to a small value to force multiple flat
+	// files with the test data set.
+	store := idb.(*db).store
+	store.maxBlockFileSize = 16 * 1024 // 16KiB
+
+	blocks, err := loadBlocks(t, blockDataFile, blockDataNet)
+	if err != nil {
+		t.Errorf("loadBlocks: Unexpected error: %v", err)
+		return
+	}
+	for i, block := range blocks {
+		err := idb.Update(func(tx database.Tx) error {
+			return tx.StoreBlock(block)
+		})
+		if err != nil {
+			t.Errorf("StoreBlock #%d: unexpected error: %v", i, err)
+			return
+		}
+	}
+
+	// Ensure pruning requires a writable transaction and an existing block
+	// to keep.
+	const keepIdx = 100
+	keepHash := blocks[keepIdx].Hash()
+	err = idb.View(func(tx database.Tx) error {
+		return tx.PruneBlocks(0, keepHash, nil)
+	})
+	if !checkDbError(t, "PruneBlocks read-only", err,
+		database.ErrTxNotWritable) {
+		return
+	}
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.PruneBlocks(0, &chainhash.Hash{}, nil)
+	})
+	if !checkDbError(t, "PruneBlocks unknown keep hash", err,
+		database.ErrBlockNotFound) {
+		return
+	}
+
+	// Ensure nothing is pruned when the target size is not exceeded.
+	var pruned []chainhash.Hash
+	pruneFn := func(hash *chainhash.Hash, block []byte) error {
+		pruned = append(pruned, *hash)
+		return nil
+	}
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.PruneBlocks(^uint64(0), keepHash, pruneFn)
+	})
+	if err != nil {
+		t.Errorf("PruneBlocks: unexpected error: %v", err)
+		return
+	}
+	if len(pruned) != 0 {
+		t.Errorf("PruneBlocks: pruned %d blocks under the target size",
+			len(pruned))
+		return
+	}
+
+	// Prune as much as possible and ensure the blocks are provided to the
+	// caller in the order they were stored and that the block to keep is
+	// retained.
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.PruneBlocks(0, keepHash, pruneFn)
+	})
+	if err != nil {
+		t.Errorf("PruneBlocks: unexpected error: %v", err)
+		return
+	}
+	if len(pruned) == 0 || len(pruned) >= keepIdx {
+		t.Errorf("PruneBlocks: unexpected number of pruned blocks %d",
+			len(pruned))
+		return
+	}
+	for i := range pruned {
+		if pruned[i] != *blocks[i].Hash() {
+			t.Errorf("PruneBlocks: pruned block #%d is %v, want %v",
+				i, pruned[i], blocks[i].Hash())
+			return
+		}
+	}
+	if fileExists(blockFilePath(dbPath, 0)) {
+		t.Error("PruneBlocks: block file 0 was not removed")
+		return
+	}
+
+	// testPrunedState ensures the headers of all blocks are available, the
+	// pruned blocks can't be fetched, and the remaining blocks can be.
+	testPrunedState := func(idb database.DB) bool {
+		err := idb.View(func(tx database.Tx) error {
+			for i, block := range blocks {
+				hash := block.Hash()
+				hasBlock, err := tx.HasBlock(hash)
+				if err != nil || !hasBlock {
+					t.Errorf("HasBlock #%d: got %v (err %v), "+
+						"want true", i, hasBlock, err)
+					return errSubTestFail
+				}
+				hdr, err := tx.FetchBlockHeader(hash)
+				if err != nil {
+					t.Errorf("FetchBlockHeader #%d: "+
+						"unexpected error: %v", i, err)
+					return errSubTestFail
+				}
+				wantHdr, _ := block.MsgBlock().Header.Bytes()
+				if !bytes.Equal(hdr, wantHdr) {
+					t.Errorf("FetchBlockHeader #%d: "+
+						"mismatched header", i)
+					return errSubTestFail
+				}
+
+				region := database.BlockRegion{Hash: hash, Len: 1}
+				_, blockErr := tx.FetchBlock(hash)
+				_, regionErr := tx.FetchBlockRegion(&region)
+				if i < len(pruned) {
+					if !checkDbError(t, "FetchBlock",
+						blockErr, database.ErrBlockPruned) ||
+						!checkDbError(t, "FetchBlockRegion",
+							regionErr,
+							database.ErrBlockPruned) {
+
+						return errSubTestFail
+					}
+					continue
+				}
+				if blockErr != nil || regionErr != nil {
+					t.Errorf("FetchBlock #%d: unexpected "+
+						"error: %v, %v", i, blockErr,
+						regionErr)
+					return errSubTestFail
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			if err != errSubTestFail {
+				t.Errorf("View: unexpected error: %v", err)
+			}
+			return false
+		}
+		return true
+	}
+	if !testPrunedState(idb) {
+		return
+	}
+
+	// Ensure nothing more is pruned since the remaining files all contain
+	// blocks that must be kept.
+	numPruned := len(pruned)
+	err = idb.Update(func(tx database.Tx) error {
+		return tx.PruneBlocks(0, keepHash, pruneFn)
+	})
+	if err != nil {
+		t.Errorf("PruneBlocks: unexpected error: %v", err)
+		return
+	}
+	if len(pruned) != numPruned {
+		t.Errorf("PruneBlocks: unexpectedly pruned %d more blocks",
+			len(pruned)-numPruned)
+		return
+	}
+
+	// Ensure the pruned state is retained across a reopen of the database.
+	idb.Close()
+	idb, err = database.Open(dbType, dbPath, blockDataNet)
+	if err != nil {
+		t.Errorf("Failed to reopen test database (%s) %v", dbType, err)
+		return
+	}
+	testPrunedState(idb)
+}
@@ -231,7 +231,9 @@ type Tx interface {
 	StoreBlock(block *hcutil.Block) error
 
 	// HasBlock returns whether or not a block with the given hash exists
-	// in the database.
+	// in the database.  Blocks whose data has been removed by PruneBlocks
+	// still exist from the viewpoint of this function since their headers
+	// are retained.
 	//
 	// The interface contract guarantees at least the following errors will
 	// be returned (other implementation-specific errors are possible):
@@ -307,6 +309,7 @@ type Tx interface {
 	// The interface contract guarantees at least the following errors will
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if the requested block hash does not exist
+	//   - ErrBlockPruned if the requested block data has been pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -325,6 +328,7 @@ type Tx interface {
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if the any of the requested block hashes do not
 	//     exist
+	//   - ErrBlockPruned if any of the requested block data has been pruned
 	//   - ErrTxClosed if the transaction has already been closed
 	//   - ErrCorruption if the database has somehow become corrupted
 	//
@@ -350,6 +354,7 @@ type Tx interface {
 	// The interface contract guarantees at least the following errors will
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if the requested block hash does not exist
+	//   - ErrBlockPruned if the requested block data has been pruned
 	//   - ErrBlockRegionInvalid if the region exceeds the bounds of the
 	//     associated block
 	//   - ErrTxClosed if the transaction has already been closed
@@ -378,6 +383,7 @@ type Tx interface {
 	// be returned (other implementation-specific errors are possible):
 	//   - ErrBlockNotFound if any of the requested block hashed do not
 	//     exist
+	//   - ErrBlockPruned if any of the requested block data has been pruned
 	//   - ErrBlockRegionInvalid if one or more region exceed the bounds of
 	//     the associated block
 	//   - ErrTxClosed if the transaction has already been closed
@@ -390,6 +396,32 @@ type Tx interface {
 	// implementations.
 	FetchBlockRegions(regions []BlockRegion) ([][]byte, error)
 
+	// PruneBlocks removes the data for the oldest blocks in the database
+	// until the total size of the stored block data is at or below the
+	// provided target size in bytes or there are no more blocks that are
+	// allowed to be removed.  The block identified by keepHash, along with
+	// every block stored after it, is always retained.
+	//
+	// The headers of pruned blocks remain available via FetchBlockHeader
+	// and FetchBlockHeaders, however, attempts to fetch their data will
+	// return ErrBlockPruned.
+	//
+	// The provided function, which may be nil, is invoked with the hash and
+	// raw serialized bytes of each block before its data is removed so the
+	// caller can retain any information derived from it.  Returning an
+	// error from the function aborts the prune and the error is returned.
+	//
+	// Depending on the backend implementation, the underlying storage might
+	// not actually be released until the transaction is committed.
+	//
+	// The interface contract guarantees at least the following errors will
+	// be returned (other implementation-specific errors are possible):
+	//   - ErrBlockNotFound if the block identified by keepHash does not
+	//     exist
+	//   - ErrTxNotWritable if attempted against a read-only transaction
+	//   - ErrTxClosed if the transaction has already been closed
+	PruneBlocks(targetSize uint64, keepHash *chainhash.Hash, fn func(hash *chainhash.Hash, block []byte) error) error
+
 	// ******************************************************************
 	// Methods related to both atomic metadata storage and block storage.
 	// ******************************************************************
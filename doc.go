@@ -43,6 +43,8 @@ Application Options:
                             banning misbehaving peers.
       --whitelist=          Add an IP network or IP that will not be banned.
                             (eg. 192.168.1.0/24 or ::1)
+      --v2transport         Encrypt connections with peers which support the v2
+                            transport
   -u, --rpcuser=            Username for RPC connections
   -P, --rpcpass=            Password for RPC connections
       --rpclimituser=       Username for limited RPC connections
@@ -72,11 +74,24 @@ Application Options:
       --noonion             Disable connecting to tor hidden services
       --torisolation        Enable Tor stream isolation by randomizing user
                             credentials for each connection.
+      --i2pproxy=           Connect to I2P destinations via SOCKS5 proxy
+                            (eg. 127.0.0.1:4447)
+      --cjdnsreachable      Connect to CJDNS addresses, which requires the host
+                            to be part of the CJDNS network
       --testnet             Use the test network
       --simnet              Use the simulation test network
       --nocheckpoints       Disable built-in checkpoints.  Don't do this unless
                             you know what you're doing.
+      --assumevalid=        Hash of a block whose ancestors are assumed to have
+                            valid scripts during the initial sync when it is
+                            part of the best header chain (default: none, all
+                            scripts are validated)
       --dbtype=             Database backend to use for the Block Chain (ffldb)
+      --prune=              Delete the data of old blocks to keep the total size
+                            of the stored blocks below the specified target in
+                            MiB (0 = disabled, minimum 1536).  Incompatible with
+                            --txindex, --addrindex, --addrutxoindex,
+                            --spendindex, and --ticketindex
       --profile=            Enable HTTP profiling on given [addr:]port -- NOTE: port
                             must be between 1024 and 65536
       --cpuprofile=         Write CPU profile to the specified file
@@ -100,11 +115,38 @@ Application Options:
                             high priority for relaying
       --maxorphantx=        Max number of orphan transactions to keep in memory
                             (1000)
+      --maxmempool=         Max size in MiB of the transaction memory pool --
+                            The transactions with the lowest fee rate are
+                            evicted when it is exceeded (0 to disable) (300)
+      --nopersistmempool    Do not save the transaction memory pool on
+                            shutdown and reload it on startup
+      --limitancestorcount= Max number of unconfirmed ancestors in the memory
+                            pool a transaction may have, including itself (0
+                            to disable) (25)
+      --limitancestorsize=  Max total size in kB of a transaction and its
+                            unconfirmed ancestors in the memory pool (0 to
+                            disable) (101)
+      --limitdescendantcount= Max number of descendants in the memory pool any
+                            ancestor of a transaction may have, including
+                            itself (0 to disable) (25)
+      --limitdescendantsize= Max total size in kB of any ancestor of a
+                            transaction and its descendants in the memory pool
+                            (0 to disable) (101)
       --generate            Generate (mine) bitcoins using the CPU
       --miningaddr=         Add the specified payment address to the list of
                             addresses to use for generated blocks -- At least
                             one address is required if the generate option is
                             set
+      --stratum             Enable the built-in Stratum mining server -- At
+                            least one mining address is required
+      --stratumlisten=      Add an interface/port to listen for Stratum miner
+                            connections (default port: 3333)
+      --stratumdiff=        Initial share difficulty assigned to Stratum miner
+                            connections (1)
+      --stratummaxclients=  Max number of Stratum miner connections (50)
+      --stratumpass=        Password Stratum miners must authorize with --
+                            NOTE: This is required if the Stratum server is
+                            bound to non localhost addresses
       --blockminsize=       Mininum block size in bytes to be used when creating
                             a block
       --blockmaxsize=       Maximum block size in bytes to be used when creating
@@ -118,13 +160,20 @@ Application Options:
       --allowoldvotes       Enable the addition of very old votes to the mempool
 
       --nopeerbloomfilters  Disable bloom filtering support.
+      --nocfilters          Disable committed filtering (CF) support.
       --sigcachemaxsize=    The maximum number of entries in the signature
                             verification cache.
+      --utxocachemaxsize=   The maximum size in MiB of the UTXO cache (0 = write
+                            the UTXO set changes of every block to the
+                            database) (default: 150)
       --blocksonly          Do not accept transactions from remote peers.
       --relaynonstd         Relay non-standard transactions regardless of the
                             default settings for the active network.
       --rejectnonstd        Reject non-standard transactions regardless of the
                             default settings for the active network.
+      --rejectreplacement   Reject transactions that attempt to replace existing
+                            transactions within the mempool through the
+                            replace-by-fee policy.
 
 Help Options:
   -h, --help           Show this help message
@@ -0,0 +1,195 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"math"
+	"sort"
+	"time"
+)
+
+const (
+	// evictProtectNetGroup is the number of inbound peers with the highest
+	// keyed network group which are protected from eviction.  Since the key
+	// is only known to this node, an attacker can't predict which network
+	// groups are protected.
+	evictProtectNetGroup = 4
+
+	// evictProtectPing is the number of inbound peers with the lowest ping
+	// time which are protected from eviction.
+	evictProtectPing = 8
+
+	// evictProtectTxRelay is the number of inbound peers which most
+	// recently relayed a new transaction that are protected from eviction.
+	evictProtectTxRelay = 4
+
+	// evictProtectBlockRelay is the number of inbound peers which most
+	// recently relayed a new block that are protected from eviction.
+	evictProtectBlockRelay = 4
+)
+
+// These constants define the reasons reported for the eviction status of
+// inbound peers.
+const (
+	// evictReasonNetGroup means the peer is protected by its network
+	// group.
+	evictReasonNetGroup = "netgroup"
+
+	// evictReasonPing means the peer is protected by its low ping time.
+	evictReasonPing = "ping"
+
+	// evictReasonTxRelay means the peer is protected since it recently
+	// relayed a new transaction.
+	evictReasonTxRelay = "txrelay"
+
+	// evictReasonBlockRelay means the peer is protected since it recently
+	// relayed a new block.
+	evictReasonBlockRelay = "blockrelay"
+
+	// evictReasonUptime means the peer is protected by the age of its
+	// connection.
+	evictReasonUptime = "uptime"
+
+	// evictReasonWhitelisted means the peer is whitelisted and is never
+	// evicted.
+	evictReasonWhitelisted = "whitelisted"
+
+	// evictReasonCandidate means the peer is not protected and may be
+	// evicted.
+	evictReasonCandidate = "candidate"
+
+	// evictReasonNext means the peer is evicted next to make room for a new
+	// inbound peer.
+	evictReasonNext = "next"
+)
+
+// evictionCandidate houses the details of an inbound peer which are used to
+// decide which peer to evict.
+type evictionCandidate struct {
+	id             int32
+	netGroup       string
+	netGroupKey    uint64
+	connTime       time.Time
+	pingMicros     int64
+	lastBlockRelay int64
+	lastTxRelay    int64
+}
+
+// protectCandidates removes up to n of the passed candidates from the returned
+// candidates after sorting them with the passed less function and records the
+// passed reason for them.  Candidates for which eligible returns false are
+// never protected.
+func protectCandidates(candidates []*evictionCandidate, n int, reason string,
+	reasons map[int32]string, less func(a, b *evictionCandidate) bool,
+	eligible func(c *evictionCandidate) bool) []*evictionCandidate {
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return less(candidates[i], candidates[j])
+	})
+
+	remaining := make([]*evictionCandidate, 0, len(candidates))
+	for _, c := range candidates {
+		if n > 0 && eligible(c) {
+			reasons[c.id] = reason
+			n--
+			continue
+		}
+		remaining = append(remaining, c)
+	}
+	return remaining
+}
+
+// selectEvictionCandidate chooses the inbound peer to evict from the passed
+// candidates in order to make room for a new inbound peer.  It returns the id
+// of the chosen peer along with the eviction status of every candidate.  The
+// returned bool is false when all candidates are protected.
+//
+// Peers are protected in the following order so an attacker would need to beat
+// the honest peers at all of them to take over the inbound slots:
+//
+//   - the peers with the highest keyed network group
+//   - the peers with the lowest ping time
+//   - the peers which most recently relayed a new transaction
+//   - the peers which most recently relayed a new block
+//   - half of the remaining peers with the oldest connections
+//
+// The peer with the youngest connection of the network group with the most
+// remaining peers is chosen from the unprotected peers.
+func selectEvictionCandidate(candidates []*evictionCandidate) (int32, bool, map[int32]string) {
+	reasons := make(map[int32]string, len(candidates))
+	remaining := make([]*evictionCandidate, len(candidates))
+	copy(remaining, candidates)
+
+	all := func(c *evictionCandidate) bool { return true }
+	remaining = protectCandidates(remaining, evictProtectNetGroup,
+		evictReasonNetGroup, reasons, func(a, b *evictionCandidate) bool {
+			return a.netGroupKey > b.netGroupKey
+		}, all)
+	remaining = protectCandidates(remaining, evictProtectPing,
+		evictReasonPing, reasons, func(a, b *evictionCandidate) bool {
+			return pingOrMax(a) < pingOrMax(b)
+		}, func(c *evictionCandidate) bool {
+			return c.pingMicros > 0
+		})
+	remaining = protectCandidates(remaining, evictProtectTxRelay,
+		evictReasonTxRelay, reasons, func(a, b *evictionCandidate) bool {
+			return a.lastTxRelay > b.lastTxRelay
+		}, func(c *evictionCandidate) bool {
+			return c.lastTxRelay != 0
+		})
+	remaining = protectCandidates(remaining, evictProtectBlockRelay,
+		evictReasonBlockRelay, reasons, func(a, b *evictionCandidate) bool {
+			return a.lastBlockRelay > b.lastBlockRelay
+		}, func(c *evictionCandidate) bool {
+			return c.lastBlockRelay != 0
+		})
+	remaining = protectCandidates(remaining, len(remaining)/2,
+		evictReasonUptime, reasons, func(a, b *evictionCandidate) bool {
+			return a.connTime.Before(b.connTime)
+		}, all)
+	if len(remaining) == 0 {
+		return 0, false, reasons
+	}
+
+	// Group the remaining peers by network group and keep track of the
+	// youngest connection of each group.
+	groups := make(map[string][]*evictionCandidate)
+	youngest := make(map[string]*evictionCandidate)
+	for _, c := range remaining {
+		reasons[c.id] = evictReasonCandidate
+		groups[c.netGroup] = append(groups[c.netGroup], c)
+		if y, ok := youngest[c.netGroup]; !ok || c.connTime.After(y.connTime) {
+			youngest[c.netGroup] = c
+		}
+	}
+
+	// Choose the group with the most peers, preferring the one with the
+	// youngest connection on ties, and evict its youngest connection.
+	var evictGroup string
+	for group, members := range groups {
+		if evictGroup == "" {
+			evictGroup = group
+			continue
+		}
+		most := len(groups[evictGroup])
+		if len(members) > most || (len(members) == most &&
+			youngest[group].connTime.After(youngest[evictGroup].connTime)) {
+
+			evictGroup = group
+		}
+	}
+	evict := youngest[evictGroup]
+	reasons[evict.id] = evictReasonNext
+	return evict.id, true, reasons
+}
+
+// pingOrMax returns the ping time of the candidate or the maximum ping time if
+// the ping time is not known yet.
+func pingOrMax(c *evictionCandidate) int64 {
+	if c.pingMicros <= 0 {
+		return math.MaxInt64
+	}
+	return c.pingMicros
+}
@@ -0,0 +1,101 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestSelectEvictionCandidate ensures inbound peers are protected from eviction
+// as intended and the youngest peer of the network group with the most
+// unprotected peers is evicted.
+func TestSelectEvictionCandidate(t *testing.T) {
+	now := time.Now()
+
+	// Ensure no peer is evicted when all of them are protected.
+	var candidates []*evictionCandidate
+	for i := 0; i < evictProtectNetGroup; i++ {
+		candidates = append(candidates, &evictionCandidate{
+			id:          int32(i),
+			netGroup:    fmt.Sprintf("10.%d.0.0", i),
+			netGroupKey: uint64(i),
+			connTime:    now,
+		})
+	}
+	if _, ok, reasons := selectEvictionCandidate(candidates); ok {
+		t.Fatalf("evicted a peer with all peers protected: %v", reasons)
+	}
+
+	// Create honest peers in distinct network groups which have low ping
+	// times, relay transactions and blocks or have old connections, along
+	// with attacking peers which share a network group.
+	candidates = candidates[:0]
+	id := int32(0)
+	add := func(group string, key uint64, age time.Duration, ping, txRelay,
+		blockRelay int64) int32 {
+
+		id++
+		candidates = append(candidates, &evictionCandidate{
+			id:             id,
+			netGroup:       group,
+			netGroupKey:    key,
+			connTime:       now.Add(-age),
+			pingMicros:     ping,
+			lastTxRelay:    txRelay,
+			lastBlockRelay: blockRelay,
+		})
+		return id
+	}
+	honest := make(map[int32]string)
+	for i := 0; i < evictProtectNetGroup; i++ {
+		honest[add(fmt.Sprintf("20.%d.0.0", i), 1000+uint64(i),
+			time.Minute, 0, 0, 0)] = evictReasonNetGroup
+	}
+	for i := 0; i < evictProtectPing; i++ {
+		honest[add(fmt.Sprintf("30.%d.0.0", i), uint64(i), time.Minute,
+			int64(100+i), 0, 0)] = evictReasonPing
+	}
+	for i := 0; i < evictProtectTxRelay; i++ {
+		honest[add(fmt.Sprintf("40.%d.0.0", i), uint64(i), time.Minute,
+			0, now.Unix(), 0)] = evictReasonTxRelay
+	}
+	for i := 0; i < evictProtectBlockRelay; i++ {
+		honest[add(fmt.Sprintf("50.%d.0.0", i), uint64(i), time.Minute,
+			0, 0, now.Unix())] = evictReasonBlockRelay
+	}
+	for i := 0; i < 10; i++ {
+		honest[add(fmt.Sprintf("60.%d.0.0", i), uint64(i), time.Hour,
+			0, 0, 0)] = evictReasonUptime
+	}
+	var youngest int32
+	for i := 0; i < 10; i++ {
+		youngest = add("70.1.0.0", uint64(i), time.Duration(10-i)*time.Second,
+			10000, 0, 0)
+	}
+
+	evicted, ok, reasons := selectEvictionCandidate(candidates)
+	if !ok {
+		t.Fatal("no peer evicted")
+	}
+	if evicted != youngest {
+		t.Errorf("evicted peer %d, want %d", evicted, youngest)
+	}
+	if reasons[evicted] != evictReasonNext {
+		t.Errorf("evicted peer has status %q, want %q", reasons[evicted],
+			evictReasonNext)
+	}
+	for id, want := range honest {
+		if reasons[id] != want {
+			t.Errorf("peer %d has status %q, want %q", id, reasons[id],
+				want)
+		}
+	}
+	if len(reasons) != len(candidates) {
+		t.Errorf("got status of %d peers, want %d", len(reasons),
+			len(candidates))
+	}
+}
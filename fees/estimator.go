@@ -0,0 +1,531 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fees
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil"
+)
+
+const (
+	// DefaultMaxConfirmations is the default maximum number of blocks a
+	// transaction is tracked for in order to estimate the fee rate required
+	// to confirm it.  It is also the maximum target accepted by the
+	// estimation functions.
+	DefaultMaxConfirmations = 32
+
+	// DefaultMinBucketFee is the default fee rate in atoms/kB of the lowest
+	// fee rate bucket.  It matches the default minimum relay fee of the
+	// memory pool since transactions paying less than that are not relayed.
+	DefaultMinBucketFee = hcutil.Amount(1e5)
+
+	// DefaultMaxBucketFee is the default fee rate in atoms/kB of the
+	// highest fee rate bucket.  Transactions paying more than this are
+	// tracked in the highest bucket.
+	DefaultMaxBucketFee = hcutil.Amount(1e8)
+
+	// DefaultFeeRateStep is the default multiplier used to determine the
+	// upper bound of each fee rate bucket from the previous one.
+	DefaultFeeRateStep = 1.1
+
+	// DefaultDecay is the default factor applied to all tracked statistics
+	// on every processed block so that recent data is given more weight
+	// than older data.  At the default value the weight of a data point
+	// halves about every 346 blocks.
+	DefaultDecay = 0.998
+
+	// DefaultSuccessPct is the default minimum ratio of transactions that
+	// need to have confirmed within the target number of blocks for a fee
+	// rate range to be considered a valid estimate.
+	DefaultSuccessPct = 0.95
+
+	// EconomicalSuccessPct is a lower success ratio which can be used to
+	// obtain estimates that favor lower fees over the certainty of being
+	// mined within the target.
+	EconomicalSuccessPct = 0.85
+
+	// minTxsPerEstimate is the minimum (decayed) number of transactions a
+	// fee rate range must have observed before it is used to produce an
+	// estimate.
+	minTxsPerEstimate = 2.0
+
+	// estimatorStateVersion is the current version of the serialized state
+	// produced by Save.
+	estimatorStateVersion = 1
+)
+
+var (
+	// EstimatorDatabaseKey is the key used to store the serialized state of
+	// the fee estimator in the metadata bucket of the block database.
+	EstimatorDatabaseKey = []byte("feeestimator")
+
+	// ErrNoSuccessPctBucketFound is returned by the estimation functions
+	// when none of the fee rate ranges have confirmed enough transactions
+	// within the target to reach the required success ratio.
+	ErrNoSuccessPctBucketFound = errors.New("no fee rate range has " +
+		"enough confirmed transactions to produce an estimate")
+
+	// ErrTargetConfTooLarge is returned by the estimation functions when
+	// the requested target number of confirmations is outside of the range
+	// tracked by the estimator.
+	ErrTargetConfTooLarge = errors.New("target confirmations outside of " +
+		"the tracked range")
+)
+
+// EstimatorConfig houses the configuration parameters of a fee estimator.
+type EstimatorConfig struct {
+	// MaxConfirms is the maximum number of blocks a transaction is tracked
+	// for.  Defaults to DefaultMaxConfirmations when zero.
+	MaxConfirms uint32
+
+	// MinBucketFee is the upper bound of the lowest fee rate bucket in
+	// atoms/kB.  Defaults to DefaultMinBucketFee when zero.
+	MinBucketFee hcutil.Amount
+
+	// MaxBucketFee is the upper bound of the highest fee rate bucket in
+	// atoms/kB.  Defaults to DefaultMaxBucketFee when zero.
+	MaxBucketFee hcutil.Amount
+
+	// FeeRateStep is the multiplier between the upper bounds of adjacent
+	// fee rate buckets.  Defaults to DefaultFeeRateStep when zero.
+	FeeRateStep float64
+
+	// Decay is the factor the statistics are multiplied by on every
+	// processed block.  Defaults to DefaultDecay when zero.
+	Decay float64
+
+	// BestHeight is the height of the current best block of the main
+	// chain.  Memory pool transactions added before the next block is
+	// processed are assumed to have entered the memory pool at this
+	// height.
+	BestHeight int64
+}
+
+// feeRate is a transaction fee rate in atoms/kB.
+type feeRate float64
+
+// feeBucket houses the (decayed) confirmation statistics of the transactions
+// whose fee rate falls into a single fee rate range.
+type feeBucket struct {
+	// confirmed holds, for each number of blocks n starting at one, the
+	// number of transactions which were mined within n blocks of entering
+	// the memory pool.  The counts are cumulative, so confirmed[n-1]
+	// includes confirmed[n-2].
+	confirmed []float64
+
+	// total is the number of transactions which were mined, regardless of
+	// how long it took.
+	total float64
+
+	// feeSum is the sum of the fee rates of all mined transactions.
+	feeSum float64
+}
+
+// memPoolTxDesc describes a memory pool transaction which is being tracked by
+// the estimator.
+type memPoolTxDesc struct {
+	addedHeight int64
+	bucket      int
+	rate        feeRate
+}
+
+// Estimator tracks how long memory pool transactions take to be mined based on
+// the fee rate they pay and uses that information to estimate the fee rate a
+// new transaction needs to pay in order to be mined within a given number of
+// blocks.
+//
+// All exported methods are safe for concurrent access.
+type Estimator struct {
+	mtx         sync.RWMutex
+	maxConfirms int
+	decay       float64
+	bucketFees  []feeRate
+	buckets     []feeBucket
+	memPool     map[chainhash.Hash]memPoolTxDesc
+	bestHeight  int64
+}
+
+// NewEstimator returns a new fee estimator configured with the passed
+// parameters.  Zero values in the config are replaced by their defaults.
+func NewEstimator(cfg *EstimatorConfig) (*Estimator, error) {
+	maxConfirms := cfg.MaxConfirms
+	if maxConfirms == 0 {
+		maxConfirms = DefaultMaxConfirmations
+	}
+	minFee := cfg.MinBucketFee
+	if minFee == 0 {
+		minFee = DefaultMinBucketFee
+	}
+	maxFee := cfg.MaxBucketFee
+	if maxFee == 0 {
+		maxFee = DefaultMaxBucketFee
+	}
+	step := cfg.FeeRateStep
+	if step == 0 {
+		step = DefaultFeeRateStep
+	}
+	decay := cfg.Decay
+	if decay == 0 {
+		decay = DefaultDecay
+	}
+
+	if minFee <= 0 || maxFee < minFee {
+		return nil, fmt.Errorf("invalid fee rate bucket range [%v, %v]",
+			minFee, maxFee)
+	}
+	if step <= 1 {
+		return nil, fmt.Errorf("fee rate step %v must be greater "+
+			"than 1", step)
+	}
+	if decay <= 0 || decay > 1 {
+		return nil, fmt.Errorf("decay %v must be in the range (0, 1]",
+			decay)
+	}
+
+	var bucketFees []feeRate
+	for fee := float64(minFee); fee < float64(maxFee); fee *= step {
+		bucketFees = append(bucketFees, feeRate(fee))
+	}
+	bucketFees = append(bucketFees, feeRate(maxFee))
+
+	e := &Estimator{
+		maxConfirms: int(maxConfirms),
+		decay:       decay,
+		bucketFees:  bucketFees,
+		buckets:     make([]feeBucket, len(bucketFees)),
+		memPool:     make(map[chainhash.Hash]memPoolTxDesc),
+		bestHeight:  cfg.BestHeight,
+	}
+	for i := range e.buckets {
+		e.buckets[i].confirmed = make([]float64, maxConfirms)
+	}
+
+	return e, nil
+}
+
+// bucketIndex returns the index of the bucket which tracks the passed fee
+// rate.  Fee rates above the highest bucket bound are tracked in the highest
+// bucket.
+func (e *Estimator) bucketIndex(rate feeRate) int {
+	for i, bound := range e.bucketFees {
+		if rate <= bound {
+			return i
+		}
+	}
+	return len(e.bucketFees) - 1
+}
+
+// AddMemPoolTransaction starts tracking the passed memory pool transaction
+// which pays the given fee and has the given serialized size.  The
+// transaction is assumed to have entered the memory pool at the current best
+// height known to the estimator.
+func (e *Estimator) AddMemPoolTransaction(txHash *chainhash.Hash, fee, size int64) {
+	if size <= 0 {
+		return
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if _, exists := e.memPool[*txHash]; exists {
+		return
+	}
+
+	rate := feeRate(float64(fee) * 1000 / float64(size))
+	e.memPool[*txHash] = memPoolTxDesc{
+		addedHeight: e.bestHeight,
+		bucket:      e.bucketIndex(rate),
+		rate:        rate,
+	}
+	log.Tracef("Tracking transaction %v with fee rate %.0f atoms/kB",
+		txHash, rate)
+}
+
+// RemoveMemPoolTransaction stops tracking the passed transaction.  It is
+// intended to be called when a transaction leaves the memory pool for any
+// reason other than being mined, so it is not counted towards the
+// statistics.
+func (e *Estimator) RemoveMemPoolTransaction(txHash *chainhash.Hash) {
+	e.mtx.Lock()
+	delete(e.memPool, *txHash)
+	e.mtx.Unlock()
+}
+
+// ProcessBlock records the confirmation of every tracked transaction in the
+// regular transaction tree of the passed block, which must have been connected
+// to the main chain, and decays the existing statistics.
+//
+// Blocks that do not extend the best height known to the estimator (such as
+// those connected during a reorganization) only stop tracking the
+// transactions they contain so that they aren't counted twice.
+func (e *Estimator) ProcessBlock(block *hcutil.Block) {
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	height := block.Height()
+	if height <= e.bestHeight {
+		for _, tx := range block.Transactions() {
+			delete(e.memPool, *tx.Hash())
+		}
+		e.bestHeight = height
+		return
+	}
+
+	// Decay the existing statistics once per block so that more recent
+	// data has a larger influence on the estimates.
+	for i := range e.buckets {
+		b := &e.buckets[i]
+		for j := range b.confirmed {
+			b.confirmed[j] *= e.decay
+		}
+		b.total *= e.decay
+		b.feeSum *= e.decay
+	}
+
+	var recorded int
+	for _, tx := range block.Transactions() {
+		desc, exists := e.memPool[*tx.Hash()]
+		if !exists {
+			continue
+		}
+		delete(e.memPool, *tx.Hash())
+
+		// A transaction added at the previous best height and mined in
+		// this block took one block to confirm.
+		blocksToConfirm := int(height - desc.addedHeight)
+		if blocksToConfirm < 1 {
+			blocksToConfirm = 1
+		}
+		b := &e.buckets[desc.bucket]
+		for j := blocksToConfirm - 1; j < e.maxConfirms; j++ {
+			b.confirmed[j]++
+		}
+		b.total++
+		b.feeSum += float64(desc.rate)
+		recorded++
+	}
+
+	e.bestHeight = height
+	log.Debugf("Processed block %v (height %d): recorded %d confirmed "+
+		"transactions, tracking %d", block.Hash(), height, recorded,
+		len(e.memPool))
+}
+
+// estimateFee returns the lowest fee rate estimate for which at least
+// successPct of the transactions were mined within targetConfs blocks along
+// with the success ratio actually observed for that estimate.
+//
+// This function MUST be called with the estimator lock held (for reads).
+func (e *Estimator) estimateFee(targetConfs int, successPct float64) (feeRate, float64, error) {
+	if targetConfs < 1 || targetConfs > e.maxConfirms {
+		return 0, 0, ErrTargetConfTooLarge
+	}
+
+	// Transactions still in the memory pool for at least the target number
+	// of blocks have already failed to confirm within the target.
+	stuck := make([]float64, len(e.buckets))
+	for _, desc := range e.memPool {
+		if e.bestHeight-desc.addedHeight >= int64(targetConfs) {
+			stuck[desc.bucket]++
+		}
+	}
+
+	// Starting at the highest fee rate, group adjacent buckets until there
+	// is enough data to be meaningful and check whether the group reached
+	// the required success ratio.  The lowest group that did is the
+	// estimate.  Stop at the first group that didn't since lower fee rates
+	// are not expected to perform any better.
+	var (
+		confirmed, total, feeSum, count  float64
+		bestFeeSum, bestCount, bestRatio float64
+		found                            bool
+	)
+	for i := len(e.buckets) - 1; i >= 0; i-- {
+		b := &e.buckets[i]
+		confirmed += b.confirmed[targetConfs-1]
+		total += b.total + stuck[i]
+		feeSum += b.feeSum
+		count += b.total
+		if total < minTxsPerEstimate {
+			continue
+		}
+
+		ratio := confirmed / total
+		if ratio < successPct {
+			break
+		}
+
+		bestFeeSum, bestCount, bestRatio = feeSum, count, ratio
+		found = true
+		confirmed, total, feeSum, count = 0, 0, 0, 0
+	}
+	if !found || bestCount == 0 {
+		return 0, 0, ErrNoSuccessPctBucketFound
+	}
+
+	return feeRate(bestFeeSum / bestCount), bestRatio, nil
+}
+
+// EstimateFee returns the estimated fee rate in atoms/kB a transaction needs
+// to pay in order to be mined within targetConfs blocks with the default
+// success ratio.
+func (e *Estimator) EstimateFee(targetConfs int32) (hcutil.Amount, error) {
+	e.mtx.RLock()
+	rate, _, err := e.estimateFee(int(targetConfs), DefaultSuccessPct)
+	e.mtx.RUnlock()
+	if err != nil {
+		return 0, err
+	}
+
+	return hcutil.Amount(math.Ceil(float64(rate))), nil
+}
+
+// EstimateSmartFee returns the estimated fee rate in atoms/kB a transaction
+// needs to pay in order to be mined within targetConfs blocks with at least
+// the passed success ratio, the ratio of the observed transactions paying that
+// rate which were mined in time (the confidence of the estimate) and the
+// number of blocks the estimate is valid for.  When there is not enough data
+// for the requested target, the closest larger target that has enough data is
+// used instead.
+func (e *Estimator) EstimateSmartFee(targetConfs int32, successPct float64) (hcutil.Amount, float64, int32, error) {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	if targetConfs < 1 || int(targetConfs) > e.maxConfirms {
+		return 0, 0, 0, ErrTargetConfTooLarge
+	}
+
+	for target := int(targetConfs); target <= e.maxConfirms; target++ {
+		rate, confidence, err := e.estimateFee(target, successPct)
+		if err == ErrNoSuccessPctBucketFound {
+			continue
+		}
+		if err != nil {
+			return 0, 0, 0, err
+		}
+
+		return hcutil.Amount(math.Ceil(float64(rate))), confidence,
+			int32(target), nil
+	}
+
+	return 0, 0, 0, ErrNoSuccessPctBucketFound
+}
+
+// MaxConfirms returns the maximum target number of confirmations accepted by
+// the estimation functions.
+func (e *Estimator) MaxConfirms() int32 {
+	return int32(e.maxConfirms)
+}
+
+// Save serializes the statistics of the estimator so they can be restored with
+// Restore after a restart.  The tracked memory pool transactions are not
+// saved since the memory pool does not survive restarts either.
+//
+// The serialized format is:
+//
+//	<version><max confirms><num buckets><buckets>
+//
+//	Field          Type     Size
+//	version        uint32   4
+//	max confirms   uint32   4
+//	num buckets    uint32   4
+//	buckets        []bucket num buckets * (24 + max confirms * 8)
+//
+// and each bucket is serialized as its fee rate bound, total, fee sum and
+// confirmed counts as float64.
+func (e *Estimator) Save() []byte {
+	e.mtx.RLock()
+	defer e.mtx.RUnlock()
+
+	var buf bytes.Buffer
+	le := binary.LittleEndian
+	binary.Write(&buf, le, uint32(estimatorStateVersion))
+	binary.Write(&buf, le, uint32(e.maxConfirms))
+	binary.Write(&buf, le, uint32(len(e.buckets)))
+	for i := range e.buckets {
+		b := &e.buckets[i]
+		binary.Write(&buf, le, float64(e.bucketFees[i]))
+		binary.Write(&buf, le, b.total)
+		binary.Write(&buf, le, b.feeSum)
+		binary.Write(&buf, le, b.confirmed)
+	}
+
+	return buf.Bytes()
+}
+
+// Restore replaces the statistics of the estimator with the ones serialized
+// by Save.  The best height and tracked memory pool transactions of the
+// estimator are not modified.  An error is returned if the data is malformed
+// or was produced by an estimator with a different bucket layout, in which
+// case the estimator is left unchanged.
+func (e *Estimator) Restore(data []byte) error {
+	r := bytes.NewReader(data)
+	le := binary.LittleEndian
+
+	var version, maxConfirms, numBuckets uint32
+	if err := binary.Read(r, le, &version); err != nil {
+		return err
+	}
+	if version != estimatorStateVersion {
+		return fmt.Errorf("unsupported fee estimator state version %d",
+			version)
+	}
+	if err := binary.Read(r, le, &maxConfirms); err != nil {
+		return err
+	}
+	if err := binary.Read(r, le, &numBuckets); err != nil {
+		return err
+	}
+
+	e.mtx.Lock()
+	defer e.mtx.Unlock()
+
+	if int(maxConfirms) != e.maxConfirms ||
+		int(numBuckets) != len(e.buckets) {
+
+		return fmt.Errorf("saved fee estimator state has %d buckets "+
+			"and %d max confirmations instead of %d and %d",
+			numBuckets, maxConfirms, len(e.buckets), e.maxConfirms)
+	}
+
+	buckets := make([]feeBucket, numBuckets)
+	for i := range buckets {
+		var bound float64
+		if err := binary.Read(r, le, &bound); err != nil {
+			return err
+		}
+		if feeRate(bound) != e.bucketFees[i] {
+			return fmt.Errorf("saved fee estimator state bucket %d "+
+				"has bound %.0f instead of %.0f", i, bound,
+				e.bucketFees[i])
+		}
+
+		b := &buckets[i]
+		b.confirmed = make([]float64, maxConfirms)
+		if err := binary.Read(r, le, &b.total); err != nil {
+			return err
+		}
+		if err := binary.Read(r, le, &b.feeSum); err != nil {
+			return err
+		}
+		if err := binary.Read(r, le, b.confirmed); err != nil {
+			return err
+		}
+	}
+	if r.Len() != 0 {
+		return fmt.Errorf("%d trailing bytes in saved fee estimator "+
+			"state", r.Len())
+	}
+
+	e.buckets = buckets
+	return nil
+}
@@ -0,0 +1,228 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package fees
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// estimatorHarness provides a fee estimator along with helpers to feed it
+// memory pool transactions and mined blocks.
+type estimatorHarness struct {
+	t      *testing.T
+	est    *Estimator
+	height int64
+	nonce  uint32
+}
+
+// newEstimatorHarness returns a harness with a fee estimator using the default
+// configuration at the passed best height.
+func newEstimatorHarness(t *testing.T, height int64) *estimatorHarness {
+	est, err := NewEstimator(&EstimatorConfig{BestHeight: height})
+	if err != nil {
+		t.Fatalf("NewEstimator: unexpected error: %v", err)
+	}
+	return &estimatorHarness{t: t, est: est, height: height}
+}
+
+// newTx returns a new unique transaction.
+func (h *estimatorHarness) newTx() *hcutil.Tx {
+	h.nonce++
+	msgTx := wire.NewMsgTx()
+	msgTx.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		h.nonce, wire.TxTreeRegular), nil))
+	msgTx.AddTxOut(wire.NewTxOut(1, nil))
+	return hcutil.NewTx(msgTx)
+}
+
+// addTxs adds count new transactions paying the passed fee rate to the
+// estimator and returns them.
+func (h *estimatorHarness) addTxs(count int, rate hcutil.Amount) []*hcutil.Tx {
+	txs := make([]*hcutil.Tx, 0, count)
+	for i := 0; i < count; i++ {
+		tx := h.newTx()
+		h.est.AddMemPoolTransaction(tx.Hash(), int64(rate), 1000)
+		txs = append(txs, tx)
+	}
+	return txs
+}
+
+// connectBlock connects a new block containing the passed transactions.
+func (h *estimatorHarness) connectBlock(txs []*hcutil.Tx) {
+	h.height++
+	msgBlock := &wire.MsgBlock{
+		Header: wire.BlockHeader{Height: uint32(h.height)},
+	}
+	msgBlock.AddTransaction(wire.NewMsgTx())
+	for _, tx := range txs {
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+	h.est.ProcessBlock(hcutil.NewBlock(msgBlock))
+}
+
+// TestEstimateFee ensures the estimator produces the expected estimates for
+// transactions of different fee rates confirming at different speeds.
+func TestEstimateFee(t *testing.T) {
+	h := newEstimatorHarness(t, 100)
+
+	// There is no data before any transaction was mined.
+	if _, err := h.est.EstimateFee(1); err != ErrNoSuccessPctBucketFound {
+		t.Fatalf("EstimateFee: unexpected error -- got %v, want %v",
+			err, ErrNoSuccessPctBucketFound)
+	}
+
+	// High fee transactions are mined in the next block while low fee
+	// transactions are mined after four blocks.
+	const highFee, lowFee = hcutil.Amount(1e6), hcutil.Amount(2e5)
+	for i := 0; i < 10; i++ {
+		high := h.addTxs(10, highFee)
+		low := h.addTxs(10, lowFee)
+		h.connectBlock(high)
+		h.connectBlock(nil)
+		h.connectBlock(nil)
+		h.connectBlock(low)
+	}
+
+	tests := []struct {
+		target int32
+		min    hcutil.Amount
+		max    hcutil.Amount
+	}{
+		{target: 1, min: highFee * 9 / 10, max: highFee * 11 / 10},
+		{target: 2, min: highFee * 9 / 10, max: highFee * 11 / 10},
+		{target: 4, min: lowFee * 9 / 10, max: lowFee * 11 / 10},
+		{target: 10, min: lowFee * 9 / 10, max: lowFee * 11 / 10},
+	}
+	for _, test := range tests {
+		fee, err := h.est.EstimateFee(test.target)
+		if err != nil {
+			t.Errorf("EstimateFee(%d): unexpected error: %v",
+				test.target, err)
+			continue
+		}
+		if fee < test.min || fee > test.max {
+			t.Errorf("EstimateFee(%d): got %v, want between %v and "+
+				"%v", test.target, fee, test.min, test.max)
+		}
+	}
+
+	// Targets outside of the tracked range are rejected.
+	_, err := h.est.EstimateFee(DefaultMaxConfirmations + 1)
+	if err != ErrTargetConfTooLarge {
+		t.Fatalf("EstimateFee: unexpected error -- got %v, want %v",
+			err, ErrTargetConfTooLarge)
+	}
+}
+
+// TestEstimateSmartFee ensures the smart estimate falls back to larger
+// targets when there is not enough data for the requested one.
+func TestEstimateSmartFee(t *testing.T) {
+	h := newEstimatorHarness(t, 0)
+	for i := 0; i < 5; i++ {
+		txs := h.addTxs(10, 5e5)
+		h.connectBlock(nil)
+		h.connectBlock(nil)
+		h.connectBlock(txs)
+	}
+
+	fee, confidence, blocks, err := h.est.EstimateSmartFee(1,
+		DefaultSuccessPct)
+	if err != nil {
+		t.Fatalf("EstimateSmartFee: unexpected error: %v", err)
+	}
+	if blocks != 3 {
+		t.Errorf("EstimateSmartFee: unexpected blocks -- got %d, want 3",
+			blocks)
+	}
+	if fee != 5e5 {
+		t.Errorf("EstimateSmartFee: unexpected fee -- got %v, want %v",
+			fee, hcutil.Amount(5e5))
+	}
+	if confidence < DefaultSuccessPct || confidence > 1 {
+		t.Errorf("EstimateSmartFee: unexpected confidence %v",
+			confidence)
+	}
+}
+
+// TestEstimatorRemoveAndStuck ensures removed transactions are not counted
+// and transactions stuck in the memory pool count as failures.
+func TestEstimatorRemoveAndStuck(t *testing.T) {
+	h := newEstimatorHarness(t, 0)
+	for i := 0; i < 5; i++ {
+		txs := h.addTxs(10, 5e5)
+		h.connectBlock(txs)
+	}
+	if _, err := h.est.EstimateFee(1); err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+
+	// Removed transactions are never counted.
+	for _, tx := range h.addTxs(100, 5e5) {
+		h.est.RemoveMemPoolTransaction(tx.Hash())
+	}
+	h.connectBlock(nil)
+	if _, err := h.est.EstimateFee(1); err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+
+	// A large number of transactions paying the same rate which are not
+	// mined make that rate insufficient.
+	h.addTxs(100, 5e5)
+	h.connectBlock(nil)
+	if _, err := h.est.EstimateFee(1); err != ErrNoSuccessPctBucketFound {
+		t.Fatalf("EstimateFee: unexpected error -- got %v, want %v",
+			err, ErrNoSuccessPctBucketFound)
+	}
+}
+
+// TestEstimatorSaveRestore ensures the statistics of an estimator survive a
+// save and restore round trip and that incompatible data is rejected.
+func TestEstimatorSaveRestore(t *testing.T) {
+	h := newEstimatorHarness(t, 0)
+	for i := 0; i < 5; i++ {
+		txs := h.addTxs(10, 3e5)
+		h.connectBlock(nil)
+		h.connectBlock(txs)
+	}
+	want, err := h.est.EstimateFee(2)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+	saved := h.est.Save()
+
+	restored, err := NewEstimator(&EstimatorConfig{BestHeight: h.height})
+	if err != nil {
+		t.Fatalf("NewEstimator: unexpected error: %v", err)
+	}
+	if err := restored.Restore(saved); err != nil {
+		t.Fatalf("Restore: unexpected error: %v", err)
+	}
+	got, err := restored.EstimateFee(2)
+	if err != nil {
+		t.Fatalf("EstimateFee: unexpected error: %v", err)
+	}
+	if got != want {
+		t.Fatalf("EstimateFee: unexpected restored estimate -- got %v, "+
+			"want %v", got, want)
+	}
+
+	// Data from an estimator with a different layout must be rejected.
+	other, err := NewEstimator(&EstimatorConfig{MaxConfirms: 8})
+	if err != nil {
+		t.Fatalf("NewEstimator: unexpected error: %v", err)
+	}
+	if err := other.Restore(saved); err == nil {
+		t.Fatal("Restore: did not reject incompatible state")
+	}
+
+	// Truncated data must be rejected.
+	if err := restored.Restore(saved[:len(saved)-1]); err == nil {
+		t.Fatal("Restore: did not reject truncated state")
+	}
+}
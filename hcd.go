@@ -148,6 +148,30 @@ func hcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropAddrUtxoIndex {
+		if err := indexers.DropAddrUtxoIndex(db); err != nil {
+			hcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropSpendIndex {
+		if err := indexers.DropSpendIndex(db); err != nil {
+			hcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
+	if cfg.DropTicketIndex {
+		if err := indexers.DropTicketIndex(db); err != nil {
+			hcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 	if cfg.DropTxIndex {
 		if err := indexers.DropTxIndex(db); err != nil {
 			hcdLog.Errorf("%v", err)
@@ -164,6 +188,14 @@ func hcdMain(serverChan chan<- *server) error {
 
 		return nil
 	}
+	if cfg.DropCFIndex {
+		if err := indexers.DropCfIndex(db); err != nil {
+			hcdLog.Errorf("%v", err)
+			return err
+		}
+
+		return nil
+	}
 
 	// Create server and start it.
 	lifetimeNotifier.notifyStartupEvent(lifetimeEventP2PServer)
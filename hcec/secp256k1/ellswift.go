@@ -0,0 +1,198 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"errors"
+	"io"
+	"math/big"
+)
+
+// EllSwiftPubKeyLen is the length of a public key encoded with
+// EllSwiftEncode.
+const EllSwiftPubKeyLen = 64
+
+// fieldOps performs arithmetic modulo the prime of the field of a curve.
+type fieldOps struct {
+	curve *KoblitzCurve
+	p     *big.Int
+}
+
+// mod returns a reduced modulo the prime.
+func (f fieldOps) mod(a *big.Int) *big.Int {
+	return a.Mod(a, f.p)
+}
+
+// add returns a + b.
+func (f fieldOps) add(a, b *big.Int) *big.Int {
+	return f.mod(new(big.Int).Add(a, b))
+}
+
+// sub returns a - b.
+func (f fieldOps) sub(a, b *big.Int) *big.Int {
+	return f.mod(new(big.Int).Sub(a, b))
+}
+
+// mul returns a * b.
+func (f fieldOps) mul(a, b *big.Int) *big.Int {
+	return f.mod(new(big.Int).Mul(a, b))
+}
+
+// div returns a / b.  b must not be zero.
+func (f fieldOps) div(a, b *big.Int) *big.Int {
+	return f.mul(a, new(big.Int).ModInverse(b, f.p))
+}
+
+// neg returns -a.
+func (f fieldOps) neg(a *big.Int) *big.Int {
+	return f.mod(new(big.Int).Neg(a))
+}
+
+// sqrt returns a square root of a along with whether a is a square.
+func (f fieldOps) sqrt(a *big.Int) (*big.Int, bool) {
+	r := new(big.Int).Exp(a, f.curve.QPlus1Div4(), f.p)
+	return r, f.mul(r, r).Cmp(a) == 0
+}
+
+// curveRHS returns x^3 + 7.
+func (f fieldOps) curveRHS(x *big.Int) *big.Int {
+	return f.add(f.mul(f.mul(x, x), x), f.curve.Params().B)
+}
+
+// isValidX returns whether x is the x coordinate of a point on the curve.
+func (f fieldOps) isValidX(x *big.Int) bool {
+	_, ok := f.sqrt(f.curveRHS(x))
+	return ok
+}
+
+// sqrtMinus3 returns the square root of -3 which is used by the encoding.
+func (f fieldOps) sqrtMinus3() *big.Int {
+	r, _ := f.sqrt(f.neg(big.NewInt(3)))
+	return r
+}
+
+// xSwiftEC maps the passed field elements to the x coordinate of a point on the
+// curve as specified by the SwiftEC construction.
+func (f fieldOps) xSwiftEC(u, t *big.Int) *big.Int {
+	u, t = f.mod(new(big.Int).Set(u)), f.mod(new(big.Int).Set(t))
+	if u.Sign() == 0 {
+		u.SetInt64(1)
+	}
+	if t.Sign() == 0 {
+		t.SetInt64(1)
+	}
+	u3Plus7 := f.curveRHS(u)
+	t2 := f.mul(t, t)
+	if f.add(u3Plus7, t2).Sign() == 0 {
+		t = f.add(t, t)
+		t2 = f.mul(t, t)
+	}
+
+	x := f.div(f.sub(u3Plus7, t2), f.add(t, t))
+	y := f.div(f.add(x, t), f.mul(f.sqrtMinus3(), u))
+	x1 := f.add(u, f.mul(big.NewInt(4), f.mul(y, y)))
+	if f.isValidX(x1) {
+		return x1
+	}
+	xDivY := f.div(x, y)
+	two := big.NewInt(2)
+	x2 := f.div(f.sub(f.neg(xDivY), u), two)
+	if f.isValidX(x2) {
+		return x2
+	}
+	return f.div(f.sub(xDivY, u), two)
+}
+
+// xSwiftECInv returns a field element t for which xSwiftEC maps the passed u
+// and t to the passed x coordinate, or nil when there is none for the passed
+// case, which selects one of the up to eight solutions.
+func (f fieldOps) xSwiftECInv(x, u *big.Int, c byte) *big.Int {
+	var s, v *big.Int
+	if c&2 == 0 {
+		if f.isValidX(f.sub(f.neg(x), u)) {
+			return nil
+		}
+		v = x
+		denom := f.add(f.add(f.mul(u, u), f.mul(u, v)), f.mul(v, v))
+		s = f.div(f.neg(f.curveRHS(u)), denom)
+	} else {
+		s = f.sub(x, u)
+		if s.Sign() == 0 {
+			return nil
+		}
+		a := f.mul(big.NewInt(4), f.curveRHS(u))
+		a = f.add(a, f.mul(f.mul(big.NewInt(3), s), f.mul(u, u)))
+		r, ok := f.sqrt(f.neg(f.mul(s, a)))
+		if !ok || (c&1 == 1 && r.Sign() == 0) {
+			return nil
+		}
+		v = f.div(f.sub(f.div(r, s), u), big.NewInt(2))
+	}
+	w, ok := f.sqrt(s)
+	if !ok {
+		return nil
+	}
+
+	two := big.NewInt(2)
+	sqrtMinus3 := f.sqrtMinus3()
+	one := big.NewInt(1)
+	switch c & 5 {
+	case 0:
+		m := f.div(f.mul(u, f.sub(one, sqrtMinus3)), two)
+		return f.neg(f.mul(w, f.add(m, v)))
+	case 1:
+		m := f.div(f.mul(u, f.add(one, sqrtMinus3)), two)
+		return f.mul(w, f.add(m, v))
+	case 4:
+		m := f.div(f.mul(u, f.sub(one, sqrtMinus3)), two)
+		return f.mul(w, f.add(m, v))
+	default:
+		m := f.div(f.mul(u, f.add(one, sqrtMinus3)), two)
+		return f.neg(f.mul(w, f.add(m, v)))
+	}
+}
+
+// EllSwiftEncode encodes the x coordinate of the passed public key with the
+// ElligatorSwift encoding, which is indistinguishable from uniformly random
+// bytes, using the passed source of randomness.  Since only the x coordinate is
+// encoded, the key decoded by EllSwiftDecode may be the negation of the passed
+// key, which does not affect the x coordinate of a shared secret.
+func EllSwiftEncode(rand io.Reader, pubKey *PublicKey) ([]byte, error) {
+	f := fieldOps{curve: S256(), p: S256().Params().P}
+	var buf [33]byte
+	for {
+		if _, err := io.ReadFull(rand, buf[:]); err != nil {
+			return nil, err
+		}
+		u := f.mod(new(big.Int).SetBytes(buf[:32]))
+		if u.Sign() == 0 {
+			continue
+		}
+		t := f.xSwiftECInv(pubKey.X, u, buf[32]&7)
+		if t == nil || f.xSwiftEC(u, t).Cmp(pubKey.X) != 0 {
+			continue
+		}
+
+		encoded := make([]byte, 0, EllSwiftPubKeyLen)
+		encoded = paddedAppend(32, encoded, u.Bytes())
+		encoded = paddedAppend(32, encoded, t.Bytes())
+		return encoded, nil
+	}
+}
+
+// EllSwiftDecode decodes a public key encoded with EllSwiftEncode.  Every
+// sequence of EllSwiftPubKeyLen bytes decodes to a valid public key.
+func EllSwiftDecode(encoded []byte) (*PublicKey, error) {
+	if len(encoded) != EllSwiftPubKeyLen {
+		return nil, errors.New("invalid ElligatorSwift public key length")
+	}
+	curve := S256()
+	f := fieldOps{curve: curve, p: curve.Params().P}
+	u := new(big.Int).SetBytes(encoded[:32])
+	t := new(big.Int).SetBytes(encoded[32:])
+	x := f.xSwiftEC(u, t)
+	y, _ := f.sqrt(f.curveRHS(x))
+	return NewPublicKey(curve, x, y), nil
+}
@@ -0,0 +1,97 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package secp256k1
+
+import (
+	"bytes"
+	"crypto/rand"
+	"testing"
+)
+
+// TestEllSwift ensures public keys round trip through the ElligatorSwift
+// encoding and that both sides of an exchange using the decoded keys agree on
+// the shared secret.
+func TestEllSwift(t *testing.T) {
+	c := S256()
+	for i := 0; i < 32; i++ {
+		privKey1, err := GeneratePrivateKey(c)
+		if err != nil {
+			t.Fatalf("private key generation error: %v", err)
+		}
+		privKey2, err := GeneratePrivateKey(c)
+		if err != nil {
+			t.Fatalf("private key generation error: %v", err)
+		}
+		pk1x, pk1y := privKey1.Public()
+		pk2x, pk2y := privKey2.Public()
+
+		encoded1, err := EllSwiftEncode(rand.Reader,
+			NewPublicKey(c, pk1x, pk1y))
+		if err != nil {
+			t.Fatalf("EllSwiftEncode: %v", err)
+		}
+		if len(encoded1) != EllSwiftPubKeyLen {
+			t.Fatalf("encoded length %d, want %d", len(encoded1),
+				EllSwiftPubKeyLen)
+		}
+		encoded2, err := EllSwiftEncode(rand.Reader,
+			NewPublicKey(c, pk2x, pk2y))
+		if err != nil {
+			t.Fatalf("EllSwiftEncode: %v", err)
+		}
+
+		pubKey1, err := EllSwiftDecode(encoded1)
+		if err != nil {
+			t.Fatalf("EllSwiftDecode: %v", err)
+		}
+		if pubKey1.X.Cmp(pk1x) != 0 {
+			t.Fatalf("decoded x %x, want %x", pubKey1.X, pk1x)
+		}
+		pubKey2, err := EllSwiftDecode(encoded2)
+		if err != nil {
+			t.Fatalf("EllSwiftDecode: %v", err)
+		}
+
+		secret1 := GenerateSharedSecret(privKey1, pubKey2)
+		secret2 := GenerateSharedSecret(privKey2, pubKey1)
+		if !bytes.Equal(secret1, secret2) {
+			t.Fatalf("shared secrets %x and %x do not match", secret1,
+				secret2)
+		}
+	}
+}
+
+// TestEllSwiftDecode ensures any sequence of bytes of the correct length
+// decodes to a point on the curve.
+func TestEllSwiftDecode(t *testing.T) {
+	c := S256()
+	tests := [][]byte{
+		bytes.Repeat([]byte{0x00}, EllSwiftPubKeyLen),
+		bytes.Repeat([]byte{0xff}, EllSwiftPubKeyLen),
+		append(bytes.Repeat([]byte{0x00}, 32),
+			bytes.Repeat([]byte{0xff}, 32)...),
+	}
+	for i := 0; i < 32; i++ {
+		encoded := make([]byte, EllSwiftPubKeyLen)
+		if _, err := rand.Read(encoded); err != nil {
+			t.Fatalf("rand.Read: %v", err)
+		}
+		tests = append(tests, encoded)
+	}
+
+	for _, encoded := range tests {
+		pubKey, err := EllSwiftDecode(encoded)
+		if err != nil {
+			t.Fatalf("EllSwiftDecode(%x): %v", encoded, err)
+		}
+		if !c.IsOnCurve(pubKey.X, pubKey.Y) {
+			t.Fatalf("EllSwiftDecode(%x) is not on the curve", encoded)
+		}
+	}
+
+	if _, err := EllSwiftDecode(make([]byte, 33)); err == nil {
+		t.Fatal("EllSwiftDecode accepted a key of the wrong length")
+	}
+}
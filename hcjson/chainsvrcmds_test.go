@@ -90,6 +90,17 @@ func TestChainSvrCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"addnode","params":["127.0.0.1","remove"],"id":1}`,
 			unmarshalled: &hcjson.AddNodeCmd{Addr: "127.0.0.1", SubCmd: hcjson.ANRemove},
 		},
+		{
+			name: "clearbanned",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("clearbanned")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewClearBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"clearbanned","params":[],"id":1}`,
+			unmarshalled: &hcjson.ClearBannedCmd{},
+		},
 		{
 			name: "debuglevel",
 			newCmd: func() (interface{}, error) {
@@ -757,6 +768,17 @@ func TestChainSvrCmds(t *testing.T) {
 				Command: hcjson.String("getblock"),
 			},
 		},
+		{
+			name: "listbanned",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("listbanned")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewListBannedCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"listbanned","params":[],"id":1}`,
+			unmarshalled: &hcjson.ListBannedCmd{},
+		},
 		{
 			name: "ping",
 			newCmd: func() (interface{}, error) {
@@ -937,6 +959,39 @@ func TestChainSvrCmds(t *testing.T) {
 				AllowHighFees: hcjson.Bool(false),
 			},
 		},
+		{
+			name: "setban",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("setban", "10.0.0.0/24", hcjson.SBAdd)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewSetBanCmd("10.0.0.0/24", hcjson.SBAdd, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["10.0.0.0/24","add"],"id":1}`,
+			unmarshalled: &hcjson.SetBanCmd{
+				Subnet:   "10.0.0.0/24",
+				SubCmd:   hcjson.SBAdd,
+				BanTime:  hcjson.Int64(0),
+				Absolute: hcjson.Bool(false),
+			},
+		},
+		{
+			name: "setban optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("setban", "10.0.0.1", hcjson.SBAdd, 1600000000, true)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewSetBanCmd("10.0.0.1", hcjson.SBAdd,
+					hcjson.Int64(1600000000), hcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"setban","params":["10.0.0.1","add",1600000000,true],"id":1}`,
+			unmarshalled: &hcjson.SetBanCmd{
+				Subnet:   "10.0.0.1",
+				SubCmd:   hcjson.SBAdd,
+				BanTime:  hcjson.Int64(1600000000),
+				Absolute: hcjson.Bool(true),
+			},
+		},
 		{
 			name: "setgenerate",
 			newCmd: func() (interface{}, error) {
@@ -84,6 +84,14 @@ type DecodeScriptResult struct {
 	P2sh      string   `json:"p2sh"`
 }
 
+// EstimateSmartFeeResult models the data returned from the estimatesmartfee
+// command.
+type EstimateSmartFeeResult struct {
+	FeeRate    float64 `json:"feerate"`
+	Confidence float64 `json:"confidence"`
+	Blocks     int64   `json:"blocks"`
+}
+
 // GetAddedNodeInfoResultAddr models the data of the addresses portion of the
 // getaddednodeinfo command.
 type GetAddedNodeInfoResultAddr struct {
@@ -111,6 +119,8 @@ type GetBlockChainInfoResult struct {
 	SyncHeight           int64   `json:"syncheight"`
 	DifficultyRatio      float64 `json:"difficultyratio"`
 	MaxBlockSize         int64   `json:"maxblocksize"`
+	Pruned               bool    `json:"pruned"`
+	PruneHeight          int64   `json:"pruneheight,omitempty"`
 }
 
 // GetBlockSubsidyResult models the data returned from the getblocksubsidy
@@ -139,6 +149,13 @@ type GetBlockTemplateResultAux struct {
 	Flags string `json:"flags"`
 }
 
+// GetBlockTemplateResultTxOut models the coinbaseoutputs field of the
+// getblocktemplate command.
+type GetBlockTemplateResultTxOut struct {
+	Value    int64  `json:"value"`
+	PkScript string `json:"pkscript"`
+}
+
 // GetBlockTemplateResult models the data returned from the getblocktemplate
 // command.
 type GetBlockTemplateResult struct {
@@ -157,6 +174,23 @@ type GetBlockTemplateResult struct {
 	CoinbaseValue *int64                     `json:"coinbasevalue,omitempty"`
 	WorkID        string                     `json:"workid,omitempty"`
 
+	// Fields which are also encoded in the header, but are provided
+	// separately for convenience.
+	Version           int32  `json:"version"`
+	Height            int64  `json:"height"`
+	PreviousBlockHash string `json:"previousblockhash"`
+	Bits              string `json:"bits"`
+	CurTime           int64  `json:"curtime"`
+
+	// Hashes of the stake transactions in the template by type, and the
+	// outputs which must precede the output paying CoinbaseValue in the
+	// coinbase of the block.  CoinbaseOutputs is only provided along with
+	// CoinbaseValue.
+	Votes           []string                      `json:"votes"`
+	Tickets         []string                      `json:"tickets"`
+	Revocations     []string                      `json:"revocations"`
+	CoinbaseOutputs []GetBlockTemplateResultTxOut `json:"coinbaseoutputs,omitempty"`
+
 	// Optional long polling from BIP 0022.
 	LongPollID  string `json:"longpollid,omitempty"`
 	LongPollURI string `json:"longpolluri,omitempty"`
@@ -180,8 +214,11 @@ type GetBlockTemplateResult struct {
 // GetMempoolInfoResult models the data returned from the getmempoolinfo
 // command.
 type GetMempoolInfoResult struct {
-	Size  int64 `json:"size"`
-	Bytes int64 `json:"bytes"`
+	Size          int64   `json:"size"`
+	Bytes         int64   `json:"bytes"`
+	MaxMempool    int64   `json:"maxmempool"`
+	MempoolMinFee float64 `json:"mempoolminfee"`
+	MinRelayTxFee float64 `json:"minrelaytxfee"`
 }
 
 // GetNetworkInfoResult models the data returned from the getnetworkinfo
@@ -213,6 +250,9 @@ type GetPeerInfoResult struct {
 	Version        uint32  `json:"version"`
 	SubVer         string  `json:"subver"`
 	Inbound        bool    `json:"inbound"`
+	Eviction       string  `json:"eviction,omitempty"`
+	Transport      string  `json:"transport"`
+	SessionID      string  `json:"sessionid,omitempty"`
 	StartingHeight int64   `json:"startingheight"`
 	CurrentHeight  int64   `json:"currentheight,omitempty"`
 	BanScore       int32   `json:"banscore"`
@@ -464,6 +504,14 @@ type InfoChainResult struct {
 	Errors          string  `json:"errors"`
 }
 
+// ListBannedResult models the data from the listbanned command.
+type ListBannedResult struct {
+	Address     string `json:"address"`
+	BanCreated  int64  `json:"bancreated"`
+	BannedUntil int64  `json:"banneduntil"`
+	BanReason   string `json:"banreason"`
+}
+
 // LocalAddressesResult models the localaddresses data from the getnetworkinfo
 // command.
 type LocalAddressesResult struct {
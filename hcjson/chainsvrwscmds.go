@@ -69,6 +69,16 @@ func NewNotifyBlocksCmd() *NotifyBlocksCmd {
 	return &NotifyBlocksCmd{}
 }
 
+// NotifyMempoolVotesCmd is a type handling custom marshaling and
+// unmarshaling of notifymempoolvotes JSON websocket extension commands.
+type NotifyMempoolVotesCmd struct {
+}
+
+// NewNotifyMempoolVotesCmd creates a new NotifyMempoolVotesCmd.
+func NewNotifyMempoolVotesCmd() *NotifyMempoolVotesCmd {
+	return &NotifyMempoolVotesCmd{}
+}
+
 // NotifyWinningTicketsCmd is a type handling custom marshaling and
 // unmarshaling of notifywinningtickets JSON websocket extension
 // commands.
@@ -179,6 +189,7 @@ func init() {
 	MustRegisterCmd("loadtxfilter", (*LoadTxFilterCmd)(nil), flags)
 	MustRegisterCmd("setParams", (*SetHcdParmasCmd)(nil), flags)
 	MustRegisterCmd("notifyblocks", (*NotifyBlocksCmd)(nil), flags)
+	MustRegisterCmd("notifymempoolvotes", (*NotifyMempoolVotesCmd)(nil), flags)
 	MustRegisterCmd("notifynewtransactions", (*NotifyNewTransactionsCmd)(nil), flags)
 	MustRegisterCmd("notifynewtickets", (*NotifyNewTicketsCmd)(nil), flags)
 	MustRegisterCmd("notifyspentandmissedtickets",
@@ -42,6 +42,17 @@ func TestChainSvrWsCmds(t *testing.T) {
 			marshalled:   `{"jsonrpc":"1.0","method":"authenticate","params":["user","pass"],"id":1}`,
 			unmarshalled: &hcjson.AuthenticateCmd{Username: "user", Passphrase: "pass"},
 		},
+		{
+			name: "notifymempoolvotes",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("notifymempoolvotes")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewNotifyMempoolVotesCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"notifymempoolvotes","params":[],"id":1}`,
+			unmarshalled: &hcjson.NotifyMempoolVotesCmd{},
+		},
 		{
 			name: "notifywinningtickets",
 			newCmd: func() (interface{}, error) {
@@ -36,6 +36,11 @@ const (
 	// from the chain server that inform a client that a relevant
 	// transaction was accepted by the mempool.
 	RelevantTxAcceptedNtfnMethod = "relevanttxaccepted"
+
+	// TxReplacedNtfnMethod is the method used for notifications from the
+	// chain server that a transaction in the mempool has been replaced by
+	// a transaction paying a higher fee.
+	TxReplacedNtfnMethod = "txreplaced"
 )
 
 // BlockConnectedNtfn defines the blockconnected JSON-RPC notification.
@@ -126,6 +131,21 @@ func NewRelevantTxAcceptedNtfn(txHex string) *RelevantTxAcceptedNtfn {
 	return &RelevantTxAcceptedNtfn{Transaction: txHex}
 }
 
+// TxReplacedNtfn defines the txreplaced JSON-RPC notification.
+type TxReplacedNtfn struct {
+	ReplacedTxID    string `json:"replacedtxid"`
+	ReplacementTxID string `json:"replacementtxid"`
+}
+
+// NewTxReplacedNtfn returns a new instance which can be used to issue a
+// txreplaced JSON-RPC notification.
+func NewTxReplacedNtfn(replacedTxID, replacementTxID string) *TxReplacedNtfn {
+	return &TxReplacedNtfn{
+		ReplacedTxID:    replacedTxID,
+		ReplacementTxID: replacementTxID,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -137,4 +157,5 @@ func init() {
 	MustRegisterCmd(TxAcceptedNtfnMethod, (*TxAcceptedNtfn)(nil), flags)
 	MustRegisterCmd(TxAcceptedVerboseNtfnMethod, (*TxAcceptedVerboseNtfn)(nil), flags)
 	MustRegisterCmd(RelevantTxAcceptedNtfnMethod, (*RelevantTxAcceptedNtfn)(nil), flags)
+	MustRegisterCmd(TxReplacedNtfnMethod, (*TxReplacedNtfn)(nil), flags)
 }
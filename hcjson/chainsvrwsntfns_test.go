@@ -128,6 +128,20 @@ func TestChainSvrWsNtfns(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "txreplaced",
+			newNtfn: func() (interface{}, error) {
+				return hcjson.NewCmd("txreplaced", "123", "456")
+			},
+			staticNtfn: func() interface{} {
+				return hcjson.NewTxReplacedNtfn("123", "456")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"txreplaced","params":["123","456"],"id":null}`,
+			unmarshalled: &hcjson.TxReplacedNtfn{
+				ReplacedTxID:    "123",
+				ReplacementTxID: "456",
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -111,6 +111,91 @@ func NewExistsMempoolTxsCmd(txHashBlob string) *ExistsMempoolTxsCmd {
 	}
 }
 
+// GetAddressBalanceCmd defines the getaddressbalance JSON-RPC command.
+type GetAddressBalanceCmd struct {
+	Address string
+}
+
+// NewGetAddressBalanceCmd returns a new instance which can be used to issue a
+// getaddressbalance JSON-RPC command.
+func NewGetAddressBalanceCmd(address string) *GetAddressBalanceCmd {
+	return &GetAddressBalanceCmd{
+		Address: address,
+	}
+}
+
+// GetAddressDeltasCmd defines the getaddressdeltas JSON-RPC command.
+type GetAddressDeltasCmd struct {
+	Address string
+	Skip    *int  `jsonrpcdefault:"0"`
+	Count   *int  `jsonrpcdefault:"100"`
+	Reverse *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetAddressDeltasCmd returns a new instance which can be used to issue a
+// getaddressdeltas JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressDeltasCmd(address string, skip, count *int, reverse *bool) *GetAddressDeltasCmd {
+	return &GetAddressDeltasCmd{
+		Address: address,
+		Skip:    skip,
+		Count:   count,
+		Reverse: reverse,
+	}
+}
+
+// GetAddressUtxosCmd defines the getaddressutxos JSON-RPC command.
+type GetAddressUtxosCmd struct {
+	Address string
+	Skip    *int `jsonrpcdefault:"0"`
+	Count   *int `jsonrpcdefault:"100"`
+}
+
+// NewGetAddressUtxosCmd returns a new instance which can be used to issue a
+// getaddressutxos JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetAddressUtxosCmd(address string, skip, count *int) *GetAddressUtxosCmd {
+	return &GetAddressUtxosCmd{
+		Address: address,
+		Skip:    skip,
+		Count:   count,
+	}
+}
+
+// GetCFHeadersCmd defines the getcfheaders JSON-RPC command.
+type GetCFHeadersCmd struct {
+	Hash       string
+	FilterType *string `jsonrpcdefault:"\"regular\""`
+}
+
+// NewGetCFHeadersCmd returns a new instance which can be used to issue a
+// getcfheaders JSON-RPC command.
+func NewGetCFHeadersCmd(hash string, filterType *string) *GetCFHeadersCmd {
+	return &GetCFHeadersCmd{
+		Hash:       hash,
+		FilterType: filterType,
+	}
+}
+
+// GetCFilterCmd defines the getcfilter JSON-RPC command.
+type GetCFilterCmd struct {
+	Hash       string
+	FilterType *string `jsonrpcdefault:"\"regular\""`
+}
+
+// NewGetCFilterCmd returns a new instance which can be used to issue a
+// getcfilter JSON-RPC command.
+func NewGetCFilterCmd(hash string, filterType *string) *GetCFilterCmd {
+	return &GetCFilterCmd{
+		Hash:       hash,
+		FilterType: filterType,
+	}
+}
+
 // GetCoinSupplyCmd defines the getcoinsupply JSON-RPC command.
 type GetCoinSupplyCmd struct{}
 
@@ -120,6 +205,86 @@ func NewGetCoinSupplyCmd() *GetCoinSupplyCmd {
 	return &GetCoinSupplyCmd{}
 }
 
+// GetMempoolAncestorsCmd defines the getmempoolancestors JSON-RPC command.
+type GetMempoolAncestorsCmd struct {
+	TxHash  string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetMempoolAncestorsCmd returns a new instance which can be used to issue a
+// getmempoolancestors JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMempoolAncestorsCmd(txHash string, verbose *bool) *GetMempoolAncestorsCmd {
+	return &GetMempoolAncestorsCmd{
+		TxHash:  txHash,
+		Verbose: verbose,
+	}
+}
+
+// GetMempoolDescendantsCmd defines the getmempooldescendants JSON-RPC command.
+type GetMempoolDescendantsCmd struct {
+	TxHash  string
+	Verbose *bool `jsonrpcdefault:"false"`
+}
+
+// NewGetMempoolDescendantsCmd returns a new instance which can be used to issue
+// a getmempooldescendants JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMempoolDescendantsCmd(txHash string, verbose *bool) *GetMempoolDescendantsCmd {
+	return &GetMempoolDescendantsCmd{
+		TxHash:  txHash,
+		Verbose: verbose,
+	}
+}
+
+// GetMempoolEntryCmd defines the getmempoolentry JSON-RPC command.
+type GetMempoolEntryCmd struct {
+	TxHash string
+}
+
+// NewGetMempoolEntryCmd returns a new instance which can be used to issue a
+// getmempoolentry JSON-RPC command.
+func NewGetMempoolEntryCmd(txHash string) *GetMempoolEntryCmd {
+	return &GetMempoolEntryCmd{
+		TxHash: txHash,
+	}
+}
+
+// GetMempoolVotesCmd defines the getmempoolvotes JSON-RPC command.
+type GetMempoolVotesCmd struct {
+	BlockHash *string
+}
+
+// NewGetMempoolVotesCmd returns a new instance which can be used to issue a
+// getmempoolvotes JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewGetMempoolVotesCmd(blockHash *string) *GetMempoolVotesCmd {
+	return &GetMempoolVotesCmd{
+		BlockHash: blockHash,
+	}
+}
+
+// GetSpendingInfoCmd defines the getspendinginfo JSON-RPC command.
+type GetSpendingInfoCmd struct {
+	TxHash string
+	Vout   uint32
+}
+
+// NewGetSpendingInfoCmd returns a new instance which can be used to issue a
+// getspendinginfo JSON-RPC command.
+func NewGetSpendingInfoCmd(txHash string, vout uint32) *GetSpendingInfoCmd {
+	return &GetSpendingInfoCmd{
+		TxHash: txHash,
+		Vout:   vout,
+	}
+}
+
 // GetStakeDifficultyCmd is a type handling custom marshaling and
 // unmarshaling of getstakedifficulty JSON RPC commands.
 type GetStakeDifficultyCmd struct{}
@@ -160,6 +325,42 @@ func NewGetStakeVersionsCmd(hash string, count int32) *GetStakeVersionsCmd {
 	}
 }
 
+// GetStratumInfoCmd defines the getstratuminfo JSON-RPC command.
+type GetStratumInfoCmd struct{}
+
+// NewGetStratumInfoCmd returns a new instance which can be used to issue a
+// getstratuminfo JSON-RPC command.
+func NewGetStratumInfoCmd() *GetStratumInfoCmd {
+	return &GetStratumInfoCmd{}
+}
+
+// GetTicketInfoCmd defines the getticketinfo JSON-RPC command.
+type GetTicketInfoCmd struct {
+	Ticket string
+}
+
+// NewGetTicketInfoCmd returns a new instance which can be used to issue a
+// getticketinfo JSON-RPC command.
+func NewGetTicketInfoCmd(ticket string) *GetTicketInfoCmd {
+	return &GetTicketInfoCmd{
+		Ticket: ticket,
+	}
+}
+
+// GetTicketPoolInfoCmd defines the getticketpoolinfo JSON-RPC command.
+type GetTicketPoolInfoCmd struct {
+	Height *int64
+}
+
+// NewGetTicketPoolInfoCmd returns a new instance which can be used to issue a
+// getticketpoolinfo JSON-RPC command.  A nil height reports the state of the
+// ticket pool after the current best block.
+func NewGetTicketPoolInfoCmd(height *int64) *GetTicketPoolInfoCmd {
+	return &GetTicketPoolInfoCmd{
+		Height: height,
+	}
+}
+
 // GetTicketPoolValueCmd defines the getticketpoolvalue JSON-RPC command.
 type GetTicketPoolValueCmd struct{}
 
@@ -183,6 +384,19 @@ func NewGetVoteInfoCmd(version uint32) *GetVoteInfoCmd {
 	}
 }
 
+// GetWinningTicketsCmd defines the getwinningtickets JSON-RPC command.
+type GetWinningTicketsCmd struct {
+	BlockHash string
+}
+
+// NewGetWinningTicketsCmd returns a new instance which can be used to issue a
+// getwinningtickets JSON-RPC command.
+func NewGetWinningTicketsCmd(blockHash string) *GetWinningTicketsCmd {
+	return &GetWinningTicketsCmd{
+		BlockHash: blockHash,
+	}
+}
+
 // LiveTicketsCmd is a type handling custom marshaling and
 // unmarshaling of livetickets JSON RPC commands.
 type LiveTicketsCmd struct{}
@@ -193,6 +407,15 @@ func NewLiveTicketsCmd() *LiveTicketsCmd {
 	return &LiveTicketsCmd{}
 }
 
+// LoadMempoolCmd defines the loadmempool JSON-RPC command.
+type LoadMempoolCmd struct{}
+
+// NewLoadMempoolCmd returns a new instance which can be used to issue a
+// loadmempool JSON-RPC command.
+func NewLoadMempoolCmd() *LoadMempoolCmd {
+	return &LoadMempoolCmd{}
+}
+
 // MissedTicketsCmd is a type handling custom marshaling and
 // unmarshaling of missedtickets JSON RPC commands.
 type MissedTicketsCmd struct{}
@@ -203,6 +426,23 @@ func NewMissedTicketsCmd() *MissedTicketsCmd {
 	return &MissedTicketsCmd{}
 }
 
+// ProjectStakeDiffCmd defines the projectstakediff JSON-RPC command.
+type ProjectStakeDiffCmd struct {
+	Purchases       []int64
+	Windows         *int64 `jsonrpcdefault:"1"`
+	FuturePurchases *int64
+}
+
+// NewProjectStakeDiffCmd returns a new instance which can be used to issue a
+// projectstakediff JSON-RPC command.
+func NewProjectStakeDiffCmd(purchases []int64, windows, futurePurchases *int64) *ProjectStakeDiffCmd {
+	return &ProjectStakeDiffCmd{
+		Purchases:       purchases,
+		Windows:         windows,
+		FuturePurchases: futurePurchases,
+	}
+}
+
 // RebroadcastMissedCmd is a type handling custom marshaling and
 // unmarshaling of rebroadcastwinners JSON RPC commands.
 type RebroadcastMissedCmd struct{}
@@ -223,6 +463,33 @@ func NewRebroadcastWinnersCmd() *RebroadcastWinnersCmd {
 	return &RebroadcastWinnersCmd{}
 }
 
+// SaveMempoolCmd defines the savemempool JSON-RPC command.
+type SaveMempoolCmd struct{}
+
+// NewSaveMempoolCmd returns a new instance which can be used to issue a
+// savemempool JSON-RPC command.
+func NewSaveMempoolCmd() *SaveMempoolCmd {
+	return &SaveMempoolCmd{}
+}
+
+// TestMempoolAcceptCmd defines the testmempoolaccept JSON-RPC command.
+type TestMempoolAcceptCmd struct {
+	RawTxs        []string
+	AllowHighFees *bool `jsonrpcdefault:"false"`
+}
+
+// NewTestMempoolAcceptCmd returns a new instance which can be used to issue a
+// testmempoolaccept JSON-RPC command.
+//
+// The parameters which are pointers indicate they are optional.  Passing nil
+// for optional parameters will use the default value.
+func NewTestMempoolAcceptCmd(rawTxs []string, allowHighFees *bool) *TestMempoolAcceptCmd {
+	return &TestMempoolAcceptCmd{
+		RawTxs:        rawTxs,
+		AllowHighFees: allowHighFees,
+	}
+}
+
 // TicketFeeInfoCmd defines the ticketsfeeinfo JSON-RPC command.
 type TicketFeeInfoCmd struct {
 	Blocks  *uint32
@@ -300,16 +567,34 @@ func init() {
 	MustRegisterCmd("existsliveticket", (*ExistsLiveTicketCmd)(nil), flags)
 	MustRegisterCmd("existslivetickets", (*ExistsLiveTicketsCmd)(nil), flags)
 	MustRegisterCmd("existsmempooltxs", (*ExistsMempoolTxsCmd)(nil), flags)
+	MustRegisterCmd("getaddressbalance", (*GetAddressBalanceCmd)(nil), flags)
+	MustRegisterCmd("getaddressdeltas", (*GetAddressDeltasCmd)(nil), flags)
+	MustRegisterCmd("getaddressutxos", (*GetAddressUtxosCmd)(nil), flags)
+	MustRegisterCmd("getcfheaders", (*GetCFHeadersCmd)(nil), flags)
+	MustRegisterCmd("getcfilter", (*GetCFilterCmd)(nil), flags)
 	MustRegisterCmd("getcoinsupply", (*GetCoinSupplyCmd)(nil), flags)
+	MustRegisterCmd("getmempoolancestors", (*GetMempoolAncestorsCmd)(nil), flags)
+	MustRegisterCmd("getmempooldescendants", (*GetMempoolDescendantsCmd)(nil), flags)
+	MustRegisterCmd("getmempoolentry", (*GetMempoolEntryCmd)(nil), flags)
+	MustRegisterCmd("getmempoolvotes", (*GetMempoolVotesCmd)(nil), flags)
+	MustRegisterCmd("getspendinginfo", (*GetSpendingInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakedifficulty", (*GetStakeDifficultyCmd)(nil), flags)
 	MustRegisterCmd("getstakeversioninfo", (*GetStakeVersionInfoCmd)(nil), flags)
 	MustRegisterCmd("getstakeversions", (*GetStakeVersionsCmd)(nil), flags)
+	MustRegisterCmd("getstratuminfo", (*GetStratumInfoCmd)(nil), flags)
+	MustRegisterCmd("getticketinfo", (*GetTicketInfoCmd)(nil), flags)
+	MustRegisterCmd("getticketpoolinfo", (*GetTicketPoolInfoCmd)(nil), flags)
 	MustRegisterCmd("getticketpoolvalue", (*GetTicketPoolValueCmd)(nil), flags)
 	MustRegisterCmd("getvoteinfo", (*GetVoteInfoCmd)(nil), flags)
+	MustRegisterCmd("getwinningtickets", (*GetWinningTicketsCmd)(nil), flags)
 	MustRegisterCmd("livetickets", (*LiveTicketsCmd)(nil), flags)
+	MustRegisterCmd("loadmempool", (*LoadMempoolCmd)(nil), flags)
 	MustRegisterCmd("missedtickets", (*MissedTicketsCmd)(nil), flags)
+	MustRegisterCmd("projectstakediff", (*ProjectStakeDiffCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastmissed", (*RebroadcastMissedCmd)(nil), flags)
 	MustRegisterCmd("rebroadcastwinners", (*RebroadcastWinnersCmd)(nil), flags)
+	MustRegisterCmd("savemempool", (*SaveMempoolCmd)(nil), flags)
+	MustRegisterCmd("testmempoolaccept", (*TestMempoolAcceptCmd)(nil), flags)
 	MustRegisterCmd("ticketfeeinfo", (*TicketFeeInfoCmd)(nil), flags)
 	MustRegisterCmd("ticketsforaddress", (*TicketsForAddressCmd)(nil), flags)
 	MustRegisterCmd("ticketvwap", (*TicketVWAPCmd)(nil), flags)
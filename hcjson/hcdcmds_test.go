@@ -43,6 +43,311 @@ func TestDcrdCmds(t *testing.T) {
 				LevelSpec: "trace",
 			},
 		},
+		{
+			name: "getaddressbalance",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getaddressbalance", "1Address")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetAddressBalanceCmd("1Address")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressbalance","params":["1Address"],"id":1}`,
+			unmarshalled: &hcjson.GetAddressBalanceCmd{
+				Address: "1Address",
+			},
+		},
+		{
+			name: "getaddressdeltas",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getaddressdeltas", "1Address")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetAddressDeltasCmd("1Address", nil, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressdeltas","params":["1Address"],"id":1}`,
+			unmarshalled: &hcjson.GetAddressDeltasCmd{
+				Address: "1Address",
+				Skip:    hcjson.Int(0),
+				Count:   hcjson.Int(100),
+				Reverse: hcjson.Bool(false),
+			},
+		},
+		{
+			name: "getaddressdeltas optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getaddressdeltas", "1Address", 5, 10, true)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetAddressDeltasCmd("1Address",
+					hcjson.Int(5), hcjson.Int(10), hcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressdeltas","params":["1Address",5,10,true],"id":1}`,
+			unmarshalled: &hcjson.GetAddressDeltasCmd{
+				Address: "1Address",
+				Skip:    hcjson.Int(5),
+				Count:   hcjson.Int(10),
+				Reverse: hcjson.Bool(true),
+			},
+		},
+		{
+			name: "getaddressutxos",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getaddressutxos", "1Address", 5)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetAddressUtxosCmd("1Address",
+					hcjson.Int(5), nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getaddressutxos","params":["1Address",5],"id":1}`,
+			unmarshalled: &hcjson.GetAddressUtxosCmd{
+				Address: "1Address",
+				Skip:    hcjson.Int(5),
+				Count:   hcjson.Int(100),
+			},
+		},
+		{
+			name: "getcfilter",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getcfilter", "deadbeef")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetCFilterCmd("deadbeef", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcfilter","params":["deadbeef"],"id":1}`,
+			unmarshalled: &hcjson.GetCFilterCmd{
+				Hash:       "deadbeef",
+				FilterType: hcjson.String("regular"),
+			},
+		},
+		{
+			name: "getcfheaders",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getcfheaders", "deadbeef", "regular")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetCFHeadersCmd("deadbeef",
+					hcjson.String("regular"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getcfheaders","params":["deadbeef","regular"],"id":1}`,
+			unmarshalled: &hcjson.GetCFHeadersCmd{
+				Hash:       "deadbeef",
+				FilterType: hcjson.String("regular"),
+			},
+		},
+		{
+			name: "getspendinginfo",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getspendinginfo", "123", 1)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetSpendingInfoCmd("123", 1)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getspendinginfo","params":["123",1],"id":1}`,
+			unmarshalled: &hcjson.GetSpendingInfoCmd{
+				TxHash: "123",
+				Vout:   1,
+			},
+		},
+		{
+			name: "getmempoolancestors",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getmempoolancestors", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetMempoolAncestorsCmd("123", nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolancestors","params":["123"],"id":1}`,
+			unmarshalled: &hcjson.GetMempoolAncestorsCmd{
+				TxHash:  "123",
+				Verbose: hcjson.Bool(false),
+			},
+		},
+		{
+			name: "getmempooldescendants",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getmempooldescendants", "123", true)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetMempoolDescendantsCmd("123",
+					hcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempooldescendants","params":["123",true],"id":1}`,
+			unmarshalled: &hcjson.GetMempoolDescendantsCmd{
+				TxHash:  "123",
+				Verbose: hcjson.Bool(true),
+			},
+		},
+		{
+			name: "getmempoolentry",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getmempoolentry", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetMempoolEntryCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolentry","params":["123"],"id":1}`,
+			unmarshalled: &hcjson.GetMempoolEntryCmd{
+				TxHash: "123",
+			},
+		},
+		{
+			name: "getmempoolvotes",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getmempoolvotes")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetMempoolVotesCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolvotes","params":[],"id":1}`,
+			unmarshalled: &hcjson.GetMempoolVotesCmd{
+				BlockHash: nil,
+			},
+		},
+		{
+			name: "getmempoolvotes optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getmempoolvotes", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetMempoolVotesCmd(hcjson.String("123"))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getmempoolvotes","params":["123"],"id":1}`,
+			unmarshalled: &hcjson.GetMempoolVotesCmd{
+				BlockHash: hcjson.String("123"),
+			},
+		},
+		{
+			name: "getstratuminfo",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getstratuminfo")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetStratumInfoCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"getstratuminfo","params":[],"id":1}`,
+			unmarshalled: &hcjson.GetStratumInfoCmd{},
+		},
+		{
+			name: "getticketinfo",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getticketinfo", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetTicketInfoCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getticketinfo","params":["123"],"id":1}`,
+			unmarshalled: &hcjson.GetTicketInfoCmd{
+				Ticket: "123",
+			},
+		},
+		{
+			name: "getticketpoolinfo",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getticketpoolinfo")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetTicketPoolInfoCmd(nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getticketpoolinfo","params":[],"id":1}`,
+			unmarshalled: &hcjson.GetTicketPoolInfoCmd{
+				Height: nil,
+			},
+		},
+		{
+			name: "getticketpoolinfo optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getticketpoolinfo", 1000)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetTicketPoolInfoCmd(hcjson.Int64(1000))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getticketpoolinfo","params":[1000],"id":1}`,
+			unmarshalled: &hcjson.GetTicketPoolInfoCmd{
+				Height: hcjson.Int64(1000),
+			},
+		},
+		{
+			name: "loadmempool",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("loadmempool")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewLoadMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"loadmempool","params":[],"id":1}`,
+			unmarshalled: &hcjson.LoadMempoolCmd{},
+		},
+		{
+			name: "projectstakediff",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("projectstakediff", []int64{1, 2})
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewProjectStakeDiffCmd([]int64{1, 2}, nil, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"projectstakediff","params":[[1,2]],"id":1}`,
+			unmarshalled: &hcjson.ProjectStakeDiffCmd{
+				Purchases:       []int64{1, 2},
+				Windows:         hcjson.Int64(1),
+				FuturePurchases: nil,
+			},
+		},
+		{
+			name: "projectstakediff optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("projectstakediff", []int64{1, 2}, 3, 5)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewProjectStakeDiffCmd([]int64{1, 2},
+					hcjson.Int64(3), hcjson.Int64(5))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"projectstakediff","params":[[1,2],3,5],"id":1}`,
+			unmarshalled: &hcjson.ProjectStakeDiffCmd{
+				Purchases:       []int64{1, 2},
+				Windows:         hcjson.Int64(3),
+				FuturePurchases: hcjson.Int64(5),
+			},
+		},
+		{
+			name: "savemempool",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("savemempool")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewSaveMempoolCmd()
+			},
+			marshalled:   `{"jsonrpc":"1.0","method":"savemempool","params":[],"id":1}`,
+			unmarshalled: &hcjson.SaveMempoolCmd{},
+		},
+		{
+			name: "testmempoolaccept",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("testmempoolaccept", []string{"1234", "5678"})
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewTestMempoolAcceptCmd([]string{"1234", "5678"}, nil)
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"testmempoolaccept","params":[["1234","5678"]],"id":1}`,
+			unmarshalled: &hcjson.TestMempoolAcceptCmd{
+				RawTxs:        []string{"1234", "5678"},
+				AllowHighFees: hcjson.Bool(false),
+			},
+		},
+		{
+			name: "testmempoolaccept optional",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("testmempoolaccept", []string{"1234"}, true)
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewTestMempoolAcceptCmd([]string{"1234"},
+					hcjson.Bool(true))
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"testmempoolaccept","params":[["1234"],true],"id":1}`,
+			unmarshalled: &hcjson.TestMempoolAcceptCmd{
+				RawTxs:        []string{"1234"},
+				AllowHighFees: hcjson.Bool(true),
+			},
+		},
 		{
 			name: "getstakeversions",
 			newCmd: func() (interface{}, error) {
@@ -70,6 +375,19 @@ func TestDcrdCmds(t *testing.T) {
 				Version: 1,
 			},
 		},
+		{
+			name: "getwinningtickets",
+			newCmd: func() (interface{}, error) {
+				return hcjson.NewCmd("getwinningtickets", "123")
+			},
+			staticCmd: func() interface{} {
+				return hcjson.NewGetWinningTicketsCmd("123")
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"getwinningtickets","params":["123"],"id":1}`,
+			unmarshalled: &hcjson.GetWinningTicketsCmd{
+				BlockHash: "123",
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -6,6 +6,66 @@
 
 package hcjson
 
+// GetAddressBalanceResult models the data returned from the getaddressbalance
+// command.
+type GetAddressBalanceResult struct {
+	Address  string  `json:"address"`
+	Balance  float64 `json:"balance"`
+	Received float64 `json:"received"`
+}
+
+// AddressDeltaResult models a single change to the balance of an address
+// included in the getaddressdeltas command result.
+type AddressDeltaResult struct {
+	TxID    string  `json:"txid"`
+	Index   uint32  `json:"index"`
+	IsInput bool    `json:"isinput"`
+	Tree    int8    `json:"tree"`
+	Amount  float64 `json:"amount"`
+	Height  int64   `json:"height"`
+}
+
+// AddressUtxoResult models a single unspent output included in the
+// getaddressutxos command result.
+type AddressUtxoResult struct {
+	TxID          string  `json:"txid"`
+	Vout          uint32  `json:"vout"`
+	Tree          int8    `json:"tree"`
+	Amount        float64 `json:"amount"`
+	ScriptVersion uint16  `json:"scriptversion"`
+	ScriptPubKey  string  `json:"scriptpubkey"`
+	Height        int64   `json:"height"`
+}
+
+// GetMempoolEntryResult models the data returned from the getmempoolentry
+// command and the verbose getmempoolancestors and getmempooldescendants
+// commands.  The ancestor and descendant counts, sizes and fees include the
+// transaction itself.
+type GetMempoolEntryResult struct {
+	Size             int32    `json:"size"`
+	Fee              float64  `json:"fee"`
+	Time             int64    `json:"time"`
+	Height           int64    `json:"height"`
+	StartingPriority float64  `json:"startingpriority"`
+	CurrentPriority  float64  `json:"currentpriority"`
+	AncestorCount    int64    `json:"ancestorcount"`
+	AncestorSize     int64    `json:"ancestorsize"`
+	AncestorFees     float64  `json:"ancestorfees"`
+	DescendantCount  int64    `json:"descendantcount"`
+	DescendantSize   int64    `json:"descendantsize"`
+	DescendantFees   float64  `json:"descendantfees"`
+	Depends          []string `json:"depends"`
+	SpentBy          []string `json:"spentby"`
+}
+
+// GetSpendingInfoResult models the data returned from the getspendinginfo
+// command.
+type GetSpendingInfoResult struct {
+	TxID   string `json:"txid"`
+	Vin    uint32 `json:"vin"`
+	Height int64  `json:"height"`
+}
+
 // GetStakeDifficultyResult models the data returned from the
 // getstakedifficulty command.
 type GetStakeDifficultyResult struct {
@@ -56,6 +116,48 @@ type GetStakeVersionsResult struct {
 	StakeVersions []StakeVersions `json:"stakeversions"`
 }
 
+// TicketStatusChangeResult models a change of the status of a ticket as part
+// of the data returned from the getticketinfo command.
+type TicketStatusChangeResult struct {
+	Status    string `json:"status"`
+	Height    int64  `json:"height"`
+	BlockHash string `json:"blockhash"`
+}
+
+// GetTicketInfoResult models the data returned from the getticketinfo command.
+type GetTicketInfoResult struct {
+	Hash           string                     `json:"hash"`
+	Status         string                     `json:"status"`
+	Price          float64                    `json:"price"`
+	PurchaseHeight int64                      `json:"purchaseheight"`
+	MaturityHeight int64                      `json:"maturityheight"`
+	ExpiryHeight   int64                      `json:"expiryheight"`
+	History        []TicketStatusChangeResult `json:"history"`
+}
+
+// TicketChangeResult models a ticket along with its new status as part of the
+// data returned from the getticketpoolinfo command.
+type TicketChangeResult struct {
+	Ticket string `json:"ticket"`
+	Status string `json:"status"`
+}
+
+// GetTicketPoolInfoResult models the data returned from the getticketpoolinfo
+// command.
+type GetTicketPoolInfoResult struct {
+	Height        int64                `json:"height"`
+	Hash          string               `json:"hash"`
+	Live          uint32               `json:"live"`
+	LiveValue     float64              `json:"livevalue"`
+	Immature      uint32               `json:"immature"`
+	ImmatureValue float64              `json:"immaturevalue"`
+	Voted         uint32               `json:"voted"`
+	Missed        uint32               `json:"missed"`
+	Expired       uint32               `json:"expired"`
+	Revoked       uint32               `json:"revoked"`
+	Changes       []TicketChangeResult `json:"changes"`
+}
+
 // Choice models an individual choice inside an Agenda.
 type Choice struct {
 	Id          string  `json:"id"`
@@ -91,6 +193,28 @@ type GetVoteInfoResult struct {
 	Agendas       []Agenda `json:"agendas,omitempty"`
 }
 
+// WinningTicketResult models a ticket selected to vote on a block as part of
+// the data returned from the getwinningtickets command.
+type WinningTicketResult struct {
+	Ticket string `json:"ticket"`
+	Index  int64  `json:"index"`
+	Voted  bool   `json:"voted"`
+}
+
+// GetWinningTicketsResult models the data returned from the getwinningtickets
+// command.
+type GetWinningTicketsResult struct {
+	Hash             string                `json:"hash"`
+	Height           int64                 `json:"height"`
+	PoolSize         int64                 `json:"poolsize"`
+	HeaderPoolSize   uint32                `json:"headerpoolsize"`
+	FinalState       string                `json:"finalstate"`
+	HeaderFinalState string                `json:"headerfinalstate"`
+	Winners          []WinningTicketResult `json:"winners"`
+	UnselectedVotes  []string              `json:"unselectedvotes"`
+	Verified         bool                  `json:"verified"`
+}
+
 // EstimateStakeDiffResult models the data returned from the estimatestakediff
 // command.
 type EstimateStakeDiffResult struct {
@@ -100,12 +224,84 @@ type EstimateStakeDiffResult struct {
 	User     *float64 `json:"user,omitempty"`
 }
 
+// StakeDiffWindowResult models the projected stake difficulty of a retarget
+// interval as part of the data returned from the projectstakediff command.
+type StakeDiffWindowResult struct {
+	Height    int64   `json:"height"`
+	StakeDiff float64 `json:"stakediff"`
+	PoolSize  int64   `json:"poolsize"`
+	Immature  int64   `json:"immature"`
+}
+
+// MempoolVoteResult models a vote on a block in the memory pool as part of the
+// data returned from the getmempoolvotes command and the mempoolvotes
+// notification.
+type MempoolVoteResult struct {
+	Ticket     string `json:"ticket"`
+	Vote       string `json:"vote"`
+	VoteBits   uint16 `json:"votebits"`
+	BlockValid bool   `json:"blockvalid"`
+}
+
+// MempoolVotesResult models the votes on a block in the memory pool as part of
+// the data returned from the getmempoolvotes command and the mempoolvotes
+// notification.
+type MempoolVotesResult struct {
+	Hash     string              `json:"hash"`
+	Height   int64               `json:"height"`
+	Received int64               `json:"received,omitempty"`
+	Age      float64             `json:"age,omitempty"`
+	Votes    []MempoolVoteResult `json:"votes"`
+	Missing  []string            `json:"missing"`
+}
+
+// ProjectStakeDiffResult models the data returned from the projectstakediff
+// command.
+type ProjectStakeDiffResult struct {
+	Height             int64                   `json:"height"`
+	Current            float64                 `json:"current"`
+	Next               float64                 `json:"next"`
+	NextRetargetHeight int64                   `json:"nextretargetheight"`
+	Windows            []StakeDiffWindowResult `json:"windows"`
+}
+
+// StratumWorkerResult models the statistics of a single worker included in
+// the getstratuminfo command result.
+type StratumWorkerResult struct {
+	Name           string  `json:"name"`
+	Connections    int     `json:"connections"`
+	Difficulty     float64 `json:"difficulty"`
+	AcceptedShares uint64  `json:"acceptedshares"`
+	RejectedShares uint64  `json:"rejectedshares"`
+	StaleShares    uint64  `json:"staleshares"`
+	BlocksFound    uint64  `json:"blocksfound"`
+	HashesPerSec   float64 `json:"hashespersec"`
+	LastShare      int64   `json:"lastshare"`
+}
+
+// GetStratumInfoResult models the data returned from the getstratuminfo
+// command.
+type GetStratumInfoResult struct {
+	Listeners   []string              `json:"listeners"`
+	Connections int                   `json:"connections"`
+	JobID       string                `json:"jobid"`
+	Height      int64                 `json:"height"`
+	Workers     []StratumWorkerResult `json:"workers"`
+}
+
 // LiveTicketsResult models the data returned from the livetickets
 // command.
 type LiveTicketsResult struct {
 	Tickets []string `json:"tickets"`
 }
 
+// LoadMempoolResult models the data returned from the loadmempool command.
+type LoadMempoolResult struct {
+	Accepted int `json:"accepted"`
+	Orphans  int `json:"orphans"`
+	Rejected int `json:"rejected"`
+}
+
 // MissedTicketsResult models the data returned from the missedtickets
 // command.
 type MissedTicketsResult struct {
@@ -161,6 +357,14 @@ type FeeInfoWindow struct {
 	StdDev      float64 `json:"stddev"`
 }
 
+// TestMempoolAcceptResult models the data returned from the testmempoolaccept
+// command for each transaction.
+type TestMempoolAcceptResult struct {
+	TxID         string `json:"txid"`
+	Allowed      bool   `json:"allowed"`
+	RejectReason string `json:"reject-reason,omitempty"`
+}
+
 // TicketFeeInfoResult models the data returned from the ticketfeeinfo command.
 // command.
 type TicketFeeInfoResult struct {
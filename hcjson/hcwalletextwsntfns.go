@@ -36,6 +36,10 @@ const (
 	// StakeDifficultyNtfnMethod is the method of the daemon
 	// stakedifficulty notification.
 	StakeDifficultyNtfnMethod = "stakedifficulty"
+
+	// MempoolVotesNtfnMethod is the method of the daemon mempoolvotes
+	// notification.
+	MempoolVotesNtfnMethod = "mempoolvotes"
 )
 
 // TicketPurchasedNtfn is a type handling custom marshaling and
@@ -161,6 +165,19 @@ func NewStakeDifficultyNtfn(hash string, height int32, stakeDiff int64) *StakeDi
 	}
 }
 
+// MempoolVotesNtfn is a type handling custom marshaling and unmarshaling of
+// mempoolvotes JSON websocket notifications.
+type MempoolVotesNtfn struct {
+	Votes MempoolVotesResult
+}
+
+// NewMempoolVotesNtfn creates a new MempoolVotesNtfn.
+func NewMempoolVotesNtfn(votes MempoolVotesResult) *MempoolVotesNtfn {
+	return &MempoolVotesNtfn{
+		Votes: votes,
+	}
+}
+
 func init() {
 	// The commands in this file are only usable by websockets and are
 	// notifications.
@@ -173,4 +190,5 @@ func init() {
 	MustRegisterCmd(SpentAndMissedTicketsNtfnMethod, (*SpentAndMissedTicketsNtfn)(nil), flags)
 	MustRegisterCmd(NewTicketsNtfnMethod, (*NewTicketsNtfn)(nil), flags)
 	MustRegisterCmd(StakeDifficultyNtfnMethod, (*StakeDifficultyNtfn)(nil), flags)
+	MustRegisterCmd(MempoolVotesNtfnMethod, (*MempoolVotesNtfn)(nil), flags)
 }
@@ -121,6 +121,34 @@ func TestHcwalletChainSvrWsNtfns(t *testing.T) {
 				Tickets:   []string{"a", "b"},
 			},
 		},
+		{
+			name: "mempoolvotes",
+			newNtfn: func() (interface{}, error) {
+				return hcjson.NewCmd("mempoolvotes", hcjson.MempoolVotesResult{
+					Hash:    "123",
+					Height:  100,
+					Votes:   []hcjson.MempoolVoteResult{},
+					Missing: []string{"a"},
+				})
+			},
+			staticNtfn: func() interface{} {
+				return hcjson.NewMempoolVotesNtfn(hcjson.MempoolVotesResult{
+					Hash:    "123",
+					Height:  100,
+					Votes:   []hcjson.MempoolVoteResult{},
+					Missing: []string{"a"},
+				})
+			},
+			marshalled: `{"jsonrpc":"1.0","method":"mempoolvotes","params":[{"hash":"123","height":100,"votes":[],"missing":["a"]}],"id":null}`,
+			unmarshalled: &hcjson.MempoolVotesNtfn{
+				Votes: hcjson.MempoolVotesResult{
+					Hash:    "123",
+					Height:  100,
+					Votes:   []hcjson.MempoolVoteResult{},
+					Missing: []string{"a"},
+				},
+			},
+		},
 	}
 
 	t.Logf("Running %d tests", len(tests))
@@ -0,0 +1,101 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"io"
+)
+
+// bitWriter accumulates bits, most significant bit first, into a byte slice.
+type bitWriter struct {
+	bytes []byte
+
+	// next is the mask of the next bit to write within the final byte.  A
+	// zero mask means a new byte must be started.
+	next byte
+}
+
+// writeBit appends a single bit to the stream.
+func (w *bitWriter) writeBit(bit bool) {
+	if w.next == 0 {
+		w.bytes = append(w.bytes, 0)
+		w.next = 1 << 7
+	}
+	if bit {
+		w.bytes[len(w.bytes)-1] |= w.next
+	}
+	w.next >>= 1
+}
+
+// writeNBits appends the nbits least significant bits of data to the stream,
+// most significant bit first.
+func (w *bitWriter) writeNBits(data uint64, nbits uint) {
+	for nbits > 0 {
+		nbits--
+		w.writeBit(data&(1<<nbits) != 0)
+	}
+}
+
+// bitReader reads bits, most significant bit first, from a byte slice.
+type bitReader struct {
+	bytes []byte
+
+	// next is the mask of the next bit to read within the first byte.
+	next byte
+}
+
+// newBitReader returns a bit reader positioned at the start of the passed
+// bytes.
+func newBitReader(bytes []byte) bitReader {
+	return bitReader{bytes: bytes, next: 1 << 7}
+}
+
+// readBit reads a single bit from the stream.  io.EOF is returned when there
+// are no more bits available.
+func (r *bitReader) readBit() (bool, error) {
+	if len(r.bytes) == 0 {
+		return false, io.EOF
+	}
+	bit := r.bytes[0]&r.next != 0
+	r.next >>= 1
+	if r.next == 0 {
+		r.bytes = r.bytes[1:]
+		r.next = 1 << 7
+	}
+	return bit, nil
+}
+
+// readUnary reads a unary encoded value, which is the number of one bits
+// before the next zero bit, from the stream.
+func (r *bitReader) readUnary() (uint64, error) {
+	var value uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if !bit {
+			return value, nil
+		}
+		value++
+	}
+}
+
+// readNBits reads nbits bits from the stream and returns them as the least
+// significant bits of the result.
+func (r *bitReader) readNBits(nbits uint) (uint64, error) {
+	var value uint64
+	for ; nbits > 0; nbits-- {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		value <<= 1
+		if bit {
+			value |= 1
+		}
+	}
+	return value, nil
+}
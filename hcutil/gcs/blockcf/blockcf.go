@@ -0,0 +1,143 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package blockcf provides functions for building committed filters for blocks
+using Golomb-coded sets in a way that is useful for light clients such as SPV
+wallets.
+
+Committed filters are a reversal of how bloom filters are typically used by a
+light client: a consensus-validating full node commits to a single filter for
+every block and serves the filter to any light client that asks for it.  Light
+clients then match their own addresses and outpoints against the filters
+locally and only fetch the blocks that match.  Unlike bloom filters, this does
+not reveal anything about the wallet to the serving node.
+*/
+package blockcf
+
+import (
+	"encoding/binary"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil/gcs"
+	"github.com/HcashOrg/hcd/txscript"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// P is the collision probability used for block committed filters (2^-20).
+const P = 20
+
+// zeroHash is the zero value hash used by the previous outpoint of coinbase
+// and stakebase inputs.
+var zeroHash chainhash.Hash
+
+// Key creates a block committed filter key by truncating a block hash to the
+// key size.
+func Key(hash *chainhash.Hash) [gcs.KeySize]byte {
+	var key [gcs.KeySize]byte
+	copy(key[:], hash[:])
+	return key
+}
+
+// isStakeTagged returns whether or not the passed output script begins with
+// one of the opcodes used to tag the outputs of stake transactions.
+func isStakeTagged(script []byte) bool {
+	if len(script) == 0 {
+		return false
+	}
+	switch script[0] {
+	case txscript.OP_SSTX, txscript.OP_SSGEN, txscript.OP_SSRTX,
+		txscript.OP_SSTXCHANGE:
+		return true
+	}
+	return false
+}
+
+// OutPointBytes returns the serialization of an outpoint as it is committed
+// to by the regular filter: the transaction hash followed by the little-endian
+// output index and the transaction tree.
+func OutPointBytes(outPoint *wire.OutPoint) []byte {
+	b := make([]byte, chainhash.HashSize+5)
+	copy(b, outPoint.Hash[:])
+	binary.LittleEndian.PutUint32(b[chainhash.HashSize:], outPoint.Index)
+	b[chainhash.HashSize+4] = byte(outPoint.Tree)
+	return b
+}
+
+// dataSet is a set of data elements that ignores duplicates.
+type dataSet struct {
+	data [][]byte
+	seen map[string]struct{}
+}
+
+// add adds the passed element to the set when it is not already a member.
+func (s *dataSet) add(b []byte) {
+	if len(b) == 0 {
+		return
+	}
+	if _, ok := s.seen[string(b)]; ok {
+		return
+	}
+	s.seen[string(b)] = struct{}{}
+	s.data = append(s.data, b)
+}
+
+// addTransactions adds the outpoints spent by and the output scripts created
+// by each of the passed transactions to the set.
+func (s *dataSet) addTransactions(txns []*wire.MsgTx) {
+	for _, tx := range txns {
+		for _, txIn := range tx.TxIn {
+			// Coinbase and stakebase inputs do not reference a
+			// previous output.
+			prevOut := &txIn.PreviousOutPoint
+			if prevOut.Hash == zeroHash {
+				continue
+			}
+			s.add(OutPointBytes(prevOut))
+		}
+
+		for _, txOut := range tx.TxOut {
+			// Scripts tagged with a stake opcode are committed to
+			// without the tag so they match the same data as an
+			// untagged script paying to the same destination.
+			script := txOut.PkScript
+			if isStakeTagged(script) {
+				script = script[1:]
+			}
+			s.add(script)
+		}
+	}
+}
+
+// Regular builds a regular GCS filter from a block.  A regular filter commits
+// to every previous outpoint spent by and every output script created by the
+// transactions in both the regular and stake trees of the block, making it
+// possible for a wallet to detect both payments to its addresses and spends
+// of its outputs.
+func Regular(block *wire.MsgBlock) (*gcs.Filter, error) {
+	set := dataSet{seen: make(map[string]struct{})}
+	set.addTransactions(block.Transactions)
+	set.addTransactions(block.STransactions)
+
+	blockHash := block.BlockHash()
+	return gcs.NewFilter(P, Key(&blockHash), set.data)
+}
+
+// MatchScript returns whether or not the passed output script is likely a
+// member of the passed regular filter for the block with the given hash.  Any
+// stake tag is removed from the script in the same way it is done when the
+// filter is built.
+func MatchScript(filter *gcs.Filter, blockHash *chainhash.Hash, script []byte) bool {
+	if isStakeTagged(script) {
+		script = script[1:]
+	}
+	return filter.Match(Key(blockHash), script)
+}
+
+// MatchOutPoint returns whether or not a spend of the passed outpoint is
+// likely committed to by the passed regular filter for the block with the
+// given hash.
+func MatchOutPoint(filter *gcs.Filter, blockHash *chainhash.Hash, outPoint *wire.OutPoint) bool {
+	return filter.Match(Key(blockHash), OutPointBytes(outPoint))
+}
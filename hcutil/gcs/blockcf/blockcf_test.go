@@ -0,0 +1,88 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package blockcf_test
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil/gcs/blockcf"
+	"github.com/HcashOrg/hcd/txscript"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// p2pkhScript returns a pay-to-pubkey-hash script paying to a hash made of
+// the passed byte, optionally tagged with the passed stake opcode.
+func p2pkhScript(b byte, tag byte) []byte {
+	script := []byte{txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20}
+	for i := 0; i < 20; i++ {
+		script = append(script, b)
+	}
+	script = append(script, txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	if tag != 0 {
+		script = append([]byte{tag}, script...)
+	}
+	return script
+}
+
+// TestRegularFilter ensures regular filters commit to the outpoints spent and
+// scripts created by both transaction trees while ignoring coinbase inputs.
+func TestRegularFilter(t *testing.T) {
+	spent := wire.NewOutPoint(&chainhash.Hash{0x01}, 2, wire.TxTreeRegular)
+	stakeSpent := wire.NewOutPoint(&chainhash.Hash{0x02}, 0, wire.TxTreeStake)
+
+	coinbase := wire.NewMsgTx()
+	coinbase.AddTxIn(wire.NewTxIn(wire.NewOutPoint(&chainhash.Hash{},
+		wire.MaxPrevOutIndex, wire.TxTreeRegular), nil))
+	coinbase.AddTxOut(wire.NewTxOut(1, p2pkhScript(0x10, 0)))
+
+	regular := wire.NewMsgTx()
+	regular.AddTxIn(wire.NewTxIn(spent, nil))
+	regular.AddTxOut(wire.NewTxOut(1, p2pkhScript(0x11, 0)))
+
+	ticket := wire.NewMsgTx()
+	ticket.AddTxIn(wire.NewTxIn(stakeSpent, nil))
+	ticket.AddTxOut(wire.NewTxOut(1, p2pkhScript(0x12, txscript.OP_SSTX)))
+
+	block := &wire.MsgBlock{
+		Header:        wire.BlockHeader{Height: 100},
+		Transactions:  []*wire.MsgTx{coinbase, regular},
+		STransactions: []*wire.MsgTx{ticket},
+	}
+	filter, err := blockcf.Regular(block)
+	if err != nil {
+		t.Fatalf("Regular: unexpected error: %v", err)
+	}
+	if filter.N() != 5 {
+		t.Fatalf("Regular: unexpected number of items -- got %d, want 5",
+			filter.N())
+	}
+
+	blockHash := block.BlockHash()
+	for i, script := range [][]byte{
+		p2pkhScript(0x10, 0),
+		p2pkhScript(0x11, 0),
+		p2pkhScript(0x12, 0),
+		p2pkhScript(0x12, txscript.OP_SSTX),
+		p2pkhScript(0x12, txscript.OP_SSGEN),
+	} {
+		if !blockcf.MatchScript(filter, &blockHash, script) {
+			t.Errorf("MatchScript #%d: script did not match", i)
+		}
+	}
+	if blockcf.MatchScript(filter, &blockHash, p2pkhScript(0x13, 0)) {
+		t.Error("MatchScript: unexpected match for unrelated script")
+	}
+
+	for i, outPoint := range []*wire.OutPoint{spent, stakeSpent} {
+		if !blockcf.MatchOutPoint(filter, &blockHash, outPoint) {
+			t.Errorf("MatchOutPoint #%d: outpoint did not match", i)
+		}
+	}
+	unspent := wire.NewOutPoint(&chainhash.Hash{0x01}, 3, wire.TxTreeRegular)
+	if blockcf.MatchOutPoint(filter, &blockHash, unspent) {
+		t.Error("MatchOutPoint: unexpected match for unspent outpoint")
+	}
+}
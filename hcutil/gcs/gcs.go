@@ -0,0 +1,277 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+/*
+Package gcs provides an API for building and using Golomb-coded set filters.
+
+A Golomb-coded set (GCS) is a space-efficient probabilistic data structure that
+is used to test set membership with a tunable false positive rate of 1/2^P,
+while having no false negatives.  Every item added to a filter is hashed with
+SipHash-2-4 into the range [0, N*2^P), where N is the number of items.  The
+sorted differences between successive hashes are then Golomb-Rice coded with
+the quotient in unary followed by the P-bit remainder.
+
+Unlike bloom filters, the filters are immutable once created and are intended
+to be built by a full node and queried by light clients.
+*/
+package gcs
+
+import (
+	"encoding/binary"
+	"errors"
+	"sort"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+const (
+	// KeySize is the size of the byte array required for the key material
+	// of the SipHash keyed hash function.
+	KeySize = 16
+
+	// MaxP is the maximum supported collision probability exponent.
+	MaxP = 32
+
+	// nSize is the number of bytes used to serialize the number of items
+	// in a filter.
+	nSize = 4
+)
+
+var (
+	// ErrNTooBig signifies that the filter can't handle the number of
+	// items provided.
+	ErrNTooBig = errors.New("N is too big to fit in uint32")
+
+	// ErrPTooBig signifies that the filter can't handle the collision
+	// probability exponent provided.
+	ErrPTooBig = errors.New("P is too big")
+
+	// ErrMisserialized signifies that the serialized filter is malformed.
+	ErrMisserialized = errors.New("misserialized filter")
+)
+
+// Filter describes an immutable filter that can be built from a set of data
+// elements, serialized, deserialized, and queried in a thread-safe manner.
+type Filter struct {
+	n          uint32
+	p          uint8
+	modulusNP  uint64
+	filterData []byte
+}
+
+// keyHalves splits the passed key into the two halves expected by SipHash.
+func keyHalves(key *[KeySize]byte) (uint64, uint64) {
+	return binary.LittleEndian.Uint64(key[0:8]),
+		binary.LittleEndian.Uint64(key[8:16])
+}
+
+// NewFilter builds a new GCS filter with the collision probability of
+// 1/(2**P), key key, and including every []byte in data as a member of the
+// set.
+func NewFilter(P uint8, key [KeySize]byte, data [][]byte) (*Filter, error) {
+	// Make sure the parameters will fit the hash function in use.  An empty
+	// data set results in an empty filter that never matches.
+	if uint64(len(data)) > uint64(^uint32(0)) {
+		return nil, ErrNTooBig
+	}
+	if P > MaxP {
+		return nil, ErrPTooBig
+	}
+
+	f := &Filter{
+		n: uint32(len(data)),
+		p: P,
+	}
+	if f.n == 0 {
+		return f, nil
+	}
+	f.modulusNP = uint64(f.n) << P
+
+	// Map the data into the hash range and sort the resulting values.
+	k0, k1 := keyHalves(&key)
+	values := make([]uint64, 0, len(data))
+	for _, d := range data {
+		values = append(values, SipHash(k0, k1, d)%f.modulusNP)
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	// Write the sorted list of values into the filter bitstream, encoding
+	// the difference between each value and the previous one.
+	var w bitWriter
+	var lastValue uint64
+	for _, v := range values {
+		delta := v - lastValue
+		lastValue = v
+
+		// The quotient is written in unary followed by the P-bit
+		// remainder.
+		for q := delta >> P; q > 0; q-- {
+			w.writeBit(true)
+		}
+		w.writeBit(false)
+		w.writeNBits(delta, uint(P))
+	}
+	f.filterData = w.bytes
+
+	return f, nil
+}
+
+// FromBytes deserializes a GCS filter from a known N, P, and serialized filter
+// as returned by Bytes().
+func FromBytes(N uint32, P uint8, d []byte) (*Filter, error) {
+	if P > MaxP {
+		return nil, ErrPTooBig
+	}
+	if (N == 0) != (len(d) == 0) {
+		return nil, ErrMisserialized
+	}
+
+	f := &Filter{
+		n:          N,
+		p:          P,
+		modulusNP:  uint64(N) << P,
+		filterData: make([]byte, len(d)),
+	}
+	copy(f.filterData, d)
+	return f, nil
+}
+
+// FromNBytes deserializes a GCS filter from a known P, and serialized N and
+// filter as returned by NBytes().
+func FromNBytes(P uint8, d []byte) (*Filter, error) {
+	if len(d) < nSize {
+		return nil, ErrMisserialized
+	}
+	return FromBytes(binary.BigEndian.Uint32(d), P, d[nSize:])
+}
+
+// Bytes returns the serialized format of the GCS filter, which does not
+// include N or P (returned by separate methods) or the key used by SipHash.
+func (f *Filter) Bytes() []byte {
+	filterData := make([]byte, len(f.filterData))
+	copy(filterData, f.filterData)
+	return filterData
+}
+
+// NBytes returns the serialized format of the GCS filter with N, which does
+// not include P (returned by a separate method) or the key used by SipHash.
+func (f *Filter) NBytes() []byte {
+	filterData := make([]byte, nSize+len(f.filterData))
+	binary.BigEndian.PutUint32(filterData, f.n)
+	copy(filterData[nSize:], f.filterData)
+	return filterData
+}
+
+// P returns the filter's collision probability as a negative power of 2 (that
+// is, a collision probability of `1/2**20` is represented as 20).
+func (f *Filter) P() uint8 {
+	return f.p
+}
+
+// N returns the size of the data set used to build the filter.
+func (f *Filter) N() uint32 {
+	return f.n
+}
+
+// Hash returns the hash of the serialized filter including N.
+func (f *Filter) Hash() chainhash.Hash {
+	return chainhash.HashH(f.NBytes())
+}
+
+// readFullUint64 reads a value represented by the sum of a unary multiple of
+// the filter's P modulus (`2**P`) and a big-endian P-bit remainder.
+func (f *Filter) readFullUint64(r *bitReader) (uint64, error) {
+	quotient, err := r.readUnary()
+	if err != nil {
+		return 0, err
+	}
+	remainder, err := r.readNBits(uint(f.p))
+	if err != nil {
+		return 0, err
+	}
+	return quotient<<f.p | remainder, nil
+}
+
+// Match checks whether a []byte value is likely (within collision probability)
+// to be a member of the set represented by the filter.
+func (f *Filter) Match(key [KeySize]byte, data []byte) bool {
+	if f.n == 0 {
+		return false
+	}
+
+	// Map the search term into the hash range.
+	k0, k1 := keyHalves(&key)
+	term := SipHash(k0, k1, data) % f.modulusNP
+
+	// Go through the search filter and look for the desired value.
+	r := newBitReader(f.filterData)
+	var value uint64
+	for i := uint32(0); i < f.n; i++ {
+		delta, err := f.readFullUint64(&r)
+		if err != nil {
+			return false
+		}
+		value += delta
+		if value == term {
+			return true
+		}
+		if value > term {
+			return false
+		}
+	}
+
+	return false
+}
+
+// MatchAny checks whether any []byte value is likely (within collision
+// probability) to be a member of the set represented by the filter faster
+// than calling Match() for each value individually.
+func (f *Filter) MatchAny(key [KeySize]byte, data [][]byte) bool {
+	if f.n == 0 || len(data) == 0 {
+		return false
+	}
+
+	// Map the search terms into the hash range and sort them.
+	k0, k1 := keyHalves(&key)
+	terms := make([]uint64, 0, len(data))
+	for _, d := range data {
+		terms = append(terms, SipHash(k0, k1, d)%f.modulusNP)
+	}
+	sort.Slice(terms, func(i, j int) bool { return terms[i] < terms[j] })
+
+	// Zip down the filter and the sorted search terms, advancing whichever
+	// one is behind, until a match is found or either runs out.
+	r := newBitReader(f.filterData)
+	var value uint64
+	var termIdx int
+	for i := uint32(0); i < f.n; i++ {
+		delta, err := f.readFullUint64(&r)
+		if err != nil {
+			return false
+		}
+		value += delta
+
+		for terms[termIdx] < value {
+			termIdx++
+			if termIdx == len(terms) {
+				return false
+			}
+		}
+		if terms[termIdx] == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MakeHeaderForFilter makes a filter chain header for a filter, given the
+// filter and the previous filter chain header.
+func MakeHeaderForFilter(filter *Filter, prevHeader *chainhash.Hash) chainhash.Hash {
+	filterTip := make([]byte, 2*chainhash.HashSize)
+	filterHash := filter.Hash()
+	copy(filterTip, filterHash[:])
+	copy(filterTip[chainhash.HashSize:], prevHeader[:])
+	return chainhash.HashH(filterTip)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil/gcs"
+)
+
+// testP is the collision probability exponent used by the tests.
+const testP = 20
+
+// testKey is the key used by the tests.
+var testKey = [gcs.KeySize]byte{0x4c, 0xb1, 0xab, 0x12, 0x57, 0x62, 0x1e,
+	0x41, 0x3b, 0x8b, 0x0e, 0x26, 0x64, 0x8d, 0x4a, 0x15}
+
+// testData returns count deterministic, unique data elements starting at the
+// passed offset.
+func testData(offset, count int) [][]byte {
+	data := make([][]byte, 0, count)
+	for i := offset; i < offset+count; i++ {
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], uint64(i))
+		hash := chainhash.HashH(b[:])
+		data = append(data, hash[:])
+	}
+	return data
+}
+
+// TestGCSFilterMatch ensures every member of a filter matches, both
+// individually and as part of a set, and that non-members rarely match.
+func TestGCSFilterMatch(t *testing.T) {
+	members := testData(0, 1000)
+	filter, err := gcs.NewFilter(testP, testKey, members)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error: %v", err)
+	}
+	if filter.N() != uint32(len(members)) {
+		t.Fatalf("N: got %d, want %d", filter.N(), len(members))
+	}
+	if filter.P() != testP {
+		t.Fatalf("P: got %d, want %d", filter.P(), testP)
+	}
+
+	for i, member := range members {
+		if !filter.Match(testKey, member) {
+			t.Fatalf("Match: member #%d did not match", i)
+		}
+	}
+
+	nonMembers := testData(len(members), 1000)
+	var falsePositives int
+	for _, nonMember := range nonMembers {
+		if filter.Match(testKey, nonMember) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 2 {
+		t.Fatalf("Match: too many false positives (%d)", falsePositives)
+	}
+
+	if !filter.MatchAny(testKey, append(nonMembers[:10:10], members[500])) {
+		t.Fatal("MatchAny: set containing a member did not match")
+	}
+	if filter.MatchAny(testKey, nonMembers[:10]) {
+		t.Fatal("MatchAny: set without members matched")
+	}
+}
+
+// TestGCSFilterSerialization ensures filters survive a serialization round
+// trip and that malformed serialized filters are rejected.
+func TestGCSFilterSerialization(t *testing.T) {
+	members := testData(0, 100)
+	filter, err := gcs.NewFilter(testP, testKey, members)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error: %v", err)
+	}
+
+	fromBytes, err := gcs.FromBytes(filter.N(), testP, filter.Bytes())
+	if err != nil {
+		t.Fatalf("FromBytes: unexpected error: %v", err)
+	}
+	fromNBytes, err := gcs.FromNBytes(testP, filter.NBytes())
+	if err != nil {
+		t.Fatalf("FromNBytes: unexpected error: %v", err)
+	}
+	for _, f := range []*gcs.Filter{fromBytes, fromNBytes} {
+		if !bytes.Equal(f.NBytes(), filter.NBytes()) {
+			t.Fatal("deserialized filter does not match original")
+		}
+		if f.Hash() != filter.Hash() {
+			t.Fatal("deserialized filter hash does not match original")
+		}
+		if !f.Match(testKey, members[42]) {
+			t.Fatal("deserialized filter does not match member")
+		}
+	}
+
+	if _, err := gcs.FromNBytes(testP, []byte{0x00}); err != gcs.ErrMisserialized {
+		t.Fatalf("FromNBytes: unexpected error -- got %v, want %v", err,
+			gcs.ErrMisserialized)
+	}
+	if _, err := gcs.FromBytes(1, testP, nil); err != gcs.ErrMisserialized {
+		t.Fatalf("FromBytes: unexpected error -- got %v, want %v", err,
+			gcs.ErrMisserialized)
+	}
+	if _, err := gcs.NewFilter(gcs.MaxP+1, testKey, members); err != gcs.ErrPTooBig {
+		t.Fatalf("NewFilter: unexpected error -- got %v, want %v", err,
+			gcs.ErrPTooBig)
+	}
+}
+
+// TestGCSEmptyFilter ensures an empty filter never matches and serializes as
+// only its item count.
+func TestGCSEmptyFilter(t *testing.T) {
+	filter, err := gcs.NewFilter(testP, testKey, nil)
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error: %v", err)
+	}
+	if filter.Match(testKey, []byte{0x00}) {
+		t.Fatal("Match: empty filter matched")
+	}
+	if filter.MatchAny(testKey, testData(0, 10)) {
+		t.Fatal("MatchAny: empty filter matched")
+	}
+	if !bytes.Equal(filter.NBytes(), []byte{0, 0, 0, 0}) {
+		t.Fatalf("NBytes: unexpected serialization %x", filter.NBytes())
+	}
+}
+
+// TestMakeHeaderForFilter ensures filter headers commit to both the filter
+// and the previous header.
+func TestMakeHeaderForFilter(t *testing.T) {
+	f1, err := gcs.NewFilter(testP, testKey, testData(0, 10))
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error: %v", err)
+	}
+	f2, err := gcs.NewFilter(testP, testKey, testData(10, 10))
+	if err != nil {
+		t.Fatalf("NewFilter: unexpected error: %v", err)
+	}
+
+	var prev chainhash.Hash
+	h1 := gcs.MakeHeaderForFilter(f1, &prev)
+	h2 := gcs.MakeHeaderForFilter(f2, &prev)
+	if h1 == h2 {
+		t.Fatal("headers for different filters are identical")
+	}
+	if gcs.MakeHeaderForFilter(f1, &h2) == h1 {
+		t.Fatal("headers with different previous headers are identical")
+	}
+}
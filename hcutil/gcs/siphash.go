@@ -0,0 +1,70 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+// sipRound performs a single SipHash round on the passed state.
+func sipRound(v0, v1, v2, v3 uint64) (uint64, uint64, uint64, uint64) {
+	v0 += v1
+	v1 = bits.RotateLeft64(v1, 13)
+	v1 ^= v0
+	v0 = bits.RotateLeft64(v0, 32)
+	v2 += v3
+	v3 = bits.RotateLeft64(v3, 16)
+	v3 ^= v2
+	v0 += v3
+	v3 = bits.RotateLeft64(v3, 21)
+	v3 ^= v0
+	v2 += v1
+	v1 = bits.RotateLeft64(v1, 17)
+	v1 ^= v2
+	v2 = bits.RotateLeft64(v2, 32)
+	return v0, v1, v2, v3
+}
+
+// SipHash implements the SipHash-2-4 keyed pseudorandom function.  The 128-bit
+// key is provided as two little-endian 64-bit halves k0 and k1.  This
+// implementation yields a 64-bit hash value which is suitable for mapping the
+// items of a filter uniformly into its hash range.
+func SipHash(k0, k1 uint64, data []byte) uint64 {
+	v0 := k0 ^ 0x736f6d6570736575
+	v1 := k1 ^ 0x646f72616e646f6d
+	v2 := k0 ^ 0x6c7967656e657261
+	v3 := k1 ^ 0x7465646279746573
+
+	// Calculate the hash in 8-byte chunks.
+	dataLen := len(data)
+	for len(data) >= 8 {
+		m := binary.LittleEndian.Uint64(data)
+		v3 ^= m
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+		v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+		v0 ^= m
+		data = data[8:]
+	}
+
+	// Handle the remaining bytes along with the length of the data.
+	m := uint64(dataLen) << 56
+	for i, b := range data {
+		m |= uint64(b) << (8 * uint(i))
+	}
+	v3 ^= m
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0 ^= m
+
+	// Finalization.
+	v2 ^= 0xff
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+	v0, v1, v2, v3 = sipRound(v0, v1, v2, v3)
+
+	return v0 ^ v1 ^ v2 ^ v3
+}
@@ -0,0 +1,41 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package gcs_test
+
+import (
+	"testing"
+
+	"github.com/HcashOrg/hcd/hcutil/gcs"
+)
+
+// TestSipHash ensures the SipHash function produces the correct hash for the
+// reference key 00 01 .. 0f and messages 00 01 .. of various lengths.
+func TestSipHash(t *testing.T) {
+	const k0, k1 = 0x0706050403020100, 0x0f0e0d0c0b0a0908
+	var tests = []struct {
+		dataLen int
+		out     uint64
+	}{
+		{0, 0x726fdb47dd0e0e31},
+		{1, 0x74f839c593dc67fd},
+		{7, 0xab0200f58b01d137},
+		{8, 0x93f5f5799a932462},
+		{15, 0xa129ca6149be45e5},
+		{16, 0x3f2acc7f57c29bdb},
+	}
+
+	for i, test := range tests {
+		data := make([]byte, test.dataLen)
+		for j := range data {
+			data[j] = byte(j)
+		}
+		result := gcs.SipHash(k0, k1, data)
+		if result != test.out {
+			t.Errorf("SipHash test #%d failed: got %x want %x", i,
+				result, test.out)
+			continue
+		}
+	}
+}
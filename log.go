@@ -17,6 +17,7 @@ import (
 	"github.com/HcashOrg/hcd/blockchain/stake"
 	"github.com/HcashOrg/hcd/connmgr"
 	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/fees"
 	"github.com/HcashOrg/hcd/mempool"
 	"github.com/HcashOrg/hcd/peer"
 	"github.com/HcashOrg/hcd/txscript"
@@ -66,6 +67,7 @@ var (
 	hcdLog  = backendLog.Logger("HC")
 	chanLog = backendLog.Logger("CHAN")
 	discLog = backendLog.Logger("DISC")
+	feesLog = backendLog.Logger("FEES")
 	indxLog = backendLog.Logger("INDX")
 	minrLog = backendLog.Logger("MINR")
 	peerLog = backendLog.Logger("PEER")
@@ -73,6 +75,7 @@ var (
 	scrpLog = backendLog.Logger("SCRP")
 	srvrLog = backendLog.Logger("SRVR")
 	stkeLog = backendLog.Logger("STKE")
+	strmLog = backendLog.Logger("STRM")
 	txmpLog = backendLog.Logger("TXMP")
 )
 
@@ -87,6 +90,7 @@ func init() {
 	txscript.UseLogger(scrpLog)
 	stake.UseLogger(stkeLog)
 	mempool.UseLogger(txmpLog)
+	fees.UseLogger(feesLog)
 }
 
 // subsystemLoggers maps each subsystem identifier to its associated logger.
@@ -99,6 +103,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"HC":   hcdLog,
 	"CHAN": chanLog,
 	"DISC": discLog,
+	"FEES": feesLog,
 	"INDX": indxLog,
 	"MINR": minrLog,
 	"PEER": peerLog,
@@ -106,6 +111,7 @@ var subsystemLoggers = map[string]btclog.Logger{
 	"SCRP": scrpLog,
 	"SRVR": srvrLog,
 	"STKE": stkeLog,
+	"STRM": strmLog,
 	"TXMP": txmpLog,
 }
 
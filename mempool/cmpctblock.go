@@ -0,0 +1,324 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/hcutil/gcs"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// shortTxIDMask is the mask applied to the SipHash output to obtain the
+// short transaction ids of a compact block.
+const shortTxIDMask = 1<<(8*wire.ShortTxIDSize) - 1
+
+var (
+	// errDuplicateShortID is returned when a compact block contains the
+	// same short id more than once within a transaction tree.  Such blocks
+	// can't be reconstructed and must be requested in full.
+	errDuplicateShortID = errors.New("duplicate short transaction id")
+
+	// errIncompleteBlock is returned when a partial block is converted to a
+	// block before all of its transactions are known.
+	errIncompleteBlock = errors.New("partial block is missing transactions")
+)
+
+// ShortTxIDKeys returns the SipHash keys used to compute the short transaction
+// ids of a compact block with the passed header and nonce.  The keys are the
+// first two little-endian 64-bit words of the BLAKE-256 hash of the serialized
+// header followed by the little-endian nonce.
+func ShortTxIDKeys(header *wire.BlockHeader, nonce uint64) (uint64, uint64) {
+	var buf bytes.Buffer
+	buf.Grow(wire.MaxBlockHeaderPayload + 8)
+	header.Serialize(&buf)
+	var nonceBytes [8]byte
+	binary.LittleEndian.PutUint64(nonceBytes[:], nonce)
+	buf.Write(nonceBytes[:])
+
+	hash := chainhash.HashB(buf.Bytes())
+	return binary.LittleEndian.Uint64(hash[0:8]),
+		binary.LittleEndian.Uint64(hash[8:16])
+}
+
+// ShortTxID returns the short id of the transaction with the passed hash for
+// the compact block the keys were derived from.
+//
+// NOTE: The transaction hash does not commit to the witness, so the fraud
+// proofs which the miner fills in are carried along with the short ids.  A
+// transaction with malleated signature scripts still has the same short id, so
+// blocks which are reconstructed from such transactions fail the merkle root
+// checks of PartialBlock.Block and must be requested in full.
+func ShortTxID(k0, k1 uint64, txHash *chainhash.Hash) uint64 {
+	return gcs.SipHash(k0, k1, txHash[:]) & shortTxIDMask
+}
+
+// NewCmpctBlock returns a compact block for the passed block using the passed
+// nonce.  Only the coinbase is sent in full since the remaining transactions
+// of both trees, including the votes and tickets, are expected to be in the
+// memory pool of the receiver.
+func NewCmpctBlock(block *hcutil.Block, nonce uint64) *wire.MsgCmpctBlock {
+	msgBlock := block.MsgBlock()
+	msg := wire.NewMsgCmpctBlock(&msgBlock.Header, nonce)
+	k0, k1 := ShortTxIDKeys(&msgBlock.Header, nonce)
+
+	txns := block.Transactions()
+	msg.ShortTxns = make([]wire.ShortTx, 0, len(txns))
+	for i, tx := range txns {
+		if i == 0 {
+			msg.PrefilledTxns = []wire.PrefilledTx{{
+				Index: 0,
+				Tx:    tx.MsgTx(),
+			}}
+			continue
+		}
+		msg.ShortTxns = append(msg.ShortTxns, newShortTx(k0, k1, tx))
+	}
+
+	stxns := block.STransactions()
+	msg.StakeShortTxns = make([]wire.ShortTx, 0, len(stxns))
+	for _, stx := range stxns {
+		msg.StakeShortTxns = append(msg.StakeShortTxns,
+			newShortTx(k0, k1, stx))
+	}
+
+	return msg
+}
+
+// newShortTx returns the short id of the passed transaction along with the
+// fraud proofs of its inputs.
+func newShortTx(k0, k1 uint64, tx *hcutil.Tx) wire.ShortTx {
+	txIns := tx.MsgTx().TxIn
+	fraudProofs := make([]wire.FraudProof, 0, len(txIns))
+	for _, txIn := range txIns {
+		fraudProofs = append(fraudProofs, wire.FraudProof{
+			ValueIn:     txIn.ValueIn,
+			BlockHeight: txIn.BlockHeight,
+			BlockIndex:  txIn.BlockIndex,
+		})
+	}
+	return wire.ShortTx{
+		ShortID:     ShortTxID(k0, k1, tx.Hash()),
+		FraudProofs: fraudProofs,
+	}
+}
+
+// partialTxTree houses a transaction tree of a partial block along with the
+// slots of the short ids which are yet to be filled and the fraud proofs of
+// the transactions which belong into them.
+type partialTxTree struct {
+	txns        []*wire.MsgTx
+	shortIDs    map[uint64]int
+	fraudProofs map[int][]wire.FraudProof
+	collided    map[int]struct{}
+}
+
+// newPartialTxTree returns a transaction tree with the passed prefilled
+// transactions in place and the slots of the passed short transactions left
+// empty.
+func newPartialTxTree(shortTxns []wire.ShortTx, prefilled []wire.PrefilledTx) (*partialTxTree, error) {
+	numTxns := len(shortTxns) + len(prefilled)
+	tree := &partialTxTree{
+		txns:        make([]*wire.MsgTx, numTxns),
+		shortIDs:    make(map[uint64]int, len(shortTxns)),
+		fraudProofs: make(map[int][]wire.FraudProof, len(shortTxns)),
+		collided:    make(map[int]struct{}),
+	}
+	for _, ptx := range prefilled {
+		index := int(ptx.Index)
+		if index >= numTxns || tree.txns[index] != nil || ptx.Tx == nil {
+			return nil, fmt.Errorf("invalid prefilled transaction "+
+				"index %d", index)
+		}
+		tree.txns[index] = ptx.Tx
+	}
+
+	// The short ids are assigned to the slots which are not prefilled in
+	// order.
+	slot := 0
+	for _, stx := range shortTxns {
+		for tree.txns[slot] != nil {
+			slot++
+		}
+		if _, exists := tree.shortIDs[stx.ShortID]; exists {
+			return nil, errDuplicateShortID
+		}
+		tree.shortIDs[stx.ShortID] = slot
+		tree.fraudProofs[slot] = stx.FraudProofs
+		slot++
+	}
+
+	return tree, nil
+}
+
+// fill places a copy of the passed transaction with the fraud proofs of the
+// block applied to its inputs into the slot of the passed short id, if any.
+// Slots which match more than one transaction, or a transaction with a
+// different number of inputs, are left empty since it is not possible to tell
+// which one belongs to the block.
+func (tree *partialTxTree) fill(shortID uint64, tx *hcutil.Tx) {
+	slot, exists := tree.shortIDs[shortID]
+	if !exists {
+		return
+	}
+	fraudProofs := tree.fraudProofs[slot]
+	if len(fraudProofs) != len(tx.MsgTx().TxIn) {
+		return
+	}
+	if _, collided := tree.collided[slot]; collided {
+		return
+	}
+	if tree.txns[slot] != nil {
+		tree.txns[slot] = nil
+		tree.collided[slot] = struct{}{}
+		return
+	}
+	msgTx := hcutil.NewTxDeepTxIns(tx.MsgTx()).MsgTx()
+	for i, txIn := range msgTx.TxIn {
+		txIn.ValueIn = fraudProofs[i].ValueIn
+		txIn.BlockHeight = fraudProofs[i].BlockHeight
+		txIn.BlockIndex = fraudProofs[i].BlockIndex
+	}
+	tree.txns[slot] = msgTx
+}
+
+// missing returns the indexes of the transactions of the tree which are not
+// known.
+func (tree *partialTxTree) missing() []uint32 {
+	var indexes []uint32
+	for i, tx := range tree.txns {
+		if tx == nil {
+			indexes = append(indexes, uint32(i))
+		}
+	}
+	return indexes
+}
+
+// fillMissing places the passed transactions into the empty slots of the tree
+// in order.
+func (tree *partialTxTree) fillMissing(txns []*wire.MsgTx) error {
+	missing := tree.missing()
+	if len(txns) != len(missing) {
+		return fmt.Errorf("got %d transactions for %d missing ones",
+			len(txns), len(missing))
+	}
+	for i, index := range missing {
+		tree.txns[index] = txns[i]
+	}
+	return nil
+}
+
+// PartialBlock houses a block which is reconstructed from a compact block and
+// the transactions of the memory pool.  The transactions which could not be
+// found are requested from the peer by their indexes via MissingIndexes and
+// FillMissing.
+type PartialBlock struct {
+	header  wire.BlockHeader
+	regular *partialTxTree
+	stake   *partialTxTree
+}
+
+// NewPartialBlock reconstructs as much as possible of the block described by
+// the passed compact block from the transactions of the pool.  The votes,
+// tickets and revocations of the pool are matched against the stake tree and
+// all other transactions against the regular tree.
+//
+// An error is returned when the compact block is malformed, in which case the
+// block must be requested in full.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) NewPartialBlock(msg *wire.MsgCmpctBlock) (*PartialBlock, error) {
+	regular, err := newPartialTxTree(msg.ShortTxns, msg.PrefilledTxns)
+	if err != nil {
+		return nil, err
+	}
+	stakeTree, err := newPartialTxTree(msg.StakeShortTxns,
+		msg.StakePrefilledTxns)
+	if err != nil {
+		return nil, err
+	}
+
+	k0, k1 := ShortTxIDKeys(&msg.Header, msg.Nonce)
+	mp.mtx.RLock()
+	for txHash, desc := range mp.pool {
+		txHash := txHash
+		shortID := ShortTxID(k0, k1, &txHash)
+		if desc.Type == stake.TxTypeRegular {
+			regular.fill(shortID, desc.Tx)
+			continue
+		}
+		stakeTree.fill(shortID, desc.Tx)
+	}
+	mp.mtx.RUnlock()
+
+	return &PartialBlock{
+		header:  msg.Header,
+		regular: regular,
+		stake:   stakeTree,
+	}, nil
+}
+
+// BlockHash returns the hash of the block which is being reconstructed.
+func (pb *PartialBlock) BlockHash() chainhash.Hash {
+	return pb.header.BlockHash()
+}
+
+// MissingIndexes returns the indexes of the transactions of the regular and
+// stake trees which are not known yet.
+func (pb *PartialBlock) MissingIndexes() ([]uint32, []uint32) {
+	return pb.regular.missing(), pb.stake.missing()
+}
+
+// IsComplete returns whether all transactions of the block are known.
+func (pb *PartialBlock) IsComplete() bool {
+	regular, stakeMissing := pb.MissingIndexes()
+	return len(regular) == 0 && len(stakeMissing) == 0
+}
+
+// FillMissing fills the missing transactions of the regular and stake trees
+// with the passed transactions, which must be in the order of the indexes
+// returned by MissingIndexes.
+func (pb *PartialBlock) FillMissing(txns, stxns []*wire.MsgTx) error {
+	if err := pb.regular.fillMissing(txns); err != nil {
+		return err
+	}
+	return pb.stake.fillMissing(stxns)
+}
+
+// Block returns the reconstructed block.  An error is returned when there are
+// missing transactions or the transactions do not match the merkle roots of
+// the header, which happens when a short id matched the wrong transaction.
+func (pb *PartialBlock) Block() (*hcutil.Block, error) {
+	if !pb.IsComplete() {
+		return nil, errIncompleteBlock
+	}
+
+	msgBlock := &wire.MsgBlock{
+		Header:        pb.header,
+		Transactions:  pb.regular.txns,
+		STransactions: pb.stake.txns,
+	}
+	block := hcutil.NewBlock(msgBlock)
+
+	merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
+	if !pb.header.MerkleRoot.IsEqual(merkles[len(merkles)-1]) {
+		return nil, errors.New("reconstructed transactions do not " +
+			"match the merkle root")
+	}
+	merkles = blockchain.BuildMerkleTreeStore(block.STransactions())
+	if !pb.header.StakeRoot.IsEqual(merkles[len(merkles)-1]) {
+		return nil, errors.New("reconstructed stake transactions do " +
+			"not match the stake root")
+	}
+
+	return block, nil
+}
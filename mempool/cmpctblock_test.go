@@ -0,0 +1,124 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestCmpctBlockReconstruction ensures blocks are reconstructed from compact
+// blocks and the transactions of the pool and that the missing transactions
+// are identified.
+func TestCmpctBlockReconstruction(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	coinbase, err := harness.CreateCoinbaseTx(1, 1)
+	if err != nil {
+		t.Fatalf("unable to create coinbase: %v", err)
+	}
+
+	// Add all but the final transaction of the chain to the pool.
+	for _, tx := range chainedTxns[:2] {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept %v: %v",
+				tx.Hash(), err)
+		}
+	}
+
+	// Create a block with the coinbase and the chained transactions with
+	// the fraud proofs filled in like a miner would.
+	msgBlock := &wire.MsgBlock{Header: wire.BlockHeader{Height: 1}}
+	txns := []*hcutil.Tx{coinbase}
+	for i, tx := range chainedTxns {
+		blockTx := hcutil.NewTxDeepTxIns(tx.MsgTx())
+		for _, txIn := range blockTx.MsgTx().TxIn {
+			txIn.BlockHeight = 1
+			txIn.BlockIndex = uint32(i)
+		}
+		txns = append(txns, blockTx)
+	}
+	for _, tx := range txns {
+		msgBlock.AddTransaction(tx.MsgTx())
+	}
+	merkles := blockchain.BuildMerkleTreeStore(txns)
+	msgBlock.Header.MerkleRoot = *merkles[len(merkles)-1]
+	block := hcutil.NewBlock(msgBlock)
+
+	// Only the coinbase is sent in full.
+	cmpctBlock := NewCmpctBlock(block, 0x1234)
+	if len(cmpctBlock.PrefilledTxns) != 1 || len(cmpctBlock.ShortTxns) != 3 ||
+		len(cmpctBlock.StakeShortTxns) != 0 {
+
+		t.Fatalf("unexpected compact block: %d prefilled, %d short, "+
+			"%d stake short", len(cmpctBlock.PrefilledTxns),
+			len(cmpctBlock.ShortTxns), len(cmpctBlock.StakeShortTxns))
+	}
+
+	// The final transaction of the chain is missing from the pool.
+	pb, err := harness.txPool.NewPartialBlock(cmpctBlock)
+	if err != nil {
+		t.Fatalf("NewPartialBlock: unexpected error: %v", err)
+	}
+	missing, stakeMissing := pb.MissingIndexes()
+	if !reflect.DeepEqual(missing, []uint32{3}) || len(stakeMissing) != 0 {
+		t.Fatalf("unexpected missing indexes %v, stake %v", missing,
+			stakeMissing)
+	}
+	if _, err := pb.Block(); err == nil {
+		t.Fatal("Block: did not reject incomplete block")
+	}
+
+	// Filling the slot with the wrong transaction yields a block which
+	// does not match the merkle root.
+	wrongTxns := []*wire.MsgTx{chainedTxns[0].MsgTx()}
+	if err := pb.FillMissing(wrongTxns, nil); err != nil {
+		t.Fatalf("FillMissing: unexpected error: %v", err)
+	}
+	if _, err := pb.Block(); err == nil {
+		t.Fatal("Block: did not reject mismatched merkle root")
+	}
+
+	// Filling the slot with the missing transaction yields the block.
+	pb, err = harness.txPool.NewPartialBlock(cmpctBlock)
+	if err != nil {
+		t.Fatalf("NewPartialBlock: unexpected error: %v", err)
+	}
+	if err := pb.FillMissing(nil, nil); err == nil {
+		t.Fatal("FillMissing: did not reject missing transactions")
+	}
+	missingTxns := []*wire.MsgTx{txns[3].MsgTx()}
+	if err := pb.FillMissing(missingTxns, nil); err != nil {
+		t.Fatalf("FillMissing: unexpected error: %v", err)
+	}
+	reconstructed, err := pb.Block()
+	if err != nil {
+		t.Fatalf("Block: unexpected error: %v", err)
+	}
+	if *reconstructed.Hash() != *block.Hash() {
+		t.Fatalf("reconstructed block %v, want %v", reconstructed.Hash(),
+			block.Hash())
+	}
+
+	// Compact blocks with duplicate short ids can't be reconstructed.
+	cmpctBlock.ShortTxns[1].ShortID = cmpctBlock.ShortTxns[0].ShortID
+	if _, err := harness.txPool.NewPartialBlock(cmpctBlock); err == nil {
+		t.Fatal("NewPartialBlock: did not reject duplicate short ids")
+	}
+}
@@ -7,11 +7,13 @@
 package mempool
 
 import (
+	"container/heap"
 	"container/list"
 	"crypto/rand"
 	"fmt"
 	"math"
 	"math/big"
+	"sort"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -68,6 +70,23 @@ const (
 	// maxNullDataOutputs is the maximum number of OP_RETURN null data
 	// pushes in a transaction, after which it is considered non-standard.
 	maxNullDataOutputs = 4
+
+	// rollingFeeHalfLife is the half-life of the dynamic minimum fee rate
+	// that is raised whenever transactions are evicted due to the pool
+	// exceeding its maximum size.  The fee rate decays faster when the pool
+	// is well below its maximum size.
+	rollingFeeHalfLife = 12 * time.Hour
+
+	// MaxRBFSequence is the maximum sequence number an input can use to
+	// signal that the transaction spending it can be replaced by a
+	// transaction paying a higher fee.
+	MaxRBFSequence = 0xfffffffd
+
+	// maxReplacementEvictions is the maximum number of transactions that
+	// can be evicted from the pool when accepting a replacement
+	// transaction, including the descendants of the transactions it
+	// directly conflicts with.
+	maxReplacementEvictions = 100
 )
 
 // VoteTx is a struct describing a block vote (SSGen).
@@ -75,6 +94,7 @@ type VoteTx struct {
 	SsgenHash chainhash.Hash // Vote
 	SstxHash  chainhash.Hash // Ticket
 	Vote      bool
+	VoteBits  uint16
 }
 
 // Config is a descriptor containing the memory pool configuration.
@@ -134,6 +154,23 @@ type Config struct {
 	// to use for indexing the unconfirmed transactions in the memory pool.
 	// This can be nil if the address index is not enabled.
 	ExistsAddrIndex *indexers.ExistsAddrIndex
+
+	// AddTxToFeeEstimation defines an optional function to be called
+	// whenever a new regular transaction is added to the memory pool so
+	// that it can be tracked for fee estimation purposes.
+	AddTxToFeeEstimation func(txHash *chainhash.Hash, fee, size int64)
+
+	// RemoveTxFromFeeEstimation defines an optional function to be called
+	// whenever a transaction is removed from the memory pool in order to
+	// stop tracking it for fee estimation purposes.
+	RemoveTxFromFeeEstimation func(txHash *chainhash.Hash)
+
+	// TxReplaced defines an optional function to be called for every
+	// transaction that is evicted from the memory pool because a
+	// transaction replacing it was accepted.  That includes the
+	// transactions which depend on the replaced transactions.  It is
+	// called without the mempool lock held.
+	TxReplaced func(replaced, replacement *hcutil.Tx)
 }
 
 // Policy houses the policy (configuration parameters) which is used to
@@ -176,6 +213,38 @@ type Policy struct {
 	// considered a non-zero fee.
 	MinRelayTxFee hcutil.Amount
 
+	// RejectReplacement defines whether or not to reject regular
+	// transactions which conflict with transactions in the pool that
+	// signal replaceability through the sequence numbers of their inputs.
+	RejectReplacement bool
+
+	// MaxPoolSize is the maximum total serialized size in bytes of all
+	// transactions in the main pool.  Once it is exceeded, the transaction
+	// packages with the lowest fee rate are evicted and the minimum fee
+	// rate required for new transactions is raised accordingly.  A value of
+	// 0 disables the limit.
+	MaxPoolSize int64
+
+	// MaxAncestorCount is the maximum number of transactions a transaction
+	// and all of its ancestors in the pool may consist of.  A value of 0
+	// disables the limit.
+	MaxAncestorCount int
+
+	// MaxAncestorSize is the maximum total serialized size in bytes of a
+	// transaction and all of its ancestors in the pool.  A value of 0
+	// disables the limit.
+	MaxAncestorSize int64
+
+	// MaxDescendantCount is the maximum number of transactions any
+	// transaction in the pool and all of its descendants in the pool may
+	// consist of.  A value of 0 disables the limit.
+	MaxDescendantCount int
+
+	// MaxDescendantSize is the maximum total serialized size in bytes of
+	// any transaction in the pool and all of its descendants in the pool.
+	// A value of 0 disables the limit.
+	MaxDescendantSize int64
+
 	// AllowOldVotes defines whether or not votes on old blocks will be
 	// admitted and relayed.
 	AllowOldVotes bool
@@ -197,6 +266,30 @@ type TxDesc struct {
 	// StartingPriority is the priority of the transaction when it was added
 	// to the pool.
 	StartingPriority float64
+
+	// parents and children hold the transactions in the pool which the
+	// transaction spends outputs of and which spend outputs of the
+	// transaction, respectively.
+	parents  map[chainhash.Hash]*TxDesc
+	children map[chainhash.Hash]*TxDesc
+
+	// descendantFee and descendantSize are the combined fee and serialized
+	// size of the transaction and all of its descendants in the pool, since
+	// they must all be evicted together.
+	descendantFee  int64
+	descendantSize int64
+
+	// evictionIndex is the index of the transaction in the eviction heap of
+	// the pool.  It is -1 while the transaction is temporarily taken out of
+	// the heap.
+	evictionIndex int
+}
+
+// replacedTx describes a transaction which was evicted from the pool because a
+// transaction replacing it was accepted.
+type replacedTx struct {
+	replaced    *hcutil.Tx
+	replacement *hcutil.Tx
 }
 
 // TxPool is used as a source of transactions that need to be mined into blocks
@@ -213,6 +306,23 @@ type TxPool struct {
 	orphansByPrev map[chainhash.Hash]map[chainhash.Hash]*hcutil.Tx
 	addrindex     map[string]map[chainhash.Hash]struct{} // maps address to txs
 	outpoints     map[wire.OutPoint]*hcutil.Tx
+	poolSize      int64 // total serialized size of all txns in the pool.
+
+	// evictionHeap holds the transactions in the pool ordered by the order
+	// in which they are evicted when the pool exceeds its maximum size.
+	evictionHeap evictionHeap
+
+	// replacedTxns holds the replaced transactions the TxReplaced callback
+	// has not been invoked for yet.  The callback is invoked once the
+	// mempool lock is released.
+	replacedTxns []replacedTx
+
+	// rollingMinFee is the dynamic minimum fee rate in atoms/kB which is
+	// raised when transactions are evicted due to the pool size limit and
+	// decays over time.  lastRollingFeeUpdate is the last time it was
+	// updated.
+	rollingMinFee        float64
+	lastRollingFeeUpdate time.Time
 
 	// Votes on blocks.
 	votesMtx sync.RWMutex
@@ -257,6 +367,7 @@ func (mp *TxPool) insertVote(ssgen *hcutil.Tx) error {
 		SsgenHash: *voteHash,
 		SstxHash:  *ticketHash,
 		Vote:      vote,
+		VoteBits:  voteBits,
 	}
  	// Append the new vote.
 	mp.votes[blockHash] = append(vts, voteTx)
@@ -566,16 +677,92 @@ func (mp *TxPool) removeTransaction(tx *hcutil.Tx, removeRedeemers bool) {
 			mp.cfg.AddrIndex.RemoveUnconfirmedTx(txHash)
 		}
 
+		// Stop tracking the transaction for fee estimation.
+		if mp.cfg.RemoveTxFromFeeEstimation != nil {
+			mp.cfg.RemoveTxFromFeeEstimation(txHash)
+		}
+
 		// Mark the referenced outpoints as unspent by the pool.
 
 		for _, txIn := range txDesc.Tx.MsgTx().TxIn {
 			delete(mp.outpoints, txIn.PreviousOutPoint)
 		}
+
+		// Unlink the transaction from its parents and from any
+		// children which remain in the pool, such as when the
+		// transaction was mined, and update the descendant totals of
+		// its ancestors accordingly.
+		ancestors := make(map[chainhash.Hash]*TxDesc)
+		mp.addAncestors(txDesc, ancestors)
+		for _, parent := range txDesc.parents {
+			delete(parent.children, *txHash)
+		}
+		for _, child := range txDesc.children {
+			delete(child.parents, *txHash)
+		}
+		txSize := int64(msgTx.SerializeSize())
+		if len(txDesc.children) == 0 {
+			for _, ancestor := range ancestors {
+				ancestor.descendantFee -= txDesc.Fee
+				ancestor.descendantSize -= txSize
+				mp.fixEvictionHeap(ancestor)
+			}
+		} else {
+			for _, ancestor := range ancestors {
+				mp.updateDescendantTotals(ancestor)
+			}
+		}
+		if txDesc.evictionIndex >= 0 {
+			heap.Remove(&mp.evictionHeap, txDesc.evictionIndex)
+		}
 		delete(mp.pool, *txHash)
+		mp.poolSize -= txSize
 		atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 	}
 }
 
+// removeReplacedTransaction removes the passed transaction, which conflicts
+// with the passed replacement transaction, along with all of its descendants
+// from the pool and notifies the caller about each of them.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) removeReplacedTransaction(txDesc *TxDesc, replacement *hcutil.Tx) {
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	mp.addDescendants(txDesc, descendants)
+	mp.removeTransaction(txDesc.Tx, true)
+
+	log.Debugf("Replaced transaction %v with %v (evicted %d descendants)",
+		txDesc.Tx.Hash(), replacement.Hash(), len(descendants))
+	if mp.cfg.TxReplaced == nil {
+		return
+	}
+	mp.replacedTxns = append(mp.replacedTxns, replacedTx{txDesc.Tx,
+		replacement})
+	for _, descendant := range descendants {
+		mp.replacedTxns = append(mp.replacedTxns,
+			replacedTx{descendant.Tx, replacement})
+	}
+}
+
+// notifyReplacedTxns invokes the TxReplaced callback for the transactions which
+// were replaced since the last time it was called.
+//
+// This function MUST NOT be called with the mempool lock held.
+func (mp *TxPool) notifyReplacedTxns() {
+	if mp.cfg.TxReplaced == nil {
+		return
+	}
+
+	mp.mtx.Lock()
+	replacedTxns := mp.replacedTxns
+	mp.replacedTxns = nil
+	mp.mtx.Unlock()
+
+	for _, r := range replacedTxns {
+		mp.cfg.TxReplaced(r.replaced, r.replacement)
+	}
+}
+
 // RemoveTransaction removes the passed transaction from the mempool. When the
 // removeRedeemers flag is set, any transactions that redeem outputs from the
 // removed transaction will also be removed recursively from the mempool, as
@@ -620,7 +807,8 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 	// Add the transaction to the pool and mark the referenced outpoints
 	// as spent by the pool.
 	msgTx := tx.MsgTx()
-	mp.pool[*tx.Hash()] = &TxDesc{
+	txSize := int64(msgTx.SerializeSize())
+	txDesc := &TxDesc{
 		TxDesc: mining.TxDesc{
 			Tx:     tx,
 			Type:   txType,
@@ -629,10 +817,64 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 			Fee:    fee,
 		},
 		StartingPriority: CalcPriority(msgTx, utxoView, height),
+		parents:          make(map[chainhash.Hash]*TxDesc),
+		children:         make(map[chainhash.Hash]*TxDesc),
+		descendantFee:    fee,
+		descendantSize:   txSize,
 	}
+	mp.pool[*tx.Hash()] = txDesc
 	for _, txIn := range msgTx.TxIn {
 		mp.outpoints[txIn.PreviousOutPoint] = tx
+
+		// Link the transaction with the transactions in the pool it
+		// spends outputs of.
+		parentHash := txIn.PreviousOutPoint.Hash
+		if parent, exists := mp.pool[parentHash]; exists {
+			txDesc.parents[parentHash] = parent
+			parent.children[*tx.Hash()] = txDesc
+		}
 	}
+
+	// Link the transaction with the transactions in the pool which already
+	// spend its outputs.  This happens when the transaction is added back
+	// to the pool after the block it was mined in was disapproved while its
+	// children remained in the pool.
+	tree := wire.TxTreeRegular
+	if txType != stake.TxTypeRegular {
+		tree = wire.TxTreeStake
+	}
+	for i := range msgTx.TxOut {
+		outpoint := wire.OutPoint{Hash: *tx.Hash(), Index: uint32(i),
+			Tree: tree}
+		txRedeemer, exists := mp.outpoints[outpoint]
+		if !exists {
+			continue
+		}
+		if child, exists := mp.pool[*txRedeemer.Hash()]; exists {
+			txDesc.children[*txRedeemer.Hash()] = child
+			child.parents[*tx.Hash()] = txDesc
+		}
+	}
+
+	// Add the transaction to the eviction heap and include it in the
+	// descendant totals of its ancestors.  The totals are recalculated from
+	// scratch in the rare case the transaction already has descendants.
+	heap.Push(&mp.evictionHeap, txDesc)
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	mp.addAncestors(txDesc, ancestors)
+	if len(txDesc.children) == 0 {
+		for _, ancestor := range ancestors {
+			ancestor.descendantFee += fee
+			ancestor.descendantSize += txSize
+			mp.fixEvictionHeap(ancestor)
+		}
+	} else {
+		mp.updateDescendantTotals(txDesc)
+		for _, ancestor := range ancestors {
+			mp.updateDescendantTotals(ancestor)
+		}
+	}
+	mp.poolSize += txSize
 	atomic.StoreInt64(&mp.lastUpdated, time.Now().Unix())
 
 	// Add unconfirmed address index entries associated with the transaction
@@ -643,25 +885,580 @@ func (mp *TxPool) addTransaction(utxoView *blockchain.UtxoViewpoint,
 	if mp.cfg.ExistsAddrIndex != nil {
 		mp.cfg.ExistsAddrIndex.AddUnconfirmedTx(msgTx)
 	}
+
+	// Track regular transactions for fee estimation.  Stake transactions
+	// are not mined based on the fees they pay, so they are excluded.
+	if txType == stake.TxTypeRegular && mp.cfg.AddTxToFeeEstimation != nil {
+		mp.cfg.AddTxToFeeEstimation(tx.Hash(), fee,
+			int64(msgTx.SerializeSize()))
+	}
 }
 
 // checkPoolDoubleSpend checks whether or not the passed transaction is
 // attempting to spend coins already spent by other transactions in the pool.
-// Note it does not check for double spends against transactions already in the
-// main chain.
+// Conflicts are allowed when the passed transaction is a regular transaction
+// which may replace the conflicting transactions, in which case true is
+// returned so the replacement can be validated once its fee is known.  Note it
+// does not check for double spends against transactions already in the main
+// chain.
 //
 // This function MUST be called with the mempool lock held (for reads).
-func (mp *TxPool) checkPoolDoubleSpend(tx *hcutil.Tx, txType stake.TxType) error {
+func (mp *TxPool) checkPoolDoubleSpend(tx *hcutil.Tx, txType stake.TxType) (bool, error) {
+	var isReplacement bool
 	for i, txIn := range tx.MsgTx().TxIn {
 		// We don't care about double spends of stake bases.
 		if  i == 0 && (txType == stake.TxTypeSSGen || txType == stake.TxTypeSSRtx) {
 			continue
 		}
 
-		if txR, exists := mp.outpoints[txIn.PreviousOutPoint]; exists {
+		txR, exists := mp.outpoints[txIn.PreviousOutPoint]
+		if !exists {
+			continue
+		}
+
+		// Only regular transactions which conflict with regular
+		// transactions that signal replaceability may replace them.
+		conflict := mp.pool[*txR.Hash()]
+		if mp.cfg.Policy.RejectReplacement ||
+			txType != stake.TxTypeRegular || conflict == nil ||
+			conflict.Type != stake.TxTypeRegular ||
+			!mp.signalsReplacement(conflict, nil) {
+
 			str := fmt.Sprintf("transaction %v in the pool "+
 				"already spends the same coins", txR.Hash())
-			return txRuleError(wire.RejectDuplicate, str)
+			return false, txRuleError(wire.RejectDuplicate, str)
+		}
+		isReplacement = true
+	}
+
+	return isReplacement, nil
+}
+
+// signalsReplacement returns whether or not the passed transaction in the pool
+// can be replaced.  That is the case when any of its inputs has a sequence
+// number of at most MaxRBFSequence or when any of its unconfirmed ancestors can
+// be replaced, since replacing an ancestor also evicts the transaction.  The
+// visited map is used to avoid examining the same ancestor more than once and
+// may be nil.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) signalsReplacement(txDesc *TxDesc, visited map[chainhash.Hash]struct{}) bool {
+	if visited == nil {
+		visited = make(map[chainhash.Hash]struct{})
+	}
+	visited[*txDesc.Tx.Hash()] = struct{}{}
+
+	for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+		if txIn.Sequence <= MaxRBFSequence {
+			return true
+		}
+	}
+	for _, txIn := range txDesc.Tx.MsgTx().TxIn {
+		parentHash := txIn.PreviousOutPoint.Hash
+		if _, ok := visited[parentHash]; ok {
+			continue
+		}
+		parent, exists := mp.pool[parentHash]
+		if !exists {
+			continue
+		}
+		if mp.signalsReplacement(parent, visited) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateReplacement determines whether the passed regular transaction, which
+// pays the provided fee, is allowed to replace the transactions in the pool it
+// conflicts with.  The replacement must pay a higher fee rate than every
+// transaction it evicts, pay for the evicted transactions and its own relay
+// fee, evict no more than maxReplacementEvictions transactions, and not spend
+// any unconfirmed outputs that the transactions it directly conflicts with did
+// not already spend.  The transactions it directly conflicts with are returned
+// when it is allowed.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) validateReplacement(tx *hcutil.Tx, txFee int64) ([]*TxDesc, error) {
+	txHash := tx.Hash()
+	msgTx := tx.MsgTx()
+
+	// Gather the transactions which directly conflict with the replacement
+	// along with all of their descendants since they are evicted too.
+	var conflicts []*TxDesc
+	evicted := make(map[chainhash.Hash]*TxDesc)
+	for _, txIn := range msgTx.TxIn {
+		txR, exists := mp.outpoints[txIn.PreviousOutPoint]
+		if !exists {
+			continue
+		}
+		conflictHash := *txR.Hash()
+		if _, ok := evicted[conflictHash]; ok {
+			continue
+		}
+		conflict := mp.pool[conflictHash]
+		conflicts = append(conflicts, conflict)
+		evicted[conflictHash] = conflict
+		mp.addDescendants(conflict, evicted)
+	}
+	if len(evicted) > maxReplacementEvictions {
+		str := fmt.Sprintf("replacement transaction %v would evict %d "+
+			"transactions which is more than the maximum of %d",
+			txHash, len(evicted), maxReplacementEvictions)
+		return nil, txRuleError(wire.RejectNonstandard, str)
+	}
+
+	// The replacement may not spend outputs of the transactions it evicts
+	// nor any unconfirmed outputs which were not already spent by the
+	// transactions it directly conflicts with.
+	parents := make(map[chainhash.Hash]struct{})
+	for _, conflict := range conflicts {
+		for _, txIn := range conflict.Tx.MsgTx().TxIn {
+			parents[txIn.PreviousOutPoint.Hash] = struct{}{}
+		}
+	}
+	for _, txIn := range msgTx.TxIn {
+		parentHash := txIn.PreviousOutPoint.Hash
+		if _, ok := evicted[parentHash]; ok {
+			str := fmt.Sprintf("replacement transaction %v spends "+
+				"outputs of transaction %v which it replaces",
+				txHash, parentHash)
+			return nil, txRuleError(wire.RejectInvalid, str)
+		}
+		if _, ok := parents[parentHash]; ok {
+			continue
+		}
+		if mp.isTransactionInPool(&parentHash) {
+			str := fmt.Sprintf("replacement transaction %v spends "+
+				"new unconfirmed input %v which is not spent by "+
+				"the transactions it replaces", txHash, parentHash)
+			return nil, txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
+	// The replacement must pay a higher fee rate than every transaction
+	// it evicts and its fee must cover the fees of all of them in addition
+	// to its own relay fee.
+	txSize := int64(msgTx.SerializeSize())
+	txFeeRate := float64(txFee) / float64(txSize)
+	var evictedFees int64
+	for hash, txDesc := range evicted {
+		size := int64(txDesc.Tx.MsgTx().SerializeSize())
+		feeRate := float64(txDesc.Fee) / float64(size)
+		if txFeeRate <= feeRate {
+			str := fmt.Sprintf("replacement transaction %v has a fee "+
+				"rate of %.2f atoms/byte which is not higher than "+
+				"the fee rate of %.2f atoms/byte of transaction %v "+
+				"it replaces", txHash, txFeeRate, feeRate, hash)
+			return nil, txRuleError(wire.RejectInsufficientFee, str)
+		}
+		evictedFees += txDesc.Fee
+	}
+	minFee := evictedFees + calcMinRequiredTxRelayFee(txSize,
+		mp.cfg.Policy.MinRelayTxFee)
+	if txFee < minFee {
+		str := fmt.Sprintf("replacement transaction %v has %v fees "+
+			"which is under the required amount of %v to replace %d "+
+			"transactions", txHash, hcutil.Amount(txFee),
+			hcutil.Amount(minFee), len(evicted))
+		return nil, txRuleError(wire.RejectInsufficientFee, str)
+	}
+
+	return conflicts, nil
+}
+
+// evictionClass returns the class used to order the eviction of transactions
+// of the passed type when the pool exceeds its maximum size.  All transactions
+// in a lower class are evicted before any in a higher class, which ensures
+// votes and revocations, which are required for the chain to make progress,
+// are only ever evicted once no other transactions remain.
+func evictionClass(txType stake.TxType) int {
+	switch txType {
+	case stake.TxTypeSSGen, stake.TxTypeSSRtx:
+		return 2
+	case stake.TxTypeSStx:
+		return 1
+	}
+	return 0
+}
+
+// packageFeeRate returns the fee rate in atoms/kB of the package consisting of
+// the transaction and all of its descendants in the pool.
+func (txD *TxDesc) packageFeeRate() float64 {
+	return float64(txD.descendantFee) * 1000 / float64(txD.descendantSize)
+}
+
+// evictsBefore returns whether the package of the first passed transaction is
+// evicted from the pool before the package of the second one.  That is to say
+// packages with a lower eviction class come first and, within the same class,
+// packages with a lower fee rate come first.  Ties are broken by evicting the
+// most recently added transactions first.
+func evictsBefore(a, b *TxDesc) bool {
+	classA, classB := evictionClass(a.Type), evictionClass(b.Type)
+	if classA != classB {
+		return classA < classB
+	}
+	if rateA, rateB := a.packageFeeRate(), b.packageFeeRate(); rateA != rateB {
+		return rateA < rateB
+	}
+	return a.Added.After(b.Added)
+}
+
+// evictionHeap implements a min-heap of the transactions in the pool ordered by
+// the order in which their packages are evicted from the pool.  The index of
+// each transaction in the heap is kept up to date so it can be fixed up or
+// removed when its descendants change.
+type evictionHeap []*TxDesc
+
+// Len returns the number of transactions in the heap.  It is part of the
+// heap.Interface implementation.
+func (h evictionHeap) Len() int { return len(h) }
+
+// Less returns whether the transaction with index i is evicted before the one
+// with index j.  It is part of the heap.Interface implementation.
+func (h evictionHeap) Less(i, j int) bool { return evictsBefore(h[i], h[j]) }
+
+// Swap swaps the transactions at the passed indices in the heap.  It is part of
+// the heap.Interface implementation.
+func (h evictionHeap) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].evictionIndex = i
+	h[j].evictionIndex = j
+}
+
+// Push pushes the passed transaction onto the heap.  It is part of the
+// heap.Interface implementation.
+func (h *evictionHeap) Push(x interface{}) {
+	txDesc := x.(*TxDesc)
+	txDesc.evictionIndex = len(*h)
+	*h = append(*h, txDesc)
+}
+
+// Pop removes the last transaction from the heap and returns it.  It is part of
+// the heap.Interface implementation.
+func (h *evictionHeap) Pop() interface{} {
+	n := len(*h)
+	txDesc := (*h)[n-1]
+	(*h)[n-1] = nil
+	*h = (*h)[:n-1]
+	txDesc.evictionIndex = -1
+	return txDesc
+}
+
+// updateDescendantTotals recalculates the combined fee and size of the passed
+// transaction and all of its descendants in the pool and updates its position
+// in the eviction heap accordingly.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) updateDescendantTotals(txDesc *TxDesc) {
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	mp.addDescendants(txDesc, descendants)
+	txDesc.descendantFee = txDesc.Fee
+	txDesc.descendantSize = int64(txDesc.Tx.MsgTx().SerializeSize())
+	for _, descendant := range descendants {
+		txDesc.descendantFee += descendant.Fee
+		txDesc.descendantSize += int64(descendant.Tx.MsgTx().SerializeSize())
+	}
+	mp.fixEvictionHeap(txDesc)
+}
+
+// fixEvictionHeap restores the order of the eviction heap after the descendant
+// totals of the passed transaction changed.  Transactions which are temporarily
+// taken out of the heap are ignored.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) fixEvictionHeap(txDesc *TxDesc) {
+	if txDesc.evictionIndex >= 0 {
+		heap.Fix(&mp.evictionHeap, txDesc.evictionIndex)
+	}
+}
+
+// addDescendants adds all transactions in the pool which spend outputs of the
+// passed transaction, recursively, to the provided map.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) addDescendants(txDesc *TxDesc, descendants map[chainhash.Hash]*TxDesc) {
+	for childHash, child := range txDesc.children {
+		if _, ok := descendants[childHash]; ok {
+			continue
+		}
+		descendants[childHash] = child
+		mp.addDescendants(child, descendants)
+	}
+}
+
+// addAncestors adds all transactions in the pool the passed transaction spends
+// outputs of, recursively, to the provided map.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) addAncestors(txDesc *TxDesc, ancestors map[chainhash.Hash]*TxDesc) {
+	for parentHash, parent := range txDesc.parents {
+		if _, ok := ancestors[parentHash]; ok {
+			continue
+		}
+		ancestors[parentHash] = parent
+		mp.addAncestors(parent, ancestors)
+	}
+}
+
+// checkChainLimits ensures that adding the passed transaction to the pool does
+// not exceed the configured limits on the number and total size of the
+// ancestors of the transaction and of the descendants of each of its
+// ancestors.  Both the counts and the sizes include the transaction itself.
+// The transactions which are about to be evicted by the passed transaction as
+// a replacement, along with their descendants, are not taken into account.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) checkChainLimits(tx *hcutil.Tx, conflicts []*TxDesc) error {
+	policy := &mp.cfg.Policy
+	txHash := tx.Hash()
+	txSize := int64(tx.MsgTx().SerializeSize())
+
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	for _, txIn := range tx.MsgTx().TxIn {
+		parentHash := txIn.PreviousOutPoint.Hash
+		if _, ok := ancestors[parentHash]; ok {
+			continue
+		}
+		if parent, exists := mp.pool[parentHash]; exists {
+			ancestors[parentHash] = parent
+			mp.addAncestors(parent, ancestors)
+		}
+	}
+	if len(ancestors) == 0 {
+		return nil
+	}
+
+	ancestorSize := txSize
+	for _, ancestor := range ancestors {
+		ancestorSize += int64(ancestor.Tx.MsgTx().SerializeSize())
+	}
+	if policy.MaxAncestorCount > 0 &&
+		len(ancestors)+1 > policy.MaxAncestorCount {
+
+		str := fmt.Sprintf("transaction %v has %d unconfirmed ancestors "+
+			"which exceeds the limit of %d", txHash, len(ancestors),
+			policy.MaxAncestorCount-1)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+	if policy.MaxAncestorSize > 0 && ancestorSize > policy.MaxAncestorSize {
+		str := fmt.Sprintf("transaction %v and its unconfirmed ancestors "+
+			"have a size of %d bytes which exceeds the limit of %d",
+			txHash, ancestorSize, policy.MaxAncestorSize)
+		return txRuleError(wire.RejectNonstandard, str)
+	}
+
+	if policy.MaxDescendantCount <= 0 && policy.MaxDescendantSize <= 0 {
+		return nil
+	}
+	evicted := make(map[chainhash.Hash]*TxDesc)
+	for _, conflict := range conflicts {
+		evicted[*conflict.Tx.Hash()] = conflict
+		mp.addDescendants(conflict, evicted)
+	}
+	for ancestorHash, ancestor := range ancestors {
+		descendants := make(map[chainhash.Hash]*TxDesc)
+		mp.addDescendants(ancestor, descendants)
+		descendantCount := 2
+		descendantSize := int64(ancestor.Tx.MsgTx().SerializeSize()) +
+			txSize
+		for hash, descendant := range descendants {
+			if _, ok := evicted[hash]; ok {
+				continue
+			}
+			descendantCount++
+			descendantSize += int64(descendant.Tx.MsgTx().SerializeSize())
+		}
+		if policy.MaxDescendantCount > 0 &&
+			descendantCount > policy.MaxDescendantCount {
+
+			str := fmt.Sprintf("transaction %v would give unconfirmed "+
+				"ancestor %v %d descendants which exceeds the "+
+				"limit of %d", txHash, ancestorHash,
+				descendantCount-1, policy.MaxDescendantCount-1)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+		if policy.MaxDescendantSize > 0 &&
+			descendantSize > policy.MaxDescendantSize {
+
+			str := fmt.Sprintf("transaction %v would give unconfirmed "+
+				"ancestor %v and its descendants a size of %d "+
+				"bytes which exceeds the limit of %d", txHash,
+				ancestorHash, descendantSize,
+				policy.MaxDescendantSize)
+			return txRuleError(wire.RejectNonstandard, str)
+		}
+	}
+
+	return nil
+}
+
+// minFeeRate returns the current dynamic minimum fee rate in atoms/kB that
+// transactions must pay in order to be accepted into the pool after the pool
+// size limit has caused transactions to be evicted.  The rate decays
+// exponentially over time and drops to zero once it falls below half of the
+// configured minimum relay fee.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) minFeeRate() hcutil.Amount {
+	if mp.rollingMinFee == 0 {
+		return 0
+	}
+
+	// Decay the fee rate faster when the pool has plenty of room.
+	halfLife := rollingFeeHalfLife
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	if mp.poolSize < maxSize/4 {
+		halfLife /= 4
+	} else if mp.poolSize < maxSize/2 {
+		halfLife /= 2
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(mp.lastRollingFeeUpdate)
+	mp.rollingMinFee /= math.Pow(2, elapsed.Seconds()/halfLife.Seconds())
+	mp.lastRollingFeeUpdate = now
+	if mp.rollingMinFee < float64(mp.cfg.Policy.MinRelayTxFee)/2 {
+		mp.rollingMinFee = 0
+	}
+
+	return hcutil.Amount(mp.rollingMinFee)
+}
+
+// limitPoolSize evicts the transaction packages with the lowest fee rate from
+// the pool until its total size is no longer above the configured maximum.
+// Each evicted package raises the dynamic minimum fee rate to the fee rate of
+// the package plus the minimum relay fee so that replacing it requires paying
+// a higher fee.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) limitPoolSize() {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	if maxSize <= 0 || mp.poolSize <= maxSize {
+		return
+	}
+
+	// Evict the package at the top of the eviction heap until the pool is
+	// small enough.
+	var numEvicted int
+	startSize := mp.poolSize
+	for mp.poolSize > maxSize && len(mp.evictionHeap) > 0 {
+		numEvicted += mp.evictPackage(mp.evictionHeap[0])
+	}
+
+	log.Debugf("Evicted %d transactions (%d bytes) to keep the pool below "+
+		"its maximum size of %d bytes (min fee rate now %v/kB)",
+		numEvicted, startSize-mp.poolSize, maxSize,
+		hcutil.Amount(mp.rollingMinFee))
+}
+
+// evictPackage evicts the passed transaction along with all of its descendants
+// from the pool due to the pool size limit and raises the dynamic minimum fee
+// rate to the fee rate of the package plus the minimum relay fee.  It returns
+// the number of evicted transactions.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) evictPackage(txDesc *TxDesc) int {
+	feeRate := txDesc.packageFeeRate()
+	numPoolTxns := len(mp.pool)
+	mp.removeTransaction(txDesc.Tx, true)
+
+	newMinFee := feeRate + float64(mp.cfg.Policy.MinRelayTxFee)
+	if newMinFee > mp.rollingMinFee {
+		mp.rollingMinFee = newMinFee
+	}
+	mp.lastRollingFeeUpdate = time.Now()
+
+	return numPoolTxns - len(mp.pool)
+}
+
+// makeRoomForReplacement ensures the passed replacement transaction, which pays
+// the provided fee, remains in the pool after it replaces the passed
+// conflicting transactions when the pool is at its maximum size.  It evicts the
+// packages which would be evicted before the replacement until there is enough
+// room for it once the conflicting transactions and their descendants are
+// gone.  An error is returned when that is not possible, in which case the
+// conflicting transactions are left in the pool.
+//
+// This function MUST be called with the mempool lock held (for writes).
+func (mp *TxPool) makeRoomForReplacement(tx *hcutil.Tx, txType stake.TxType, txFee int64, conflicts []*TxDesc) error {
+	maxSize := mp.cfg.Policy.MaxPoolSize
+	if maxSize <= 0 {
+		return nil
+	}
+
+	// The replacement is evicted before packages with the same fee rate
+	// since it is the most recently added transaction.
+	txSize := int64(tx.MsgTx().SerializeSize())
+	replacementDesc := &TxDesc{
+		TxDesc: mining.TxDesc{
+			Tx:    tx,
+			Type:  txType,
+			Added: time.Now(),
+			Fee:   txFee,
+		},
+		descendantFee:  txFee,
+		descendantSize: txSize,
+	}
+
+	// The space taken by the replaced transactions is freed by the
+	// replacement itself, so take them out of the eviction heap while
+	// making room for it and put the ones which remain back afterwards.
+	replaced := make(map[chainhash.Hash]*TxDesc)
+	for _, conflict := range conflicts {
+		replaced[*conflict.Tx.Hash()] = conflict
+		mp.addDescendants(conflict, replaced)
+	}
+	for _, txDesc := range replaced {
+		heap.Remove(&mp.evictionHeap, txDesc.evictionIndex)
+	}
+	defer func() {
+		for txHash, txDesc := range replaced {
+			if _, exists := mp.pool[txHash]; exists {
+				heap.Push(&mp.evictionHeap, txDesc)
+			}
+		}
+	}()
+	excessSize := func() int64 {
+		size := mp.poolSize + txSize - maxSize
+		for txHash, txDesc := range replaced {
+			if _, exists := mp.pool[txHash]; exists {
+				size -= int64(txDesc.Tx.MsgTx().SerializeSize())
+			}
+		}
+		return size
+	}
+
+	// Keep track of the unconfirmed transactions the replacement spends
+	// since evicting them would make it an orphan.
+	var parents []chainhash.Hash
+	for _, txIn := range tx.MsgTx().TxIn {
+		parentHash := txIn.PreviousOutPoint.Hash
+		if mp.isTransactionInPool(&parentHash) {
+			parents = append(parents, parentHash)
+		}
+	}
+
+	for excessSize() > 0 {
+		if len(mp.evictionHeap) == 0 ||
+			!evictsBefore(mp.evictionHeap[0], replacementDesc) {
+
+			str := fmt.Sprintf("replacement transaction %v has "+
+				"insufficient fees to be accepted into the full "+
+				"mempool", tx.Hash())
+			return txRuleError(wire.RejectInsufficientFee, str)
+		}
+		mp.evictPackage(mp.evictionHeap[0])
+
+		for _, parentHash := range parents {
+			if !mp.isTransactionInPool(&parentHash) {
+				str := fmt.Sprintf("replacement transaction %v "+
+					"has insufficient fees to keep its "+
+					"unconfirmed parent %v in the full mempool",
+					tx.Hash(), parentHash)
+				return txRuleError(wire.RejectInsufficientFee, str)
+			}
 		}
 	}
 
@@ -766,19 +1563,23 @@ func (mp *TxPool) FetchTransaction(txHash *chainhash.Hash, includeRecentBlock bo
 // MaybeAcceptTransaction.  See the comment for MaybeAcceptTransaction for
 // more details.
 //
+// When testPkg is not nil, the transaction is only validated.  The pool is not
+// modified and the transaction is added to testPkg instead when it would have
+// been accepted, so later transactions of the package may spend its outputs.
+//
 // This function MUST be called with the mempool lock held (for writes).
 // hcd - TODO
 // We need to make sure thing also assigns the TxType after it evaluates the tx,
 // so that we can easily pick different stake tx types from the mempool later.
 // This should probably be done at the bottom using "IsSStx" etc functions.
 // It should also set the hcutil tree type for the tx as well.
-func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowHighFees bool) ([]*chainhash.Hash, error) {
+func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowHighFees bool, testPkg *testAcceptPackage) ([]*chainhash.Hash, error) {
 	msgTx := tx.MsgTx()
 	txHash := tx.Hash()
 	// Don't accept the transaction if it already exists in the pool.  This
 	// applies to orphan transactions as well.  This check is intended to
 	// be a quick check to weed out duplicates.
-	if mp.haveTransaction(txHash) {
+	if mp.haveTransaction(txHash) || testPkg.haveTransaction(txHash) {
 		str := fmt.Sprintf("already have transaction %v", txHash)
 		return nil, txRuleError(wire.RejectDuplicate, str)
 	}
@@ -867,6 +1668,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 	}
 
 	// Handle stake transaction double spending exceptions.
+	var isReplacement bool
 	if (txType == stake.TxTypeSSGen) || (txType == stake.TxTypeSSRtx) {
 		if txType == stake.TxTypeSSGen {
 			ssGenAlreadyFound := 0
@@ -909,11 +1711,17 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 		// at this point.  There is a more in-depth check that happens later
 		// after fetching the referenced transaction inputs from the main chain
 		// which examines the actual spend data and prevents double spends.
-		err = mp.checkPoolDoubleSpend(tx, txType)
+		// Regular transactions which conflict with replaceable
+		// transactions are validated as replacements once their fee is
+		// known.
+		isReplacement, err = mp.checkPoolDoubleSpend(tx, txType)
 		if err != nil {
 			return nil, err
 		}
 	}
+	if err := testPkg.checkDoubleSpend(tx, txType); err != nil {
+		return nil, err
+	}
 
 	// Votes that are on too old of blocks are rejected.
 	if txType == stake.TxTypeSSGen {
@@ -943,6 +1751,7 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 		}
 		return nil, err
 	}
+	testPkg.addInputUtxos(utxoView)
 
 	// Don't allow the transaction if it exists in the main chain and is not
 	// not already fully spent.
@@ -1145,6 +1954,23 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 		}
 	}
 
+	// Require regular transactions and tickets to pay the dynamic minimum fee
+	// rate which is raised when transactions are evicted due to the pool
+	// size limit.  Votes and revocations are exempt since they are required
+	// for the chain to make progress.
+	if isNew && (txType == stake.TxTypeRegular || txType == stake.TxTypeSStx) {
+		if minFeeRate := mp.minFeeRate(); minFeeRate > 0 {
+			minPoolFee := calcMinRequiredTxRelayFee(serializedSize,
+				minFeeRate)
+			if txFee < minPoolFee {
+				str := fmt.Sprintf("transaction %v has %v fees which "+
+					"is under the current mempool minimum fee of %v",
+					txHash, txFee, minPoolFee)
+				return nil, txRuleError(wire.RejectInsufficientFee, str)
+			}
+		}
+	}
+
 	// Check whether allowHighFees is set to false (default), if so, then make
 	// sure the current fee is sensible.  If people would like to avoid this
 	// check then they can AllowHighFees = true
@@ -1159,6 +1985,23 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 		}
 	}
 
+	// Ensure a replacement transaction satisfies the replacement policy
+	// before evicting the transactions it conflicts with.
+	var conflicts []*TxDesc
+	if isReplacement {
+		conflicts, err = mp.validateReplacement(tx, txFee)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Don't allow the transaction to create unconfirmed transaction chains
+	// which exceed the configured ancestor and descendant limits.
+	err = mp.checkChainLimits(tx, conflicts)
+	if err != nil {
+		return nil, err
+	}
+
 	// Verify crypto signatures for each input and reject the transaction if
 	// any don't verify.
 	flags, err := mp.cfg.Policy.StandardVerifyFlags()
@@ -1174,9 +2017,44 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 		return nil, err
 	}
 
+	// Stop without modifying the pool when only testing whether the
+	// transaction would be accepted.
+	if testPkg != nil {
+		testPkg.addTransaction(tx, txType)
+		return nil, nil
+	}
+
+	// Evict the transactions replaced by the transaction along with all of
+	// their descendants once it is certain the transaction itself won't be
+	// evicted due to the pool size limit.
+	if len(conflicts) > 0 {
+		err := mp.makeRoomForReplacement(tx, txType, txFee, conflicts)
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, conflict := range conflicts {
+		// Conflicts which spend outputs of a package evicted while
+		// making room for the transaction are already gone.
+		if !mp.isTransactionInPool(conflict.Tx.Hash()) {
+			continue
+		}
+		mp.removeReplacedTransaction(conflict, tx)
+	}
+
 	// Add to transaction pool.
 	mp.addTransaction(utxoView, tx, txType, bestHeight, txFee)
 
+	// Evict the lowest fee rate transactions when the pool has grown beyond
+	// its maximum size.  The transaction is rejected when it does not pay a
+	// high enough fee rate to remain in the pool itself.
+	mp.limitPoolSize()
+	if !mp.isTransactionInPool(txHash) {
+		str := fmt.Sprintf("transaction %v has insufficient fees to be "+
+			"accepted into the full mempool", txHash)
+		return nil, txRuleError(wire.RejectInsufficientFee, str)
+	}
+
 	// If it's an SSGen (vote), insert it into the list of
 	// votes.
 	if txType == stake.TxTypeSSGen {
@@ -1205,8 +2083,9 @@ func (mp *TxPool) maybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit, allowH
 func (mp *TxPool) MaybeAcceptTransaction(tx *hcutil.Tx, isNew, rateLimit bool) ([]*chainhash.Hash, error) {
 	// Protect concurrent access.
 	mp.mtx.Lock()
-	hashes, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true)
+	hashes, err := mp.maybeAcceptTransaction(tx, isNew, rateLimit, true, nil)
 	mp.mtx.Unlock()
+	mp.notifyReplacedTxns()
 
 	return hashes, err
 }
@@ -1256,7 +2135,7 @@ func (mp *TxPool) processOrphans(hash *chainhash.Hash) []*hcutil.Tx {
 			// Potentially accept the transaction into the
 			// transaction pool.
 			missingParents, err := mp.maybeAcceptTransaction(tx,
-				true, true, true)
+				true, true, true, nil)
 			if err != nil {
 				// TODO: Remove orphans that depend on this
 				// failed transaction.
@@ -1362,6 +2241,7 @@ func (mp *TxPool) ProcessOrphans(hash *chainhash.Hash) []*hcutil.Tx {
 	mp.mtx.Lock()
 	acceptedTxns := mp.processOrphans(hash)
 	mp.mtx.Unlock()
+	mp.notifyReplacedTxns()
 	return acceptedTxns
 }
 
@@ -1377,7 +2257,9 @@ func (mp *TxPool) ProcessOrphans(hash *chainhash.Hash) []*hcutil.Tx {
 //
 // This function is safe for concurrent access.
 func (mp *TxPool) ProcessTransaction(tx *hcutil.Tx, allowOrphan, rateLimit, allowHighFees bool) ([]*hcutil.Tx, error) {
-	// Protect concurrent access.
+	// Protect concurrent access.  The replaced transactions are notified
+	// once the lock is released.
+	defer mp.notifyReplacedTxns()
 	mp.mtx.Lock()
 	defer mp.mtx.Unlock()
 	var err error
@@ -1391,7 +2273,7 @@ func (mp *TxPool) ProcessTransaction(tx *hcutil.Tx, allowOrphan, rateLimit, allo
 	// Potentially accept the transaction to the memory pool.
 	var missingParents []*chainhash.Hash
 	missingParents, err = mp.maybeAcceptTransaction(tx, true, rateLimit,
-		allowHighFees)
+		allowHighFees, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -1555,6 +2437,154 @@ func (mp *TxPool) RawMempoolVerbose(filterType *stake.TxType) map[string]*hcjson
 	return result
 }
 
+// mempoolEntry returns the getmempoolentry result for the passed transaction
+// in the pool, which includes the aggregated count, size and fees of the
+// transaction along with all of its ancestors and descendants in the pool.
+//
+// This function MUST be called with the mempool lock held (for reads).
+func (mp *TxPool) mempoolEntry(desc *TxDesc, bestHeight int64) *hcjson.GetMempoolEntryResult {
+	// Calculate the current priority based on the inputs to the
+	// transaction.  Use zero if one or more of the input transactions
+	// can't be found for some reason.
+	tx := desc.Tx
+	var currentPriority float64
+	utxos, err := mp.fetchInputUtxos(tx)
+	if err == nil {
+		currentPriority = CalcPriority(tx.MsgTx(), utxos, bestHeight+1)
+	}
+
+	size := int64(tx.MsgTx().SerializeSize())
+	entry := &hcjson.GetMempoolEntryResult{
+		Size:             int32(size),
+		Fee:              hcutil.Amount(desc.Fee).ToCoin(),
+		Time:             desc.Added.Unix(),
+		Height:           desc.Height,
+		StartingPriority: desc.StartingPriority,
+		CurrentPriority:  currentPriority,
+		Depends:          make([]string, 0, len(desc.parents)),
+		SpentBy:          make([]string, 0, len(desc.children)),
+	}
+
+	ancestors := make(map[chainhash.Hash]*TxDesc)
+	mp.addAncestors(desc, ancestors)
+	ancestorFees := desc.Fee
+	entry.AncestorCount = int64(len(ancestors)) + 1
+	entry.AncestorSize = size
+	for _, ancestor := range ancestors {
+		entry.AncestorSize += int64(ancestor.Tx.MsgTx().SerializeSize())
+		ancestorFees += ancestor.Fee
+	}
+	entry.AncestorFees = hcutil.Amount(ancestorFees).ToCoin()
+
+	descendants := make(map[chainhash.Hash]*TxDesc)
+	mp.addDescendants(desc, descendants)
+	descendantFees := desc.Fee
+	entry.DescendantCount = int64(len(descendants)) + 1
+	entry.DescendantSize = size
+	for _, descendant := range descendants {
+		entry.DescendantSize += int64(descendant.Tx.MsgTx().SerializeSize())
+		descendantFees += descendant.Fee
+	}
+	entry.DescendantFees = hcutil.Amount(descendantFees).ToCoin()
+
+	for parentHash := range desc.parents {
+		entry.Depends = append(entry.Depends, parentHash.String())
+	}
+	for childHash := range desc.children {
+		entry.SpentBy = append(entry.SpentBy, childHash.String())
+	}
+	sort.Strings(entry.Depends)
+	sort.Strings(entry.SpentBy)
+
+	return entry
+}
+
+// MempoolEntry returns the getmempoolentry result for the transaction with the
+// passed hash.  It returns an error when the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolEntry(txHash *chainhash.Hash) (*hcjson.GetMempoolEntryResult, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	desc, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction is not in the pool")
+	}
+	return mp.mempoolEntry(desc, mp.cfg.BestHeight()), nil
+}
+
+// relatedEntries returns the getmempoolentry results of the transactions the
+// passed function adds to a map for the transaction with the passed hash keyed
+// by their hashes.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) relatedEntries(txHash *chainhash.Hash, addRelated func(*TxDesc, map[chainhash.Hash]*TxDesc)) (map[string]*hcjson.GetMempoolEntryResult, error) {
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	desc, exists := mp.pool[*txHash]
+	if !exists {
+		return nil, fmt.Errorf("transaction is not in the pool")
+	}
+	related := make(map[chainhash.Hash]*TxDesc)
+	addRelated(desc, related)
+
+	bestHeight := mp.cfg.BestHeight()
+	result := make(map[string]*hcjson.GetMempoolEntryResult, len(related))
+	for hash, relatedDesc := range related {
+		result[hash.String()] = mp.mempoolEntry(relatedDesc, bestHeight)
+	}
+	return result, nil
+}
+
+// MempoolAncestors returns the getmempoolentry results of all ancestors in the
+// pool of the transaction with the passed hash keyed by their hashes.  It
+// returns an error when the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolAncestors(txHash *chainhash.Hash) (map[string]*hcjson.GetMempoolEntryResult, error) {
+	return mp.relatedEntries(txHash, mp.addAncestors)
+}
+
+// MempoolDescendants returns the getmempoolentry results of all descendants in
+// the pool of the transaction with the passed hash keyed by their hashes.  It
+// returns an error when the transaction is not in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MempoolDescendants(txHash *chainhash.Hash) (map[string]*hcjson.GetMempoolEntryResult, error) {
+	return mp.relatedEntries(txHash, mp.addDescendants)
+}
+
+// PoolSize returns the total serialized size in bytes of all transactions in
+// the main pool.  It does not include the orphan pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) PoolSize() int64 {
+	mp.mtx.RLock()
+	size := mp.poolSize
+	mp.mtx.RUnlock()
+
+	return size
+}
+
+// MinFeeRate returns the minimum fee rate in atoms/kB that regular transactions
+// and tickets currently must pay to be accepted into the pool.  This is the
+// greater of the configured minimum relay fee and the dynamic minimum fee rate
+// which is raised when transactions are evicted due to the pool size limit.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) MinFeeRate() hcutil.Amount {
+	mp.mtx.Lock()
+	minFeeRate := mp.minFeeRate()
+	mp.mtx.Unlock()
+
+	if minFeeRate < mp.cfg.Policy.MinRelayTxFee {
+		minFeeRate = mp.cfg.Policy.MinRelayTxFee
+	}
+	return minFeeRate
+}
+
 // LastUpdated returns the last time a transaction was added to or removed from
 // the main pool.  It does not include the orphan pool.
 //
@@ -6,6 +6,7 @@
 package mempool
 
 import (
+	"bytes"
 	"encoding/hex"
 	"fmt"
 	"reflect"
@@ -674,6 +675,834 @@ func TestOrphanEviction(t *testing.T) {
 	}
 }
 
+// TestPoolSizeEviction ensures that exceeding the maximum pool size evicts the
+// transactions with the lowest fee rate and raises the minimum fee rate
+// required for new transactions.
+func TestPoolSizeEviction(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// Split the spendable output provided by the harness into several
+	// outputs and add the splitting transaction to the fake chain so each
+	// of the outputs can be independently spent.
+	const numTxns = 6
+	splitTx, err := harness.CreateSignedTx(outputs, numTxns)
+	if err != nil {
+		t.Fatalf("unable to create split transaction: %v", err)
+	}
+	harness.chain.utxos.AddTxOuts(splitTx, harness.chain.BestHeight(),
+		wire.NullBlockIndex)
+
+	// createTx returns a signed transaction that spends the requested output
+	// of the splitting transaction while paying the provided fee.
+	createTx := func(outputNum uint32, fee int64) *hcutil.Tx {
+		spendable := txOutToSpendableOut(splitTx, outputNum)
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spendable.outPoint,
+			Sequence:         wire.MaxTxInSequenceNum,
+		})
+		tx.AddTxOut(&wire.TxOut{
+			PkScript: harness.payScript,
+			Value:    int64(spendable.amount) - fee,
+		})
+		sigScript, err := txscript.SignatureScript(tx, 0,
+			harness.payScript, txscript.SigHashAll, harness.signKey,
+			true)
+		if err != nil {
+			t.Fatalf("unable to sign transaction: %v", err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+		return hcutil.NewTx(tx)
+	}
+
+	// Limit the pool to the size of three transactions and add them with
+	// increasing fees.
+	txns := make([]*hcutil.Tx, 0, numTxns)
+	for i := uint32(0); i < 3; i++ {
+		tx := createTx(i, int64(i+1)*10000)
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction: %v", err)
+		}
+		txns = append(txns, tx)
+	}
+	harness.txPool.cfg.Policy.MaxPoolSize = harness.txPool.PoolSize()
+	minRelayTxFee := harness.txPool.cfg.Policy.MinRelayTxFee
+	if rate := harness.txPool.MinFeeRate(); rate != minRelayTxFee {
+		t.Fatalf("MinFeeRate: unexpected rate for pool with room -- "+
+			"got %v, want %v", rate, minRelayTxFee)
+	}
+
+	// Add a transaction that pays a higher fee than all of the others and
+	// ensure the transaction with the lowest fee rate is evicted.
+	tx := createTx(3, 40000)
+	_, err = harness.txPool.ProcessTransaction(tx, false, false, true)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid transaction: "+
+			"%v", err)
+	}
+	if harness.txPool.IsTransactionInPool(txns[0].Hash()) {
+		t.Fatal("IsTransactionInPool: true for evicted transaction")
+	}
+	for _, tx := range append(txns[1:], tx) {
+		if !harness.txPool.IsTransactionInPool(tx.Hash()) {
+			t.Fatalf("IsTransactionInPool: false for transaction %v",
+				tx.Hash())
+		}
+	}
+	if size := harness.txPool.PoolSize(); size >
+		harness.txPool.cfg.Policy.MaxPoolSize {
+		t.Fatalf("PoolSize: pool size %d is larger than the max %d", size,
+			harness.txPool.cfg.Policy.MaxPoolSize)
+	}
+
+	// Ensure the minimum fee rate was raised above the fee rate of the
+	// evicted transaction.
+	evictedRate := hcutil.Amount(10000 * 1000 /
+		int64(txns[0].MsgTx().SerializeSize()))
+	if rate := harness.txPool.MinFeeRate(); rate <= evictedRate {
+		t.Fatalf("MinFeeRate: rate %v was not raised above the evicted "+
+			"rate %v", rate, evictedRate)
+	}
+
+	// Ensure a transaction paying the same fee as the evicted one is now
+	// rejected due to the raised minimum fee rate.
+	tx = createTx(4, 10000)
+	_, err = harness.txPool.ProcessTransaction(tx, false, false, true)
+	if err == nil {
+		t.Fatal("ProcessTransaction: accepted transaction below the " +
+			"minimum fee rate")
+	}
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: unexpected reject code -- got %v, "+
+			"want %v", code, wire.RejectInsufficientFee)
+	}
+}
+
+// checkDescendantTotals ensures the descendant totals of every transaction in
+// the pool match the ones calculated from its descendants and that the eviction
+// heap is consistent with them.
+func checkDescendantTotals(t *testing.T, mp *TxPool) {
+	t.Helper()
+
+	mp.mtx.RLock()
+	defer mp.mtx.RUnlock()
+
+	if len(mp.evictionHeap) != len(mp.pool) {
+		t.Fatalf("eviction heap has %d transactions, pool has %d",
+			len(mp.evictionHeap), len(mp.pool))
+	}
+	for i, txDesc := range mp.evictionHeap {
+		if txDesc.evictionIndex != i {
+			t.Fatalf("transaction %v has eviction index %d, want %d",
+				txDesc.Tx.Hash(), txDesc.evictionIndex, i)
+		}
+		if i > 0 && evictsBefore(txDesc, mp.evictionHeap[(i-1)/2]) {
+			t.Fatalf("transaction %v is evicted before its parent in "+
+				"the eviction heap", txDesc.Tx.Hash())
+		}
+	}
+	for txHash, txDesc := range mp.pool {
+		descendants := make(map[chainhash.Hash]*TxDesc)
+		mp.addDescendants(txDesc, descendants)
+		fee := txDesc.Fee
+		size := int64(txDesc.Tx.MsgTx().SerializeSize())
+		for _, descendant := range descendants {
+			fee += descendant.Fee
+			size += int64(descendant.Tx.MsgTx().SerializeSize())
+		}
+		if txDesc.descendantFee != fee || txDesc.descendantSize != size {
+			t.Fatalf("transaction %v has descendant totals %d/%d, "+
+				"want %d/%d", txHash, txDesc.descendantFee,
+				txDesc.descendantSize, fee, size)
+		}
+	}
+}
+
+// TestPoolSizeEvictionPackages ensures transactions are evicted along with
+// their descendants based on the fee rate of the whole package, so a child
+// paying a high fee protects its parent, and that the descendant totals used to
+// order the evictions are kept up to date.
+func TestPoolSizeEvictionPackages(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	splitTx, err := harness.CreateSignedTx(outputs, 4)
+	if err != nil {
+		t.Fatalf("unable to create split transaction: %v", err)
+	}
+	harness.chain.utxos.AddTxOuts(splitTx, harness.chain.BestHeight(),
+		wire.NullBlockIndex)
+
+	// createTx returns a signed transaction that spends the passed output
+	// while paying the provided fee.
+	createTx := func(spendable spendableOutput, fee int64) *hcutil.Tx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spendable.outPoint,
+			Sequence:         wire.MaxTxInSequenceNum,
+		})
+		tx.AddTxOut(&wire.TxOut{
+			PkScript: harness.payScript,
+			Value:    int64(spendable.amount) - fee,
+		})
+		sigScript, err := txscript.SignatureScript(tx, 0,
+			harness.payScript, txscript.SigHashAll, harness.signKey,
+			true)
+		if err != nil {
+			t.Fatalf("unable to sign transaction: %v", err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+		return hcutil.NewTx(tx)
+	}
+	accept := func(tx *hcutil.Tx) {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+		checkDescendantTotals(t, harness.txPool)
+	}
+
+	// Add a parent paying a low fee with a child paying a high fee along
+	// with an unrelated transaction paying a medium fee.
+	parent := createTx(txOutToSpendableOut(splitTx, 0), 1000)
+	child := createTx(txOutToSpendableOut(parent, 0), 50000)
+	unrelated := createTx(txOutToSpendableOut(splitTx, 1), 10000)
+	for _, tx := range []*hcutil.Tx{parent, child, unrelated} {
+		accept(tx)
+	}
+
+	// Limit the pool to its current size and ensure a new transaction
+	// evicts the unrelated transaction instead of the low fee parent since
+	// the package of the parent pays a higher fee rate.
+	harness.txPool.cfg.Policy.MaxPoolSize = harness.txPool.PoolSize()
+	accept(createTx(txOutToSpendableOut(splitTx, 2), 30000))
+	if harness.txPool.IsTransactionInPool(unrelated.Hash()) {
+		t.Fatal("IsTransactionInPool: true for evicted transaction")
+	}
+	for _, tx := range []*hcutil.Tx{parent, child} {
+		if !harness.txPool.IsTransactionInPool(tx.Hash()) {
+			t.Fatalf("IsTransactionInPool: false for transaction %v",
+				tx.Hash())
+		}
+	}
+
+	// Ensure removing the child as though it was mined updates the totals
+	// of the parent.
+	harness.txPool.RemoveTransaction(child, false)
+	checkDescendantTotals(t, harness.txPool)
+}
+
+// TestReplaceByFee ensures regular transactions which conflict with
+// transactions in the pool that signal replaceability replace them only when
+// they satisfy the replacement policy and that all other conflicts are still
+// rejected.
+func TestReplaceByFee(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	var replaced []chainhash.Hash
+	harness.txPool.cfg.TxReplaced = func(tx, replacement *hcutil.Tx) {
+		// The callback is invoked without the pool lock held, so it
+		// may query the pool.
+		if !harness.txPool.IsTransactionInPool(replacement.Hash()) {
+			t.Errorf("TxReplaced: replacement %v is not in the pool",
+				replacement.Hash())
+		}
+		replaced = append(replaced, *tx.Hash())
+	}
+
+	// Split the spendable output provided by the harness into several
+	// outputs and add the splitting transaction to the fake chain so each
+	// of the outputs can be independently spent.
+	splitTx, err := harness.CreateSignedTx(outputs, 2)
+	if err != nil {
+		t.Fatalf("unable to create split transaction: %v", err)
+	}
+	harness.chain.utxos.AddTxOuts(splitTx, harness.chain.BestHeight(),
+		wire.NullBlockIndex)
+
+	// createTx returns a signed transaction that spends the provided output
+	// with the provided sequence number while paying the provided fee.
+	createTx := func(spendable spendableOutput, sequence uint32, fee int64) *hcutil.Tx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spendable.outPoint,
+			Sequence:         sequence,
+		})
+		tx.AddTxOut(&wire.TxOut{
+			PkScript: harness.payScript,
+			Value:    int64(spendable.amount) - fee,
+		})
+		sigScript, err := txscript.SignatureScript(tx, 0,
+			harness.payScript, txscript.SigHashAll, harness.signKey,
+			true)
+		if err != nil {
+			t.Fatalf("unable to sign transaction: %v", err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+		return hcutil.NewTx(tx)
+	}
+	mustAccept := func(tx *hcutil.Tx) {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+	}
+	mustReject := func(tx *hcutil.Tx, wantCode wire.RejectCode) {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err == nil {
+			t.Fatalf("ProcessTransaction: accepted invalid replacement "+
+				"transaction %v", tx.Hash())
+		}
+		if code, _ := extractRejectCode(err); code != wantCode {
+			t.Fatalf("ProcessTransaction: unexpected reject code -- "+
+				"got %v, want %v", code, wantCode)
+		}
+	}
+
+	// Ensure a transaction which does not signal replaceability can't be
+	// replaced regardless of the fee paid by the conflicting transaction.
+	output0 := txOutToSpendableOut(splitTx, 0)
+	final := createTx(output0, wire.MaxTxInSequenceNum, 10000)
+	mustAccept(final)
+	mustReject(createTx(output0, MaxRBFSequence, 100000),
+		wire.RejectDuplicate)
+
+	// Add a replaceable transaction along with a child that spends it.
+	output1 := txOutToSpendableOut(splitTx, 1)
+	parent := createTx(output1, MaxRBFSequence, 10000)
+	mustAccept(parent)
+	child := createTx(txOutToSpendableOut(parent, 0), wire.MaxTxInSequenceNum,
+		10000)
+	mustAccept(child)
+
+	// Ensure a replacement which pays a higher fee rate than both
+	// transactions, but not enough to cover their combined fees, is
+	// rejected.
+	mustReject(createTx(output1, MaxRBFSequence, 15000),
+		wire.RejectInsufficientFee)
+
+	// Ensure a replacement paying enough fees replaces both the conflicting
+	// transaction and its child.
+	replacement := createTx(output1, MaxRBFSequence, 40000)
+	mustAccept(replacement)
+	for _, tx := range []*hcutil.Tx{parent, child} {
+		if harness.txPool.IsTransactionInPool(tx.Hash()) {
+			t.Fatalf("IsTransactionInPool: true for replaced "+
+				"transaction %v", tx.Hash())
+		}
+	}
+	if len(replaced) != 2 {
+		t.Fatalf("TxReplaced: got %d notifications, want 2",
+			len(replaced))
+	}
+
+	// Ensure a replacement which does not pay a higher fee rate than the
+	// transaction it conflicts with is rejected.
+	mustReject(createTx(output1, MaxRBFSequence, 39000),
+		wire.RejectInsufficientFee)
+
+	// Ensure replacements are rejected when disabled by the policy.
+	harness.txPool.cfg.Policy.RejectReplacement = true
+	mustReject(createTx(output1, MaxRBFSequence, 100000),
+		wire.RejectDuplicate)
+	if !harness.txPool.IsTransactionInPool(replacement.Hash()) {
+		t.Fatal("IsTransactionInPool: false for replacement transaction")
+	}
+}
+
+// TestReplaceByFeeFullPool ensures a replacement transaction which would not
+// remain in a full pool is rejected without evicting the transaction it
+// conflicts with, while one paying a high enough fee rate makes room for
+// itself by evicting the packages with a lower fee rate.
+func TestReplaceByFeeFullPool(t *testing.T) {
+	t.Parallel()
+
+	harness, outputs, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	var numReplaced int
+	harness.txPool.cfg.TxReplaced = func(tx, replacement *hcutil.Tx) {
+		numReplaced++
+	}
+	splitTx, err := harness.CreateSignedTx(outputs, 3)
+	if err != nil {
+		t.Fatalf("unable to create split transaction: %v", err)
+	}
+	harness.chain.utxos.AddTxOuts(splitTx, harness.chain.BestHeight(),
+		wire.NullBlockIndex)
+
+	// createTx returns a signed replaceable transaction that spends the
+	// provided output into the provided number of outputs while paying the
+	// provided fee.
+	createTx := func(spendable spendableOutput, numOutputs int, fee int64) *hcutil.Tx {
+		tx := wire.NewMsgTx()
+		tx.AddTxIn(&wire.TxIn{
+			PreviousOutPoint: spendable.outPoint,
+			Sequence:         MaxRBFSequence,
+		})
+		amount := (int64(spendable.amount) - fee) / int64(numOutputs)
+		for i := 0; i < numOutputs; i++ {
+			tx.AddTxOut(&wire.TxOut{
+				PkScript: harness.payScript,
+				Value:    amount,
+			})
+		}
+		sigScript, err := txscript.SignatureScript(tx, 0,
+			harness.payScript, txscript.SigHashAll, harness.signKey,
+			true)
+		if err != nil {
+			t.Fatalf("unable to sign transaction: %v", err)
+		}
+		tx.TxIn[0].SignatureScript = sigScript
+		return hcutil.NewTx(tx)
+	}
+	inPool := func(txns ...*hcutil.Tx) int {
+		var n int
+		for _, tx := range txns {
+			if harness.txPool.IsTransactionInPool(tx.Hash()) {
+				n++
+			}
+		}
+		return n
+	}
+
+	// Fill the pool with a replaceable transaction and two transactions
+	// paying a high fee rate and limit the pool to the size it would have
+	// after replacing the first transaction with a larger one and evicting
+	// one of the others.
+	original := createTx(txOutToSpendableOut(splitTx, 0), 1, 10000)
+	high1 := createTx(txOutToSpendableOut(splitTx, 1), 1, 100000)
+	high2 := createTx(txOutToSpendableOut(splitTx, 2), 1, 100000)
+	for _, tx := range []*hcutil.Tx{original, high1, high2} {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+	}
+	output0 := txOutToSpendableOut(splitTx, 0)
+	lowReplacement := createTx(output0, 20, 90000)
+	replacementSize := int64(lowReplacement.MsgTx().SerializeSize())
+	harness.txPool.cfg.Policy.MaxPoolSize = harness.txPool.PoolSize() +
+		replacementSize - int64(original.MsgTx().SerializeSize()) -
+		int64(high1.MsgTx().SerializeSize())
+
+	// Ensure a replacement which pays a higher fee rate than the
+	// transaction it replaces, but a lower one than the rest of the pool,
+	// is rejected without evicting anything.
+	_, err = harness.txPool.ProcessTransaction(lowReplacement, false, false,
+		true)
+	if err == nil {
+		t.Fatal("ProcessTransaction: accepted replacement which does not " +
+			"fit into the full pool")
+	}
+	if code, _ := extractRejectCode(err); code != wire.RejectInsufficientFee {
+		t.Fatalf("ProcessTransaction: unexpected reject code -- got %v, "+
+			"want %v", code, wire.RejectInsufficientFee)
+	}
+	if n := inPool(original, high1, high2); n != 3 || numReplaced != 0 {
+		t.Fatalf("unexpected pool after rejected replacement -- %d of 3 "+
+			"transactions remain, %d replaced", n, numReplaced)
+	}
+	checkDescendantTotals(t, harness.txPool)
+
+	// Ensure a replacement paying a higher fee rate than the rest of the
+	// pool evicts one of the other transactions to make room for itself.
+	highReplacement := createTx(output0, 20, 600000)
+	_, err = harness.txPool.ProcessTransaction(highReplacement, false, false,
+		true)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid replacement: "+
+			"%v", err)
+	}
+	if inPool(highReplacement) != 1 || inPool(original) != 0 ||
+		inPool(high1, high2) != 1 || numReplaced != 1 {
+
+		t.Fatalf("unexpected pool after accepted replacement -- "+
+			"replacement in pool %v, original in pool %v, %d of 2 "+
+			"high fee transactions in pool, %d replaced",
+			inPool(highReplacement) == 1, inPool(original) == 1,
+			inPool(high1, high2), numReplaced)
+	}
+	checkDescendantTotals(t, harness.txPool)
+}
+
+// TestDumpLoad ensures the transactions in the main pool and the orphan pool
+// survive being dumped and loaded into a fresh pool while block vote metadata
+// is only restored for the votes which are accepted again.
+func TestDumpLoad(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+
+	// Add the first two transactions of a chain to the main pool and the
+	// last one to the orphan pool.
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 4)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range []*hcutil.Tx{chainedTxns[0], chainedTxns[1],
+		chainedTxns[3]} {
+
+		_, err := harness.txPool.ProcessTransaction(tx, true, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+	}
+	blockHash := chainhash.Hash{0x01}
+	vote := VoteTx{
+		SsgenHash: chainhash.Hash{0x02},
+		SstxHash:  chainhash.Hash{0x03},
+		Vote:      true,
+		VoteBits:  hcutil.BlockValid | 0x0004,
+	}
+	harness.txPool.votes[blockHash] = []VoteTx{vote}
+
+	var buf bytes.Buffer
+	if err := harness.txPool.Dump(&buf); err != nil {
+		t.Fatalf("Dump: unexpected error: %v", err)
+	}
+	dump := buf.Bytes()
+
+	// Ensure the dump is rejected when it is for a different version.
+	pool := New(&harness.txPool.cfg)
+	badVersion := append([]byte(nil), dump...)
+	badVersion[0]++
+	if _, err := pool.Load(bytes.NewReader(badVersion), nil); err == nil {
+		t.Fatal("Load: did not reject dump with unsupported version")
+	}
+
+	// Ensure the transactions are restored into a fresh pool.
+	stats, err := pool.Load(bytes.NewReader(dump), nil)
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if len(stats.Accepted) != 2 || stats.Orphans != 1 || stats.Rejected != 0 {
+		t.Fatalf("Load: unexpected stats - got %d accepted, %d orphans, "+
+			"%d rejected - want 2 accepted, 1 orphan, 0 rejected",
+			len(stats.Accepted), stats.Orphans, stats.Rejected)
+	}
+	for _, tx := range chainedTxns[:2] {
+		if !pool.IsTransactionInPool(tx.Hash()) {
+			t.Fatalf("IsTransactionInPool: false for loaded tx %v",
+				tx.Hash())
+		}
+	}
+	if !pool.IsOrphanInPool(chainedTxns[3].Hash()) {
+		t.Fatalf("IsOrphanInPool: false for loaded orphan %v",
+			chainedTxns[3].Hash())
+	}
+
+	// Ensure the vote metadata of the original pool is not restored since
+	// the vote it describes is not part of the dump.
+	gotVotes := pool.VotesForBlocks([]chainhash.Hash{blockHash})
+	if len(gotVotes[0]) != 0 {
+		t.Fatalf("VotesForBlocks: unexpected votes %v", gotVotes[0])
+	}
+
+	// Ensure loading the same dump again rejects the known transactions
+	// while keeping the orphan.
+	stats, err = pool.Load(bytes.NewReader(dump), nil)
+	if err != nil {
+		t.Fatalf("Load: unexpected error: %v", err)
+	}
+	if len(stats.Accepted) != 0 || stats.Rejected != 3 {
+		t.Fatalf("Load: unexpected stats on reload - got %d accepted, "+
+			"%d rejected - want 0 accepted, 3 rejected",
+			len(stats.Accepted), stats.Rejected)
+	}
+}
+
+// TestChainLimits ensures the ancestor and descendant limits of the pool are
+// enforced and the related entries of the transactions in the pool are
+// reported as expected.
+func TestChainLimits(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	harness.txPool.cfg.Policy.MaxAncestorCount = 3
+	harness.txPool.cfg.Policy.MaxDescendantCount = 3
+
+	// Ensure the first three transactions of a chain are accepted while the
+	// fourth one is rejected since it would have three ancestors.
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 4)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	for _, tx := range chainedTxns[:3] {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+	}
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[3], false, false,
+		true)
+	if err == nil {
+		t.Fatal("ProcessTransaction: accepted transaction exceeding the " +
+			"ancestor limit")
+	}
+
+	// Ensure the entries report the chain.
+	entry, err := harness.txPool.MempoolEntry(chainedTxns[1].Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	if entry.AncestorCount != 2 || entry.DescendantCount != 2 {
+		t.Fatalf("MempoolEntry: unexpected counts - got %d ancestors, %d "+
+			"descendants - want 2 ancestors, 2 descendants",
+			entry.AncestorCount, entry.DescendantCount)
+	}
+	if len(entry.Depends) != 1 ||
+		entry.Depends[0] != chainedTxns[0].Hash().String() {
+
+		t.Fatalf("MempoolEntry: unexpected depends %v", entry.Depends)
+	}
+	if len(entry.SpentBy) != 1 ||
+		entry.SpentBy[0] != chainedTxns[2].Hash().String() {
+
+		t.Fatalf("MempoolEntry: unexpected spentby %v", entry.SpentBy)
+	}
+	ancestors, err := harness.txPool.MempoolAncestors(chainedTxns[2].Hash())
+	if err != nil {
+		t.Fatalf("MempoolAncestors: unexpected error: %v", err)
+	}
+	if len(ancestors) != 2 {
+		t.Fatalf("MempoolAncestors: got %d ancestors, want 2",
+			len(ancestors))
+	}
+	descendants, err := harness.txPool.MempoolDescendants(
+		chainedTxns[2].Hash())
+	if err != nil {
+		t.Fatalf("MempoolDescendants: unexpected error: %v", err)
+	}
+	if len(descendants) != 0 {
+		t.Fatalf("MempoolDescendants: got %d descendants, want 0",
+			len(descendants))
+	}
+	if _, err := harness.txPool.MempoolEntry(chainedTxns[3].Hash()); err == nil {
+		t.Fatal("MempoolEntry: did not fail for transaction not in pool")
+	}
+
+	// Ensure a third child of a parent is rejected since it would give the
+	// parent three descendants.
+	harness, spendableOuts, err = newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	harness.txPool.cfg.Policy.MaxDescendantCount = 3
+	splitTx, err := harness.CreateSignedTx(spendableOuts, 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+	_, err = harness.txPool.ProcessTransaction(splitTx, false, false, true)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid transaction "+
+			"%v: %v", splitTx.Hash(), err)
+	}
+	for i := uint32(0); i < 3; i++ {
+		out := txOutToSpendableOut(splitTx, i)
+		tx, err := harness.CreateSignedTx([]spendableOutput{out}, 1)
+		if err != nil {
+			t.Fatalf("unable to create transaction: %v", err)
+		}
+		_, err = harness.txPool.ProcessTransaction(tx, false, false, true)
+		if i < 2 && err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+		if i == 2 && err == nil {
+			t.Fatal("ProcessTransaction: accepted transaction " +
+				"exceeding the descendant limit")
+		}
+	}
+}
+
+// TestReaddedParent ensures a transaction which is added back to the pool
+// while transactions spending its outputs remain in the pool, such as when the
+// block it was mined in is disapproved, is linked with them as their parent.
+func TestReaddedParent(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+
+	// Add the first transaction of the chain to the fake chain as though
+	// it was mined and add the rest of the chain to the pool.
+	parent := chainedTxns[0]
+	harness.chain.utxos.AddTxOuts(parent, harness.chain.BestHeight(),
+		wire.NullBlockIndex)
+	for _, tx := range chainedTxns[1:] {
+		_, err := harness.txPool.ProcessTransaction(tx, false, false, true)
+		if err != nil {
+			t.Fatalf("ProcessTransaction: failed to accept valid "+
+				"transaction %v: %v", tx.Hash(), err)
+		}
+	}
+
+	// Remove the first transaction from the fake chain as though the block
+	// it was mined in was disapproved and add it back to the pool.
+	delete(harness.chain.utxos.Entries(), *parent.Hash())
+	_, err = harness.txPool.MaybeAcceptTransaction(parent, false, true)
+	if err != nil {
+		t.Fatalf("MaybeAcceptTransaction: failed to accept valid "+
+			"transaction %v: %v", parent.Hash(), err)
+	}
+
+	// Ensure the transactions already in the pool are reported as the
+	// descendants of the re-added transaction.
+	entry, err := harness.txPool.MempoolEntry(parent.Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	if entry.DescendantCount != 3 || len(entry.SpentBy) != 1 ||
+		entry.SpentBy[0] != chainedTxns[1].Hash().String() {
+
+		t.Fatalf("MempoolEntry: unexpected descendants - got count %d, "+
+			"spentby %v", entry.DescendantCount, entry.SpentBy)
+	}
+	entry, err = harness.txPool.MempoolEntry(chainedTxns[1].Hash())
+	if err != nil {
+		t.Fatalf("MempoolEntry: unexpected error: %v", err)
+	}
+	if len(entry.Depends) != 1 || entry.Depends[0] != parent.Hash().String() {
+		t.Fatalf("MempoolEntry: unexpected depends %v", entry.Depends)
+	}
+	descendants, err := harness.txPool.MempoolDescendants(parent.Hash())
+	if err != nil {
+		t.Fatalf("MempoolDescendants: unexpected error: %v", err)
+	}
+	if len(descendants) != 2 {
+		t.Fatalf("MempoolDescendants: got %d descendants, want 2",
+			len(descendants))
+	}
+
+	checkDescendantTotals(t, harness.txPool)
+
+	// Ensure removing the re-added transaction along with its redeemers
+	// removes the whole chain.
+	harness.txPool.RemoveTransaction(parent, true)
+	if count := harness.txPool.Count(); count != 0 {
+		t.Fatalf("Count: got %d transactions after removing the chain, "+
+			"want 0", count)
+	}
+}
+
+// TestTestAcceptTransactions ensures testing whether transactions would be
+// accepted reports the expected results for a package of transactions without
+// modifying the pool.
+func TestTestAcceptTransactions(t *testing.T) {
+	t.Parallel()
+
+	harness, spendableOuts, err := newPoolHarness(&chaincfg.MainNetParams)
+	if err != nil {
+		t.Fatalf("unable to create test pool: %v", err)
+	}
+	chainedTxns, err := harness.CreateTxChain(spendableOuts[0], 3)
+	if err != nil {
+		t.Fatalf("unable to create transaction chain: %v", err)
+	}
+	doubleSpend, err := harness.CreateSignedTx([]spendableOutput{
+		txOutToSpendableOut(chainedTxns[0], 0)}, 2)
+	if err != nil {
+		t.Fatalf("unable to create transaction: %v", err)
+	}
+
+	tests := []struct {
+		name    string
+		txns    []*hcutil.Tx
+		allowed []bool
+	}{
+		{
+			name:    "chain spending earlier package transactions",
+			txns:    chainedTxns,
+			allowed: []bool{true, true, true},
+		},
+		{
+			name:    "missing parent",
+			txns:    chainedTxns[1:],
+			allowed: []bool{false, false},
+		},
+		{
+			name:    "double spend within package",
+			txns:    []*hcutil.Tx{chainedTxns[0], chainedTxns[1], doubleSpend},
+			allowed: []bool{true, true, false},
+		},
+		{
+			name:    "duplicate within package",
+			txns:    []*hcutil.Tx{chainedTxns[0], chainedTxns[0]},
+			allowed: []bool{true, false},
+		},
+	}
+	for _, test := range tests {
+		results := harness.txPool.TestAcceptTransactions(test.txns, false)
+		if len(results) != len(test.txns) {
+			t.Fatalf("%s: got %d results, want %d", test.name,
+				len(results), len(test.txns))
+		}
+		for i, result := range results {
+			if result.Tx != test.txns[i] {
+				t.Fatalf("%s: result %d is for transaction %v, want %v",
+					test.name, i, result.Tx.Hash(), test.txns[i].Hash())
+			}
+			if allowed := result.Err == nil; allowed != test.allowed[i] {
+				t.Fatalf("%s: transaction %d allowed %v, want %v "+
+					"(err: %v)", test.name, i, allowed,
+					test.allowed[i], result.Err)
+			}
+		}
+		if count := harness.txPool.Count(); count != 0 {
+			t.Fatalf("%s: pool has %d transactions, want 0", test.name,
+				count)
+		}
+	}
+
+	// Ensure a transaction spending an output of a transaction in the pool
+	// is allowed while the pool transaction itself is reported as a
+	// duplicate.
+	_, err = harness.txPool.ProcessTransaction(chainedTxns[0], false, false,
+		true)
+	if err != nil {
+		t.Fatalf("ProcessTransaction: failed to accept valid transaction "+
+			"%v: %v", chainedTxns[0].Hash(), err)
+	}
+	results := harness.txPool.TestAcceptTransactions(chainedTxns[:2], false)
+	if results[0].Err == nil || results[1].Err != nil {
+		t.Fatalf("TestAcceptTransactions: unexpected results %v, %v",
+			results[0].Err, results[1].Err)
+	}
+}
+
 // add test for tx lock 
 func TestTxLockPool(t *testing.T) {
 	t.Parallel()
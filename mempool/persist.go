@@ -0,0 +1,245 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+const (
+	// dumpVersion is the current version of the mempool dump format.
+	dumpVersion = 3
+
+	// maxDumpEntries is the maximum number of entries allowed in any of the
+	// sections of a mempool dump.  It prevents a corrupt dump from causing
+	// huge allocations.
+	maxDumpEntries = 1 << 20
+)
+
+// errLoadInterrupted is returned by Load when it is interrupted before all of
+// the transactions in the dump were processed.
+var errLoadInterrupted = errors.New("mempool load interrupted")
+
+// -----------------------------------------------------------------------------
+// A mempool dump houses the transactions of the main pool and the orphan pool
+// so they can be restored after a restart.  The block vote metadata is not
+// stored since it is rebuilt from the votes which are accepted to the main pool
+// again.  All integers are encoded little endian.
+//
+// The serialized format is:
+//
+//   <version><network><num txns><txns><num orphans><orphans>
+//
+//   Field              Type              Size
+//   version            uint32            4 bytes
+//   network            uint32            4 bytes
+//   num txns           VarInt            variable
+//   txns               []wire.MsgTx      variable
+//   num orphans        VarInt            variable
+//   orphans            []wire.MsgTx      variable
+//
+// The transactions of the main pool are stored in the order they were added to
+// the pool so every transaction comes after the transactions it depends on.
+// -----------------------------------------------------------------------------
+
+// LoadStats describes the outcome of loading a mempool dump.
+type LoadStats struct {
+	// Accepted holds the transactions that were accepted to the main pool
+	// in the order they were accepted.
+	Accepted []*hcutil.Tx
+
+	// Orphans is the number of transactions that remain in the orphan pool
+	// after the load.
+	Orphans int
+
+	// Rejected is the number of transactions that were rejected, for
+	// example because they were mined or became invalid while the node was
+	// not running.
+	Rejected int
+}
+
+// txnsByAddedTime implements sort.Interface to sort a slice of transaction
+// descriptors by the time they were added to the pool.
+type txnsByAddedTime []*TxDesc
+
+// Len returns the number of elements in the slice.  It is part of the
+// sort.Interface implementation.
+func (s txnsByAddedTime) Len() int {
+	return len(s)
+}
+
+// Swap swaps the elements at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (s txnsByAddedTime) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the transaction with index i should sort before the
+// transaction with index j.  It is part of the sort.Interface implementation.
+func (s txnsByAddedTime) Less(i, j int) bool {
+	return s[i].Added.Before(s[j].Added)
+}
+
+// writeDumpTxns writes the passed transactions prefixed by their count.
+func writeDumpTxns(w io.Writer, txns []*hcutil.Tx) error {
+	err := wire.WriteVarInt(w, 0, uint64(len(txns)))
+	if err != nil {
+		return err
+	}
+	for _, tx := range txns {
+		if err := tx.MsgTx().Serialize(w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readDumpCount reads an entry count and ensures it is sane.
+func readDumpCount(r io.Reader) (int, error) {
+	count, err := wire.ReadVarInt(r, 0)
+	if err != nil {
+		return 0, err
+	}
+	if count > maxDumpEntries {
+		return 0, fmt.Errorf("mempool dump entry count %d exceeds the "+
+			"maximum of %d", count, maxDumpEntries)
+	}
+	return int(count), nil
+}
+
+// readDumpTxns reads transactions prefixed by their count.
+func readDumpTxns(r io.Reader) ([]*hcutil.Tx, error) {
+	count, err := readDumpCount(r)
+	if err != nil {
+		return nil, err
+	}
+	txns := make([]*hcutil.Tx, 0, count)
+	for i := 0; i < count; i++ {
+		var msgTx wire.MsgTx
+		if err := msgTx.Deserialize(r); err != nil {
+			return nil, err
+		}
+		txns = append(txns, hcutil.NewTx(&msgTx))
+	}
+	return txns, nil
+}
+
+// Dump writes the transactions in the main pool and the orphan pool to w so they
+// can be restored with Load.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Dump(w io.Writer) error {
+	mp.mtx.RLock()
+	descs := make([]*TxDesc, 0, len(mp.pool))
+	for _, desc := range mp.pool {
+		descs = append(descs, desc)
+	}
+	orphans := make([]*hcutil.Tx, 0, len(mp.orphans))
+	for _, tx := range mp.orphans {
+		orphans = append(orphans, tx)
+	}
+	mp.mtx.RUnlock()
+
+	var header [8]byte
+	binary.LittleEndian.PutUint32(header[0:4], dumpVersion)
+	binary.LittleEndian.PutUint32(header[4:8], uint32(mp.cfg.ChainParams.Net))
+	if _, err := w.Write(header[:]); err != nil {
+		return err
+	}
+
+	sort.Sort(txnsByAddedTime(descs))
+	txns := make([]*hcutil.Tx, 0, len(descs))
+	for _, desc := range descs {
+		txns = append(txns, desc.Tx)
+	}
+	if err := writeDumpTxns(w, txns); err != nil {
+		return err
+	}
+	if err := writeDumpTxns(w, orphans); err != nil {
+		return err
+	}
+
+	log.Debugf("Dumped %d transactions and %d orphans", len(txns),
+		len(orphans))
+	return nil
+}
+
+// Load reads a mempool dump created by Dump from r.  Every transaction is
+// revalidated through ProcessTransaction, so transactions which were mined or
+// became invalid in the mean time are rejected, and the block vote metadata is
+// rebuilt from the votes which are accepted.  The dump is read entirely before
+// any of it is applied, so nothing is changed when the dump is malformed.
+//
+// The load stops early with an error when the passed interrupt channel is
+// closed.  The interrupt channel may be nil.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) Load(r io.Reader, interrupt <-chan struct{}) (*LoadStats, error) {
+	var header [8]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	version := binary.LittleEndian.Uint32(header[0:4])
+	if version != dumpVersion {
+		return nil, fmt.Errorf("unsupported mempool dump version %d",
+			version)
+	}
+	net := wire.CurrencyNet(binary.LittleEndian.Uint32(header[4:8]))
+	if net != mp.cfg.ChainParams.Net {
+		return nil, fmt.Errorf("mempool dump is for network %v instead "+
+			"of %v", net, mp.cfg.ChainParams.Net)
+	}
+
+	txns, err := readDumpTxns(r)
+	if err != nil {
+		return nil, err
+	}
+	orphans, err := readDumpTxns(r)
+	if err != nil {
+		return nil, err
+	}
+
+	// Revalidate the transactions.  Orphans are allowed since the main pool
+	// transactions may depend on the orphans which are processed after
+	// them.  Transactions which were added as orphans may still be accepted
+	// to the main pool once their parents are processed, so they are only
+	// counted as orphans when they remain in the orphan pool.
+	stats := new(LoadStats)
+	var orphaned []*hcutil.Tx
+	for _, tx := range append(txns, orphans...) {
+		select {
+		case <-interrupt:
+			return stats, errLoadInterrupted
+		default:
+		}
+
+		accepted, err := mp.ProcessTransaction(tx, true, false, true)
+		if err != nil {
+			log.Debugf("Rejected transaction %v from mempool dump: %v",
+				tx.Hash(), err)
+			stats.Rejected++
+			continue
+		}
+		if len(accepted) == 0 {
+			orphaned = append(orphaned, tx)
+			continue
+		}
+		stats.Accepted = append(stats.Accepted, accepted...)
+	}
+	for _, tx := range orphaned {
+		if mp.IsOrphanInPool(tx.Hash()) {
+			stats.Orphans++
+		}
+	}
+
+	return stats, nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package mempool
+
+import (
+	"fmt"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestAcceptResult describes whether a transaction passed to
+// TestAcceptTransactions would be accepted to the pool.
+type TestAcceptResult struct {
+	// Tx is the transaction which was tested.
+	Tx *hcutil.Tx
+
+	// Err is the reason the transaction would be rejected or nil when it
+	// would be accepted.
+	Err error
+}
+
+// testAcceptPackage houses the transactions of a package which would be
+// accepted to the pool along with the outputs they spend.  It allows the
+// transactions of a package to depend on each other while they are validated
+// without modifying the pool.
+//
+// All of the methods may be called on a nil package in which case they do
+// nothing.
+type testAcceptPackage struct {
+	txns  map[chainhash.Hash]*hcutil.Tx
+	spent map[wire.OutPoint]*chainhash.Hash
+}
+
+// newTestAcceptPackage returns a new empty package.
+func newTestAcceptPackage() *testAcceptPackage {
+	return &testAcceptPackage{
+		txns:  make(map[chainhash.Hash]*hcutil.Tx),
+		spent: make(map[wire.OutPoint]*chainhash.Hash),
+	}
+}
+
+// haveTransaction returns whether the passed transaction hash is already part
+// of the package.
+func (pkg *testAcceptPackage) haveTransaction(hash *chainhash.Hash) bool {
+	if pkg == nil {
+		return false
+	}
+	_, exists := pkg.txns[*hash]
+	return exists
+}
+
+// checkDoubleSpend ensures the passed transaction does not spend any of the
+// outputs already spent by the transactions of the package.
+func (pkg *testAcceptPackage) checkDoubleSpend(tx *hcutil.Tx, txType stake.TxType) error {
+	if pkg == nil {
+		return nil
+	}
+	for i, txIn := range tx.MsgTx().TxIn {
+		if i == 0 && txType == stake.TxTypeSSGen {
+			continue
+		}
+		if spender, exists := pkg.spent[txIn.PreviousOutPoint]; exists {
+			str := fmt.Sprintf("output %v already spent by "+
+				"transaction %v in the package",
+				txIn.PreviousOutPoint, spender)
+			return txRuleError(wire.RejectDuplicate, str)
+		}
+	}
+	return nil
+}
+
+// addInputUtxos populates the inputs of the passed view which are missing with
+// the outputs of the transactions of the package.
+func (pkg *testAcceptPackage) addInputUtxos(utxoView *blockchain.UtxoViewpoint) {
+	if pkg == nil {
+		return
+	}
+	for originHash, entry := range utxoView.Entries() {
+		if entry != nil && !entry.IsFullySpent() {
+			continue
+		}
+		if tx, exists := pkg.txns[originHash]; exists {
+			utxoView.AddTxOuts(tx, mempoolHeight, wire.NullBlockIndex)
+		}
+	}
+}
+
+// addTransaction adds the passed transaction which would be accepted to the
+// pool to the package.
+func (pkg *testAcceptPackage) addTransaction(tx *hcutil.Tx, txType stake.TxType) {
+	txHash := tx.Hash()
+	pkg.txns[*txHash] = tx
+	for i, txIn := range tx.MsgTx().TxIn {
+		if i == 0 && txType == stake.TxTypeSSGen {
+			continue
+		}
+		pkg.spent[txIn.PreviousOutPoint] = txHash
+	}
+}
+
+// TestAcceptTransactions runs the passed transactions through the same
+// standardness, fee, stake and script checks as MaybeAcceptTransaction without
+// modifying the pool, relaying them or adding them to the orphan pool.  The
+// transactions are tested in order and may spend the outputs of the earlier
+// transactions which would be accepted.  Transactions with missing inputs are
+// rejected.
+//
+// The ancestor and descendant limits only consider the transactions which are
+// already in the pool.
+//
+// This function is safe for concurrent access.
+func (mp *TxPool) TestAcceptTransactions(txns []*hcutil.Tx, allowHighFees bool) []TestAcceptResult {
+	// Protect concurrent access.
+	mp.mtx.Lock()
+	defer mp.mtx.Unlock()
+
+	pkg := newTestAcceptPackage()
+	results := make([]TestAcceptResult, 0, len(txns))
+	for _, tx := range txns {
+		missingParents, err := mp.maybeAcceptTransaction(tx, true, false,
+			allowHighFees, pkg)
+		if err == nil && len(missingParents) > 0 {
+			str := fmt.Sprintf("transaction %v references outputs of "+
+				"unknown or fully-spent transaction %v", tx.Hash(),
+				missingParents[0])
+			err = txRuleError(wire.RejectDuplicate, str)
+		}
+		results = append(results, TestAcceptResult{Tx: tx, Err: err})
+	}
+	return results
+}
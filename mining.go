@@ -57,7 +57,12 @@ type txPrioItem struct {
 	tx       *hcutil.Tx
 	txType   stake.TxType
 	fee      int64
+	size     int64
 	priority float64
+
+	// feePerKB is the fee per kilobyte of the transaction.  For
+	// transactions which are selected as a package along with their
+	// ancestors, it is the fee per kilobyte of the entire package.
 	feePerKB float64
 
 	// dependsOn holds a map of transaction hashes which this one depends
@@ -65,6 +70,157 @@ type txPrioItem struct {
 	// transactions in the source pool and hence must come after them in
 	// a block.
 	dependsOn map[chainhash.Hash]struct{}
+
+	// ancestors holds the transactions in the source pool which this one
+	// directly or indirectly depends on and which have not been included
+	// in the block yet.  It is only set for regular transactions which
+	// depend solely on other regular transactions in the source pool since
+	// those are the only ones that are selected as a package.
+	ancestors map[chainhash.Hash]*txPrioItem
+
+	// numAncestors is the total number of ancestors of the transaction in
+	// the source pool.  A transaction always has more ancestors than any
+	// of its own ancestors, so ordering by it yields a valid order for the
+	// transactions of a package.
+	numAncestors int
+
+	// queued and included track whether the transaction is currently in
+	// the priority queue and whether it has been included in the block.
+	queued   bool
+	included bool
+}
+
+// removeIncludedAncestors removes the ancestors which have already been
+// included in the block from the item.
+func (item *txPrioItem) removeIncludedAncestors() {
+	for hash, ancestor := range item.ancestors {
+		if ancestor.included {
+			delete(item.ancestors, hash)
+		}
+	}
+}
+
+// packageFeePerKB returns the fee per kilobyte of the transaction together with
+// all of its ancestors which have not been included in the block yet.
+func (item *txPrioItem) packageFeePerKB() float64 {
+	item.removeIncludedAncestors()
+	fee, size := item.fee, item.size
+	for _, ancestor := range item.ancestors {
+		fee += ancestor.fee
+		size += ancestor.size
+	}
+	return (float64(fee) * float64(kilobyte)) / float64(size)
+}
+
+// packageTxns returns the ancestors of the transaction which have not been
+// included in the block yet followed by the transaction itself.  The returned
+// transactions are ordered such that every transaction comes after all of the
+// transactions it depends on.
+func (item *txPrioItem) packageTxns() []*txPrioItem {
+	item.removeIncludedAncestors()
+	pkg := make([]*txPrioItem, 0, len(item.ancestors)+1)
+	for _, ancestor := range item.ancestors {
+		pkg = append(pkg, ancestor)
+	}
+	sort.Sort(byNumAncestors(pkg))
+	return append(pkg, item)
+}
+
+// byNumAncestors implements sort.Interface to sort a slice of priority items by
+// their number of ancestors in the source pool.
+type byNumAncestors []*txPrioItem
+
+// Len returns the number of elements in the slice.  It is part of the
+// sort.Interface implementation.
+func (s byNumAncestors) Len() int {
+	return len(s)
+}
+
+// Swap swaps the elements at the passed indices.  It is part of the
+// sort.Interface implementation.
+func (s byNumAncestors) Swap(i, j int) {
+	s[i], s[j] = s[j], s[i]
+}
+
+// Less returns whether the item with index i should sort before the item with
+// index j.  It is part of the sort.Interface implementation.
+func (s byNumAncestors) Less(i, j int) bool {
+	return s[i].numAncestors < s[j].numAncestors
+}
+
+// setPrioItemAncestors populates the ancestors of the items in the passed map
+// which depend on other transactions in it and are eligible to be selected as
+// a package.  Regular transactions are eligible when all of their ancestors are
+// regular transactions in the map.  Stake transactions are never eligible and
+// keep being added to a block only once the transactions they depend on have
+// been included.
+func setPrioItemAncestors(items map[chainhash.Hash]*txPrioItem) {
+	type ancestry struct {
+		ancestors map[chainhash.Hash]*txPrioItem
+		eligible  bool
+	}
+	known := make(map[chainhash.Hash]*ancestry, len(items))
+
+	var findAncestors func(hash chainhash.Hash, item *txPrioItem) *ancestry
+	findAncestors = func(hash chainhash.Hash, item *txPrioItem) *ancestry {
+		if a, ok := known[hash]; ok {
+			return a
+		}
+
+		a := &ancestry{eligible: item.txType == stake.TxTypeRegular}
+		if a.eligible && len(item.dependsOn) != 0 {
+			a.ancestors = make(map[chainhash.Hash]*txPrioItem)
+			for parentHash := range item.dependsOn {
+				parent, ok := items[parentHash]
+				if !ok {
+					a.eligible = false
+					break
+				}
+				parentAncestry := findAncestors(parentHash, parent)
+				if !parentAncestry.eligible {
+					a.eligible = false
+					break
+				}
+				a.ancestors[parentHash] = parent
+				for ancestorHash, ancestor := range parentAncestry.ancestors {
+					a.ancestors[ancestorHash] = ancestor
+				}
+			}
+		}
+		known[hash] = a
+		return a
+	}
+
+	for hash, item := range items {
+		a := findAncestors(hash, item)
+		if a.eligible && len(a.ancestors) != 0 {
+			item.ancestors = a.ancestors
+			item.numAncestors = len(a.ancestors)
+		}
+	}
+}
+
+// queuePrioItem adds the passed item to the priority queue unless it is already
+// queued or has already been included in the block.
+func queuePrioItem(pq *txPriorityQueue, item *txPrioItem) {
+	if item.queued || item.included {
+		return
+	}
+	item.queued = true
+	heap.Push(pq, item)
+}
+
+// queuePackages adds every item in the passed map which is selected as a
+// package along with its ancestors to the priority queue keyed by the fee per
+// kilobyte of the package.
+func queuePackages(pq *txPriorityQueue, items map[chainhash.Hash]*txPrioItem) {
+	for _, item := range items {
+		if item.ancestors == nil || item.queued || item.included {
+			continue
+		}
+		item.feePerKB = item.packageFeePerKB()
+		queuePrioItem(pq, item)
+	}
 }
 
 // txPriorityQueueLessFunc describes a function that can be used as a compare
@@ -1086,6 +1242,13 @@ func handleCreatedBlockTemplate(blockTemplate *BlockTemplate,
 // the priority queue is updated to prioritize by fees per kilobyte (then
 // priority).
 //
+// While prioritizing by fees, regular transactions which spend outputs from
+// other regular transactions in the source pool are also added to the priority
+// queue using the fee per kilobyte of the package formed by the transaction and
+// all of its ancestors which are not in the block yet.  Selecting such a
+// transaction adds the entire package to the block, so a high-fee child is able
+// to pay for its low-fee parents.
+//
 // When the fees per kilobyte drop below the TxMinFreeFee policy setting, the
 // transaction will be skipped unless the BlockMinSize policy setting is
 // nonzero, in which case the block will be filled with the low-fee/free
@@ -1285,6 +1448,12 @@ func NewBlockTemplate(policy *mining.Policy, server *server,
 	// in the block once each transaction has been included.
 	dependers := make(map[chainhash.Hash]map[chainhash.Hash]*txPrioItem)
 
+	// prioItems houses the priority items of all transactions which are
+	// candidates for inclusion in the block keyed by their hash.  It is used
+	// to determine the ancestors of the transactions which are selected as
+	// a package.
+	prioItems := make(map[chainhash.Hash]*txPrioItem, len(sourceTxns))
+
 	// Create slices to hold the fees and number of signature operations
 	// for each of the selected transactions and add an entry for the
 	// coinbase.  This allows the code below to simply append details about
@@ -1407,11 +1576,13 @@ mempoolLoop:
 		prioItem.feePerKB = (float64(txDesc.Fee) * float64(kilobyte)) /
 			float64(txSize)
 		prioItem.fee = txDesc.Fee
+		prioItem.size = int64(txSize)
+		prioItems[*tx.Hash()] = prioItem
 
 		// Add the transaction to the priority queue to mark it ready
 		// for inclusion in the block unless it has dependencies.
 		if prioItem.dependsOn == nil {
-			heap.Push(priorityQueue, prioItem)
+			queuePrioItem(priorityQueue, prioItem)
 		}
 
 		// Merge the referenced outputs from the input transactions to
@@ -1420,6 +1591,18 @@ mempoolLoop:
 		mergeUtxoView(blockUtxos, utxos)
 	}
 
+	// Determine the ancestors of the regular transactions which depend on
+	// other transactions in the source pool.  Once the priority queue is
+	// sorted by fees, such transactions are queued by the fee per kilobyte
+	// of the package they form with their ancestors and the whole package
+	// is added to the block when they are selected.  This allows a child
+	// paying a high fee to pull in its low-fee parents (child pays for
+	// parent).
+	setPrioItemAncestors(prioItems)
+	if sortedByFee {
+		queuePackages(priorityQueue, prioItems)
+	}
+
 	minrLog.Tracef("Priority queue len %d, dependers len %d",
 		priorityQueue.Len(), len(dependers))
 
@@ -1447,13 +1630,92 @@ mempoolLoop:
 		foundWinningTickets[ticketHash] = false
 	}
 
-	// Choose which transactions make it into the block.
-	for priorityQueue.Len() > 0 {
-		// Grab the highest priority (or highest fee per kilobyte
-		// depending on the sort order) transaction.
-		prioItem := heap.Pop(priorityQueue).(*txPrioItem)
+	// Choose which transactions make it into the block.  pkgTxns holds the
+	// remaining transactions of the package currently being added.
+	var pkgTxns []*txPrioItem
+	for priorityQueue.Len() > 0 || len(pkgTxns) > 0 {
+		var prioItem *txPrioItem
+		inPackage := len(pkgTxns) > 0
+		if inPackage {
+			// Grab the next transaction of the package being added and
+			// abandon the rest of the package when any of the
+			// transactions it depends on was skipped.
+			prioItem = pkgTxns[0]
+			pkgTxns = pkgTxns[1:]
+			prioItem.removeIncludedAncestors()
+			if len(prioItem.ancestors) != 0 {
+				minrLog.Tracef("Skipping tx %s since its package "+
+					"could not be fully included", prioItem.tx.Hash())
+				pkgTxns = nil
+				continue
+			}
+		} else {
+			// Grab the highest priority (or highest fee per kilobyte
+			// depending on the sort order) transaction.  Transactions
+			// which were already included as part of a package are
+			// skipped.
+			prioItem = heap.Pop(priorityQueue).(*txPrioItem)
+			prioItem.queued = false
+			if prioItem.included {
+				continue
+			}
+		}
 		tx := prioItem.tx
 
+		// Add the transaction as a package along with the ancestors which
+		// have not been included yet.  The fee per kilobyte of the
+		// package drops when some of its ancestors are included by other
+		// packages, so it is queued again with the updated value in that
+		// case.
+		if !inPackage && sortedByFee && len(prioItem.ancestors) != 0 {
+			deps := dependers[*tx.Hash()]
+			feePerKB := prioItem.packageFeePerKB()
+			if feePerKB < prioItem.feePerKB {
+				prioItem.feePerKB = feePerKB
+				queuePrioItem(priorityQueue, prioItem)
+				continue
+			}
+			prioItem.feePerKB = feePerKB
+
+			if len(prioItem.ancestors) != 0 {
+				pkg := prioItem.packageTxns()
+				pkgSize := uint32(0)
+				for _, item := range pkg {
+					pkgSize += uint32(item.size)
+				}
+				blockPlusPkgSize := blockSize + pkgSize
+				if blockPlusPkgSize < blockSize ||
+					blockPlusPkgSize >= policy.BlockMaxSize {
+
+					minrLog.Tracef("Skipping tx %s (package size "+
+						"%v) because it would exceed the max "+
+						"block size; cur block size %v, cur "+
+						"num tx %v", tx.Hash(), pkgSize,
+						blockSize, len(blockTxns))
+					logSkippedDeps(tx, deps)
+					continue
+				}
+				if feePerKB < float64(policy.TxMinFreeFee) &&
+					blockPlusPkgSize >= policy.BlockMinSize {
+
+					minrLog.Tracef("Skipping tx %s with package "+
+						"feePerKB %.2f < TxMinFreeFee %d and "+
+						"block size %d >= minBlockSize %d",
+						tx.Hash(), feePerKB,
+						policy.TxMinFreeFee, blockPlusPkgSize,
+						policy.BlockMinSize)
+					logSkippedDeps(tx, deps)
+					continue
+				}
+
+				minrLog.Tracef("Adding package of %d transactions "+
+					"for tx %s (package feePerKB %.2f)", len(pkg),
+					tx.Hash(), feePerKB)
+				pkgTxns = pkg
+				continue
+			}
+		}
+
 		// Store if this is an SStx or not.
 		isSStx := prioItem.txType == stake.TxTypeSStx
 
@@ -1553,8 +1815,10 @@ mempoolLoop:
 		}
 
 		// Skip free transactions once the block is larger than the
-		// minimum block size, except for stake transactions.
-		if sortedByFee &&
+		// minimum block size, except for stake transactions and the
+		// transactions of a package whose fee per kilobyte was already
+		// checked as a whole.
+		if sortedByFee && !inPackage &&
 			(prioItem.feePerKB < float64(policy.TxMinFreeFee)) &&
 			(tx.Tree() != wire.TxTreeStake) &&
 			(blockPlusTxSize >= policy.BlockMinSize) {
@@ -1582,6 +1846,7 @@ mempoolLoop:
 
 			sortedByFee = true
 			priorityQueue.SetLessFunc(txPQByStakeAndFee)
+			queuePackages(priorityQueue, prioItems)
 
 			// Put the transaction back into the priority queue and
 			// skip it so it is re-priortized by fees if it won't
@@ -1592,7 +1857,7 @@ mempoolLoop:
 			if blockPlusTxSize > policy.BlockPrioritySize ||
 				prioItem.priority < mempool.MinHighPriority {
 
-				heap.Push(priorityQueue, prioItem)
+				queuePrioItem(priorityQueue, prioItem)
 				continue
 			}
 		}
@@ -1635,6 +1900,7 @@ mempoolLoop:
 		blockTxns = append(blockTxns, tx)
 		blockSize += txSize
 		blockSigOps += numSigOps
+		prioItem.included = true
 
 		// Accumulate the SStxs in the block, because only a certain number
 		// are allowed.
@@ -1659,7 +1925,7 @@ mempoolLoop:
 			// are no more dependencies after this one.
 			delete(item.dependsOn, *tx.Hash())
 			if len(item.dependsOn) == 0 {
-				heap.Push(priorityQueue, item)
+				queuePrioItem(priorityQueue, item)
 			}
 		}
 	}
@@ -1743,6 +2009,11 @@ mempoolLoop:
 			}
 			topBlockRegTx := topBlock.Transactions()
 
+			// Transactions which spend outputs of removed transactions
+			// are removed as well since they were added after them as
+			// part of the same package.
+			removedTxns := make(map[chainhash.Hash]struct{})
+
 			tempBlockTxns := make([]*hcutil.Tx, 0, len(sourceTxns))
 			for _, tx := range blockTxns {
 				if tx.Tree() == wire.TxTreeRegular {
@@ -1751,9 +2022,12 @@ mempoolLoop:
 					// probably very expensive.
 					isValid := true
 					for _, txIn := range tx.MsgTx().TxIn {
+						prevHash := &txIn.PreviousOutPoint.Hash
+						if _, ok := removedTxns[*prevHash]; ok {
+							isValid = false
+						}
 						for _, parentTx := range topBlockRegTx {
-							if txIn.PreviousOutPoint.Hash.IsEqual(
-								parentTx.Hash()) {
+							if prevHash.IsEqual(parentTx.Hash()) {
 								isValid = false
 							}
 						}
@@ -1762,6 +2036,8 @@ mempoolLoop:
 					if isValid {
 						txCopy := hcutil.NewTxDeepTxIns(tx.MsgTx())
 						tempBlockTxns = append(tempBlockTxns, txCopy)
+					} else {
+						removedTxns[*tx.Hash()] = struct{}{}
 					}
 				} else {
 					txCopy := hcutil.NewTxDeepTxIns(tx.MsgTx())
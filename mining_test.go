@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	"github.com/HcashOrg/hcd/blockchain/stake"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
 )
 
 // TestStakeTxFeePrioHeap tests the priority heaps including the stake types for
@@ -128,3 +129,101 @@ func TestStakeTxFeePrioHeap(t *testing.T) {
 		}
 	}
 }
+
+// TestPrioItemPackages ensures the ancestors of transactions which are selected
+// as a package are determined properly and that the package fee per kilobyte
+// and transaction order account for ancestors already included in the block.
+func TestPrioItemPackages(t *testing.T) {
+	hashA := chainhash.Hash{0x0a}
+	hashB := chainhash.Hash{0x0b}
+	hashC := chainhash.Hash{0x0c}
+	hashD := chainhash.Hash{0x0d}
+	hashE := chainhash.Hash{0x0e}
+	hashTicket := chainhash.Hash{0x01}
+	hashMissing := chainhash.Hash{0x02}
+	dependsOn := func(hashes ...chainhash.Hash) map[chainhash.Hash]struct{} {
+		deps := make(map[chainhash.Hash]struct{})
+		for _, hash := range hashes {
+			deps[hash] = struct{}{}
+		}
+		return deps
+	}
+
+	// Create a chain of regular transactions A <- B <- C where the parent
+	// pays no fee, along with a regular transaction D which spends a
+	// ticket and a regular transaction E which spends a transaction that
+	// is not available.
+	a := &txPrioItem{txType: stake.TxTypeRegular, fee: 0, size: 250}
+	b := &txPrioItem{txType: stake.TxTypeRegular, fee: 1000, size: 250,
+		dependsOn: dependsOn(hashA)}
+	c := &txPrioItem{txType: stake.TxTypeRegular, fee: 5000, size: 250,
+		dependsOn: dependsOn(hashA, hashB)}
+	ticket := &txPrioItem{txType: stake.TxTypeSStx, fee: 1000, size: 300}
+	d := &txPrioItem{txType: stake.TxTypeRegular, fee: 1000, size: 250,
+		dependsOn: dependsOn(hashTicket)}
+	e := &txPrioItem{txType: stake.TxTypeRegular, fee: 1000, size: 250,
+		dependsOn: dependsOn(hashMissing)}
+	items := map[chainhash.Hash]*txPrioItem{
+		hashA:      a,
+		hashB:      b,
+		hashC:      c,
+		hashD:      d,
+		hashE:      e,
+		hashTicket: ticket,
+	}
+	setPrioItemAncestors(items)
+
+	tests := []struct {
+		name         string
+		item         *txPrioItem
+		numAncestors int
+	}{
+		{"A", a, 0},
+		{"B", b, 1},
+		{"C", c, 2},
+		{"D", d, 0},
+		{"E", e, 0},
+		{"ticket", ticket, 0},
+	}
+	for _, test := range tests {
+		if len(test.item.ancestors) != test.numAncestors ||
+			test.item.numAncestors != test.numAncestors {
+
+			t.Fatalf("%s: unexpected number of ancestors - got %d "+
+				"(%d), want %d", test.name, len(test.item.ancestors),
+				test.item.numAncestors, test.numAncestors)
+		}
+	}
+	if d.ancestors != nil || e.ancestors != nil {
+		t.Fatal("transactions which depend on unavailable or stake " +
+			"transactions must not be selected as a package")
+	}
+
+	// Ensure the package of C includes its ancestors in dependency order.
+	checkPackage := func(wantFeePerKB float64, want ...*txPrioItem) {
+		t.Helper()
+		if feePerKB := c.packageFeePerKB(); feePerKB != wantFeePerKB {
+			t.Fatalf("unexpected package fee per KB - got %v, want %v",
+				feePerKB, wantFeePerKB)
+		}
+		pkg := c.packageTxns()
+		if len(pkg) != len(want) {
+			t.Fatalf("unexpected package length - got %d, want %d",
+				len(pkg), len(want))
+		}
+		for i := range want {
+			if pkg[i] != want[i] {
+				t.Fatalf("unexpected package transaction at index %d",
+					i)
+			}
+		}
+	}
+	checkPackage(8000, a, b, c)
+
+	// Ensure ancestors which were included in the block are no longer
+	// part of the package.
+	a.included = true
+	checkPackage(12000, b, c)
+	b.included = true
+	checkPackage(20000, c)
+}
@@ -0,0 +1,112 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer_test
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/peer"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestAddrV2Negotiation ensures peers signal support for addrv2 messages when
+// the negotiated protocol version allows it and PushAddrMsg only relays the
+// addresses which require addrv2 messages to peers which support them.
+func TestAddrV2Negotiation(t *testing.T) {
+	torV3, err := wire.NewNetAddressType(wire.TorV3Address,
+		bytes.Repeat([]byte{0x01}, 32), 8333, wire.SFNodeNetwork)
+	if err != nil {
+		t.Fatalf("NewNetAddressType: unexpected error %v", err)
+	}
+	ipv4 := wire.NewNetAddressIPPort(net.ParseIP("173.194.115.66"), 8333,
+		wire.SFNodeNetwork)
+
+	tests := []struct {
+		name       string
+		pver       uint32
+		wantAddrV2 bool
+		wantSent   int
+	}{
+		{"addrv2", wire.AddrV2Version, true, 2},
+		{"addr", wire.AddrV2Version - 1, false, 1},
+	}
+
+	for _, test := range tests {
+		verack := make(chan struct{}, 2)
+		addrs := make(chan int, 1)
+		cfg := &peer.Config{
+			Listeners: peer.MessageListeners{
+				OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+					verack <- struct{}{}
+				},
+				OnAddr: func(p *peer.Peer, msg *wire.MsgAddr) {
+					addrs <- len(msg.AddrList)
+				},
+				OnAddrV2: func(p *peer.Peer, msg *wire.MsgAddrV2) {
+					addrs <- len(msg.AddrList)
+				},
+			},
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			ChainParams:      &chaincfg.MainNetParams,
+			ProtocolVersion:  test.pver,
+		}
+
+		inConn, outConn := pipe(
+			&conn{raddr: "10.0.0.1:8333"},
+			&conn{raddr: "10.0.0.2:8333"},
+		)
+		inPeer := peer.NewInboundPeer(cfg)
+		inPeer.AssociateConnection(inConn)
+		outPeer, err := peer.NewOutboundPeer(cfg, "10.0.0.2:8333")
+		if err != nil {
+			t.Fatalf("%s: NewOutboundPeer: unexpected err %v", test.name,
+				err)
+		}
+		outPeer.AssociateConnection(outConn)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-verack:
+			case <-time.After(time.Second):
+				t.Fatalf("%s: verack timeout", test.name)
+			}
+		}
+
+		for _, p := range []*peer.Peer{inPeer, outPeer} {
+			if p.WantsAddrV2() != test.wantAddrV2 {
+				t.Errorf("%s: WantsAddrV2 of %s: got %v, want %v",
+					test.name, p, p.WantsAddrV2(), test.wantAddrV2)
+			}
+		}
+
+		sent, err := outPeer.PushAddrMsg([]*wire.NetAddress{ipv4, torV3})
+		if err != nil {
+			t.Fatalf("%s: PushAddrMsg: unexpected err %v", test.name, err)
+		}
+		if len(sent) != test.wantSent {
+			t.Errorf("%s: PushAddrMsg: sent %d addresses, want %d",
+				test.name, len(sent), test.wantSent)
+		}
+		select {
+		case n := <-addrs:
+			if n != test.wantSent {
+				t.Errorf("%s: received %d addresses, want %d",
+					test.name, n, test.wantSent)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%s: addr timeout", test.name)
+		}
+
+		inPeer.Disconnect()
+		outPeer.Disconnect()
+		inPeer.WaitForDisconnect()
+		outPeer.WaitForDisconnect()
+	}
+}
@@ -74,6 +74,18 @@ Disconnect to disconnect from the peer and clean up all resources.
 WaitForDisconnect can be used to block until peer disconnection and resource
 cleanup has completed.
 
+Encrypted Transport
+
+Setting the V2Transport field of the Config struct enables the encrypted v2
+transport.  Outbound peers perform an ephemeral secp256k1 key exchange with the
+remote peer and encrypt all messages with ChaCha20-Poly1305 using keys derived
+from the shared secret.  The handshake fails when the remote peer does not
+support the v2 transport, in which case V2Rejected reports whether the remote
+peer closed the connection like legacy peers do so the caller may reconnect
+without it.  Inbound peers accept both encrypted connections and unencrypted connections of
+legacy peers.  The V2Transport function reports which transport a connection
+uses.
+
 Callbacks
 
 In order to do anything useful with a peer, it is necessary to react to HC
@@ -12,8 +12,24 @@ The functions are only exported while the tests are being run.
 
 package peer
 
+import (
+	"io"
+
+	"github.com/HcashOrg/hcd/wire"
+)
+
 // TstAllowSelfConns allows the test package to allow self connections by
 // disabling the detection logic.
 func TstAllowSelfConns() {
 	allowSelfConns = true
 }
+
+// TstV2Handshake makes the internal v2Handshake function available to the test
+// package.  It returns the session id of the resulting transport.
+func TstV2Handshake(rw io.ReadWriter, initiator bool, net wire.CurrencyNet, prefix []byte) ([]byte, error) {
+	t, err := v2Handshake(rw, initiator, net, prefix)
+	if err != nil {
+		return nil, err
+	}
+	return t.sessionID[:], nil
+}
@@ -94,6 +94,8 @@ func invSummary(invList []*wire.InvVect) string {
 			return fmt.Sprintf("error %s", iv.Hash)
 		case wire.InvTypeBlock:
 			return fmt.Sprintf("block %s", iv.Hash)
+		case wire.InvTypeCmpctBlock:
+			return fmt.Sprintf("cmpctblock %s", iv.Hash)
 		case wire.InvTypeTx:
 			return fmt.Sprintf("tx %s", iv.Hash)
 		}
@@ -156,6 +158,9 @@ func messageSummary(msg wire.Message) string {
 	case *wire.MsgAddr:
 		return fmt.Sprintf("%d addr", len(msg.AddrList))
 
+	case *wire.MsgAddrV2:
+		return fmt.Sprintf("%d addr", len(msg.AddrList))
+
 	case *wire.MsgPing:
 		// No summary - perhaps add nonce.
 
@@ -178,6 +183,20 @@ func messageSummary(msg wire.Message) string {
 		return fmt.Sprintf("hash %s, ver %d, %d tx, %s", msg.BlockHash(),
 			header.Version, len(msg.Transactions), header.Timestamp)
 
+	case *wire.MsgCmpctBlock:
+		return fmt.Sprintf("hash %s, %d short tx, %d prefilled tx, "+
+			"%d short stx, %d prefilled stx", msg.Header.BlockHash(),
+			len(msg.ShortTxns), len(msg.PrefilledTxns),
+			len(msg.StakeShortTxns), len(msg.StakePrefilledTxns))
+
+	case *wire.MsgGetBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx, %d stx", msg.BlockHash,
+			len(msg.Indexes), len(msg.StakeIndexes))
+
+	case *wire.MsgBlockTxn:
+		return fmt.Sprintf("hash %s, %d tx, %d stx", msg.BlockHash,
+			len(msg.Transactions), len(msg.STransactions))
+
 	case *wire.MsgInv:
 		return invSummary(msg.InvList)
 
@@ -8,6 +8,7 @@ package peer
 
 import (
 	"bytes"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
@@ -29,7 +30,7 @@ import (
 
 const (
 	// MaxProtocolVersion is the max protocol version the peer supports.
-	MaxProtocolVersion = wire.FeeFilterVersion
+	MaxProtocolVersion = wire.AddrV2Version
 
 	// outputBufferSize is the number of elements the output channels use.
 	outputBufferSize = 5000
@@ -103,6 +104,13 @@ type MessageListeners struct {
 	// OnAddr is invoked when a peer receives an addr wire message.
 	OnAddr func(p *Peer, msg *wire.MsgAddr)
 
+	// OnSendAddrV2 is invoked when a peer receives a sendaddrv2 wire
+	// message.
+	OnSendAddrV2 func(p *Peer, msg *wire.MsgSendAddrV2)
+
+	// OnAddrV2 is invoked when a peer receives an addrv2 wire message.
+	OnAddrV2 func(p *Peer, msg *wire.MsgAddrV2)
+
 	// OnPing is invoked when a peer receives a ping wire message.
 	OnPing func(p *Peer, msg *wire.MsgPing)
 
@@ -148,6 +156,35 @@ type MessageListeners struct {
 	// message.
 	OnGetHeaders func(p *Peer, msg *wire.MsgGetHeaders)
 
+	// OnGetCFilter is invoked when a peer receives a getcfilter wire
+	// message.
+	OnGetCFilter func(p *Peer, msg *wire.MsgGetCFilter)
+
+	// OnGetCFHeaders is invoked when a peer receives a getcfheaders wire
+	// message.
+	OnGetCFHeaders func(p *Peer, msg *wire.MsgGetCFHeaders)
+
+	// OnCFilter is invoked when a peer receives a cfilter wire message.
+	OnCFilter func(p *Peer, msg *wire.MsgCFilter)
+
+	// OnCFHeaders is invoked when a peer receives a cfheaders wire
+	// message.
+	OnCFHeaders func(p *Peer, msg *wire.MsgCFHeaders)
+
+	// OnSendCmpct is invoked when a peer receives a sendcmpct wire message.
+	OnSendCmpct func(p *Peer, msg *wire.MsgSendCmpct)
+
+	// OnCmpctBlock is invoked when a peer receives a cmpctblock wire
+	// message.
+	OnCmpctBlock func(p *Peer, msg *wire.MsgCmpctBlock)
+
+	// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire
+	// message.
+	OnGetBlockTxn func(p *Peer, msg *wire.MsgGetBlockTxn)
+
+	// OnBlockTxn is invoked when a peer receives a blocktxn wire message.
+	OnBlockTxn func(p *Peer, msg *wire.MsgBlockTxn)
+
 	// OnFeeFilter is invoked when a peer receives a feefilter wire message.
 	OnFeeFilter func(p *Peer, msg *wire.MsgFeeFilter)
 
@@ -237,6 +274,12 @@ type Config struct {
 	// not send inv messages for transactions.
 	DisableRelayTx bool
 
+	// V2Transport specifies whether to use the encrypted v2 transport.
+	// Outbound peers initiate the v2 handshake, which fails when the remote
+	// peer does not support it, while inbound peers accept both v1 and v2
+	// connections.
+	V2Transport bool
+
 	// Listeners houses callback functions to be invoked on receiving peer
 	// messages.
 	Listeners MessageListeners
@@ -342,6 +385,8 @@ type StatsSnap struct {
 	LastPingNonce  uint64
 	LastPingTime   time.Time
 	LastPingMicros int64
+	V2Transport    bool
+	SessionID      string
 }
 
 // HashFunc is a function which returns a block hash, height and error
@@ -393,6 +438,12 @@ type Peer struct {
 
 	conn net.Conn
 
+	// These fields are set while negotiating the transport before any
+	// messages are read or written.  connReader is the reader of v1
+	// messages and v2 is the encrypted transport, if any.
+	connReader io.Reader
+	v2         *v2Transport
+
 	// These fields are set at creation time and never modified, so they are
 	// safe to read from concurrently without a mutex.
 	addr    string
@@ -408,6 +459,11 @@ type Peer struct {
 	advertisedProtoVer   uint32 // protocol version advertised by remote
 	protocolVersion      uint32 // negotiated protocol version
 	sendHeadersPreferred bool   // peer sent a sendheaders message
+	cmpctBlockVersion    uint64 // compact block version sent by the peer
+	sendCmpctPreferred   bool   // peer wants cmpctblock announcements
+	v2Transport          bool   // connection uses the v2 transport
+	v2Rejected           bool   // remote peer rejected the v2 handshake
+	sendAddrV2Preferred  bool   // peer sent a sendaddrv2 message
 	versionSent          bool
 	verAckReceived       bool
 
@@ -485,6 +541,14 @@ func (p *Peer) AddKnownInventory(invVect *wire.InvVect) {
 	p.knownInventory.Add(invVect)
 }
 
+// IsKnownInventory returns whether the passed inventory is in the cache of
+// known inventory for the peer.
+//
+// This function is safe for concurrent access.
+func (p *Peer) IsKnownInventory(invVect *wire.InvVect) bool {
+	return p.knownInventory.Exists(invVect)
+}
+
 // StatsSnapshot returns a snapshot of the current peer flags and statistics.
 //
 // This function is safe for concurrent access.
@@ -497,8 +561,14 @@ func (p *Peer) StatsSnapshot() *StatsSnap {
 	userAgent := p.userAgent
 	services := p.services
 	protocolVersion := p.advertisedProtoVer
+	v2Transport := p.v2Transport
 	p.flagsMtx.Unlock()
 
+	var sessionID string
+	if v2Transport {
+		sessionID = hex.EncodeToString(p.v2.sessionID[:])
+	}
+
 	// Get a copy of all relevant flags and stats.
 	statsSnap := &StatsSnap{
 		ID:             id,
@@ -518,6 +588,8 @@ func (p *Peer) StatsSnapshot() *StatsSnap {
 		LastPingNonce:  p.lastPingNonce,
 		LastPingMicros: p.lastPingMicros,
 		LastPingTime:   p.lastPingTime,
+		V2Transport:    v2Transport,
+		SessionID:      sessionID,
 	}
 
 	p.statsMtx.RUnlock()
@@ -750,6 +822,68 @@ func (p *Peer) WantsHeaders() bool {
 	return sendHeadersPreferred
 }
 
+// CmpctBlockVersion returns the compact block version the peer signalled
+// support for with a sendcmpct message.  It is zero when the peer does not
+// support a compact block version known to this package.
+//
+// This function is safe for concurrent access.
+func (p *Peer) CmpctBlockVersion() uint64 {
+	p.flagsMtx.Lock()
+	cmpctBlockVersion := p.cmpctBlockVersion
+	p.flagsMtx.Unlock()
+
+	return cmpctBlockVersion
+}
+
+// WantsCmpctBlocks returns if the peer wants new blocks to be announced by
+// cmpctblock messages instead of inventory vectors or headers.
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsCmpctBlocks() bool {
+	p.flagsMtx.Lock()
+	sendCmpctPreferred := p.sendCmpctPreferred
+	p.flagsMtx.Unlock()
+
+	return sendCmpctPreferred
+}
+
+// WantsAddrV2 returns if the peer wants addresses to be relayed by addrv2
+// messages instead of addr messages.
+//
+// This function is safe for concurrent access.
+func (p *Peer) WantsAddrV2() bool {
+	p.flagsMtx.Lock()
+	sendAddrV2Preferred := p.sendAddrV2Preferred
+	p.flagsMtx.Unlock()
+
+	return sendAddrV2Preferred
+}
+
+// V2Transport returns whether the connection to the peer uses the encrypted
+// v2 transport.
+//
+// This function is safe for concurrent access.
+func (p *Peer) V2Transport() bool {
+	p.flagsMtx.Lock()
+	v2Transport := p.v2Transport
+	p.flagsMtx.Unlock()
+
+	return v2Transport
+}
+
+// V2Rejected returns whether the remote peer closed the connection before
+// sending any of its public key during the v2 handshake, which is how peers
+// that only support the v1 transport react to it.
+//
+// This function is safe for concurrent access.
+func (p *Peer) V2Rejected() bool {
+	p.flagsMtx.Lock()
+	v2Rejected := p.v2Rejected
+	p.flagsMtx.Unlock()
+
+	return v2Rejected
+}
+
 // localVersionMsg creates a version message that can be used to send to the
 // remote peer.
 func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
@@ -836,35 +970,51 @@ func (p *Peer) localVersionMsg() (*wire.MsgVersion, error) {
 // addresses.  This function is useful over manually sending the message via
 // QueueMessage since it automatically limits the addresses to the maximum
 // number allowed by the message and randomizes the chosen addresses when there
-// are too many.  It returns the addresses that were actually sent and no
-// message will be sent if there are no entries in the provided addresses slice.
+// are too many.  Peers which sent a sendaddrv2 message are sent an addrv2
+// message instead, while addresses which can only be relayed by addrv2 messages
+// are skipped for all other peers.  It returns the addresses that were actually
+// sent and no message will be sent if there are no entries in the provided
+// addresses slice.
 //
 // This function is safe for concurrent access.
 func (p *Peer) PushAddrMsg(addresses []*wire.NetAddress) ([]*wire.NetAddress, error) {
+	addrV2 := p.WantsAddrV2()
+	addrList := make([]*wire.NetAddress, 0, len(addresses))
+	for _, na := range addresses {
+		if !addrV2 && na.RequiresAddrV2() {
+			continue
+		}
+		addrList = append(addrList, na)
+	}
 
 	// Nothing to send.
-	if len(addresses) == 0 {
+	if len(addrList) == 0 {
 		return nil, nil
 	}
 
-	msg := wire.NewMsgAddr()
-	msg.AddrList = make([]*wire.NetAddress, len(addresses))
-	copy(msg.AddrList, addresses)
-
 	// Randomize the addresses sent if there are more than the maximum allowed.
-	if len(msg.AddrList) > wire.MaxAddrPerMsg {
+	if len(addrList) > wire.MaxAddrPerMsg {
 		// Shuffle the address list.
-		for i := range msg.AddrList {
+		for i := range addrList {
 			j := rand.Intn(i + 1)
-			msg.AddrList[i], msg.AddrList[j] = msg.AddrList[j], msg.AddrList[i]
+			addrList[i], addrList[j] = addrList[j], addrList[i]
 		}
 
 		// Truncate it to the maximum size.
-		msg.AddrList = msg.AddrList[:wire.MaxAddrPerMsg]
+		addrList = addrList[:wire.MaxAddrPerMsg]
+	}
+
+	if addrV2 {
+		msg := wire.NewMsgAddrV2()
+		msg.AddrList = addrList
+		p.QueueMessage(msg, nil)
+		return addrList, nil
 	}
 
+	msg := wire.NewMsgAddr()
+	msg.AddrList = addrList
 	p.QueueMessage(msg, nil)
-	return msg.AddrList, nil
+	return addrList, nil
 }
 
 // PushGetBlocksMsg sends a getblocks message for the provided block locator
@@ -1081,8 +1231,17 @@ func (p *Peer) handlePongMsg(msg *wire.MsgPong) {
 
 // readMessage reads the next wire message from the peer with logging.
 func (p *Peer) readMessage() (wire.Message, []byte, error) {
-	n, msg, buf, err := wire.ReadMessageN(p.conn, p.ProtocolVersion(),
-		p.cfg.ChainParams.Net)
+	var n int
+	var msg wire.Message
+	var buf []byte
+	var err error
+	if p.v2 != nil {
+		n, msg, buf, err = p.v2.readMessage(p.ProtocolVersion(),
+			p.cfg.ChainParams.Net)
+	} else {
+		n, msg, buf, err = wire.ReadMessageN(p.connReader,
+			p.ProtocolVersion(), p.cfg.ChainParams.Net)
+	}
 	atomic.AddUint64(&p.bytesReceived, uint64(n))
 	if p.cfg.Listeners.OnRead != nil {
 		p.cfg.Listeners.OnRead(p, n, msg, err)
@@ -1144,8 +1303,15 @@ func (p *Peer) writeMessage(msg wire.Message) error {
 	}))
 
 	// Write the message to the peer.
-	n, err := wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
-		p.cfg.ChainParams.Net)
+	var n int
+	var err error
+	if p.v2 != nil {
+		n, err = p.v2.writeMessage(msg, p.ProtocolVersion(),
+			p.cfg.ChainParams.Net)
+	} else {
+		n, err = wire.WriteMessageN(p.conn, msg, p.ProtocolVersion(),
+			p.cfg.ChainParams.Net)
+	}
 	atomic.AddUint64(&p.bytesSent, uint64(n))
 	if p.cfg.Listeners.OnWrite != nil {
 		p.cfg.Listeners.OnWrite(p, n, msg, err)
@@ -1201,11 +1367,16 @@ func (p *Peer) maybeAddDeadline(pendingResponses map[string]time.Time, msgCmd st
 		pendingResponses[wire.CmdInv] = deadline
 
 	case wire.CmdGetData:
-		// Expects a block, tx, or notfound message.
+		// Expects a block, cmpctblock, tx, or notfound message.
 		pendingResponses[wire.CmdBlock] = deadline
+		pendingResponses[wire.CmdCmpctBlock] = deadline
 		pendingResponses[wire.CmdTx] = deadline
 		pendingResponses[wire.CmdNotFound] = deadline
 
+	case wire.CmdGetBlockTxn:
+		// Expects a blocktxn message.
+		pendingResponses[wire.CmdBlockTxn] = deadline
+
 	case wire.CmdGetHeaders:
 		// Expects a headers message.  Use a longer deadline since it
 		// can take a while for the remote peer to load all of the
@@ -1262,10 +1433,13 @@ out:
 				switch msgCmd := msg.message.Command(); msgCmd {
 				case wire.CmdBlock:
 					fallthrough
+				case wire.CmdCmpctBlock:
+					fallthrough
 				case wire.CmdTx:
 					fallthrough
 				case wire.CmdNotFound:
 					delete(pendingResponses, wire.CmdBlock)
+					delete(pendingResponses, wire.CmdCmpctBlock)
 					delete(pendingResponses, wire.CmdTx)
 					delete(pendingResponses, wire.CmdNotFound)
 
@@ -1443,6 +1617,24 @@ out:
 				p.cfg.Listeners.OnAddr(p, msg)
 			}
 
+		case *wire.MsgSendAddrV2:
+			// The peer must signal support for addrv2 messages
+			// before its verack, so it is ignored afterwards.
+			if !p.VerAckReceived() {
+				p.flagsMtx.Lock()
+				p.sendAddrV2Preferred = true
+				p.flagsMtx.Unlock()
+			}
+
+			if p.cfg.Listeners.OnSendAddrV2 != nil {
+				p.cfg.Listeners.OnSendAddrV2(p, msg)
+			}
+
+		case *wire.MsgAddrV2:
+			if p.cfg.Listeners.OnAddrV2 != nil {
+				p.cfg.Listeners.OnAddrV2(p, msg)
+			}
+
 		case *wire.MsgPing:
 			p.handlePingMsg(msg)
 			if p.cfg.Listeners.OnPing != nil {
@@ -1515,6 +1707,55 @@ out:
 				p.cfg.Listeners.OnGetHeaders(p, msg)
 			}
 
+		case *wire.MsgGetCFilter:
+			if p.cfg.Listeners.OnGetCFilter != nil {
+				p.cfg.Listeners.OnGetCFilter(p, msg)
+			}
+
+		case *wire.MsgGetCFHeaders:
+			if p.cfg.Listeners.OnGetCFHeaders != nil {
+				p.cfg.Listeners.OnGetCFHeaders(p, msg)
+			}
+
+		case *wire.MsgCFilter:
+			if p.cfg.Listeners.OnCFilter != nil {
+				p.cfg.Listeners.OnCFilter(p, msg)
+			}
+
+		case *wire.MsgCFHeaders:
+			if p.cfg.Listeners.OnCFHeaders != nil {
+				p.cfg.Listeners.OnCFHeaders(p, msg)
+			}
+
+		case *wire.MsgSendCmpct:
+			// Only the compact block version known to this package
+			// is supported.
+			if msg.CmpctBlockVersion == wire.CmpctBlockVersion {
+				p.flagsMtx.Lock()
+				p.cmpctBlockVersion = msg.CmpctBlockVersion
+				p.sendCmpctPreferred = msg.AnnounceUsingCmpctBlock
+				p.flagsMtx.Unlock()
+			}
+
+			if p.cfg.Listeners.OnSendCmpct != nil {
+				p.cfg.Listeners.OnSendCmpct(p, msg)
+			}
+
+		case *wire.MsgCmpctBlock:
+			if p.cfg.Listeners.OnCmpctBlock != nil {
+				p.cfg.Listeners.OnCmpctBlock(p, msg)
+			}
+
+		case *wire.MsgGetBlockTxn:
+			if p.cfg.Listeners.OnGetBlockTxn != nil {
+				p.cfg.Listeners.OnGetBlockTxn(p, msg)
+			}
+
+		case *wire.MsgBlockTxn:
+			if p.cfg.Listeners.OnBlockTxn != nil {
+				p.cfg.Listeners.OnBlockTxn(p, msg)
+			}
+
 		case *wire.MsgFeeFilter:
 			if p.cfg.Listeners.OnFeeFilter != nil {
 				p.cfg.Listeners.OnFeeFilter(p, msg)
@@ -1866,6 +2107,7 @@ func (p *Peer) AssociateConnection(conn net.Conn) {
 	}
 
 	p.conn = conn
+	p.connReader = conn
 	p.timeConnected = time.Now()
 
 	if p.inbound {
@@ -1920,6 +2162,10 @@ func (p *Peer) start() error {
 
 	negotiateErr := make(chan error)
 	go func() {
+		if err := p.negotiateTransport(); err != nil {
+			negotiateErr <- err
+			return
+		}
 		if p.inbound {
 			negotiateErr <- p.negotiateInboundProtocol()
 		} else {
@@ -1945,6 +2191,12 @@ func (p *Peer) start() error {
 	go p.queueHandler()
 	go p.outHandler()
 
+	// Signal support for addrv2 messages, which must happen before sending
+	// the verack message, when the negotiated protocol version allows it.
+	if p.ProtocolVersion() >= wire.AddrV2Version {
+		p.QueueMessage(wire.NewMsgSendAddrV2(), nil)
+	}
+
 	// Send our verack message now that the IO processing machinery has started.
 	p.QueueMessage(wire.NewMsgVerAck(), nil)
 	return nil
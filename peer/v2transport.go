@@ -0,0 +1,466 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer
+
+import (
+	"bytes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+
+	"github.com/HcashOrg/hcd/hcec/secp256k1"
+	"github.com/HcashOrg/hcd/wire"
+	"golang.org/x/crypto/chacha20poly1305"
+	"golang.org/x/crypto/hkdf"
+)
+
+const (
+	// v2PubKeySize is the size of the ElligatorSwift encoded ephemeral
+	// public keys which are exchanged during the v2 handshake.  The
+	// encoding is indistinguishable from random bytes.
+	v2PubKeySize = secp256k1.EllSwiftPubKeyLen
+
+	// v2MaxGarbageSize is the maximum number of random bytes which are sent
+	// after the public key during the v2 handshake, so the size of the
+	// handshake does not identify the protocol either.
+	v2MaxGarbageSize = 4095
+
+	// v2GarbageTerminatorSize is the size of the terminator which both
+	// sides of an encrypted connection derive from the shared secret and
+	// send after their garbage.
+	v2GarbageTerminatorSize = 16
+
+	// v2LengthSize is the number of bytes the length of the contents of an
+	// encrypted frame is encoded with.
+	v2LengthSize = 4
+
+	// v2TagSize is the size of the authentication tag which is appended
+	// to the encrypted length and contents of a frame.
+	v2TagSize = 16
+
+	// v2SessionIDSize is the size of the session id which both sides of an
+	// encrypted connection derive from the shared secret.
+	v2SessionIDSize = 32
+
+	// maxV2ContentSize is the maximum size of the contents of an encrypted
+	// frame, which is a single v1 encoded message including its header.
+	maxV2ContentSize = wire.MessageHeaderSize + wire.MaxMessagePayload
+)
+
+var (
+	// v2KeySalt is the salt used to derive the keys of an encrypted
+	// connection from the shared secret.  The network magic is appended to
+	// it so connections of different networks never share keys.
+	v2KeySalt = []byte("hcd_v2_transport")
+
+	// errV2NonceExhausted is returned when a cipher of an encrypted
+	// connection has used up all of its nonces.
+	errV2NonceExhausted = errors.New("encrypted transport nonces exhausted")
+
+	// errV2NoGarbageTerminator is returned when the remote peer does not
+	// send its garbage terminator within the maximum garbage size.
+	errV2NoGarbageTerminator = errors.New("garbage terminator not found")
+
+	// errV2Rejected is returned when the remote peer closes the connection
+	// before sending any of its public key, which is how peers that only
+	// support the v1 transport react to the handshake.
+	errV2Rejected = errors.New("connection closed before the public key " +
+		"was received")
+)
+
+// v2Cipher houses an authenticated cipher of one direction of an encrypted
+// connection along with the counter used as its nonce.
+type v2Cipher struct {
+	aead    cipher.AEAD
+	counter uint64
+	nonce   [chacha20poly1305.NonceSize]byte
+}
+
+// newV2Cipher returns a cipher which uses the passed ChaCha20-Poly1305 key.
+func newV2Cipher(key []byte) (*v2Cipher, error) {
+	aead, err := chacha20poly1305.New(key)
+	if err != nil {
+		return nil, err
+	}
+	return &v2Cipher{aead: aead}, nil
+}
+
+// nextNonce returns the nonce for the next frame and advances the counter.
+func (c *v2Cipher) nextNonce() ([]byte, error) {
+	if c.counter == ^uint64(0) {
+		return nil, errV2NonceExhausted
+	}
+	binary.LittleEndian.PutUint64(c.nonce[4:], c.counter)
+	c.counter++
+	return c.nonce[:], nil
+}
+
+// seal encrypts and authenticates the passed plaintext along with the passed
+// additional data and appends the result to dst.
+func (c *v2Cipher) seal(dst, plaintext, additionalData []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Seal(dst, nonce, plaintext, additionalData), nil
+}
+
+// open authenticates and decrypts the passed ciphertext along with the passed
+// additional data.
+func (c *v2Cipher) open(ciphertext, additionalData []byte) ([]byte, error) {
+	nonce, err := c.nextNonce()
+	if err != nil {
+		return nil, err
+	}
+	return c.aead.Open(ciphertext[:0], nonce, ciphertext, additionalData)
+}
+
+// v2Transport provides the encrypted v2 transport of a connection.  Every v1
+// encoded message is sent in its own frame which consists of the encrypted
+// length of the message followed by the encrypted message.  The length and the
+// message are encrypted with separate keys so the frame boundaries can only be
+// recovered by the remote peer.
+//
+// The garbage sent and received during the handshake is authenticated as the
+// additional data of the first frame of the respective direction.
+//
+// The ciphers of each direction are only used by a single goroutine at a time,
+// so the transport does not need to be protected for concurrent access.
+type v2Transport struct {
+	rw             io.ReadWriter
+	sendLength     *v2Cipher
+	sendContent    *v2Cipher
+	recvLength     *v2Cipher
+	recvContent    *v2Cipher
+	sessionID      [v2SessionIDSize]byte
+	sendTerminator [v2GarbageTerminatorSize]byte
+	recvTerminator [v2GarbageTerminatorSize]byte
+	sendGarbage    []byte
+	recvGarbage    []byte
+}
+
+// newV2Transport derives the keys of an encrypted connection from the passed
+// shared secret and the ephemeral public keys of the initiating and responding
+// sides and returns the transport for the passed side.
+func newV2Transport(rw io.ReadWriter, initiator bool, net wire.CurrencyNet,
+	secret, initiatorPub, responderPub []byte) (*v2Transport, error) {
+
+	salt := make([]byte, len(v2KeySalt)+4)
+	copy(salt, v2KeySalt)
+	binary.LittleEndian.PutUint32(salt[len(v2KeySalt):], uint32(net))
+	info := make([]byte, 0, 2*v2PubKeySize)
+	info = append(info, initiatorPub...)
+	info = append(info, responderPub...)
+	kdf := hkdf.New(sha256.New, secret, salt, info)
+
+	// Derive the length and content keys of the initiator followed by the
+	// ones of the responder.
+	var ciphers [4]*v2Cipher
+	for i := range ciphers {
+		key := make([]byte, chacha20poly1305.KeySize)
+		if _, err := io.ReadFull(kdf, key); err != nil {
+			return nil, err
+		}
+		c, err := newV2Cipher(key)
+		if err != nil {
+			return nil, err
+		}
+		ciphers[i] = c
+	}
+
+	t := &v2Transport{rw: rw}
+	if _, err := io.ReadFull(kdf, t.sessionID[:]); err != nil {
+		return nil, err
+	}
+
+	// Derive the garbage terminators of the initiator and the responder.
+	var terminators [2][v2GarbageTerminatorSize]byte
+	for i := range terminators {
+		if _, err := io.ReadFull(kdf, terminators[i][:]); err != nil {
+			return nil, err
+		}
+	}
+
+	if initiator {
+		t.sendLength, t.sendContent = ciphers[0], ciphers[1]
+		t.recvLength, t.recvContent = ciphers[2], ciphers[3]
+		t.sendTerminator, t.recvTerminator = terminators[0], terminators[1]
+	} else {
+		t.sendLength, t.sendContent = ciphers[2], ciphers[3]
+		t.recvLength, t.recvContent = ciphers[0], ciphers[1]
+		t.sendTerminator, t.recvTerminator = terminators[1], terminators[0]
+	}
+	return t, nil
+}
+
+// readMessage reads the next frame from the connection and decodes the message
+// it contains.  The number of bytes read from the connection is returned along
+// with the message and its payload.
+func (t *v2Transport) readMessage(pver uint32, net wire.CurrencyNet) (int, wire.Message, []byte, error) {
+	var lengthBuf [v2LengthSize + v2TagSize]byte
+	n, err := io.ReadFull(t.rw, lengthBuf[:])
+	if err != nil {
+		return n, nil, nil, err
+	}
+	plainLength, err := t.recvLength.open(lengthBuf[:], nil)
+	if err != nil {
+		return n, nil, nil, fmt.Errorf("unable to decrypt frame "+
+			"length: %v", err)
+	}
+	length := binary.LittleEndian.Uint32(plainLength)
+	if length > maxV2ContentSize {
+		return n, nil, nil, fmt.Errorf("frame length %d exceeds the "+
+			"maximum of %d", length, maxV2ContentSize)
+	}
+
+	content := make([]byte, length+v2TagSize)
+	contentRead, err := io.ReadFull(t.rw, content)
+	n += contentRead
+	if err != nil {
+		return n, nil, nil, err
+	}
+	plaintext, err := t.recvContent.open(content, t.recvGarbage)
+	if err != nil {
+		return n, nil, nil, fmt.Errorf("unable to decrypt frame: %v",
+			err)
+	}
+	t.recvGarbage = nil
+
+	// Every frame houses exactly one message.
+	r := bytes.NewReader(plaintext)
+	_, msg, payload, err := wire.ReadMessageN(r, pver, net)
+	if err != nil {
+		return n, nil, nil, err
+	}
+	if r.Len() != 0 {
+		return n, nil, nil, fmt.Errorf("frame contains %d bytes past "+
+			"the %v message", r.Len(), msg.Command())
+	}
+	return n, msg, payload, nil
+}
+
+// writeMessage encodes the passed message and writes it to the connection in a
+// single frame.  The number of bytes written to the connection is returned.
+func (t *v2Transport) writeMessage(msg wire.Message, pver uint32, net wire.CurrencyNet) (int, error) {
+	var content bytes.Buffer
+	_, err := wire.WriteMessageN(&content, msg, pver, net)
+	if err != nil {
+		return 0, err
+	}
+	length := content.Len()
+	if length > maxV2ContentSize {
+		return 0, fmt.Errorf("message length %d exceeds the maximum "+
+			"frame length of %d", length, maxV2ContentSize)
+	}
+
+	frame := make([]byte, 0, v2LengthSize+length+2*v2TagSize)
+	var plainLength [v2LengthSize]byte
+	binary.LittleEndian.PutUint32(plainLength[:], uint32(length))
+	frame, err = t.sendLength.seal(frame, plainLength[:], nil)
+	if err != nil {
+		return 0, err
+	}
+	frame, err = t.sendContent.seal(frame, content.Bytes(), t.sendGarbage)
+	if err != nil {
+		return 0, err
+	}
+	t.sendGarbage = nil
+	return t.rw.Write(frame)
+}
+
+// v2EphemeralKey returns a new ephemeral private key along with the encoding of
+// its public key.  The encoding of the initiating side never starts with the
+// network magic so the responding side can tell it from a v1 message.
+func v2EphemeralKey(initiator bool, net wire.CurrencyNet) (*secp256k1.PrivateKey, []byte, error) {
+	for {
+		privKey, err := secp256k1.GeneratePrivateKey(secp256k1.S256())
+		if err != nil {
+			return nil, nil, err
+		}
+		pubKey := (*secp256k1.PublicKey)(&privKey.PublicKey)
+		encoded, err := secp256k1.EllSwiftEncode(rand.Reader, pubKey)
+		if err != nil {
+			return nil, nil, err
+		}
+		if initiator && binary.LittleEndian.Uint32(encoded) == uint32(net) {
+			continue
+		}
+		return privKey, encoded, nil
+	}
+}
+
+// v2RandomGarbage returns a random number of random bytes up to
+// v2MaxGarbageSize.
+func v2RandomGarbage() ([]byte, error) {
+	var sizeBuf [2]byte
+	if _, err := rand.Read(sizeBuf[:]); err != nil {
+		return nil, err
+	}
+	size := int(binary.LittleEndian.Uint16(sizeBuf[:])) % (v2MaxGarbageSize + 1)
+	garbage := make([]byte, size)
+	if _, err := rand.Read(garbage); err != nil {
+		return nil, err
+	}
+	return garbage, nil
+}
+
+// readV2Garbage reads the garbage the remote peer sent after its public key
+// along with the passed terminator which follows it and returns the garbage.
+// The bytes are read one at a time after the size of the terminator, so none
+// of the frames following it are consumed.
+func readV2Garbage(r io.Reader, terminator []byte) ([]byte, error) {
+	buf := make([]byte, v2GarbageTerminatorSize,
+		v2MaxGarbageSize+v2GarbageTerminatorSize)
+	if _, err := io.ReadFull(r, buf); err != nil {
+		return nil, err
+	}
+	var b [1]byte
+	for !bytes.Equal(buf[len(buf)-v2GarbageTerminatorSize:], terminator) {
+		if len(buf) == cap(buf) {
+			return nil, errV2NoGarbageTerminator
+		}
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			return nil, err
+		}
+		buf = append(buf, b[0])
+	}
+	return buf[:len(buf)-v2GarbageTerminatorSize], nil
+}
+
+// v2Handshake performs the ephemeral key exchange of an encrypted connection
+// over the passed connection and returns the resulting transport.  The
+// responding side passes the bytes of the public key of the initiator it
+// already read from the connection in prefix.
+//
+// Each side sends its encoded public key followed by a random amount of
+// garbage and the garbage terminator derived from the shared secret.  The
+// initiating side sends its public key and garbage first and its terminator
+// once it received the public key of the responding side.  The writes are
+// performed concurrently with the reads since either side may only read the
+// garbage of the other side after sending its own.
+func v2Handshake(rw io.ReadWriter, initiator bool, net wire.CurrencyNet, prefix []byte) (*v2Transport, error) {
+	privKey, ourPub, err := v2EphemeralKey(initiator, net)
+	if err != nil {
+		return nil, err
+	}
+	garbage, err := v2RandomGarbage()
+	if err != nil {
+		return nil, err
+	}
+
+	writeErr := make(chan error, 1)
+	write := func(b []byte) {
+		go func() {
+			_, err := rw.Write(b)
+			writeErr <- err
+		}()
+	}
+	if initiator {
+		write(append(append([]byte(nil), ourPub...), garbage...))
+	}
+	theirPub := make([]byte, v2PubKeySize)
+	copy(theirPub, prefix)
+	if _, err := io.ReadFull(rw, theirPub[len(prefix):]); err != nil {
+		if err == io.EOF || errors.Is(err, syscall.ECONNRESET) {
+			return nil, errV2Rejected
+		}
+		return nil, err
+	}
+	remoteKey, err := secp256k1.EllSwiftDecode(theirPub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid handshake public key: %v", err)
+	}
+
+	secret := secp256k1.GenerateSharedSecret(privKey, remoteKey)
+	var t *v2Transport
+	if initiator {
+		t, err = newV2Transport(rw, true, net, secret, ourPub, theirPub)
+	} else {
+		t, err = newV2Transport(rw, false, net, secret, theirPub, ourPub)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if initiator {
+		if err := <-writeErr; err != nil {
+			return nil, err
+		}
+		write(t.sendTerminator[:])
+	} else {
+		msg := make([]byte, 0, v2PubKeySize+len(garbage)+
+			v2GarbageTerminatorSize)
+		msg = append(msg, ourPub...)
+		msg = append(msg, garbage...)
+		write(append(msg, t.sendTerminator[:]...))
+	}
+	theirGarbage, err := readV2Garbage(rw, t.recvTerminator[:])
+	if err != nil {
+		return nil, err
+	}
+	if err := <-writeErr; err != nil {
+		return nil, err
+	}
+	t.sendGarbage, t.recvGarbage = garbage, theirGarbage
+	return t, nil
+}
+
+// negotiateTransport sets up the transport of the connection.  When the v2
+// transport is enabled, outbound peers initiate the handshake of an encrypted
+// connection while inbound peers detect whether the remote peer initiates one
+// or sends a v1 message instead, in which case they fall back to the v1
+// transport on the same connection.
+//
+// Outbound peers can't fall back on the same connection since a remote peer
+// which only supports the v1 transport disconnects once it fails to parse the
+// public key as a message header.  The caller is expected to only initiate the
+// handshake with addresses that advertise support for the v2 transport and to
+// connect with the v2 transport disabled to the addresses of peers for which
+// V2Rejected returns true instead.
+func (p *Peer) negotiateTransport() error {
+	if !p.cfg.V2Transport {
+		return nil
+	}
+
+	var prefix []byte
+	if p.inbound {
+		// All v1 messages start with the network magic, which the
+		// encoding of the public key of the initiator never starts
+		// with.
+		prefix = make([]byte, 4)
+		if _, err := io.ReadFull(p.conn, prefix); err != nil {
+			return err
+		}
+		if binary.LittleEndian.Uint32(prefix) == uint32(p.cfg.ChainParams.Net) {
+			log.Debugf("Falling back to the v1 transport for %s", p)
+			p.connReader = io.MultiReader(bytes.NewReader(prefix), p.conn)
+			return nil
+		}
+	}
+
+	t, err := v2Handshake(p.conn, !p.inbound, p.cfg.ChainParams.Net, prefix)
+	if err != nil {
+		if err == errV2Rejected {
+			p.flagsMtx.Lock()
+			p.v2Rejected = true
+			p.flagsMtx.Unlock()
+		}
+		return fmt.Errorf("v2 handshake failed: %v", err)
+	}
+	p.v2 = t
+
+	p.flagsMtx.Lock()
+	p.v2Transport = true
+	p.flagsMtx.Unlock()
+
+	log.Debugf("Established v2 transport with %s (session id %x)", p,
+		t.sessionID)
+	return nil
+}
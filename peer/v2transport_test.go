@@ -0,0 +1,216 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package peer_test
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/HcashOrg/hcd/chaincfg"
+	"github.com/HcashOrg/hcd/peer"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// TestV2Transport ensures peers negotiate the encrypted v2 transport when both
+// sides enable it and inbound peers fall back to the v1 transport for outbound
+// peers which do not.
+func TestV2Transport(t *testing.T) {
+	tests := []struct {
+		name      string
+		inboundV2 bool
+		outbound  bool
+		wantV2    bool
+	}{
+		{"v2 to v2", true, true, true},
+		{"v1 to v2", true, false, false},
+		{"v1 to v1", false, false, false},
+	}
+
+	for _, test := range tests {
+		verack := make(chan struct{}, 2)
+		newCfg := func(v2 bool) *peer.Config {
+			return &peer.Config{
+				Listeners: peer.MessageListeners{
+					OnVerAck: func(p *peer.Peer, msg *wire.MsgVerAck) {
+						verack <- struct{}{}
+					},
+				},
+				UserAgentName:    "peer",
+				UserAgentVersion: "1.0",
+				ChainParams:      &chaincfg.MainNetParams,
+				V2Transport:      v2,
+			}
+		}
+
+		inConn, outConn := pipe(
+			&conn{raddr: "10.0.0.1:8333"},
+			&conn{raddr: "10.0.0.2:8333"},
+		)
+		inPeer := peer.NewInboundPeer(newCfg(test.inboundV2))
+		inPeer.AssociateConnection(inConn)
+		outPeer, err := peer.NewOutboundPeer(newCfg(test.outbound),
+			"10.0.0.2:8333")
+		if err != nil {
+			t.Fatalf("%s: NewOutboundPeer: unexpected err %v", test.name,
+				err)
+		}
+		outPeer.AssociateConnection(outConn)
+
+		for i := 0; i < 2; i++ {
+			select {
+			case <-verack:
+			case <-time.After(time.Second):
+				t.Fatalf("%s: verack timeout", test.name)
+			}
+		}
+
+		for _, p := range []*peer.Peer{inPeer, outPeer} {
+			if p.V2Transport() != test.wantV2 {
+				t.Errorf("%s: V2Transport of %s: got %v, want %v",
+					test.name, p, p.V2Transport(), test.wantV2)
+			}
+		}
+		inSnap, outSnap := inPeer.StatsSnapshot(), outPeer.StatsSnapshot()
+		if inSnap.SessionID != outSnap.SessionID {
+			t.Errorf("%s: mismatched session ids %s and %s", test.name,
+				inSnap.SessionID, outSnap.SessionID)
+		}
+		if (inSnap.SessionID != "") != test.wantV2 {
+			t.Errorf("%s: unexpected session id %q", test.name,
+				inSnap.SessionID)
+		}
+
+		inPeer.Disconnect()
+		outPeer.Disconnect()
+		inPeer.WaitForDisconnect()
+		outPeer.WaitForDisconnect()
+	}
+}
+
+// TestV2Rejected ensures outbound peers only report that the v2 handshake was
+// rejected when the remote peer closes the connection before sending any of
+// its public key, which is what peers that only support the v1 transport do.
+func TestV2Rejected(t *testing.T) {
+	tests := []struct {
+		name  string
+		reply []byte
+		want  bool
+	}{
+		{"closed without reply", nil, true},
+		{"closed during public key", make([]byte, 10), false},
+	}
+
+	for _, test := range tests {
+		localConn, remoteConn := net.Pipe()
+		go func(reply []byte) {
+			// Read what a v1 peer would parse as a message header.
+			hdr := make([]byte, wire.MessageHeaderSize)
+			io.ReadFull(remoteConn, hdr)
+			if len(reply) > 0 {
+				remoteConn.Write(reply)
+			}
+			remoteConn.Close()
+		}(test.reply)
+
+		p, err := peer.NewOutboundPeer(&peer.Config{
+			UserAgentName:    "peer",
+			UserAgentVersion: "1.0",
+			ChainParams:      &chaincfg.MainNetParams,
+			V2Transport:      true,
+		}, "10.0.0.2:8333")
+		if err != nil {
+			t.Fatalf("%s: NewOutboundPeer: unexpected err %v", test.name,
+				err)
+		}
+		p.AssociateConnection(localConn)
+
+		disconnected := make(chan struct{})
+		go func() {
+			p.WaitForDisconnect()
+			close(disconnected)
+		}()
+		select {
+		case <-disconnected:
+		case <-time.After(time.Second):
+			t.Fatalf("%s: disconnect timeout", test.name)
+		}
+		if p.V2Rejected() != test.want {
+			t.Errorf("%s: V2Rejected: got %v, want %v", test.name,
+				p.V2Rejected(), test.want)
+		}
+	}
+}
+
+// recordingConn records the bytes written to the wrapped connection.
+type recordingConn struct {
+	io.ReadWriter
+	written bytes.Buffer
+}
+
+// Write records the passed bytes and writes them to the wrapped connection.
+func (c *recordingConn) Write(b []byte) (int, error) {
+	c.written.Write(b)
+	return c.ReadWriter.Write(b)
+}
+
+// TestV2Handshake ensures both sides of the v2 handshake derive the same
+// session and that the bytes sent by the initiator neither have a fixed size
+// nor start with the prefix of a serialized public key.
+func TestV2Handshake(t *testing.T) {
+	currencyNet := wire.MainNet
+	sizes := make(map[int]struct{})
+	firstBytes := make(map[byte]struct{})
+	for i := 0; i < 32; i++ {
+		inConn, outConn := net.Pipe()
+		recConn := &recordingConn{ReadWriter: outConn}
+
+		// The responding side reads the prefix the inbound peer uses to
+		// detect v1 messages before the handshake.
+		type result struct {
+			sessionID []byte
+			err       error
+		}
+		inResult := make(chan result, 1)
+		go func() {
+			prefix := make([]byte, 4)
+			if _, err := io.ReadFull(inConn, prefix); err != nil {
+				inResult <- result{err: err}
+				return
+			}
+			sessionID, err := peer.TstV2Handshake(inConn, false,
+				currencyNet, prefix)
+			inResult <- result{sessionID, err}
+		}()
+		outSessionID, err := peer.TstV2Handshake(recConn, true,
+			currencyNet, nil)
+		if err != nil {
+			t.Fatalf("initiator handshake failed: %v", err)
+		}
+		in := <-inResult
+		if in.err != nil {
+			t.Fatalf("responder handshake failed: %v", in.err)
+		}
+		if !bytes.Equal(in.sessionID, outSessionID) {
+			t.Fatalf("mismatched session ids %x and %x", in.sessionID,
+				outSessionID)
+		}
+		inConn.Close()
+		outConn.Close()
+
+		sent := recConn.written.Bytes()
+		sizes[len(sent)] = struct{}{}
+		firstBytes[sent[0]] = struct{}{}
+	}
+
+	if len(sizes) < 2 {
+		t.Fatalf("initiator always sent %v bytes", sizes)
+	}
+	if len(firstBytes) <= 2 {
+		t.Fatalf("initiator only started with bytes %v", firstBytes)
+	}
+}
@@ -37,12 +37,15 @@ import (
 
 	"github.com/HcashOrg/bitset"
 	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/blockchain/indexers"
 	"github.com/HcashOrg/hcd/blockchain/stake"
 	"github.com/HcashOrg/hcd/chaincfg"
 	"github.com/HcashOrg/hcd/chaincfg/chainec"
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/connmgr"
 	"github.com/HcashOrg/hcd/crypto/bliss"
 	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/fees"
 	"github.com/HcashOrg/hcd/hcjson"
 	"github.com/HcashOrg/hcd/hcutil"
 	"github.com/HcashOrg/hcd/mempool"
@@ -99,6 +102,11 @@ const (
 	// in the memory pool.
 	gbtRegenerateSeconds = 60
 
+	// gbtCoinbasePayoutIdx is the index of the coinbase output which pays
+	// the proof-of-work subsidy and transaction fees to the miner.  It is
+	// preceded by the tax output and the extra nonce null data output.
+	gbtCoinbasePayoutIdx = 2
+
 	// merkleRootPairSize
 	merkleRootPairSize = 64
 
@@ -106,6 +114,10 @@ const (
 	// transaction output's pkscript type is a ticket commitment.
 	sstxCommitmentString = "sstxcommitment"
 
+	// maxStakeDiffProjectionWindows is the maximum number of stake
+	// difficulty retarget intervals the projectstakediff RPC projects.
+	maxStakeDiffProjectionWindows = 32
+
 	// maxSigOpsPerTx is the maximum number of signature operations
 	// in a single transaction we will relay or mine.  It is a fraction
 	// of the max signature operations for a block.
@@ -178,6 +190,7 @@ type commandHandler func(*rpcServer, interface{}, <-chan struct{}) (interface{},
 var rpcHandlers map[string]commandHandler
 var rpcHandlersBeforeInit = map[string]commandHandler{
 	"addnode":               handleAddNode,
+	"clearbanned":           handleClearBanned,
 	"createrawsstx":         handleCreateRawSStx,
 	"createrawssgentx":      handleCreateRawSSGenTx,
 	"createrawssrtx":        handleCreateRawSSRtx,
@@ -186,6 +199,7 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"decoderawtransaction":  handleDecodeRawTransaction,
 	"decodescript":          handleDecodeScript,
 	"estimatefee":           handleEstimateFee,
+	"estimatesmartfee":      handleEstimateSmartFee,
 	"estimatestakediff":     handleEstimateStakeDiff,
 	"existsaddress":         handleExistsAddress,
 	"existsaddresses":       handleExistsAddresses,
@@ -196,6 +210,9 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"existsmempooltxs":      handleExistsMempoolTxs,
 	"generate":              handleGenerate,
 	"getaddednodeinfo":      handleGetAddedNodeInfo,
+	"getaddressbalance":     handleGetAddressBalance,
+	"getaddressdeltas":      handleGetAddressDeltas,
+	"getaddressutxos":       handleGetAddressUtxos,
 	"getbestblock":          handleGetBestBlock,
 	"getbestblockhash":      handleGetBestBlockHash,
 	"getblock":              handleGetBlock,
@@ -203,6 +220,9 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getblockhash":          handleGetBlockHash,
 	"getblockheader":        handleGetBlockHeader,
 	"getblocksubsidy":       handleGetBlockSubsidy,
+	"getblocktemplate":      handleGetBlockTemplate,
+	"getcfheaders":          handleGetCFHeaders,
+	"getcfilter":            handleGetCFilter,
 	"getcoinsupply":         handleGetCoinSupply,
 	"getconnectioncount":    handleGetConnectionCount,
 	"getcurrentnet":         handleGetCurrentNet,
@@ -212,32 +232,47 @@ var rpcHandlersBeforeInit = map[string]commandHandler{
 	"getheaders":            handleGetHeaders,
 	"getinfo":               handleGetInfo,
 	"getblockchaininfo":     handleGetBlockchainInfo,
+	"getmempoolancestors":   handleGetMempoolAncestors,
+	"getmempooldescendants": handleGetMempoolDescendants,
+	"getmempoolentry":       handleGetMempoolEntry,
 	"getmempoolinfo":        handleGetMempoolInfo,
+	"getmempoolvotes":       handleGetMempoolVotes,
 	"getmininginfo":         handleGetMiningInfo,
 	"getnettotals":          handleGetNetTotals,
 	"getnetworkhashps":      handleGetNetworkHashPS,
 	"getpeerinfo":           handleGetPeerInfo,
 	"getrawmempool":         handleGetRawMempool,
 	"getrawtransaction":     handleGetRawTransaction,
+	"getspendinginfo":       handleGetSpendingInfo,
 	"getstakedifficulty":    handleGetStakeDifficulty,
 	"getstakeversioninfo":   handleGetStakeVersionInfo,
 	"getstakeversions":      handleGetStakeVersions,
+	"getstratuminfo":        handleGetStratumInfo,
+	"getticketinfo":         handleGetTicketInfo,
+	"getticketpoolinfo":     handleGetTicketPoolInfo,
 	"getticketpoolvalue":    handleGetTicketPoolValue,
 	"getvoteinfo":           handleGetVoteInfo,
+	"getwinningtickets":     handleGetWinningTickets,
 	"gettxout":              handleGetTxOut,
 	"getwork":               handleGetWork,
 	"help":                  handleHelp,
+	"listbanned":            handleListBanned,
 	"livetickets":           handleLiveTickets,
+	"loadmempool":           handleLoadMempool,
 	"missedtickets":         handleMissedTickets,
 	"node":                  handleNode,
 	"ping":                  handlePing,
+	"projectstakediff":      handleProjectStakeDiff,
 	"searchrawtransactions": handleSearchRawTransactions,
 	"rebroadcastmissed":     handleRebroadcastMissed,
 	"rebroadcastwinners":    handleRebroadcastWinners,
+	"savemempool":           handleSaveMempool,
 	"sendrawtransaction":    handleSendRawTransaction,
+	"setban":                handleSetBan,
 	"setgenerate":           handleSetGenerate,
 	"stop":                  handleStop,
 	"submitblock":           handleSubmitBlock,
+	"testmempoolaccept":     handleTestMempoolAccept,
 	"ticketfeeinfo":         handleTicketFeeInfo,
 	"ticketsforaddress":     handleTicketsForAddress,
 	"ticketvwap":            handleTicketVWAP,
@@ -301,9 +336,7 @@ var rpcAskWallet = map[string]struct{}{
 
 // Commands that are currently unimplemented, but should ultimately be.
 var rpcUnimplemented = map[string]struct{}{
-	"estimatefee":       {},
 	"estimatepriority":  {},
-	"getblocktemplate":  {},
 	"getblockchaininfo": {},
 	"getchaintips":      {},
 	"getnetworkinfo":    {},
@@ -326,6 +359,9 @@ var rpcLimited = map[string]struct{}{
 	"createrawtransaction":  {},
 	"decoderawtransaction":  {},
 	"decodescript":          {},
+	"getaddressbalance":     {},
+	"getaddressdeltas":      {},
+	"getaddressutxos":       {},
 	"getbestblock":          {},
 	"getbestblockhash":      {},
 	"getblock":              {},
@@ -342,6 +378,7 @@ var rpcLimited = map[string]struct{}{
 	"searchrawtransactions": {},
 	"sendrawtransaction":    {},
 	"submitblock":           {},
+	"testmempoolaccept":     {},
 	"validateaddress":       {},
 	"verifymessage":         {},
 	"verifyblissmessage":    {},
@@ -461,6 +498,7 @@ type gbtWorkState struct {
 	lastGenerated time.Time
 	prevHash      *chainhash.Hash
 	minTimestamp  time.Time
+	numVotes      int
 	template      *BlockTemplate
 	notifyMap     map[chainhash.Hash]map[int64]chan struct{}
 	timeSource    blockchain.MedianTimeSource
@@ -726,6 +764,14 @@ func handleCreateRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan
 	return mtxHex, nil
 }
 
+// handleClearBanned implements the clearbanned command.
+func handleClearBanned(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := s.server.banList.Clear(); err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not save ban list")
+	}
+	return nil, nil
+}
+
 // handleCreateRawSStx handles createrawsstx commands.
 func handleCreateRawSStx(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcjson.CreateRawSStxCmd)
@@ -1473,10 +1519,75 @@ func handleDecodeScript(s *rpcServer, cmd interface{}, closeChan <-chan struct{}
 }
 
 // handleEstimateFee implenents the estimatefee command.
-// TODO this is a very basic implementation.  It should be
-// modified to match the bitcoin-core one.
 func handleEstimateFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
-	return cfg.minRelayTxFee.ToCoin(), nil
+	c := cmd.(*hcjson.EstimateFeeCmd)
+
+	// Clamp the number of blocks to the range tracked by the estimator
+	// rather than rejecting it since any value used to be accepted.
+	feeEstimator := s.server.feeEstimator
+	maxConfirms := int64(feeEstimator.MaxConfirms())
+	numBlocks := c.NumBlocks
+	if numBlocks < 1 {
+		numBlocks = 1
+	} else if numBlocks > maxConfirms {
+		numBlocks = maxConfirms
+	}
+
+	// Fall back to the minimum relay fee when there is not enough data to
+	// produce an estimate.  Estimates are never lower than the minimum
+	// relay fee since such transactions would not be relayed.
+	fee, err := feeEstimator.EstimateFee(int32(numBlocks))
+	if err != nil && err != fees.ErrNoSuccessPctBucketFound {
+		return nil, rpcInternalError(err.Error(), "Could not estimate fee")
+	}
+	if fee < cfg.minRelayTxFee {
+		fee = cfg.minRelayTxFee
+	}
+
+	return fee.ToCoin(), nil
+}
+
+// handleEstimateSmartFee implements the estimatesmartfee command.
+func handleEstimateSmartFee(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.EstimateSmartFeeCmd)
+
+	feeEstimator := s.server.feeEstimator
+	if c.Confirmations < 1 ||
+		c.Confirmations > int64(feeEstimator.MaxConfirms()) {
+
+		return nil, rpcInvalidError("Confirmations must be between 1 "+
+			"and %d", feeEstimator.MaxConfirms())
+	}
+
+	successPct := fees.DefaultSuccessPct
+	if c.Mode != nil {
+		switch *c.Mode {
+		case hcjson.EstimateSmartFeeConservative:
+		case hcjson.EstimateSmartFeeEconomical:
+			successPct = fees.EconomicalSuccessPct
+		default:
+			return nil, rpcInvalidError("Invalid estimate mode %q",
+				*c.Mode)
+		}
+	}
+
+	fee, confidence, blocks, err := feeEstimator.EstimateSmartFee(
+		int32(c.Confirmations), successPct)
+	if err == fees.ErrNoSuccessPctBucketFound {
+		return nil, rpcMiscError("Insufficient data to estimate fee")
+	}
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not estimate fee")
+	}
+	if fee < cfg.minRelayTxFee {
+		fee = cfg.minRelayTxFee
+	}
+
+	return &hcjson.EstimateSmartFeeResult{
+		FeeRate:    fee.ToCoin(),
+		Confidence: confidence,
+		Blocks:     int64(blocks),
+	}, nil
 }
 
 // handleEstimateStakeDiff implements the estimatestakediff command.
@@ -1857,6 +1968,132 @@ func handleGetAddedNodeInfo(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return results, nil
 }
 
+// addrUtxoIndexQuery returns the address utxo index along with the decoded
+// address and the number of entries to skip and return for the given
+// parameters of the address utxo index commands.  The returned number of
+// requested entries is clamped to 1 when negative.
+func addrUtxoIndexQuery(s *rpcServer, encodedAddr string, skip, count *int) (*indexers.AddrUtxoIndex, hcutil.Address, uint32, uint32, error) {
+	// Respond with an error if the address utxo index is not enabled.
+	addrUtxoIndex := s.server.addrUtxoIndex
+	if addrUtxoIndex == nil {
+		return nil, nil, 0, 0, rpcInternalError("Address utxo index "+
+			"must be enabled (--addrutxoindex)", "Configuration")
+	}
+
+	// Attempt to decode the supplied address.
+	addr, err := hcutil.DecodeAddress(encodedAddr)
+	if err != nil {
+		return nil, nil, 0, 0, rpcAddressKeyError("Could not decode "+
+			"address: %v", err)
+	}
+
+	// Override the default number of requested entries and entries to
+	// skip if needed.
+	numRequested := 100
+	if count != nil {
+		numRequested = *count
+		if numRequested < 0 {
+			numRequested = 1
+		}
+	}
+	var numToSkip int
+	if skip != nil {
+		numToSkip = *skip
+		if numToSkip < 0 {
+			numToSkip = 0
+		}
+	}
+
+	return addrUtxoIndex, addr, uint32(numToSkip), uint32(numRequested), nil
+}
+
+// handleGetAddressBalance implements the getaddressbalance command.
+func handleGetAddressBalance(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetAddressBalanceCmd)
+	addrUtxoIndex, addr, _, _, err := addrUtxoIndexQuery(s, c.Address,
+		nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	balance, received, err := addrUtxoIndex.Balance(addr)
+	if err != nil {
+		context := "Failed to load address balance"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	return &hcjson.GetAddressBalanceResult{
+		Address:  addr.EncodeAddress(),
+		Balance:  hcutil.Amount(balance).ToCoin(),
+		Received: hcutil.Amount(received).ToCoin(),
+	}, nil
+}
+
+// handleGetAddressDeltas implements the getaddressdeltas command.
+func handleGetAddressDeltas(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetAddressDeltasCmd)
+	addrUtxoIndex, addr, numToSkip, numRequested, err :=
+		addrUtxoIndexQuery(s, c.Address, c.Skip, c.Count)
+	if err != nil {
+		return nil, err
+	}
+	var reverse bool
+	if c.Reverse != nil {
+		reverse = *c.Reverse
+	}
+
+	deltas, err := addrUtxoIndex.DeltasForAddress(addr, numToSkip,
+		numRequested, reverse)
+	if err != nil {
+		context := "Failed to load address deltas"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	results := make([]hcjson.AddressDeltaResult, 0, len(deltas))
+	for _, delta := range deltas {
+		results = append(results, hcjson.AddressDeltaResult{
+			TxID:    delta.Hash.String(),
+			Index:   delta.Index,
+			IsInput: delta.IsInput,
+			Tree:    delta.Tree,
+			Amount:  hcutil.Amount(delta.Amount).ToCoin(),
+			Height:  delta.Height,
+		})
+	}
+	return results, nil
+}
+
+// handleGetAddressUtxos implements the getaddressutxos command.
+func handleGetAddressUtxos(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetAddressUtxosCmd)
+	addrUtxoIndex, addr, numToSkip, numRequested, err :=
+		addrUtxoIndexQuery(s, c.Address, c.Skip, c.Count)
+	if err != nil {
+		return nil, err
+	}
+
+	utxos, err := addrUtxoIndex.UtxosForAddress(addr, numToSkip,
+		numRequested)
+	if err != nil {
+		context := "Failed to load address utxos"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	results := make([]hcjson.AddressUtxoResult, 0, len(utxos))
+	for _, utxo := range utxos {
+		results = append(results, hcjson.AddressUtxoResult{
+			TxID:          utxo.Hash.String(),
+			Vout:          utxo.Index,
+			Tree:          utxo.Tree,
+			Amount:        hcutil.Amount(utxo.Amount).ToCoin(),
+			ScriptVersion: utxo.ScriptVersion,
+			ScriptPubKey:  hex.EncodeToString(utxo.PkScript),
+			Height:        utxo.Height,
+		})
+	}
+	return results, nil
+}
+
 // handleGetBestBlock implements the getbestblock command.
 func handleGetBestBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	// All other "get block" commands give either the height, the hash, or
@@ -1906,6 +2143,14 @@ func handleGetBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (i
 	}
 	blk, err := s.server.blockManager.chain.FetchBlockByHash(hash)
 	if err != nil {
+		// The header of a pruned block is still known even though the
+		// full block data is no longer available.
+		if s.chain.PruneHeight() >= 0 {
+			if exists, _ := s.chain.HaveBlock(hash); exists {
+				return nil, rpcMiscError(fmt.Sprintf("Block not "+
+					"available (pruned data): %v", hash))
+			}
+		}
 		return nil, &hcjson.RPCError{
 			Code:    hcjson.ErrRPCBlockNotFound,
 			Message: fmt.Sprintf("Block not found: %v", hash),
@@ -2274,6 +2519,29 @@ func (state *gbtWorkState) NotifyMempoolTx(lastUpdated time.Time) {
 	}()
 }
 
+// NotifyVote uses the number of votes available in the memory pool for the
+// passed block hash to notify any long poll clients with a new block template
+// when additional votes for the block the current template builds on have
+// arrived.  Unlike other memory pool changes, this is done immediately since
+// the votes determine the stake tree and subsidy of the next block.
+func (state *gbtWorkState) NotifyVote(blockHash *chainhash.Hash, numVotes int) {
+	go func() {
+		state.Lock()
+		defer state.Unlock()
+
+		// No need to notify anything if no block templates have been generated
+		// yet or the vote is not for the block the template builds on.
+		if state.prevHash == nil || state.lastGenerated.IsZero() ||
+			!state.prevHash.IsEqual(blockHash) {
+			return
+		}
+
+		if numVotes != state.numVotes {
+			state.notifyLongPollers(state.prevHash, time.Now())
+		}
+	}()
+}
+
 // templateUpdateChan returns a channel that will be closed once the block
 // template associated with the passed previous hash and last generated time
 // is stale.  The function will return existing channels for duplicate
@@ -2304,8 +2572,9 @@ func (state *gbtWorkState) templateUpdateChan(prevHash *chainhash.Hash, lastGene
 
 // updateBlockTemplate creates or updates a block template for the work state.
 // A new block template will be generated when the current best block has
-// changed or the transactions in the memory pool have been updated and it has
-// been long enough since the last template was generated.  Otherwise, the
+// changed, the number of votes available for it has changed, or the
+// transactions in the memory pool have been updated and it has been long
+// enough since the last template was generated.  Otherwise, the
 // timestamp for the existing block template is updated (and possibly the
 // difficulty on testnet per the consesus rules).  Finally, if the
 // useCoinbaseValue flag is false and the existing block template does not
@@ -2327,9 +2596,11 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 	var msgBlock *wire.MsgBlock
 	var targetDifficulty string
 	latestHash, _ := s.server.blockManager.chainState.Best()
+	numVotes := len(s.server.txMemPool.VoteHashesForBlock(*latestHash))
 	template := state.template
 	if template == nil || state.prevHash == nil ||
 		!state.prevHash.IsEqual(latestHash) ||
+		numVotes != state.numVotes ||
 		(state.lastTxUpdate != lastTxUpdate &&
 			time.Now().After(state.lastGenerated.Add(time.Second*
 				gbtRegenerateSeconds))) {
@@ -2384,6 +2655,7 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 		state.lastTxUpdate = lastTxUpdate
 		state.prevHash = latestHash
 		state.minTimestamp = minTimestamp
+		state.numVotes = numVotes
 
 		rpcsLog.Debugf("Generated block template (timestamp %v, "+
 			"target %s, merkle root %s)",
@@ -2417,13 +2689,28 @@ func (state *gbtWorkState) updateBlockTemplate(s *rpcServer, useCoinbaseValue bo
 				context := "Failed to create pay-to-addr script"
 				return rpcInternalError(err.Error(), context)
 			}
-			template.Block.Transactions[0].TxOut[0].PkScript = pkScript
-			template.ValidPayAddress = true
+			//
+			// The coinbase of block one only pays out the ledger of
+			// the network, so there is no output to update.
+			blockOne := template.Block.Header.Height == 1 &&
+				len(s.server.chainParams.BlockOneLedger) != 0
+			if !blockOne {
+				coinbase := template.Block.Transactions[0]
+				if len(coinbase.TxOut) <= gbtCoinbasePayoutIdx {
+					context := "Invalid coinbase"
+					errStr := fmt.Sprintf("The template "+
+						"coinbase only has %d outputs",
+						len(coinbase.TxOut))
+					return rpcInternalError(errStr, context)
+				}
+				coinbase.TxOut[gbtCoinbasePayoutIdx].PkScript = pkScript
 
-			// Update the merkle root.
-			block := hcutil.NewBlock(template.Block)
-			merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
-			template.Block.Header.MerkleRoot = *merkles[len(merkles)-1]
+				// Update the merkle root.
+				block := hcutil.NewBlock(template.Block)
+				merkles := blockchain.BuildMerkleTreeStore(block.Transactions())
+				template.Block.Header.MerkleRoot = *merkles[len(merkles)-1]
+			}
+			template.ValidPayAddress = true
 		}
 
 		// Set locals for convenience.
@@ -2617,6 +2904,9 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 	numSTx := len(msgBlock.STransactions)
 	stransactions := make([]hcjson.GetBlockTemplateResultTx, 0, numSTx)
 	stxIndex := make(map[chainhash.Hash]int64, numSTx)
+	votes := make([]string, 0, header.Voters)
+	tickets := make([]string, 0, header.FreshStake)
+	revocations := make([]string, 0, header.Revocations)
 	for i, stx := range msgBlock.STransactions {
 		stxHash := stx.TxHashFull()
 
@@ -2652,10 +2942,13 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 			txTypeStr = "error"
 		case stake.TxTypeSStx:
 			txTypeStr = "ticket"
+			tickets = append(tickets, stxHash.String())
 		case stake.TxTypeSSGen:
 			txTypeStr = "vote"
+			votes = append(votes, stxHash.String())
 		case stake.TxTypeSSRtx:
 			txTypeStr = "revocation"
+			revocations = append(revocations, stxHash.String())
 		}
 
 		fee := int64(0)
@@ -2753,23 +3046,50 @@ func (state *gbtWorkState) blockTemplateResult(bm *blockManager, useCoinbaseValu
 	targetDifficulty := fmt.Sprintf("%064x", blockchain.CompactToBig(header.Bits))
 	templateID := encodeTemplateID(state.prevHash, state.lastGenerated)
 	reply := hcjson.GetBlockTemplateResult{
-		Header:        hex.EncodeToString(headerBytes),
-		SigOpLimit:    blockchain.MaxSigOpsPerBlock,
-		SizeLimit:     maxBlockSize,
-		Transactions:  transactions,
-		STransactions: stransactions,
-		LongPollID:    templateID,
-		SubmitOld:     submitOld,
-		Target:        targetDifficulty,
-		MinTime:       state.minTimestamp.Unix(),
-		MaxTime:       maxTime.Unix(),
-		Mutable:       gbtMutableFields,
-		NonceRange:    gbtNonceRange,
-		Capabilities:  gbtCapabilities,
+		Header:            hex.EncodeToString(headerBytes),
+		SigOpLimit:        blockchain.MaxSigOpsPerBlock,
+		SizeLimit:         maxBlockSize,
+		Transactions:      transactions,
+		STransactions:     stransactions,
+		Version:           header.Version,
+		Height:            int64(header.Height),
+		PreviousBlockHash: header.PrevBlock.String(),
+		Bits:              strconv.FormatInt(int64(header.Bits), 16),
+		CurTime:           header.Timestamp.Unix(),
+		Votes:             votes,
+		Tickets:           tickets,
+		Revocations:       revocations,
+		LongPollID:        templateID,
+		SubmitOld:         submitOld,
+		Target:            targetDifficulty,
+		MinTime:           state.minTimestamp.Unix(),
+		MaxTime:           maxTime.Unix(),
+		Mutable:           gbtMutableFields,
+		NonceRange:        gbtNonceRange,
+		Capabilities:      gbtCapabilities,
 	}
 	if useCoinbaseValue {
+		// Provide the value available to the miner along with the
+		// outputs that must precede it in the coinbase of the block.
+		coinbaseOuts := msgBlock.Transactions[0].TxOut
+		if len(coinbaseOuts) <= gbtCoinbasePayoutIdx {
+			context := "Invalid coinbase"
+			errStr := fmt.Sprintf("The template coinbase only has "+
+				"%d outputs", len(coinbaseOuts))
+			return nil, rpcInternalError(errStr, context)
+		}
+		requiredOuts := make([]hcjson.GetBlockTemplateResultTxOut, 0,
+			gbtCoinbasePayoutIdx)
+		for _, txOut := range coinbaseOuts[:gbtCoinbasePayoutIdx] {
+			requiredOuts = append(requiredOuts,
+				hcjson.GetBlockTemplateResultTxOut{
+					Value:    txOut.Value,
+					PkScript: hex.EncodeToString(txOut.PkScript),
+				})
+		}
 		reply.CoinbaseAux = gbtCoinbaseAux
-		reply.CoinbaseValue = &msgBlock.Transactions[0].TxOut[0].Value
+		reply.CoinbaseValue = &coinbaseOuts[gbtCoinbasePayoutIdx].Value
+		reply.CoinbaseOutputs = requiredOuts
 	} else {
 		// Ensure the template has a valid payment address associated
 		// with it when a full coinbase is requested.
@@ -3167,6 +3487,85 @@ func handleGetBlockTemplate(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return nil, rpcInvalidError("Invalid mode: %v", mode)
 }
 
+// parseFilterType returns the committed filter type associated with the passed
+// RPC filter type name.
+func parseFilterType(filterType string) (wire.FilterType, error) {
+	switch filterType {
+	case "regular":
+		return wire.GCSFilterRegular, nil
+	}
+	return 0, rpcInvalidError("Unknown filter type %q", filterType)
+}
+
+// handleGetCFilter implements the getcfilter command.
+func handleGetCFilter(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.server.cfIndex == nil {
+		return nil, rpcInternalError("Committed filter index disabled",
+			"Configuration")
+	}
+
+	c := cmd.(*hcjson.GetCFilterCmd)
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	filterType, err := parseFilterType(*c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	filterBytes, err := s.server.cfIndex.FilterByBlockHash(hash, filterType)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Failed to fetch filter")
+	}
+	if len(filterBytes) == 0 {
+		return nil, &hcjson.RPCError{
+			Code:    hcjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+		}
+	}
+
+	return hex.EncodeToString(filterBytes), nil
+}
+
+// handleGetCFHeaders implements the getcfheaders command.
+func handleGetCFHeaders(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.server.cfIndex == nil {
+		return nil, rpcInternalError("Committed filter index disabled",
+			"Configuration")
+	}
+
+	c := cmd.(*hcjson.GetCFHeadersCmd)
+	hash, err := chainhash.NewHashFromStr(c.Hash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Hash)
+	}
+	filterType, err := parseFilterType(*c.FilterType)
+	if err != nil {
+		return nil, err
+	}
+
+	headerBytes, err := s.server.cfIndex.FilterHeaderByBlockHash(hash,
+		filterType)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Failed to fetch filter header")
+	}
+	if len(headerBytes) == 0 {
+		return nil, &hcjson.RPCError{
+			Code:    hcjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.Hash),
+		}
+	}
+
+	headerHash, err := chainhash.NewHash(headerBytes)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(),
+			"Failed to parse filter header")
+	}
+	return headerHash.String(), nil
+}
+
 // handleGetCoinSupply implements the getcoinsupply command.
 func handleGetCoinSupply(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	return s.chain.TotalSubsidy(), nil
@@ -3274,6 +3673,68 @@ func handleGetInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (in
 	return ret, nil
 }
 
+// mempoolRelatedResult returns the result of the getmempoolancestors and
+// getmempooldescendants commands for the passed related transaction entries.
+// It is either a sorted array of the transaction hashes or the entries keyed
+// by their hashes when verbose results are requested.
+func mempoolRelatedResult(entries map[string]*hcjson.GetMempoolEntryResult, verbose *bool) interface{} {
+	if verbose != nil && *verbose {
+		return entries
+	}
+
+	hashStrings := make([]string, 0, len(entries))
+	for hash := range entries {
+		hashStrings = append(hashStrings, hash)
+	}
+	sort.Strings(hashStrings)
+	return hashStrings
+}
+
+// handleGetMempoolAncestors implements the getmempoolancestors command.
+func handleGetMempoolAncestors(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetMempoolAncestorsCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxHash)
+	}
+
+	entries, err := s.server.txMemPool.MempoolAncestors(txHash)
+	if err != nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	return mempoolRelatedResult(entries, c.Verbose), nil
+}
+
+// handleGetMempoolDescendants implements the getmempooldescendants command.
+func handleGetMempoolDescendants(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetMempoolDescendantsCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxHash)
+	}
+
+	entries, err := s.server.txMemPool.MempoolDescendants(txHash)
+	if err != nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	return mempoolRelatedResult(entries, c.Verbose), nil
+}
+
+// handleGetMempoolEntry implements the getmempoolentry command.
+func handleGetMempoolEntry(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetMempoolEntryCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxHash)
+	}
+
+	entry, err := s.server.txMemPool.MempoolEntry(txHash)
+	if err != nil {
+		return nil, rpcNoTxInfoError(txHash)
+	}
+	return entry, nil
+}
+
 // handleGetMempoolInfo implements the getmempoolinfo command.
 func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	mempoolTxns := s.server.txMemPool.TxDescs()
@@ -3284,13 +3745,117 @@ func handleGetMempoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct
 	}
 
 	ret := &hcjson.GetMempoolInfoResult{
-		Size:  int64(len(mempoolTxns)),
-		Bytes: numBytes,
+		Size:          int64(len(mempoolTxns)),
+		Bytes:         numBytes,
+		MaxMempool:    cfg.MaxMempool * 1024 * 1024,
+		MempoolMinFee: s.server.txMemPool.MinFeeRate().ToCoin(),
+		MinRelayTxFee: cfg.minRelayTxFee.ToCoin(),
 	}
 
 	return ret, nil
 }
 
+// mempoolVotes returns the winning tickets of the block with the passed hash
+// and height split into the ones with a vote on the block in the memory pool
+// and the missing ones, along with how long ago the block arrived.
+func (s *rpcServer) mempoolVotes(hash *chainhash.Hash, height int64) (*hcjson.MempoolVotesResult, error) {
+	winners, _, _, err := s.chain.LotteryDataForBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	votes := s.server.txMemPool.VotesForBlocks([]chainhash.Hash{*hash})[0]
+	ticketVotes := make(map[chainhash.Hash]*mempool.VoteTx, len(votes))
+	for i := range votes {
+		ticketVotes[votes[i].SstxHash] = &votes[i]
+	}
+
+	result := &hcjson.MempoolVotesResult{
+		Hash:    hash.String(),
+		Height:  height,
+		Votes:   make([]hcjson.MempoolVoteResult, 0, len(winners)),
+		Missing: make([]string, 0, len(winners)),
+	}
+	for _, ticket := range winners {
+		vote, ok := ticketVotes[ticket]
+		if !ok {
+			result.Missing = append(result.Missing, ticket.String())
+			continue
+		}
+		result.Votes = append(result.Votes, hcjson.MempoolVoteResult{
+			Ticket:     ticket.String(),
+			Vote:       vote.SsgenHash.String(),
+			VoteBits:   vote.VoteBits,
+			BlockValid: vote.Vote,
+		})
+	}
+	if received, ok := s.server.blockManager.BlockArrival(hash); ok {
+		result.Received = received.Unix()
+		result.Age = time.Since(received).Seconds()
+	}
+	return result, nil
+}
+
+// handleGetMempoolVotes implements the getmempoolvotes command.
+func handleGetMempoolVotes(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetMempoolVotesCmd)
+
+	// Report the votes on the passed block or on all of the current tip
+	// candidates when no block is specified.
+	svh := s.server.chainParams.StakeValidationHeight
+	var hashes []chainhash.Hash
+	var height int64
+	if c.BlockHash != nil {
+		hash, err := chainhash.NewHashFromStr(*c.BlockHash)
+		if err != nil {
+			return nil, rpcDecodeHexError(*c.BlockHash)
+		}
+		var header wire.BlockHeader
+		err = s.server.db.View(func(dbTx database.Tx) error {
+			headerBytes, err := dbTx.FetchBlockHeader(hash)
+			if err != nil {
+				return err
+			}
+			return header.FromBytes(headerBytes)
+		})
+		if err != nil {
+			return nil, &hcjson.RPCError{
+				Code:    hcjson.ErrRPCBlockNotFound,
+				Message: fmt.Sprintf("Block not found: %v", *c.BlockHash),
+			}
+		}
+		height = int64(header.Height)
+		if height < svh-1 {
+			return nil, rpcInvalidError("Block at height %d is not "+
+				"voted on since it precedes the stake validation "+
+				"height %d", height, svh)
+		}
+		hashes = []chainhash.Hash{*hash}
+	} else {
+		height = s.chain.BestSnapshot().Height
+		if height < svh-1 {
+			return []hcjson.MempoolVotesResult{}, nil
+		}
+		var err error
+		hashes, err = s.chain.TipGeneration()
+		if err != nil {
+			return nil, rpcInternalError(err.Error(), "Could not get "+
+				"tip generation")
+		}
+	}
+
+	results := make([]hcjson.MempoolVotesResult, 0, len(hashes))
+	for i := range hashes {
+		result, err := s.mempoolVotes(&hashes[i], height)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(), "Could not get "+
+				"lottery data for block "+hashes[i].String())
+		}
+		results = append(results, *result)
+	}
+	return results, nil
+}
+
 // handleGetMiningInfo implements the getmininginfo command. We only return the
 // fields that are not related to wallet functionality.
 func handleGetMiningInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
@@ -3456,6 +4021,7 @@ func handleGetNetworkHashPS(s *rpcServer, cmd interface{}, closeChan <-chan stru
 func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	peers := s.server.Peers()
 	syncPeer := s.server.blockManager.SyncPeer()
+	evictionStatus := s.server.EvictionStatus()
 	infos := make([]*hcjson.GetPeerInfoResult, 0, len(peers))
 	for _, p := range peers {
 		statsSnap := p.StatsSnapshot()
@@ -3473,11 +4039,17 @@ func handleGetPeerInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 			Version:        statsSnap.Version,
 			SubVer:         statsSnap.UserAgent,
 			Inbound:        statsSnap.Inbound,
+			Eviction:       evictionStatus[statsSnap.ID],
+			Transport:      "v1",
+			SessionID:      statsSnap.SessionID,
 			StartingHeight: statsSnap.StartingHeight,
 			CurrentHeight:  statsSnap.LastBlock,
 			BanScore:       int32(p.banScore.Int()),
 			SyncNode:       p == syncPeer,
 		}
+		if statsSnap.V2Transport {
+			info.Transport = "v2"
+		}
 		if p.LastPingNonce() != 0 {
 			wait := float64(time.Since(statsSnap.LastPingTime).Nanoseconds())
 			// We actually want microseconds.
@@ -3669,6 +4241,42 @@ func handleGetRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan str
 	return *rawTxn, nil
 }
 
+// handleGetSpendingInfo implements the getspendinginfo command.
+func handleGetSpendingInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the spend index is not enabled.
+	spendIndex := s.server.spendIndex
+	if spendIndex == nil {
+		return nil, rpcInternalError("Spend index must be enabled "+
+			"(--spendindex)", "Configuration")
+	}
+
+	c := cmd.(*hcjson.GetSpendingInfoCmd)
+	txHash, err := chainhash.NewHashFromStr(c.TxHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.TxHash)
+	}
+
+	// The tree is not part of the index key since the hash and index alone
+	// identify the output.
+	outpoint := wire.OutPoint{Hash: *txHash, Index: c.Vout}
+	info, err := spendIndex.SpendingTx(&outpoint)
+	if err != nil {
+		context := "Failed to load spending information"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// Return nil when the output is unspent or does not exist.
+	if info == nil {
+		return nil, nil
+	}
+
+	return &hcjson.GetSpendingInfoResult{
+		TxID:   info.Hash.String(),
+		Vin:    info.Index,
+		Height: info.Height,
+	}, nil
+}
+
 // handleGetStakeDifficulty implements the getstakedifficulty command.
 func handleGetStakeDifficulty(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	best := s.chain.BestSnapshot()
@@ -3773,6 +4381,10 @@ func handleGetBlockchainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 	//	}
 	//}
 
+	// Blocks at or below the prune height no longer have their full data
+	// available.
+	pruneHeight := s.chain.PruneHeight()
+
 	// Generate rpc response.
 	response := hcjson.GetBlockChainInfoResult{
 		Chain:      s.server.chainParams.Name,
@@ -3786,8 +4398,12 @@ func handleGetBlockchainInfo(s *rpcServer, cmd interface{}, closeChan <-chan str
 		Difficulty:           float64(best.Bits),
 		DifficultyRatio:      getDifficultyRatio(best.Bits),
 		MaxBlockSize:         maxBlockSize,
+		Pruned:               cfg.Prune != 0 || pruneHeight >= 0,
 		//Deployments:          dInfo,
 	}
+	if pruneHeight >= 0 {
+		response.PruneHeight = pruneHeight
+	}
 
 	return response, nil
 }
@@ -3865,6 +4481,16 @@ func handleGetStakeVersionInfo(s *rpcServer, cmd interface{}, closeChan <-chan s
 	return result, nil
 }
 
+// handleGetStratumInfo implements the getstratuminfo command.
+func handleGetStratumInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if s.server.stratumServer == nil {
+		return nil, rpcMiscError("The Stratum server is not enabled. " +
+			"Restart with --stratum to enable it.")
+	}
+
+	return s.server.stratumServer.Info(), nil
+}
+
 // handleGetStakeVersions implements the getstakeversions command.
 func handleGetStakeVersions(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcjson.GetStakeVersionsCmd)
@@ -3908,6 +4534,125 @@ func handleGetStakeVersions(s *rpcServer, cmd interface{}, closeChan <-chan stru
 	return result, nil
 }
 
+// handleGetTicketInfo implements the getticketinfo command.
+func handleGetTicketInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the ticket index is not enabled.
+	ticketIndex := s.server.ticketIndex
+	if ticketIndex == nil {
+		return nil, rpcInternalError("Ticket index must be enabled "+
+			"(--ticketindex)", "Configuration")
+	}
+
+	c := cmd.(*hcjson.GetTicketInfoCmd)
+	ticket, err := chainhash.NewHashFromStr(c.Ticket)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.Ticket)
+	}
+
+	history, err := ticketIndex.TicketHistory(ticket)
+	if err != nil {
+		context := "Failed to load ticket history"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	if history == nil || len(history.Changes) == 0 {
+		return nil, hcjson.NewRPCError(hcjson.ErrRPCNoTxInfo,
+			fmt.Sprintf("No information available about ticket %v",
+				ticket))
+	}
+
+	changes := make([]hcjson.TicketStatusChangeResult, 0,
+		len(history.Changes))
+	for _, change := range history.Changes {
+		hash, err := s.chain.BlockHashByHeight(change.Height)
+		if err != nil {
+			context := "Failed to fetch block hash"
+			return nil, rpcInternalError(err.Error(), context)
+		}
+		changes = append(changes, hcjson.TicketStatusChangeResult{
+			Status:    change.Status.String(),
+			Height:    change.Height,
+			BlockHash: hash.String(),
+		})
+	}
+
+	// Tickets are always purchased by the block of their first change.
+	params := s.server.chainParams
+	purchaseHeight := history.Changes[0].Height
+	maturityHeight := purchaseHeight + int64(params.TicketMaturity)
+	return &hcjson.GetTicketInfoResult{
+		Hash:           ticket.String(),
+		Status:         history.Changes[len(history.Changes)-1].Status.String(),
+		Price:          hcutil.Amount(history.Price).ToCoin(),
+		PurchaseHeight: purchaseHeight,
+		MaturityHeight: maturityHeight,
+		ExpiryHeight:   maturityHeight + int64(params.TicketExpiry),
+		History:        changes,
+	}, nil
+}
+
+// handleGetTicketPoolInfo implements the getticketpoolinfo command.
+func handleGetTicketPoolInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	// Respond with an error if the ticket index is not enabled.
+	ticketIndex := s.server.ticketIndex
+	if ticketIndex == nil {
+		return nil, rpcInternalError("Ticket index must be enabled "+
+			"(--ticketindex)", "Configuration")
+	}
+
+	c := cmd.(*hcjson.GetTicketPoolInfoCmd)
+	height := s.chain.BestSnapshot().Height
+	if c.Height != nil {
+		height = *c.Height
+	}
+	hash, err := s.chain.BlockHashByHeight(height)
+	if err != nil {
+		return nil, &hcjson.RPCError{
+			Code: hcjson.ErrRPCOutOfRange,
+			Message: fmt.Sprintf("Block number out of range: %v",
+				height),
+		}
+	}
+
+	info, err := ticketIndex.TicketPoolInfo(height)
+	if err != nil {
+		context := "Failed to load ticket pool information"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+
+	// The ticket pool is empty after the genesis block, which is never
+	// indexed.
+	if info == nil {
+		if height != 0 {
+			return nil, rpcInternalError(fmt.Sprintf("No ticket "+
+				"pool information for height %d", height),
+				"Ticket index")
+		}
+		info = &indexers.TicketPoolInfo{}
+	}
+
+	changes := make([]hcjson.TicketChangeResult, 0, len(info.Changes))
+	for _, change := range info.Changes {
+		changes = append(changes, hcjson.TicketChangeResult{
+			Ticket: change.Ticket.String(),
+			Status: change.Status.String(),
+		})
+	}
+
+	return &hcjson.GetTicketPoolInfoResult{
+		Height:        height,
+		Hash:          hash.String(),
+		Live:          info.Live,
+		LiveValue:     hcutil.Amount(info.LiveValue).ToCoin(),
+		Immature:      info.Immature,
+		ImmatureValue: hcutil.Amount(info.ImmatureValue).ToCoin(),
+		Voted:         info.Voted,
+		Missed:        info.Missed,
+		Expired:       info.Expired,
+		Revoked:       info.Revoked,
+		Changes:       changes,
+	}, nil
+}
+
 // handleGetTicketPoolValue implements the getticketpoolvalue command.
 func handleGetTicketPoolValue(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	amt, err := s.server.blockManager.TicketPoolValue()
@@ -4024,6 +4769,68 @@ func handleGetVoteInfo(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return result, nil
 }
 
+// handleGetWinningTickets implements the getwinningtickets command.
+func handleGetWinningTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.GetWinningTicketsCmd)
+	hash, err := chainhash.NewHashFromStr(c.BlockHash)
+	if err != nil {
+		return nil, rpcDecodeHexError(c.BlockHash)
+	}
+	var header wire.BlockHeader
+	err = s.server.db.View(func(dbTx database.Tx) error {
+		headerBytes, err := dbTx.FetchBlockHeader(hash)
+		if err != nil {
+			return err
+		}
+		return header.FromBytes(headerBytes)
+	})
+	if err != nil {
+		return nil, &hcjson.RPCError{
+			Code:    hcjson.ErrRPCBlockNotFound,
+			Message: fmt.Sprintf("Block not found: %v", c.BlockHash),
+		}
+	}
+	svh := s.server.chainParams.StakeValidationHeight
+	if int64(header.Height) < svh {
+		return nil, rpcInvalidError("Block at height %d precedes the "+
+			"stake validation height %d", header.Height, svh)
+	}
+
+	// Reproduce the lottery from the parent and diff the winners against
+	// the votes in the block.
+	replay, err := s.chain.ReplayLottery(hash)
+	if err != nil {
+		context := "Failed to replay ticket lottery"
+		return nil, rpcInternalError(err.Error(), context)
+	}
+	winners := make([]hcjson.WinningTicketResult, 0, len(replay.Winners))
+	for _, winner := range replay.Winners {
+		winners = append(winners, hcjson.WinningTicketResult{
+			Ticket: winner.Ticket.String(),
+			Index:  int64(winner.Index),
+			Voted:  winner.Voted,
+		})
+	}
+	unselectedVotes := make([]string, 0, len(replay.UnselectedVotes))
+	for _, ticket := range replay.UnselectedVotes {
+		unselectedVotes = append(unselectedVotes, ticket.String())
+	}
+
+	return &hcjson.GetWinningTicketsResult{
+		Hash:             hash.String(),
+		Height:           int64(header.Height),
+		PoolSize:         int64(replay.PoolSize),
+		HeaderPoolSize:   header.PoolSize,
+		FinalState:       hex.EncodeToString(replay.FinalState[:]),
+		HeaderFinalState: hex.EncodeToString(header.FinalState[:]),
+		Winners:          winners,
+		UnselectedVotes:  unselectedVotes,
+		Verified: replay.PoolSize == int(header.PoolSize) &&
+			replay.FinalState == header.FinalState &&
+			len(replay.UnselectedVotes) == 0,
+	}, nil
+}
+
 // bigToLEUint256 returns the passed big integer as an unsigned 256-bit integer
 // encoded as little-endian bytes.  Numbers which are larger than the max
 // unsigned 256-bit integer are truncated.
@@ -4530,6 +5337,21 @@ func handleHelp(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return help, nil
 }
 
+// handleListBanned implements the listbanned command.
+func handleListBanned(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	entries := s.server.banList.Entries()
+	bans := make([]hcjson.ListBannedResult, 0, len(entries))
+	for _, entry := range entries {
+		bans = append(bans, hcjson.ListBannedResult{
+			Address:     entry.Subnet.String(),
+			BanCreated:  entry.Created.Unix(),
+			BannedUntil: entry.Expires.Unix(),
+			BanReason:   entry.Reason,
+		})
+	}
+	return bans, nil
+}
+
 // handleLiveTickets implements the livetickets command.
 func handleLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	lt, err := s.server.blockManager.chain.LiveTickets()
@@ -4546,6 +5368,20 @@ func handleLiveTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return hcjson.LiveTicketsResult{Tickets: ltString}, nil
 }
 
+// handleLoadMempool implements the loadmempool command.
+func handleLoadMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	stats, err := s.server.loadMempool()
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Failed to load mempool")
+	}
+
+	return &hcjson.LoadMempoolResult{
+		Accepted: len(stats.Accepted),
+		Orphans:  stats.Orphans,
+		Rejected: stats.Rejected,
+	}, nil
+}
+
 // handleMissedTickets implements the missedtickets command.
 func handleMissedTickets(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	mt, err := s.server.blockManager.chain.MissedTickets()
@@ -4575,6 +5411,83 @@ func handlePing(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (inter
 	return nil, nil
 }
 
+// handleProjectStakeDiff implements the projectstakediff command.
+func handleProjectStakeDiff(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.ProjectStakeDiffCmd)
+
+	// Calculate the number of blocks which remain in the current stake
+	// difficulty interval before the next retarget.
+	best := s.chain.BestSnapshot()
+	bestHeight := best.Height
+	bestHeader, err := s.chain.HeaderByHeight(bestHeight)
+	if err != nil {
+		return nil, rpcInternalError(err.Error(), "Could not fetch "+
+			"best block header")
+	}
+	intervalSize := activeNetParams.StakeDiffWindowSize
+	nextRetargetHeight := (bestHeight/intervalSize + 1) * intervalSize
+	remaining := nextRetargetHeight - bestHeight - 1
+	if int64(len(c.Purchases)) > remaining {
+		return nil, rpcInvalidError("%d ticket purchase volumes were "+
+			"specified, but only %d blocks remain in the current "+
+			"interval", len(c.Purchases), remaining)
+	}
+
+	windows := int64(1)
+	if c.Windows != nil {
+		windows = *c.Windows
+	}
+	if windows < 1 || windows > maxStakeDiffProjectionWindows {
+		return nil, rpcInvalidError("Number of windows must be between "+
+			"1 and %d", maxStakeDiffProjectionWindows)
+	}
+
+	// Assume the blocks without a specified purchase volume include as
+	// many tickets as are selected to vote in each block, which keeps the
+	// pool size steady, unless told otherwise.
+	futurePurchases := int64(activeNetParams.TicketsPerBlock)
+	if c.FuturePurchases != nil {
+		futurePurchases = *c.FuturePurchases
+	}
+
+	numBlocks := remaining + (windows-1)*intervalSize
+	purchases := make([]int64, numBlocks)
+	for i := range purchases {
+		if i < len(c.Purchases) {
+			purchases[i] = c.Purchases[i]
+			continue
+		}
+		purchases[i] = futurePurchases
+	}
+	projections, err := s.chain.ProjectStakeDifficulty(purchases)
+	if err != nil {
+		return nil, rpcInvalidError("Could not project stake "+
+			"difficulty: %v", err)
+	}
+	if len(projections) == 0 {
+		return nil, rpcInternalError("No stake difficulty projected",
+			"")
+	}
+
+	results := make([]hcjson.StakeDiffWindowResult, 0, len(projections))
+	for _, projection := range projections {
+		results = append(results, hcjson.StakeDiffWindowResult{
+			Height:    projection.Height,
+			StakeDiff: hcutil.Amount(projection.StakeDiff).ToCoin(),
+			PoolSize:  projection.PoolSize,
+			Immature:  projection.ImmatureTickets,
+		})
+	}
+
+	return &hcjson.ProjectStakeDiffResult{
+		Height:             bestHeight,
+		Current:            hcutil.Amount(bestHeader.SBits).ToCoin(),
+		Next:               results[0].StakeDiff,
+		NextRetargetHeight: results[0].Height,
+		Windows:            results,
+	}, nil
+}
+
 // handleRebroadcastMissed implements the rebroadcastmissed command.
 func handleRebroadcastMissed(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	hash, height := s.server.blockManager.chainState.Best()
@@ -4632,6 +5545,15 @@ func handleRebroadcastWinners(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return nil, nil
 }
 
+// handleSaveMempool implements the savemempool command.
+func handleSaveMempool(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	if err := s.server.saveMempool(); err != nil {
+		return nil, rpcInternalError(err.Error(), "Failed to save mempool")
+	}
+
+	return nil, nil
+}
+
 // retrievedTx represents a transaction that was either loaded from the
 // transaction memory pool or from the database.  When a transaction is loaded
 // from the database, it is loaded with the raw serialized bytes while the
@@ -5201,6 +6123,68 @@ func handleSendRawTransaction(s *rpcServer, cmd interface{}, closeChan <-chan st
 	return tx.Hash().String(), nil
 }
 
+// handleSetBan implements the setban command.
+func handleSetBan(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.SetBanCmd)
+
+	subnet, err := connmgr.ParseSubnet(c.Subnet)
+	if err != nil {
+		return nil, rpcInvalidError("Invalid IP/Subnet: %v", err)
+	}
+
+	switch c.SubCmd {
+	case hcjson.SBAdd:
+		if s.server.banList.IsBannedSubnet(subnet) {
+			return nil, rpcMiscError("IP/Subnet already banned")
+		}
+
+		// The ban time is the number of seconds to ban the subnet for
+		// unless it is absolute, in which case it is the unix time the
+		// ban expires.  The configured ban duration is used when it is
+		// not set.
+		var banTime int64
+		if c.BanTime != nil {
+			banTime = *c.BanTime
+		}
+		if banTime < 0 {
+			return nil, rpcInvalidError("Ban time must not be negative")
+		}
+		now := time.Now()
+		expires := now.Add(cfg.BanDuration)
+		switch {
+		case c.Absolute != nil && *c.Absolute:
+			expires = time.Unix(banTime, 0)
+			if !expires.After(now) {
+				return nil, rpcInvalidError("Absolute ban time %d "+
+					"is in the past", banTime)
+			}
+		case banTime > 0:
+			expires = now.Add(time.Duration(banTime) * time.Second)
+		}
+
+		err := s.server.BanSubnet(subnet, expires, "manually added")
+		if err != nil {
+			return nil, rpcInternalError(err.Error(),
+				"Could not save ban list")
+		}
+
+	case hcjson.SBRemove:
+		banned, err := s.server.banList.Unban(subnet)
+		if err != nil {
+			return nil, rpcInternalError(err.Error(),
+				"Could not save ban list")
+		}
+		if !banned {
+			return nil, rpcMiscError("IP/Subnet was not banned")
+		}
+
+	default:
+		return nil, rpcInvalidError("Invalid subcommand for setban")
+	}
+
+	return nil, nil
+}
+
 // handleSetGenerate implements the setgenerate command.
 func handleSetGenerate(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
 	c := cmd.(*hcjson.SetGenerateCmd)
@@ -5270,6 +6254,44 @@ func handleSubmitBlock(s *rpcServer, cmd interface{}, closeChan <-chan struct{})
 	return nil, nil
 }
 
+// handleTestMempoolAccept implements the testmempoolaccept command.
+func handleTestMempoolAccept(s *rpcServer, cmd interface{}, closeChan <-chan struct{}) (interface{}, error) {
+	c := cmd.(*hcjson.TestMempoolAcceptCmd)
+
+	txns := make([]*hcutil.Tx, 0, len(c.RawTxs))
+	for _, hexStr := range c.RawTxs {
+		if len(hexStr)%2 != 0 {
+			hexStr = "0" + hexStr
+		}
+		serializedTx, err := hex.DecodeString(hexStr)
+		if err != nil {
+			return nil, rpcDecodeHexError(hexStr)
+		}
+		msgtx := wire.NewMsgTx()
+		err = msgtx.Deserialize(bytes.NewReader(serializedTx))
+		if err != nil {
+			return nil, rpcDeserializationError("Could not decode Tx: %v",
+				err)
+		}
+		txns = append(txns, hcutil.NewTx(msgtx))
+	}
+
+	results := s.server.txMemPool.TestAcceptTransactions(txns,
+		*c.AllowHighFees)
+	reply := make([]hcjson.TestMempoolAcceptResult, 0, len(results))
+	for _, result := range results {
+		r := hcjson.TestMempoolAcceptResult{
+			TxID:    result.Tx.Hash().String(),
+			Allowed: result.Err == nil,
+		}
+		if result.Err != nil {
+			r.RejectReason = result.Err.Error()
+		}
+		reply = append(reply, r)
+	}
+	return reply, nil
+}
+
 // min gets the minimum amount from a slice of amounts.
 func min(s []hcutil.Amount) hcutil.Amount {
 	if len(s) == 0 {
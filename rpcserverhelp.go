@@ -35,6 +35,9 @@ var helpDescsEnUS = map[string]string{
 	"addnode-addr":      "IP address and port of the peer to operate on",
 	"addnode-subcmd":    "'add' to add a persistent peer, 'remove' to remove a persistent peer, or 'onetry' to try a single connection to a peer",
 
+	// ClearBannedCmd help.
+	"clearbanned--synopsis": "Removes all bans of IP addresses and subnets.",
+
 	// NodeCmd help.
 	"node--synopsis":     "Attempts to add or remove a peer.",
 	"node-subcmd":        "'disconnect' to remove all matching non-persistent peers, 'remove' to remove a persistent peer, or 'connect' to connect to a peer",
@@ -220,6 +223,48 @@ var helpDescsEnUS = map[string]string{
 	"getaddednodeinfo--condition1": "dns=true",
 	"getaddednodeinfo--result0":    "List of added peers",
 
+	// GetAddressBalanceCmd help.
+	"getaddressbalance--synopsis": "Returns the balance of an address and the total amount it has ever received.\n" +
+		"NOTE: This requires the address utxo index to be enabled via the --addrutxoindex option.",
+	"getaddressbalance-address": "The address to query the balance for",
+
+	// GetAddressBalanceResult help.
+	"getaddressbalanceresult-address":  "The address that was queried",
+	"getaddressbalanceresult-balance":  "The total value of the unspent outputs paying to the address in coins",
+	"getaddressbalanceresult-received": "The total value of all outputs that have ever paid to the address in coins",
+
+	// GetAddressDeltasCmd help.
+	"getaddressdeltas--synopsis": "Returns the changes to the balance of an address in the order they were applied to the main chain.\n" +
+		"NOTE: This requires the address utxo index to be enabled via the --addrutxoindex option.",
+	"getaddressdeltas-address": "The address to query the balance changes for",
+	"getaddressdeltas-skip":    "The number of leading balance changes to leave out of the results",
+	"getaddressdeltas-count":   "The maximum number of balance changes to return",
+	"getaddressdeltas-reverse": "Specifies that the balance changes should be returned in reverse order (newest first)",
+
+	// AddressDeltaResult help.
+	"addressdeltaresult-txid":    "The hash of the transaction that changed the balance",
+	"addressdeltaresult-index":   "The input index when the balance change spends an output, otherwise the output index",
+	"addressdeltaresult-isinput": "Whether or not the balance change spends a previous output",
+	"addressdeltaresult-tree":    "The tree of the transaction that changed the balance",
+	"addressdeltaresult-amount":  "The amount of the balance change in coins (negative for spends)",
+	"addressdeltaresult-height":  "The height of the block that contains the transaction",
+
+	// GetAddressUtxosCmd help.
+	"getaddressutxos--synopsis": "Returns the unspent outputs paying to an address ordered by transaction hash and output index.\n" +
+		"NOTE: This requires the address utxo index to be enabled via the --addrutxoindex option.",
+	"getaddressutxos-address": "The address to query the unspent outputs for",
+	"getaddressutxos-skip":    "The number of leading unspent outputs to leave out of the results",
+	"getaddressutxos-count":   "The maximum number of unspent outputs to return",
+
+	// AddressUtxoResult help.
+	"addressutxoresult-txid":          "The hash of the transaction that created the output",
+	"addressutxoresult-vout":          "The index of the output",
+	"addressutxoresult-tree":          "The tree of the transaction that created the output",
+	"addressutxoresult-amount":        "The value of the output in coins",
+	"addressutxoresult-scriptversion": "The version of the public key script",
+	"addressutxoresult-scriptpubkey":  "The hex-encoded public key script",
+	"addressutxoresult-height":        "The height of the block that contains the transaction",
+
 	// GetBestBlockResult help.
 	"getbestblockresult-hash":   "Hex-encoded bytes of the best block hash",
 	"getbestblockresult-height": "Height of the best block",
@@ -232,6 +277,23 @@ var helpDescsEnUS = map[string]string{
 	"getbestblockhash--synopsis": "Returns the hash of the of the best (most recent) block in the longest block chain.",
 	"getbestblockhash--result0":  "The hex-encoded block hash",
 
+	// GetBlockChainInfoCmd help.
+	"getblockchaininfo--synopsis": "Returns information about the current state of the block chain.",
+
+	// GetBlockChainInfoResult help.
+	"getblockchaininforesult-chain":                "The name of the network the node is on",
+	"getblockchaininforesult-blocks":               "The height of the best block in the main chain",
+	"getblockchaininforesult-headers":              "The height of the best known header",
+	"getblockchaininforesult-bestblockhash":        "The hash of the best block in the main chain",
+	"getblockchaininforesult-difficulty":           "The compact difficulty bits of the best block",
+	"getblockchaininforesult-verificationprogress": "An estimate of the fraction of the chain which has been verified",
+	"getblockchaininforesult-chainwork":            "The hex-encoded total work of the main chain",
+	"getblockchaininforesult-syncheight":           "The height of the best block announced by the peers of the node",
+	"getblockchaininforesult-difficultyratio":      "The proof-of-work difficulty of the best block as a multiple of the minimum difficulty",
+	"getblockchaininforesult-maxblocksize":         "The maximum allowed block size",
+	"getblockchaininforesult-pruned":               "Whether or not the data of old blocks is pruned, in which case the node advertises the SFNodeNetworkLimited service instead of SFNodeNetwork",
+	"getblockchaininforesult-pruneheight":          "The height of the most recent block whose data was pruned (only present when block data was pruned)",
+
 	// GetBlockCmd help.
 	"getblock--synopsis":   "Returns information about a block given its hash.",
 	"getblock-hash":        "The hash of the block",
@@ -398,6 +460,14 @@ var helpDescsEnUS = map[string]string{
 	"getblocktemplateresult-reject-reason":     "Reason the proposal was invalid as-is (only applies to proposal responses)",
 	"getblocktemplateresult-stransactions":     "Stake transactions",
 	"getblocktemplateresult-header":            "Block header",
+	"getblocktemplateresult-votes":             "Hashes of the votes included in the stake transactions",
+	"getblocktemplateresult-tickets":           "Hashes of the ticket purchases included in the stake transactions",
+	"getblocktemplateresult-revocations":       "Hashes of the revocations included in the stake transactions",
+	"getblocktemplateresult-coinbaseoutputs":   "Outputs that must precede the output paying coinbasevalue in the coinbase (only provided with coinbasevalue)",
+
+	// GetBlockTemplateResultTxOut help.
+	"getblocktemplateresulttxout-value":    "The output value in atoms",
+	"getblocktemplateresulttxout-pkscript": "Hex-encoded public key script of the output",
 
 	// GetBlockTemplateCmd help.
 	"getblocktemplate--synopsis": "Returns a JSON object with information necessary to construct a block to mine or accepts a proposal to validate.\n" +
@@ -420,6 +490,17 @@ var helpDescsEnUS = map[string]string{
 	"getdifficulty--synopsis": "Returns the proof-of-work difficulty as a multiple of the minimum difficulty.",
 	"getdifficulty--result0":  "The difficulty",
 
+	// GetSpendingInfoCmd help.
+	"getspendinginfo--synopsis": "Returns the transaction input which spends an output in the main chain or null when the output is unspent or unknown.\n" +
+		"NOTE: This requires the spend index to be enabled via the --spendindex option.",
+	"getspendinginfo-txhash": "The hash of the transaction that created the output",
+	"getspendinginfo-vout":   "The index of the output",
+
+	// GetSpendingInfoResult help.
+	"getspendinginforesult-txid":   "The hash of the spending transaction",
+	"getspendinginforesult-vin":    "The index of the input which spends the output",
+	"getspendinginforesult-height": "The height of the block that contains the spending transaction",
+
 	// GetStakeDifficultyCmd help.
 	"getstakedifficulty--synopsis":     "Returns the proof-of-stake difficulty.",
 	"getstakedifficultyresult-current": "The current top block's stake difficulty",
@@ -451,6 +532,23 @@ var helpDescsEnUS = map[string]string{
 	"versionbits-version":                  "The version of the vote.",
 	"versionbits-bits":                     "The bits assigned by the vote.",
 
+	// GetStratumInfoCmd help.
+	"getstratuminfo--synopsis":           "Returns the state of the built-in Stratum mining server and the statistics of the workers that are connected to it.",
+	"getstratuminforesult-listeners":     "The addresses the Stratum server is listening on.",
+	"getstratuminforesult-connections":   "The number of connected Stratum clients.",
+	"getstratuminforesult-jobid":         "The ID of the job currently handed out to clients.",
+	"getstratuminforesult-height":        "The height of the block the current job builds.",
+	"getstratuminforesult-workers":       "Statistics for each worker that is authorized on a connected client.",
+	"stratumworkerresult-name":           "The name the worker authorized with.",
+	"stratumworkerresult-connections":    "The number of connections the worker is currently authorized on.",
+	"stratumworkerresult-difficulty":     "The share difficulty most recently assigned to the worker.",
+	"stratumworkerresult-acceptedshares": "The number of shares accepted from the worker.",
+	"stratumworkerresult-rejectedshares": "The number of invalid, duplicate, or low difficulty shares from the worker.",
+	"stratumworkerresult-staleshares":    "The number of shares from the worker for jobs that are no longer valid.",
+	"stratumworkerresult-blocksfound":    "The number of blocks found by the worker that were accepted by the chain.",
+	"stratumworkerresult-hashespersec":   "The estimated hash rate of the worker based on its accepted shares.",
+	"stratumworkerresult-lastshare":      "The time of the last accepted share from the worker in seconds since 1 Jan 1970 GMT (0 if none).",
+
 	// GetVoteInfo
 	"getvoteinfo--synopsis":           "Returns the vote info statistics.",
 	"getvoteinfo-version":             "The stake version.",
@@ -478,6 +576,26 @@ var helpDescsEnUS = map[string]string{
 	"choice-count":                    "How many votes received.",
 	"choice-progress":                 "Progress of the overall count.",
 
+	// GetWinningTicketsCmd help.
+	"getwinningtickets--synopsis": "Reproduces the ticket lottery which selected the tickets that may vote on a block from the header and live tickets of its parent and compares the winners against the votes in the block.",
+	"getwinningtickets-blockhash": "The hash of the block",
+
+	// GetWinningTicketsResult help.
+	"getwinningticketsresult-hash":             "The hash of the block",
+	"getwinningticketsresult-height":           "The height of the block",
+	"getwinningticketsresult-poolsize":         "The number of live tickets the winners were selected from",
+	"getwinningticketsresult-headerpoolsize":   "The ticket pool size committed to by the block header",
+	"getwinningticketsresult-finalstate":       "The hex-encoded final state of the reproduced lottery",
+	"getwinningticketsresult-headerfinalstate": "The hex-encoded final state committed to by the block header",
+	"getwinningticketsresult-winners":          "The winning tickets in the order they were selected",
+	"getwinningticketsresult-unselectedvotes":  "The tickets voted by the block which were not selected",
+	"getwinningticketsresult-verified":         "Whether the pool size and final state match the block header and the block only contains votes of winning tickets",
+
+	// WinningTicketResult help.
+	"winningticketresult-ticket": "The hash of the ticket",
+	"winningticketresult-index":  "The index of the ticket in the live tickets sorted by their hashes",
+	"winningticketresult-voted":  "Whether the block contains a vote of the ticket",
+
 	// GetGenerateCmd help.
 	"getgenerate--synopsis": "Returns if the server is set to generate coins (mine) or not.",
 	"getgenerate--result0":  "True if mining, false if not",
@@ -525,12 +643,69 @@ var helpDescsEnUS = map[string]string{
 	// GetInfoCmd help.
 	"getinfo--synopsis": "Returns a JSON object containing various state info.",
 
+	// GetMempoolAncestorsCmd help.
+	"getmempoolancestors--synopsis":   "Returns the unconfirmed ancestors in the memory pool of a transaction in the memory pool.",
+	"getmempoolancestors-txhash":      "The hash of the transaction",
+	"getmempoolancestors-verbose":     "Returns JSON objects keyed by the transaction hashes when true or an array of transaction hashes when false",
+	"getmempoolancestors--condition0": "verbose=false",
+	"getmempoolancestors--condition1": "verbose=true",
+	"getmempoolancestors--result0":    "Array of transaction hashes",
+
+	// GetMempoolDescendantsCmd help.
+	"getmempooldescendants--synopsis":   "Returns the descendants in the memory pool of a transaction in the memory pool.",
+	"getmempooldescendants-txhash":      "The hash of the transaction",
+	"getmempooldescendants-verbose":     "Returns JSON objects keyed by the transaction hashes when true or an array of transaction hashes when false",
+	"getmempooldescendants--condition0": "verbose=false",
+	"getmempooldescendants--condition1": "verbose=true",
+	"getmempooldescendants--result0":    "Array of transaction hashes",
+
+	// GetMempoolEntryCmd help.
+	"getmempoolentry--synopsis": "Returns information about a transaction in the memory pool along with the aggregated counts, sizes and fees of its ancestors and descendants in the memory pool.",
+	"getmempoolentry-txhash":    "The hash of the transaction",
+
+	// GetMempoolEntryResult help.
+	"getmempoolentryresult-size":             "Transaction size in bytes",
+	"getmempoolentryresult-fee":              "Transaction fee in HC",
+	"getmempoolentryresult-time":             "Local time transaction entered pool in seconds since 1 Jan 1970 GMT",
+	"getmempoolentryresult-height":           "Block height when transaction entered the pool",
+	"getmempoolentryresult-startingpriority": "Priority when transaction entered the pool",
+	"getmempoolentryresult-currentpriority":  "Current priority",
+	"getmempoolentryresult-ancestorcount":    "Number of unconfirmed ancestors in the pool, including the transaction itself",
+	"getmempoolentryresult-ancestorsize":     "Total size in bytes of the transaction and its unconfirmed ancestors in the pool",
+	"getmempoolentryresult-ancestorfees":     "Total fees in HC of the transaction and its unconfirmed ancestors in the pool",
+	"getmempoolentryresult-descendantcount":  "Number of descendants in the pool, including the transaction itself",
+	"getmempoolentryresult-descendantsize":   "Total size in bytes of the transaction and its descendants in the pool",
+	"getmempoolentryresult-descendantfees":   "Total fees in HC of the transaction and its descendants in the pool",
+	"getmempoolentryresult-depends":          "Unconfirmed transactions in the pool used as inputs for this transaction",
+	"getmempoolentryresult-spentby":          "Transactions in the pool spending outputs of this transaction",
+
 	// GetMempoolInfoCmd help.
 	"getmempoolinfo--synopsis": "Returns memory pool information",
 
 	// GetMempoolInfoResult help.
-	"getmempoolinforesult-bytes": "Size in bytes of the mempool",
-	"getmempoolinforesult-size":  "Number of transactions in the mempool",
+	"getmempoolinforesult-bytes":         "Size in bytes of the mempool",
+	"getmempoolinforesult-size":          "Number of transactions in the mempool",
+	"getmempoolinforesult-maxmempool":    "Maximum size in bytes of the mempool (0 when unlimited)",
+	"getmempoolinforesult-mempoolminfee": "Minimum fee rate in HC/kB for regular transactions and tickets to be accepted, which is raised above the minimum relay fee while the mempool is full",
+	"getmempoolinforesult-minrelaytxfee": "Configured minimum relay fee rate in HC/kB",
+
+	// GetMempoolVotesCmd help.
+	"getmempoolvotes--synopsis": "Returns the tickets selected to vote on each of the current tip candidates split into the ones with a vote in the memory pool and the missing ones.",
+	"getmempoolvotes-blockhash": "Report the votes on this block instead of the current tip candidates",
+
+	// MempoolVotesResult help.
+	"mempoolvotesresult-hash":     "The hash of the block",
+	"mempoolvotesresult-height":   "The height of the block",
+	"mempoolvotesresult-received": "The time the block was accepted in seconds since 1 Jan 1970 GMT (omitted when unknown)",
+	"mempoolvotesresult-age":      "The number of seconds since the block was accepted (omitted when unknown)",
+	"mempoolvotesresult-votes":    "The selected tickets with a vote in the memory pool",
+	"mempoolvotesresult-missing":  "The selected tickets without a vote in the memory pool",
+
+	// MempoolVoteResult help.
+	"mempoolvoteresult-ticket":     "The hash of the ticket",
+	"mempoolvoteresult-vote":       "The hash of the vote",
+	"mempoolvoteresult-votebits":   "The vote bits of the vote",
+	"mempoolvoteresult-blockvalid": "Whether the vote approves the regular transaction tree of the block",
 
 	// GetMiningInfoResult help.
 	"getmininginforesult-blocks":           "Height of the latest best block",
@@ -579,6 +754,9 @@ var helpDescsEnUS = map[string]string{
 	"getpeerinforesult-version":        "The protocol version of the peer",
 	"getpeerinforesult-subver":         "The user agent of the peer",
 	"getpeerinforesult-inbound":        "Whether or not the peer is an inbound connection",
+	"getpeerinforesult-eviction":       "The eviction status of an inbound peer: the reason it is protected from eviction (netgroup, ping, txrelay, blockrelay, uptime or whitelisted), candidate when it may be evicted, or next when it is evicted to make room for the next inbound peer once the maximum number of peers is reached",
+	"getpeerinforesult-transport":      "The transport of the connection (v1 or the encrypted v2)",
+	"getpeerinforesult-sessionid":      "The session id of an encrypted v2 connection",
 	"getpeerinforesult-startingheight": "The latest block height the peer knew about when the connection was established",
 	"getpeerinforesult-currentheight":  "The current height of the peer",
 	"getpeerinforesult-banscore":       "The ban score",
@@ -612,6 +790,47 @@ var helpDescsEnUS = map[string]string{
 	"getrawtransaction--condition1": "verbose=true",
 	"getrawtransaction--result0":    "Hex-encoded bytes of the serialized transaction",
 
+	// GetTicketInfoCmd help.
+	"getticketinfo--synopsis": "Returns the price, status, and status changes of a ticket in the main chain.\n" +
+		"NOTE: This requires the ticket history index to be enabled via the --ticketindex option.",
+	"getticketinfo-ticket": "The hash of the ticket",
+
+	// GetTicketInfoResult help.
+	"getticketinforesult-hash":           "The hash of the ticket",
+	"getticketinforesult-status":         "The current status of the ticket (immature, live, voted, missed, expired, or revoked)",
+	"getticketinforesult-price":          "The price of the ticket in coins",
+	"getticketinforesult-purchaseheight": "The height of the block that contains the ticket",
+	"getticketinforesult-maturityheight": "The height of the block at which the ticket becomes live",
+	"getticketinforesult-expiryheight":   "The height of the block at which the ticket expires unless it has been selected to vote",
+	"getticketinforesult-history":        "The status changes of the ticket in the order they were made",
+
+	// TicketStatusChangeResult help.
+	"ticketstatuschangeresult-status":    "The new status of the ticket",
+	"ticketstatuschangeresult-height":    "The height of the block which changed the status",
+	"ticketstatuschangeresult-blockhash": "The hash of the block which changed the status",
+
+	// GetTicketPoolInfoCmd help.
+	"getticketpoolinfo--synopsis": "Returns the state of the ticket pool after a main chain block along with the status changes of tickets made by the block.\n" +
+		"NOTE: This requires the ticket history index to be enabled via the --ticketindex option.",
+	"getticketpoolinfo-height": "The height of the block (default: the current best block)",
+
+	// GetTicketPoolInfoResult help.
+	"getticketpoolinforesult-height":        "The height of the block",
+	"getticketpoolinforesult-hash":          "The hash of the block",
+	"getticketpoolinforesult-live":          "The number of live tickets",
+	"getticketpoolinforesult-livevalue":     "The total price of the live tickets in coins",
+	"getticketpoolinforesult-immature":      "The number of immature tickets",
+	"getticketpoolinforesult-immaturevalue": "The total price of the immature tickets in coins",
+	"getticketpoolinforesult-voted":         "The number of tickets which have voted up to the block",
+	"getticketpoolinforesult-missed":        "The number of tickets which have been missed up to the block",
+	"getticketpoolinforesult-expired":       "The number of tickets which have expired up to the block",
+	"getticketpoolinforesult-revoked":       "The number of tickets which have been revoked up to the block",
+	"getticketpoolinforesult-changes":       "The status changes of tickets made by the block in the order they were made",
+
+	// TicketChangeResult help.
+	"ticketchangeresult-ticket": "The hash of the ticket",
+	"ticketchangeresult-status": "The new status of the ticket",
+
 	// GetTicketPoolValue help.
 	"getticketpoolvalue--synopsis": "Return the current value of all locked funds in the ticket pool",
 	"getticketpoolvalue--result0":  "Total value of ticket pool",
@@ -651,6 +870,15 @@ var helpDescsEnUS = map[string]string{
 	"help--result0":    "List of commands",
 	"help--result1":    "Help for specified command",
 
+	// ListBannedCmd help.
+	"listbanned--synopsis": "Returns the banned IP addresses and subnets.",
+
+	// ListBannedResult help.
+	"listbannedresult-address":     "The banned IP address or subnet in CIDR notation",
+	"listbannedresult-bancreated":  "The time the ban was created in seconds since 1 Jan 1970 GMT",
+	"listbannedresult-banneduntil": "The time the ban expires in seconds since 1 Jan 1970 GMT",
+	"listbannedresult-banreason":   "The reason the IP address or subnet was banned",
+
 	// PingCmd help.
 	"ping--synopsis": "Queues a ping to be sent to each connected peer.\n" +
 		"Ping times are provided by getpeerinfo via the pingtime and pingwait fields.",
@@ -661,6 +889,9 @@ var helpDescsEnUS = map[string]string{
 	// RebroadcastWinnerCmd help.
 	"rebroadcastwinners--synopsis": "Asks the daemon to rebroadcast the winners of the voting lottery.\n",
 
+	// SaveMempoolCmd help.
+	"savemempool--synopsis": "Saves the transaction memory pool to mempool.dat in the data directory.",
+
 	// SearchRawTransactionsCmd help.
 	"searchrawtransactions--synopsis": "Returns raw data for transactions involving the passed address.\n" +
 		"Returned transactions are pulled from both the database, and transactions currently in the mempool.\n" +
@@ -684,6 +915,14 @@ var helpDescsEnUS = map[string]string{
 	"sendrawtransaction-allowhighfees": "Whether or not to allow insanely high fees (hcd does not yet implement this parameter, so it has no effect)",
 	"sendrawtransaction--result0":      "The hash of the transaction",
 
+	// SetBanCmd help.
+	"setban--synopsis": "Bans an IP address or subnet and disconnects the peers within it, or removes a ban.\n" +
+		"Bans are saved to the data directory and survive restarts.",
+	"setban-subnet":   "The IP address or subnet in CIDR notation (e.g. 192.168.0.0/24) to operate on",
+	"setban-subcmd":   "'add' to ban the IP address or subnet or 'remove' to remove its ban",
+	"setban-bantime":  "The number of seconds to ban the IP address or subnet for or 0 to use the configured ban duration (--banduration)",
+	"setban-absolute": "Specifies that bantime is the time the ban expires in seconds since 1 Jan 1970 GMT",
+
 	// SetGenerateCmd help.
 	"setgenerate--synopsis":    "Set the server to generate coins (mine) or not.",
 	"setgenerate-generate":     "Use true to enable generation, false to disable it",
@@ -704,6 +943,16 @@ var helpDescsEnUS = map[string]string{
 	"submitblock--condition1": "Block rejected",
 	"submitblock--result1":    "The reason the block was rejected",
 
+	// TestMempoolAcceptCmd help.
+	"testmempoolaccept--synopsis":     "Returns whether serialized, hex-encoded transactions would be accepted to the memory pool without relaying them or adding them to the pool. The transactions are tested in order and may spend the outputs of the earlier transactions which would be accepted.",
+	"testmempoolaccept-rawtxs":        "Serialized, hex-encoded signed transactions",
+	"testmempoolaccept-allowhighfees": "Whether or not to allow insanely high fees",
+
+	// TestMempoolAcceptResult help.
+	"testmempoolacceptresult-txid":          "The hash of the transaction",
+	"testmempoolacceptresult-allowed":       "Whether the transaction would be accepted to the memory pool",
+	"testmempoolacceptresult-reject-reason": "The reason the transaction would be rejected (only when allowed is false)",
+
 	// ValidateAddressResult help.
 	"validateaddresschainresult-isvalid": "Whether or not the address is valid",
 	"validateaddresschainresult-address": "The HC address (only when isvalid is true)",
@@ -751,6 +1000,9 @@ var helpDescsEnUS = map[string]string{
 	// NotifyStakeDifficultyCmd help
 	"notifystakedifficulty--synopsis": "Request notifications for whenever stake difficulty goes up.",
 
+	// NotifyMempoolVotesCmd help
+	"notifymempoolvotes--synopsis": "Request notifications for whenever a tip candidate arrives or a vote on one is accepted into the memory pool, reporting which of the tickets selected to vote on it have votes in the memory pool.",
+
 	// NotifyWinningTicketsCmd help
 	"notifywinningtickets--synopsis": "Request notifications for whenever any tickets is chosen to vote.",
 
@@ -785,10 +1037,19 @@ var helpDescsEnUS = map[string]string{
 	// -------- Hcd-specific help --------
 
 	// EstimateFee help.
-	"estimatefee--synopsis": "Returns the estimated fee in hc/kb.",
-	"estimatefee-numblocks": "(unused)",
+	"estimatefee--synopsis": "Returns the estimated fee in hc/kb required for a transaction to be mined within numblocks blocks.\n" +
+		"The minimum relay fee is returned when there is not enough data to produce an estimate.",
+	"estimatefee-numblocks": "The target number of blocks for the transaction to be mined within (values outside of the range tracked by the fee estimator are clamped to it)",
 	"estimatefee--result0":  "Estimated fee.",
 
+	// EstimateSmartFee help.
+	"estimatesmartfee--synopsis":        "Returns the estimated fee in hc/kb required for a transaction to be mined within the target number of blocks along with the confidence of the estimate.",
+	"estimatesmartfee-confirmations":    "The target number of blocks for the transaction to be mined within",
+	"estimatesmartfee-mode":             "The estimation mode, either 'conservative' or 'economical'",
+	"estimatesmartfeeresult-feerate":    "Estimated fee rate in hc/kb",
+	"estimatesmartfeeresult-confidence": "Ratio of the observed transactions paying the estimated fee rate which were mined within the target",
+	"estimatesmartfeeresult-blocks":     "Number of blocks the estimate is valid for, which may be larger than the requested target when there is not enough data",
+
 	// EstimateStakeDiff help.
 	"estimatestakediff--synopsis":      "Estimate the next minimum, maximum, expected, and user-specified stake difficulty",
 	"estimatestakediff-tickets":        "Use this number of new tickets in blocks to estimate the next difficulty",
@@ -797,6 +1058,37 @@ var helpDescsEnUS = map[string]string{
 	"estimatestakediffresult-expected": "Expected estimate for stake difficulty",
 	"estimatestakediffresult-user":     "Estimate for stake difficulty with the passed user amount of tickets",
 
+	// ProjectStakeDiffCmd help.
+	"projectstakediff--synopsis":       "Project the stake difficulty of the next retarget interval and optionally the ones after it from hypothetical numbers of tickets purchased in each block.",
+	"projectstakediff-purchases":       "The number of tickets purchased in each remaining block of the current interval, starting with the next block",
+	"projectstakediff-windows":         "The number of retarget intervals to project",
+	"projectstakediff-futurepurchases": "The number of tickets purchased in each block without a specified number (default: the number of votes per block)",
+
+	// ProjectStakeDiffResult help.
+	"projectstakediffresult-height":             "The height of the current best block",
+	"projectstakediffresult-current":            "The stake difficulty of the current best block",
+	"projectstakediffresult-next":               "The projected stake difficulty of the next retarget interval",
+	"projectstakediffresult-nextretargetheight": "The height of the first block of the next retarget interval",
+	"projectstakediffresult-windows":            "The projected retarget intervals",
+
+	// StakeDiffWindowResult help.
+	"stakediffwindowresult-height":    "The height of the first block of the interval",
+	"stakediffwindowresult-stakediff": "The projected stake difficulty of the interval",
+	"stakediffwindowresult-poolsize":  "The projected number of live tickets committed to by the first block of the interval",
+	"stakediffwindowresult-immature":  "The projected number of immature tickets as of the block before the interval",
+
+	// GetCFilter help.
+	"getcfilter--synopsis":  "Returns the committed filter for a block",
+	"getcfilter-hash":       "The block hash of the filter being queried",
+	"getcfilter-filtertype": "The type of committed filter to return (regular)",
+	"getcfilter--result0":   "The committed filter serialized with the N value and encoded as a hex string",
+
+	// GetCFHeaders help.
+	"getcfheaders--synopsis":  "Returns the committed filter header for a block",
+	"getcfheaders-hash":       "The block hash of the filter header being queried",
+	"getcfheaders-filtertype": "The type of committed filter header to return (regular)",
+	"getcfheaders--result0":   "The committed filter header, which commits to the filter of the block and the filter header of its parent",
+
 	// GetCoinSupply help
 	"getcoinsupply--synopsis": "Returns current total coin supply in atoms",
 	"getcoinsupply--result0":  "Current coin supply in atoms",
@@ -805,6 +1097,15 @@ var helpDescsEnUS = map[string]string{
 	"livetickets--synopsis":     "Request tickets the live ticket hashes from the ticket database",
 	"liveticketsresult-tickets": "List of live tickets",
 
+	// LoadMempoolCmd help.
+	"loadmempool--synopsis": "Loads the transaction memory pool saved to mempool.dat in the data directory.\n" +
+		"The loaded transactions are validated again and those which are accepted are relayed.",
+
+	// LoadMempoolResult help.
+	"loadmempoolresult-accepted": "Number of transactions accepted to the memory pool",
+	"loadmempoolresult-orphans":  "Number of transactions added to the orphan pool",
+	"loadmempoolresult-rejected": "Number of transactions rejected because they were mined, are already known or are no longer valid",
+
 	// MissedTickets help.
 	"missedtickets--synopsis":     "Request tickets the client missed",
 	"missedticketsresult-tickets": "List of missed tickets",
@@ -892,6 +1193,7 @@ var helpDescsEnUS = map[string]string{
 // pointer to the type (or nil to indicate no return value).
 var rpcResultTypes = map[string][]interface{}{
 	"addnode":               nil,
+	"clearbanned":           nil,
 	"createrawsstx":         {(*string)(nil)},
 	"createrawssgentx":      {(*string)(nil)},
 	"createrawssrtx":        {(*string)(nil)},
@@ -900,6 +1202,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"decoderawtransaction":  {(*hcjson.TxRawDecodeResult)(nil)},
 	"decodescript":          {(*hcjson.DecodeScriptResult)(nil)},
 	"estimatefee":           {(*float64)(nil)},
+	"estimatesmartfee":      {(*hcjson.EstimateSmartFeeResult)(nil)},
 	"estimatestakediff":     {(*hcjson.EstimateStakeDiffResult)(nil)},
 	"existsaddress":         {(*bool)(nil)},
 	"existsaddresses":       {(*string)(nil)},
@@ -909,6 +1212,9 @@ var rpcResultTypes = map[string][]interface{}{
 	"existslivetickets":     {(*string)(nil)},
 	"existsmempooltxs":      {(*string)(nil)},
 	"getaddednodeinfo":      {(*[]string)(nil), (*[]hcjson.GetAddedNodeInfoResult)(nil)},
+	"getaddressbalance":     {(*hcjson.GetAddressBalanceResult)(nil)},
+	"getaddressdeltas":      {(*[]hcjson.AddressDeltaResult)(nil)},
+	"getaddressutxos":       {(*[]hcjson.AddressUtxoResult)(nil)},
 	"getbestblock":          {(*hcjson.GetBestBlockResult)(nil)},
 	"generate":              {(*[]string)(nil)},
 	"getbestblockhash":      {(*string)(nil)},
@@ -921,38 +1227,55 @@ var rpcResultTypes = map[string][]interface{}{
 	"getconnectioncount":    {(*int32)(nil)},
 	"getcurrentnet":         {(*uint32)(nil)},
 	"getdifficulty":         {(*float64)(nil)},
+	"getspendinginfo":       {(*hcjson.GetSpendingInfoResult)(nil)},
 	"getstakedifficulty":    {(*hcjson.GetStakeDifficultyResult)(nil)},
 	"getstakeversioninfo":   {(*hcjson.GetStakeVersionInfoResult)(nil)},
 	"getblockchaininfo":     {(*hcjson.GetBlockChainInfoResult)(nil)},
 	"getstakeversions":      {(*hcjson.GetStakeVersionsResult)(nil)},
+	"getstratuminfo":        {(*hcjson.GetStratumInfoResult)(nil)},
 	"getgenerate":           {(*bool)(nil)},
 	"gethashespersec":       {(*float64)(nil)},
 	"getheaders":            {(*hcjson.GetHeadersResult)(nil)},
 	"getinfo":               {(*hcjson.InfoChainResult)(nil)},
+	"getmempoolancestors":   {(*[]string)(nil), (*hcjson.GetMempoolEntryResult)(nil)},
+	"getmempooldescendants": {(*[]string)(nil), (*hcjson.GetMempoolEntryResult)(nil)},
+	"getmempoolentry":       {(*hcjson.GetMempoolEntryResult)(nil)},
 	"getmempoolinfo":        {(*hcjson.GetMempoolInfoResult)(nil)},
+	"getmempoolvotes":       {(*[]hcjson.MempoolVotesResult)(nil)},
 	"getmininginfo":         {(*hcjson.GetMiningInfoResult)(nil)},
 	"getnettotals":          {(*hcjson.GetNetTotalsResult)(nil)},
 	"getnetworkhashps":      {(*int64)(nil)},
 	"getpeerinfo":           {(*[]hcjson.GetPeerInfoResult)(nil)},
 	"getrawmempool":         {(*[]string)(nil), (*hcjson.GetRawMempoolVerboseResult)(nil)},
 	"getrawtransaction":     {(*string)(nil), (*hcjson.TxRawResult)(nil)},
+	"getticketinfo":         {(*hcjson.GetTicketInfoResult)(nil)},
+	"getticketpoolinfo":     {(*hcjson.GetTicketPoolInfoResult)(nil)},
 	"getticketpoolvalue":    {(*float64)(nil)},
 	"gettxout":              {(*hcjson.GetTxOutResult)(nil)},
 	"getvoteinfo":           {(*hcjson.GetVoteInfoResult)(nil)},
+	"getwinningtickets":     {(*hcjson.GetWinningTicketsResult)(nil)},
 	"getwork":               {(*hcjson.GetWorkResult)(nil), (*bool)(nil)},
 	"getcoinsupply":         {(*int64)(nil)},
+	"getcfilter":            {(*string)(nil)},
+	"getcfheaders":          {(*string)(nil)},
 	"help":                  {(*string)(nil), (*string)(nil)},
+	"listbanned":            {(*[]hcjson.ListBannedResult)(nil)},
 	"livetickets":           {(*hcjson.LiveTicketsResult)(nil)},
+	"loadmempool":           {(*hcjson.LoadMempoolResult)(nil)},
 	"missedtickets":         {(*hcjson.MissedTicketsResult)(nil)},
 	"node":                  nil,
 	"ping":                  nil,
+	"projectstakediff":      {(*hcjson.ProjectStakeDiffResult)(nil)},
 	"rebroadcastmissed":     nil,
 	"rebroadcastwinners":    nil,
+	"savemempool":           nil,
 	"searchrawtransactions": {(*string)(nil), (*[]hcjson.SearchRawTransactionsResult)(nil)},
 	"sendrawtransaction":    {(*string)(nil)},
+	"setban":                nil,
 	"setgenerate":           nil,
 	"stop":                  {(*string)(nil)},
 	"submitblock":           {nil, (*string)(nil)},
+	"testmempoolaccept":     {(*[]hcjson.TestMempoolAcceptResult)(nil)},
 	"ticketfeeinfo":         {(*hcjson.TicketFeeInfoResult)(nil)},
 	"ticketsforaddress":     {(*hcjson.TicketsForAddressResult)(nil)},
 	"ticketvwap":            {(*float64)(nil)},
@@ -967,6 +1290,7 @@ var rpcResultTypes = map[string][]interface{}{
 	"loadtxfilter":                nil,
 	"session":                     {(*hcjson.SessionResult)(nil)},
 	"notifywinningtickets":        nil,
+	"notifymempoolvotes":          nil,
 	"notifyspentandmissedtickets": nil,
 	"notifynewtickets":            nil,
 	"notifystakedifficulty":       nil,
@@ -68,6 +68,7 @@ var wsHandlersBeforeInit = map[string]wsCommandHandler{
 	"loadtxfilter":                handleLoadTxFilter,
 	"notifyblocks":                handleNotifyBlocks,
 	"notifywinningtickets":        handleWinningTickets,
+	"notifymempoolvotes":          handleMempoolVotes,
 	"notifyspentandmissedtickets": handleSpentAndMissedTickets,
 	"notifynewtickets":            handleNewTickets,
 	"notifystakedifficulty":       handleStakeDifficulty,
@@ -317,6 +318,25 @@ func (m *wsNotificationManager) NotifyMempoolTx(tx *hcutil.Tx, isNew bool) {
 	}
 }
 
+// NotifyTxReplaced passes a transaction evicted from the mempool along with the
+// transaction that replaced it to the notification manager for transaction
+// notification processing.
+func (m *wsNotificationManager) NotifyTxReplaced(replaced, replacement *hcutil.Tx) {
+	n := &notificationTxReplaced{
+		replaced:    replaced,
+		replacement: replacement,
+	}
+
+	// As NotifyTxReplaced will be called by mempool and the RPC server
+	// may no longer be running, use a select statement to unblock
+	// enqueuing the notification once the RPC server has begun
+	// shutting down.
+	select {
+	case m.queueNotification <- n:
+	case <-m.quit:
+	}
+}
+
 // WinningTicketsNtfnData is the data that is used to generate
 // winning ticket notifications (which indicate a block and
 // the tickets eligible to vote on it).
@@ -505,6 +525,10 @@ type notificationTxAcceptedByMempool struct {
 	isNew bool
 	tx    *hcutil.Tx
 }
+type notificationTxReplaced struct {
+	replaced    *hcutil.Tx
+	replacement *hcutil.Tx
+}
 
 // Notification control requests
 type notificationRegisterClient wsClient
@@ -521,6 +545,8 @@ type notificationRegisterStakeDifficulty wsClient
 type notificationUnregisterStakeDifficulty wsClient
 type notificationRegisterNewMempoolTxs wsClient
 type notificationUnregisterNewMempoolTxs wsClient
+type notificationRegisterMempoolVotes wsClient
+type notificationUnregisterMempoolVotes wsClient
 
 // notificationHandler reads notifications and control messages from the queue
 // handler and processes one at a time.
@@ -541,6 +567,7 @@ func (m *wsNotificationManager) notificationHandler() {
 	ticketNewNotifications := make(map[chan struct{}]*wsClient)
 	stakeDifficultyNotifications := make(map[chan struct{}]*wsClient)
 	txNotifications := make(map[chan struct{}]*wsClient)
+	mempoolVoteNotifications := make(map[chan struct{}]*wsClient)
 
 out:
 	for {
@@ -574,6 +601,14 @@ out:
 				m.notifyWinningTickets(winningTicketNotifications,
 					(*WinningTicketsNtfnData)(n))
 
+				// Report the votes on the new tip candidate, which
+				// are typically all missing at this point, so clients
+				// learn when the block arrived.
+				if len(mempoolVoteNotifications) != 0 {
+					m.notifyMempoolVotes(mempoolVoteNotifications,
+						&n.BlockHash, n.BlockHeight)
+				}
+
 			case *notificationSpentAndMissedTickets:
 				m.notifySpentAndMissedTickets(ticketSMNotifications,
 					(*blockchain.TicketNotificationsData)(n))
@@ -591,6 +626,14 @@ out:
 					m.notifyForNewTx(txNotifications, n.tx)
 				}
 				m.notifyRelevantTxAccepted(n.tx, clients)
+				if n.isNew && len(mempoolVoteNotifications) != 0 {
+					m.notifyMempoolVote(mempoolVoteNotifications,
+						n.tx)
+				}
+
+			case *notificationTxReplaced:
+				m.notifyTxReplaced(clients, txNotifications,
+					n.replaced, n.replacement)
 
 			case *notificationRegisterBlocks:
 				wsc := (*wsClient)(n)
@@ -632,6 +675,14 @@ out:
 				wsc := (*wsClient)(n)
 				delete(stakeDifficultyNotifications, wsc.quit)
 
+			case *notificationRegisterMempoolVotes:
+				wsc := (*wsClient)(n)
+				mempoolVoteNotifications[wsc.quit] = wsc
+
+			case *notificationUnregisterMempoolVotes:
+				wsc := (*wsClient)(n)
+				delete(mempoolVoteNotifications, wsc.quit)
+
 			case *notificationRegisterClient:
 				wsc := (*wsClient)(n)
 				clients[wsc.quit] = wsc
@@ -642,6 +693,7 @@ out:
 				// the client itself.
 				delete(blockNotifications, wsc.quit)
 				delete(txNotifications, wsc.quit)
+				delete(mempoolVoteNotifications, wsc.quit)
 				delete(clients, wsc.quit)
 
 			case *notificationRegisterNewMempoolTxs:
@@ -909,6 +961,65 @@ func (*wsNotificationManager) notifyWinningTickets(
 	}
 }
 
+// RegisterMempoolVotes requests mempool vote update notifications to the passed
+// websocket client.
+func (m *wsNotificationManager) RegisterMempoolVotes(wsc *wsClient) {
+	m.queueNotification <- (*notificationRegisterMempoolVotes)(wsc)
+}
+
+// UnregisterMempoolVotes removes mempool vote notifications for the passed
+// websocket client.
+func (m *wsNotificationManager) UnregisterMempoolVotes(wsc *wsClient) {
+	m.queueNotification <- (*notificationUnregisterMempoolVotes)(wsc)
+}
+
+// notifyMempoolVotes notifies websocket clients that have registered for
+// mempool vote updates about the votes on the block with the passed hash and
+// height.
+func (m *wsNotificationManager) notifyMempoolVotes(
+	clients map[chan struct{}]*wsClient, hash *chainhash.Hash, height int64) {
+
+	votes, err := m.server.mempoolVotes(hash, height)
+	if err != nil {
+		rpcsLog.Debugf("Failed to get mempool votes on block %v: %v",
+			hash, err)
+		return
+	}
+
+	ntfn := hcjson.NewMempoolVotesNtfn(*votes)
+	marshalledJSON, err := hcjson.MarshalCmd(nil, ntfn)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal mempool votes notification: "+
+			"%v", err)
+		return
+	}
+
+	for _, wsc := range clients {
+		wsc.QueueNotification(marshalledJSON)
+	}
+}
+
+// notifyMempoolVote notifies websocket clients that have registered for
+// mempool vote updates about the votes on the block the passed transaction
+// votes on when it is a vote on one of the current tip candidates.
+func (m *wsNotificationManager) notifyMempoolVote(
+	clients map[chan struct{}]*wsClient, tx *hcutil.Tx) {
+
+	msgTx := tx.MsgTx()
+	if stake.DetermineTxType(msgTx) != stake.TxTypeSSGen {
+		return
+	}
+	hash, height, err := stake.SSGenBlockVotedOn(msgTx)
+	if err != nil {
+		return
+	}
+	if int64(height) != m.server.chain.BestSnapshot().Height {
+		return
+	}
+
+	m.notifyMempoolVotes(clients, &hash, int64(height))
+}
+
 // RegisterSpentAndMissedTickets requests spent/missed tickets update notifications
 // to the passed websocket client.
 func (m *wsNotificationManager) RegisterSpentAndMissedTickets(wsc *wsClient) {
@@ -1164,6 +1275,65 @@ func (m *wsNotificationManager) notifyRelevantTxAccepted(tx *hcutil.Tx,
 	}
 }
 
+// notifyTxReplaced notifies websocket clients that have registered for updates
+// when new transactions are added to the memory pool, as well as clients with
+// a transaction filter the replaced transaction is relevant to, that the
+// replaced transaction was evicted from the memory pool in favor of the
+// replacement transaction.  The outputs of the replaced transaction are no
+// longer watched since they can never be spent.
+func (m *wsNotificationManager) notifyTxReplaced(clients,
+	txClients map[chan struct{}]*wsClient, replaced, replacement *hcutil.Tx) {
+
+	clientsToNotify := make(map[chan struct{}]*wsClient, len(txClients))
+	for q, c := range txClients {
+		clientsToNotify[q] = c
+	}
+
+	msgTx := replaced.MsgTx()
+	for q, c := range clients {
+		c.Lock()
+		f := c.filterData
+		c.Unlock()
+		if f == nil {
+			continue
+		}
+		f.mu.Lock()
+
+		for _, input := range msgTx.TxIn {
+			if f.existsUnspentOutPoint(&input.PreviousOutPoint) {
+				clientsToNotify[q] = c
+			}
+		}
+		for i := range msgTx.TxOut {
+			op := wire.OutPoint{
+				Hash:  *replaced.Hash(),
+				Index: uint32(i),
+				Tree:  replaced.Tree(),
+			}
+			if f.existsUnspentOutPoint(&op) {
+				clientsToNotify[q] = c
+				f.removeUnspentOutPoint(&op)
+			}
+		}
+
+		f.mu.Unlock()
+	}
+
+	if len(clientsToNotify) == 0 {
+		return
+	}
+	n := hcjson.NewTxReplacedNtfn(replaced.Hash().String(),
+		replacement.Hash().String())
+	marshalled, err := hcjson.MarshalCmd(nil, n)
+	if err != nil {
+		rpcsLog.Errorf("Failed to marshal notification: %v", err)
+		return
+	}
+	for _, c := range clientsToNotify {
+		c.QueueNotification(marshalled)
+	}
+}
+
 // AddClient adds the passed websocket client to the notification manager.
 func (m *wsNotificationManager) AddClient(wsc *wsClient) {
 	m.queueNotification <- (*notificationRegisterClient)(wsc)
@@ -1810,6 +1980,13 @@ func handleWinningTickets(wsc *wsClient, icmd interface{}) (interface{},
 	return nil, nil
 }
 
+// handleMempoolVotes implements the notifymempoolvotes command extension for
+// websocket connections.
+func handleMempoolVotes(wsc *wsClient, icmd interface{}) (interface{}, error) {
+	wsc.server.ntfnMgr.RegisterMempoolVotes(wsc)
+	return nil, nil
+}
+
 // handleSpentAndMissedTickets implements the notifyspentandmissedtickets command
 // extension for websocket connections.
 func handleSpentAndMissedTickets(wsc *wsClient, icmd interface{}) (interface{},
@@ -21,6 +21,13 @@ const FileContents = `[Application Options]
 ; $VARIABLE here.  Also, ~ is expanded to $LOCALAPPDATA on Windows.
 ; datadir=~/.hcd/data
 
+; Delete the data of old blocks to keep the total size of the stored blocks
+; below the specified target in MiB.  The data needed to handle reorganizations
+; is always kept, so the minimum target is 1536 MiB.  A pruned node does not
+; advertise that it serves the full block chain and can't be used with the
+; txindex, addrindex, addrutxoindex, spendindex, or ticketindex options.
+; prune=1536
+
 
 ; ------------------------------------------------------------------------------
 ; Network settings
@@ -57,6 +64,14 @@ const FileContents = `[Application Options]
 ; to correlate connections.
 ; torisolation=1
 
+; Connect to I2P destinations via the SOCKS5 proxy of an I2P router.  I2P
+; addresses are never dialed unless this is set.
+; i2pproxy=127.0.0.1:4447
+
+; Connect to CJDNS addresses directly.  Only set this when the host is part of
+; the CJDNS network.
+; cjdnsreachable=1
+
 ; Use Universal Plug and Play (UPnP) to automatically open the listen port
 ; and obtain the external IP address from supported devices.  NOTE: This option
 ; will have no effect if exernal IP addresses are specified.
@@ -132,6 +147,11 @@ const FileContents = `[Application Options]
 ; whitelist=192.168.0.0/24
 ; whitelist=fd00::/16
 
+; Encrypt connections with peers which support the v2 transport.  Outbound
+; connections are only encrypted when the peer is known to advertise support
+; for it and are retried unencrypted when the peer rejects the handshake.
+; v2transport=1
+
 ; Disable DNS seeding for peers.  By default, when hcd starts, it will use
 ; DNS to query for available peers to connect with.
 ; nodnsseed=1
@@ -171,6 +191,9 @@ const FileContents = `[Application Options]
 ; Disable peer bloom filtering.  See BIP0111.
 ; nopeerbloomfilters=1
 
+; Disable committed peer filtering (CF).
+; nocfilters=1
+
 
 ; ------------------------------------------------------------------------------
 ; RPC server options - The following options control the built-in RPC server
@@ -245,6 +268,26 @@ const FileContents = `[Application Options]
 ; Limit orphan transaction pool to 1000 transactions.
 ; maxorphantx=1000
 
+; Limit the transaction memory pool to 300 MiB.  The transactions with the
+; lowest fee rate are evicted and the minimum fee required for new transactions
+; is raised while the pool is full.  Set to 0 to disable the limit.
+; maxmempool=300
+
+; Do not save the transaction memory pool to mempool.dat in the data directory
+; on shutdown and reload it on startup.  The reloaded transactions are validated
+; again, so those which were mined or became invalid are dropped.
+; nopersistmempool=1
+
+; Limit the unconfirmed transaction chains in the memory pool.  A transaction is
+; rejected when it has more than limitancestorcount unconfirmed ancestors or
+; limitancestorsize kB of them, including itself, or when it would give any of
+; its ancestors more than limitdescendantcount descendants or
+; limitdescendantsize kB of them.  Set a limit to 0 to disable it.
+; limitancestorcount=25
+; limitancestorsize=101
+; limitdescendantcount=25
+; limitdescendantsize=101
+
 ; Do not accept transactions from remote peers.
 ; blocksonly=1
 
@@ -254,6 +297,13 @@ const FileContents = `[Application Options]
 ; Reject non-standard transactions regardless of default network settings.
 ; rejectnonstd=1
 
+; Reject regular transactions which attempt to replace transactions in the
+; memory pool that signal replaceability by using an input sequence number of at
+; most 0xfffffffd.  Replacements must pay a higher fee rate than every
+; transaction they evict as well as an absolute fee that covers the fees of the
+; evicted transactions plus their own relay fee.
+; rejectreplacement=1
+
 
 ; ------------------------------------------------------------------------------
 ; Optional Transaction Indexes
@@ -262,6 +312,19 @@ const FileContents = `[Application Options]
 ; Delete the entire address index on start up, then exit.
 ; dropaddrindex=0
 
+; Delete the entire address utxo index on start up, then exit.
+; dropaddrutxoindex=0
+
+; Delete the entire spend index on start up, then exit.
+; dropspendindex=0
+
+; Delete the entire ticket history index on start up, then exit.
+; dropticketindex=0
+
+; Delete the entire committed filter index on start up, then exit.  Committed
+; filtering must be disabled with nocfilters for this option to be used.
+; dropcfindex=0
+
 
 ; ------------------------------------------------------------------------------
 ; Optional Indexes
@@ -275,6 +338,20 @@ const FileContents = `[Application Options]
 ; searchrawtransactions RPC available.
 ; addrindex=1
 
+; Build and maintain an address-based unspent output and balance index which
+; makes the getaddressbalance, getaddressutxos, and getaddressdeltas RPCs
+; available.
+; addrutxoindex=1
+
+; Build and maintain an index of the transactions which spend each output which
+; makes the getspendinginfo RPC available.
+; spendindex=1
+
+; Build and maintain an index of the status changes of all tickets and the state
+; of the ticket pool after every block which makes the getticketinfo and
+; getticketpoolinfo RPCs available.
+; ticketindex=1
+
 
 ; ------------------------------------------------------------------------------
 ; Signature Verification Cache
@@ -284,6 +361,23 @@ const FileContents = `[Application Options]
 ; sigcachemaxsize=50000
 
 
+; ------------------------------------------------------------------------------
+; UTXO Cache
+; ------------------------------------------------------------------------------
+
+; Limit the cache of the unspent transaction output set to approximately 150
+; MiB.  The changes to the set are written to the database in batches once the
+; limit is exceeded and every few minutes.  A value of 0 writes the changes of
+; every block.
+; utxocachemaxsize=150
+
+; Skip the script validation of the ancestors of the specified block during the
+; initial sync when the block is part of the best header chain.  All of the
+; other checks are still performed.  This is disabled by default, so the
+; scripts of all blocks are validated unless a block is specified.
+; assumevalid=
+
+
 ; ------------------------------------------------------------------------------
 ; Coin Generation (Mining) Settings - The following options control the
 ; generation of block templates used by external mining applications through RPC
@@ -303,6 +397,19 @@ const FileContents = `[Application Options]
 ; miningaddr=youraddress2
 ; miningaddr=youraddress3
 
+; Enable the built-in Stratum mining server.  Mining hardware and software that
+; speaks the Stratum protocol may connect to it directly.  At least one
+; miningaddr must be specified.
+; stratum=1
+
+; Specify the interfaces for the Stratum server to listen on.  One listen
+; address per line.  The default port is 3333.
+; stratumlisten=0.0.0.0:3333
+
+; Specify the initial share difficulty assigned to each Stratum connection.
+; Miners may request a different difficulty via mining.suggest_difficulty.
+; stratumdiff=1
+
 ; Specify the minimum block size in bytes to create.  By default, only
 ; transactions which have enough fees or a high enough priority will be included
 ; in generated block templates.  Specifying a minimum block size will instead
@@ -7,6 +7,7 @@
 package main
 
 import (
+	"bufio"
 	"bytes"
 	"crypto/rand"
 	"encoding/binary"
@@ -14,6 +15,8 @@ import (
 	"fmt"
 	"math"
 	"net"
+	"os"
+	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
@@ -25,10 +28,12 @@ import (
 	"github.com/HcashOrg/hcd/addrmgr"
 	"github.com/HcashOrg/hcd/blockchain"
 	"github.com/HcashOrg/hcd/blockchain/indexers"
+	"github.com/HcashOrg/hcd/blockchain/stake"
 	"github.com/HcashOrg/hcd/chaincfg"
 	"github.com/HcashOrg/hcd/chaincfg/chainhash"
 	"github.com/HcashOrg/hcd/connmgr"
 	"github.com/HcashOrg/hcd/database"
+	"github.com/HcashOrg/hcd/fees"
 	"github.com/HcashOrg/hcd/hcutil"
 	"github.com/HcashOrg/hcd/hcutil/bloom"
 	"github.com/HcashOrg/hcd/mempool"
@@ -41,7 +46,7 @@ import (
 const (
 	// defaultServices describes the default services that are supported by
 	// the server.
-	defaultServices = wire.SFNodeNetwork | wire.SFNodeBloom
+	defaultServices = wire.SFNodeNetwork | wire.SFNodeBloom | wire.SFNodeCF
 
 	// defaultRequiredServices describes the default services that are
 	// required to be supported by outbound peers.
@@ -57,7 +62,24 @@ const (
 	connectionRetryInterval = time.Second * 5
 
 	// maxProtocolVersion is the max protocol version the server supports.
-	maxProtocolVersion = wire.MaxBlockSizeVersion
+	maxProtocolVersion = wire.AddrV2Version
+
+	// maxBlockTxnDepth is the maximum depth of the blocks whose
+	// transactions are served in response to getblocktxn messages.  The
+	// full block is sent for deeper blocks.
+	maxBlockTxnDepth = 10
+
+	// maxV1OnlyAddrs is the maximum number of outbound addresses which are
+	// remembered to not support the v2 transport.
+	maxV1OnlyAddrs = 1000
+
+	// mempoolDumpFilename is the name of the file in the data directory
+	// the memory pool is saved to on shutdown.
+	mempoolDumpFilename = "mempool.dat"
+
+	// banListFilename is the name of the file in the data directory the ban
+	// list is saved to.
+	banListFilename = "banlist.json"
 )
 
 var (
@@ -104,14 +126,23 @@ type updatePeerHeightsMsg struct {
 	originPeer *serverPeer
 }
 
+// banPeerMsg describes a peer to ban along with the reason it is banned.
+type banPeerMsg struct {
+	sp     *serverPeer
+	reason string
+}
+
 // peerState maintains state of inbound, persistent, outbound peers as well
-// as banned peers and outbound groups.
+// as outbound groups.
 type peerState struct {
 	inboundPeers    map[int32]*serverPeer
 	outboundPeers   map[int32]*serverPeer
 	persistentPeers map[int32]*serverPeer
-	banned          map[string]time.Time
 	outboundGroups  map[string]int
+
+	// evictionKey is the random key the network groups of inbound peers
+	// are hashed with to decide which of them are protected from eviction.
+	evictionKey [32]byte
 }
 
 // Count returns the count of all known peers.
@@ -150,19 +181,23 @@ type server struct {
 	started       int32
 	shutdown      int32
 	shutdownSched int32
+	mempoolLoaded int32
 
 	chainParams          *chaincfg.Params
 	addrManager          *addrmgr.AddrManager
+	banList              *connmgr.BanList
 	connManager          *connmgr.ConnManager
 	sigCache             *txscript.SigCache
 	rpcServer            *rpcServer
 	blockManager         *blockManager
 	txMemPool            *mempool.TxPool
+	feeEstimator         *fees.Estimator
 	cpuMiner             *CPUMiner
+	stratumServer        *stratumServer
 	modifyRebroadcastInv chan interface{}
 	newPeers             chan *serverPeer
 	donePeers            chan *serverPeer
-	banPeers             chan *serverPeer
+	banPeers             chan banPeerMsg
 	query                chan interface{}
 	relayInv             chan relayMsg
 	broadcast            chan broadcastMsg
@@ -174,18 +209,34 @@ type server struct {
 	timeSource           blockchain.MedianTimeSource
 	services             wire.ServiceFlag
 
+	// v1OnlyAddrs houses the outbound addresses whose peers rejected the v2
+	// handshake so the next connections to them use the v1 transport even
+	// when they are still known to advertise support for it.
+	v1OnlyMtx   sync.Mutex
+	v1OnlyAddrs map[string]struct{}
+
 	// The following fields are used for optional indexes.  They will be nil
 	// if the associated index is not enabled.  These fields are set during
 	// initial creation of the server and never changed afterwards, so they
 	// do not need to be protected for concurrent access.
 	txIndex         *indexers.TxIndex
 	addrIndex       *indexers.AddrIndex
+	addrUtxoIndex   *indexers.AddrUtxoIndex
+	spendIndex      *indexers.SpendIndex
+	ticketIndex     *indexers.TicketIndex
 	existsAddrIndex *indexers.ExistsAddrIndex
+	cfIndex         *indexers.CfIndex
 }
 
 // serverPeer extends the peer to maintain state shared by the server and
 // the blockmanager.
 type serverPeer struct {
+	// The following variables must only be used atomically.
+	// lastBlockRelay and lastTxRelay are the unix times at which the peer
+	// last relayed a new block and transaction respectively.
+	lastBlockRelay int64
+	lastTxRelay    int64
+
 	*peer.Peer
 
 	connReq         *connmgr.ConnReq
@@ -328,7 +379,8 @@ func (sp *serverPeer) addBanScore(persistent, transient uint32, reason string) {
 		if score > cfg.BanThreshold {
 			peerLog.Warnf("Misbehaving peer %s -- banning and disconnecting",
 				sp)
-			sp.server.BanPeer(sp)
+			sp.server.BanPeer(sp, fmt.Sprintf("ban score %d: %s",
+				score, reason))
 			sp.Disconnect()
 		}
 	}
@@ -378,7 +430,7 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 	val := valid.FindAllStringSubmatch(p.UserAgent(), 1)
 	if !(len(val) != 0 && len(val[0]) != 0) {
 		peerLog.Warnf("peer has no hcd agentVersion %s ", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "no hcd user agent")
 		sp.Disconnect()
 		return
 	}
@@ -387,7 +439,7 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 	versionArray := strings.Split(receiveVerisonStr, ".")
 	if len(versionArray) != 3 {
 		peerLog.Warnf("parser remote app version %s fail", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "invalid user agent version")
 		sp.Disconnect()
 		return
 	}
@@ -395,21 +447,21 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 	oldAppMajor, err := strconv.ParseInt(versionArray[0], 10, 32)
 	if err != nil {
 		peerLog.Warnf("parser remote app version %s fail", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "invalid user agent version")
 		sp.Disconnect()
 		return
 	}
 	oldAppMinor, err := strconv.ParseInt(versionArray[1], 10, 32)
 	if err != nil {
 		peerLog.Warnf("parser remote app version %s fail", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "invalid user agent version")
 		sp.Disconnect()
 		return
 	}
 	oldAppPatch, err := strconv.ParseInt(versionArray[2], 10, 32)
 	if err != nil {
 		peerLog.Warnf("parser remote app version %s fail", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "invalid user agent version")
 		sp.Disconnect()
 		return
 	}
@@ -419,7 +471,7 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 
 	if oldVersion < currVersion {
 		peerLog.Warnf("too old version peer %s ", sp)
-		sp.server.BanPeer(sp)
+		sp.server.BanPeer(sp, "too old version")
 		sp.Disconnect()
 		return
 	}
@@ -461,6 +513,14 @@ func (sp *serverPeer) OnVersion(p *peer.Peer, msg *wire.MsgVersion) {
 		}
 	}
 
+	// Signal support for compact blocks to peers which understand them.
+	// New blocks are still announced by inventory vectors, and the compact
+	// blocks are requested with getdata once the chain is current.
+	if p.ProtocolVersion() >= wire.SendCmpctVersion {
+		p.QueueMessage(wire.NewMsgSendCmpct(false, wire.CmpctBlockVersion),
+			nil)
+	}
+
 	// Add valid peer to the server.
 	sp.server.AddPeer(sp)
 }
@@ -667,6 +727,70 @@ func (sp *serverPeer) OnBlock(p *peer.Peer, msg *wire.MsgBlock, buf []byte) {
 	<-sp.blockProcessed
 }
 
+// OnCmpctBlock is invoked when a peer receives a cmpctblock wire message.  It
+// blocks until the block has been reconstructed and fully processed or the
+// missing transactions have been requested.
+func (sp *serverPeer) OnCmpctBlock(p *peer.Peer, msg *wire.MsgCmpctBlock) {
+	// Add the block to the known inventory for the peer.
+	blockHash := msg.Header.BlockHash()
+	iv := wire.NewInvVect(wire.InvTypeBlock, &blockHash)
+	p.AddKnownInventory(iv)
+
+	sp.server.blockManager.QueueCmpctBlock(msg, sp)
+	<-sp.blockProcessed
+}
+
+// OnBlockTxn is invoked when a peer receives a blocktxn wire message.  It
+// blocks until the block completed by the transactions has been fully
+// processed.
+func (sp *serverPeer) OnBlockTxn(p *peer.Peer, msg *wire.MsgBlockTxn) {
+	sp.server.blockManager.QueueBlockTxn(msg, sp)
+	<-sp.blockProcessed
+}
+
+// OnGetBlockTxn is invoked when a peer receives a getblocktxn wire message.  It
+// responds with the requested transactions of a recent block or the full block
+// when it is too deep in the chain.
+func (sp *serverPeer) OnGetBlockTxn(p *peer.Peer, msg *wire.MsgGetBlockTxn) {
+	chain := sp.server.blockManager.chain
+	block, err := chain.FetchBlockByHash(&msg.BlockHash)
+	if err != nil {
+		peerLog.Debugf("Unable to fetch requested block %v for "+
+			"getblocktxn from %v: %v", msg.BlockHash, sp, err)
+		return
+	}
+
+	best := chain.BestSnapshot()
+	if best.Height-block.Height() > maxBlockTxnDepth {
+		peerLog.Debugf("Sending full block %v to %v in response to "+
+			"getblocktxn for a deep block", msg.BlockHash, sp)
+		sp.QueueMessage(block.MsgBlock(), nil)
+		return
+	}
+
+	// Requesting transactions which are not part of the block is a
+	// protocol violation.
+	msgBlock := block.MsgBlock()
+	resp := wire.NewMsgBlockTxn(&msg.BlockHash)
+	for _, index := range msg.Indexes {
+		if int(index) >= len(msgBlock.Transactions) {
+			sp.addBanScore(100, 0, "getblocktxn")
+			return
+		}
+		resp.Transactions = append(resp.Transactions,
+			msgBlock.Transactions[index])
+	}
+	for _, index := range msg.StakeIndexes {
+		if int(index) >= len(msgBlock.STransactions) {
+			sp.addBanScore(100, 0, "getblocktxn")
+			return
+		}
+		resp.STransactions = append(resp.STransactions,
+			msgBlock.STransactions[index])
+	}
+	sp.QueueMessage(resp, nil)
+}
+
 // OnInv is invoked when a peer receives an inv wire message and is used to
 // examine the inventory being advertised by the remote peer and react
 // accordingly.  We pass the message down to blockmanager which will call
@@ -748,6 +872,8 @@ func (sp *serverPeer) OnGetData(p *peer.Peer, msg *wire.MsgGetData) {
 			err = sp.server.pushTxMsg(sp, &iv.Hash, c, waitChan)
 		case wire.InvTypeBlock:
 			err = sp.server.pushBlockMsg(sp, &iv.Hash, c, waitChan)
+		case wire.InvTypeCmpctBlock:
+			err = sp.server.pushCmpctBlockMsg(sp, &iv.Hash, c, waitChan)
 		default:
 			peerLog.Warnf("Unknown type %d in inventory request from %s",
 				iv.Type,sp)
@@ -957,6 +1083,143 @@ func (sp *serverPeer) OnGetHeaders(p *peer.Peer, msg *wire.MsgGetHeaders) {
 	p.QueueMessage(&wire.MsgHeaders{Headers: blockHeaders}, nil)
 }
 
+// OnGetCFilter is invoked when a peer receives a getcfilter wire message.
+func (sp *serverPeer) OnGetCFilter(p *peer.Peer, msg *wire.MsgGetCFilter) {
+	// Disconnect and/or ban depending on the node cf services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfilter requests if not in sync.
+	if !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	// Ignore requests for unsupported filter types.
+	if msg.FilterType != wire.GCSFilterRegular {
+		peerLog.Debugf("Peer %v requested unsupported filter type %v",
+			sp, msg.FilterType)
+		return
+	}
+
+	filterBytes, err := sp.server.cfIndex.FilterByBlockHash(&msg.BlockHash,
+		msg.FilterType)
+	if err != nil {
+		peerLog.Errorf("OnGetCFilter: failed to fetch filter: %v", err)
+		return
+	}
+	if len(filterBytes) == 0 {
+		peerLog.Debugf("Could not obtain committed filter for %v",
+			msg.BlockHash)
+		return
+	}
+
+	filterMsg := wire.NewMsgCFilter(&msg.BlockHash, msg.FilterType,
+		filterBytes)
+	p.QueueMessage(filterMsg, nil)
+}
+
+// OnGetCFHeaders is invoked when a peer receives a getcfheaders wire message.
+func (sp *serverPeer) OnGetCFHeaders(p *peer.Peer, msg *wire.MsgGetCFHeaders) {
+	// Disconnect and/or ban depending on the node cf services flag and
+	// negotiated protocol version.
+	if !sp.enforceNodeCFFlag(msg.Command()) {
+		return
+	}
+
+	// Ignore getcfheaders requests if not in sync.
+	if !sp.server.blockManager.IsCurrent() {
+		return
+	}
+
+	// Ignore requests for unsupported filter types.
+	if msg.FilterType != wire.GCSFilterRegular {
+		peerLog.Debugf("Peer %v requested unsupported filter type %v",
+			sp, msg.FilterType)
+		return
+	}
+
+	blockHashes, err := sp.server.locateBlocks(msg.BlockLocatorHashes,
+		&msg.HashStop)
+	if err != nil {
+		peerLog.Errorf("OnGetCFHeaders: failed to fetch hashes: %v", err)
+		return
+	}
+	if len(blockHashes) == 0 {
+		// Nothing to send.
+		return
+	}
+	if len(blockHashes) > wire.MaxCFHeadersPerMsg {
+		blockHashes = blockHashes[:wire.MaxCFHeadersPerMsg]
+	}
+
+	// Fetch the filter header for each located block.
+	headersMsg := wire.NewMsgCFHeaders()
+	headersMsg.FilterType = msg.FilterType
+	headersMsg.StopHash = blockHashes[len(blockHashes)-1]
+	for i := range blockHashes {
+		headerBytes, err := sp.server.cfIndex.FilterHeaderByBlockHash(
+			&blockHashes[i], msg.FilterType)
+		if err != nil {
+			peerLog.Errorf("OnGetCFHeaders: failed to fetch filter "+
+				"header: %v", err)
+			return
+		}
+		if len(headerBytes) == 0 {
+			peerLog.Debugf("Could not obtain committed filter header "+
+				"for %v", blockHashes[i])
+			return
+		}
+
+		headerHash, err := chainhash.NewHash(headerBytes)
+		if err != nil {
+			peerLog.Errorf("OnGetCFHeaders: failed to parse filter "+
+				"header: %v", err)
+			return
+		}
+		headersMsg.AddCFHeader(headerHash)
+	}
+
+	p.QueueMessage(headersMsg, nil)
+}
+
+// enforceNodeCFFlag disconnects the peer if the server is not configured to
+// allow committed filters.  Additionally, if the peer has negotiated to a
+// protocol version that is high enough to observe the committed filter service
+// support bit, it will be banned since it is intentionally violating the
+// protocol.
+func (sp *serverPeer) enforceNodeCFFlag(cmd string) bool {
+	if sp.server.services&wire.SFNodeCF != wire.SFNodeCF {
+		// Ban the peer if the protocol version is high enough that the
+		// peer is knowingly violating the protocol and banning is
+		// enabled.
+		//
+		// NOTE: Even though the addBanScore function already examines
+		// whether or not banning is enabled, it is checked here as well
+		// to ensure the violation is logged and the peer is
+		// disconnected regardless.
+		if sp.ProtocolVersion() >= wire.NodeCFVersion &&
+			!cfg.DisableBanning {
+
+			// Disonnect the peer regardless of whether it was
+			// banned.
+			sp.addBanScore(100, 0, cmd)
+			sp.Disconnect()
+			return false
+		}
+
+		// Disconnect the peer regardless of protocol version or banning
+		// state.
+		peerLog.Debugf("%s sent an unsupported %s request -- "+
+			"disconnecting", sp, cmd)
+		sp.Disconnect()
+		return false
+	}
+
+	return true
+}
+
 // enforceNodeBloomFlag disconnects the peer if the server is not configured to
 // allow bloom filters.  Additionally, if the peer has negotiated to a protocol
 // version  that is high enough to observe the bloom filter service support bit,
@@ -1076,6 +1339,32 @@ func (sp *serverPeer) OnGetAddr(p *peer.Peer, msg *wire.MsgGetAddr) {
 // OnAddr is invoked when a peer receives an addr wire message and is used to
 // notify the server about advertised addresses.
 func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
+	sp.addAdvertisedAddresses(p, msg.Command(), msg.AddrList)
+}
+
+// OnAddrV2 is invoked when a peer receives an addrv2 wire message and is used
+// to notify the server about advertised addresses including the ones of
+// networks which can't be relayed by addr messages.
+func (sp *serverPeer) OnAddrV2(p *peer.Peer, msg *wire.MsgAddrV2) {
+	// Addresses of unknown networks are skipped, but still count toward
+	// the addresses of the message.
+	addrList := make([]*wire.NetAddress, 0, len(msg.AddrList))
+	for _, na := range msg.AddrList {
+		if addrmgr.IsValid(na) {
+			addrList = append(addrList, na)
+		}
+	}
+	if len(addrList) == 0 && len(msg.AddrList) != 0 {
+		return
+	}
+
+	sp.addAdvertisedAddresses(p, msg.Command(), addrList)
+}
+
+// addAdvertisedAddresses adds the addresses advertised by the peer with an
+// addr or addrv2 message of the passed command to the known addresses of the
+// peer and the address manager.
+func (sp *serverPeer) addAdvertisedAddresses(p *peer.Peer, cmd string, addrList []*wire.NetAddress) {
 	// Ignore addresses when running on the simulation test network.  This
 	// helps prevent the network from becoming another public test network
 	// since it will not be able to learn about other peers that have not
@@ -1085,15 +1374,15 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 	}
 
 	// A message that has no addresses is invalid.
-	if len(msg.AddrList) == 0 {
+	if len(addrList) == 0 {
 		peerLog.Errorf("Command [%s] from %s does not contain any addresses",
-			msg.Command(), p)
+			cmd, p)
 		p.Disconnect()
 		return
 	}
 
 	now := time.Now()
-	for _, na := range msg.AddrList {
+	for _, na := range addrList {
 		// Don't add more address if we're disconnecting.
 		if !p.Connected() {
 			return
@@ -1116,7 +1405,7 @@ func (sp *serverPeer) OnAddr(p *peer.Peer, msg *wire.MsgAddr) {
 	// addresses, and last seen updates.
 	// XXX bitcoind gives a 2 hour time penalty here, do we want to do the
 	// same?
-	sp.server.addrManager.AddAddresses(msg.AddrList, p.NA())
+	sp.server.addrManager.AddAddresses(addrList, p.NA())
 }
 
 // OnRead is invoked when a peer receives a message and it is used to update
@@ -1192,6 +1481,19 @@ func (s *server) AnnounceNewTransactions(newTxs []*hcutil.Tx) {
 			// about stale block templates due to the new transaction.
 			s.rpcServer.gbtWorkState.NotifyMempoolTx(
 				s.txMemPool.LastUpdated())
+
+			// Votes are notified separately since additional votes on
+			// the block being built on invalidate templates right away.
+			msgTx := tx.MsgTx()
+			if stake.DetermineTxType(msgTx) == stake.TxTypeSSGen {
+				blockHash, _, err := stake.SSGenBlockVotedOn(msgTx)
+				if err == nil {
+					numVotes := len(s.txMemPool.VoteHashesForBlock(
+						blockHash))
+					s.rpcServer.gbtWorkState.NotifyVote(&blockHash,
+						numVotes)
+				}
+			}
 		}
 	}
 }
@@ -1271,6 +1573,43 @@ func (s *server) pushBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan cha
 	return nil
 }
 
+// pushCmpctBlockMsg sends a cmpctblock message for the provided block hash to
+// the connected peer.  The full block is sent when the peer did not signal
+// support for compact blocks.  An error is returned if the block hash is not
+// known.
+func (s *server) pushCmpctBlockMsg(sp *serverPeer, hash *chainhash.Hash, doneChan chan<- struct{}, waitChan <-chan struct{}) error {
+	if sp.CmpctBlockVersion() != wire.CmpctBlockVersion {
+		return s.pushBlockMsg(sp, hash, doneChan, waitChan)
+	}
+
+	block, err := sp.server.blockManager.chain.FetchBlockByHash(hash)
+	if err != nil {
+		peerLog.Tracef("Unable to fetch requested block hash %v: %v",
+			hash, err)
+
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+	nonce, err := wire.RandomUint64()
+	if err != nil {
+		if doneChan != nil {
+			doneChan <- struct{}{}
+		}
+		return err
+	}
+	cmpctBlock := mempool.NewCmpctBlock(block, nonce)
+
+	// Once we have fetched data wait for any previous operation to finish.
+	if waitChan != nil {
+		<-waitChan
+	}
+
+	sp.QueueMessage(cmpctBlock, doneChan)
+	return nil
+}
+
 // handleUpdatePeerHeight updates the heights of all peers who were known to
 // announce a block we recently accepted.
 func (s *server) handleUpdatePeerHeights(state *peerState, umsg updatePeerHeightsMsg) {
@@ -1320,23 +1659,24 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 		sp.Disconnect()
 		return false
 	}
-	if banEnd, ok := state.banned[host]; ok {
-		if time.Now().Before(banEnd) {
-			srvrLog.Debugf("Peer %s is banned for another %v - disconnecting",
-				host, banEnd.Sub(time.Now()))
+	if ip := net.ParseIP(host); ip != nil {
+		if ban := s.banList.Lookup(ip); ban != nil {
+			srvrLog.Debugf("Peer %s is banned (%s) for another %v - "+
+				"disconnecting", host, ban.Reason,
+				time.Until(ban.Expires))
 			sp.Disconnect()
 			return false
 		}
-
-		srvrLog.Infof("Peer %s is no longer banned", host)
-		delete(state.banned, host)
 	}
 
 	// TODO: Check for max peers from a single IP.
 
 	// Limit max number of total peers.
-	// allow whitelisted inbound peers regardless.
-	if state.Count() >= cfg.MaxPeers && !(sp.Inbound() && sp.isWhitelisted) {
+	// allow whitelisted inbound peers regardless.  New inbound peers are
+	// allowed as well when an existing inbound peer can be evicted.
+	if state.Count() >= cfg.MaxPeers && !(sp.Inbound() && sp.isWhitelisted) &&
+		!(sp.Inbound() && s.evictInboundPeer(state, sp)) {
+
 		srvrLog.Infof("Max peers reached [%d] - disconnecting peer %s",
 			cfg.MaxPeers, sp)
 		sp.Disconnect()
@@ -1361,6 +1701,62 @@ func (s *server) handleAddPeerMsg(state *peerState, sp *serverPeer) bool {
 	return true
 }
 
+// evictionCandidates returns the details of the connected inbound peers which
+// may be evicted to make room for new inbound peers.  Whitelisted peers are
+// never evicted.  It is invoked from the peerHandler goroutine.
+func (s *server) evictionCandidates(state *peerState) []*evictionCandidate {
+	candidates := make([]*evictionCandidate, 0, len(state.inboundPeers))
+	for _, sp := range state.inboundPeers {
+		if sp.isWhitelisted || !sp.Connected() {
+			continue
+		}
+
+		group := addrmgr.GroupKey(sp.NA())
+		keyed := make([]byte, 0, len(state.evictionKey)+len(group))
+		keyed = append(keyed, state.evictionKey[:]...)
+		keyed = append(keyed, group...)
+		candidates = append(candidates, &evictionCandidate{
+			id:             sp.ID(),
+			netGroup:       group,
+			netGroupKey:    binary.LittleEndian.Uint64(chainhash.HashB(keyed)),
+			connTime:       sp.TimeConnected(),
+			pingMicros:     sp.LastPingMicros(),
+			lastBlockRelay: atomic.LoadInt64(&sp.lastBlockRelay),
+			lastTxRelay:    atomic.LoadInt64(&sp.lastTxRelay),
+		})
+	}
+	return candidates
+}
+
+// evictInboundPeer disconnects an unprotected inbound peer to make room for the
+// passed new inbound peer.  It returns whether a peer was evicted.  It is
+// invoked from the peerHandler goroutine.
+func (s *server) evictInboundPeer(state *peerState, newPeer *serverPeer) bool {
+	id, ok, reasons := selectEvictionCandidate(s.evictionCandidates(state))
+	if !ok {
+		srvrLog.Debugf("No inbound peer to evict for %s - all %d "+
+			"candidates are protected", newPeer, len(reasons))
+		return false
+	}
+
+	// Summarize the protection reasons for the debug log.
+	protected := make(map[string]int)
+	for _, reason := range reasons {
+		protected[reason]++
+	}
+	sp := state.inboundPeers[id]
+	srvrLog.Infof("Evicting inbound peer %s (netgroup %s, connected %v) "+
+		"to make room for %s", sp, addrmgr.GroupKey(sp.NA()),
+		time.Since(sp.TimeConnected()).Truncate(time.Second), newPeer)
+	srvrLog.Debugf("Eviction protection of inbound peers: %d netgroup, "+
+		"%d ping, %d txrelay, %d blockrelay, %d uptime, %d candidates",
+		protected[evictReasonNetGroup], protected[evictReasonPing],
+		protected[evictReasonTxRelay], protected[evictReasonBlockRelay],
+		protected[evictReasonUptime], protected[evictReasonCandidate]+1)
+	sp.Disconnect()
+	return true
+}
+
 // handleDonePeerMsg deals with peers that have signalled they are done.  It is
 // invoked from the peerHandler goroutine.
 func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
@@ -1400,26 +1796,65 @@ func (s *server) handleDonePeerMsg(state *peerState, sp *serverPeer) {
 
 // handleBanPeerMsg deals with banning peers.  It is invoked from the
 // peerHandler goroutine.
-func (s *server) handleBanPeerMsg(state *peerState, sp *serverPeer) {
+func (s *server) handleBanPeerMsg(state *peerState, msg banPeerMsg) {
+	sp := msg.sp
 	host, _, err := net.SplitHostPort(sp.Addr())
 	if err != nil {
 		srvrLog.Debugf("can't split ban peer %s %v", sp.Addr(), err)
 		return
 	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		srvrLog.Debugf("can't ban peer %s: not an IP address", sp.Addr())
+		return
+	}
+	subnet := s.banList.BanAddress(ip, time.Now().Add(cfg.BanDuration),
+		msg.reason)
 	direction := directionString(sp.Inbound())
-	srvrLog.Infof("Banned peer %s (%s) for %v", host, direction,
-		cfg.BanDuration)
-	state.banned[host] = time.Now().Add(cfg.BanDuration)
+	srvrLog.Infof("Banned peer %s (%s) as part of %s for %v: %s", host,
+		direction, subnet, cfg.BanDuration, msg.reason)
 }
 
 // handleRelayInvMsg deals with relaying inventory to peers that are not already
 // known to have it.  It is invoked from the peerHandler goroutine.
 func (s *server) handleRelayInvMsg(state *peerState, msg relayMsg) {
+	// cmpctBlock is the compact block of a relayed block.  It is only
+	// created once it is needed by a peer which wants new blocks to be
+	// announced by compact blocks.
+	var cmpctBlock *wire.MsgCmpctBlock
 	state.forAllPeers(func(sp *serverPeer) {
 		if !sp.Connected() {
 			return
 		}
 
+		// If the inventory is a block and the peer prefers compact
+		// blocks, send a cmpctblock message instead of an inventory
+		// message.
+		if msg.invVect.Type == wire.InvTypeBlock && sp.WantsCmpctBlocks() {
+			if sp.IsKnownInventory(msg.invVect) {
+				return
+			}
+			if cmpctBlock == nil {
+				chain := s.blockManager.chain
+				block, err := chain.FetchBlockByHash(&msg.invVect.Hash)
+				if err != nil {
+					peerLog.Warnf("Unable to fetch block %v "+
+						"to relay: %v", msg.invVect.Hash, err)
+					return
+				}
+				nonce, err := wire.RandomUint64()
+				if err != nil {
+					peerLog.Errorf("Failed to generate compact "+
+						"block nonce: %v", err)
+					return
+				}
+				cmpctBlock = mempool.NewCmpctBlock(block, nonce)
+			}
+			sp.AddKnownInventory(msg.invVect)
+			sp.QueueMessage(cmpctBlock, nil)
+			return
+		}
+
 		// If the inventory is a block and the peer prefers headers,
 		// generate and send a headers message instead of an inventory
 		// message.
@@ -1495,6 +1930,10 @@ type getPeersMsg struct {
 	reply chan []*serverPeer
 }
 
+type getEvictionStatusMsg struct {
+	reply chan map[int32]string
+}
+
 type getOutboundGroup struct {
 	key   string
 	reply chan int
@@ -1520,6 +1959,11 @@ type removeNodeMsg struct {
 	reply chan error
 }
 
+type disconnectSubnetMsg struct {
+	subnet *net.IPNet
+	reply  chan int
+}
+
 // handleQuery is the central handler for all queries and commands from other
 // goroutines related to peer state.
 func (s *server) handleQuery(state *peerState, querymsg interface{}) {
@@ -1592,6 +2036,15 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 		} else {
 			msg.reply <- 0
 		}
+	case getEvictionStatusMsg:
+		_, _, reasons := selectEvictionCandidate(s.evictionCandidates(state))
+		for id, sp := range state.inboundPeers {
+			if sp.isWhitelisted {
+				reasons[id] = evictReasonWhitelisted
+			}
+		}
+		msg.reply <- reasons
+
 	// Request a list of the persistent (added) peers.
 	case getAddedNodesMsg:
 		// Respond with a slice of the relavent peers.
@@ -1629,6 +2082,27 @@ func (s *server) handleQuery(state *peerState, querymsg interface{}) {
 		}
 
 		msg.reply <- errors.New("peer not found")
+
+	case disconnectSubnetMsg:
+		// Disconnect all peers with addresses in the subnet.
+		inSubnet := func(sp *serverPeer) bool {
+			host, _, err := net.SplitHostPort(sp.Addr())
+			if err != nil {
+				return false
+			}
+			ip := net.ParseIP(host)
+			return ip != nil && msg.subnet.Contains(ip)
+		}
+		disconnected := 0
+		for disconnectPeer(state.inboundPeers, inSubnet, nil) {
+			disconnected++
+		}
+		for disconnectPeer(state.outboundPeers, inSubnet, func(sp *serverPeer) {
+			state.outboundGroups[addrmgr.GroupKey(sp.NA())]--
+		}) {
+			disconnected++
+		}
+		msg.reply <- disconnected
 	}
 }
 
@@ -1671,11 +2145,17 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 			OnGetData:        sp.OnGetData,
 			OnGetBlocks:      sp.OnGetBlocks,
 			OnGetHeaders:     sp.OnGetHeaders,
+			OnGetCFilter:     sp.OnGetCFilter,
+			OnGetCFHeaders:   sp.OnGetCFHeaders,
+			OnCmpctBlock:     sp.OnCmpctBlock,
+			OnGetBlockTxn:    sp.OnGetBlockTxn,
+			OnBlockTxn:       sp.OnBlockTxn,
 			OnFilterAdd:      sp.OnFilterAdd,
 			OnFilterClear:    sp.OnFilterClear,
 			OnFilterLoad:     sp.OnFilterLoad,
 			OnGetAddr:        sp.OnGetAddr,
 			OnAddr:           sp.OnAddr,
+			OnAddrV2:         sp.OnAddrV2,
 			OnRead:           sp.OnRead,
 			OnWrite:          sp.OnWrite,
 		},
@@ -1688,7 +2168,48 @@ func newPeerConfig(sp *serverPeer) *peer.Config {
 		Services:         sp.server.services,
 		DisableRelayTx:   cfg.BlocksOnly,
 		ProtocolVersion:  maxProtocolVersion,
+		V2Transport:      cfg.V2Transport,
+	}
+}
+
+// addV1OnlyAddr records that the outbound address does not support the v2
+// transport.  An arbitrary address is forgotten when the maximum number of
+// addresses is reached.
+func (s *server) addV1OnlyAddr(addr string) {
+	s.v1OnlyMtx.Lock()
+	if len(s.v1OnlyAddrs) >= maxV1OnlyAddrs {
+		for a := range s.v1OnlyAddrs {
+			delete(s.v1OnlyAddrs, a)
+			break
+		}
+	}
+	s.v1OnlyAddrs[addr] = struct{}{}
+	s.v1OnlyMtx.Unlock()
+}
+
+// isV1OnlyAddr returns whether the outbound address is known to not support
+// the v2 transport.
+func (s *server) isV1OnlyAddr(addr string) bool {
+	s.v1OnlyMtx.Lock()
+	_, ok := s.v1OnlyAddrs[addr]
+	s.v1OnlyMtx.Unlock()
+	return ok
+}
+
+// initiateV2 returns whether the v2 handshake should be initiated with the
+// outbound address, which is only the case when the address is known to
+// advertise support for the v2 transport and its peer did not reject the
+// handshake before.
+func (s *server) initiateV2(addr string) bool {
+	na, err := s.addrManager.DeserializeNetAddress(addr)
+	if err != nil {
+		return false
 	}
+	services, ok := s.addrManager.KnownServices(na)
+	if !ok || services&wire.SFNodeP2PV2 != wire.SFNodeP2PV2 {
+		return false
+	}
+	return !s.isV1OnlyAddr(addr)
 }
 
 // inboundPeerConnected is invoked by the connection manager when a new inbound
@@ -1710,7 +2231,11 @@ func (s *server) inboundPeerConnected(conn net.Conn) {
 // manager of the attempt.
 func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 	sp := newServerPeer(s, c.Permanent)
-	p, err := peer.NewOutboundPeer(newPeerConfig(sp), c.Addr.String())
+	peerCfg := newPeerConfig(sp)
+	if peerCfg.V2Transport && !s.initiateV2(c.Addr.String()) {
+		peerCfg.V2Transport = false
+	}
+	p, err := peer.NewOutboundPeer(peerCfg, c.Addr.String())
 	if err != nil {
 		srvrLog.Debugf("Cannot create outbound peer %s: %v", c.Addr, err)
 		s.connManager.Disconnect(c.ID())
@@ -1728,6 +2253,13 @@ func (s *server) outboundPeerConnected(c *connmgr.ConnReq, conn net.Conn) {
 // done.
 func (s *server) peerDoneHandler(sp *serverPeer) {
 	sp.WaitForDisconnect()
+
+	// Legacy peers close the connection instead of sending their public key
+	// during the v2 handshake, so fall back to the v1 transport for the next
+	// connection to an outbound address when that happened.
+	if !sp.Inbound() && sp.V2Rejected() {
+		s.addV1OnlyAddr(sp.Addr())
+	}
 	s.donePeers <- sp
 
 	// Only tell block manager we are gone if we ever told it we existed.
@@ -1755,9 +2287,11 @@ func (s *server) peerHandler() {
 		inboundPeers:    make(map[int32]*serverPeer),
 		persistentPeers: make(map[int32]*serverPeer),
 		outboundPeers:   make(map[int32]*serverPeer),
-		banned:          make(map[string]time.Time),
 		outboundGroups:  make(map[string]int),
 	}
+	if _, err := rand.Read(state.evictionKey[:]); err != nil {
+		srvrLog.Errorf("Unable to generate eviction key: %v", err)
+	}
 
 	if !cfg.DisableDNSSeed {
 		// Add peers discovered through DNS to the address manager.
@@ -1816,6 +2350,9 @@ out:
 	s.connManager.Stop()
 	s.blockManager.Stop()
 	s.addrManager.Stop()
+	if err := s.banList.Flush(); err != nil {
+		srvrLog.Errorf("Unable to save ban list: %v", err)
+	}
 
 	// Drain channels before exiting so nothing is left waiting around
 	// to send.
@@ -1841,9 +2378,28 @@ func (s *server) AddPeer(sp *serverPeer) {
 	s.newPeers <- sp
 }
 
-// BanPeer bans a peer that has already been connected to the server by ip.
-func (s *server) BanPeer(sp *serverPeer) {
-	s.banPeers <- sp
+// BanPeer bans a peer that has already been connected to the server by ip for
+// the passed reason.
+func (s *server) BanPeer(sp *serverPeer, reason string) {
+	s.banPeers <- banPeerMsg{sp: sp, reason: reason}
+}
+
+// BanSubnet bans the passed subnet until the passed expiry time for the passed
+// reason and disconnects all connected peers with addresses in the subnet.
+func (s *server) BanSubnet(subnet *net.IPNet, expires time.Time, reason string) error {
+	if err := s.banList.Ban(subnet, expires, reason); err != nil {
+		return err
+	}
+	srvrLog.Infof("Banned %s until %v: %s", subnet,
+		expires.Truncate(time.Second), reason)
+
+	replyChan := make(chan int)
+	s.query <- disconnectSubnetMsg{subnet: subnet, reply: replyChan}
+	if n := <-replyChan; n > 0 {
+		srvrLog.Infof("Disconnected %d peers in banned subnet %s", n,
+			subnet)
+	}
+	return nil
 }
 
 // RelayInventory relays the passed inventory vector to all connected peers
@@ -1895,6 +2451,17 @@ func (s *server) Peers() []*serverPeer {
 	return <-replyChan
 }
 
+// EvictionStatus returns the eviction status of the inbound peers keyed by their
+// ids.  The status is the reason the peer is protected from eviction, or
+// whether it is a candidate for eviction or the peer which is evicted next.
+func (s *server) EvictionStatus() map[int32]string {
+	replyChan := make(chan map[int32]string)
+
+	s.query <- getEvictionStatusMsg{reply: replyChan}
+
+	return <-replyChan
+}
+
 // DisconnectNodeByAddr disconnects a peer by target address. Both outbound and
 // inbound nodes will be searched for the target node. An error message will
 // be returned if the peer was not found.
@@ -2085,6 +2652,21 @@ func (s *server) Start() {
 	if cfg.Generate {
 		s.cpuMiner.Start()
 	}
+
+	// Start the Stratum server if it is enabled.
+	if s.stratumServer != nil {
+		s.stratumServer.Start()
+	}
+
+	// Reload the memory pool saved on the last shutdown in the background
+	// now that the peer handler is running to relay the transactions.
+	if !cfg.NoPersistMempool {
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.loadPersistedMempool()
+		}()
+	}
 }
 
 // Stop gracefully shuts down the server by stopping and disconnecting all
@@ -2103,11 +2685,35 @@ func (s *server) Stop() error {
 		s.cpuMiner.Stop()
 	}
 
+	// Stop the Stratum server if needed.
+	if s.stratumServer != nil {
+		s.stratumServer.Stop()
+	}
+
 	// Shutdown the RPC server if it's not disabled.
 	if !cfg.DisableRPC && s.rpcServer != nil {
 		s.rpcServer.Stop()
 	}
 
+	// Save the fee estimator state in the database so the collected
+	// statistics survive the restart.
+	err := s.db.Update(func(dbTx database.Tx) error {
+		return dbTx.Metadata().Put(fees.EstimatorDatabaseKey,
+			s.feeEstimator.Save())
+	})
+	if err != nil {
+		srvrLog.Errorf("Unable to save fee estimator state: %v", err)
+	}
+
+	// Save the memory pool so it is reloaded on the next startup.  It is
+	// not saved when the memory pool saved on the last shutdown has not
+	// been fully reloaded yet so those transactions are not lost.
+	if !cfg.NoPersistMempool && atomic.LoadInt32(&s.mempoolLoaded) != 0 {
+		if err := s.saveMempool(); err != nil {
+			srvrLog.Errorf("Unable to save mempool: %v", err)
+		}
+	}
+
 	// Signal the remaining goroutines to quit.
 	close(s.quit)
 	return nil
@@ -2118,6 +2724,88 @@ func (s *server) WaitForShutdown() {
 	s.wg.Wait()
 }
 
+// mempoolDumpPath returns the path of the file the memory pool is saved to.
+func mempoolDumpPath() string {
+	return filepath.Join(cfg.DataDir, mempoolDumpFilename)
+}
+
+// saveMempool writes the transactions in the memory pool to the mempool dump
+// file in the data directory.  The dump is written to a temporary file which
+// then replaces the existing one so a failed write never leaves a truncated
+// dump behind.
+func (s *server) saveMempool() error {
+	path := mempoolDumpPath()
+	tmpPath := path + ".new"
+	f, err := os.Create(tmpPath)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(f)
+	err = s.txMemPool.Dump(w)
+	if err == nil {
+		err = w.Flush()
+	}
+	if err == nil {
+		err = f.Sync()
+	}
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// loadMempool reads the mempool dump file in the data directory, processes the
+// transactions it contains and announces those which were accepted to the
+// memory pool.
+func (s *server) loadMempool() (*mempool.LoadStats, error) {
+	f, err := os.Open(mempoolDumpPath())
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	stats, err := s.txMemPool.Load(bufio.NewReader(f), s.quit)
+	if err != nil {
+		return nil, err
+	}
+	if len(stats.Accepted) > 0 {
+		s.AnnounceNewTransactions(stats.Accepted)
+	}
+	return stats, nil
+}
+
+// loadPersistedMempool reloads the memory pool saved on the last shutdown and
+// marks the memory pool as loaded so it is saved again on shutdown.  A missing
+// dump file is not an error since there is nothing to reload on the first
+// startup.
+//
+// This must be run as a goroutine.
+func (s *server) loadPersistedMempool() {
+	stats, err := s.loadMempool()
+	switch {
+	case os.IsNotExist(err):
+	case err != nil:
+		select {
+		case <-s.quit:
+			// The load was interrupted by the shutdown, so the dump
+			// must not be overwritten.
+			return
+		default:
+		}
+		srvrLog.Errorf("Unable to load mempool from %s: %v",
+			mempoolDumpPath(), err)
+	default:
+		srvrLog.Infof("Loaded %d transactions from the saved mempool "+
+			"(%d orphans, %d rejected)", len(stats.Accepted),
+			stats.Orphans, stats.Rejected)
+	}
+	atomic.StoreInt32(&s.mempoolLoaded, 1)
+}
+
 
 
 // parseListeners splits the list of listen addresses passed in addrs into
@@ -2246,8 +2934,35 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	if cfg.NoPeerBloomFilters {
 		services &^= wire.SFNodeBloom
 	}
+	if cfg.NoCFilters {
+		services &^= wire.SFNodeCF
+	}
+	if cfg.V2Transport {
+		services |= wire.SFNodeP2PV2
+	}
+
+	// The full block chain can't be served to peers when pruning is enabled
+	// or when block data was pruned by a previous run, so only advertise
+	// that recent blocks are available.
+	var pruneHeight int64
+	err := db.View(func(dbTx database.Tx) error {
+		pruneHeight = blockchain.DBFetchPruneHeight(dbTx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Prune != 0 || pruneHeight >= 0 {
+		services &^= wire.SFNodeNetwork
+		services |= wire.SFNodeNetworkLimited
+	}
 
 	amgr := addrmgr.New(cfg.DataDir, hcdLookup)
+	banList, err := connmgr.NewBanList(filepath.Join(cfg.DataDir,
+		banListFilename))
+	if err != nil {
+		return nil, err
+	}
 
 	var listeners []net.Listener
 	var nat NAT
@@ -2376,9 +3091,10 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	s := server{
 		chainParams:          chainParams,
 		addrManager:          amgr,
+		banList:              banList,
 		newPeers:             make(chan *serverPeer, cfg.MaxPeers),
 		donePeers:            make(chan *serverPeer, cfg.MaxPeers),
-		banPeers:             make(chan *serverPeer, cfg.MaxPeers),
+		banPeers:             make(chan banPeerMsg, cfg.MaxPeers),
 		query:                make(chan interface{}),
 		relayInv:             make(chan relayMsg, cfg.MaxPeers),
 		broadcast:            make(chan broadcastMsg, cfg.MaxPeers),
@@ -2390,21 +3106,30 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		timeSource:           blockchain.NewMedianTime(),
 		services:             services,
 		sigCache:             txscript.NewSigCache(cfg.SigCacheMaxSize),
+		v1OnlyAddrs:          make(map[string]struct{}),
 	}
 
 	// Create the transaction and address indexes if needed.
 	//
 	// CAUTION: the txindex needs to be first in the indexes array because
-	// the addrindex uses data from the txindex during catchup.  If the
-	// addrindex is run first, it may not have the transactions from the
-	// current block indexed.
+	// the addrindex and addrutxoindex use data from the txindex during
+	// catchup.  If either is run first, it may not have the transactions
+	// from the current block indexed.
 	var indexes []indexers.Indexer
-	if cfg.TxIndex || cfg.AddrIndex {
-		// Enable transaction index if address index is enabled since it
-		// requires it.
+	needsTxIndex := cfg.AddrIndex || cfg.AddrUtxoIndex
+	if (cfg.TxIndex || needsTxIndex || cfg.SpendIndex || cfg.TicketIndex) &&
+		pruneHeight >= 0 {
+
+		return nil, errors.New("the transaction, address, spend, and " +
+			"ticket history indexes require the data for all " +
+			"blocks, which has been pruned")
+	}
+	if cfg.TxIndex || needsTxIndex {
+		// Enable transaction index if an address index is enabled since
+		// it requires it.
 		if !cfg.TxIndex {
 			indxLog.Infof("Transaction index enabled because it " +
-				"is required by the address index")
+				"is required by the address indexes")
 			cfg.TxIndex = true
 		} else {
 			indxLog.Info("Transaction index is enabled")
@@ -2418,16 +3143,64 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		s.addrIndex = indexers.NewAddrIndex(db, chainParams)
 		indexes = append(indexes, s.addrIndex)
 	}
+	if cfg.AddrUtxoIndex {
+		indxLog.Info("Address utxo index is enabled")
+		s.addrUtxoIndex = indexers.NewAddrUtxoIndex(db, chainParams)
+		indexes = append(indexes, s.addrUtxoIndex)
+	}
+	if cfg.SpendIndex {
+		indxLog.Info("Spend index is enabled")
+		s.spendIndex = indexers.NewSpendIndex(db)
+		indexes = append(indexes, s.spendIndex)
+	}
+	if cfg.TicketIndex {
+		indxLog.Info("Ticket history index is enabled")
+		s.ticketIndex = indexers.NewTicketIndex(db)
+		indexes = append(indexes, s.ticketIndex)
+	}
 	if !cfg.NoExistsAddrIndex {
 		indxLog.Info("Exists address index is enabled")
 		s.existsAddrIndex = indexers.NewExistsAddrIndex(db, chainParams)
 		indexes = append(indexes, s.existsAddrIndex)
 	}
+	if !cfg.NoCFilters {
+		indxLog.Info("CF index is enabled")
+		s.cfIndex = indexers.NewCfIndex(db, chainParams)
+		indexes = append(indexes, s.cfIndex)
+	}
 
 	// Create an index manager if any of the optional indexes are enabled.
 	var indexManager blockchain.IndexManager
 	if len(indexes) > 0 {
-		indexManager = indexers.NewManager(db, indexes, chainParams)
+		manager := indexers.NewManager(db, indexes, chainParams)
+
+		// The exists address and committed filter indexes are enabled by
+		// default and are kept up to date while blocks are pruned, but
+		// they can't be caught up once the blocks they are missing have
+		// been pruned, so refuse to start rather than fail part way
+		// through the catchup.
+		if pruneHeight >= 0 {
+			behind, err := manager.IndexesBehind(pruneHeight)
+			if err != nil {
+				return nil, err
+			}
+			if len(behind) > 0 {
+				indexer := behind[0]
+				var flag string
+				switch indexer {
+				case s.existsAddrIndex:
+					flag = "--noexistsaddrindex"
+				case s.cfIndex:
+					flag = "--nocfilters"
+				}
+				return nil, fmt.Errorf("the %s is behind the "+
+					"block data pruned through height %d "+
+					"and can't be caught up -- disable it "+
+					"with %s or resync the block chain",
+					indexer.Name(), pruneHeight, flag)
+			}
+		}
+		indexManager = manager
 	}
 	bm, err := newBlockManager(&s, indexManager)
 	if err != nil {
@@ -2435,6 +3208,33 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 	}
 	s.blockManager = bm
 
+	// Create the fee estimator and restore the statistics it collected
+	// before the last shutdown if they are available.  The saved state is
+	// removed from the database once loaded so that stale statistics are
+	// not restored again after an unclean shutdown.
+	s.feeEstimator, err = fees.NewEstimator(&fees.EstimatorConfig{
+		MinBucketFee: cfg.minRelayTxFee,
+		BestHeight:   bm.chain.BestSnapshot().Height,
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(dbTx database.Tx) error {
+		meta := dbTx.Metadata()
+		feeEstimatorState := meta.Get(fees.EstimatorDatabaseKey)
+		if feeEstimatorState == nil {
+			return nil
+		}
+		if err := s.feeEstimator.Restore(feeEstimatorState); err != nil {
+			srvrLog.Warnf("Unable to restore fee estimator state: %v",
+				err)
+		}
+		return meta.Delete(fees.EstimatorDatabaseKey)
+	})
+	if err != nil {
+		return nil, err
+	}
+
 	txC := mempool.Config{
 		Policy: mempool.Policy{
 			MaxTxVersion:         2,
@@ -2445,6 +3245,12 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 			MaxOrphanTxSize:      defaultMaxOrphanTxSize,
 			MaxSigOpsPerTx:       blockchain.MaxSigOpsPerBlock / 5,
 			MinRelayTxFee:        cfg.minRelayTxFee,
+			RejectReplacement:    cfg.RejectReplacement,
+			MaxPoolSize:          cfg.MaxMempool * 1024 * 1024,
+			MaxAncestorCount:     cfg.LimitAncestorCount,
+			MaxAncestorSize:      cfg.LimitAncestorSize * 1000,
+			MaxDescendantCount:   cfg.LimitDescendantCount,
+			MaxDescendantSize:    cfg.LimitDescendantSize * 1000,
 			AllowOldVotes:        cfg.AllowOldVotes,
 			StandardVerifyFlags: func() (txscript.ScriptFlags, error) {
 				return standardScriptVerifyFlags(bm.chain)
@@ -2467,6 +3273,15 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		PastMedianTime:   func() time.Time { return bm.chain.BestSnapshot().MedianTime },
 		AddrIndex:        s.addrIndex,
 		ExistsAddrIndex:  s.existsAddrIndex,
+
+		AddTxToFeeEstimation:      s.feeEstimator.AddMemPoolTransaction,
+		RemoveTxFromFeeEstimation: s.feeEstimator.RemoveMemPoolTransaction,
+		TxReplaced: func(replaced, replacement *hcutil.Tx) {
+			if s.rpcServer != nil {
+				s.rpcServer.ntfnMgr.NotifyTxReplaced(replaced,
+					replacement)
+			}
+		},
 	}
 	s.txMemPool = mempool.New(&txC)
 
@@ -2513,6 +3328,17 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 					continue
 				}
 
+				// Skip addresses of networks which can't be
+				// reached with the current configuration.
+				if !isReachableNetwork(addr.NetAddress()) {
+					continue
+				}
+
+				// Skip banned addresses.
+				if s.banList.Lookup(addr.NetAddress().IP) != nil {
+					continue
+				}
+
 				// allow nondefault ports after 50 failed tries.
 				if fmt.Sprintf("%d", addr.NetAddress().Port) !=
 					activeNetParams.DefaultPort && tries < 50 {
@@ -2540,6 +3366,7 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		Dial:           hcdDial,
 		OnConnection:   s.outboundPeerConnected,
 		GetNewAddress:  newAddressFunc,
+		BanList:        banList,
 	})
 	if err != nil {
 		return nil, err
@@ -2576,18 +3403,65 @@ func newServer(listenAddrs []string, db database.DB, chainParams *chaincfg.Param
 		}()
 	}
 
+	if cfg.Stratum {
+		s.stratumServer, err = newStratumServer(cfg.StratumListeners,
+			&policy, &s)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	return &s, nil
 }
 
+// overlayAddr implements the net.Addr interface for the addresses of Tor onion
+// services and I2P destinations which can't be resolved to IP addresses and
+// are dialed through their proxies by name instead.
+type overlayAddr struct {
+	addr string
+}
+
+// Network returns the network of the address.
+//
+// This is part of the net.Addr interface.
+func (a *overlayAddr) Network() string {
+	return "tcp"
+}
+
+// String returns the address in the form of 'host:port'.
+//
+// This is part of the net.Addr interface.
+func (a *overlayAddr) String() string {
+	return a.addr
+}
+
+// isReachableNetwork returns whether the network of the passed address can be
+// reached with the current configuration.
+func isReachableNetwork(na *wire.NetAddress) bool {
+	switch na.NetworkType() {
+	case wire.TorV2Address, wire.TorV3Address:
+		return !cfg.NoOnion && (cfg.OnionProxy != "" || cfg.Proxy != "")
+	case wire.I2PAddress:
+		return cfg.I2PProxy != ""
+	case wire.CJDNSAddress:
+		return cfg.CJDNSReachable
+	}
+	return true
+}
+
 // addrStringToNetAddr takes an address in the form of 'host:port' and returns
 // a net.Addr which maps to the original address with any host names resolved
-// to IP addresses.
+// to IP addresses.  Tor and I2P addresses are not resolved.
 func addrStringToNetAddr(addr string) (net.Addr, error) {
 	host, strPort, err := net.SplitHostPort(addr)
 	if err != nil {
 		return nil, err
 	}
 
+	if strings.HasSuffix(host, ".onion") || strings.HasSuffix(host, ".i2p") {
+		return &overlayAddr{addr: addr}, nil
+	}
+
 	// Attempt to look up an IP address associated with the parsed host.
 	// The hcdLookup function will transparently handle performing the
 	// lookup over Tor if necessary.
@@ -0,0 +1,925 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/HcashOrg/hcd/blockchain"
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/HcashOrg/hcd/hcjson"
+	"github.com/HcashOrg/hcd/hcutil"
+	"github.com/HcashOrg/hcd/mining"
+	"github.com/HcashOrg/hcd/wire"
+)
+
+// The Stratum server hands out work as a serialized block header split around
+// the fields miners are expected to roll.  Jobs are sent with the
+// mining.notify method using the following parameters:
+//
+//	[jobID, prevHash, header1, header2, [], version, nBits, nTime, cleanJobs]
+//
+// Every field other than the job ID and the clean jobs flag is the hex encoding
+// of the bytes exactly as they appear in the serialized block header.  Miners
+// reconstruct the 180 byte header to hash as:
+//
+//	version || prevHash || header1 || nTime || nonce || header2
+//
+// where header1 covers the merkle root through the block size and header2
+// covers the extra data and stake version.  Before hashing, the miner writes
+// extraNonce1, which is assigned per connection by mining.subscribe, followed
+// by its own extraNonce2 over the bytes of header2 at stratumExtraNonceOffset.
+// The extra nonces occupy the only portion of the header's ExtraData field the
+// consensus rules leave free, so rolling them does not require rebuilding the
+// coinbase or merkle root.
+const (
+	// stratumExtraNonceOffset is the offset into the header extra data of
+	// the extra nonces.  See blockchain.CheckExtraDataBuf for the bytes
+	// which are allowed to be nonzero.
+	stratumExtraNonceOffset = 8
+
+	// stratumExtraNonce1Size is the number of bytes of the header extra
+	// data assigned to each connection.
+	stratumExtraNonce1Size = 2
+
+	// stratumExtraNonce2Size is the number of bytes of the header extra
+	// data the miner is free to roll.
+	stratumExtraNonce2Size = 2
+
+	// stratumHeader1Start and stratumHeader1End are the offsets of the
+	// first portion of the serialized header sent with each job.
+	stratumHeader1Start = 36
+	stratumHeader1End   = 136
+
+	// stratumHeader2Start is the offset of the portion of the serialized
+	// header after the nonce.
+	stratumHeader2Start = 144
+
+	// stratumMaxMessageSize is the maximum size of a single message a
+	// Stratum client may send.
+	stratumMaxMessageSize = 4096
+
+	// stratumIdleTimeout is the duration a client may remain connected
+	// without sending any messages.
+	stratumIdleTimeout = 10 * time.Minute
+
+	// stratumWriteTimeout is the maximum duration allowed to write a
+	// message to a client.
+	stratumWriteTimeout = 30 * time.Second
+
+	// stratumPollInterval is how often the current best chain, votes, and
+	// memory pool are examined to decide whether a new job is needed.
+	stratumPollInterval = time.Second
+
+	// stratumTxUpdateInterval is the minimum duration between new jobs
+	// that are only issued to include newly received transactions.
+	stratumTxUpdateInterval = 30 * time.Second
+
+	// stratumMaxJobs is the maximum number of jobs building on the same
+	// parent which are kept around for accepting late shares.
+	stratumMaxJobs = 8
+
+	// stratumMaxClientWorkers is the maximum number of workers that may
+	// authorize on a single connection.
+	stratumMaxClientWorkers = 16
+)
+
+// Error codes returned to Stratum clients.  These are the codes commonly used
+// by Stratum pool implementations.
+const (
+	stratumErrOther          = 20
+	stratumErrJobNotFound    = 21
+	stratumErrDuplicateShare = 22
+	stratumErrLowDifficulty  = 23
+	stratumErrUnauthorized   = 24
+	stratumErrNotSubscribed  = 25
+)
+
+// stratumError is an error that is returned to a Stratum client in response to
+// a request.
+type stratumError struct {
+	Code    int
+	Message string
+}
+
+// Error satisfies the error interface.
+func (e *stratumError) Error() string {
+	return fmt.Sprintf("%s (code %d)", e.Message, e.Code)
+}
+
+// MarshalJSON encodes the error in the [code, message, traceback] form used by
+// the Stratum protocol.
+func (e *stratumError) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{e.Code, e.Message, nil})
+}
+
+// newStratumError returns a new stratumError with the provided code and
+// message.
+func newStratumError(code int, message string) *stratumError {
+	return &stratumError{Code: code, Message: message}
+}
+
+// stratumRequest is a request or notification received from a Stratum client.
+type stratumRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+// stratumResponse is the response sent to a Stratum client for a request.
+type stratumResponse struct {
+	ID     json.RawMessage `json:"id"`
+	Result interface{}     `json:"result"`
+	Error  *stratumError   `json:"error"`
+}
+
+// stratumNotification is a message sent to a Stratum client which does not
+// expect a response.
+type stratumNotification struct {
+	ID     interface{}   `json:"id"`
+	Method string        `json:"method"`
+	Params []interface{} `json:"params"`
+}
+
+// stratumJob houses a block template handed out to Stratum clients along with
+// the shares that have been submitted against it.
+type stratumJob struct {
+	id     string
+	block  *wire.MsgBlock
+	target *big.Int
+
+	// shares tracks the hashes of all submitted shares so duplicates can
+	// be rejected.  It is protected by the server mutex.
+	shares map[chainhash.Hash]struct{}
+}
+
+// notifyParams returns the mining.notify parameters for the job.
+func (j *stratumJob) notifyParams(cleanJobs bool) []interface{} {
+	var buf bytes.Buffer
+	buf.Grow(wire.MaxBlockHeaderPayload)
+	// The header serialization can't fail for an in-memory buffer.
+	_ = j.block.Header.Serialize(&buf)
+	header := buf.Bytes()
+
+	return []interface{}{
+		j.id,
+		hex.EncodeToString(header[4:stratumHeader1Start]),
+		hex.EncodeToString(header[stratumHeader1Start:stratumHeader1End]),
+		hex.EncodeToString(header[stratumHeader2Start:]),
+		[]string{},
+		hex.EncodeToString(header[0:4]),
+		hex.EncodeToString(header[116:120]),
+		hex.EncodeToString(header[136:140]),
+		cleanJobs,
+	}
+}
+
+// stratumWorker houses the statistics tracked for a named worker across all of
+// the connections it has authorized on.  The statistics are dropped once all of
+// those connections are closed.
+type stratumWorker struct {
+	name           string
+	connections    int
+	difficulty     float64
+	acceptedShares uint64
+	rejectedShares uint64
+	staleShares    uint64
+	blocksFound    uint64
+	acceptedWork   float64
+	firstSeen      time.Time
+	lastShare      time.Time
+}
+
+// stratumClient represents a single miner connected to the Stratum server.
+type stratumClient struct {
+	conn        net.Conn
+	extraNonce1 [stratumExtraNonce1Size]byte
+	sendMtx     sync.Mutex
+
+	// The following fields are protected by the server mutex.
+	subscribed bool
+	difficulty float64
+	target     *big.Int
+	workers    map[string]*stratumWorker
+}
+
+// stratumServer provides a Stratum mining server that hands out work derived
+// from the same block templates as the getwork RPC and the CPU miner.
+type stratumServer struct {
+	started        int32
+	shutdown       int32
+	server         *server
+	policy         *mining.Policy
+	listeners      []net.Listener
+	hashesPerDiff1 float64
+	newBlock       chan struct{}
+	passSHA        [sha256.Size]byte
+	wg             sync.WaitGroup
+	quit           chan struct{}
+
+	// The following fields are only accessed by the job handler.
+	prevHash      chainhash.Hash
+	numVotes      int
+	lastTxUpdate  time.Time
+	lastGenerated time.Time
+	jobCounter    uint64
+
+	mtx         sync.Mutex
+	clients     map[*stratumClient]struct{}
+	workers     map[string]*stratumWorker
+	jobs        map[string]*stratumJob
+	jobOrder    []string
+	currentJob  *stratumJob
+	extraNonce1 uint16
+}
+
+// difficultyTarget returns the share target for the provided difficulty where
+// a difficulty of one corresponds to the proof of work limit of the network.
+func (s *stratumServer) difficultyTarget(difficulty float64) *big.Int {
+	target := new(big.Float).SetInt(s.server.chainParams.PowLimit)
+	target.Quo(target, big.NewFloat(difficulty))
+	result, _ := target.Int(nil)
+	return result
+}
+
+// send marshals and writes the passed message to the client.  The connection
+// is closed on failure which in turn causes the client's input handler to
+// exit.
+func (c *stratumClient) send(msg interface{}) {
+	b, err := json.Marshal(msg)
+	if err != nil {
+		strmLog.Errorf("Failed to marshal message: %v", err)
+		return
+	}
+	b = append(b, '\n')
+
+	c.sendMtx.Lock()
+	defer c.sendMtx.Unlock()
+	c.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+	if _, err := c.conn.Write(b); err != nil {
+		strmLog.Debugf("Failed to write to %s: %v", c.conn.RemoteAddr(),
+			err)
+		c.conn.Close()
+	}
+}
+
+// notify sends a notification with the given method and parameters to the
+// client.
+func (c *stratumClient) notify(method string, params ...interface{}) {
+	c.send(&stratumNotification{Method: method, Params: params})
+}
+
+// handleSubscribe handles the mining.subscribe method.
+func (s *stratumServer) handleSubscribe(c *stratumClient, params []json.RawMessage) (interface{}, *stratumError) {
+	s.mtx.Lock()
+	c.subscribed = true
+	s.mtx.Unlock()
+
+	subscriptionID := hex.EncodeToString(c.extraNonce1[:])
+	return []interface{}{
+		[][]string{
+			{"mining.set_difficulty", subscriptionID},
+			{"mining.notify", subscriptionID},
+		},
+		subscriptionID,
+		stratumExtraNonce2Size,
+	}, nil
+}
+
+// handleAuthorize handles the mining.authorize method.  Any worker name is
+// accepted since it is only used to group statistics, but the password must
+// match the configured one when there is one.
+func (s *stratumServer) handleAuthorize(c *stratumClient, params []json.RawMessage) (interface{}, *stratumError) {
+	var name string
+	if len(params) < 1 || json.Unmarshal(params[0], &name) != nil ||
+		name == "" {
+		return nil, newStratumError(stratumErrOther,
+			"invalid worker name")
+	}
+	if cfg.StratumPass != "" {
+		var pass string
+		if len(params) >= 2 {
+			json.Unmarshal(params[1], &pass)
+		}
+		passSHA := sha256.Sum256([]byte(pass))
+		if subtle.ConstantTimeCompare(passSHA[:], s.passSHA[:]) != 1 {
+			strmLog.Warnf("Stratum authorization failure for worker "+
+				"%s from %s", name, c.conn.RemoteAddr())
+			return nil, newStratumError(stratumErrUnauthorized,
+				"invalid password")
+		}
+	}
+
+	s.mtx.Lock()
+	if _, ok := c.workers[name]; !ok {
+		if len(c.workers) >= stratumMaxClientWorkers {
+			s.mtx.Unlock()
+			return nil, newStratumError(stratumErrOther,
+				"too many workers")
+		}
+		worker, ok := s.workers[name]
+		if !ok {
+			worker = &stratumWorker{
+				name:      name,
+				firstSeen: time.Now(),
+			}
+			s.workers[name] = worker
+		}
+		worker.connections++
+		worker.difficulty = c.difficulty
+		c.workers[name] = worker
+	}
+	s.mtx.Unlock()
+
+	strmLog.Debugf("Authorized worker %s from %s", name, c.conn.RemoteAddr())
+	return true, nil
+}
+
+// handleSuggestDifficulty handles the mining.suggest_difficulty method which
+// sets the share difficulty of the connection.  Suggestions below the initial
+// share difficulty are raised to it so clients can't have every hash counted
+// as a share and inflate the stats of their workers.
+func (s *stratumServer) handleSuggestDifficulty(c *stratumClient, params []json.RawMessage) (interface{}, *stratumError) {
+	var difficulty float64
+	if len(params) < 1 || json.Unmarshal(params[0], &difficulty) != nil ||
+		math.IsNaN(difficulty) || math.IsInf(difficulty, 0) ||
+		difficulty <= 0 {
+		return nil, newStratumError(stratumErrOther,
+			"invalid difficulty")
+	}
+	clamped := difficulty < cfg.StratumDifficulty
+	if clamped {
+		difficulty = cfg.StratumDifficulty
+	}
+
+	s.mtx.Lock()
+	c.difficulty = difficulty
+	c.target = s.difficultyTarget(difficulty)
+	for _, worker := range c.workers {
+		worker.difficulty = difficulty
+	}
+	s.mtx.Unlock()
+
+	// Let the client know the difficulty it must use when it differs from
+	// the suggested one.
+	if clamped {
+		c.notify("mining.set_difficulty", difficulty)
+	}
+
+	return true, nil
+}
+
+// parseHexParam decodes the hex encoded string parameter and ensures it has
+// the expected length.
+func parseHexParam(param json.RawMessage, size int) ([]byte, error) {
+	var str string
+	if err := json.Unmarshal(param, &str); err != nil {
+		return nil, err
+	}
+	b, err := hex.DecodeString(str)
+	if err != nil {
+		return nil, err
+	}
+	if len(b) != size {
+		return nil, fmt.Errorf("got %d bytes, want %d", len(b), size)
+	}
+	return b, nil
+}
+
+// handleSubmit handles the mining.submit method.  Shares that meet the
+// difficulty of the connection are credited to the worker and those which also
+// meet the network difficulty are submitted as blocks.
+func (s *stratumServer) handleSubmit(c *stratumClient, params []json.RawMessage) (interface{}, *stratumError) {
+	if len(params) < 5 {
+		return nil, newStratumError(stratumErrOther,
+			"invalid number of parameters")
+	}
+	var name, jobID string
+	if json.Unmarshal(params[0], &name) != nil {
+		return nil, newStratumError(stratumErrOther,
+			"invalid worker name")
+	}
+
+	s.mtx.Lock()
+	subscribed := c.subscribed
+	worker, authorized := c.workers[name]
+	s.mtx.Unlock()
+	if !subscribed {
+		return nil, newStratumError(stratumErrNotSubscribed,
+			"not subscribed")
+	}
+	if !authorized {
+		return nil, newStratumError(stratumErrUnauthorized,
+			"unauthorized worker")
+	}
+
+	// rejectShare updates the worker statistics for a rejected share and
+	// returns the provided error.
+	rejectShare := func(code int, message string) *stratumError {
+		s.mtx.Lock()
+		if code == stratumErrJobNotFound {
+			worker.staleShares++
+		} else {
+			worker.rejectedShares++
+		}
+		s.mtx.Unlock()
+		return newStratumError(code, message)
+	}
+
+	if json.Unmarshal(params[1], &jobID) != nil {
+		return nil, rejectShare(stratumErrOther, "invalid job id")
+	}
+	extraNonce2, err := parseHexParam(params[2], stratumExtraNonce2Size)
+	if err != nil {
+		return nil, rejectShare(stratumErrOther, "invalid extranonce2")
+	}
+	nTime, err := parseHexParam(params[3], 4)
+	if err != nil {
+		return nil, rejectShare(stratumErrOther, "invalid ntime")
+	}
+	nonce, err := parseHexParam(params[4], 4)
+	if err != nil {
+		return nil, rejectShare(stratumErrOther, "invalid nonce")
+	}
+
+	s.mtx.Lock()
+	job, ok := s.jobs[jobID]
+	s.mtx.Unlock()
+	if !ok {
+		return nil, rejectShare(stratumErrJobNotFound, "job not found")
+	}
+
+	// Reconstruct the header the miner hashed.  The time may only be rolled
+	// forward and must not be further in the future than the consensus
+	// rules allow.
+	header := job.block.Header
+	timestamp := int64(binary.LittleEndian.Uint32(nTime))
+	maxTimestamp := time.Now().Unix() + blockchain.MaxTimeOffsetSeconds
+	if timestamp < header.Timestamp.Unix() || timestamp > maxTimestamp {
+		return nil, rejectShare(stratumErrOther, "ntime out of range")
+	}
+	header.Timestamp = time.Unix(timestamp, 0)
+	header.Nonce = binary.LittleEndian.Uint32(nonce)
+	extraNonce := header.ExtraData[stratumExtraNonceOffset:]
+	copy(extraNonce, c.extraNonce1[:])
+	copy(extraNonce[stratumExtraNonce1Size:], extraNonce2)
+	hash := header.BlockHash()
+	hashNum := blockchain.HashToBig(&hash)
+
+	s.mtx.Lock()
+	if _, ok := job.shares[hash]; ok {
+		s.mtx.Unlock()
+		return nil, rejectShare(stratumErrDuplicateShare,
+			"duplicate share")
+	}
+	if hashNum.Cmp(c.target) > 0 {
+		s.mtx.Unlock()
+		return nil, rejectShare(stratumErrLowDifficulty,
+			"low difficulty share")
+	}
+	job.shares[hash] = struct{}{}
+	worker.acceptedShares++
+	worker.acceptedWork += c.difficulty
+	worker.difficulty = c.difficulty
+	worker.lastShare = time.Now()
+	s.mtx.Unlock()
+
+	// Submit the block when the share also satisfies the network target.
+	if hashNum.Cmp(job.target) <= 0 {
+		msgBlock := hcutil.NewBlockDeepCopy(job.block).MsgBlock()
+		msgBlock.Header = header
+		if s.submitBlock(hcutil.NewBlock(msgBlock), name) {
+			s.mtx.Lock()
+			worker.blocksFound++
+			s.mtx.Unlock()
+		}
+	}
+
+	return true, nil
+}
+
+// submitBlock submits the passed block found by the named worker to the
+// network after ensuring it passes all of the consensus validation rules.
+func (s *stratumServer) submitBlock(block *hcutil.Block, worker string) bool {
+	isOrphan, err := s.server.blockManager.ProcessBlock(block,
+		blockchain.BFNone)
+	if err != nil {
+		if _, ok := err.(blockchain.RuleError); !ok {
+			strmLog.Errorf("Unexpected error while processing block "+
+				"submitted by worker %s: %v", worker, err)
+			return false
+		}
+		strmLog.Infof("Block submitted by worker %s rejected: %v",
+			worker, err)
+		return false
+	}
+	if isOrphan {
+		strmLog.Infof("Block submitted by worker %s is an orphan "+
+			"building on parent %v", worker,
+			block.MsgBlock().Header.PrevBlock)
+		return false
+	}
+
+	strmLog.Infof("Block submitted by worker %s accepted (hash %s, "+
+		"height %v)", worker, block.Hash(), block.Height())
+	return true
+}
+
+// handleRequest dispatches a request received from a client to the handler
+// for its method.
+func (s *stratumServer) handleRequest(c *stratumClient, req *stratumRequest) (interface{}, *stratumError) {
+	switch req.Method {
+	case "mining.subscribe":
+		return s.handleSubscribe(c, req.Params)
+	case "mining.authorize":
+		return s.handleAuthorize(c, req.Params)
+	case "mining.suggest_difficulty":
+		return s.handleSuggestDifficulty(c, req.Params)
+	case "mining.submit":
+		return s.handleSubmit(c, req.Params)
+	}
+	return nil, newStratumError(stratumErrOther, "unsupported method")
+}
+
+// sendWork sends the current share difficulty and job to the client.
+func (s *stratumServer) sendWork(c *stratumClient) {
+	s.mtx.Lock()
+	difficulty := c.difficulty
+	job := s.currentJob
+	s.mtx.Unlock()
+
+	c.notify("mining.set_difficulty", difficulty)
+	if job != nil {
+		c.notify("mining.notify", job.notifyParams(true)...)
+	}
+}
+
+// clientHandler reads and handles requests from a connected client until the
+// connection is closed.  It must be run as a goroutine.
+func (s *stratumServer) clientHandler(c *stratumClient) {
+	remoteAddr := c.conn.RemoteAddr()
+	strmLog.Debugf("New Stratum client %s", remoteAddr)
+
+	scanner := bufio.NewScanner(c.conn)
+	scanner.Buffer(make([]byte, 0, 512), stratumMaxMessageSize)
+	for {
+		c.conn.SetReadDeadline(time.Now().Add(stratumIdleTimeout))
+		if !scanner.Scan() {
+			break
+		}
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			strmLog.Debugf("Malformed request from %s: %v",
+				remoteAddr, err)
+			break
+		}
+
+		result, jsonErr := s.handleRequest(c, &req)
+		if jsonErr != nil {
+			strmLog.Debugf("Request %s from %s failed: %v",
+				req.Method, remoteAddr, jsonErr)
+		}
+		c.send(&stratumResponse{
+			ID:     req.ID,
+			Result: result,
+			Error:  jsonErr,
+		})
+
+		// Send work once the client is able to use it.
+		switch req.Method {
+		case "mining.authorize", "mining.suggest_difficulty":
+			if jsonErr == nil {
+				s.sendWork(c)
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		strmLog.Debugf("Stratum client %s read error: %v", remoteAddr,
+			err)
+	}
+
+	c.conn.Close()
+	s.mtx.Lock()
+	delete(s.clients, c)
+	for _, worker := range c.workers {
+		worker.connections--
+		if worker.connections == 0 {
+			delete(s.workers, worker.name)
+		}
+	}
+	s.mtx.Unlock()
+
+	strmLog.Debugf("Stratum client %s disconnected", remoteAddr)
+	s.wg.Done()
+}
+
+// listenHandler accepts incoming client connections on the passed listener.
+// It must be run as a goroutine.
+func (s *stratumServer) listenHandler(listener net.Listener) {
+	strmLog.Infof("Stratum server listening on %s", listener.Addr())
+	for atomic.LoadInt32(&s.shutdown) == 0 {
+		conn, err := listener.Accept()
+		if err != nil {
+			// Only log the error if not forcibly shutting down.
+			if atomic.LoadInt32(&s.shutdown) == 0 {
+				strmLog.Errorf("Can't accept connection: %v", err)
+			}
+			continue
+		}
+
+		s.mtx.Lock()
+		if len(s.clients) >= cfg.StratumMaxClients {
+			s.mtx.Unlock()
+			strmLog.Infof("Max Stratum clients exceeded [%d] - "+
+				"disconnecting client %s", cfg.StratumMaxClients,
+				conn.RemoteAddr())
+			conn.Close()
+			continue
+		}
+		s.extraNonce1++
+		c := &stratumClient{
+			conn:       conn,
+			difficulty: cfg.StratumDifficulty,
+			target:     s.difficultyTarget(cfg.StratumDifficulty),
+			workers:    make(map[string]*stratumWorker),
+		}
+		binary.BigEndian.PutUint16(c.extraNonce1[:], s.extraNonce1)
+		s.clients[c] = struct{}{}
+		s.mtx.Unlock()
+
+		s.wg.Add(1)
+		go s.clientHandler(c)
+	}
+	strmLog.Tracef("Stratum listener done for %s", listener.Addr())
+	s.wg.Done()
+}
+
+// refreshJob generates a new job when the best chain, the votes available for
+// it, or the transactions in the memory pool have changed and broadcasts it to
+// all subscribed clients.
+func (s *stratumServer) refreshJob() {
+	bm := s.server.blockManager
+	latestHash, latestHeight := bm.chainState.Best()
+
+	// No point in handing out work before the chain is synced.
+	if latestHeight != 0 && !bm.IsCurrent() {
+		return
+	}
+
+	lastTxUpdate := s.server.txMemPool.LastUpdated()
+	numVotes := len(s.server.txMemPool.VoteHashesForBlock(*latestHash))
+	s.mtx.Lock()
+	haveJob := s.currentJob != nil
+	s.mtx.Unlock()
+	if haveJob && s.prevHash == *latestHash && s.numVotes == numVotes &&
+		(s.lastTxUpdate == lastTxUpdate ||
+			time.Since(s.lastGenerated) < stratumTxUpdateInterval) {
+		return
+	}
+
+	payToAddr := cfg.miningAddrs[rand.Intn(len(cfg.miningAddrs))]
+	template, err := NewBlockTemplate(s.policy, s.server, payToAddr)
+	if err != nil {
+		strmLog.Errorf("Failed to create new block template: %v", err)
+		return
+	}
+	if template == nil {
+		// Not enough voters on the current tip and no suitable parent
+		// template to build from, so wait for more votes.
+		return
+	}
+	s.prevHash = *latestHash
+	s.numVotes = numVotes
+	s.lastTxUpdate = lastTxUpdate
+	s.lastGenerated = time.Now()
+
+	s.jobCounter++
+	msgBlock := deepCopyBlockTemplate(template).Block
+	job := &stratumJob{
+		id:     fmt.Sprintf("%x", s.jobCounter),
+		block:  msgBlock,
+		target: blockchain.CompactToBig(msgBlock.Header.Bits),
+		shares: make(map[chainhash.Hash]struct{}),
+	}
+
+	// Discard all jobs building on a different parent since any shares for
+	// them are stale and limit the number kept otherwise.
+	s.mtx.Lock()
+	cleanJobs := s.currentJob == nil ||
+		s.currentJob.block.Header.PrevBlock != msgBlock.Header.PrevBlock
+	if cleanJobs {
+		s.jobs = make(map[string]*stratumJob)
+		s.jobOrder = s.jobOrder[:0]
+	}
+	if len(s.jobOrder) >= stratumMaxJobs {
+		delete(s.jobs, s.jobOrder[0])
+		s.jobOrder = s.jobOrder[1:]
+	}
+	s.jobs[job.id] = job
+	s.jobOrder = append(s.jobOrder, job.id)
+	s.currentJob = job
+	clients := make([]*stratumClient, 0, len(s.clients))
+	for c := range s.clients {
+		if c.subscribed && len(c.workers) > 0 {
+			clients = append(clients, c)
+		}
+	}
+	s.mtx.Unlock()
+
+	strmLog.Debugf("New job %s (height %d, parent %s, clean %v)", job.id,
+		msgBlock.Header.Height, msgBlock.Header.PrevBlock, cleanJobs)
+
+	params := job.notifyParams(cleanJobs)
+	for _, c := range clients {
+		c.notify("mining.notify", params...)
+	}
+}
+
+// jobHandler keeps the job handed out to clients up to date.  It must be run
+// as a goroutine.
+func (s *stratumServer) jobHandler() {
+	ticker := time.NewTicker(stratumPollInterval)
+	defer ticker.Stop()
+
+out:
+	for {
+		s.refreshJob()
+
+		select {
+		case <-s.newBlock:
+		case <-ticker.C:
+		case <-s.quit:
+			break out
+		}
+	}
+	s.wg.Done()
+}
+
+// NotifyBlockConnected signals the job handler that a new block was connected
+// so clients are given new work without waiting for the next poll.
+func (s *stratumServer) NotifyBlockConnected() {
+	select {
+	case s.newBlock <- struct{}{}:
+	default:
+	}
+}
+
+// Info returns the state of the Stratum server and the statistics of all
+// workers that are authorized on a connected client.
+func (s *stratumServer) Info() *hcjson.GetStratumInfoResult {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	result := &hcjson.GetStratumInfoResult{
+		Listeners:   make([]string, 0, len(s.listeners)),
+		Connections: len(s.clients),
+		Workers:     make([]hcjson.StratumWorkerResult, 0, len(s.workers)),
+	}
+	for _, listener := range s.listeners {
+		result.Listeners = append(result.Listeners,
+			listener.Addr().String())
+	}
+	if s.currentJob != nil {
+		result.JobID = s.currentJob.id
+		result.Height = int64(s.currentJob.block.Header.Height)
+	}
+	for _, worker := range s.workers {
+		var hashesPerSec float64
+		elapsed := time.Since(worker.firstSeen).Seconds()
+		if elapsed > 0 {
+			hashesPerSec = worker.acceptedWork * s.hashesPerDiff1 /
+				elapsed
+		}
+		var lastShare int64
+		if !worker.lastShare.IsZero() {
+			lastShare = worker.lastShare.Unix()
+		}
+		result.Workers = append(result.Workers, hcjson.StratumWorkerResult{
+			Name:           worker.name,
+			Connections:    worker.connections,
+			Difficulty:     worker.difficulty,
+			AcceptedShares: worker.acceptedShares,
+			RejectedShares: worker.rejectedShares,
+			StaleShares:    worker.staleShares,
+			BlocksFound:    worker.blocksFound,
+			HashesPerSec:   hashesPerSec,
+			LastShare:      lastShare,
+		})
+	}
+	sort.Slice(result.Workers, func(i, j int) bool {
+		return result.Workers[i].Name < result.Workers[j].Name
+	})
+	return result
+}
+
+// Start begins accepting connections and handing out work.
+func (s *stratumServer) Start() {
+	if atomic.AddInt32(&s.started, 1) != 1 {
+		return
+	}
+
+	strmLog.Trace("Starting Stratum server")
+	for _, listener := range s.listeners {
+		s.wg.Add(1)
+		go s.listenHandler(listener)
+	}
+	s.wg.Add(1)
+	go s.jobHandler()
+}
+
+// Stop disconnects all clients and stops the Stratum server.
+func (s *stratumServer) Stop() {
+	if atomic.AddInt32(&s.shutdown, 1) != 1 {
+		strmLog.Infof("Stratum server is already in the process of " +
+			"shutting down")
+		return
+	}
+
+	strmLog.Warnf("Stratum server shutting down")
+	close(s.quit)
+	for _, listener := range s.listeners {
+		listener.Close()
+	}
+	s.mtx.Lock()
+	for c := range s.clients {
+		c.conn.Close()
+	}
+	s.mtx.Unlock()
+	s.wg.Wait()
+	strmLog.Infof("Stratum server shutdown complete")
+}
+
+// newStratumServer returns a new Stratum server listening on the passed
+// addresses which builds work according to the provided mining policy.
+func newStratumServer(listenAddrs []string, policy *mining.Policy, s *server) (*stratumServer, error) {
+	ipv4ListenAddrs, ipv6ListenAddrs, _, err := parseListeners(listenAddrs)
+	if err != nil {
+		return nil, err
+	}
+	listeners := make([]net.Listener, 0,
+		len(ipv6ListenAddrs)+len(ipv4ListenAddrs))
+	for _, addr := range ipv4ListenAddrs {
+		listener, err := net.Listen("tcp4", addr)
+		if err != nil {
+			strmLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	for _, addr := range ipv6ListenAddrs {
+		listener, err := net.Listen("tcp6", addr)
+		if err != nil {
+			strmLog.Warnf("Can't listen on %s: %v", addr, err)
+			continue
+		}
+		listeners = append(listeners, listener)
+	}
+	if len(listeners) == 0 {
+		return nil, errors.New("STRM: No valid listen address")
+	}
+
+	// The number of hashes expected to find a share at difficulty one is
+	// 2^256 / PowLimit.
+	hashesPerDiff1 := new(big.Float).SetInt(new(big.Int).Lsh(big.NewInt(1),
+		256))
+	hashesPerDiff1.Quo(hashesPerDiff1, new(big.Float).SetInt(
+		s.chainParams.PowLimit))
+	hashesPerDiff1Float, _ := hashesPerDiff1.Float64()
+
+	return &stratumServer{
+		server:         s,
+		policy:         policy,
+		listeners:      listeners,
+		hashesPerDiff1: hashesPerDiff1Float,
+		newBlock:       make(chan struct{}, 1),
+		passSHA:        sha256.Sum256([]byte(cfg.StratumPass)),
+		quit:           make(chan struct{}),
+		clients:        make(map[*stratumClient]struct{}),
+		workers:        make(map[string]*stratumWorker),
+		jobs:           make(map[string]*stratumJob),
+		extraNonce1:    uint16(rand.Uint32()),
+	}, nil
+}
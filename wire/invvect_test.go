@@ -24,6 +24,7 @@ func TestInvTypeStringer(t *testing.T) {
 		{InvTypeError, "ERROR"},
 		{InvTypeTx, "MSG_TX"},
 		{InvTypeBlock, "MSG_BLOCK"},
+		{InvTypeCmpctBlock, "MSG_CMPCT_BLOCK"},
 		{0xffffffff, "Unknown InvType (4294967295)"},
 	}
 
@@ -55,6 +55,16 @@ const (
 	CmdReject         = "reject"
 	CmdSendHeaders    = "sendheaders"
 	CmdFeeFilter      = "feefilter"
+	CmdGetCFilter     = "getcfilter"
+	CmdCFilter        = "cfilter"
+	CmdGetCFHeaders   = "getcfheaders"
+	CmdCFHeaders      = "cfheaders"
+	CmdSendCmpct      = "sendcmpct"
+	CmdCmpctBlock     = "cmpctblock"
+	CmdGetBlockTxn    = "getblocktxn"
+	CmdBlockTxn       = "blocktxn"
+	CmdSendAddrV2     = "sendaddrv2"
+	CmdAddrV2         = "addrv2"
 )
 
 // Message is an interface that describes a HC message.  A type that
@@ -145,6 +155,36 @@ func makeEmptyMessage(command string) (Message, error) {
 	case CmdFeeFilter:
 		msg = &MsgFeeFilter{}
 
+	case CmdGetCFilter:
+		msg = &MsgGetCFilter{}
+
+	case CmdCFilter:
+		msg = &MsgCFilter{}
+
+	case CmdGetCFHeaders:
+		msg = &MsgGetCFHeaders{}
+
+	case CmdCFHeaders:
+		msg = &MsgCFHeaders{}
+
+	case CmdSendCmpct:
+		msg = &MsgSendCmpct{}
+
+	case CmdCmpctBlock:
+		msg = &MsgCmpctBlock{}
+
+	case CmdGetBlockTxn:
+		msg = &MsgGetBlockTxn{}
+
+	case CmdBlockTxn:
+		msg = &MsgBlockTxn{}
+
+	case CmdSendAddrV2:
+		msg = &MsgSendAddrV2{}
+
+	case CmdAddrV2:
+		msg = &MsgAddrV2{}
+
 	default:
 		return nil, fmt.Errorf("unhandled command [%s]", command)
 	}
@@ -178,7 +218,7 @@ func readMessageHeader(r io.Reader) (int, *messageHeader, error) {
 	readElements(hr, &hdr.magic, &command, &hdr.length, &hdr.checksum)
 
 	// Strip trailing zeros from command string.
-	hdr.command = string(bytes.TrimRight(command[:], string(0)))
+	hdr.command = string(bytes.TrimRight(command[:], "\x00"))
 
 	return n, &hdr, nil
 }
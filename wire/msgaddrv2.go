@@ -0,0 +1,120 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// MsgAddrV2 implements the Message interface and represents an addrv2
+// message.  It is used to provide a list of known active peers on the network
+// like the addr message (MsgAddr), but encodes each address with a network id
+// followed by a variable length address.  This allows relaying addresses of
+// networks which can't be represented by an IP address such as Tor v3, I2P and
+// CJDNS.  Each message is limited to MaxAddrPerMsg addresses.
+//
+// Addresses of unknown networks are decoded with their network id and raw
+// address in the Type and Addr fields so they can be skipped by the receiver.
+//
+// This message was not added until protocol versions starting with
+// AddrV2Version.
+type MsgAddrV2 struct {
+	AddrList []*NetAddress
+}
+
+// AddAddress adds a known active peer to the message.
+func (msg *MsgAddrV2) AddAddress(na *NetAddress) error {
+	if len(msg.AddrList)+1 > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses in message [max %v]",
+			MaxAddrPerMsg)
+		return messageError("MsgAddrV2.AddAddress", str)
+	}
+
+	msg.AddrList = append(msg.AddrList, na)
+	return nil
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgAddrV2) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("addrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAddrV2.BtcDecode", str)
+	}
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+
+	// Limit to max addresses per message.
+	if count > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses for message "+
+			"[count %v, max %v]", count, MaxAddrPerMsg)
+		return messageError("MsgAddrV2.BtcDecode", str)
+	}
+
+	addrList := make([]NetAddress, count)
+	msg.AddrList = make([]*NetAddress, 0, count)
+	for i := uint64(0); i < count; i++ {
+		na := &addrList[i]
+		if err := readNetAddressV2(r, pver, na); err != nil {
+			return err
+		}
+		msg.AddrList = append(msg.AddrList, na)
+	}
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgAddrV2) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < AddrV2Version {
+		str := fmt.Sprintf("addrv2 message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgAddrV2.BtcEncode", str)
+	}
+
+	count := len(msg.AddrList)
+	if count > MaxAddrPerMsg {
+		str := fmt.Sprintf("too many addresses for message "+
+			"[count %v, max %v]", count, MaxAddrPerMsg)
+		return messageError("MsgAddrV2.BtcEncode", str)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+	for _, na := range msg.AddrList {
+		if err := writeNetAddressV2(w, pver, na); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgAddrV2) Command() string {
+	return CmdAddrV2
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgAddrV2) MaxPayloadLength(pver uint32) uint32 {
+	// Num addresses (varInt) + max allowed addresses.
+	return MaxVarIntPayload + (MaxAddrPerMsg * maxNetAddressV2Payload)
+}
+
+// NewMsgAddrV2 returns a new addrv2 message that conforms to the Message
+// interface.  See MsgAddrV2 for details.
+func NewMsgAddrV2() *MsgAddrV2 {
+	return &MsgAddrV2{
+		AddrList: make([]*NetAddress, 0, MaxAddrPerMsg),
+	}
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestAddrV2Wire tests the MsgAddrV2 and MsgSendAddrV2 wire encode and decode
+// with addresses of all known networks as well as an unknown one.
+func TestAddrV2Wire(t *testing.T) {
+	pver := ProtocolVersion
+	ts := time.Unix(0x495fab29, 0)
+	services := SFNodeNetwork | SFNodeP2PV2
+
+	newAddr := func(typ NetAddressType, addr []byte) *NetAddress {
+		na, err := NewNetAddressType(typ, addr, 8333, services)
+		if err != nil {
+			t.Fatalf("NewNetAddressType(%v): unexpected error %v", typ,
+				err)
+		}
+		na.Timestamp = ts
+		return na
+	}
+	torV2 := newAddr(TorV2Address, bytes.Repeat([]byte{0xab}, 10))
+	torV3 := newAddr(TorV3Address, bytes.Repeat([]byte{0x01}, 32))
+	i2p := newAddr(I2PAddress, bytes.Repeat([]byte{0x02}, 32))
+	cjdns := newAddr(CJDNSAddress, net.ParseIP("fc00::1"))
+	unknown := &NetAddress{
+		Timestamp: ts,
+		Services:  services,
+		Port:      8333,
+		Type:      NetAddressType(0x55),
+		Addr:      []byte{0x01, 0x02, 0x03},
+	}
+
+	tests := []struct {
+		na   *NetAddress
+		typ  NetAddressType
+		addr bool
+	}{
+		{newAddr(IPv4Address, []byte{127, 0, 0, 1}), IPv4Address, false},
+		{newAddr(IPv6Address, net.ParseIP("2001:db8::1")), IPv6Address, false},
+		{torV2, TorV2Address, false},
+		{torV3, TorV3Address, true},
+		{i2p, I2PAddress, true},
+		{cjdns, CJDNSAddress, true},
+		{unknown, NetAddressType(0x55), true},
+	}
+
+	msg := NewMsgAddrV2()
+	for i, test := range tests {
+		if typ := test.na.NetworkType(); typ != test.typ {
+			t.Errorf("NetworkType #%d: got %v, want %v", i, typ,
+				test.typ)
+		}
+		if test.na.RequiresAddrV2() != test.addr {
+			t.Errorf("RequiresAddrV2 #%d: got %v, want %v", i,
+				test.na.RequiresAddrV2(), test.addr)
+		}
+		if err := msg.AddAddress(test.na); err != nil {
+			t.Fatalf("AddAddress #%d: unexpected error %v", i, err)
+		}
+	}
+
+	// Ensure the message round trips.
+	var buf bytes.Buffer
+	if err := msg.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode: unexpected error %v", err)
+	}
+	var decoded MsgAddrV2
+	if err := decoded.BtcDecode(bytes.NewReader(buf.Bytes()), pver); err != nil {
+		t.Fatalf("BtcDecode: unexpected error %v", err)
+	}
+	if !reflect.DeepEqual(&decoded, msg) {
+		t.Errorf("BtcDecode\n got: %s want: %s", spew.Sdump(&decoded),
+			spew.Sdump(msg))
+	}
+	if decoded.MaxPayloadLength(pver) < uint32(buf.Len()) {
+		t.Errorf("MaxPayloadLength: got %d, encoded %d bytes",
+			decoded.MaxPayloadLength(pver), buf.Len())
+	}
+
+	// Ensure the messages are rejected prior to AddrV2Version.
+	oldPver := AddrV2Version - 1
+	if err := msg.BtcEncode(&buf, oldPver); err == nil {
+		t.Error("BtcEncode: did not reject old protocol version")
+	}
+	sendAddrV2 := NewMsgSendAddrV2()
+	if err := sendAddrV2.BtcEncode(&buf, oldPver); err == nil {
+		t.Error("BtcEncode: did not reject sendaddrv2 with old protocol " +
+			"version")
+	}
+	if err := sendAddrV2.BtcEncode(&buf, pver); err != nil {
+		t.Errorf("BtcEncode: unexpected sendaddrv2 error %v", err)
+	}
+}
+
+// TestAddrV2WireErrors ensures addrv2 messages with addresses of invalid
+// length and too many addresses are rejected.
+func TestAddrV2WireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	if _, err := NewNetAddressType(TorV3Address, []byte{0x01}, 0, 0); err == nil {
+		t.Error("NewNetAddressType: did not reject short tor v3 address")
+	}
+	if _, err := NewNetAddressType(NetAddressType(0x55), nil, 0, 0); err == nil {
+		t.Error("NewNetAddressType: did not reject unknown network")
+	}
+
+	// An IPv4 address with 5 bytes.
+	var buf bytes.Buffer
+	WriteVarInt(&buf, pver, 1)
+	writeElement(&buf, uint32(0))
+	WriteVarInt(&buf, pver, uint64(SFNodeNetwork))
+	writeElement(&buf, uint8(IPv4Address))
+	WriteVarBytes(&buf, pver, []byte{1, 2, 3, 4, 5})
+	writeElement(&buf, uint16(8333))
+	var msg MsgAddrV2
+	if err := msg.BtcDecode(bytes.NewReader(buf.Bytes()), pver); err == nil {
+		t.Error("BtcDecode: did not reject invalid ipv4 address length")
+	}
+
+	// Too many addresses.
+	buf.Reset()
+	WriteVarInt(&buf, pver, MaxAddrPerMsg+1)
+	if err := msg.BtcDecode(bytes.NewReader(buf.Bytes()), pver); err == nil {
+		t.Error("BtcDecode: did not reject too many addresses")
+	}
+}
@@ -0,0 +1,155 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+// MaxCFHeadersPerMsg is the maximum number of committed filter headers that
+// can be in a single cfheaders message.
+const MaxCFHeadersPerMsg = 2000
+
+// MsgCFHeaders implements the Message interface and represents a cfheaders
+// message.  It is used to deliver committed filter header information in
+// response to a getcfheaders message (MsgGetCFHeaders).  The maximum number of
+// committed filter headers per message is currently 2000.  See MsgGetCFHeaders
+// for details on requesting the headers.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgCFHeaders struct {
+	StopHash     chainhash.Hash
+	FilterType   FilterType
+	HeaderHashes []*chainhash.Hash
+}
+
+// AddCFHeader adds a new committed filter header to the message.
+func (msg *MsgCFHeaders) AddCFHeader(headerHash *chainhash.Hash) error {
+	if len(msg.HeaderHashes)+1 > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers in message "+
+			"[max %v]", MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.AddCFHeader", str)
+	}
+
+	msg.HeaderHashes = append(msg.HeaderHashes, headerHash)
+	return nil
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFHeaders.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	var filterType uint8
+	err = readElement(r, &filterType)
+	if err != nil {
+		return err
+	}
+	msg.FilterType = FilterType(filterType)
+
+	// Read number of filter headers and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers for "+
+			"message [count %v, max %v]", count, MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	headerHashes := make([]chainhash.Hash, count)
+	msg.HeaderHashes = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &headerHashes[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return err
+		}
+		msg.AddCFHeader(hash)
+	}
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("cfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCFHeaders.BtcEncode", str)
+	}
+
+	// Limit to max committed filter headers per message.
+	count := len(msg.HeaderHashes)
+	if count > MaxCFHeadersPerMsg {
+		str := fmt.Sprintf("too many committed filter headers for "+
+			"message [count %v, max %v]", count, MaxCFHeadersPerMsg)
+		return messageError("MsgCFHeaders.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.StopHash)
+	if err != nil {
+		return err
+	}
+
+	err = writeElement(w, uint8(msg.FilterType))
+	if err != nil {
+		return err
+	}
+
+	err = WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range msg.HeaderHashes {
+		err := writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCFHeaders) Command() string {
+	return CmdCFHeaders
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCFHeaders) MaxPayloadLength(pver uint32) uint32 {
+	// Stop hash + filter type + num headers (varInt) + max allowed
+	// headers.
+	return chainhash.HashSize + 1 + MaxVarIntPayload +
+		(MaxCFHeadersPerMsg * chainhash.HashSize)
+}
+
+// NewMsgCFHeaders returns a new cfheaders message that conforms to the Message
+// interface.  See MsgCFHeaders for details.
+func NewMsgCFHeaders() *MsgCFHeaders {
+	return &MsgCFHeaders{
+		HeaderHashes: make([]*chainhash.Hash, 0, MaxCFHeadersPerMsg),
+	}
+}
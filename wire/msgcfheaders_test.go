@@ -0,0 +1,90 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFHeadersWire tests the MsgGetCFHeaders and MsgCFHeaders wire encode
+// and decode for various protocol versions.
+func TestCFHeadersWire(t *testing.T) {
+	hash1 := chainhash.Hash{0x01}
+	hash2 := chainhash.Hash{0x02}
+
+	getCFHeaders := NewMsgGetCFHeaders()
+	getCFHeaders.AddBlockLocatorHash(&hash1)
+	getCFHeaders.AddBlockLocatorHash(&hash2)
+	getCFHeaders.HashStop = hash2
+	getCFHeaders.FilterType = GCSFilterRegular
+
+	cfHeaders := NewMsgCFHeaders()
+	cfHeaders.StopHash = hash2
+	cfHeaders.AddCFHeader(&hash1)
+	cfHeaders.AddCFHeader(&hash2)
+
+	tests := []struct {
+		in  Message // Message to encode
+		out Message // Empty message to decode into
+		cmd string  // Expected command
+	}{
+		{getCFHeaders, &MsgGetCFHeaders{}, "getcfheaders"},
+		{cfHeaders, &MsgCFHeaders{}, "cfheaders"},
+	}
+
+	for i, test := range tests {
+		if cmd := test.in.Command(); cmd != test.cmd {
+			t.Errorf("Command #%d: got %v, want %v", i, cmd, test.cmd)
+		}
+
+		// Encode and decode the message with the latest protocol
+		// version.
+		var buf bytes.Buffer
+		if err := test.in.BtcEncode(&buf, ProtocolVersion); err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		err := test.out.BtcDecode(bytes.NewReader(buf.Bytes()),
+			ProtocolVersion)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(test.out), spew.Sdump(test.in))
+			continue
+		}
+
+		// Ensure the message is rejected prior to NodeCFVersion.
+		pver := NodeCFVersion - 1
+		if err := test.in.BtcEncode(&buf, pver); err == nil {
+			t.Errorf("BtcEncode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+		err = test.out.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+		if err == nil {
+			t.Errorf("BtcDecode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+	}
+
+	// Ensure too many headers are rejected.
+	for i := 0; i < MaxCFHeadersPerMsg; i++ {
+		cfHeaders.HeaderHashes = append(cfHeaders.HeaderHashes, &hash1)
+	}
+	var buf bytes.Buffer
+	if err := cfHeaders.BtcEncode(&buf, ProtocolVersion); err == nil {
+		t.Error("BtcEncode: did not reject too many headers")
+	}
+	if err := cfHeaders.AddCFHeader(&hash1); err == nil {
+		t.Error("AddCFHeader: did not reject too many headers")
+	}
+}
@@ -0,0 +1,82 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCFilterWire tests the MsgGetCFilter and MsgCFilter wire encode and
+// decode for various protocol versions.
+func TestCFilterWire(t *testing.T) {
+	blockHash := chainhash.Hash{0x01, 0x02, 0x03}
+	getCFilter := NewMsgGetCFilter(&blockHash, GCSFilterRegular)
+	cFilter := NewMsgCFilter(&blockHash, GCSFilterRegular,
+		[]byte{0x00, 0x00, 0x00, 0x01, 0xaa})
+
+	tests := []struct {
+		in      Message // Message to encode
+		out     Message // Empty message to decode into
+		cmd     string  // Expected command
+		payload uint32  // Expected max payload length
+	}{
+		{getCFilter, &MsgGetCFilter{}, "getcfilter", 33},
+		{cFilter, &MsgCFilter{}, "cfilter", 33 + 5 + MaxCFilterDataSize},
+	}
+
+	for i, test := range tests {
+		if cmd := test.in.Command(); cmd != test.cmd {
+			t.Errorf("Command #%d: got %v, want %v", i, cmd, test.cmd)
+		}
+		payload := test.in.MaxPayloadLength(ProtocolVersion)
+		if payload != test.payload {
+			t.Errorf("MaxPayloadLength #%d: got %v, want %v", i,
+				payload, test.payload)
+		}
+
+		// Encode and decode the message with the latest protocol
+		// version.
+		var buf bytes.Buffer
+		if err := test.in.BtcEncode(&buf, ProtocolVersion); err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		err := test.out.BtcDecode(bytes.NewReader(buf.Bytes()),
+			ProtocolVersion)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(test.out), spew.Sdump(test.in))
+			continue
+		}
+
+		// Ensure the message is rejected prior to NodeCFVersion.
+		pver := NodeCFVersion - 1
+		if err := test.in.BtcEncode(&buf, pver); err == nil {
+			t.Errorf("BtcEncode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+		err = test.out.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+		if err == nil {
+			t.Errorf("BtcDecode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+	}
+
+	// Ensure oversized filters are rejected.
+	cFilter.Data = make([]byte, MaxCFilterDataSize+1)
+	var buf bytes.Buffer
+	if err := cFilter.BtcEncode(&buf, ProtocolVersion); err == nil {
+		t.Error("BtcEncode: did not reject oversized filter")
+	}
+}
@@ -0,0 +1,297 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+)
+
+// ShortTxIDSize is the number of bytes the short transaction ids of a compact
+// block are encoded with.
+const ShortTxIDSize = 6
+
+// FraudProof houses the fraud proof fields of the witness of a transaction
+// input.  They are filled in by the miner of a block, so they generally differ
+// from the ones of the transaction in the memory pool.
+type FraudProof struct {
+	ValueIn     int64
+	BlockHeight uint32
+	BlockIndex  uint32
+}
+
+// ShortTx houses a transaction of a compact block which is not sent in full.
+// It consists of the short id of the transaction along with the fraud proofs
+// of all of its inputs which are needed to reconstruct the transaction of the
+// block from the one in the memory pool.
+type ShortTx struct {
+	ShortID     uint64
+	FraudProofs []FraudProof
+}
+
+// PrefilledTx houses a transaction of a compact block which is sent in full
+// along with its index in the transaction tree of the block.
+type PrefilledTx struct {
+	Index uint32
+	Tx    *MsgTx
+}
+
+// MsgCmpctBlock implements the Message interface and represents a cmpctblock
+// message.  It is used to relay a block by its header along with the short
+// ids and input fraud proofs of the transactions of both transaction trees
+// which the receiver is expected to already have in its memory pool.  The
+// transactions which the receiver is unlikely to have, such as the coinbase,
+// are sent in full.
+//
+// The short ids are computed from the transaction hashes with SipHash-2-4
+// keyed by the hash of the header and the nonce, truncated to ShortTxIDSize
+// bytes.
+//
+// This message was not added until protocol versions starting with
+// SendCmpctVersion.
+type MsgCmpctBlock struct {
+	Header             BlockHeader
+	Nonce              uint64
+	ShortTxns          []ShortTx
+	PrefilledTxns      []PrefilledTx
+	StakeShortTxns     []ShortTx
+	StakePrefilledTxns []PrefilledTx
+}
+
+// readShortTx reads a short id followed by the fraud proofs of the inputs of a
+// transaction from r into st.
+func readShortTx(r io.Reader, pver uint32, st *ShortTx) error {
+	var buf [8]byte
+	_, err := io.ReadFull(r, buf[:ShortTxIDSize])
+	if err != nil {
+		return err
+	}
+	st.ShortID = binary.LittleEndian.Uint64(buf[:])
+
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > uint64(maxTxInPerMessage) {
+		str := fmt.Sprintf("too many fraud proofs to fit into a "+
+			"transaction [count %d, max %d]", count, maxTxInPerMessage)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+	st.FraudProofs = make([]FraudProof, count)
+	for i := range st.FraudProofs {
+		fp := &st.FraudProofs[i]
+		err := readElements(r, &fp.ValueIn, &fp.BlockHeight,
+			&fp.BlockIndex)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// writeShortTx writes the short id followed by the fraud proofs of the inputs
+// of a transaction to w.
+func writeShortTx(w io.Writer, pver uint32, st *ShortTx) error {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], st.ShortID)
+	if _, err := w.Write(buf[:ShortTxIDSize]); err != nil {
+		return err
+	}
+
+	err := WriteVarInt(w, pver, uint64(len(st.FraudProofs)))
+	if err != nil {
+		return err
+	}
+	for _, fp := range st.FraudProofs {
+		err := writeElements(w, fp.ValueIn, fp.BlockHeight, fp.BlockIndex)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// readCmpctTxTree reads the short transactions and prefilled transactions of a
+// transaction tree of a compact block from r.  The indexes of the prefilled
+// transactions are differentially encoded.
+func readCmpctTxTree(r io.Reader, pver uint32) ([]ShortTx, []PrefilledTx, error) {
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count > maxTxPerTree {
+		str := fmt.Sprintf("too many short ids to fit into a block "+
+			"[count %d, max %d]", count, maxTxPerTree)
+		return nil, nil, messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	shortTxns := make([]ShortTx, count)
+	for i := range shortTxns {
+		if err := readShortTx(r, pver, &shortTxns[i]); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	count, err = ReadVarInt(r, pver)
+	if err != nil {
+		return nil, nil, err
+	}
+	if count > maxTxPerTree-uint64(len(shortTxns)) {
+		str := fmt.Sprintf("too many transactions to fit into a block "+
+			"[count %d, max %d]", count+uint64(len(shortTxns)),
+			maxTxPerTree)
+		return nil, nil, messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	// The indexes must refer to transactions of the tree, so they can't
+	// exceed the total number of transactions.
+	totalTxns := count + uint64(len(shortTxns))
+	prefilled := make([]PrefilledTx, 0, count)
+	var nextIndex uint64
+	for i := uint64(0); i < count; i++ {
+		diff, err := ReadVarInt(r, pver)
+		if err != nil {
+			return nil, nil, err
+		}
+		if diff >= totalTxns-nextIndex {
+			str := fmt.Sprintf("prefilled transaction index out of "+
+				"range [max %d]", totalTxns-1)
+			return nil, nil, messageError("MsgCmpctBlock.BtcDecode",
+				str)
+		}
+		index := nextIndex + diff
+
+		var tx MsgTx
+		if err := tx.BtcDecode(r, pver); err != nil {
+			return nil, nil, err
+		}
+		prefilled = append(prefilled, PrefilledTx{
+			Index: uint32(index),
+			Tx:    &tx,
+		})
+		nextIndex = index + 1
+	}
+
+	return shortTxns, prefilled, nil
+}
+
+// writeCmpctTxTree writes the short transactions and prefilled transactions of
+// a transaction tree of a compact block to w.  The prefilled transactions must
+// be sorted by their index.
+func writeCmpctTxTree(w io.Writer, pver uint32, shortTxns []ShortTx, prefilled []PrefilledTx) error {
+	err := WriteVarInt(w, pver, uint64(len(shortTxns)))
+	if err != nil {
+		return err
+	}
+	for i := range shortTxns {
+		if err := writeShortTx(w, pver, &shortTxns[i]); err != nil {
+			return err
+		}
+	}
+
+	err = WriteVarInt(w, pver, uint64(len(prefilled)))
+	if err != nil {
+		return err
+	}
+	var nextIndex uint64
+	for _, ptx := range prefilled {
+		index := uint64(ptx.Index)
+		if index < nextIndex {
+			str := "prefilled transactions are not sorted by index"
+			return messageError("MsgCmpctBlock.BtcEncode", str)
+		}
+		err := WriteVarInt(w, pver, index-nextIndex)
+		if err != nil {
+			return err
+		}
+		if err := ptx.Tx.BtcEncode(w, pver); err != nil {
+			return err
+		}
+		nextIndex = index + 1
+	}
+
+	return nil
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcDecode", str)
+	}
+
+	err := readBlockHeader(r, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = readElement(r, &msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	msg.ShortTxns, msg.PrefilledTxns, err = readCmpctTxTree(r, pver)
+	if err != nil {
+		return err
+	}
+	msg.StakeShortTxns, msg.StakePrefilledTxns, err = readCmpctTxTree(r,
+		pver)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("cmpctblock message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgCmpctBlock.BtcEncode", str)
+	}
+
+	err := writeBlockHeader(w, pver, &msg.Header)
+	if err != nil {
+		return err
+	}
+	err = writeElement(w, msg.Nonce)
+	if err != nil {
+		return err
+	}
+
+	err = writeCmpctTxTree(w, pver, msg.ShortTxns, msg.PrefilledTxns)
+	if err != nil {
+		return err
+	}
+	return writeCmpctTxTree(w, pver, msg.StakeShortTxns,
+		msg.StakePrefilledTxns)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgCmpctBlock) Command() string {
+	return CmdCmpctBlock
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgCmpctBlock) MaxPayloadLength(pver uint32) uint32 {
+	// A compact block is never larger than the block it represents.
+	return MaxBlockPayload
+}
+
+// NewMsgCmpctBlock returns a new cmpctblock message that conforms to the
+// Message interface using the passed block header and nonce.  The short and
+// prefilled transactions of both transaction trees are left empty.  See
+// MsgCmpctBlock for details.
+func NewMsgCmpctBlock(header *BlockHeader, nonce uint64) *MsgCmpctBlock {
+	return &MsgCmpctBlock{
+		Header: *header,
+		Nonce:  nonce,
+	}
+}
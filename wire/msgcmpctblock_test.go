@@ -0,0 +1,131 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+	"github.com/davecgh/go-spew/spew"
+)
+
+// TestCmpctBlockWire tests the MsgSendCmpct, MsgCmpctBlock, MsgGetBlockTxn and
+// MsgBlockTxn wire encode and decode for various protocol versions.
+func TestCmpctBlockWire(t *testing.T) {
+	blockHash := testBlock.Header.BlockHash()
+	sendCmpct := NewMsgSendCmpct(true, CmpctBlockVersion)
+	cmpctBlock := NewMsgCmpctBlock(&testBlock.Header, 0x0102030405060708)
+	cmpctBlock.ShortTxns = []ShortTx{
+		{0x010203040506, []FraudProof{{5000000000, 100, 2}}},
+		{0xffffffffffff, []FraudProof{{1, 2, 3}, {-1, 0, 0xffffffff}}},
+	}
+	cmpctBlock.PrefilledTxns = []PrefilledTx{{0, multiTx}, {3, multiTx}}
+	cmpctBlock.StakeShortTxns = []ShortTx{{0x0a0b0c0d0e0f, []FraudProof{}}}
+	cmpctBlock.StakePrefilledTxns = []PrefilledTx{{0, multiTx}}
+	getBlockTxn := NewMsgGetBlockTxn(&blockHash, []uint32{1, 2, 5},
+		[]uint32{0})
+	blockTxn := NewMsgBlockTxn(&blockHash)
+	blockTxn.Transactions = []*MsgTx{multiTx, multiTx}
+	blockTxn.STransactions = []*MsgTx{}
+
+	maxIndexes := uint32(MaxTxPerTxTree(ProtocolVersion))
+	tests := []struct {
+		in      Message // Message to encode
+		out     Message // Empty message to decode into
+		cmd     string  // Expected command
+		payload uint32  // Expected max payload length
+	}{
+		{sendCmpct, &MsgSendCmpct{}, "sendcmpct", 9},
+		{cmpctBlock, &MsgCmpctBlock{}, "cmpctblock", MaxBlockPayload},
+		{getBlockTxn, &MsgGetBlockTxn{}, "getblocktxn",
+			32 + 2*(9+maxIndexes*9)},
+		{blockTxn, &MsgBlockTxn{}, "blocktxn", 32 + MaxBlockPayload},
+	}
+
+	for i, test := range tests {
+		if cmd := test.in.Command(); cmd != test.cmd {
+			t.Errorf("Command #%d: got %v, want %v", i, cmd, test.cmd)
+		}
+		payload := test.in.MaxPayloadLength(ProtocolVersion)
+		if payload != test.payload {
+			t.Errorf("MaxPayloadLength #%d: got %v, want %v", i,
+				payload, test.payload)
+		}
+
+		// Encode and decode the message with the latest protocol
+		// version.
+		var buf bytes.Buffer
+		if err := test.in.BtcEncode(&buf, ProtocolVersion); err != nil {
+			t.Errorf("BtcEncode #%d error %v", i, err)
+			continue
+		}
+		err := test.out.BtcDecode(bytes.NewReader(buf.Bytes()),
+			ProtocolVersion)
+		if err != nil {
+			t.Errorf("BtcDecode #%d error %v", i, err)
+			continue
+		}
+		if !reflect.DeepEqual(test.out, test.in) {
+			t.Errorf("BtcDecode #%d\n got: %s want: %s", i,
+				spew.Sdump(test.out), spew.Sdump(test.in))
+			continue
+		}
+
+		// Ensure the message is rejected prior to SendCmpctVersion.
+		pver := SendCmpctVersion - 1
+		if err := test.in.BtcEncode(&buf, pver); err == nil {
+			t.Errorf("BtcEncode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+		err = test.out.BtcDecode(bytes.NewReader(buf.Bytes()), pver)
+		if err == nil {
+			t.Errorf("BtcDecode #%d: did not reject protocol "+
+				"version %d", i, pver)
+		}
+	}
+}
+
+// TestCmpctBlockWireErrors ensures compact block related messages with invalid
+// transaction indexes are rejected.
+func TestCmpctBlockWireErrors(t *testing.T) {
+	pver := ProtocolVersion
+
+	// Ensure unsorted indexes can't be encoded.
+	cmpctBlock := NewMsgCmpctBlock(&testBlock.Header, 0)
+	cmpctBlock.ShortTxns = []ShortTx{{1, nil}, {2, nil}}
+	cmpctBlock.PrefilledTxns = []PrefilledTx{{1, multiTx}, {0, multiTx}}
+	var buf bytes.Buffer
+	if err := cmpctBlock.BtcEncode(&buf, pver); err == nil {
+		t.Error("BtcEncode: did not reject unsorted prefilled " +
+			"transactions")
+	}
+	getBlockTxn := NewMsgGetBlockTxn(&chainhash.Hash{}, []uint32{2, 2}, nil)
+	if err := getBlockTxn.BtcEncode(&buf, pver); err == nil {
+		t.Error("BtcEncode: did not reject duplicate indexes")
+	}
+
+	// Ensure a prefilled transaction with an index past the transactions
+	// of the tree is rejected.
+	cmpctBlock.PrefilledTxns = []PrefilledTx{{3, multiTx}}
+	buf.Reset()
+	if err := cmpctBlock.BtcEncode(&buf, pver); err != nil {
+		t.Fatalf("BtcEncode: unexpected error %v", err)
+	}
+	var msg MsgCmpctBlock
+	if err := msg.BtcDecode(bytes.NewReader(buf.Bytes()), pver); err == nil {
+		t.Error("BtcDecode: did not reject out of range prefilled index")
+	}
+
+	// Ensure more short ids than fit into a block are rejected.
+	buf.Reset()
+	writeBlockHeader(&buf, pver, &testBlock.Header)
+	writeElement(&buf, uint64(0))
+	WriteVarInt(&buf, pver, MaxTxPerTxTree(pver)+1)
+	if err := msg.BtcDecode(bytes.NewReader(buf.Bytes()), pver); err == nil {
+		t.Error("BtcDecode: did not reject too many short ids")
+	}
+}
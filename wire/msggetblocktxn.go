@@ -0,0 +1,152 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+// MsgGetBlockTxn implements the Message interface and represents a
+// getblocktxn message.  It is used to request the transactions of a block
+// which could not be reconstructed from a cmpctblock message (MsgCmpctBlock).
+// The transactions are identified by their indexes in the regular and stake
+// transaction trees of the block and returned via a blocktxn message
+// (MsgBlockTxn).
+//
+// This message was not added until protocol versions starting with
+// SendCmpctVersion.
+type MsgGetBlockTxn struct {
+	BlockHash    chainhash.Hash
+	Indexes      []uint32
+	StakeIndexes []uint32
+}
+
+// readTxIndexes reads a list of differentially encoded transaction indexes
+// from r.
+func readTxIndexes(r io.Reader, pver uint32) ([]uint32, error) {
+	maxTxPerTree := MaxTxPerTxTree(pver)
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return nil, err
+	}
+	if count > maxTxPerTree {
+		str := fmt.Sprintf("too many transaction indexes for message "+
+			"[count %d, max %d]", count, maxTxPerTree)
+		return nil, messageError("MsgGetBlockTxn.BtcDecode", str)
+	}
+
+	indexes := make([]uint32, 0, count)
+	var nextIndex uint64
+	for i := uint64(0); i < count; i++ {
+		diff, err := ReadVarInt(r, pver)
+		if err != nil {
+			return nil, err
+		}
+		if diff >= maxTxPerTree-nextIndex {
+			str := fmt.Sprintf("transaction index out of range "+
+				"[max %d]", maxTxPerTree-1)
+			return nil, messageError("MsgGetBlockTxn.BtcDecode", str)
+		}
+		index := nextIndex + diff
+		indexes = append(indexes, uint32(index))
+		nextIndex = index + 1
+	}
+
+	return indexes, nil
+}
+
+// writeTxIndexes writes the passed transaction indexes, which must be sorted,
+// differentially encoded to w.
+func writeTxIndexes(w io.Writer, pver uint32, indexes []uint32) error {
+	err := WriteVarInt(w, pver, uint64(len(indexes)))
+	if err != nil {
+		return err
+	}
+	var nextIndex uint64
+	for _, index := range indexes {
+		if uint64(index) < nextIndex {
+			str := "transaction indexes are not sorted"
+			return messageError("MsgGetBlockTxn.BtcEncode", str)
+		}
+		err := WriteVarInt(w, pver, uint64(index)-nextIndex)
+		if err != nil {
+			return err
+		}
+		nextIndex = uint64(index) + 1
+	}
+
+	return nil
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+	msg.Indexes, err = readTxIndexes(r, pver)
+	if err != nil {
+		return err
+	}
+	msg.StakeIndexes, err = readTxIndexes(r, pver)
+	return err
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("getblocktxn message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetBlockTxn.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+	err = writeTxIndexes(w, pver, msg.Indexes)
+	if err != nil {
+		return err
+	}
+	return writeTxIndexes(w, pver, msg.StakeIndexes)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetBlockTxn) Command() string {
+	return CmdGetBlockTxn
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetBlockTxn) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + the index count (varInt) and max indexes (varInt) of
+	// both transaction trees.
+	maxIndexes := uint32(MaxTxPerTxTree(pver))
+	return chainhash.HashSize + 2*(MaxVarIntPayload+
+		maxIndexes*MaxVarIntPayload)
+}
+
+// NewMsgGetBlockTxn returns a new getblocktxn message that conforms to the
+// Message interface using the passed parameters.  See MsgGetBlockTxn for
+// details.
+func NewMsgGetBlockTxn(blockHash *chainhash.Hash, indexes, stakeIndexes []uint32) *MsgGetBlockTxn {
+	return &MsgGetBlockTxn{
+		BlockHash:    *blockHash,
+		Indexes:      indexes,
+		StakeIndexes: stakeIndexes,
+	}
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+// MsgGetCFHeaders implements the Message interface and represents a
+// getcfheaders message.  It is used to request a list of committed filter
+// headers for blocks starting after the last known hash in the slice of block
+// locator hashes.  The list is returned via a cfheaders message (MsgCFHeaders)
+// and is limited by a specific hash to stop at or the maximum number of
+// committed filter headers per message, which is currently 2000.
+//
+// Set the HashStop field to the hash at which to stop and use
+// AddBlockLocatorHash to build up the list of block locator hashes.  See
+// MsgGetHeaders for details on building the block locator hashes.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgGetCFHeaders struct {
+	BlockLocatorHashes []*chainhash.Hash
+	HashStop           chainhash.Hash
+	FilterType         FilterType
+}
+
+// AddBlockLocatorHash adds a new block locator hash to the message.
+func (msg *MsgGetCFHeaders) AddBlockLocatorHash(hash *chainhash.Hash) error {
+	if len(msg.BlockLocatorHashes)+1 > MaxBlockLocatorsPerMsg {
+		str := fmt.Sprintf("too many block locator hashes for message [max %v]",
+			MaxBlockLocatorsPerMsg)
+		return messageError("MsgGetCFHeaders.AddBlockLocatorHash", str)
+	}
+
+	msg.BlockLocatorHashes = append(msg.BlockLocatorHashes, hash)
+	return nil
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFHeaders) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFHeaders.BtcDecode", str)
+	}
+
+	// Read num block locator hashes and limit to max.
+	count, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	if count > MaxBlockLocatorsPerMsg {
+		str := fmt.Sprintf("too many block locator hashes for message "+
+			"[count %v, max %v]", count, MaxBlockLocatorsPerMsg)
+		return messageError("MsgGetCFHeaders.BtcDecode", str)
+	}
+
+	// Create a contiguous slice of hashes to deserialize into in order to
+	// reduce the number of allocations.
+	locatorHashes := make([]chainhash.Hash, count)
+	msg.BlockLocatorHashes = make([]*chainhash.Hash, 0, count)
+	for i := uint64(0); i < count; i++ {
+		hash := &locatorHashes[i]
+		err := readElement(r, hash)
+		if err != nil {
+			return err
+		}
+		msg.AddBlockLocatorHash(hash)
+	}
+
+	err = readElement(r, &msg.HashStop)
+	if err != nil {
+		return err
+	}
+
+	var filterType uint8
+	err = readElement(r, &filterType)
+	if err != nil {
+		return err
+	}
+	msg.FilterType = FilterType(filterType)
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFHeaders) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfheaders message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFHeaders.BtcEncode", str)
+	}
+
+	// Limit to max block locator hashes per message.
+	count := len(msg.BlockLocatorHashes)
+	if count > MaxBlockLocatorsPerMsg {
+		str := fmt.Sprintf("too many block locator hashes for message "+
+			"[count %v, max %v]", count, MaxBlockLocatorsPerMsg)
+		return messageError("MsgGetCFHeaders.BtcEncode", str)
+	}
+
+	err := WriteVarInt(w, pver, uint64(count))
+	if err != nil {
+		return err
+	}
+
+	for _, hash := range msg.BlockLocatorHashes {
+		err := writeElement(w, hash)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = writeElement(w, &msg.HashStop)
+	if err != nil {
+		return err
+	}
+
+	return writeElement(w, uint8(msg.FilterType))
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFHeaders) Command() string {
+	return CmdGetCFHeaders
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFHeaders) MaxPayloadLength(pver uint32) uint32 {
+	// Num block locator hashes (varInt) + max allowed block locators + hash
+	// stop + filter type.
+	return MaxVarIntPayload + (MaxBlockLocatorsPerMsg *
+		chainhash.HashSize) + chainhash.HashSize + 1
+}
+
+// NewMsgGetCFHeaders returns a new getcfheaders message that conforms to the
+// Message interface.  See MsgGetCFHeaders for details.
+func NewMsgGetCFHeaders() *MsgGetCFHeaders {
+	return &MsgGetCFHeaders{
+		BlockLocatorHashes: make([]*chainhash.Hash, 0,
+			MaxBlockLocatorsPerMsg),
+	}
+}
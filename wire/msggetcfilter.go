@@ -0,0 +1,111 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/HcashOrg/hcd/chaincfg/chainhash"
+)
+
+// FilterType is used to represent a filter type.
+type FilterType uint8
+
+const (
+	// GCSFilterRegular is the regular filter type which commits to the
+	// outpoints spent and the output scripts created by both transaction
+	// trees of a block.
+	GCSFilterRegular FilterType = iota
+)
+
+// filterTypeStrings is a map of filter types back to their constant names for
+// pretty printing.
+var filterTypeStrings = map[FilterType]string{
+	GCSFilterRegular: "GCSFilterRegular",
+}
+
+// String returns the FilterType in human-readable form.
+func (t FilterType) String() string {
+	if s, ok := filterTypeStrings[t]; ok {
+		return s
+	}
+
+	return fmt.Sprintf("Unknown FilterType (%d)", uint8(t))
+}
+
+// MsgGetCFilter implements the Message interface and represents a getcfilter
+// message.  It is used to request a committed filter for a block.
+//
+// This message was not added until protocol versions starting with
+// NodeCFVersion.
+type MsgGetCFilter struct {
+	BlockHash  chainhash.Hash
+	FilterType FilterType
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilter.BtcDecode", str)
+	}
+
+	err := readElement(r, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	var filterType uint8
+	err = readElement(r, &filterType)
+	if err != nil {
+		return err
+	}
+	msg.FilterType = FilterType(filterType)
+
+	return nil
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < NodeCFVersion {
+		str := fmt.Sprintf("getcfilter message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgGetCFilter.BtcEncode", str)
+	}
+
+	err := writeElement(w, &msg.BlockHash)
+	if err != nil {
+		return err
+	}
+
+	return writeElement(w, uint8(msg.FilterType))
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgGetCFilter) Command() string {
+	return CmdGetCFilter
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgGetCFilter) MaxPayloadLength(pver uint32) uint32 {
+	// Block hash + filter type.
+	return chainhash.HashSize + 1
+}
+
+// NewMsgGetCFilter returns a new getcfilter message that conforms to the
+// Message interface using the passed parameters and defaults for the remaining
+// fields.
+func NewMsgGetCFilter(blockHash *chainhash.Hash, filterType FilterType) *MsgGetCFilter {
+	return &MsgGetCFilter{
+		BlockHash:  *blockHash,
+		FilterType: filterType,
+	}
+}
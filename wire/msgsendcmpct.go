@@ -0,0 +1,75 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"fmt"
+	"io"
+)
+
+// CmpctBlockVersion is the version of the compact block encoding implemented
+// by this package.  It is negotiated by the sendcmpct message.
+const CmpctBlockVersion uint64 = 1
+
+// MsgSendCmpct implements the Message interface and represents a sendcmpct
+// message.  It is used to signal the peer that compact blocks are supported
+// with the given encoding version and whether new blocks should be announced
+// by sending a cmpctblock message right away instead of an inventory vector
+// or headers message.
+//
+// This message was not added until protocol versions starting with
+// SendCmpctVersion.
+type MsgSendCmpct struct {
+	AnnounceUsingCmpctBlock bool
+	CmpctBlockVersion       uint64
+}
+
+// BtcDecode decodes r using the hcd protocol encoding into the receiver.
+// This is part of the Message interface implementation.
+func (msg *MsgSendCmpct) BtcDecode(r io.Reader, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("sendcmpct message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendCmpct.BtcDecode", str)
+	}
+
+	return readElements(r, &msg.AnnounceUsingCmpctBlock,
+		&msg.CmpctBlockVersion)
+}
+
+// BtcEncode encodes the receiver to w using the hcd protocol encoding.
+// This is part of the Message interface implementation.
+func (msg *MsgSendCmpct) BtcEncode(w io.Writer, pver uint32) error {
+	if pver < SendCmpctVersion {
+		str := fmt.Sprintf("sendcmpct message invalid for protocol "+
+			"version %d", pver)
+		return messageError("MsgSendCmpct.BtcEncode", str)
+	}
+
+	return writeElements(w, msg.AnnounceUsingCmpctBlock,
+		msg.CmpctBlockVersion)
+}
+
+// Command returns the protocol command string for the message.  This is part
+// of the Message interface implementation.
+func (msg *MsgSendCmpct) Command() string {
+	return CmdSendCmpct
+}
+
+// MaxPayloadLength returns the maximum length the payload can be for the
+// receiver.  This is part of the Message interface implementation.
+func (msg *MsgSendCmpct) MaxPayloadLength(pver uint32) uint32 {
+	// Announce flag + compact block version.
+	return 1 + 8
+}
+
+// NewMsgSendCmpct returns a new sendcmpct message that conforms to the Message
+// interface using the passed parameters.
+func NewMsgSendCmpct(announce bool, version uint64) *MsgSendCmpct {
+	return &MsgSendCmpct{
+		AnnounceUsingCmpctBlock: announce,
+		CmpctBlockVersion:       version,
+	}
+}
@@ -48,6 +48,15 @@ type NetAddress struct {
 	// Port the peer is using.  This is encoded in big endian on the wire
 	// which differs from most everything else.
 	Port uint16
+
+	// Type identifies the network of addresses which can't be represented
+	// by an IP address and are only relayed with addrv2 messages (MsgAddrV2),
+	// such as Tor v3, I2P and CJDNS addresses.  Their raw address is stored in
+	// Addr while IP is nil.  It is zero for all other addresses.
+	Type NetAddressType
+
+	// Addr is the raw address of addresses which set Type.
+	Addr []byte
 }
 
 // HasService returns whether the specified service is supported by the address.
@@ -0,0 +1,240 @@
+// Copyright (c) 2018-2020 The Hc developers
+// Use of this source code is governed by an ISC
+// license that can be found in the LICENSE file.
+
+package wire
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"net"
+)
+
+// NetAddressType identifies the network of an address.  The values are the
+// network ids of addrv2 messages.
+type NetAddressType uint8
+
+const (
+	// IPv4Address identifies an IPv4 address.
+	IPv4Address NetAddressType = 1
+
+	// IPv6Address identifies an IPv6 address.
+	IPv6Address NetAddressType = 2
+
+	// TorV2Address identifies a legacy Tor v2 onion address.  It is
+	// represented by an onioncat IPv6 address.
+	TorV2Address NetAddressType = 3
+
+	// TorV3Address identifies a Tor v3 onion address.  The address is the
+	// ed25519 public key of the onion service.
+	TorV3Address NetAddressType = 4
+
+	// I2PAddress identifies an I2P address.  The address is the SHA256
+	// hash of the destination.
+	I2PAddress NetAddressType = 5
+
+	// CJDNSAddress identifies a CJDNS address.  The address is an IPv6
+	// address in fc00::/8.
+	CJDNSAddress NetAddressType = 6
+)
+
+const (
+	// maxAddrV2Size is the maximum size of the raw address of an address
+	// in an addrv2 message.  Addresses of unknown networks are skipped
+	// but still need to be read, so they are limited as well.
+	maxAddrV2Size = 512
+
+	// maxNetAddressV2Payload is the maximum size of an address in an addrv2
+	// message.  It consists of the timestamp, the services, the network id,
+	// the raw address and the port.
+	maxNetAddressV2Payload = 4 + MaxVarIntPayload + 1 + MaxVarIntPayload +
+		maxAddrV2Size + 2
+)
+
+// Map of network ids back to their names for pretty printing.
+var netAddressTypeStrings = map[NetAddressType]string{
+	IPv4Address:  "IPv4",
+	IPv6Address:  "IPv6",
+	TorV2Address: "TorV2",
+	TorV3Address: "TorV3",
+	I2PAddress:   "I2P",
+	CJDNSAddress: "CJDNS",
+}
+
+// String returns the NetAddressType in human-readable form.
+func (t NetAddressType) String() string {
+	if s, ok := netAddressTypeStrings[t]; ok {
+		return s
+	}
+	return fmt.Sprintf("Unknown NetAddressType (%d)", uint8(t))
+}
+
+// onionCatPrefix is the IPv6 prefix of onioncat addresses which represent
+// legacy Tor v2 onion addresses.
+var onionCatPrefix = []byte{0xfd, 0x87, 0xd8, 0x7e, 0xeb, 0x43}
+
+// netAddressTypeSize returns the size of the raw addresses of the passed
+// network or 0 if the network is unknown.
+func netAddressTypeSize(t NetAddressType) int {
+	switch t {
+	case IPv4Address:
+		return net.IPv4len
+	case IPv6Address, CJDNSAddress:
+		return net.IPv6len
+	case TorV2Address:
+		return 10
+	case TorV3Address, I2PAddress:
+		return 32
+	}
+	return 0
+}
+
+// NetworkType returns the network of the address.
+func (na *NetAddress) NetworkType() NetAddressType {
+	if na.Type != 0 {
+		return na.Type
+	}
+	if na.IP.To4() != nil {
+		return IPv4Address
+	}
+	if len(na.IP) == net.IPv6len && bytes.HasPrefix(na.IP, onionCatPrefix) {
+		return TorV2Address
+	}
+	return IPv6Address
+}
+
+// RequiresAddrV2 returns whether the address can only be relayed with addrv2
+// messages because it can't be represented by an IP address.
+func (na *NetAddress) RequiresAddrV2() bool {
+	return na.Type != 0
+}
+
+// NewNetAddressType returns a new NetAddress using the provided network, raw
+// address, port and supported services with defaults for the remaining
+// fields.  IPv4, IPv6 and Tor v2 addresses are represented by their IP
+// address, while the raw address of all other networks is kept in Addr.
+func NewNetAddressType(t NetAddressType, addr []byte, port uint16, services ServiceFlag) (*NetAddress, error) {
+	size := netAddressTypeSize(t)
+	if size == 0 {
+		return nil, fmt.Errorf("unknown address network %d", uint8(t))
+	}
+	if len(addr) != size {
+		return nil, fmt.Errorf("invalid %v address length %d", t,
+			len(addr))
+	}
+
+	switch t {
+	case IPv4Address, IPv6Address:
+		ip := make(net.IP, len(addr))
+		copy(ip, addr)
+		return NewNetAddressIPPort(ip, port, services), nil
+
+	case TorV2Address:
+		ip := make(net.IP, 0, net.IPv6len)
+		ip = append(ip, onionCatPrefix...)
+		ip = append(ip, addr...)
+		return NewNetAddressIPPort(ip, port, services), nil
+	}
+
+	na := NewNetAddressIPPort(nil, port, services)
+	na.Type = t
+	na.Addr = make([]byte, len(addr))
+	copy(na.Addr, addr)
+	return na, nil
+}
+
+// addrV2Bytes returns the network id and the raw address of the address as
+// encoded in addrv2 messages.
+func addrV2Bytes(na *NetAddress) (NetAddressType, []byte) {
+	t := na.NetworkType()
+	switch t {
+	case IPv4Address:
+		return t, na.IP.To4()
+	case IPv6Address:
+		ip := na.IP.To16()
+		if ip == nil {
+			ip = make(net.IP, net.IPv6len)
+		}
+		return t, ip
+	case TorV2Address:
+		return t, na.IP[len(onionCatPrefix):]
+	}
+	return t, na.Addr
+}
+
+// readNetAddressV2 reads an address encoded as in addrv2 messages from r.
+// Addresses of unknown networks are returned with their network id and raw
+// address so the caller can skip them.
+func readNetAddressV2(r io.Reader, pver uint32, na *NetAddress) error {
+	err := readElement(r, (*uint32Time)(&na.Timestamp))
+	if err != nil {
+		return err
+	}
+	services, err := ReadVarInt(r, pver)
+	if err != nil {
+		return err
+	}
+	var t uint8
+	if err := readElement(r, &t); err != nil {
+		return err
+	}
+	addr, err := ReadVarBytes(r, pver, maxAddrV2Size, "addrv2 address")
+	if err != nil {
+		return err
+	}
+	// Sigh.  Hcd protocol mixes little and big endian.
+	port, err := binarySerializer.Uint16(r, bigEndian)
+	if err != nil {
+		return err
+	}
+
+	// Keep the raw address of unknown networks.
+	if netAddressTypeSize(NetAddressType(t)) == 0 {
+		*na = NetAddress{
+			Timestamp: na.Timestamp,
+			Services:  ServiceFlag(services),
+			Port:      port,
+			Type:      NetAddressType(t),
+			Addr:      addr,
+		}
+		return nil
+	}
+
+	decoded, err := NewNetAddressType(NetAddressType(t), addr, port,
+		ServiceFlag(services))
+	if err != nil {
+		return messageError("readNetAddressV2", err.Error())
+	}
+	decoded.Timestamp = na.Timestamp
+	*na = *decoded
+	return nil
+}
+
+// writeNetAddressV2 serializes an address as encoded in addrv2 messages to w.
+func writeNetAddressV2(w io.Writer, pver uint32, na *NetAddress) error {
+	err := writeElement(w, uint32(na.Timestamp.Unix()))
+	if err != nil {
+		return err
+	}
+	err = WriteVarInt(w, pver, uint64(na.Services))
+	if err != nil {
+		return err
+	}
+	t, addr := addrV2Bytes(na)
+	if len(addr) > maxAddrV2Size {
+		str := fmt.Sprintf("address is too long [len %d, max %d]",
+			len(addr), maxAddrV2Size)
+		return messageError("writeNetAddressV2", str)
+	}
+	if err := writeElement(w, uint8(t)); err != nil {
+		return err
+	}
+	if err := WriteVarBytes(w, pver, addr); err != nil {
+		return err
+	}
+
+	// Sigh.  Hcd protocol mixes little and big endian.
+	return binary.Write(w, bigEndian, na.Port)
+}
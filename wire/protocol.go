@@ -18,7 +18,7 @@ const (
 	InitialProcotolVersion uint32 = 1
 
 	// ProtocolVersion is the latest protocol version this package supports.
-	ProtocolVersion uint32 = 5
+	ProtocolVersion uint32 = 8
 
 	// BIP0111Version is the protocol version which added the SFNodeBloom
 	// service flag.
@@ -35,6 +35,21 @@ const (
 	// FeeFilterVersion is the protocol version which added a new
 	// feefilter message.
 	FeeFilterVersion uint32 = 5
+
+	// NodeCFVersion is the protocol version which adds the SFNodeCF service
+	// flag and the getcfilter, cfilter, getcfheaders and cfheaders
+	// messages.
+	NodeCFVersion uint32 = 6
+
+	// SendCmpctVersion is the protocol version which added the sendcmpct,
+	// cmpctblock, getblocktxn and blocktxn messages used to relay compact
+	// blocks.
+	SendCmpctVersion uint32 = 7
+
+	// AddrV2Version is the protocol version which added the sendaddrv2 and
+	// addrv2 messages used to relay addresses of networks such as Tor v3,
+	// I2P and CJDNS.
+	AddrV2Version uint32 = 8
 )
 
 // ServiceFlag identifies services supported by a hcd peer.
@@ -47,12 +62,27 @@ const (
 	// SFNodeBloom is a flag used to indiciate a peer supports bloom
 	// filtering.
 	SFNodeBloom
+
+	// SFNodeCF is a flag used to indicate a peer supports committed
+	// filters (CFs).
+	SFNodeCF
+
+	// SFNodeP2PV2 is a flag used to indicate a peer supports the encrypted
+	// v2 transport.
+	SFNodeP2PV2
+
+	// SFNodeNetworkLimited is a flag used to indicate a peer only serves
+	// recent blocks because the data of older blocks was pruned.
+	SFNodeNetworkLimited
 )
 
 // Map of service flags back to their constant names for pretty printing.
 var sfStrings = map[ServiceFlag]string{
-	SFNodeNetwork: "SFNodeNetwork",
-	SFNodeBloom:   "SFNodeBloom",
+	SFNodeNetwork:        "SFNodeNetwork",
+	SFNodeBloom:          "SFNodeBloom",
+	SFNodeCF:             "SFNodeCF",
+	SFNodeP2PV2:          "SFNodeP2PV2",
+	SFNodeNetworkLimited: "SFNodeNetworkLimited",
 }
 
 // orderedSFStrings is an ordered list of service flags from highest to
@@ -60,6 +90,9 @@ var sfStrings = map[ServiceFlag]string{
 var orderedSFStrings = []ServiceFlag{
 	SFNodeNetwork,
 	SFNodeBloom,
+	SFNodeCF,
+	SFNodeP2PV2,
+	SFNodeNetworkLimited,
 }
 
 // String returns the ServiceFlag in human-readable form.
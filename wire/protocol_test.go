@@ -17,7 +17,10 @@ func TestServiceFlagStringer(t *testing.T) {
 		{0, "0x0"},
 		{SFNodeNetwork, "SFNodeNetwork"},
 		{SFNodeBloom, "SFNodeBloom"},
-		{0xffffffff, "SFNodeNetwork|SFNodeBloom|0xfffffffc"},
+		{SFNodeCF, "SFNodeCF"},
+		{SFNodeP2PV2, "SFNodeP2PV2"},
+		{SFNodeNetworkLimited, "SFNodeNetworkLimited"},
+		{0xffffffff, "SFNodeNetwork|SFNodeBloom|SFNodeCF|SFNodeP2PV2|SFNodeNetworkLimited|0xffffffe0"},
 	}
 
 	t.Logf("Running %d tests", len(tests))